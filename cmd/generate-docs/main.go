@@ -105,6 +105,7 @@ func buildDocumentation() []SectionDoc {
 		extractSection("defaults", "Global configuration options", defaults.Defaults, defaults.Defaults),
 		extractSection("defaults.git", "Git integration settings", defaults.Defaults.Git, defaults.Defaults.Git),
 		extractSection("task_defaults", "Default values that apply to all tasks unless overridden at the task level", defaults.TaskDefaults, defaults.TaskDefaults),
+		extractSection("hooks", "Commands run once before and after all tasks", defaults.Hooks, defaults.Hooks),
 		extractSection("tasks.<task-id>", "Individual task configuration. Task ID must be unique.", config.TaskConfig{}, config.TaskConfig{}),
 	}
 }
@@ -420,6 +421,8 @@ func generateJSONSchema(docs []SectionDoc) error {
 		switch field.Type {
 		case "string":
 			fieldSchema["type"] = "string"
+		case "int":
+			fieldSchema["type"] = "integer"
 		case "bool":
 			fieldSchema["type"] = "boolean"
 		}
@@ -436,6 +439,34 @@ func generateJSONSchema(docs []SectionDoc) error {
 		},
 	}
 
+	// fileTypeMap maps file extensions (or names) to task types
+	properties["fileTypeMap"] = map[string]interface{}{
+		"type":        "object",
+		"description": "File extensions (\".go\") or file names (\"Dockerfile\") mapped to the task types --changed-only runs when such files change",
+		"additionalProperties": map[string]interface{}{
+			"type":     "array",
+			"items":    map[string]interface{}{"type": "string"},
+			"minItems": 1,
+		},
+	}
+
+	// Profiles overlay the same sections
+	properties["profiles"] = map[string]interface{}{
+		"type":        "object",
+		"description": "Named overlays selected with --profile or DEVPIPE_PROFILE; only the keys a profile sets change",
+		"patternProperties": map[string]interface{}{
+			"^[a-zA-Z0-9_-]+$": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"defaults":      map[string]interface{}{"$ref": "#/properties/defaults"},
+					"task_defaults": map[string]interface{}{"$ref": "#/properties/task_defaults"},
+					"hooks":         map[string]interface{}{"$ref": "#/properties/hooks"},
+					"tasks":         map[string]interface{}{"$ref": "#/properties/tasks"},
+				},
+			},
+		},
+	}
+
 	// Write to file
 	data, err := json.MarshalIndent(schema, "", "  ")
 	if err != nil {
@@ -515,24 +546,71 @@ func generateCLIDocs() error {
 	sb.WriteString("### Run Flags\n\n")
 	sb.WriteString("| Flag | Description | Default |\n")
 	sb.WriteString("|------|-------------|---------||\n")
-	sb.WriteString("| `--config <path>` | Path to config file | `config.toml` |\n")
+	sb.WriteString("| `--config <path>` | Path to config file, or `-` to read it from stdin | `$DEVPIPE_CONFIG`, else `config.toml` or `.devpipe.toml` in this or a parent directory |\n")
+	sb.WriteString("| `--profile <name>` | Lay the config's `[profiles.<name>]` section over the rest of the config | `$DEVPIPE_PROFILE` |\n")
+	sb.WriteString("| `--no-discovery` | Only look for `config.toml` in the current directory, not in parent directories up to the git root | `false` |\n")
 	sb.WriteString("| `--since <ref>` | Git ref to compare against (overrides config) | - |\n")
-	sb.WriteString("| `--only <task-id>` | Run only a single task by id | - |\n")
-	sb.WriteString("| `--skip <task-id>` | Skip a task by id (repeatable) | - |\n")
+	sb.WriteString("| `--changed-only` | Run only tasks whose `type` the `[fileTypeMap]` section maps the changed files' extensions to, e.g. `.go` to `test` and `build` | `false` |\n")
+	sb.WriteString("| `--only <tasks>` | Run only tasks matching a comma-separated list of ids, phases or globs (`test-*`) | - |\n")
+	sb.WriteString("| `--skip <task>` | Skip tasks by id, phase or glob (repeatable) | - |\n")
+	sb.WriteString("| `--tag <tag>` | Run only tasks with this tag (repeatable or comma-separated) | - |\n")
+	sb.WriteString("| `--exclude-tag <tag>` | Skip tasks with this tag (repeatable or comma-separated) | - |\n")
+	sb.WriteString("| `--label <key=value>` | Attach a label to the run record and report, e.g. `--label pr=1234` (repeatable; overrides `defaults.labels`) | - |\n")
 	sb.WriteString("| `--fix-type <type>` | Fix behavior: `auto`, `helper`, `none` (overrides config) | - |\n")
 	sb.WriteString("| `--ui <mode>` | UI mode: `basic`, `full` | `basic` |\n")
 	sb.WriteString("| `--dashboard` | Show dashboard with live progress | `false` |\n")
-	sb.WriteString("| `--fail-fast` | Stop on first task failure | `false` |\n")
+	sb.WriteString("| `--serve` | Serve the HTML dashboard with live progress on port 8080 and open it in the browser; keeps serving after the run until Ctrl-C | `false` |\n")
+	sb.WriteString("| `--jobs <n>` | Max tasks to run in parallel per phase, overrides `defaults.maxParallel` and phase `maxParallel` (0 or 1 = sequential) | config |\n")
+	sb.WriteString("| `--fail-fast` | Stop on first task failure (same as `defaults.failFast = \"task\"`) | `false` |\n")
+	sb.WriteString("| `--exit-zero` | Exit 0 even when tasks fail, for pipelines that report without blocking; config errors and interrupts keep their exit codes | `false` |\n")
 	sb.WriteString("| `--fast` | Skip long-running tasks (> fastThreshold) | `false` |\n")
-	sb.WriteString("| `--dry-run` | Do not execute commands, simulate only | `false` |\n")
+	sb.WriteString("| `--no-cache` | Run tasks even when their `cacheInputs` are unchanged since they last passed | `false` |\n")
+	sb.WriteString("| `--resume [runID]` | Re-run only the tasks that failed or were skipped in the latest (or given) run, plus every task in later phases; passing tasks are reported as cached | - |\n")
+	sb.WriteString("| `--dry-run` | Do not execute commands; show what each task would run | `false` |\n")
 	sb.WriteString("| `--verbose` | Show verbose output (always logged to pipeline.log) | `false` |\n")
+	sb.WriteString("| `--quiet` | Only print failing tasks and the final summary (logs are still written) | `false` |\n")
+	sb.WriteString("| `--timestamps[=MODE]` | Prefix task output lines with a timestamp: `clock` (bare flag) or `elapsed` (overrides config) | `off` |\n")
+	sb.WriteString("| `--notify` | Send a desktop notification when the pipeline finishes | `false` |\n")
+	sb.WriteString("| `--open` | Open the run's HTML report in the default browser when the pipeline finishes (skipped in CI, without a display, or when output is not a terminal) | `false` |\n")
+	sb.WriteString("| `--strict-env` | Fail if a `${VAR}` in the config is not defined (overrides `defaults.strictEnv`) | `false` |\n")
+	sb.WriteString("| `--github` | Emit GitHub Actions `::error` annotations for failed tasks (auto-enabled when `GITHUB_ACTIONS=true`) | `false` |\n")
+	sb.WriteString("| `--github-only` | Emit GitHub Actions annotations instead of the terminal summary | `false` |\n")
 	sb.WriteString("| `--no-color` | Disable colored output | `false` |\n")
+	sb.WriteString("| `--no-emoji` | Use ASCII status markers instead of emoji and symbols | `false` |\n")
+	sb.WriteString("| `--flat-summary` | List tasks in the summary without phase headers | `false` |\n")
+	sb.WriteString("| `--no-show-failures` | Don't print the last `defaults.failureTailLines` lines of each failed task's log after the summary | `false` |\n")
+	sb.WriteString("| `--junit-out <path>` | Write a JUnit XML summary of the run (one testcase per task with its command and last 50 log lines; failures quote the failing line) | - |\n")
+	sb.WriteString("| `--markdown-out <path>` | Write a Markdown summary of the run (results table, test and findings rollups, failure log tails), e.g. for a PR comment | - |\n")
+	sb.WriteString("| `--json-out <path>` | Write a JSON summary of the run | - |\n")
+	sb.WriteString("| `--metrics-out <path>` | Write Prometheus textfile metrics (task durations and statuses) for the node_exporter textfile collector; replaced atomically | - |\n")
+	sb.WriteString("\n")
+
+	sb.WriteString("### Run Subcommand Flags\n\n")
+	sb.WriteString("`devpipe run` runs ad-hoc commands through the pipeline instead of the config's tasks. It takes the run flags above, plus:\n\n")
+	sb.WriteString("| Flag | Description | Default |\n")
+	sb.WriteString("|------|-------------|---------||\n")
+	sb.WriteString("| `--task <command>` | A command to run as a task, with ids `task-1`, `task-2`, ... in the order given (repeatable, at least one) | - |\n")
+	sb.WriteString("| `--parallel` | Run the `--task` commands in parallel, up to `--jobs` or `defaults.maxParallel`, instead of one phase each | `false` |\n")
+	sb.WriteString("\n")
+
+	sb.WriteString("### Exit Codes\n\n")
+	sb.WriteString("| Code | Meaning |\n")
+	sb.WriteString("|------|---------|\n")
+	sb.WriteString("| `0` | Every task passed, or only advisory (`continueOnError`) tasks failed; always 0 for task failures with `--exit-zero` |\n")
+	sb.WriteString("| `1` | At least one task failed |\n")
+	sb.WriteString("| `2` | The config, flags or environment kept the run from starting (invalid config, no config found, unsafe directory, failed preflight) |\n")
+	sb.WriteString("| `3` | The run was interrupted by Ctrl-C or SIGTERM |\n")
+	sb.WriteString("| `defaults.advisoryExitCode` | Only advisory tasks failed and `advisoryExitCode` is set (default 0) |\n")
 	sb.WriteString("\n")
 
 	sb.WriteString("### Validate Flags\n\n")
 	sb.WriteString("| Flag | Description | Default |\n")
 	sb.WriteString("|------|-------------|---------||\n")
-	sb.WriteString("| `--config <path>` | Path to config file to validate (supports multiple files) | `config.toml` |\n")
+	sb.WriteString("| `--config <path>` | Path to config file to validate (supports multiple files, `-` reads stdin) | `config.toml` |\n")
+	sb.WriteString("| `--strict` | Treat warnings as errors (exit 1) | `false` |\n")
+	sb.WriteString("| `--quiet` | Only print configs that fail validation | `false` |\n")
+	sb.WriteString("| `--merge-check` | With several files, show which file each task would come from if they were merged (later files win) | `false` |\n")
+	sb.WriteString("| `--json` | Print a JSON array with one `{file, valid, errors, warnings}` entry per file, in the order given; each error and warning has a `field` and `message`, plus `line` and `column` when known | `false` |\n")
 	sb.WriteString("\n")
 	sb.WriteString("See [config-validation.md](config-validation.md) for more details.\n\n")
 
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"time"
+
+	"github.com/drew/devpipe/internal/config"
+	"github.com/drew/devpipe/internal/container"
+	"github.com/drew/devpipe/internal/model"
+)
+
+// Executor runs a task's command, writing its output to stdout and stderr. exitCode is the
+// status the command exited with, or -1 when it did not exit (it could not be started or a
+// signal killed it); err is non-nil whenever the command did not succeed. usage is the
+// command's resource usage, or nil when the executor cannot measure it
+type Executor interface {
+	Run(ctx context.Context, st model.TaskDefinition, stdout, stderr io.Writer) (exitCode int, usage *model.Usage, err error)
+}
+
+// newExecutor picks the executor for a task (a variable so tests can inject a fake)
+var newExecutor = func(st model.TaskDefinition) Executor {
+	if st.Image != "" {
+		return ContainerExecutor{}
+	}
+	return ShellExecutor{}
+}
+
+// ShellExecutor runs commandArgs directly, or command through the configured shell, on the host
+type ShellExecutor struct{}
+
+// Run implements Executor
+func (ShellExecutor) Run(ctx context.Context, st model.TaskDefinition, stdout, stderr io.Writer) (int, *model.Usage, error) {
+	var cmd *exec.Cmd
+	if len(st.CommandArgs) > 0 {
+		args := config.ExpandArgs(st.CommandArgs)
+		cmd = processCommand(ctx, args[0], args[1:]...)
+	} else {
+		cmd = shellCommand(ctx, st.Shell, st.Command)
+	}
+	exitCode, err := runCommand(cmd, st, stdout, stderr)
+	return exitCode, processUsage(cmd.ProcessState), err
+}
+
+// ContainerExecutor runs a task's command in its image with the container runtime. Its usage
+// is not measured: the runtime's CLI is the only local process
+type ContainerExecutor struct{}
+
+// Run implements Executor
+func (ContainerExecutor) Run(ctx context.Context, st model.TaskDefinition, stdout, stderr io.Writer) (int, *model.Usage, error) {
+	spec := containerSpec(st)
+	spec.Args = config.ExpandArgs(spec.Args)
+	exitCode, err := runCommand(containerCommand(ctx, spec), st, stdout, stderr)
+	return exitCode, nil, err
+}
+
+// runCommand runs cmd from the task's workdir with FORCE_COLOR set
+func runCommand(cmd *exec.Cmd, st model.TaskDefinition, stdout, stderr io.Writer) (int, error) {
+	cmd.Dir = st.Workdir
+	cmd.Env = append(os.Environ(), "FORCE_COLOR=1")
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	err := cmd.Run()
+	if err == nil {
+		return 0, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), err
+	}
+	return -1, err
+}
+
+// fixTask returns st with its fixCommand as the command, to run the fix with st's executor
+func fixTask(st model.TaskDefinition) model.TaskDefinition {
+	st.Command = st.FixCommand
+	st.CommandArgs = nil
+	return st
+}
+
+// shellCommand builds a command that runs command through the configured shell
+// Falls back to the platform default shell when none is configured. Cancelling ctx
+// kills the command and everything it started.
+func shellCommand(ctx context.Context, shell []string, command string) *exec.Cmd {
+	if len(shell) == 0 {
+		shell = config.DefaultShell()
+	}
+	args := append(append([]string{}, shell[1:]...), command)
+	return processCommand(ctx, shell[0], args...)
+}
+
+// processCommand runs name in its own process group so cancelling ctx kills its children too
+func processCommand(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	setProcessGroup(cmd)
+	// Don't wait forever on output pipes held open by processes that survived the kill
+	cmd.WaitDelay = 5 * time.Second
+	return cmd
+}
+
+// containerSpec describes running st's command (or commandArgs) in its image, with the
+// workdir mounted and FORCE_COLOR, the git variables, requiredEnv and the variables the
+// command references passed through, for its shell to expand
+func containerSpec(st model.TaskDefinition) container.Spec {
+	runtime := st.ContainerRuntime
+	if runtime == "" {
+		runtime = container.DefaultRuntime
+	}
+	env := append([]string{"FORCE_COLOR"}, config.GitEnvNames()...)
+	env = append(env, st.RequiredEnv...)
+	for _, name := range config.EnvRefs(st.Command) {
+		if !slices.Contains(env, name) {
+			env = append(env, name)
+		}
+	}
+	return container.Spec{
+		Runtime: runtime,
+		Image:   st.Image,
+		Name:    container.NewName(st.ID),
+		Workdir: st.Workdir,
+		Env:     env,
+		Command: st.Command,
+		Args:    st.CommandArgs,
+	}
+}
+
+// containerCommand runs spec with its runtime. Cancelling it removes the container, since
+// killing the runtime's CLI would leave the container running
+func containerCommand(ctx context.Context, spec container.Spec) *exec.Cmd {
+	cmd := processCommand(ctx, spec.Runtime, container.RunArgs(spec)...)
+	kill := cmd.Cancel
+	cmd.Cancel = func() error {
+		_ = exec.Command(spec.Runtime, container.RemoveArgs(spec.Name)...).Run()
+		return kill()
+	}
+	return cmd
+}
@@ -0,0 +1,119 @@
+// Package cache skips tasks whose inputs are unchanged since their last successful run.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// Entry records the input hash of a task's last successful run
+type Entry struct {
+	TaskID    string `json:"taskId"`
+	Hash      string `json:"hash"`
+	RunID     string `json:"runId"`
+	Timestamp string `json:"timestamp"`
+}
+
+// Store keeps one Entry per task under <outputRoot>/cache
+type Store struct {
+	dir string
+}
+
+// NewStore returns a store rooted at <outputRoot>/cache
+func NewStore(outputRoot string) *Store {
+	return &Store{dir: filepath.Join(outputRoot, "cache")}
+}
+
+// HashInputs hashes the task command together with the path and contents of every file
+// under workdir matching one of patterns (doublestar globs relative to workdir), so a
+// change to the command or to any matched file produces a different hash
+func HashInputs(workdir, command string, patterns []string) (string, error) {
+	files := make(map[string]struct{})
+	fsys := os.DirFS(workdir)
+	for _, pattern := range patterns {
+		matches, err := doublestar.Glob(fsys, pattern, doublestar.WithFilesOnly())
+		if err != nil {
+			return "", fmt.Errorf("invalid cacheInputs pattern %q: %w", pattern, err)
+		}
+		for _, m := range matches {
+			files[m] = struct{}{}
+		}
+	}
+
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "command\x00%s\n", command)
+	for _, p := range paths {
+		sum, err := hashFile(filepath.Join(workdir, filepath.FromSlash(p)))
+		if err != nil {
+			return "", err
+		}
+		_, _ = fmt.Fprintf(h, "%s\x00%s\n", p, sum)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashFile returns the hex sha256 of a file's contents
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Lookup returns the stored entry for taskID if its hash matches
+func (s *Store) Lookup(taskID, hash string) (Entry, bool) {
+	data, err := os.ReadFile(s.path(taskID))
+	if err != nil {
+		return Entry{}, false
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Hash != hash {
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+// Save records hash as the inputs of taskID's successful run runID
+func (s *Store) Save(taskID, hash, runID string) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(Entry{
+		TaskID:    taskID,
+		Hash:      hash,
+		RunID:     runID,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(taskID), data, 0o644)
+}
+
+// path is the entry file for a task; ids are escaped so they are always a single file name
+func (s *Store) path(taskID string) string {
+	return filepath.Join(s.dir, url.PathEscape(taskID)+".json")
+}
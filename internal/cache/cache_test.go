@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestHashInputs(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example\n")
+	writeFile(t, filepath.Join(dir, "src", "main.go"), "package main\n")
+	writeFile(t, filepath.Join(dir, "docs", "README.md"), "docs\n")
+
+	patterns := []string{"src/**", "go.mod"}
+	base, err := HashInputs(dir, "go build ./...", patterns)
+	if err != nil {
+		t.Fatalf("HashInputs() error = %v", err)
+	}
+
+	// Stable for unchanged inputs, ignores unmatched files
+	writeFile(t, filepath.Join(dir, "docs", "README.md"), "changed docs\n")
+	if again, _ := HashInputs(dir, "go build ./...", patterns); again != base {
+		t.Errorf("hash changed although no matched file changed")
+	}
+
+	// A different command invalidates the hash
+	if other, _ := HashInputs(dir, "go build -race ./...", patterns); other == base {
+		t.Errorf("hash did not change when the command changed")
+	}
+
+	// Editing or adding a matched file invalidates the hash
+	writeFile(t, filepath.Join(dir, "src", "main.go"), "package main\n\nfunc main() {}\n")
+	edited, _ := HashInputs(dir, "go build ./...", patterns)
+	if edited == base {
+		t.Errorf("hash did not change when a matched file changed")
+	}
+	writeFile(t, filepath.Join(dir, "src", "util.go"), "package main\n")
+	if added, _ := HashInputs(dir, "go build ./...", patterns); added == edited {
+		t.Errorf("hash did not change when a matched file was added")
+	}
+
+	if _, err := HashInputs(dir, "go build ./...", []string{"src/[.go"}); err == nil {
+		t.Errorf("expected an error for an invalid pattern")
+	}
+}
+
+func TestStoreLookupAndSave(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	if _, ok := store.Lookup("build", "abc"); ok {
+		t.Fatalf("expected a miss before anything is saved")
+	}
+
+	if err := store.Save("build", "abc", "run-1"); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	entry, ok := store.Lookup("build", "abc")
+	if !ok || entry.RunID != "run-1" {
+		t.Errorf("Lookup() = %+v, %v; want hit from run-1", entry, ok)
+	}
+	if _, ok := store.Lookup("build", "def"); ok {
+		t.Errorf("expected a miss for a different hash")
+	}
+
+	// Ids with path separators stay inside the cache directory
+	if err := store.Save("web/lint", "xyz", "run-2"); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, ok := store.Lookup("web/lint", "xyz"); !ok {
+		t.Errorf("expected a hit for an id containing a slash")
+	}
+}
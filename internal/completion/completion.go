@@ -0,0 +1,217 @@
+// Package completion generates shell completion scripts for devpipe.
+package completion
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Shells are the shells Script can generate completion for
+var Shells = []string{"bash", "zsh", "fish"}
+
+// Kinds of flag values, used to pick how a flag's value is completed
+const (
+	ArgNone  = ""      // Boolean flag without a value
+	ArgTask  = "task"  // Comma-separated task ids, read from "devpipe list"
+	ArgFile  = "file"  // A path
+	ArgValue = "value" // Free text
+)
+
+// Flag is a run flag to complete
+type Flag struct {
+	Name  string
+	Usage string
+	Arg   string
+}
+
+// Script returns the completion script for shell. Subcommands and flags are completed from the
+// given lists. Task ids for --only and --skip are read by running "devpipe list" (with the
+// --config already on the command line) when completing, so they follow the local config and
+// complete nothing when there is none
+func Script(shell string, commands []string, flags []Flag) (string, error) {
+	switch shell {
+	case "bash":
+		return bashScript(commands, flags), nil
+	case "zsh":
+		return zshScript(commands, flags), nil
+	case "fish":
+		return fishScript(commands, flags), nil
+	default:
+		return "", fmt.Errorf("unsupported shell %q (supported: %s)", shell, strings.Join(Shells, ", "))
+	}
+}
+
+// namesWithArg returns the --names of the flags whose value is of kind arg
+func namesWithArg(flags []Flag, arg string) []string {
+	var names []string
+	for _, f := range flags {
+		if f.Arg == arg {
+			names = append(names, "--"+f.Name)
+		}
+	}
+	return names
+}
+
+func bashScript(commands []string, flags []Flag) string {
+	var names []string
+	for _, f := range flags {
+		names = append(names, "--"+f.Name)
+	}
+	var b strings.Builder
+	b.WriteString(`# bash completion for devpipe
+# Load it with: source <(devpipe completion bash)
+
+_devpipe_tasks() {
+    local config="" i
+    for ((i = 1; i < COMP_CWORD - 1; i++)); do
+        [[ ${COMP_WORDS[i]} == --config ]] && config=${COMP_WORDS[i+1]}
+    done
+    [[ $config == - ]] && config=""
+    devpipe list ${config:+--config "$config"} 2>/dev/null
+}
+
+_devpipe() {
+    local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+    if [[ $cur == = ]]; then
+        cur=""
+    elif [[ $prev == = ]]; then
+        prev=${COMP_WORDS[COMP_CWORD-2]}
+    fi
+
+    if [[ $COMP_CWORD -gt 1 && ${COMP_WORDS[1]} != -* ]]; then
+        COMPREPLY=($(compgen -f -- "$cur"))
+        return
+    fi
+
+    case $prev in
+`)
+	fmt.Fprintf(&b, "        %s)\n", strings.Join(namesWithArg(flags, ArgTask), "|"))
+	b.WriteString(`            local prefix=""
+            [[ $cur == *,* ]] && prefix=${cur%,*},
+            COMPREPLY=($(compgen -P "$prefix" -W "$(_devpipe_tasks)" -- "${cur##*,}"))
+            return
+            ;;
+`)
+	fmt.Fprintf(&b, "        %s)\n", strings.Join(namesWithArg(flags, ArgFile), "|"))
+	b.WriteString(`            COMPREPLY=($(compgen -f -- "$cur"))
+            return
+            ;;
+`)
+	fmt.Fprintf(&b, "        %s)\n", strings.Join(namesWithArg(flags, ArgValue), "|"))
+	b.WriteString(`            return
+            ;;
+    esac
+
+    if [[ $COMP_CWORD -eq 1 && $cur != -* ]]; then
+`)
+	fmt.Fprintf(&b, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(commands, " "))
+	b.WriteString("        return\n    fi\n")
+	fmt.Fprintf(&b, "    COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	b.WriteString("}\n\ncomplete -F _devpipe devpipe\n")
+	return b.String()
+}
+
+// zshSpec quotes an _arguments spec for a flag, escaping the characters the spec syntax
+// uses in its description
+func zshSpec(f Flag) string {
+	desc := strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`, `:`, `\:`).Replace(f.Usage)
+	spec := "--" + f.Name
+	switch f.Arg {
+	case ArgNone:
+		spec += "[" + desc + "]"
+	case ArgTask:
+		spec += "=[" + desc + "]:task:_devpipe_tasks"
+	case ArgFile:
+		spec += "=[" + desc + "]:file:_files"
+	default:
+		spec += "=[" + desc + "]:value: "
+	}
+	return "'" + strings.ReplaceAll(spec, "'", `'\''`) + "'"
+}
+
+func zshScript(commands []string, flags []Flag) string {
+	var b strings.Builder
+	b.WriteString(`#compdef devpipe
+# zsh completion for devpipe
+# Load it with: source <(devpipe completion zsh), or save it as _devpipe in a directory on $fpath
+
+_devpipe_tasks() {
+    local config=${opt_args[--config]}
+    local -a tasks
+    [[ $config == - ]] && config=""
+    tasks=(${(f)"$(devpipe list ${config:+--config "$config"} 2>/dev/null)"})
+    (( $#tasks )) || return 1
+    _values -s , task $tasks
+}
+
+_devpipe() {
+    if (( CURRENT > 2 )) && [[ $words[2] != -* ]]; then
+        _files
+        return
+    fi
+    if (( CURRENT == 2 )) && [[ $words[2] != -* ]]; then
+`)
+	fmt.Fprintf(&b, "        local -a commands=(%s)\n", strings.Join(commands, " "))
+	b.WriteString(`        _describe -t commands command commands
+        return
+    fi
+    _arguments \
+`)
+	for _, f := range flags {
+		fmt.Fprintf(&b, "        %s \\\n", zshSpec(f))
+	}
+	b.WriteString(`        && return
+}
+
+if [[ $zsh_eval_context[-1] == loadautofunc ]]; then
+    _devpipe "$@"
+else
+    compdef _devpipe devpipe
+fi
+`)
+	return b.String()
+}
+
+// fishQuote single-quotes s for fish, which only treats \' and \\ as escapes inside them
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+func fishScript(commands []string, flags []Flag) string {
+	var b strings.Builder
+	b.WriteString(`# fish completion for devpipe
+# Load it with: devpipe completion fish | source, or save it to ~/.config/fish/completions/devpipe.fish
+
+function __devpipe_tasks
+    set -l tokens (commandline -opc)
+    set -l args
+    set -l i (contains -i -- --config $tokens)
+    if test -n "$i"; and test (count $tokens) -gt $i; and test "$tokens[(math $i + 1)]" != -
+        set args --config $tokens[(math $i + 1)]
+    end
+    set -l prefix (string match -r -- '.*,' (commandline -ct))
+    test -n "$prefix"; or set prefix ''
+    for task in (devpipe list $args 2>/dev/null)
+        echo $prefix$task
+    end
+end
+
+`)
+	cmds := strings.Join(commands, " ")
+	b.WriteString("complete -c devpipe -f\n")
+	fmt.Fprintf(&b, "complete -c devpipe -n __fish_use_subcommand -a %s\n", fishQuote(cmds))
+	runOnly := fishQuote("not __fish_seen_subcommand_from " + cmds)
+	for _, f := range flags {
+		line := fmt.Sprintf("complete -c devpipe -n %s -l %s", runOnly, f.Name)
+		switch f.Arg {
+		case ArgTask:
+			line += " -x -a '(__devpipe_tasks)'"
+		case ArgFile:
+			line += " -r -F"
+		case ArgValue:
+			line += " -x"
+		}
+		fmt.Fprintf(&b, "%s -d %s\n", line, fishQuote(f.Usage))
+	}
+	return b.String()
+}
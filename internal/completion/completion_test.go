@@ -0,0 +1,87 @@
+package completion
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+var testFlags = []Flag{
+	{Name: "config", Usage: "Path to config file", Arg: ArgFile},
+	{Name: "fail-fast", Usage: "Stop on first task failure", Arg: ArgNone},
+	{Name: "only", Usage: "Run only specific tasks [comma-separated]", Arg: ArgTask},
+	{Name: "open", Usage: "Open the run's HTML report", Arg: ArgNone},
+	{Name: "ui", Usage: "UI mode: basic, full", Arg: ArgValue},
+}
+
+func TestScript(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{"bash", []string{"complete -F _devpipe devpipe", "--only)", "--config)", "--ui)", `"init list"`}},
+		{"zsh", []string{"#compdef devpipe", `'--only=[Run only specific tasks \[comma-separated\]]:task:_devpipe_tasks'`, `'--ui=[UI mode\: basic, full]:value: '`, `'--open[Open the run'\''s HTML report]'`}},
+		{"fish", []string{"-a 'init list'", "-l only -x -a '(__devpipe_tasks)'", "-l config -r -F", `-d 'Open the run\'s HTML report'`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			script, err := Script(tt.shell, []string{"init", "list"}, testFlags)
+			if err != nil {
+				t.Fatalf("Script() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(script, want) {
+					t.Errorf("script is missing %q:\n%s", want, script)
+				}
+			}
+		})
+	}
+
+	if _, err := Script("tcsh", nil, nil); err == nil {
+		t.Error("Script() for an unsupported shell should fail")
+	}
+}
+
+func TestBashScriptCompletesTasks(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not installed")
+	}
+	script, err := Script("bash", []string{"init", "list"}, testFlags)
+	if err != nil {
+		t.Fatalf("Script() error = %v", err)
+	}
+
+	// devpipe is stubbed to list two tasks, or none for a missing config
+	complete := func(words string) string {
+		t.Helper()
+		cmd := exec.Command("bash", "-c", `
+devpipe() { [[ $* == *missing* ]] && return 1; printf 'lint\nunit-tests\n'; }
+`+script+`
+COMP_WORDS=(`+words+`)
+COMP_CWORD=$((${#COMP_WORDS[@]} - 1))
+_devpipe
+echo "${COMPREPLY[*]}"`)
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("bash failed for %s: %v", words, err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	tests := []struct {
+		words string
+		want  string
+	}{
+		{`devpipe li`, "list"},
+		{`devpipe --fail`, "--fail-fast"},
+		{`devpipe --only ""`, "lint unit-tests"},
+		{`devpipe --only lint,u`, "lint,unit-tests"},
+		{`devpipe --only = l`, "lint"},
+		{`devpipe --config missing.toml --only ""`, ""},
+	}
+	for _, tt := range tests {
+		if got := complete(tt.words); got != tt.want {
+			t.Errorf("completing %s = %q, want %q", tt.words, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+// Package condition evaluates the small boolean expressions used by task `when` fields.
+//
+// Grammar:
+//
+//	expr       = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | "(" expr ")" | comparison
+//	comparison = operand [ ("==" | "!=" | "<" | "<=" | ">" | ">=") operand ]
+//	operand    = "branch" | "changedFiles" | "env.NAME" | 'quoted' | "quoted" | bare-word
+//
+// An operand on its own is true unless it is empty, "false" or "0".
+package condition
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Context holds the values a condition can refer to
+type Context struct {
+	Branch       string                      // Current git branch ("branch")
+	ChangedFiles int                         // Number of changed files ("changedFiles")
+	Env          func(string) (string, bool) // Environment lookup ("env.NAME"), may be nil
+}
+
+// Expr is a parsed condition
+type Expr struct {
+	source string
+	root   node
+}
+
+// String returns the original expression
+func (e *Expr) String() string {
+	return e.source
+}
+
+// Eval evaluates the expression against ctx
+func (e *Expr) Eval(ctx Context) bool {
+	return e.root.eval(ctx)
+}
+
+// Parse parses a condition expression
+func Parse(expr string) (*Expr, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty condition")
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+
+	return &Expr{source: expr, root: root}, nil
+}
+
+// Evaluate parses and evaluates expr in one step
+func Evaluate(expr string, ctx Context) (bool, error) {
+	e, err := Parse(expr)
+	if err != nil {
+		return false, err
+	}
+	return e.Eval(ctx), nil
+}
+
+// node is an evaluable part of an expression
+type node interface {
+	eval(ctx Context) bool
+}
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(ctx Context) bool { return n.left.eval(ctx) || n.right.eval(ctx) }
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(ctx Context) bool { return n.left.eval(ctx) && n.right.eval(ctx) }
+
+type notNode struct{ inner node }
+
+func (n notNode) eval(ctx Context) bool { return !n.inner.eval(ctx) }
+
+type truthyNode struct{ value operand }
+
+func (n truthyNode) eval(ctx Context) bool {
+	v := n.value.resolve(ctx)
+	return v != "" && v != "false" && v != "0"
+}
+
+type compareNode struct {
+	op          string
+	left, right operand
+}
+
+func (n compareNode) eval(ctx Context) bool {
+	l := n.left.resolve(ctx)
+	r := n.right.resolve(ctx)
+
+	// Compare numerically when both sides are integers
+	cmp := strings.Compare(l, r)
+	if li, err := strconv.Atoi(l); err == nil {
+		if ri, err := strconv.Atoi(r); err == nil {
+			cmp = compareInts(li, ri)
+		}
+	}
+
+	switch n.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default: // ">="
+		return cmp >= 0
+	}
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// operand is a literal or a reference to a context value
+type operand struct {
+	literal string
+	ref     string // "branch", "changedFiles" or "env.NAME"; empty for literals
+}
+
+func (o operand) resolve(ctx Context) string {
+	switch {
+	case o.ref == "":
+		return o.literal
+	case o.ref == "branch":
+		return ctx.Branch
+	case o.ref == "changedFiles":
+		return strconv.Itoa(ctx.ChangedFiles)
+	default:
+		if ctx.Env == nil {
+			return ""
+		}
+		value, _ := ctx.Env(strings.TrimPrefix(o.ref, "env."))
+		return value
+	}
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// tokenize splits an expression into words, quoted strings and operators
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at position %d", i+1)
+			}
+			tokens = append(tokens, token{tokenString, s[i+1 : i+1+end]})
+			i += end + 2
+		case strings.HasPrefix(s[i:], "&&"), strings.HasPrefix(s[i:], "||"),
+			strings.HasPrefix(s[i:], "=="), strings.HasPrefix(s[i:], "!="),
+			strings.HasPrefix(s[i:], "<="), strings.HasPrefix(s[i:], ">="):
+			tokens = append(tokens, token{tokenOp, s[i : i+2]})
+			i += 2
+		case strings.ContainsRune("()!<>", rune(c)):
+			tokens = append(tokens, token{tokenOp, string(c)})
+			i++
+		case c == '=' || c == '&' || c == '|':
+			return nil, fmt.Errorf("unexpected %q at position %d", c, i+1)
+		default:
+			start := i
+			for i < len(s) && !strings.ContainsRune(" \t'\"()!<>=&|", rune(s[i])) {
+				i++
+			}
+			tokens = append(tokens, token{tokenWord, s[start:i]})
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peekOp(ops ...string) string {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenOp {
+		return ""
+	}
+	for _, op := range ops {
+		if p.tokens[p.pos].text == op {
+			return op
+		}
+	}
+	return ""
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("||") != "" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("&&") != "" {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peekOp("!") != "" {
+		p.pos++
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{inner}, nil
+	}
+
+	if p.peekOp("(") != "" {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peekOp(")") == "" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return inner, nil
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	op := p.peekOp("==", "!=", "<", "<=", ">", ">=")
+	if op == "" {
+		return truthyNode{left}, nil
+	}
+	p.pos++
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return compareNode{op: op, left: left, right: right}, nil
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	if p.pos >= len(p.tokens) {
+		return operand{}, fmt.Errorf("unexpected end of condition")
+	}
+	tok := p.tokens[p.pos]
+	switch tok.kind {
+	case tokenString:
+		p.pos++
+		return operand{literal: tok.text}, nil
+	case tokenWord:
+		p.pos++
+		switch {
+		case tok.text == "branch", tok.text == "changedFiles":
+			return operand{ref: tok.text}, nil
+		case strings.HasPrefix(tok.text, "env."):
+			if tok.text == "env." {
+				return operand{}, fmt.Errorf("missing variable name after \"env.\"")
+			}
+			return operand{ref: tok.text}, nil
+		default:
+			return operand{literal: tok.text}, nil
+		}
+	default:
+		return operand{}, fmt.Errorf("unexpected %q", tok.text)
+	}
+}
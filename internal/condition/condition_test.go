@@ -0,0 +1,83 @@
+package condition
+
+import "testing"
+
+func TestEvaluate(t *testing.T) {
+	env := map[string]string{"DEPLOY": "true", "STAGE": "prod", "EMPTY": ""}
+	ctx := Context{
+		Branch:       "main",
+		ChangedFiles: 3,
+		Env: func(name string) (string, bool) {
+			v, ok := env[name]
+			return v, ok
+		},
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"branch == main", true},
+		{"branch != main", false},
+		{"branch == 'feature/login'", false},
+		{`branch == "main"`, true},
+		{"env.DEPLOY == true", true},
+		{"env.DEPLOY", true},
+		{"env.EMPTY", false},
+		{"env.MISSING", false},
+		{"!env.MISSING", true},
+		{"changedFiles > 0", true},
+		{"changedFiles >= 10", false},
+		{"changedFiles < 10", true},
+		{"branch == main && env.STAGE == prod", true},
+		{"branch == dev || env.STAGE == prod", true},
+		{"branch == dev || env.STAGE == dev && changedFiles > 0", false},
+		{"(branch == dev || env.STAGE == prod) && changedFiles > 0", true},
+		{"!(branch == main)", false},
+		{"true", true},
+		{"false", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := Evaluate(tt.expr, ctx)
+			if err != nil {
+				t.Fatalf("Evaluate(%q) error = %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Evaluate(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateNilEnv(t *testing.T) {
+	got, err := Evaluate("env.DEPLOY == true", Context{})
+	if err != nil || got {
+		t.Errorf("Evaluate() = %v, %v; want false, nil", got, err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"   ",
+		"branch ==",
+		"branch = main",
+		"branch == main &&",
+		"(branch == main",
+		"branch == main)",
+		"branch == 'main",
+		"env. == x",
+		"branch main",
+		"a & b",
+	}
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := Parse(expr); err == nil {
+				t.Errorf("Parse(%q) expected error", expr)
+			}
+		})
+	}
+}
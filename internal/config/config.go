@@ -5,16 +5,29 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/BurntSushi/toml"
+	"github.com/drew/devpipe/internal/scaffold"
 )
 
 // Config represents the complete devpipe configuration
 type Config struct {
 	Defaults     DefaultsConfig        `toml:"defaults"`
 	TaskDefaults TaskDefaultsConfig    `toml:"task_defaults"`
+	Hooks        HooksConfig           `toml:"hooks"`
 	Tasks        map[string]TaskConfig `toml:"tasks"`
+
+	// Changed file extensions (or file names) mapped to the task types --changed-only runs for them
+	FileTypeMap map[string][]string `toml:"fileTypeMap"`
+
+	// Named overlays selected with --profile or DEVPIPE_PROFILE
+	Profiles map[string]ProfileConfig `toml:"profiles"`
+	// Profile is the name of the applied profile, "" for none
+	Profile string `toml:"-"`
+
+	meta toml.MetaData // Decoding metadata, needed to decode the profiles later
 }
 
 // DefaultsConfig holds global defaults
@@ -23,24 +36,96 @@ type DefaultsConfig struct {
 	ProjectRoot string `toml:"projectRoot" doc:"Repo/project root directory (optional override, auto-detected from git or config location if not set)"`
 	// Directory for run outputs and logs
 	OutputRoot string `toml:"outputRoot" doc:"Directory for run outputs and logs"`
+	// How run IDs (and run directory names) are generated
+	RunIDFormat string `toml:"runIdFormat" doc:"How run IDs, and so run directory names, are generated: timestamp (UTC time plus a random suffix), hostname (timestamp, host name and random suffix, for output roots shared between machines) or counter (000001, 000002, ... with the last number kept in run-counter in the output root)" enum:"timestamp,hostname,counter"`
 	// Tasks longer than this (seconds) are skipped with --fast
 	FastThreshold int `toml:"fastThreshold" doc:"Tasks longer than this (seconds) are skipped with --fast"`
 	// UI mode: basic or full
 	UIMode string `toml:"uiMode" doc:"UI mode: basic or full" enum:"basic,full"`
 	// Dashboard refresh rate in milliseconds
-	AnimationRefreshMs int `toml:"animationRefreshMs" doc:"Dashboard refresh rate in milliseconds"`
+	AnimationRefreshMs int `toml:"animationRefreshMs" doc:"Dashboard refresh rate in milliseconds (20-2000; at least 200 over SSH to avoid flicker)"`
+	// Spinner shown next to running tasks in the dashboard
+	AnimationStyle string `toml:"animationStyle" doc:"Spinner shown next to running tasks in the dashboard: dots, line, bar, or plain (a static ASCII marker for terminals with poor glyph support)" enum:"dots,line,bar,plain"`
 	// Group tasks by phase or type in dashboard
 	AnimatedGroupBy string `toml:"animatedGroupBy" doc:"Group tasks by phase or type in dashboard" enum:"phase,type"`
+	// Collapse passed, skipped and pending tasks into counts in the dashboard
+	CollapseCompleted bool `toml:"collapseCompleted" doc:"In --dashboard mode, show passed, skipped and pending tasks as a count line so running and failed tasks stay on screen (the final view still lists every task)"`
+	// What to do when a passing task finishes almost instantly without any output or metrics
+	EmptyOutput string `toml:"emptyOutput" doc:"What to do when a passing task finishes almost instantly without any output or metrics: warn, fail, or ignore" enum:"warn,fail,ignore"`
+	// Maximum number of tasks to run in parallel within a phase
+	MaxParallel *int `toml:"maxParallel" doc:"Maximum number of tasks to run in parallel within a phase (0 or 1 runs tasks sequentially)"`
+	// Stop the pipeline on failures: off, phase or task
+	FailFast string `toml:"failFast" doc:"Stop the pipeline when a task fails: off, phase (finish the failing phase, then skip the remaining phases) or task (same as --fail-fast)" enum:"off,phase,task"`
+	// Check that task programs are installed before running anything
+	Preflight bool `toml:"preflight" doc:"Before running, check that the program of every selected task (and auto-fix) command is installed, and stop with a list of the missing ones (like devpipe doctor)"`
+	// Container CLI used for tasks that set image
+	ContainerRuntime string `toml:"containerRuntime" doc:"Container CLI that runs tasks with an image, e.g. docker or podman (needs a docker-compatible run command)"`
+	// Shell used to run task and fix commands, e.g. ["sh", "-c"] or ["pwsh", "-Command"]
+	Shell []string `toml:"shell" doc:"Shell used to run task and fix commands, as the program followed by its arguments (default: [\"sh\", \"-c\"] on Unix, [\"cmd\", \"/c\"] on Windows)"`
+	// Send a desktop notification when the pipeline finishes
+	Notify bool `toml:"notify" doc:"Send a desktop notification when the pipeline finishes"`
+	// Open the run's HTML report in the browser when the pipeline finishes
+	OpenReport bool `toml:"openReport" doc:"Open the run's HTML report in the default browser when the pipeline finishes (same as --open; skipped in CI and without a display)"`
+	// Descriptive key/value labels attached to every run
+	Labels map[string]string `toml:"labels" doc:"Descriptive key/value labels recorded on every run and shown on its report, e.g. { team = \"web\" }. --label key=value adds to or overrides them"`
+	// Fail validation when a ${VAR} reference is not defined in the environment
+	StrictEnv bool `toml:"strictEnv" doc:"Fail validation when a ${VAR} reference is not defined in the environment (otherwise it expands to empty with a warning)"`
+	// Historical duration statistic used for task time estimates
+	EstimateStat string `toml:"estimateStat" doc:"Historical duration statistic used for task time estimates: mean, p95, or max (p95 gives a more realistic upper bound for tasks with occasional long runs)" enum:"mean,p95,max"`
+	// Minimum pass/fail flips over the last 25 runs for a test to be reported as flaky
+	FlakyThreshold int `toml:"flakyThreshold" doc:"Minimum number of pass/fail flips over the last 25 runs for a JUnit test case to be listed under Flaky Tests in the dashboard"`
+	// Runs listed on the dashboard and given (re)generated report pages
+	DashboardRecentLimit int `toml:"dashboardRecentLimit" doc:"Number of most recent runs listed on the dashboard and whose report pages are generated or regenerated, which keeps generate-reports fast on a long history (default: 100). Older runs keep the report pages they have; devpipe serve generates a missing one when it is opened"`
+	// Log lines previewed per task on the run report
+	LogPreviewLines int `toml:"logPreviewLines" doc:"Number of trailing log lines shown for a passed or advisory task on its run's HTML report (default: 10). The raw log link always has the full output"`
+	// Log lines previewed per failed task on the run report
+	LogPreviewLinesFailed int `toml:"logPreviewLinesFailed" doc:"Number of trailing log lines shown for a failed task on its run's HTML report, enough for a stack trace or test failure block (default: 50)"`
+	// Log lines of each failed task repeated after the terminal summary (0 = off)
+	FailureTailLines *int `toml:"failureTailLines" doc:"Number of trailing log lines of each failed task printed after the terminal summary, so the error is at the bottom of the output instead of scrolled away (default: 20, 0 disables). --no-show-failures turns it off for one run"`
+	// Copy output files into the run directory
+	CopyArtifacts *bool `toml:"copyArtifacts" doc:"Copy each task's output files into the run directory so the run keeps them (default: true). Set to false when output files are large or kept elsewhere: metrics are still parsed, and the report shows the original path instead of linking a copy"`
+	// Prefix streamed task output lines with a timestamp
+	Timestamps string `toml:"timestamps" doc:"Prefix each streamed task output line with a timestamp: off, clock (wall-clock time) or elapsed (time since the task started). Overridden by --timestamps" enum:"off,clock,elapsed"`
+	// Also write timestamp prefixes into task log files
+	TimestampsInLogs bool `toml:"timestampsInLogs" doc:"Also write the timestamp prefix into task log files (by default logs keep the raw command output)"`
+	// Print a still-running line for tasks silent this long (non-animated mode)
+	HeartbeatSeconds *int `toml:"heartbeatSeconds" doc:"Without --dashboard, print \"still running (90s)...\" for a task that has produced no output for this many seconds, so logs don't look stalled (0 disables)"`
+	// Maximum bytes of output kept per task (0 = unlimited)
+	MaxLogBytes int64 `toml:"maxLogBytes" doc:"Maximum bytes of output kept per task in its log and on the console, e.g. 104857600 for 100 MB. Output beyond it is dropped with an \"[output truncated after N bytes]\" marker while the command keeps running (0 = unlimited)"`
+	// Exit code when the only failures are advisory (continueOnError) tasks
+	AdvisoryExitCode int `toml:"advisoryExitCode" doc:"Exit code of a run whose only failures are advisory (continueOnError) tasks, e.g. 4 so wrapper scripts can tell them apart (0 keeps them from failing the run)"`
+	// Regexes whose matches are replaced with *** in task output
+	MaskPatterns []string `toml:"maskPatterns" doc:"Regular expressions whose matches are replaced with *** in task output before it reaches logs, reports or the console. A pattern with a capture group only masks the group, e.g. \"password=(\\\\S+)\""`
+	// Environment variables whose values are masked in task output
+	MaskEnv []string `toml:"maskEnv" doc:"Names of environment variables whose values are replaced with *** in task output, e.g. [\"NPM_TOKEN\", \"DATABASE_URL\"] (values shorter than 4 characters are not masked)"`
+	// Apply the built-in secret patterns
+	MaskBuiltins *bool `toml:"maskBuiltins" doc:"Mask common secrets in task output: AWS access and secret keys, bearer tokens, GitHub tokens and passwords in connection strings (default: true)"`
+	// Escape control characters and invalid UTF-8 in output shown on the console
+	SanitizeOutput *bool `toml:"sanitizeOutput" doc:"Hex-escape control characters (except tabs and colors) and replace invalid UTF-8 in task output shown on the console, so binary output can't scramble the terminal. The .log file keeps the raw bytes; reports and the dashboard always show sanitized logs (default: true)"`
+	// Use emoji and symbols in console output
+	Emoji *bool `toml:"emoji" doc:"Use emoji and symbols such as ✓, ✗ and 🔧 in console output; false prints ASCII status markers (+, x, !, -) and drops decorative emoji, like --no-emoji. The HTML report keeps its emoji (default: true)"`
+	// Custom status markers
+	Symbols map[string]string `toml:"symbols" doc:"Custom status markers for console output, keyed by pass, fail, warn, skip, running or pending, e.g. { pass = \"OK\", fail = \"!!\" }. Applied on top of the emoji or ASCII set"`
+	// Format of per-task log files
+	LogFormat string `toml:"logFormat" doc:"Format of per-task .log files: text (raw command output) or jsonl (one {ts, task, stream, line} record per output line, for log platforms). Console output is unchanged" enum:"text,jsonl"`
+	// Forward task output lines to a syslog server or file
+	LogSink string `toml:"logSink" doc:"Also forward every task output line (after masking) to an external sink: syslog://host:514 (UDP), syslog+tcp://host:514, or file:///path for JSONL records appended to a file or fifo. If the sink fails, devpipe warns once and the run carries on"`
+	// Prefix template for task output and status lines
+	LogPrefix *string `toml:"logPrefix" doc:"Prefix template for task output and status lines, e.g. \"{id} |\". {id} is padded to the longest task id so columns line up; set to \"\" to disable prefixes (default: \"[{id}]\")"`
 	// Git integration settings
 	Git GitConfig `toml:"git"`
 }
 
 // GitConfig holds git-related configuration
 type GitConfig struct {
-	// Git mode: staged, staged_unstaged, or ref
-	Mode string `toml:"mode" doc:"Git mode: staged, staged_unstaged, or ref" enum:"staged,staged_unstaged,ref"`
+	// Git mode: staged, staged_unstaged, ref, or branch
+	Mode string `toml:"mode" doc:"Git mode: staged, staged_unstaged, ref, or branch" enum:"staged,staged_unstaged,ref,branch"`
 	// Git ref to compare against when mode is ref
 	Ref string `toml:"ref" doc:"Git ref to compare against when mode is ref"`
+	// Also treat untracked files as changed
+	IncludeUntracked bool `toml:"includeUntracked" doc:"Also treat untracked files (git ls-files --others, respecting .gitignore) as changed, so watchPaths filtering sees brand-new files"`
+	// Drop changed files matched by ignore rules
+	RespectGitignore bool `toml:"respectGitignore" doc:"Drop changed files matched by .gitignore (even tracked ones, e.g. committed build output under dist/) before watchPaths filtering and the DEVPIPE_CHANGED_FILES* variables"`
 }
 
 // TaskDefaultsConfig holds default values for all tasks
@@ -53,10 +138,20 @@ type TaskDefaultsConfig struct {
 	FixType string `toml:"fixType" doc:"Default fix behavior: auto, helper, or none" enum:"auto,helper,none"`
 }
 
+// HooksConfig holds commands run once around the whole pipeline
+type HooksConfig struct {
+	// Setup commands run before any task
+	Before []string `toml:"before" doc:"Shell commands run in order from the project root before any task, e.g. to start a test database; a failing one stops the run before any task starts"`
+	// Teardown commands run after the last task
+	After []string `toml:"after" doc:"Shell commands run in order from the project root after the tasks finish, even when tasks failed, the run was interrupted or a before hook failed; a failing one is reported but does not fail the run"`
+}
+
 // TaskConfig represents a single task configuration
 type TaskConfig struct {
 	// Shell command to execute
-	Command string `toml:"command" doc:"Shell command to execute" required:"true"`
+	Command string `toml:"command" doc:"Shell command to execute (this or commandArgs is required)"`
+	// Program and arguments run directly, without a shell
+	CommandArgs []string `toml:"commandArgs" doc:"Program and arguments to run directly without a shell, e.g. [\"go\", \"test\", \"./...\"]. Use instead of command to avoid shell quoting"`
 	// Display name for the task
 	Name string `toml:"name" doc:"Display name for the task"`
 	// Description
@@ -69,16 +164,70 @@ type TaskConfig struct {
 	Enabled *bool `toml:"enabled" doc:"Whether this task is enabled"`
 	// Internal use only: set automatically by phase headers
 	Wait bool `toml:"wait"`
-	// Output type: junit, sarif, artifact
-	OutputType string `toml:"outputType" doc:"Output type: junit, sarif, artifact" enum:"junit,sarif,artifact"`
-	// Path to output file (relative to workdir)
-	OutputPath string `toml:"outputPath" doc:"Path to output file (relative to workdir)"`
+	// Output type: junit, tap, sarif, eslint, checkstyle, artifact, command
+	OutputType string `toml:"outputType" doc:"Output type: junit, tap, sarif, eslint, checkstyle, artifact, command (parsed by metricsParser)" enum:"junit,tap,sarif,eslint,checkstyle,artifact,command"`
+	// Path to output file (relative to workdir), or a glob matching several files
+	OutputPath string `toml:"outputPath" doc:"Path to output file (relative to workdir). A glob such as \"results/junit-*.xml\" parses every matching file and merges their metrics; the task fails if nothing matches"`
+	// Command that turns the output file into metrics when outputType is command
+	MetricsParser string `toml:"metricsParser" doc:"Command run from the workdir when outputType = \"command\", e.g. \"./parse-report.sh\". It gets the output file path as its last argument and must print a JSON object of metrics to stdout within 30 seconds"`
+	// Where the copy of the output file is stored, relative to the run directory
+	ArtifactDir string `toml:"artifactDir" doc:"Directory (relative to the run directory) the output file is copied to, e.g. \"artifacts/web\". Defaults to outputs/ with the outputPath layout preserved"`
+	// Copy the output files into the run directory (overrides defaults.copyArtifacts)
+	CopyArtifacts *bool `toml:"copyArtifacts" doc:"Copy the output files into the run directory (overrides defaults.copyArtifacts). When false the run only records their original path"`
 	// Fix behavior: auto, helper, none (overrides task_defaults)
 	FixType string `toml:"fixType" doc:"Fix behavior: auto, helper, none (overrides task_defaults)" enum:"auto,helper,none"`
+	// When an auto fix runs: after a failed check (default), or before every check
+	FixMode string `toml:"fixMode" doc:"When fixType = \"auto\" runs fixCommand: after (default) re-checks once the task has failed, before runs it ahead of every check, e.g. gofmt -w before gofmt -l, so always-fixable tasks never fail first. --fix-type none disables both" enum:"before,after"`
 	// Command to run to fix issues (required if fixType is set)
 	FixCommand string `toml:"fixCommand" doc:"Command to run to fix issues (required if fixType is set)"`
 	// File patterns to watch (glob patterns relative to workdir). Task runs only if matching files changed.
-	WatchPaths []string `toml:"watchPaths" doc:"File patterns to watch (glob patterns relative to workdir). Task runs only if matching files changed."`
+	WatchPaths []string `toml:"watchPaths" doc:"File patterns to watch (glob patterns relative to workdir). Task runs only if matching files changed. Prefix a pattern with ! to exclude files matched by an earlier one (the last matching pattern wins, like .gitignore)"`
+	// Files whose contents decide whether the task can be skipped as cached
+	CacheInputs []string `toml:"cacheInputs" doc:"Glob patterns (relative to workdir) of the task's inputs, e.g. [\"src/**\", \"go.mod\"]. When the matched files and the command are unchanged since the task last passed, it is reported as cached without running (disable with --no-cache)"`
+	// Create the workdir before running the task if it does not exist
+	CreateWorkdir bool `toml:"createWorkdir" doc:"Create the workdir (and any missing parents) before running the task instead of failing when it does not exist"`
+	// Always run this task, even when watchPaths filtering would skip it
+	Required bool `toml:"required" doc:"Always run this task, even when no changed files match its watchPaths (cannot be combined with enabled = false)"`
+	// Advisory task: report failures as WARN without failing the pipeline
+	ContinueOnError bool `toml:"continueOnError" doc:"Advisory task: a failure is reported as WARN in the summary and dashboard but does not fail the pipeline or trigger fail-fast"`
+	// Lowest finding level that fails the task: error, warning, or note
+	SarifFailOn string `toml:"sarifFailOn" doc:"Fail the task when its SARIF, ESLint or checkstyle output has findings at or above this level: error, warning, or note" enum:"error,warning,note"`
+	// Maximum number of findings allowed before the task fails
+	SarifMaxIssues *int `toml:"sarifMaxIssues" doc:"Fail the task when its SARIF, ESLint or checkstyle output has more findings than this (any level)"`
+	// Exit codes treated as success (0 always is), e.g. [0, 1] for diff or grep
+	AllowExitCodes []int `toml:"allowExitCodes" doc:"Exit codes treated as success, e.g. [0, 1] for tools like diff or grep that exit 1 when they find something (default: [0])"`
+	// Regex that fails the task when a line of its output matches, even if it exits 0
+	FailOnMatch string `toml:"failOnMatch" doc:"Regular expression that fails the task when a line of its output matches, even if the command exits 0, e.g. \"^ERROR:\" for tools that report errors without a non-zero exit code"`
+	// Regex that passes the task when a line of its output matches, even if it exits non-zero
+	PassOnMatch string `toml:"passOnMatch" doc:"Regular expression that passes the task when a line of its output matches, even if the command exits non-zero, e.g. \"0 vulnerabilities\". failOnMatch wins when both match"`
+	// Tags for selecting tasks with --tag/--exclude-tag, e.g. ["fast", "frontend"]
+	Tags []string `toml:"tags" doc:"Tags for selecting tasks with --tag and --exclude-tag, e.g. [\"fast\", \"frontend\"]"`
+	// Log lines previewed on the run report, whatever the task's status
+	LogPreviewLines *int `toml:"logPreviewLines" doc:"Number of trailing log lines shown for this task on its run's HTML report, whether it passed or failed (overrides defaults.logPreviewLines and defaults.logPreviewLinesFailed)"`
+	// Maximum bytes of output kept for this task (overrides defaults.maxLogBytes)
+	MaxLogBytes *int64 `toml:"maxLogBytes" doc:"Maximum bytes of output kept for this task (overrides defaults.maxLogBytes; 0 = unlimited)"`
+	// Concurrency group: tasks sharing it never run at the same time
+	Group string `toml:"group" doc:"Concurrency group, e.g. \"db\": tasks in the same phase with the same group run one at a time in config order, while still running in parallel with other tasks"`
+	// Descriptive key/value labels shown on the run report
+	Labels map[string]string `toml:"labels" doc:"Descriptive key/value labels recorded with the task's result and shown on the run report, e.g. { owner = \"frontend\", suite = \"smoke\" }"`
+	// Condition that must be true for the task to run, e.g. "branch == main"
+	When string `toml:"when" doc:"Condition that must be true for the task to run, e.g. \"branch == main\" or \"env.DEPLOY == true\" (supports branch, changedFiles, env.NAME, ==, !=, <, >, &&, ||, !)"`
+	// Path or glob that must exist for the task to run
+	OnlyIf string `toml:"onlyIf" doc:"Path or glob relative to the workdir that must match a file or directory for the task to run, e.g. \"coverage.out\"; checked just before the task starts, so an earlier phase can create it. Skipped as \"onlyIf: coverage.out not found\" otherwise"`
+	// Path or glob that skips the task when it exists
+	SkipIf string `toml:"skipIf" doc:"Path or glob relative to the workdir that skips the task when it matches a file or directory, e.g. \"dist/.skip-e2e\"; checked just before the task starts"`
+	// Container image the task runs in, e.g. "node:20"
+	Image string `toml:"image" doc:"Container image to run the command (and fixCommand) in, e.g. \"node:20\", with the workdir mounted at /work and FORCE_COLOR, the DEVPIPE_* git variables and requiredEnv passed through. Uses defaults.containerRuntime"`
+	// Environment variables the task needs, checked before it runs
+	RequiredEnv []string `toml:"requiredEnv" doc:"Environment variables the task needs, e.g. [\"AWS_REGION\", \"DEPLOY_TOKEN\"]. Validation warns when one is unset or empty, and the task fails with \"task X requires env NAME\" before running its command (also checked with --dry-run)"`
+	// Tasks that must finish before this one starts
+	DependsOn []string `toml:"dependsOn" doc:"Ids of tasks defined earlier that this task needs, e.g. [\"build\"]. When tasks run in parallel (--dashboard), the task starts as soon as they finish instead of waiting for the earlier phases, unless a barrier phase or wait marker is in between. It is skipped if one of them fails"`
+	// Phase headers only: maximum number of tasks to run in parallel in this phase
+	MaxParallel *int `toml:"maxParallel" doc:"Phase headers only: maximum number of tasks to run in parallel in this phase (overrides defaults.maxParallel)"`
+	// Phase headers only: skip the remaining phases when a task in this phase fails
+	FailFast *bool `toml:"failFast" doc:"Phase headers only: when a task in this phase fails, finish the phase and skip the remaining phases (overrides defaults.failFast = phase/off; false opts this phase out)"`
+	// Phase headers only: no task of this phase or later starts before the earlier phases finish
+	Barrier bool `toml:"barrier" doc:"Phase headers only: tasks of this and later phases never start before every earlier task has finished, even with dependsOn (like a wait marker)"`
 }
 
 // LoadConfig loads configuration from a TOML file
@@ -90,20 +239,33 @@ func LoadConfig(path string) (*Config, []string, map[string]PhaseInfo, map[strin
 		path = "config.toml"
 	}
 
-	// Check if file exists
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		// If user explicitly specified a config file, fail
-		if explicitPath {
-			return nil, nil, nil, nil, fmt.Errorf("config file not found: %s", path)
+	// Check if file exists (stdin always "exists")
+	if path != StdinPath {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			// If user explicitly specified a config file, fail
+			if explicitPath {
+				return nil, nil, nil, nil, fmt.Errorf("config file not found: %s", path)
+			}
+			// Otherwise, return nil to allow auto-generation
+			return nil, nil, nil, nil, nil
 		}
-		// Otherwise, return nil to allow auto-generation
-		return nil, nil, nil, nil, nil
+	}
+
+	data, err := ReadConfigFile(path)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to read config file %s: %w", DisplayPath(path), err)
 	}
 
 	var cfg Config
-	metadata, err := toml.DecodeFile(path, &cfg)
+	metadata, err := toml.Decode(string(data), &cfg)
 	if err != nil {
-		return nil, nil, nil, nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		return nil, nil, nil, nil, fmt.Errorf("failed to parse config file %s: %w", DisplayPath(path), err)
+	}
+
+	// Decode the profiles now, so their mistakes are reported whichever profile is used
+	cfg.meta = metadata
+	if err := cfg.checkProfiles(); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("invalid profile in config file %s: %w", DisplayPath(path), err)
 	}
 
 	// Check for unknown fields
@@ -116,14 +278,14 @@ func LoadConfig(path string) (*Config, []string, map[string]PhaseInfo, map[strin
 		return nil, nil, nil, nil, fmt.Errorf("unknown fields in config: %s", strings.Join(unknownFields, ", "))
 	}
 
-	// Validate tasks - only command is required (except for phase headers and wait markers)
+	// Validate tasks - only command or commandArgs is required (except for phase headers and wait markers)
 	for taskID, task := range cfg.Tasks {
 		// Skip validation for phase headers (phase-*) and wait markers (wait, wait-*)
 		if strings.HasPrefix(taskID, "phase-") || taskID == "wait" || strings.HasPrefix(taskID, "wait-") {
 			continue
 		}
-		if task.Command == "" {
-			return nil, nil, nil, nil, fmt.Errorf("task %q is missing required field: command", taskID)
+		if task.Command == "" && len(task.CommandArgs) == 0 {
+			return nil, nil, nil, nil, fmt.Errorf("task %q is missing required field: command (or commandArgs)", taskID)
 		}
 	}
 
@@ -133,6 +295,16 @@ func LoadConfig(path string) (*Config, []string, map[string]PhaseInfo, map[strin
 		return nil, nil, nil, nil, fmt.Errorf("failed to extract task order: %w", err)
 	}
 
+	// Attach per-phase settings declared on the phase header tasks
+	for key, info := range phaseNames {
+		if header, ok := cfg.Tasks[info.ID]; ok {
+			info.MaxParallel = header.MaxParallel
+			info.FailFast = header.FailFast
+			info.Barrier = header.Barrier
+			phaseNames[key] = info
+		}
+	}
+
 	return &cfg, taskOrder, phaseNames, taskToPhase, nil
 }
 
@@ -140,11 +312,32 @@ func LoadConfig(path string) (*Config, []string, map[string]PhaseInfo, map[strin
 func GetDefaults() Config {
 	return Config{
 		Defaults: DefaultsConfig{
-			OutputRoot:         ".devpipe",
-			FastThreshold:      300,
-			UIMode:             "basic",
-			AnimationRefreshMs: 500,     // 500ms = 2 FPS (efficient default)
-			AnimatedGroupBy:    "phase", // "type" or "phase"
+			OutputRoot:            ".devpipe",
+			RunIDFormat:           "timestamp",
+			FastThreshold:         300,
+			UIMode:                "basic",
+			AnimationRefreshMs:    500, // 500ms = 2 FPS (efficient default)
+			AnimationStyle:        "dots",
+			AnimatedGroupBy:       "phase", // "type" or "phase"
+			MaxParallel:           intPtr(10),
+			FailFast:              "off",
+			Shell:                 DefaultShell(),
+			ContainerRuntime:      "docker",
+			EmptyOutput:           "warn",
+			EstimateStat:          "mean",
+			FlakyThreshold:        2,
+			DashboardRecentLimit:  100,
+			LogPreviewLines:       10,
+			LogPreviewLinesFailed: 50,
+			FailureTailLines:      intPtr(20),
+			CopyArtifacts:         boolPtr(true),
+			Timestamps:            "off",
+			LogFormat:             "text",
+			HeartbeatSeconds:      intPtr(30),
+			MaskBuiltins:          boolPtr(true),
+			SanitizeOutput:        boolPtr(true),
+			Emoji:                 boolPtr(true),
+			LogPrefix:             stringPtr("[{id}]"),
 			Git: GitConfig{
 				Mode: "staged_unstaged",
 				Ref:  "HEAD",
@@ -158,18 +351,41 @@ func GetDefaults() Config {
 	}
 }
 
-// MergeWithDefaults merges loaded config with defaults
+// DefaultShell returns the platform shell used to run commands
+func DefaultShell() []string {
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/c"}
+	}
+	return []string{"sh", "-c"}
+}
+
+// OutputRootEnvVar overrides defaults.outputRoot, e.g. to point a container's runs at a mounted volume
+const OutputRootEnvVar = "DEVPIPE_OUTPUT_ROOT"
+
+// MergeWithDefaults merges loaded config with defaults. DEVPIPE_OUTPUT_ROOT, when set,
+// replaces the output root of the config file
 func MergeWithDefaults(cfg *Config) Config {
 	defaults := GetDefaults()
+	envOutputRoot := os.Getenv(OutputRootEnvVar)
+	if envOutputRoot != "" {
+		defaults.Defaults.OutputRoot = envOutputRoot
+	}
 
 	if cfg == nil {
 		return defaults
 	}
 
+	// Work on a copy so cfg still shows what the config file set (BuildEffectiveConfig relies on it)
+	merged := *cfg
+	cfg = &merged
+
 	// Merge defaults
-	if cfg.Defaults.OutputRoot == "" {
+	if cfg.Defaults.OutputRoot == "" || envOutputRoot != "" {
 		cfg.Defaults.OutputRoot = defaults.Defaults.OutputRoot
 	}
+	if cfg.Defaults.RunIDFormat == "" {
+		cfg.Defaults.RunIDFormat = defaults.Defaults.RunIDFormat
+	}
 	if cfg.Defaults.FastThreshold == 0 {
 		cfg.Defaults.FastThreshold = defaults.Defaults.FastThreshold
 	}
@@ -179,9 +395,69 @@ func MergeWithDefaults(cfg *Config) Config {
 	if cfg.Defaults.AnimationRefreshMs == 0 {
 		cfg.Defaults.AnimationRefreshMs = defaults.Defaults.AnimationRefreshMs
 	}
+	if cfg.Defaults.AnimationStyle == "" {
+		cfg.Defaults.AnimationStyle = defaults.Defaults.AnimationStyle
+	}
 	if cfg.Defaults.AnimatedGroupBy == "" {
 		cfg.Defaults.AnimatedGroupBy = defaults.Defaults.AnimatedGroupBy
 	}
+	if cfg.Defaults.MaxParallel == nil {
+		cfg.Defaults.MaxParallel = defaults.Defaults.MaxParallel
+	}
+	if cfg.Defaults.FailFast == "" {
+		cfg.Defaults.FailFast = defaults.Defaults.FailFast
+	}
+	if len(cfg.Defaults.Shell) == 0 {
+		cfg.Defaults.Shell = defaults.Defaults.Shell
+	}
+	if cfg.Defaults.ContainerRuntime == "" {
+		cfg.Defaults.ContainerRuntime = defaults.Defaults.ContainerRuntime
+	}
+	if cfg.Defaults.EmptyOutput == "" {
+		cfg.Defaults.EmptyOutput = defaults.Defaults.EmptyOutput
+	}
+	if cfg.Defaults.EstimateStat == "" {
+		cfg.Defaults.EstimateStat = defaults.Defaults.EstimateStat
+	}
+	if cfg.Defaults.FlakyThreshold == 0 {
+		cfg.Defaults.FlakyThreshold = defaults.Defaults.FlakyThreshold
+	}
+	if cfg.Defaults.DashboardRecentLimit == 0 {
+		cfg.Defaults.DashboardRecentLimit = defaults.Defaults.DashboardRecentLimit
+	}
+	if cfg.Defaults.LogPreviewLines == 0 {
+		cfg.Defaults.LogPreviewLines = defaults.Defaults.LogPreviewLines
+	}
+	if cfg.Defaults.LogPreviewLinesFailed == 0 {
+		cfg.Defaults.LogPreviewLinesFailed = defaults.Defaults.LogPreviewLinesFailed
+	}
+	if cfg.Defaults.FailureTailLines == nil {
+		cfg.Defaults.FailureTailLines = defaults.Defaults.FailureTailLines
+	}
+	if cfg.Defaults.CopyArtifacts == nil {
+		cfg.Defaults.CopyArtifacts = defaults.Defaults.CopyArtifacts
+	}
+	if cfg.Defaults.Timestamps == "" {
+		cfg.Defaults.Timestamps = defaults.Defaults.Timestamps
+	}
+	if cfg.Defaults.LogFormat == "" {
+		cfg.Defaults.LogFormat = defaults.Defaults.LogFormat
+	}
+	if cfg.Defaults.HeartbeatSeconds == nil {
+		cfg.Defaults.HeartbeatSeconds = defaults.Defaults.HeartbeatSeconds
+	}
+	if cfg.Defaults.MaskBuiltins == nil {
+		cfg.Defaults.MaskBuiltins = defaults.Defaults.MaskBuiltins
+	}
+	if cfg.Defaults.SanitizeOutput == nil {
+		cfg.Defaults.SanitizeOutput = defaults.Defaults.SanitizeOutput
+	}
+	if cfg.Defaults.Emoji == nil {
+		cfg.Defaults.Emoji = defaults.Defaults.Emoji
+	}
+	if cfg.Defaults.LogPrefix == nil {
+		cfg.Defaults.LogPrefix = defaults.Defaults.LogPrefix
+	}
 	if cfg.Defaults.Git.Mode == "" {
 		cfg.Defaults.Git.Mode = defaults.Defaults.Git.Mode
 	}
@@ -200,7 +476,9 @@ func MergeWithDefaults(cfg *Config) Config {
 	return *cfg
 }
 
-// ResolveTaskConfig resolves a task config by applying defaults
+// ResolveTaskConfig resolves a task config by applying defaults and expanding ${VAR}
+// environment references in its paths. The shell expands those in command and fixCommand
+// when they run, and commandArgs are expanded as they run (see ExpandArgs)
 func (c *Config) ResolveTaskConfig(_ string, taskCfg TaskConfig, projectRoot string) TaskConfig {
 	// Apply task defaults
 	if taskCfg.Workdir == "" {
@@ -211,6 +489,14 @@ func (c *Config) ResolveTaskConfig(_ string, taskCfg TaskConfig, projectRoot str
 		}
 	}
 
+	// Expand environment variables (undefined ones are reported by validation)
+	taskCfg.Command = shellEnv(taskCfg.Command, c.Defaults.Shell)
+	taskCfg.FixCommand = shellEnv(taskCfg.FixCommand, c.Defaults.Shell)
+	expandString(&taskCfg.Workdir)
+	expandString(&taskCfg.OutputPath)
+	expandString(&taskCfg.MetricsParser)
+	expandString(&taskCfg.ArtifactDir)
+
 	// Make workdir absolute relative to project root
 	if !filepath.IsAbs(taskCfg.Workdir) {
 		taskCfg.Workdir = filepath.Join(projectRoot, taskCfg.Workdir)
@@ -232,6 +518,14 @@ func boolPtr(b bool) *bool {
 	return &b
 }
 
+func intPtr(i int) *int {
+	return &i
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
 func intToString(i int) string {
 	return fmt.Sprintf("%d", i)
 }
@@ -246,9 +540,12 @@ func trimSpace(s string) string {
 
 // PhaseInfo holds information about a phase
 type PhaseInfo struct {
-	ID   string
-	Name string
-	Desc string
+	ID          string
+	Name        string
+	Desc        string
+	MaxParallel *int  // Optional per-phase parallelism limit from the phase header
+	FailFast    *bool // Optional per-phase fail-fast marker from the phase header
+	Barrier     bool  // Phase header marks a hard barrier that dependsOn cannot cross
 }
 
 // extractTaskOrder parses the TOML file to extract the order of [tasks.X] sections
@@ -256,7 +553,7 @@ type PhaseInfo struct {
 // header belong to that phase until the next phase header
 // Returns task order, phase info map, and task-to-phase mapping
 func extractTaskOrder(path string) ([]string, map[string]PhaseInfo, map[string]string, error) {
-	data, err := os.ReadFile(path)
+	data, err := ReadConfigFile(path)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -311,12 +608,10 @@ func extractTaskOrder(path string) ([]string, map[string]PhaseInfo, map[string]s
 						}
 					}
 
-					if phaseName != "" {
-						phaseNames[currentPhaseID] = PhaseInfo{
-							ID:   currentPhaseMarker,
-							Name: phaseName,
-							Desc: phaseDesc,
-						}
+					phaseNames[currentPhaseID] = PhaseInfo{
+						ID:   currentPhaseMarker,
+						Name: phaseName,
+						Desc: phaseDesc,
 					}
 
 					// Don't add the phase header itself to the order
@@ -348,47 +643,18 @@ func extractQuotedValue(s string) string {
 	return s
 }
 
-// GenerateDefaultConfig creates a minimal config.toml file
-func GenerateDefaultConfig(path string, _ string) error {
+// GenerateDefaultConfig creates a config.toml with tasks for the ecosystems detected
+// in projectRoot (go.mod, package.json, Cargo.toml), or placeholder tasks if none are found
+func GenerateDefaultConfig(path string, projectRoot string) error {
 	// Check if file already exists
 	if _, err := os.Stat(path); err == nil {
 		return fmt.Errorf("config file already exists: %s", path)
 	}
 
-	// Write to file
-	f, err := os.Create(path)
-	if err != nil {
-		return fmt.Errorf("failed to create config file: %w", err)
-	}
-	defer func() {
-		if cerr := f.Close(); cerr != nil && err == nil {
-			err = fmt.Errorf("failed to close config file: %w", cerr)
-		}
-	}()
-
-	// Write minimal, runnable config
-	content := `# devpipe configuration file
-# Full reference: https://github.com/drewkhoury/devpipe/blob/main/config.example.toml
-
-[tasks.lint]
-name = "Lint"
-command = "echo 'Running linter...'"
-type = "check"
-
-[tasks.format]
-name = "Format Check"
-command = "echo 'Checking code formatting...'"
-type = "check"
-
-[tasks.build]
-name = "Build"
-command = "echo 'Building application...'"
-type = "build"
-`
-
-	if _, err := f.WriteString(content); err != nil {
-		return fmt.Errorf("failed to write config: %w", err)
+	tasks := scaffold.Detect(projectRoot)
+	if len(tasks) == 0 {
+		tasks = scaffold.FallbackTasks()
 	}
 
-	return nil
+	return scaffold.Write(path, tasks)
 }
@@ -1117,3 +1117,41 @@ func TestResolveTaskConfigWithNoDefaults(t *testing.T) {
 		t.Errorf("Expected workdir '/repo', got '%s'", resolved.Workdir)
 	}
 }
+
+func TestLoadConfigPhaseMaxParallel(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.toml")
+
+	content := `[tasks.phase-build]
+name = "Build"
+maxParallel = 2
+
+[tasks.compile]
+command = "go build"
+
+[tasks.phase-test]
+
+[tasks.unit]
+command = "go test"`
+
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	_, _, phaseInfo, _, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	build := phaseInfo["wait-1"]
+	if build.MaxParallel == nil || *build.MaxParallel != 2 {
+		t.Errorf("expected build phase maxParallel=2, got %v", build.MaxParallel)
+	}
+	test, ok := phaseInfo["wait-2"]
+	if !ok {
+		t.Fatal("expected unnamed phase to be recorded")
+	}
+	if test.MaxParallel != nil {
+		t.Errorf("expected test phase maxParallel=nil, got %d", *test.MaxParallel)
+	}
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ConfigEnvVar names the config file when --config is not given, e.g. in a container entrypoint
+const ConfigEnvVar = "DEVPIPE_CONFIG"
+
+// FileNames are the config file names discovery looks for in each directory, in order
+var FileNames = []string{"config.toml", ".devpipe.toml"}
+
+// Discover looks for a config file in dir and then its parents, the way go finds go.mod, so
+// devpipe can run from any subdirectory of a project. The search stops after the git root
+// (the directory containing .git) or at the filesystem root. It returns "" when nothing is found
+func Discover(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		for _, name := range FileNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
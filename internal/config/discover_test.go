@@ -0,0 +1,45 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiscover(t *testing.T) {
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	sub := filepath.Join(repo, "services", "api")
+	for _, dir := range []string{filepath.Join(repo, ".git"), sub} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+	}
+	write := func(path string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte("[tasks.lint]\ncommand = \"make lint\"\n"), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+
+	// A config above the git root is never used
+	write(filepath.Join(root, "config.toml"))
+	if got := Discover(sub); got != "" {
+		t.Errorf("Discover() = %q, want nothing past the git root", got)
+	}
+
+	write(filepath.Join(repo, ".devpipe.toml"))
+	if got := Discover(sub); got != filepath.Join(repo, ".devpipe.toml") {
+		t.Errorf("Discover() = %q, want the repo's .devpipe.toml", got)
+	}
+
+	// config.toml wins over .devpipe.toml in the same directory, and the nearest directory wins
+	write(filepath.Join(repo, "config.toml"))
+	if got := Discover(sub); got != filepath.Join(repo, "config.toml") {
+		t.Errorf("Discover() = %q, want the repo's config.toml", got)
+	}
+	write(filepath.Join(repo, "services", ".devpipe.toml"))
+	if got := Discover(sub); got != filepath.Join(repo, "services", ".devpipe.toml") {
+		t.Errorf("Discover() = %q, want the nearest config", got)
+	}
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/drew/devpipe/internal/model"
+)
+
+// BuildEffectiveConfig creates a detailed breakdown of configuration values and their sources.
+// cfg is the loaded config (nil without a config file) and mergedCfg the result of MergeWithDefaults;
+// flagSince and flagUI are the CLI values, and uiModeStr, gitMode and gitRef the resolved ones.
+func BuildEffectiveConfig(cfg *Config, mergedCfg *Config, flagSince, flagUI, uiModeStr, gitMode, gitRef string) *model.EffectiveConfig {
+	defaults := GetDefaults()
+	var values []model.ConfigValue
+
+	// Helper to add a config value; values the active profile set are attributed to it
+	addValue := func(key, value, source, overrode string) {
+		if source == "config-file" && cfg.setByProfile(key) {
+			source = "profile"
+		}
+		values = append(values, model.ConfigValue{
+			Key:      key,
+			Value:    value,
+			Source:   source,
+			Overrode: overrode,
+		})
+	}
+
+	// Output Root
+	if os.Getenv(OutputRootEnvVar) != "" {
+		overrode := defaults.Defaults.OutputRoot
+		if cfg != nil && cfg.Defaults.OutputRoot != "" {
+			overrode = cfg.Defaults.OutputRoot
+		}
+		addValue("defaults.outputRoot", mergedCfg.Defaults.OutputRoot, "env", overrode)
+	} else if cfg != nil && cfg.Defaults.OutputRoot != "" {
+		addValue("defaults.outputRoot", mergedCfg.Defaults.OutputRoot, "config-file", "")
+	} else {
+		addValue("defaults.outputRoot", mergedCfg.Defaults.OutputRoot, "default", "")
+	}
+
+	// Fast Threshold
+	if cfg != nil && cfg.Defaults.FastThreshold != 0 {
+		addValue("defaults.fastThreshold", fmt.Sprintf("%d", mergedCfg.Defaults.FastThreshold), "config-file", "")
+	} else {
+		addValue("defaults.fastThreshold", fmt.Sprintf("%d", mergedCfg.Defaults.FastThreshold), "default", "")
+	}
+
+	// UI Mode
+	var uiSource, uiOverrode string
+	if flagUI != "basic" {
+		uiSource = "cli-flag"
+		if cfg != nil && cfg.Defaults.UIMode != "" {
+			uiOverrode = cfg.Defaults.UIMode
+		} else {
+			uiOverrode = defaults.Defaults.UIMode
+		}
+	} else if cfg != nil && cfg.Defaults.UIMode != "" {
+		uiSource = "config-file"
+	} else {
+		uiSource = "default"
+	}
+	addValue("defaults.uiMode", uiModeStr, uiSource, uiOverrode)
+
+	// Animation Refresh
+	if cfg != nil && cfg.Defaults.AnimationRefreshMs != 0 {
+		addValue("defaults.animationRefreshMs", fmt.Sprintf("%d", mergedCfg.Defaults.AnimationRefreshMs), "config-file", "")
+	} else {
+		addValue("defaults.animationRefreshMs", fmt.Sprintf("%d", mergedCfg.Defaults.AnimationRefreshMs), "default", "")
+	}
+
+	// Animation Style
+	if cfg != nil && cfg.Defaults.AnimationStyle != "" {
+		addValue("defaults.animationStyle", mergedCfg.Defaults.AnimationStyle, "config-file", "")
+	} else {
+		addValue("defaults.animationStyle", mergedCfg.Defaults.AnimationStyle, "default", "")
+	}
+
+	// Git Mode
+	var gitModeSource, gitModeOverrode string
+	if flagSince != "" {
+		gitModeSource = "cli-flag"
+		if cfg != nil && cfg.Defaults.Git.Mode != "" {
+			gitModeOverrode = cfg.Defaults.Git.Mode
+		} else {
+			gitModeOverrode = defaults.Defaults.Git.Mode
+		}
+	} else if cfg != nil && cfg.Defaults.Git.Mode != "" {
+		gitModeSource = "config-file"
+	} else {
+		gitModeSource = "default"
+	}
+	addValue("defaults.git.mode", gitMode, gitModeSource, gitModeOverrode)
+
+	// Git Ref
+	var gitRefSource, gitRefOverrode string
+	if flagSince != "" {
+		gitRefSource = "cli-flag"
+		if cfg != nil && cfg.Defaults.Git.Ref != "" {
+			gitRefOverrode = cfg.Defaults.Git.Ref
+		} else {
+			gitRefOverrode = defaults.Defaults.Git.Ref
+		}
+	} else if cfg != nil && cfg.Defaults.Git.Ref != "" {
+		gitRefSource = "config-file"
+	} else {
+		gitRefSource = "default"
+	}
+	addValue("defaults.git.ref", gitRef, gitRefSource, gitRefOverrode)
+
+	// Git Include Untracked
+	if cfg != nil && cfg.Defaults.Git.IncludeUntracked {
+		addValue("defaults.git.includeUntracked", "true", "config-file", "")
+	} else {
+		addValue("defaults.git.includeUntracked", "false", "default", "")
+	}
+
+	// Git Respect Gitignore
+	if cfg != nil && cfg.Defaults.Git.RespectGitignore {
+		addValue("defaults.git.respectGitignore", "true", "config-file", "")
+	} else {
+		addValue("defaults.git.respectGitignore", "false", "default", "")
+	}
+
+	// Task Defaults
+	if cfg != nil && cfg.TaskDefaults.Enabled != nil {
+		addValue("task_defaults.enabled", fmt.Sprintf("%t", *mergedCfg.TaskDefaults.Enabled), "config-file", "")
+	} else {
+		addValue("task_defaults.enabled", fmt.Sprintf("%t", *mergedCfg.TaskDefaults.Enabled), "default", "")
+	}
+
+	if cfg != nil && cfg.TaskDefaults.Workdir != "" {
+		addValue("task_defaults.workdir", mergedCfg.TaskDefaults.Workdir, "config-file", "")
+	} else {
+		addValue("task_defaults.workdir", mergedCfg.TaskDefaults.Workdir, "default", "")
+	}
+
+	effective := &model.EffectiveConfig{Values: values}
+	if cfg != nil {
+		effective.Profile = cfg.Profile
+	}
+	return effective
+}
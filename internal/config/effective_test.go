@@ -0,0 +1,142 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestBuildEffectiveConfig(t *testing.T) {
+	// Create a basic config
+	cfg := &Config{
+		Defaults: DefaultsConfig{
+			OutputRoot:    "custom-output",
+			FastThreshold: 30,
+			UIMode:        "full",
+		},
+	}
+
+	mergedCfg := MergeWithDefaults(cfg)
+
+	effective := BuildEffectiveConfig(cfg, &mergedCfg, "", "basic", "basic", "staged", "HEAD")
+
+	if effective == nil {
+		t.Fatal("BuildEffectiveConfig() returned nil")
+	}
+
+	if len(effective.Values) == 0 {
+		t.Error("BuildEffectiveConfig() returned no values")
+	}
+
+	// Check that we have expected config values
+	foundOutputRoot := false
+	foundUIMode := false
+	for _, val := range effective.Values {
+		if val.Key == "defaults.outputRoot" {
+			foundOutputRoot = true
+			if val.Value != "custom-output" {
+				t.Errorf("outputRoot value = %q, want %q", val.Value, "custom-output")
+			}
+			if val.Source != "config-file" {
+				t.Errorf("outputRoot source = %q, want %q", val.Source, "config-file")
+			}
+		}
+		if val.Key == "defaults.uiMode" {
+			foundUIMode = true
+			if val.Value != "basic" {
+				t.Errorf("uiMode value = %q, want %q", val.Value, "basic")
+			}
+		}
+	}
+
+	if !foundOutputRoot {
+		t.Error("BuildEffectiveConfig() missing defaults.outputRoot")
+	}
+	if !foundUIMode {
+		t.Error("BuildEffectiveConfig() missing defaults.uiMode")
+	}
+}
+
+func TestBuildEffectiveConfigWithCLIOverrides(t *testing.T) {
+	// Test that CLI flags override config values
+	cfg := &Config{
+		Defaults: DefaultsConfig{
+			UIMode: "basic",
+			Git: GitConfig{
+				Mode: "staged",
+				Ref:  "main",
+			},
+		},
+	}
+
+	mergedCfg := MergeWithDefaults(cfg)
+
+	// Simulate CLI flags overriding config
+	flagSince := "HEAD~1"
+	flagUI := "full"
+
+	effective := BuildEffectiveConfig(cfg, &mergedCfg, flagSince, flagUI, "full", "ref", "HEAD~1")
+
+	if effective == nil {
+		t.Fatal("BuildEffectiveConfig() returned nil")
+	}
+
+	// Check that CLI overrides are recorded
+	for _, val := range effective.Values {
+		if val.Key == "defaults.uiMode" {
+			if val.Source != "cli-flag" {
+				t.Errorf("uiMode source = %q, want %q", val.Source, "cli-flag")
+			}
+			if val.Overrode != "basic" {
+				t.Errorf("uiMode overrode = %q, want %q", val.Overrode, "basic")
+			}
+		}
+		if val.Key == "defaults.git.mode" {
+			if val.Source != "cli-flag" {
+				t.Errorf("git.mode source = %q, want %q", val.Source, "cli-flag")
+			}
+		}
+	}
+}
+
+func TestBuildEffectiveConfigOutputRootFromEnv(t *testing.T) {
+	t.Setenv(OutputRootEnvVar, "/work/.devpipe")
+	cfg := &Config{Defaults: DefaultsConfig{OutputRoot: "out"}}
+
+	mergedCfg := MergeWithDefaults(cfg)
+	if mergedCfg.Defaults.OutputRoot != "/work/.devpipe" {
+		t.Errorf("OutputRoot = %q, want the environment's /work/.devpipe", mergedCfg.Defaults.OutputRoot)
+	}
+	if cfg.Defaults.OutputRoot != "out" {
+		t.Errorf("MergeWithDefaults() changed the loaded config's outputRoot to %q", cfg.Defaults.OutputRoot)
+	}
+
+	effective := BuildEffectiveConfig(cfg, &mergedCfg, "", "basic", "basic", "staged", "")
+	for _, val := range effective.Values {
+		if val.Key == "defaults.outputRoot" && (val.Source != "env" || val.Overrode != "out") {
+			t.Errorf("outputRoot source = %q overrode %q, want env overriding out", val.Source, val.Overrode)
+		}
+	}
+	if merged := MergeWithDefaults(nil); merged.Defaults.OutputRoot != "/work/.devpipe" {
+		t.Errorf("OutputRoot without a config = %q, want /work/.devpipe", merged.Defaults.OutputRoot)
+	}
+}
+
+func TestBuildEffectiveConfigDefaultsSource(t *testing.T) {
+	// Only outputRoot is set in the file; everything else must be reported as a default
+	cfg := &Config{Defaults: DefaultsConfig{OutputRoot: "out"}}
+	mergedCfg := MergeWithDefaults(cfg)
+
+	if cfg.Defaults.FastThreshold != 0 {
+		t.Fatalf("MergeWithDefaults() modified the loaded config")
+	}
+
+	effective := BuildEffectiveConfig(cfg, &mergedCfg, "", "basic", mergedCfg.Defaults.UIMode, mergedCfg.Defaults.Git.Mode, mergedCfg.Defaults.Git.Ref)
+	for _, val := range effective.Values {
+		want := "default"
+		if val.Key == "defaults.outputRoot" {
+			want = "config-file"
+		}
+		if val.Source != want {
+			t.Errorf("%s source = %q, want %q", val.Key, val.Source, want)
+		}
+	}
+}
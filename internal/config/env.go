@@ -0,0 +1,180 @@
+package config
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// gitEnvVars are set by devpipe for every task once git info is known
+var gitEnvVars = map[string]bool{
+	"DEVPIPE_GIT_MODE":            true,
+	"DEVPIPE_GIT_REF":             true,
+	"DEVPIPE_CHANGED_FILES_COUNT": true,
+	"DEVPIPE_CHANGED_FILES":       true,
+	"DEVPIPE_CHANGED_FILES_JSON":  true,
+}
+
+// GitEnvNames returns the names of the DEVPIPE_* git variables, sorted
+func GitEnvNames() []string {
+	names := make([]string, 0, len(gitEnvVars))
+	for name := range gitEnvVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupEnv resolves a variable from the environment. The DEVPIPE_* git variables
+// always count as defined, even before devpipe has set them for the run.
+func LookupEnv(name string) (string, bool) {
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true
+	}
+	if gitEnvVars[name] {
+		return "", true
+	}
+	return "", false
+}
+
+// ExpandEnv replaces ${VAR} references in s using lookup and returns the names of
+// any variables lookup could not resolve (they expand to empty).
+// "$$" produces a literal "$". Bare $VAR references and shell syntax such as
+// ${VAR:-default} are left untouched so the shell can still handle them.
+func ExpandEnv(s string, lookup func(string) (string, bool)) (string, []string) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	var undefined []string
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(s[i+2:], '}')
+			if end < 0 || !isEnvName(s[i+2:i+2+end]) {
+				b.WriteByte(s[i])
+				continue
+			}
+			name := s[i+2 : i+2+end]
+			value, ok := lookup(name)
+			if !ok {
+				undefined = append(undefined, name)
+			}
+			b.WriteString(value)
+			i += 2 + end
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String(), undefined
+}
+
+// EnvRefs returns the names of the ${VAR} references in s, in order
+func EnvRefs(s string) []string {
+	var names []string
+	ExpandEnv(s, func(name string) (string, bool) {
+		names = append(names, name)
+		return "", true
+	})
+	return names
+}
+
+// ExpandArgs expands ${VAR} references in each of args with LookupEnv. No shell parses
+// commandArgs, so each stays a single argument whatever its value
+func ExpandArgs(args []string) []string {
+	if len(args) == 0 {
+		return args
+	}
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		expanded[i], _ = ExpandEnv(arg, LookupEnv)
+	}
+	return expanded
+}
+
+// shellEnv prepares a shell command's ${VAR} references. They are left for the shell to
+// expand from the environment at run time, so a value is never parsed as shell code or
+// split into separate commands, and the command recorded for the run holds no secrets.
+// "$$" becomes a "$" the shell takes literally: escaped with a backslash for sh-like shells,
+// except inside single quotes where it is already literal
+func shellEnv(s string, shell []string) string {
+	if !strings.Contains(s, "$$") {
+		return s
+	}
+	if len(shell) == 0 {
+		shell = DefaultShell()
+	}
+	name := shell[0][strings.LastIndexAny(shell[0], `/\`)+1:]
+	name = strings.TrimSuffix(strings.ToLower(name), ".exe")
+	posix := name != "cmd" && name != "pwsh" && name != "powershell"
+
+	var b strings.Builder
+	inSingle, inDouble := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && !inSingle && i+1 < len(s):
+			b.WriteByte(c)
+			i++
+			c = s[i]
+		case c == '\'' && !inDouble:
+			inSingle = !inSingle
+		case c == '"' && !inSingle:
+			inDouble = !inDouble
+		case c == '$' && i+1 < len(s) && s[i+1] == '$':
+			if posix && !inSingle {
+				b.WriteByte('\\')
+			}
+			i++
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// isEnvName reports whether s is a valid environment variable name
+func isEnvName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		isLetter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !isLetter && (i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// MissingEnv returns the names in required that lookup reports as unset or empty. The
+// DEVPIPE_* git variables may legitimately be empty, so only unset counts for them.
+func MissingEnv(required []string, lookup func(string) (string, bool)) []string {
+	var missing []string
+	for _, name := range required {
+		if value, ok := lookup(name); !ok || (value == "" && !gitEnvVars[name]) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// expandString expands s in place with LookupEnv
+func expandString(s *string) {
+	*s, _ = ExpandEnv(*s, LookupEnv)
+}
+
+// ExpandDefaults expands environment variables in defaults.projectRoot and defaults.outputRoot
+func (c *Config) ExpandDefaults() {
+	expandString(&c.Defaults.ProjectRoot)
+	expandString(&c.Defaults.OutputRoot)
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	env := map[string]string{"DEPLOY_ENV": "staging", "HOME": "/home/dev"}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	tests := []struct {
+		name          string
+		input         string
+		want          string
+		wantUndefined []string
+	}{
+		{"no vars", "go test ./...", "go test ./...", nil},
+		{"braced var", "deploy --env ${DEPLOY_ENV}", "deploy --env staging", nil},
+		{"path", "${HOME}/.devpipe", "/home/dev/.devpipe", nil},
+		{"escaped dollar", "echo $${DEPLOY_ENV} costs $$5", "echo ${DEPLOY_ENV} costs $5", nil},
+		{"bare var left for shell", "echo $DEVPIPE_CHANGED_FILES", "echo $DEVPIPE_CHANGED_FILES", nil},
+		{"shell default syntax", "echo ${FOO:-bar}", "echo ${FOO:-bar}", nil},
+		{"command substitution", "echo $(pwd)", "echo $(pwd)", nil},
+		{"unterminated", "echo ${HOME", "echo ${HOME", nil},
+		{"trailing dollar", "echo $", "echo $", nil},
+		{"undefined", "run ${MISSING} ${DEPLOY_ENV}", "run  staging", []string{"MISSING"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, undefined := ExpandEnv(tt.input, lookup)
+			if got != tt.want {
+				t.Errorf("ExpandEnv(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			if strings.Join(undefined, ",") != strings.Join(tt.wantUndefined, ",") {
+				t.Errorf("ExpandEnv(%q) undefined = %v, want %v", tt.input, undefined, tt.wantUndefined)
+			}
+		})
+	}
+}
+
+func TestLookupEnvGitVars(t *testing.T) {
+	t.Setenv("DEVPIPE_TEST_VAR", "value")
+
+	if v, ok := LookupEnv("DEVPIPE_TEST_VAR"); !ok || v != "value" {
+		t.Errorf("LookupEnv(DEVPIPE_TEST_VAR) = %q, %v", v, ok)
+	}
+	// Git vars count as defined even before devpipe sets them
+	if _, ok := LookupEnv("DEVPIPE_CHANGED_FILES_JSON"); !ok {
+		t.Error("expected DEVPIPE_CHANGED_FILES_JSON to be defined")
+	}
+	if _, ok := LookupEnv("DEVPIPE_TEST_UNDEFINED_VAR"); ok {
+		t.Error("expected DEVPIPE_TEST_UNDEFINED_VAR to be undefined")
+	}
+}
+
+func TestResolveTaskConfigExpandsEnv(t *testing.T) {
+	t.Setenv("DEPLOY_ENV", "prod")
+	t.Setenv("REPORT_DIR", "reports")
+
+	cfg := &Config{}
+	resolved := cfg.ResolveTaskConfig("deploy", TaskConfig{
+		Command:    "deploy --env ${DEPLOY_ENV}",
+		Workdir:    "${REPORT_DIR}",
+		OutputPath: "${REPORT_DIR}/junit.xml",
+	}, "/repo")
+
+	// The shell expands the command's references when it runs
+	if resolved.Command != "deploy --env ${DEPLOY_ENV}" {
+		t.Errorf("Command = %q", resolved.Command)
+	}
+	if resolved.Workdir != "/repo/reports" {
+		t.Errorf("Workdir = %q", resolved.Workdir)
+	}
+	if resolved.OutputPath != "reports/junit.xml" {
+		t.Errorf("OutputPath = %q", resolved.OutputPath)
+	}
+}
+
+func TestShellEnv(t *testing.T) {
+	sh := []string{"sh", "-c"}
+	tests := []struct {
+		input string
+		shell []string
+		want  string
+	}{
+		{"deploy --env ${DEPLOY_ENV}", sh, "deploy --env ${DEPLOY_ENV}"},
+		{"echo $${HOME} costs $$5", sh, `echo \${HOME} costs \$5`},
+		{`echo "$$5" '$$5'`, sh, `echo "\$5" '$5'`},
+		{`echo "it's $$5"`, sh, `echo "it's \$5"`},
+		{"echo $$5", []string{"cmd", "/c"}, "echo $5"},
+		{"echo $$5", []string{`C:\Program Files\PowerShell\pwsh.exe`, "-Command"}, "echo $5"},
+	}
+	for _, tt := range tests {
+		if got := shellEnv(tt.input, tt.shell); got != tt.want {
+			t.Errorf("shellEnv(%q, %v) = %q, want %q", tt.input, tt.shell, got, tt.want)
+		}
+	}
+
+	// $$ comes out as a literal $ from the shell
+	out, err := exec.Command("sh", "-c", shellEnv(`printf '%s|' $$5 "$${HOME}" '$$x'`, sh)).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "$5|${HOME}|$x|" {
+		t.Errorf("sh printed %q", out)
+	}
+}
+
+func TestExpandArgs(t *testing.T) {
+	t.Setenv("DEVPIPE_TEST_FILES", "a.go\nb; rm -rf /")
+
+	got := ExpandArgs([]string{"lint", "${DEVPIPE_TEST_FILES}"})
+	if !reflect.DeepEqual(got, []string{"lint", "a.go\nb; rm -rf /"}) {
+		t.Errorf("ExpandArgs() = %q", got)
+	}
+	if refs := EnvRefs("deploy ${DEPLOY_ENV} $${LITERAL} ${FOO:-x} ${TOKEN}"); !reflect.DeepEqual(refs, []string{"DEPLOY_ENV", "TOKEN"}) {
+		t.Errorf("EnvRefs() = %v", refs)
+	}
+}
+
+func TestValidateEnvRefs(t *testing.T) {
+	cfg := &Config{
+		Defaults: DefaultsConfig{OutputRoot: "${DEVPIPE_TEST_MISSING_ROOT}/out"},
+		Tasks: map[string]TaskConfig{
+			"deploy": {Command: "deploy ${DEVPIPE_TEST_MISSING_ENV}"},
+		},
+	}
+
+	result, _ := ValidateConfig(cfg)
+	if !result.Valid {
+		t.Fatalf("expected valid config without strictEnv, errors: %v", result.Errors)
+	}
+	if len(result.Warnings) != 2 {
+		t.Errorf("expected 2 warnings, got %v", result.Warnings)
+	}
+
+	cfg.Defaults.StrictEnv = true
+	result, _ = ValidateConfig(cfg)
+	if result.Valid || len(result.Errors) != 2 {
+		t.Errorf("expected 2 errors with strictEnv, got %v", result.Errors)
+	}
+	if !result.Errors[0].IsUndefinedEnv() {
+		t.Errorf("IsUndefinedEnv() = false for %v", result.Errors[0])
+	}
+	if result.Errors[0].Field != "defaults.outputRoot" {
+		t.Errorf("first error field = %q, want defaults.outputRoot", result.Errors[0].Field)
+	}
+}
+
+func TestValidateRequiredEnv(t *testing.T) {
+	t.Setenv("DEVPIPE_TEST_REGION", "us-east-1")
+	t.Setenv("DEVPIPE_TEST_EMPTY", "")
+
+	if missing := MissingEnv([]string{"DEVPIPE_TEST_REGION", "DEVPIPE_TEST_EMPTY", "DEVPIPE_TEST_UNSET", "DEVPIPE_CHANGED_FILES"}, LookupEnv); !reflect.DeepEqual(missing, []string{"DEVPIPE_TEST_EMPTY", "DEVPIPE_TEST_UNSET"}) {
+		t.Errorf("MissingEnv() = %v, want the empty and unset variables", missing)
+	}
+
+	result := &ValidationResult{Valid: true}
+	validateTask("deploy", TaskConfig{Command: "deploy", RequiredEnv: []string{"DEVPIPE_TEST_REGION", "DEVPIPE_TEST_UNSET"}}, result)
+	if !result.Valid || len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0].Message, "DEVPIPE_TEST_UNSET") {
+		t.Errorf("Expected one warning for the unset variable, got %v %v", result.Errors, result.Warnings)
+	}
+
+	disabled := false
+	result = &ValidationResult{Valid: true}
+	validateTask("deploy", TaskConfig{Command: "deploy", Enabled: &disabled, RequiredEnv: []string{"DEVPIPE_TEST_UNSET", "AWS-REGION"}}, result)
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Field != "tasks.deploy.requiredEnv[1]" || len(result.Warnings) != 0 {
+		t.Errorf("Expected only an error for the invalid name, got %v %v", result.Errors, result.Warnings)
+	}
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Position is where a key or table is defined in a config file, 1-based
+type Position struct {
+	Line   int
+	Column int
+}
+
+// indexSuffix matches a trailing list index in a field, e.g. the [2] of tasks.lint.watchPaths[2]
+var indexSuffix = regexp.MustCompile(`\[\d+\]$`)
+
+// keyPositions scans raw TOML for table headers and key assignments, mapping each dotted key
+// (unquoted parts joined with ".") to where it is first defined. Keys inside inline tables and
+// the contents of multi-line strings are not recorded
+func keyPositions(data string) map[string]Position {
+	positions := make(map[string]Position)
+	var table []string
+	closing := "" // Delimiter of the multi-line string being skipped
+	for i, line := range splitLines(data) {
+		if closing != "" {
+			if strings.Contains(line, closing) {
+				closing = ""
+			}
+			continue
+		}
+		trimmed := trimSpace(line)
+		if trimmed == "" || trimmed[0] == '#' {
+			continue
+		}
+		pos := Position{Line: i + 1, Column: len(line) - len(strings.TrimLeft(line, " \t")) + 1}
+
+		if trimmed[0] == '[' {
+			header := strings.TrimLeft(trimmed, "[")
+			parts, rest, ok := parseKeyPath(header)
+			if ok && strings.HasPrefix(rest, "]") {
+				table = parts
+				recordPosition(positions, parts, pos)
+			}
+			continue
+		}
+
+		parts, rest, ok := parseKeyPath(trimmed)
+		if !ok || !strings.HasPrefix(rest, "=") {
+			continue
+		}
+		recordPosition(positions, append(append([]string{}, table...), parts...), pos)
+
+		value := trimSpace(rest[1:])
+		for _, delim := range []string{`"""`, `'''`} {
+			if strings.HasPrefix(value, delim) && !strings.Contains(value[len(delim):], delim) {
+				closing = delim
+			}
+		}
+	}
+	return positions
+}
+
+// recordPosition records pos for the key and, when not already known, the tables it is in
+func recordPosition(positions map[string]Position, parts []string, pos Position) {
+	for n := len(parts); n > 0; n-- {
+		key := strings.Join(parts[:n], ".")
+		if _, ok := positions[key]; ok {
+			return
+		}
+		positions[key] = pos
+	}
+}
+
+// parseKeyPath parses a dotted key of bare, "basic" and 'literal' parts at the start of s,
+// returning the parts and what follows them (trimmed)
+func parseKeyPath(s string) (parts []string, rest string, ok bool) {
+	for {
+		s = strings.TrimLeft(s, " \t")
+		var part string
+		switch {
+		case strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'"):
+			quote := s[0]
+			end := 1
+			for end < len(s) && s[end] != quote {
+				if quote == '"' && s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, "", false
+			}
+			part, s = s[1:end], s[end+1:]
+		default:
+			end := strings.IndexFunc(s, func(r rune) bool {
+				return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+			})
+			if end == -1 {
+				end = len(s)
+			}
+			if end == 0 {
+				return nil, "", false
+			}
+			part, s = s[:end], s[end:]
+		}
+		parts = append(parts, part)
+		s = strings.TrimLeft(s, " \t")
+		if !strings.HasPrefix(s, ".") {
+			return parts, s, true
+		}
+		s = s[1:]
+	}
+}
+
+// lookupPosition finds where field is defined, falling back to the nearest enclosing key or
+// table that is in the file (e.g. the [tasks.lint] header for a missing tasks.lint.command)
+func lookupPosition(positions map[string]Position, field string) (Position, bool) {
+	for field != "" {
+		field = indexSuffix.ReplaceAllString(field, "")
+		if pos, ok := positions[field]; ok {
+			return pos, true
+		}
+		i := strings.LastIndex(field, ".")
+		if i == -1 {
+			break
+		}
+		field = field[:i]
+	}
+	return Position{}, false
+}
+
+// locate sets the line and column of each error and warning that has a field but no position yet
+func (r *ValidationResult) locate(positions map[string]Position) {
+	for _, list := range [][]ValidationError{r.Errors, r.Warnings} {
+		for i := range list {
+			if list[i].Line != 0 || list[i].Field == "" {
+				continue
+			}
+			if pos, ok := lookupPosition(positions, list[i].Field); ok {
+				list[i].Line, list[i].Column = pos.Line, pos.Column
+			}
+		}
+	}
+}
+
+// parseErrorPosition returns where a TOML syntax error is, if the parser reported it
+func parseErrorPosition(err error) (Position, bool) {
+	var parseErr toml.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Position.Line == 0 {
+		return Position{}, false
+	}
+	return Position{Line: parseErr.Position.Line, Column: parseErr.Position.Col}, true
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeyPositions(t *testing.T) {
+	data := `# comment
+[defaults]
+outputRoot = ".devpipe"
+git.ref = "main"
+
+[tasks."unit tests"]
+  command = "go test"
+desc = """
+fake = "inside a string"
+"""
+
+[[hooks.before]]
+`
+	positions := keyPositions(data)
+	tests := []struct {
+		key  string
+		want Position
+	}{
+		{"defaults", Position{Line: 2, Column: 1}},
+		{"defaults.outputRoot", Position{Line: 3, Column: 1}},
+		{"defaults.git.ref", Position{Line: 4, Column: 1}},
+		{"defaults.git", Position{Line: 4, Column: 1}},
+		{"tasks.unit tests", Position{Line: 6, Column: 1}},
+		{"tasks.unit tests.command", Position{Line: 7, Column: 3}},
+		{"hooks.before", Position{Line: 12, Column: 1}},
+	}
+	for _, tt := range tests {
+		if got := positions[tt.key]; got != tt.want {
+			t.Errorf("position of %q = %+v, want %+v", tt.key, got, tt.want)
+		}
+	}
+	if _, ok := positions["tasks.unit tests.fake"]; ok {
+		t.Error("recorded a key from inside a multi-line string")
+	}
+}
+
+func TestLookupPosition(t *testing.T) {
+	positions := keyPositions("[tasks.lint]\ncommand = \"make lint\"\nwatchPaths = [\"src/**\"]\n")
+	tests := []struct {
+		field    string
+		wantLine int
+	}{
+		{"tasks.lint.watchPaths[0]", 3},
+		{"tasks.lint.fixCommand", 1}, // Not set, so the table it belongs in
+		{"defaults.outputRoot", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got, _ := lookupPosition(positions, tt.field); got.Line != tt.wantLine {
+			t.Errorf("lookupPosition(%q) line = %d, want %d", tt.field, got.Line, tt.wantLine)
+		}
+	}
+}
+
+func TestValidateConfigFilePositions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	content := "[tasks.lint]\ncommand = \"make lint\"\nfixType = \"bogus\"\ntypo = 1\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	result, err := ValidateConfigFile(path)
+	if err != nil {
+		t.Fatalf("ValidateConfigFile() error = %v", err)
+	}
+	lines := make(map[string]int)
+	for _, e := range result.Errors {
+		lines[e.Field] = e.Line
+	}
+	if lines["tasks.lint.fixType"] != 3 || lines["tasks.lint.typo"] != 4 {
+		t.Errorf("error lines = %v, want fixType on line 3 and typo on line 4", lines)
+	}
+	if got := formatProblem(path, result.Errors[0]); got != path+":1:1: [tasks.lint.fixCommand] fixType is set but fixCommand is not specified" {
+		t.Errorf("formatProblem() = %q", got)
+	}
+
+	if err := os.WriteFile(path, []byte("[tasks.lint]\ncommand = \n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	result, err = ValidateConfigFile(path)
+	if err != nil {
+		t.Fatalf("ValidateConfigFile() error = %v", err)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Line != 2 {
+		t.Errorf("errors = %+v, want the syntax error on line 2", result.Errors)
+	}
+}
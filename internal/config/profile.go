@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ProfileEnvVar selects a profile when --profile is not given
+const ProfileEnvVar = "DEVPIPE_PROFILE"
+
+// ProfileConfig is a [profiles.<name>] section, laid over the rest of the config when the
+// profile is selected. Only the keys it sets change; lists replace the base list and tables
+// such as labels are merged key by key
+type ProfileConfig struct {
+	Defaults     toml.Primitive            `toml:"defaults"`
+	TaskDefaults toml.Primitive            `toml:"task_defaults"`
+	Hooks        toml.Primitive            `toml:"hooks"`
+	Tasks        map[string]toml.Primitive `toml:"tasks"`
+}
+
+// ProfileNames returns the names of the config's profiles, sorted
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyProfile lays the named profile over the config, so MergeWithDefaults fills in only what
+// neither the base config nor the profile set. An empty name applies nothing
+func (c *Config) ApplyProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		if len(c.Profiles) == 0 {
+			return fmt.Errorf("profile %q not found: the config defines no [profiles]", name)
+		}
+		return fmt.Errorf("profile %q not found (available: %s)", name, strings.Join(c.ProfileNames(), ", "))
+	}
+	if err := c.decodeProfile(name, profile, c); err != nil {
+		return err
+	}
+	c.Profile = name
+	return nil
+}
+
+// decodeProfile decodes the keys the profile sets onto dst
+func (c *Config) decodeProfile(name string, profile ProfileConfig, dst *Config) error {
+	sections := []struct {
+		key   string
+		value toml.Primitive
+		dst   any
+	}{
+		{"defaults", profile.Defaults, &dst.Defaults},
+		{"task_defaults", profile.TaskDefaults, &dst.TaskDefaults},
+		{"hooks", profile.Hooks, &dst.Hooks},
+	}
+	for _, s := range sections {
+		if !c.meta.IsDefined("profiles", name, s.key) {
+			continue
+		}
+		if err := c.meta.PrimitiveDecode(s.value, s.dst); err != nil {
+			return fmt.Errorf("profile %q: %s: %w", name, s.key, err)
+		}
+	}
+
+	ids := make([]string, 0, len(profile.Tasks))
+	for id := range profile.Tasks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		task, ok := dst.Tasks[id]
+		if !ok {
+			return fmt.Errorf("profile %q sets task %q, which is not defined in [tasks]", name, id)
+		}
+		if err := c.meta.PrimitiveDecode(profile.Tasks[id], &task); err != nil {
+			return fmt.Errorf("profile %q: tasks.%s: %w", name, id, err)
+		}
+		dst.Tasks[id] = task
+	}
+	return nil
+}
+
+// checkProfiles decodes every profile onto empty sections, which reports keys and types the
+// profiles get wrong and marks their keys as decoded for the unknown-field check
+func (c *Config) checkProfiles() error {
+	for _, name := range c.ProfileNames() {
+		scratch := Config{Tasks: make(map[string]TaskConfig, len(c.Tasks))}
+		for id := range c.Tasks {
+			scratch.Tasks[id] = TaskConfig{}
+		}
+		if err := c.decodeProfile(name, c.Profiles[name], &scratch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setByProfile reports whether the active profile sets key, a dotted path such as
+// "defaults.git.mode"
+func (c *Config) setByProfile(key string) bool {
+	if c == nil || c.Profile == "" {
+		return false
+	}
+	return c.meta.IsDefined(append([]string{"profiles", c.Profile}, strings.Split(key, ".")...)...)
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const profileConfig = `
+[defaults]
+outputRoot = ".devpipe"
+fastThreshold = 300
+collapseCompleted = true
+labels = { team = "web" }
+
+[defaults.git]
+mode = "staged_unstaged"
+
+[tasks.lint]
+command = "npm run lint"
+
+[tasks.e2e]
+command = "npm run e2e"
+
+[profiles.ci.defaults]
+outputRoot = "/tmp/ci-output"
+collapseCompleted = false
+labels = { env = "ci" }
+
+[profiles.ci.defaults.git]
+mode = "ref"
+ref = "origin/main"
+
+[profiles.ci.tasks.e2e]
+enabled = false
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestApplyProfile(t *testing.T) {
+	cfg, _, _, _, err := LoadConfig(writeConfig(t, profileConfig))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if err := cfg.ApplyProfile("ci"); err != nil {
+		t.Fatalf("ApplyProfile() error = %v", err)
+	}
+
+	d := cfg.Defaults
+	if d.OutputRoot != "/tmp/ci-output" || d.Git.Mode != "ref" || d.Git.Ref != "origin/main" {
+		t.Errorf("profile values not applied: outputRoot=%q git=%+v", d.OutputRoot, d.Git)
+	}
+	if d.FastThreshold != 300 {
+		t.Errorf("fastThreshold = %d, want the base 300", d.FastThreshold)
+	}
+	if d.CollapseCompleted {
+		t.Error("collapseCompleted = true, want the profile's false")
+	}
+	if d.Labels["team"] != "web" || d.Labels["env"] != "ci" {
+		t.Errorf("labels = %v, want the base and profile labels merged", d.Labels)
+	}
+	if e2e := cfg.Tasks["e2e"]; e2e.Enabled == nil || *e2e.Enabled || e2e.Command != "npm run e2e" {
+		t.Errorf("tasks.e2e = %+v, want disabled with its command kept", e2e)
+	}
+	if cfg.Profile != "ci" {
+		t.Errorf("Profile = %q, want ci", cfg.Profile)
+	}
+
+	merged := MergeWithDefaults(cfg)
+	effective := BuildEffectiveConfig(cfg, &merged, "", "basic", "basic", merged.Defaults.Git.Mode, merged.Defaults.Git.Ref)
+	if effective.Profile != "ci" {
+		t.Errorf("effective Profile = %q, want ci", effective.Profile)
+	}
+	for _, v := range effective.Values {
+		if v.Key == "defaults.outputRoot" && v.Source != "profile" {
+			t.Errorf("outputRoot source = %q, want profile", v.Source)
+		}
+		if v.Key == "defaults.fastThreshold" && v.Source != "config-file" {
+			t.Errorf("fastThreshold source = %q, want config-file", v.Source)
+		}
+	}
+}
+
+func TestApplyProfile_Errors(t *testing.T) {
+	cfg, _, _, _, err := LoadConfig(writeConfig(t, profileConfig))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if err := cfg.ApplyProfile("staging"); err == nil || !strings.Contains(err.Error(), "available: ci") {
+		t.Errorf("ApplyProfile(staging) error = %v, want the available profiles", err)
+	}
+	if err := cfg.ApplyProfile(""); err != nil || cfg.Profile != "" {
+		t.Errorf("ApplyProfile(\"\") = %v, want no profile applied", err)
+	}
+
+	tests := []struct {
+		name    string
+		profile string
+		want    string
+	}{
+		{"unknown field", "[profiles.ci.defaults]\nfastThreshhold = 10\n", "fastThreshhold"},
+		{"wrong type", "[profiles.ci.defaults]\nfastThreshold = \"fast\"\n", "profile \"ci\""},
+		{"unknown task", "[profiles.ci.tasks.deploy]\nenabled = false\n", "task \"deploy\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, _, err := LoadConfig(writeConfig(t, "[tasks.lint]\ncommand = \"lint\"\n"+tt.profile))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadConfig() error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateConfigFile_Profiles(t *testing.T) {
+	result, err := ValidateConfigFile(writeConfig(t, profileConfig))
+	if err != nil {
+		t.Fatalf("ValidateConfigFile() error = %v", err)
+	}
+	if !result.Valid {
+		t.Errorf("valid profiles reported errors: %v", result.Errors)
+	}
+
+	result, err = ValidateConfigFile(writeConfig(t, profileConfig+"\n[profiles.local.defaults]\nuiMode = \"fancy\"\n"))
+	if err != nil {
+		t.Fatalf("ValidateConfigFile() error = %v", err)
+	}
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Field != "profiles.local.defaults.uiMode" {
+		t.Errorf("errors = %v, want one for profiles.local.defaults.uiMode", result.Errors)
+	}
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// StdinPath is the config path that means "read the config from stdin" (--config -)
+const StdinPath = "-"
+
+// Stdin can only be read once, so its content is kept for every later reader
+// (task order extraction, validation, copying the config into the run directory)
+var (
+	stdinReader io.Reader = os.Stdin
+	stdinOnce   sync.Once
+	stdinData   []byte
+	stdinErr    error
+)
+
+// ReadConfigFile returns the raw contents of the config at path, reading stdin when path is StdinPath
+func ReadConfigFile(path string) ([]byte, error) {
+	if path == StdinPath {
+		stdinOnce.Do(func() {
+			stdinData, stdinErr = io.ReadAll(stdinReader)
+		})
+		return stdinData, stdinErr
+	}
+	return os.ReadFile(path)
+}
+
+// DisplayPath names a config path in messages ("stdin" for StdinPath)
+func DisplayPath(path string) string {
+	if path == StdinPath {
+		return "stdin"
+	}
+	return path
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// setStdin makes ReadConfigFile(StdinPath) read content, as if it was piped to devpipe
+func setStdin(t *testing.T, content string) {
+	t.Helper()
+	oldReader := stdinReader
+	stdinReader = strings.NewReader(content)
+	stdinOnce = sync.Once{}
+	t.Cleanup(func() {
+		stdinReader = oldReader
+		stdinOnce = sync.Once{}
+		stdinData, stdinErr = nil, nil
+	})
+}
+
+func TestLoadConfigFromStdin(t *testing.T) {
+	setStdin(t, `
+[tasks.phase-build]
+name = "Build"
+
+[tasks.lint]
+command = "make lint"
+
+[tasks.test]
+command = "make test"
+`)
+
+	cfg, order, phases, taskToPhase, err := LoadConfig(StdinPath)
+	if err != nil {
+		t.Fatalf("LoadConfig(-) error = %v", err)
+	}
+	if len(cfg.Tasks) != 3 {
+		t.Errorf("expected 3 tasks, got %d", len(cfg.Tasks))
+	}
+	if strings.Join(order, ",") != "lint,test" {
+		t.Errorf("task order = %v, want lint,test", order)
+	}
+	if taskToPhase["test"] != "phase-build" || len(phases) != 1 {
+		t.Errorf("expected test in phase-build, got taskToPhase=%v phases=%v", taskToPhase, phases)
+	}
+
+	// Stdin is read once; later readers see the same content
+	data, err := ReadConfigFile(StdinPath)
+	if err != nil || !strings.Contains(string(data), `command = "make test"`) {
+		t.Errorf("ReadConfigFile(-) = %q, %v; want the piped config", data, err)
+	}
+}
+
+func TestValidateConfigFileFromStdin(t *testing.T) {
+	setStdin(t, `
+[tasks.lint]
+command = "make lint"
+bogus = true
+`)
+
+	result, err := ValidateConfigFile(StdinPath)
+	if err != nil {
+		t.Fatalf("ValidateConfigFile(-) error = %v", err)
+	}
+	if result.Valid {
+		t.Errorf("expected the unknown field to make the piped config invalid")
+	}
+}
@@ -1,17 +1,37 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/BurntSushi/toml"
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/drew/devpipe/internal/condition"
+	"github.com/drew/devpipe/internal/logsink"
 )
 
-// ValidationError represents a configuration validation error
+// ValidationError represents a configuration validation error. Line and Column locate the
+// field in the config file (ValidateConfigFile only), or are 0 when unknown
 type ValidationError struct {
-	Field   string
-	Message string
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+}
+
+// undefinedEnvMessage starts the message of problems about an undefined ${VAR} reference
+const undefinedEnvMessage = "Environment variable ${"
+
+// IsUndefinedEnv reports whether e is about a ${VAR} reference to an undefined variable
+func (e ValidationError) IsUndefinedEnv() bool {
+	return strings.HasPrefix(e.Message, undefinedEnvMessage)
 }
 
 func (e ValidationError) Error() string {
@@ -26,6 +46,53 @@ type ValidationResult struct {
 	Valid    bool
 	Errors   []ValidationError
 	Warnings []ValidationError
+	TaskIDs  []string // Sorted ids of the tasks defined in the file (ValidateConfigFile only, excludes phase headers)
+}
+
+// TaskCollision is a task id defined by more than one config file
+type TaskCollision struct {
+	TaskID string
+	Files  []string // In the order given; the last one wins when the files are merged
+}
+
+// FindTaskCollisions returns the task ids defined by more than one of files, sorted by id.
+// taskIDs maps each file to the ids it defines (ValidationResult.TaskIDs).
+func FindTaskCollisions(files []string, taskIDs map[string][]string) []TaskCollision {
+	definedIn := make(map[string][]string)
+	for _, file := range files {
+		for _, id := range taskIDs[file] {
+			definedIn[id] = append(definedIn[id], file)
+		}
+	}
+
+	var collisions []TaskCollision
+	for id, in := range definedIn {
+		if len(in) > 1 {
+			collisions = append(collisions, TaskCollision{TaskID: id, Files: in})
+		}
+	}
+	sort.Slice(collisions, func(i, j int) bool { return collisions[i].TaskID < collisions[j].TaskID })
+	return collisions
+}
+
+// sortByField orders errors and warnings by field, keeping the order of those on the same field,
+// so results are reported the same way every time rather than in map iteration order
+func (r *ValidationResult) sortByField() {
+	for _, list := range [][]ValidationError{r.Errors, r.Warnings} {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].Field < list[j].Field })
+	}
+}
+
+// PromoteWarnings turns every warning into an error, for validate --strict
+func (r *ValidationResult) PromoteWarnings() {
+	for _, w := range r.Warnings {
+		w.Message += " (warning treated as error by --strict)"
+		r.Errors = append(r.Errors, w)
+	}
+	if len(r.Warnings) > 0 {
+		r.Valid = false
+	}
+	r.Warnings = []ValidationError{}
 }
 
 // ValidateConfig validates an already-loaded config
@@ -46,11 +113,24 @@ func ValidateConfig(cfg *Config) (*ValidationResult, error) {
 	// Validate task_defaults section
 	validateTaskDefaults(&cfg.TaskDefaults, result)
 
+	// Validate hooks section
+	validateHooks(&cfg.Hooks, result)
+
 	// Validate tasks
 	for taskID, task := range cfg.Tasks {
 		validateTask(taskID, task, result)
 	}
 
+	// Validate the fileTypeMap section
+	validateFileTypeMap(cfg.FileTypeMap, cfg.Tasks, result)
+
+	// Tasks with an image need the container runtime
+	validateContainerRuntime(cfg, result)
+
+	// Validate ${VAR} references
+	validateEnvRefs(cfg, result)
+
+	result.sortByField()
 	return result, nil
 }
 
@@ -62,31 +142,60 @@ func ValidateConfigFile(path string) (*ValidationResult, error) {
 		Warnings: []ValidationError{},
 	}
 
-	// Check if file exists
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return nil, fmt.Errorf("config file does not exist: %s", path)
+	// Check if file exists (stdin always "exists")
+	if path != StdinPath {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil, fmt.Errorf("config file does not exist: %s", path)
+		}
+	}
+
+	data, err := ReadConfigFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config file %s: %w", DisplayPath(path), err)
 	}
 
 	// Try to parse as TOML first
 	var cfg Config
-	metadata, err := toml.DecodeFile(path, &cfg)
+	metadata, err := toml.Decode(string(data), &cfg)
 	if err != nil {
 		result.Valid = false
-		result.Errors = append(result.Errors, ValidationError{
+		syntaxErr := ValidationError{
 			Message: fmt.Sprintf("Invalid TOML syntax: %v", err),
-		})
+		}
+		if pos, ok := parseErrorPosition(err); ok {
+			syntaxErr.Line, syntaxErr.Column = pos.Line, pos.Column
+		}
+		result.Errors = append(result.Errors, syntaxErr)
 		return result, nil
 	}
 
+	// Decode the profiles before the unknown-field check, which would otherwise list their keys
+	cfg.meta = metadata
+	profilesDecoded := true
+	if err := cfg.checkProfiles(); err != nil {
+		profilesDecoded = false
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "profiles",
+			Message: err.Error(),
+		})
+	}
+
 	// Check for unknown fields
+	positions := keyPositions(string(data))
 	undecoded := metadata.Undecoded()
 	if len(undecoded) > 0 {
 		result.Valid = false
 		for _, key := range undecoded {
-			result.Errors = append(result.Errors, ValidationError{
+			unknown := ValidationError{
 				Field:   key.String(),
 				Message: "Unknown configuration field",
-			})
+			}
+			// Look up the raw parts, since String() quotes the ones that need it
+			if pos, ok := positions[strings.Join(key, ".")]; ok {
+				unknown.Line, unknown.Column = pos.Line, pos.Column
+			}
+			result.Errors = append(result.Errors, unknown)
 		}
 	}
 
@@ -96,11 +205,42 @@ func ValidateConfigFile(path string) (*ValidationResult, error) {
 	// Validate task_defaults section
 	validateTaskDefaults(&cfg.TaskDefaults, result)
 
+	// Validate hooks section
+	validateHooks(&cfg.Hooks, result)
+
 	// Validate tasks
 	for taskID, task := range cfg.Tasks {
 		validateTask(taskID, task, result)
 	}
 
+	// Validate the fileTypeMap section
+	validateFileTypeMap(cfg.FileTypeMap, cfg.Tasks, result)
+
+	// Validate dependsOn and wait markers against the order tasks are defined in
+	if order, _, _, err := extractTaskOrder(path); err == nil {
+		validateDependsOn(cfg.Tasks, order, result)
+		validateWaitMarkers(cfg.Tasks, order, result)
+	}
+
+	// Validate the config each profile produces
+	if profilesDecoded {
+		validateProfiles(string(data), cfg.ProfileNames(), result)
+	}
+
+	// Tasks with an image need the container runtime
+	validateContainerRuntime(&cfg, result)
+
+	// Validate ${VAR} references
+	validateEnvRefs(&cfg, result)
+
+	// Record task ids so callers can cross-check several files
+	for taskID := range cfg.Tasks {
+		if !strings.HasPrefix(taskID, "phase-") && taskID != "wait" && !strings.HasPrefix(taskID, "wait-") {
+			result.TaskIDs = append(result.TaskIDs, taskID)
+		}
+	}
+	sort.Strings(result.TaskIDs)
+
 	// Additional validation: check for phase headers
 	if err := validatePhaseHeaders(path, result); err != nil {
 		result.Warnings = append(result.Warnings, ValidationError{
@@ -108,9 +248,195 @@ func ValidateConfigFile(path string) (*ValidationResult, error) {
 		})
 	}
 
+	result.locate(positions)
+	result.sortByField()
 	return result, nil
 }
 
+// validateProfiles checks the config each profile produces, reporting only the problems the
+// profile adds (the base config's own are already in result) under profiles.<name>
+func validateProfiles(data string, names []string, result *ValidationResult) {
+	known := make(map[ValidationError]bool, len(result.Errors))
+	for _, e := range result.Errors {
+		known[e] = true
+	}
+	for _, name := range names {
+		// Decode afresh for each profile, since applying one changes the config's maps
+		var cfg Config
+		metadata, err := toml.Decode(data, &cfg)
+		if err != nil {
+			return
+		}
+		cfg.meta = metadata
+		if err := cfg.ApplyProfile(name); err != nil {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{Field: "profiles." + name, Message: err.Error()})
+			continue
+		}
+
+		overlaid := &ValidationResult{Valid: true}
+		validateDefaults(&cfg.Defaults, overlaid)
+		validateTaskDefaults(&cfg.TaskDefaults, overlaid)
+		validateHooks(&cfg.Hooks, overlaid)
+		for taskID := range cfg.Profiles[name].Tasks {
+			validateTask(taskID, cfg.Tasks[taskID], overlaid)
+		}
+		for _, e := range overlaid.Errors {
+			if known[e] {
+				continue
+			}
+			e.Field = strings.TrimSuffix("profiles."+name+"."+e.Field, ".")
+			result.Valid = false
+			result.Errors = append(result.Errors, e)
+		}
+	}
+}
+
+// ValidateTaskOrder checks that every dependsOn names a task defined earlier in the config,
+// which also rules out cycles, and that no wait marker reuses the id of one a phase header
+// adds. It is separate from ValidateConfig because the order is only known from the config
+// file, not the decoded config
+func ValidateTaskOrder(cfg *Config, order []string) *ValidationResult {
+	result := &ValidationResult{
+		Valid:    true,
+		Errors:   []ValidationError{},
+		Warnings: []ValidationError{},
+	}
+	if cfg != nil {
+		validateDependsOn(cfg.Tasks, order, result)
+		validateWaitMarkers(cfg.Tasks, order, result)
+	}
+	return result
+}
+
+// validateWaitMarkers rejects a wait marker written in the config whose id is also one of the
+// wait-N markers phase headers add to order, since a written marker is a barrier for dependsOn
+// and the added one is not
+func validateWaitMarkers(tasks map[string]TaskConfig, order []string, result *ValidationResult) {
+	count := make(map[string]int)
+	for _, id := range order {
+		count[id]++
+	}
+	ids := make([]string, 0, len(count))
+	for id, n := range count {
+		if _, written := tasks[id]; written && n > 1 && strings.HasPrefix(id, "wait-") {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "tasks." + id,
+			Message: fmt.Sprintf("Wait marker '%s' has the id of the one a phase header adds; rename it (e.g. to 'wait')", id),
+		})
+	}
+}
+
+// validateDependsOn checks each task's dependsOn against order, the task ids in config order
+func validateDependsOn(tasks map[string]TaskConfig, order []string, result *ValidationResult) {
+	position := make(map[string]int, len(order))
+	for i, id := range order {
+		position[id] = i
+	}
+	for _, taskID := range order {
+		task, ok := tasks[taskID]
+		if !ok {
+			continue // Wait markers inserted for phase headers
+		}
+		for i, dep := range task.DependsOn {
+			field := fmt.Sprintf("tasks.%s.dependsOn[%d]", taskID, i)
+			depTask, defined := tasks[dep]
+			switch {
+			case dep == taskID:
+				result.Valid = false
+				result.Errors = append(result.Errors, ValidationError{
+					Field:   field,
+					Message: "A task cannot depend on itself",
+				})
+			case !defined || strings.HasPrefix(dep, "phase-") || dep == "wait" || strings.HasPrefix(dep, "wait-"):
+				result.Valid = false
+				result.Errors = append(result.Errors, ValidationError{
+					Field:   field,
+					Message: fmt.Sprintf("Unknown task '%s'", dep),
+				})
+			case position[dep] > position[taskID]:
+				result.Valid = false
+				result.Errors = append(result.Errors, ValidationError{
+					Field:   field,
+					Message: fmt.Sprintf("Task '%s' is defined after '%s'; dependsOn must name tasks defined earlier", dep, taskID),
+				})
+			case depTask.Enabled != nil && !*depTask.Enabled:
+				result.Warnings = append(result.Warnings, ValidationError{
+					Field:   field,
+					Message: fmt.Sprintf("Task '%s' is disabled, so this dependency is ignored", dep),
+				})
+			}
+		}
+	}
+}
+
+// ValidateWorkdirs checks that the resolved workdir of each enabled task in taskIDs (every
+// task when taskIDs is nil) exists under projectRoot, unless the task sets createWorkdir. It is
+// separate from ValidateConfig because the project root is only known once the config has been
+// loaded and merged.
+func ValidateWorkdirs(cfg *Config, projectRoot string, taskIDs []string) *ValidationResult {
+	result := &ValidationResult{
+		Valid:    true,
+		Errors:   []ValidationError{},
+		Warnings: []ValidationError{},
+	}
+
+	if cfg == nil {
+		return result
+	}
+
+	ids := append([]string{}, taskIDs...)
+	if taskIDs == nil {
+		for taskID := range cfg.Tasks {
+			ids = append(ids, taskID)
+		}
+	}
+	sort.Strings(ids)
+
+	for _, taskID := range ids {
+		task, ok := cfg.Tasks[taskID]
+		// Phase headers have no command and never run
+		if !ok || strings.HasPrefix(taskID, "phase-") || task.CreateWorkdir {
+			continue
+		}
+
+		resolved := cfg.ResolveTaskConfig(taskID, task, projectRoot)
+		if resolved.Enabled != nil && !*resolved.Enabled {
+			continue
+		}
+
+		info, err := os.Stat(resolved.Workdir)
+		switch {
+		case os.IsNotExist(err):
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   fmt.Sprintf("tasks.%s.workdir", taskID),
+				Message: fmt.Sprintf("Task '%s' workdir does not exist: %s (set createWorkdir = true to create it)", taskID, resolved.Workdir),
+			})
+		case err != nil:
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   fmt.Sprintf("tasks.%s.workdir", taskID),
+				Message: fmt.Sprintf("Task '%s' workdir cannot be accessed: %s: %v", taskID, resolved.Workdir, err),
+			})
+		case !info.IsDir():
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   fmt.Sprintf("tasks.%s.workdir", taskID),
+				Message: fmt.Sprintf("Task '%s' workdir is not a directory: %s", taskID, resolved.Workdir),
+			})
+		}
+	}
+
+	return result
+}
+
 // validateDefaults validates the defaults section
 func validateDefaults(defaults *DefaultsConfig, result *ValidationResult) {
 	// Validate UIMode
@@ -137,6 +463,97 @@ func validateDefaults(defaults *DefaultsConfig, result *ValidationResult) {
 		}
 	}
 
+	// Validate AnimationStyle
+	if defaults.AnimationStyle != "" {
+		validStyles := []string{"dots", "line", "bar", "plain"}
+		if !contains(validStyles, defaults.AnimationStyle) {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "defaults.animationStyle",
+				Message: fmt.Sprintf("Invalid animation style '%s'. Valid options: %s", defaults.AnimationStyle, strings.Join(validStyles, ", ")),
+			})
+		}
+	}
+
+	// Validate EmptyOutput
+	if defaults.EmptyOutput != "" {
+		validPolicies := []string{"warn", "fail", "ignore"}
+		if !contains(validPolicies, defaults.EmptyOutput) {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "defaults.emptyOutput",
+				Message: fmt.Sprintf("Invalid emptyOutput '%s'. Valid options: %s", defaults.EmptyOutput, strings.Join(validPolicies, ", ")),
+			})
+		}
+	}
+
+	// Validate RunIDFormat
+	if defaults.RunIDFormat != "" {
+		validFormats := []string{"timestamp", "hostname", "counter"}
+		if !contains(validFormats, defaults.RunIDFormat) {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "defaults.runIdFormat",
+				Message: fmt.Sprintf("Invalid runIdFormat '%s'. Valid options: %s", defaults.RunIDFormat, strings.Join(validFormats, ", ")),
+			})
+		}
+	}
+
+	// Validate EstimateStat
+	if defaults.EstimateStat != "" {
+		validStats := []string{"mean", "p95", "max"}
+		if !contains(validStats, defaults.EstimateStat) {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "defaults.estimateStat",
+				Message: fmt.Sprintf("Invalid estimateStat '%s'. Valid options: %s", defaults.EstimateStat, strings.Join(validStats, ", ")),
+			})
+		}
+	}
+
+	// Validate Timestamps
+	if defaults.Timestamps != "" {
+		validModes := []string{"off", "clock", "elapsed"}
+		if !contains(validModes, defaults.Timestamps) {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "defaults.timestamps",
+				Message: fmt.Sprintf("Invalid timestamps '%s'. Valid options: %s", defaults.Timestamps, strings.Join(validModes, ", ")),
+			})
+		}
+	}
+
+	// Validate LogFormat
+	if defaults.LogFormat != "" {
+		validFormats := []string{"text", "jsonl"}
+		if !contains(validFormats, defaults.LogFormat) {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "defaults.logFormat",
+				Message: fmt.Sprintf("Invalid logFormat '%s'. Valid options: %s", defaults.LogFormat, strings.Join(validFormats, ", ")),
+			})
+		}
+	}
+
+	// Validate LogSink
+	if defaults.LogSink != "" {
+		if _, err := logsink.Parse(defaults.LogSink); err != nil {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "defaults.logSink",
+				Message: fmt.Sprintf("Invalid logSink: %v", err),
+			})
+		}
+	}
+
+	// Validate LogPrefix (a template without {id} is allowed, but usually a mistake)
+	if defaults.LogPrefix != nil && *defaults.LogPrefix != "" && !strings.Contains(*defaults.LogPrefix, "{id}") {
+		result.Warnings = append(result.Warnings, ValidationError{
+			Field:   "defaults.logPrefix",
+			Message: fmt.Sprintf("logPrefix '%s' has no {id} placeholder, so output lines will not show which task they came from", *defaults.LogPrefix),
+		})
+	}
+
 	// Validate FastThreshold
 	if defaults.FastThreshold < 0 {
 		result.Valid = false
@@ -146,6 +563,117 @@ func validateDefaults(defaults *DefaultsConfig, result *ValidationResult) {
 		})
 	}
 
+	// Validate FlakyThreshold
+	if defaults.FlakyThreshold < 0 {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "defaults.flakyThreshold",
+			Message: "Flaky threshold must be non-negative",
+		})
+	}
+
+	// Validate DashboardRecentLimit
+	if defaults.DashboardRecentLimit < 0 {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "defaults.dashboardRecentLimit",
+			Message: "Dashboard recent limit must be non-negative",
+		})
+	}
+
+	// Validate the log preview lengths
+	if defaults.LogPreviewLines < 0 {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "defaults.logPreviewLines",
+			Message: "Log preview lines must be non-negative",
+		})
+	}
+	if defaults.LogPreviewLinesFailed < 0 {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "defaults.logPreviewLinesFailed",
+			Message: "Log preview lines must be non-negative",
+		})
+	}
+
+	// Validate FailureTailLines
+	if defaults.FailureTailLines != nil && *defaults.FailureTailLines < 0 {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "defaults.failureTailLines",
+			Message: "Failure tail lines must be non-negative (0 disables)",
+		})
+	}
+
+	// Validate HeartbeatSeconds
+	if defaults.HeartbeatSeconds != nil && *defaults.HeartbeatSeconds < 0 {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "defaults.heartbeatSeconds",
+			Message: "Heartbeat seconds must be non-negative (0 disables)",
+		})
+	}
+
+	// Validate MaxLogBytes
+	if defaults.MaxLogBytes < 0 {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "defaults.maxLogBytes",
+			Message: "Max log bytes must be non-negative (0 = unlimited)",
+		})
+	}
+
+	// Validate AdvisoryExitCode
+	if defaults.AdvisoryExitCode < 0 || defaults.AdvisoryExitCode > 255 {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "defaults.advisoryExitCode",
+			Message: "Advisory exit code must be between 0 and 255",
+		})
+	}
+
+	// Validate MaskPatterns
+	for i, pattern := range defaults.MaskPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   fmt.Sprintf("defaults.maskPatterns[%d]", i),
+				Message: fmt.Sprintf("Invalid regular expression %q: %v", pattern, err),
+			})
+		}
+	}
+
+	// Validate MaxParallel
+	if defaults.MaxParallel != nil && *defaults.MaxParallel < 0 {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "defaults.maxParallel",
+			Message: "Max parallel must be non-negative",
+		})
+	}
+
+	// Validate FailFast
+	if defaults.FailFast != "" {
+		validModes := []string{"off", "phase", "task"}
+		if !contains(validModes, defaults.FailFast) {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   "defaults.failFast",
+				Message: fmt.Sprintf("Invalid failFast '%s'. Valid options: %s", defaults.FailFast, strings.Join(validModes, ", ")),
+			})
+		}
+	}
+
+	// Validate Shell (an explicitly empty program can't run anything)
+	if len(defaults.Shell) > 0 && strings.TrimSpace(defaults.Shell[0]) == "" {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   "defaults.shell",
+			Message: "Shell program must not be empty",
+		})
+	}
+
 	// Validate AnimationRefreshMs
 	if defaults.AnimationRefreshMs < 0 {
 		result.Valid = false
@@ -155,14 +683,56 @@ func validateDefaults(defaults *DefaultsConfig, result *ValidationResult) {
 		})
 	}
 
+	validateLabels("defaults.labels", defaults.Labels, result)
+	validateSymbols(defaults.Symbols, result)
+
 	// Validate Git config
 	validateGitConfig(&defaults.Git, result)
 }
 
+// validateLabels rejects blank label keys, which --label could not override
+func validateLabels(field string, labels map[string]string, result *ValidationResult) {
+	for key := range labels {
+		if strings.TrimSpace(key) == "" {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   field,
+				Message: "Label keys must not be empty",
+			})
+		}
+	}
+}
+
+// validateSymbols checks that defaults.symbols only names known statuses and sets non-empty markers
+func validateSymbols(symbols map[string]string, result *ValidationResult) {
+	validNames := []string{"pass", "fail", "warn", "skip", "running", "pending"}
+	names := make([]string, 0, len(symbols))
+	for name := range symbols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		field := "defaults.symbols." + name
+		if !contains(validNames, name) {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   field,
+				Message: fmt.Sprintf("Unknown status '%s'. Valid options: %s", name, strings.Join(validNames, ", ")),
+			})
+		} else if strings.TrimSpace(symbols[name]) == "" {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   field,
+				Message: "Status marker must not be empty",
+			})
+		}
+	}
+}
+
 // validateGitConfig validates git configuration
 func validateGitConfig(git *GitConfig, result *ValidationResult) {
 	if git.Mode != "" {
-		validModes := []string{"staged", "staged_unstaged", "ref"}
+		validModes := []string{"staged", "staged_unstaged", "ref", "branch"}
 		if !contains(validModes, git.Mode) {
 			result.Valid = false
 			result.Errors = append(result.Errors, ValidationError{
@@ -196,6 +766,64 @@ func validateTaskDefaults(taskDefaults *TaskDefaultsConfig, result *ValidationRe
 	}
 }
 
+func validateHooks(hooks *HooksConfig, result *ValidationResult) {
+	stages := []struct {
+		name     string
+		commands []string
+	}{{"before", hooks.Before}, {"after", hooks.After}}
+	for _, stage := range stages {
+		for i, command := range stage.commands {
+			if strings.TrimSpace(command) == "" {
+				result.Valid = false
+				result.Errors = append(result.Errors, ValidationError{
+					Field:   fmt.Sprintf("hooks.%s[%d]", stage.name, i),
+					Message: "Hook command is empty",
+				})
+			}
+		}
+	}
+}
+
+// validateFileTypeMap checks that each fileTypeMap entry names a file type and the task types it
+// selects, and warns about task types no task has
+func validateFileTypeMap(fileTypeMap map[string][]string, tasks map[string]TaskConfig, result *ValidationResult) {
+	taskTypes := map[string]bool{}
+	for _, task := range tasks {
+		taskTypes[strings.ToLower(task.Type)] = true
+	}
+	keys := make([]string, 0, len(fileTypeMap))
+	for key := range fileTypeMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		field := fmt.Sprintf("fileTypeMap.%q", key)
+		if strings.TrimSpace(strings.TrimPrefix(key, ".")) == "" {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   field,
+				Message: "File type must be an extension (e.g. \".go\") or a file name",
+			})
+			continue
+		}
+		if len(fileTypeMap[key]) == 0 {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   field,
+				Message: "File type must map to at least one task type",
+			})
+		}
+		for _, taskType := range fileTypeMap[key] {
+			if !taskTypes[strings.ToLower(taskType)] {
+				result.Warnings = append(result.Warnings, ValidationError{
+					Field:   field,
+					Message: fmt.Sprintf("No task has type '%s'", taskType),
+				})
+			}
+		}
+	}
+}
+
 // validateTask validates a single task configuration
 func validateTask(taskID string, task TaskConfig, result *ValidationResult) {
 	prefix := fmt.Sprintf("tasks.%s", taskID)
@@ -203,7 +831,7 @@ func validateTask(taskID string, task TaskConfig, result *ValidationResult) {
 	// Check if it's a phase header
 	if strings.HasPrefix(taskID, "phase-") {
 		// Phase headers should have name but no command
-		if task.Command != "" {
+		if task.Command != "" || len(task.CommandArgs) > 0 {
 			result.Warnings = append(result.Warnings, ValidationError{
 				Field:   prefix + ".command",
 				Message: "Phase headers should not have a command",
@@ -215,23 +843,86 @@ func validateTask(taskID string, task TaskConfig, result *ValidationResult) {
 				Message: "Phase header should have a name",
 			})
 		}
+		if task.MaxParallel != nil && *task.MaxParallel < 0 {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   prefix + ".maxParallel",
+				Message: "Max parallel must be non-negative",
+			})
+		}
+		if task.Group != "" {
+			result.Warnings = append(result.Warnings, ValidationError{
+				Field:   prefix + ".group",
+				Message: "group only applies to tasks and will be ignored on a phase header",
+			})
+		}
+		if len(task.DependsOn) > 0 {
+			result.Warnings = append(result.Warnings, ValidationError{
+				Field:   prefix + ".dependsOn",
+				Message: "dependsOn only applies to tasks and will be ignored on a phase header",
+			})
+		}
 		return
 	}
 
-	// Regular tasks should have a command
-	if task.Command == "" {
+	// Wait markers only end a phase, and run nothing
+	if taskID == "wait" || strings.HasPrefix(taskID, "wait-") {
+		if task.Command != "" || len(task.CommandArgs) > 0 {
+			result.Warnings = append(result.Warnings, ValidationError{
+				Field:   prefix + ".command",
+				Message: "Wait markers should not have a command",
+			})
+		}
+		return
+	}
+
+	// maxParallel and failFast are only read from phase headers
+	if task.MaxParallel != nil {
+		result.Warnings = append(result.Warnings, ValidationError{
+			Field:   prefix + ".maxParallel",
+			Message: "maxParallel only applies to phase headers and will be ignored",
+		})
+	}
+	if task.FailFast != nil {
+		result.Warnings = append(result.Warnings, ValidationError{
+			Field:   prefix + ".failFast",
+			Message: "failFast only applies to phase headers and will be ignored",
+		})
+	}
+	if task.Barrier {
+		result.Warnings = append(result.Warnings, ValidationError{
+			Field:   prefix + ".barrier",
+			Message: "barrier only applies to phase headers and will be ignored",
+		})
+	}
+
+	// Regular tasks should have exactly one of command and commandArgs
+	switch {
+	case task.Command == "" && len(task.CommandArgs) == 0:
 		result.Valid = false
 		result.Errors = append(result.Errors, ValidationError{
 			Field:   prefix + ".command",
 			Message: "Task must have a command",
 		})
+	case task.Command != "" && len(task.CommandArgs) > 0:
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   prefix + ".commandArgs",
+			Message: "Set either command or commandArgs, not both",
+		})
+	case len(task.CommandArgs) > 0 && strings.TrimSpace(task.CommandArgs[0]) == "":
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   prefix + ".commandArgs",
+			Message: "commandArgs must start with the program to run",
+		})
 	}
 
 	// Note: task.Type is user-defined and can be any string, so we don't validate it
 
 	// Validate outputType if specified
 	if task.OutputType != "" {
-		validFormats := []string{"junit", "sarif", "artifact"}
+		validFormats := []string{"junit", "tap", "sarif", "eslint", "checkstyle", "artifact", "command"}
 		if !contains(validFormats, task.OutputType) {
 			result.Valid = false
 			result.Errors = append(result.Errors, ValidationError{
@@ -249,6 +940,96 @@ func validateTask(taskID string, task TaskConfig, result *ValidationResult) {
 		}
 	}
 
+	if task.LogPreviewLines != nil && *task.LogPreviewLines < 1 {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   prefix + ".logPreviewLines",
+			Message: "Log preview lines must be at least 1",
+		})
+	}
+
+	if task.MaxLogBytes != nil && *task.MaxLogBytes < 0 {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   prefix + ".maxLogBytes",
+			Message: "Max log bytes must be non-negative (0 = unlimited)",
+		})
+	}
+
+	// A command outputType needs the parser to run, which is only used for that type
+	if task.OutputType == "command" && strings.TrimSpace(task.MetricsParser) == "" {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   prefix + ".metricsParser",
+			Message: "metricsParser is required when outputType is command",
+		})
+	} else if task.OutputType != "command" && task.MetricsParser != "" {
+		result.Warnings = append(result.Warnings, ValidationError{
+			Field:   prefix + ".metricsParser",
+			Message: "metricsParser only applies when outputType is command",
+		})
+	}
+
+	// artifactDir must stay inside the run directory
+	if task.ArtifactDir != "" {
+		if !filepath.IsLocal(filepath.FromSlash(task.ArtifactDir)) {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   prefix + ".artifactDir",
+				Message: fmt.Sprintf("artifactDir '%s' must be a relative path inside the run directory", task.ArtifactDir),
+			})
+		} else if task.OutputPath == "" {
+			result.Warnings = append(result.Warnings, ValidationError{
+				Field:   prefix + ".artifactDir",
+				Message: "artifactDir is set but outputPath is not specified, so nothing is copied",
+			})
+		} else if task.CopyArtifacts != nil && !*task.CopyArtifacts {
+			result.Warnings = append(result.Warnings, ValidationError{
+				Field:   prefix + ".artifactDir",
+				Message: "artifactDir is set but copyArtifacts is false, so nothing is copied",
+			})
+		}
+	}
+
+	// Validate SARIF thresholds if specified
+	if task.SarifFailOn != "" {
+		validLevels := []string{"error", "warning", "note"}
+		if !contains(validLevels, task.SarifFailOn) {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   prefix + ".sarifFailOn",
+				Message: fmt.Sprintf("Invalid SARIF level '%s'. Valid options: %s", task.SarifFailOn, strings.Join(validLevels, ", ")),
+			})
+		}
+	}
+	if task.SarifMaxIssues != nil && *task.SarifMaxIssues < 0 {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   prefix + ".sarifMaxIssues",
+			Message: "SARIF max issues must be non-negative",
+		})
+	}
+	if (task.SarifFailOn != "" || task.SarifMaxIssues != nil) && !contains([]string{"sarif", "eslint", "checkstyle"}, task.OutputType) {
+		result.Warnings = append(result.Warnings, ValidationError{
+			Field:   prefix + ".outputType",
+			Message: "sarifFailOn/sarifMaxIssues only apply when outputType is sarif, eslint or checkstyle",
+		})
+	}
+
+	// Validate the output patterns
+	for _, p := range []struct{ field, pattern string }{{"failOnMatch", task.FailOnMatch}, {"passOnMatch", task.PassOnMatch}} {
+		if p.pattern == "" {
+			continue
+		}
+		if _, err := regexp.Compile(p.pattern); err != nil {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   prefix + "." + p.field,
+				Message: fmt.Sprintf("Invalid regular expression %q: %v", p.pattern, err),
+			})
+		}
+	}
+
 	// Validate fixType if specified
 	if task.FixType != "" {
 		validFixTypes := []string{"auto", "helper", "none"}
@@ -270,6 +1051,38 @@ func validateTask(taskID string, task TaskConfig, result *ValidationResult) {
 		}
 	}
 
+	// Validate fixMode
+	if task.FixMode != "" {
+		validFixModes := []string{"before", "after"}
+		if !contains(validFixModes, task.FixMode) {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   prefix + ".fixMode",
+				Message: fmt.Sprintf("Invalid fix mode '%s'. Valid options: %s", task.FixMode, strings.Join(validFixModes, ", ")),
+			})
+		} else if task.FixCommand == "" {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   prefix + ".fixCommand",
+				Message: "fixMode is set but fixCommand is not specified",
+			})
+		} else if task.FixType == "helper" || task.FixType == "none" {
+			result.Warnings = append(result.Warnings, ValidationError{
+				Field:   prefix + ".fixMode",
+				Message: fmt.Sprintf("fixMode only applies when fixType is auto, not %s", task.FixType),
+			})
+		}
+	}
+
+	// outputPath may be a glob matching several report files
+	if task.OutputPath != "" && !doublestar.ValidatePattern(filepath.ToSlash(task.OutputPath)) {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   prefix + ".outputPath",
+			Message: fmt.Sprintf("Invalid outputPath pattern '%s'", task.OutputPath),
+		})
+	}
+
 	// Warn if outputPath is set but outputType is not
 	if task.OutputPath != "" && task.OutputType == "" {
 		result.Warnings = append(result.Warnings, ValidationError{
@@ -278,21 +1091,200 @@ func validateTask(taskID string, task TaskConfig, result *ValidationResult) {
 		})
 	}
 
-	// Validate watchPaths patterns if specified
+	// Validate when condition syntax
+	if task.When != "" {
+		if _, err := condition.Parse(task.When); err != nil {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   prefix + ".when",
+				Message: fmt.Sprintf("Invalid condition '%s': %v", task.When, err),
+			})
+		}
+	}
+
+	// onlyIf and skipIf are paths or globs
+	for _, c := range [][2]string{{"onlyIf", task.OnlyIf}, {"skipIf", task.SkipIf}} {
+		if c[1] != "" && !doublestar.ValidatePattern(filepath.ToSlash(c[1])) {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   prefix + "." + c[0],
+				Message: fmt.Sprintf("Invalid %s pattern '%s'", c[0], c[1]),
+			})
+		}
+	}
+
+	// Validate requiredEnv names, and warn about the ones the current environment lacks
+	for i, name := range task.RequiredEnv {
+		if !isEnvName(name) {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   fmt.Sprintf("%s.requiredEnv[%d]", prefix, i),
+				Message: fmt.Sprintf("Invalid environment variable name '%s'", name),
+			})
+		}
+	}
+	if task.Enabled == nil || *task.Enabled {
+		for _, name := range MissingEnv(task.RequiredEnv, LookupEnv) {
+			if isEnvName(name) {
+				result.Warnings = append(result.Warnings, ValidationError{
+					Field:   prefix + ".requiredEnv",
+					Message: fmt.Sprintf("Environment variable %s is required by this task but not set", name),
+				})
+			}
+		}
+	}
+
+	// Validate cacheInputs patterns (hashed relative to the task workdir)
+	for i, pattern := range task.CacheInputs {
+		if filepath.IsAbs(pattern) || !doublestar.ValidatePattern(pattern) {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   fmt.Sprintf("%s.cacheInputs[%d]", prefix, i),
+				Message: fmt.Sprintf("Invalid cacheInputs pattern '%s': must be a valid glob relative to the task workdir", pattern),
+			})
+		}
+	}
+
+	// A required task must be able to run
+	if task.Required && task.Enabled != nil && !*task.Enabled {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   prefix + ".required",
+			Message: "required = true cannot be combined with enabled = false",
+		})
+	}
+
+	// Validate tags (they are passed as comma-separated CLI values)
+	for i, tag := range task.Tags {
+		if strings.TrimSpace(tag) == "" || strings.ContainsAny(tag, ", \t") {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   fmt.Sprintf("%s.tags[%d]", prefix, i),
+				Message: fmt.Sprintf("Invalid tag '%s': tags must be non-empty and contain no commas or spaces", tag),
+			})
+		}
+	}
+
+	validateLabels(prefix+".labels", task.Labels, result)
+
+	// A group that is only whitespace looks unset but would still serialize tasks
+	if task.Group != "" && strings.TrimSpace(task.Group) == "" {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			Field:   prefix + ".group",
+			Message: "group must not be blank",
+		})
+	}
+
+	// Validate watchPaths patterns if specified ("!" negates a pattern)
+	negations := 0
 	for i, pattern := range task.WatchPaths {
-		if pattern == "" {
+		glob := strings.TrimPrefix(pattern, "!")
+		if glob != pattern {
+			negations++
+		}
+		if glob == "" {
 			result.Warnings = append(result.Warnings, ValidationError{
 				Field:   fmt.Sprintf("%s.watchPaths[%d]", prefix, i),
 				Message: "Empty watchPath pattern will be ignored",
 			})
+			continue
+		}
+		if !doublestar.ValidatePattern(glob) {
+			result.Valid = false
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   fmt.Sprintf("%s.watchPaths[%d]", prefix, i),
+				Message: fmt.Sprintf("Invalid watchPath pattern '%s'", pattern),
+			})
+		}
+	}
+	if negations > 0 && negations == len(task.WatchPaths) {
+		result.Warnings = append(result.Warnings, ValidationError{
+			Field:   fmt.Sprintf("%s.watchPaths", prefix),
+			Message: "watchPaths contains only negation patterns, so no changed file can match and the task will always be skipped",
+		})
+	}
+}
+
+// lookPath finds the container runtime (a variable so tests can stub it)
+var lookPath = exec.LookPath
+
+// validateContainerRuntime warns when enabled tasks set an image but the container runtime is
+// not installed, since each of them would fail
+func validateContainerRuntime(cfg *Config, result *ValidationResult) {
+	var tasks []string
+	for taskID, task := range cfg.Tasks {
+		if task.Image != "" && (task.Enabled == nil || *task.Enabled) {
+			tasks = append(tasks, taskID)
+		}
+	}
+	if len(tasks) == 0 {
+		return
+	}
+	runtime := cfg.Defaults.ContainerRuntime
+	if runtime == "" {
+		runtime = "docker"
+	}
+	if _, err := lookPath(runtime); err != nil {
+		sort.Strings(tasks)
+		result.Warnings = append(result.Warnings, ValidationError{
+			Field:   "defaults.containerRuntime",
+			Message: fmt.Sprintf("Container runtime '%s' is not installed, so tasks with an image will fail: %s", runtime, strings.Join(tasks, ", ")),
+		})
+	}
+}
+
+// validateEnvRefs checks that ${VAR} references in paths and commands are defined.
+// Undefined variables are errors with defaults.strictEnv, warnings otherwise.
+func validateEnvRefs(cfg *Config, result *ValidationResult) {
+	fields := map[string]string{
+		"defaults.projectRoot":  cfg.Defaults.ProjectRoot,
+		"defaults.outputRoot":   cfg.Defaults.OutputRoot,
+		"task_defaults.workdir": cfg.TaskDefaults.Workdir,
+	}
+	for i, command := range cfg.Hooks.Before {
+		fields[fmt.Sprintf("hooks.before[%d]", i)] = command
+	}
+	for i, command := range cfg.Hooks.After {
+		fields[fmt.Sprintf("hooks.after[%d]", i)] = command
+	}
+	for taskID, task := range cfg.Tasks {
+		prefix := fmt.Sprintf("tasks.%s", taskID)
+		fields[prefix+".command"] = task.Command
+		fields[prefix+".commandArgs"] = strings.Join(task.CommandArgs, " ")
+		fields[prefix+".fixCommand"] = task.FixCommand
+		fields[prefix+".workdir"] = task.Workdir
+		fields[prefix+".outputPath"] = task.OutputPath
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, field := range names {
+		_, undefined := ExpandEnv(fields[field], LookupEnv)
+		for _, name := range undefined {
+			if cfg.Defaults.StrictEnv {
+				result.Valid = false
+				result.Errors = append(result.Errors, ValidationError{
+					Field:   field,
+					Message: fmt.Sprintf(undefinedEnvMessage+"%s} is not defined", name),
+				})
+			} else {
+				result.Warnings = append(result.Warnings, ValidationError{
+					Field:   field,
+					Message: fmt.Sprintf(undefinedEnvMessage+"%s} is not defined and will expand to empty", name),
+				})
+			}
 		}
-		// Note: We don't validate glob syntax here as filepath.Match will handle it at runtime
 	}
 }
 
 // validatePhaseHeaders checks that phase headers are properly formatted
 func validatePhaseHeaders(path string, _ *ValidationResult) error {
-	data, err := os.ReadFile(path)
+	data, err := ReadConfigFile(path)
 	if err != nil {
 		return err
 	}
@@ -334,10 +1326,41 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
+// FileValidationResult is one file's entry in the output of validate --json
+type FileValidationResult struct {
+	File     string            `json:"file"`
+	Valid    bool              `json:"valid"`
+	Errors   []ValidationError `json:"errors"`
+	Warnings []ValidationError `json:"warnings"`
+}
+
+// NewFileValidationResult describes result, the validation of the config file at path
+func NewFileValidationResult(path string, result *ValidationResult) FileValidationResult {
+	return FileValidationResult{
+		File:     DisplayPath(path),
+		Valid:    result.Valid,
+		Errors:   append([]ValidationError{}, result.Errors...),
+		Warnings: append([]ValidationError{}, result.Warnings...),
+	}
+}
+
+// WriteValidationJSON writes results to w as a JSON array, one entry per file in the order validated
+func WriteValidationJSON(w io.Writer, results []FileValidationResult) error {
+	if results == nil {
+		results = []FileValidationResult{}
+	}
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
 // PrintValidationResult prints the validation result in a human-readable format
 func PrintValidationResult(path string, result *ValidationResult) {
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Printf("📋 Validating: %s\n", path)
+	fmt.Printf("📋 Validating: %s\n", DisplayPath(path))
 
 	if result.Valid && len(result.Warnings) == 0 {
 		fmt.Println("✅ Configuration is valid!")
@@ -348,11 +1371,7 @@ func PrintValidationResult(path string, result *ValidationResult) {
 	if len(result.Errors) > 0 {
 		fmt.Printf("\n❌ Found %d error(s):\n", len(result.Errors))
 		for _, err := range result.Errors {
-			if err.Field != "" {
-				fmt.Printf("  • [%s] %s\n", err.Field, err.Message)
-			} else {
-				fmt.Printf("  • %s\n", err.Message)
-			}
+			fmt.Printf("  • %s\n", formatProblem(path, err))
 		}
 		fmt.Println()
 	}
@@ -360,11 +1379,7 @@ func PrintValidationResult(path string, result *ValidationResult) {
 	if len(result.Warnings) > 0 {
 		fmt.Printf("⚠️  Found %d warning(s):\n", len(result.Warnings))
 		for _, warn := range result.Warnings {
-			if warn.Field != "" {
-				fmt.Printf("  • [%s] %s\n", warn.Field, warn.Message)
-			} else {
-				fmt.Printf("  • %s\n", warn.Message)
-			}
+			fmt.Printf("  • %s\n", formatProblem(path, warn))
 		}
 		fmt.Println()
 	}
@@ -376,3 +1391,16 @@ func PrintValidationResult(path string, result *ValidationResult) {
 	}
 	fmt.Println()
 }
+
+// formatProblem formats an error or warning for PrintValidationResult, prefixed with
+// file:line:column when its position is known so editors can jump to it
+func formatProblem(path string, problem ValidationError) string {
+	msg := problem.Message
+	if problem.Field != "" {
+		msg = fmt.Sprintf("[%s] %s", problem.Field, problem.Message)
+	}
+	if problem.Line > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s", DisplayPath(path), problem.Line, problem.Column, msg)
+	}
+	return msg
+}
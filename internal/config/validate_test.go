@@ -1,8 +1,12 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -48,6 +52,78 @@ func TestValidateDefaults(t *testing.T) {
 			},
 			wantValid: false,
 		},
+		{
+			name: "invalid animation style",
+			defaults: DefaultsConfig{
+				OutputRoot:     ".devpipe",
+				AnimationStyle: "braille",
+			},
+			wantValid: false,
+		},
+		{
+			name: "unknown status symbol",
+			defaults: DefaultsConfig{
+				OutputRoot: ".devpipe",
+				Symbols:    map[string]string{"passed": "OK"},
+			},
+			wantValid: false,
+		},
+		{
+			name: "empty status symbol",
+			defaults: DefaultsConfig{
+				OutputRoot: ".devpipe",
+				Symbols:    map[string]string{"fail": " "},
+			},
+			wantValid: false,
+		},
+		{
+			name: "invalid empty output policy",
+			defaults: DefaultsConfig{
+				OutputRoot:  ".devpipe",
+				EmptyOutput: "explode",
+			},
+			wantValid: false,
+		},
+		{
+			name: "invalid run ID format",
+			defaults: DefaultsConfig{
+				OutputRoot:  ".devpipe",
+				RunIDFormat: "uuid",
+			},
+			wantValid: false,
+		},
+		{
+			name: "invalid estimate stat",
+			defaults: DefaultsConfig{
+				OutputRoot:   ".devpipe",
+				EstimateStat: "p99",
+			},
+			wantValid: false,
+		},
+		{
+			name: "invalid timestamps mode",
+			defaults: DefaultsConfig{
+				OutputRoot: ".devpipe",
+				Timestamps: "utc",
+			},
+			wantValid: false,
+		},
+		{
+			name: "invalid log format",
+			defaults: DefaultsConfig{
+				OutputRoot: ".devpipe",
+				LogFormat:  "json",
+			},
+			wantValid: false,
+		},
+		{
+			name: "blank shell program",
+			defaults: DefaultsConfig{
+				OutputRoot: ".devpipe",
+				Shell:      []string{" ", "-c"},
+			},
+			wantValid: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -418,6 +494,266 @@ func TestValidateTaskMissingCommand(t *testing.T) {
 	}
 }
 
+func TestValidateTaskCommandArgs(t *testing.T) {
+	tests := []struct {
+		name  string
+		task  TaskConfig
+		valid bool
+	}{
+		{"args only", TaskConfig{CommandArgs: []string{"go", "test", "./..."}}, true},
+		{"both set", TaskConfig{Command: "go test ./...", CommandArgs: []string{"go", "test"}}, false},
+		{"empty program", TaskConfig{CommandArgs: []string{"", "test"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &ValidationResult{Valid: true}
+			validateTask("test", tt.task, result)
+			if result.Valid != tt.valid {
+				t.Errorf("Valid = %v, want %v (errors: %v)", result.Valid, tt.valid, result.Errors)
+			}
+		})
+	}
+}
+
+func TestValidateTaskArtifactDir(t *testing.T) {
+	tests := []struct {
+		name     string
+		dir      string
+		valid    bool
+		warnings int
+	}{
+		{"relative", "artifacts/web", true, 0},
+		{"absolute", "/tmp/artifacts", false, 0},
+		{"escapes run dir", "../artifacts", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &ValidationResult{Valid: true}
+			validateTask("test", TaskConfig{Command: "make", OutputType: "junit", OutputPath: "junit.xml", ArtifactDir: tt.dir}, result)
+			if result.Valid != tt.valid || len(result.Warnings) != tt.warnings {
+				t.Errorf("Valid = %v, warnings = %v; want %v, %d", result.Valid, result.Warnings, tt.valid, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestValidateLabels(t *testing.T) {
+	result := &ValidationResult{Valid: true}
+	validateTask("test", TaskConfig{Command: "make", Labels: map[string]string{"owner": "web"}}, result)
+	if !result.Valid {
+		t.Errorf("Expected labels to be valid, got %v", result.Errors)
+	}
+
+	result = &ValidationResult{Valid: true}
+	validateDefaults(&DefaultsConfig{Labels: map[string]string{" ": "x"}}, result)
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Field != "defaults.labels" {
+		t.Errorf("Expected an error for a blank label key, got %v", result.Errors)
+	}
+}
+
+func TestValidateTaskGroup(t *testing.T) {
+	result := &ValidationResult{Valid: true}
+	validateTask("migrate", TaskConfig{Command: "make migrate", Group: "db"}, result)
+	if !result.Valid || len(result.Warnings) != 0 {
+		t.Errorf("Expected group to be valid, got errors %v, warnings %v", result.Errors, result.Warnings)
+	}
+
+	result = &ValidationResult{Valid: true}
+	validateTask("migrate", TaskConfig{Command: "make migrate", Group: "  "}, result)
+	if result.Valid {
+		t.Error("Expected a blank group to be invalid")
+	}
+
+	result = &ValidationResult{Valid: true}
+	validateTask("phase-test", TaskConfig{Name: "Test", Group: "db"}, result)
+	if len(result.Warnings) != 1 || result.Warnings[0].Field != "tasks.phase-test.group" {
+		t.Errorf("Expected a warning for group on a phase header, got %v", result.Warnings)
+	}
+}
+
+func TestValidateTaskWhen(t *testing.T) {
+	tests := []struct {
+		name      string
+		when      string
+		wantValid bool
+	}{
+		{"no condition", "", true},
+		{"branch condition", "branch == main", true},
+		{"env condition", "env.DEPLOY == true && changedFiles > 0", true},
+		{"single equals", "branch = main", false},
+		{"dangling operator", "branch == main &&", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &ValidationResult{
+				Valid:  true,
+				Errors: []ValidationError{},
+			}
+
+			validateTask("deploy", TaskConfig{Command: "make deploy", When: tt.when}, result)
+
+			if result.Valid != tt.wantValid {
+				t.Errorf("validateTask() valid = %v, want %v, errors: %v", result.Valid, tt.wantValid, result.Errors)
+			}
+		})
+	}
+}
+
+func TestValidateTaskTags(t *testing.T) {
+	tests := []struct {
+		name      string
+		tags      []string
+		wantValid bool
+	}{
+		{"no tags", nil, true},
+		{"valid tags", []string{"fast", "frontend", "team-web"}, true},
+		{"empty tag", []string{"fast", ""}, false},
+		{"tag with comma", []string{"fast,slow"}, false},
+		{"tag with space", []string{"front end"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &ValidationResult{
+				Valid:  true,
+				Errors: []ValidationError{},
+			}
+
+			validateTask("lint", TaskConfig{Command: "eslint .", Tags: tt.tags}, result)
+
+			if result.Valid != tt.wantValid {
+				t.Errorf("validateTask() valid = %v, want %v, errors: %v", result.Valid, tt.wantValid, result.Errors)
+			}
+		})
+	}
+}
+
+func TestValidateTaskCacheInputs(t *testing.T) {
+	tests := []struct {
+		name      string
+		patterns  []string
+		wantValid bool
+	}{
+		{"relative globs", []string{"src/**", "go.mod"}, true},
+		{"absolute path", []string{"/etc/passwd"}, false},
+		{"invalid glob", []string{"src/[.go"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &ValidationResult{
+				Valid:  true,
+				Errors: []ValidationError{},
+			}
+
+			validateTask("build", TaskConfig{Command: "go build ./...", CacheInputs: tt.patterns}, result)
+
+			if result.Valid != tt.wantValid {
+				t.Errorf("validateTask() valid = %v, want %v, errors: %v", result.Valid, tt.wantValid, result.Errors)
+			}
+		})
+	}
+}
+
+func TestValidateTaskWatchPaths(t *testing.T) {
+	tests := []struct {
+		name         string
+		patterns     []string
+		wantValid    bool
+		wantWarnings int
+	}{
+		{"include and negation", []string{"src/**", "!src/**/*.test.js"}, true, 0},
+		{"invalid negated glob", []string{"src/**", "!src/[.js"}, false, 0},
+		{"only negations", []string{"!docs/**"}, true, 1},
+		{"bare negation", []string{"src/**", "!"}, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &ValidationResult{
+				Valid:  true,
+				Errors: []ValidationError{},
+			}
+
+			validateTask("build", TaskConfig{Command: "npm run build", WatchPaths: tt.patterns}, result)
+
+			if result.Valid != tt.wantValid {
+				t.Errorf("validateTask() valid = %v, want %v, errors: %v", result.Valid, tt.wantValid, result.Errors)
+			}
+			if len(result.Warnings) != tt.wantWarnings {
+				t.Errorf("validateTask() warnings = %v, want %d", result.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestValidateTaskRequired(t *testing.T) {
+	enabled, disabled := true, false
+	tests := []struct {
+		name      string
+		enabled   *bool
+		wantValid bool
+	}{
+		{"required", nil, true},
+		{"required and enabled", &enabled, true},
+		{"required but disabled", &disabled, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &ValidationResult{
+				Valid:  true,
+				Errors: []ValidationError{},
+			}
+
+			validateTask("security", TaskConfig{Command: "gosec ./...", Required: true, Enabled: tt.enabled}, result)
+
+			if result.Valid != tt.wantValid {
+				t.Errorf("validateTask() valid = %v, want %v, errors: %v", result.Valid, tt.wantValid, result.Errors)
+			}
+		})
+	}
+}
+
+func TestValidateTaskSarifThresholds(t *testing.T) {
+	negative, zero := -1, 0
+	tests := []struct {
+		name         string
+		task         TaskConfig
+		wantValid    bool
+		wantWarnings int
+	}{
+		{"fail on error", TaskConfig{Command: "gosec", OutputType: "sarif", OutputPath: "out.sarif", SarifFailOn: "error"}, true, 0},
+		{"max issues zero", TaskConfig{Command: "gosec", OutputType: "sarif", OutputPath: "out.sarif", SarifMaxIssues: &zero}, true, 0},
+		{"invalid level", TaskConfig{Command: "gosec", OutputType: "sarif", OutputPath: "out.sarif", SarifFailOn: "critical"}, false, 0},
+		{"negative max issues", TaskConfig{Command: "gosec", OutputType: "sarif", OutputPath: "out.sarif", SarifMaxIssues: &negative}, false, 0},
+		{"not a sarif task", TaskConfig{Command: "go test", SarifFailOn: "error"}, true, 1},
+		{"eslint task", TaskConfig{Command: "eslint -f json .", OutputType: "eslint", OutputPath: "eslint.json", SarifFailOn: "error"}, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &ValidationResult{
+				Valid:    true,
+				Errors:   []ValidationError{},
+				Warnings: []ValidationError{},
+			}
+
+			validateTask("security", tt.task, result)
+
+			if result.Valid != tt.wantValid {
+				t.Errorf("validateTask() valid = %v, want %v, errors: %v", result.Valid, tt.wantValid, result.Errors)
+			}
+			if len(result.Warnings) != tt.wantWarnings {
+				t.Errorf("validateTask() warnings = %d, want %d, warnings: %v", len(result.Warnings), tt.wantWarnings, result.Warnings)
+			}
+		})
+	}
+}
+
 func TestValidateTaskMetricsWarnings(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -611,3 +947,443 @@ command = "go test"`
 		t.Errorf("Expected valid result, got errors: %v", result.Errors)
 	}
 }
+
+func TestValidateWorkdirs(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "web"), 0o755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "notes.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	disabled := false
+
+	tests := []struct {
+		name      string
+		task      TaskConfig
+		wantValid bool
+	}{
+		{"default workdir", TaskConfig{Command: "make"}, true},
+		{"existing subdirectory", TaskConfig{Command: "npm test", Workdir: "web"}, true},
+		{"missing subdirectory", TaskConfig{Command: "npm test", Workdir: "wbe"}, false},
+		{"not a directory", TaskConfig{Command: "npm test", Workdir: "notes.txt"}, false},
+		{"missing but createWorkdir", TaskConfig{Command: "npm test", Workdir: "build/out", CreateWorkdir: true}, true},
+		{"missing but disabled", TaskConfig{Command: "npm test", Workdir: "wbe", Enabled: &disabled}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Tasks: map[string]TaskConfig{"frontend": tt.task}}
+
+			result := ValidateWorkdirs(cfg, root, nil)
+
+			if result.Valid != tt.wantValid {
+				t.Errorf("ValidateWorkdirs() valid = %v, want %v, errors: %v", result.Valid, tt.wantValid, result.Errors)
+			}
+		})
+	}
+
+	// The error names the task and the resolved absolute path
+	result := ValidateWorkdirs(&Config{Tasks: map[string]TaskConfig{"frontend": {Command: "npm test", Workdir: "wbe"}}}, root, nil)
+	if len(result.Errors) != 1 {
+		t.Fatalf("expected one error, got %v", result.Errors)
+	}
+	msg := result.Errors[0].Message
+	if !strings.Contains(msg, "frontend") || !strings.Contains(msg, filepath.Join(root, "wbe")) {
+		t.Errorf("error should name the task and resolved path, got %q", msg)
+	}
+
+	// Only the tasks given are checked
+	cfg := &Config{Tasks: map[string]TaskConfig{
+		"frontend": {Command: "npm test", Workdir: "wbe"},
+		"backend":  {Command: "go test"},
+	}}
+	if result := ValidateWorkdirs(cfg, root, []string{"backend", "unknown"}); !result.Valid {
+		t.Errorf("expected the unselected task to be ignored, got %v", result.Errors)
+	}
+	if result := ValidateWorkdirs(cfg, root, []string{"frontend"}); result.Valid {
+		t.Error("expected an error for the selected task")
+	}
+}
+
+func TestPromoteWarnings(t *testing.T) {
+	result := &ValidationResult{
+		Valid:    true,
+		Errors:   []ValidationError{},
+		Warnings: []ValidationError{{Field: "tasks.lint.watchPaths[0]", Message: "Empty watchPath pattern will be ignored"}},
+	}
+
+	result.PromoteWarnings()
+
+	if result.Valid {
+		t.Errorf("expected a result with warnings to be invalid under --strict")
+	}
+	if len(result.Errors) != 1 || len(result.Warnings) != 0 {
+		t.Fatalf("expected the warning to become an error, got errors=%v warnings=%v", result.Errors, result.Warnings)
+	}
+	if result.Errors[0].Field != "tasks.lint.watchPaths[0]" || !strings.Contains(result.Errors[0].Message, "--strict") {
+		t.Errorf("promoted error = %+v", result.Errors[0])
+	}
+
+	// A clean result stays valid
+	clean := &ValidationResult{Valid: true, Errors: []ValidationError{}, Warnings: []ValidationError{}}
+	clean.PromoteWarnings()
+	if !clean.Valid {
+		t.Errorf("expected a result without warnings to stay valid")
+	}
+}
+
+func TestWriteValidationJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	content := "[tasks.zeta]\n\n[tasks.alpha]\n\n[tasks.mid]\ncommand = \"make\"\nwatchPaths = [\"\"]\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	result, err := ValidateConfigFile(path)
+	if err != nil {
+		t.Fatalf("ValidateConfigFile() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteValidationJSON(&buf, []FileValidationResult{NewFileValidationResult(path, result)}); err != nil {
+		t.Fatalf("WriteValidationJSON() error = %v", err)
+	}
+	var got []struct {
+		File     string `json:"file"`
+		Valid    bool   `json:"valid"`
+		Errors   []struct{ Field, Message string }
+		Warnings []struct{ Field, Message string }
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, buf.String())
+	}
+	if len(got) != 1 || got[0].File != path || got[0].Valid {
+		t.Fatalf("got %+v, want one invalid entry for %s", got, path)
+	}
+	// Errors are ordered by field, not by map iteration
+	var fields []string
+	for _, e := range got[0].Errors {
+		fields = append(fields, e.Field)
+	}
+	if strings.Join(fields, ",") != "tasks.alpha.command,tasks.zeta.command" {
+		t.Errorf("error fields = %v, want alpha before zeta", fields)
+	}
+	if len(got[0].Warnings) != 1 || got[0].Warnings[0].Field != "tasks.mid.watchPaths[0]" {
+		t.Errorf("warnings = %+v, want the empty watchPath", got[0].Warnings)
+	}
+
+	buf.Reset()
+	if err := WriteValidationJSON(&buf, nil); err != nil || strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("WriteValidationJSON(nil) = %q, %v; want an empty array", buf.String(), err)
+	}
+}
+
+func TestFindTaskCollisions(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		return path
+	}
+	backend := write("backend.toml", "[tasks.phase-test]\nname = \"Test\"\n\n[tasks.test]\ncommand = \"go test ./...\"\n\n[tasks.lint]\ncommand = \"golangci-lint run\"\n")
+	frontend := write("frontend.toml", "[tasks.phase-test]\nname = \"Test\"\n\n[tasks.test]\ncommand = \"npm test\"\n")
+
+	files := []string{backend, frontend}
+	taskIDs := make(map[string][]string)
+	for _, file := range files {
+		result, err := ValidateConfigFile(file)
+		if err != nil {
+			t.Fatalf("ValidateConfigFile(%s) error = %v", file, err)
+		}
+		taskIDs[file] = result.TaskIDs
+	}
+	if got := strings.Join(taskIDs[backend], ","); got != "lint,test" {
+		t.Errorf("TaskIDs = %s, want lint,test (phase headers excluded)", got)
+	}
+
+	collisions := FindTaskCollisions(files, taskIDs)
+	if len(collisions) != 1 {
+		t.Fatalf("expected one collision, got %+v", collisions)
+	}
+	if collisions[0].TaskID != "test" || strings.Join(collisions[0].Files, ",") != backend+","+frontend {
+		t.Errorf("collision = %+v, want test in backend then frontend", collisions[0])
+	}
+}
+
+func TestValidateTaskMetricsParser(t *testing.T) {
+	tests := []struct {
+		name     string
+		task     TaskConfig
+		valid    bool
+		warnings int
+	}{
+		{"command with parser", TaskConfig{Command: "make", OutputType: "command", OutputPath: "report.txt", MetricsParser: "./parse-report.sh"}, true, 0},
+		{"command without parser", TaskConfig{Command: "make", OutputType: "command", OutputPath: "report.txt"}, false, 0},
+		{"parser for another type", TaskConfig{Command: "make", OutputType: "junit", OutputPath: "junit.xml", MetricsParser: "./parse-report.sh"}, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &ValidationResult{Valid: true}
+			validateTask("test", tt.task, result)
+			if result.Valid != tt.valid || len(result.Warnings) != tt.warnings {
+				t.Errorf("Valid = %v, warnings = %v; want %v, %d", result.Valid, result.Warnings, tt.valid, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestValidateTaskOutputPathGlob(t *testing.T) {
+	result := &ValidationResult{Valid: true}
+	validateTask("test", TaskConfig{Command: "make", OutputType: "junit", OutputPath: "results/junit-*.xml"}, result)
+	if !result.Valid {
+		t.Errorf("glob outputPath rejected: %v", result.Errors)
+	}
+
+	result = &ValidationResult{Valid: true}
+	validateTask("test", TaskConfig{Command: "make", OutputType: "junit", OutputPath: "results/junit-[.xml"}, result)
+	if result.Valid {
+		t.Error("invalid outputPath pattern accepted")
+	}
+}
+
+func TestValidateMaxLogBytes(t *testing.T) {
+	negative := int64(-1)
+	result := &ValidationResult{Valid: true}
+	validateTask("test", TaskConfig{Command: "make", MaxLogBytes: &negative}, result)
+	if result.Valid {
+		t.Error("negative task maxLogBytes accepted")
+	}
+
+	limit := int64(0)
+	result = &ValidationResult{Valid: true}
+	validateTask("test", TaskConfig{Command: "make", MaxLogBytes: &limit}, result)
+	if !result.Valid || len(result.Warnings) != 0 {
+		t.Errorf("maxLogBytes = 0 rejected: %v %v", result.Errors, result.Warnings)
+	}
+}
+
+func TestValidateMaskPatterns(t *testing.T) {
+	result := &ValidationResult{Valid: true}
+	validateDefaults(&DefaultsConfig{MaskPatterns: []string{`token=(\S+)`, "("}}, result)
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Field != "defaults.maskPatterns[1]" {
+		t.Errorf("Expected one error for the invalid pattern, got %v", result.Errors)
+	}
+}
+
+func TestValidateOutputMatchPatterns(t *testing.T) {
+	result := &ValidationResult{Valid: true}
+	validateTask("scan", TaskConfig{Command: "scan", FailOnMatch: "^ERROR:", PassOnMatch: "[0-9"}, result)
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Field != "tasks.scan.passOnMatch" {
+		t.Errorf("Expected one error for the invalid passOnMatch, got %v", result.Errors)
+	}
+}
+
+func TestValidateLogPreviewLines(t *testing.T) {
+	result := &ValidationResult{Valid: true}
+	validateDefaults(&DefaultsConfig{LogPreviewLines: 20, LogPreviewLinesFailed: -1}, result)
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Field != "defaults.logPreviewLinesFailed" {
+		t.Errorf("Expected one error for the negative logPreviewLinesFailed, got %v", result.Errors)
+	}
+
+	zero := 0
+	result = &ValidationResult{Valid: true}
+	validateTask("test", TaskConfig{Command: "make", LogPreviewLines: &zero}, result)
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Field != "tasks.test.logPreviewLines" {
+		t.Errorf("Expected one error for logPreviewLines = 0, got %v", result.Errors)
+	}
+}
+
+func TestValidateDashboardRecentLimit(t *testing.T) {
+	result := &ValidationResult{Valid: true}
+	validateDefaults(&DefaultsConfig{DashboardRecentLimit: -5}, result)
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Field != "defaults.dashboardRecentLimit" {
+		t.Errorf("Expected one error for the negative dashboardRecentLimit, got %v", result.Errors)
+	}
+	if merged := MergeWithDefaults(&Config{}); merged.Defaults.DashboardRecentLimit != 100 {
+		t.Errorf("DashboardRecentLimit = %d, want the default of 100", merged.Defaults.DashboardRecentLimit)
+	}
+}
+
+func TestValidateFailureTailLines(t *testing.T) {
+	negative := -1
+	result := &ValidationResult{Valid: true}
+	validateDefaults(&DefaultsConfig{FailureTailLines: &negative}, result)
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Field != "defaults.failureTailLines" {
+		t.Errorf("Expected one error for the negative failureTailLines, got %v", result.Errors)
+	}
+	if merged := MergeWithDefaults(&Config{}); *merged.Defaults.FailureTailLines != 20 {
+		t.Errorf("FailureTailLines = %d, want the default of 20", *merged.Defaults.FailureTailLines)
+	}
+}
+
+func TestValidateFileTypeMap(t *testing.T) {
+	tasks := map[string]TaskConfig{"unit": {Command: "go test ./...", Type: "Test"}}
+	result := &ValidationResult{Valid: true}
+	validateFileTypeMap(map[string][]string{".go": {"test", "build"}, ".": {"test"}, ".md": {}}, tasks, result)
+	if result.Valid || len(result.Errors) != 2 {
+		t.Errorf("Expected errors for the empty file type and task types, got %v", result.Errors)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0].Message, "build") {
+		t.Errorf("Expected one warning for the unknown build type, got %v", result.Warnings)
+	}
+}
+
+func TestValidateArtifactDirWithoutCopy(t *testing.T) {
+	result := &ValidationResult{Valid: true}
+	validateTask("test", TaskConfig{Command: "make", OutputType: "artifact", OutputPath: "dist/app", ArtifactDir: "build", CopyArtifacts: boolPtr(false)}, result)
+	if !result.Valid || len(result.Warnings) != 1 || result.Warnings[0].Field != "tasks.test.artifactDir" {
+		t.Errorf("Expected one artifactDir warning, got errors %v, warnings %v", result.Errors, result.Warnings)
+	}
+}
+
+func TestValidateLogSink(t *testing.T) {
+	for sink, valid := range map[string]bool{"syslog://logs:514": true, "file:///tmp/devpipe.jsonl": true, "kafka://logs": false} {
+		result := &ValidationResult{Valid: true}
+		validateDefaults(&DefaultsConfig{LogSink: sink}, result)
+		if result.Valid != valid {
+			t.Errorf("logSink %q: Valid = %v, want %v (errors: %v)", sink, result.Valid, valid, result.Errors)
+		}
+	}
+}
+
+func TestValidateFixMode(t *testing.T) {
+	tests := []struct {
+		name       string
+		task       TaskConfig
+		wantValid  bool
+		wantWarned bool
+	}{
+		{"before with a fix", TaskConfig{Command: "gofmt -l .", FixType: "auto", FixMode: "before", FixCommand: "gofmt -w ."}, true, false},
+		{"unknown mode", TaskConfig{Command: "gofmt -l .", FixType: "auto", FixMode: "always", FixCommand: "gofmt -w ."}, false, false},
+		{"no fixCommand", TaskConfig{Command: "gofmt -l .", FixMode: "before"}, false, false},
+		{"helper fix", TaskConfig{Command: "gofmt -l .", FixType: "helper", FixMode: "before", FixCommand: "gofmt -w ."}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &ValidationResult{Valid: true}
+			validateTask("fmt", tt.task, result)
+			if result.Valid != tt.wantValid || (len(result.Warnings) > 0) != tt.wantWarned {
+				t.Errorf("Valid = %v, warnings %v; want valid %v, warned %v (errors: %v)", result.Valid, result.Warnings, tt.wantValid, tt.wantWarned, result.Errors)
+			}
+		})
+	}
+}
+
+func TestValidateAdvisoryExitCode(t *testing.T) {
+	for code, valid := range map[int]bool{0: true, 4: true, 255: true, -1: false, 256: false} {
+		result := &ValidationResult{Valid: true}
+		validateDefaults(&DefaultsConfig{AdvisoryExitCode: code}, result)
+		if result.Valid != valid {
+			t.Errorf("advisoryExitCode = %d: valid = %v, want %v (%v)", code, result.Valid, valid, result.Errors)
+		}
+	}
+}
+
+func TestValidateDependsOn(t *testing.T) {
+	disabled := false
+	tasks := map[string]TaskConfig{
+		"phase-build": {Name: "Build"},
+		"compile":     {Command: "make"},
+		"old":         {Command: "make old", Enabled: &disabled},
+		"lint":        {Command: "make lint", DependsOn: []string{"compile", "lint", "missing", "phase-build", "unit"}},
+		"unit":        {Command: "make test", DependsOn: []string{"old"}},
+	}
+	result := &ValidationResult{Valid: true}
+	validateDependsOn(tasks, []string{"phase-build", "compile", "old", "wait-3", "lint", "unit"}, result)
+
+	var fields []string
+	for _, e := range result.Errors {
+		fields = append(fields, e.Field)
+	}
+	want := []string{"tasks.lint.dependsOn[1]", "tasks.lint.dependsOn[2]", "tasks.lint.dependsOn[3]", "tasks.lint.dependsOn[4]"}
+	if result.Valid || !reflect.DeepEqual(fields, want) {
+		t.Errorf("errors = %v, want %v", fields, want)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Field != "tasks.unit.dependsOn[0]" {
+		t.Errorf("Expected a warning for the disabled dependency, got %v", result.Warnings)
+	}
+}
+
+func TestValidateWaitMarkers(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// The wait-1 written after the build phase collides with the one the test phase header adds
+	colliding := write("colliding.toml", "[tasks.phase-build]\n\n[tasks.compile]\ncommand = \"make\"\n\n[tasks.wait-1]\n\n[tasks.phase-test]\n\n[tasks.unit]\ncommand = \"make test\"\n")
+	result, err := ValidateConfigFile(colliding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Field != "tasks.wait-1" {
+		t.Errorf("errors = %v, want one for tasks.wait-1", result.Errors)
+	}
+
+	distinct := write("distinct.toml", strings.Replace(string(mustRead(t, colliding)), "wait-1", "wait-build", 1))
+	if result, err := ValidateConfigFile(distinct); err != nil || !result.Valid {
+		t.Errorf("expected a renamed marker to be valid, got %v %v", err, result.Errors)
+	}
+}
+
+func mustRead(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestValidateContainerRuntime(t *testing.T) {
+	defer func(orig func(string) (string, error)) { lookPath = orig }(lookPath)
+	installed := map[string]bool{"podman": true}
+	lookPath = func(name string) (string, error) {
+		if installed[name] {
+			return "/usr/bin/" + name, nil
+		}
+		return "", os.ErrNotExist
+	}
+
+	disabled := false
+	cfg := &Config{Tasks: map[string]TaskConfig{
+		"lint":  {Command: "npm run lint", Image: "node:20"},
+		"old":   {Command: "make", Image: "golang:1.21", Enabled: &disabled},
+		"build": {Command: "make"},
+	}}
+	result := &ValidationResult{Valid: true}
+	validateContainerRuntime(cfg, result)
+	if !result.Valid || len(result.Warnings) != 1 || !strings.HasSuffix(result.Warnings[0].Message, "will fail: lint") {
+		t.Errorf("Expected one warning naming lint, got %v", result.Warnings)
+	}
+
+	cfg.Defaults.ContainerRuntime = "podman"
+	result = &ValidationResult{Valid: true}
+	validateContainerRuntime(cfg, result)
+	if len(result.Warnings) != 0 {
+		t.Errorf("Expected no warnings with an installed runtime, got %v", result.Warnings)
+	}
+}
+
+func TestValidateHooks(t *testing.T) {
+	result := &ValidationResult{Valid: true}
+	validateHooks(&HooksConfig{Before: []string{"docker compose up -d"}, After: []string{"docker compose down", " "}}, result)
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Field != "hooks.after[1]" {
+		t.Errorf("errors = %+v, want only the empty after hook", result.Errors)
+	}
+}
+
+func TestValidateTaskFileConditions(t *testing.T) {
+	result := &ValidationResult{Valid: true}
+	validateTask("upload", TaskConfig{Command: "make upload", OnlyIf: "coverage.out", SkipIf: "dist/[.skip"}, result)
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Field != "tasks.upload.skipIf" {
+		t.Errorf("errors = %+v, want only the invalid skipIf pattern", result.Errors)
+	}
+}
@@ -0,0 +1,60 @@
+// Package container builds the commands that run tasks inside a container image.
+package container
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// DefaultRuntime runs containers when defaults.containerRuntime is not set
+const DefaultRuntime = "docker"
+
+// WorkDir is where the task's workdir is mounted inside the container
+const WorkDir = "/work"
+
+// Spec describes one container run
+type Spec struct {
+	Runtime string   // CLI with a docker-compatible run command: docker, podman, nerdctl
+	Image   string   // Image to run, e.g. node:20
+	Name    string   // Container name, so a cancelled run can be removed
+	Workdir string   // Host directory mounted at WorkDir
+	Env     []string // Names of host environment variables passed through
+	Command string   // Run with sh -c, unless Args is set
+	Args    []string // Program and arguments run directly
+}
+
+// RunArgs returns the arguments for Runtime that run the spec, removing the container when it exits
+func RunArgs(spec Spec) []string {
+	args := []string{"run", "--rm", "--name", spec.Name, "-v", spec.Workdir + ":" + WorkDir, "-w", WorkDir}
+	for _, name := range spec.Env {
+		args = append(args, "-e", name) // Without a value the runtime copies it from its own environment
+	}
+	args = append(args, spec.Image)
+	if len(spec.Args) > 0 {
+		return append(args, spec.Args...)
+	}
+	return append(args, "sh", "-c", spec.Command)
+}
+
+// RemoveArgs returns the arguments for Runtime that stop and remove the named container. Killing
+// the runtime's CLI does not stop the container it started, so cancelled runs use this
+func RemoveArgs(name string) []string {
+	return []string{"rm", "-f", name}
+}
+
+// NewName returns a container name for taskID with a random suffix, since the same task may
+// run in several devpipe processes (or as a task and then its fix) at once
+func NewName(taskID string) string {
+	var b strings.Builder
+	for _, r := range taskID {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '.' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return "devpipe-" + b.String() + "-" + hex.EncodeToString(suffix)
+}
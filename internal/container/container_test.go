@@ -0,0 +1,40 @@
+package container
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestRunArgs(t *testing.T) {
+	spec := Spec{
+		Runtime: "docker",
+		Image:   "node:20",
+		Name:    "devpipe-lint-0a1b2c3d",
+		Workdir: "/src/web",
+		Env:     []string{"FORCE_COLOR", "NPM_TOKEN"},
+		Command: "npm run lint && npm test",
+	}
+	want := []string{"run", "--rm", "--name", "devpipe-lint-0a1b2c3d", "-v", "/src/web:/work", "-w", "/work",
+		"-e", "FORCE_COLOR", "-e", "NPM_TOKEN", "node:20", "sh", "-c", "npm run lint && npm test"}
+	if got := RunArgs(spec); !reflect.DeepEqual(got, want) {
+		t.Errorf("RunArgs() = %v, want %v", got, want)
+	}
+
+	spec.Env = nil
+	spec.Args = []string{"go", "test", "./..."}
+	want = []string{"run", "--rm", "--name", "devpipe-lint-0a1b2c3d", "-v", "/src/web:/work", "-w", "/work", "node:20", "go", "test", "./..."}
+	if got := RunArgs(spec); !reflect.DeepEqual(got, want) {
+		t.Errorf("RunArgs() with Args = %v, want %v", got, want)
+	}
+}
+
+func TestNewName(t *testing.T) {
+	name := NewName("unit tests/api")
+	if !regexp.MustCompile(`^devpipe-unit-tests-api-[0-9a-f]{8}$`).MatchString(name) {
+		t.Errorf("NewName() = %q, want a sanitized id and random suffix", name)
+	}
+	if NewName("lint") == NewName("lint") {
+		t.Error("NewName() returned the same name twice")
+	}
+}
@@ -20,6 +20,7 @@ type Summary struct {
 	TaskStats       map[string]TaskStats `json:"taskStats"`       // All runs
 	TaskStatsRecent map[string]TaskStats `json:"taskStatsRecent"` // Most recent run only
 	TaskStatsLast25 map[string]TaskStats `json:"taskStatsLast25"` // Last 25 runs
+	FlakyTests      []FlakyTest          `json:"flakyTests"`      // Tests that flip pass/fail in the last 25 runs
 	LastGenerated   string               `json:"lastGenerated"`
 	Username        string               `json:"username"`
 	Greeting        string               `json:"greeting"`
@@ -36,8 +37,10 @@ type RunSummary struct {
 	FailCount       int    `json:"failCount"`
 	SkipCount       int    `json:"skipCount"`
 	TotalTasks      int    `json:"totalTasks"`
-	Command         string `json:"command"`         // Full command line that was executed
-	PipelineVersion string `json:"pipelineVersion"` // devpipe version used to run the pipeline
+	Command         string `json:"command"`          // Full command line that was executed
+	PipelineVersion string `json:"pipelineVersion"`  // devpipe version used to run the pipeline
+	Commit          string `json:"commit,omitempty"` // HEAD commit the run checked
+	Branch          string `json:"branch,omitempty"` // Branch checked out ("" on a detached HEAD)
 }
 
 // TaskStats holds statistics for a specific task across runs
@@ -49,9 +52,15 @@ type TaskStats struct {
 	FailCount   int     `json:"failCount"`
 	SkipCount   int     `json:"skipCount"`
 	AvgDuration float64 `json:"avgDuration"`
+	P50Duration int64   `json:"p50Duration"` // Median duration in ms (non-skipped runs)
+	P95Duration int64   `json:"p95Duration"` // 95th percentile duration in ms (non-skipped runs)
+	MaxDuration int64   `json:"maxDuration"` // Longest duration in ms (non-skipped runs)
 	LastStatus  string  `json:"lastStatus"`
 }
 
+// DefaultRecentLimit is how many of the most recent runs the dashboard lists and generates report pages for
+const DefaultRecentLimit = 100
+
 // GenerateDashboard reads all runs and generates summary.json and report.html
 func GenerateDashboard(outputRoot string) error {
 	return GenerateDashboardWithVersion(outputRoot, "dev")
@@ -59,11 +68,15 @@ func GenerateDashboard(outputRoot string) error {
 
 // GenerateDashboardWithVersion generates dashboard with version info
 func GenerateDashboardWithVersion(outputRoot, version string) error {
-	return GenerateDashboardWithOptions(outputRoot, version, false, "")
+	return GenerateDashboardWithOptions(outputRoot, version, false, DefaultRecentLimit, "", DefaultFlakyThreshold)
 }
 
-// GenerateDashboardWithOptions generates dashboard with full control
-func GenerateDashboardWithOptions(outputRoot, version string, regenerateAll bool, currentRunID string) error {
+// GenerateDashboardWithOptions generates dashboard with full control.
+// recentLimit is how many of the most recent runs are listed and get report pages (0 = all):
+// of those, regenerateAll regenerates every page, otherwise only the current run's and those
+// never generated. Older runs keep whatever pages they have.
+// flakyThreshold is the minimum number of pass/fail flips for a test to be listed as flaky.
+func GenerateDashboardWithOptions(outputRoot, version string, regenerateAll bool, recentLimit int, currentRunID string, flakyThreshold int) error {
 	runsDir := filepath.Join(outputRoot, "runs")
 
 	// Read all run.json files
@@ -72,13 +85,10 @@ func GenerateDashboardWithOptions(outputRoot, version string, regenerateAll bool
 		return fmt.Errorf("failed to load runs: %w", err)
 	}
 
-	// Aggregate data
-	summary := aggregateRuns(runs, version)
-
-	// Write summary.json
-	summaryPath := filepath.Join(outputRoot, "summary.json")
-	if err := writeSummaryJSON(summaryPath, summary); err != nil {
-		return fmt.Errorf("failed to write summary.json: %w", err)
+	// Aggregate data and write summary.json
+	summary, err := writeSummary(outputRoot, runs, version, recentLimit, flakyThreshold)
+	if err != nil {
+		return err
 	}
 
 	// Copy mascot image to output directory
@@ -94,10 +104,10 @@ func GenerateDashboardWithOptions(outputRoot, version string, regenerateAll bool
 	}
 
 	// Generate individual run detail pages
-	for _, run := range runs {
-		// Skip report generation for existing runs unless regenerateAll is true
-		// or this is the current run
-		if !regenerateAll && run.RunID != currentRunID && run.ReportVersion != "" {
+	for i, run := range runs {
+		// Leave runs beyond the limit alone, and skip report generation for existing runs
+		// unless regenerateAll is true or this is the current run
+		if run.RunID != currentRunID && (recentLimit > 0 && i >= recentLimit || !regenerateAll && run.ReportVersion != "") {
 			continue
 		}
 
@@ -109,21 +119,28 @@ func GenerateDashboardWithOptions(outputRoot, version string, regenerateAll bool
 			fmt.Fprintf(os.Stderr, "WARNING: failed to update run.json for run %s: %v\n", run.RunID, err)
 		}
 
-		// Generate run detail HTML
-		detailPath := filepath.Join(runDir, "report.html")
-		if err := writeRunDetailHTML(detailPath, run); err != nil {
+		if err := writeRunPages(runDir, run); err != nil {
 			// Don't fail if one detail page fails, but log it
 			fmt.Fprintf(os.Stderr, "WARNING: failed to generate report for run %s: %v\n", run.RunID, err)
-			continue
 		}
+	}
 
-		// Generate IDE viewer HTML with embedded file list
-		idePath := filepath.Join(runDir, "ide.html")
-		if err := writeIDEViewer(idePath, run.RunID, runDir); err != nil {
-			fmt.Fprintf(os.Stderr, "WARNING: failed to generate IDE for run %s: %v\n", run.RunID, err)
-		}
+	return nil
+}
+
+// writeRunPages writes a run's report.html and its IDE viewer into runDir. It fails only
+// when the report cannot be written; an IDE viewer error is printed as a warning
+func writeRunPages(runDir string, run model.RunRecord) error {
+	// Generate run detail HTML
+	if err := writeRunDetailHTML(filepath.Join(runDir, "report.html"), run); err != nil {
+		return err
 	}
 
+	// Generate IDE viewer HTML with embedded file list
+	idePath := filepath.Join(runDir, "ide.html")
+	if err := writeIDEViewer(idePath, run.RunID, runDir, run.Tasks); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: failed to generate IDE for run %s: %v\n", run.RunID, err)
+	}
 	return nil
 }
 
@@ -144,17 +161,59 @@ func writeRunJSON(path string, run model.RunRecord) error {
 	return encoder.Encode(run)
 }
 
+// LoadRuns reads every run under outputRoot/runs, newest first
+func LoadRuns(outputRoot string) ([]model.RunRecord, error) {
+	return loadAllRuns(filepath.Join(outputRoot, "runs"))
+}
+
+// ReindexResult describes a Reindex
+type ReindexResult struct {
+	Runs       int     // Runs indexed
+	Unreadable []error // One per run.json that could not be read or parsed, and was skipped
+}
+
+// Reindex rebuilds summary.json from the run.json files under outputRoot, without
+// regenerating any HTML
+func Reindex(outputRoot, version string, recentLimit, flakyThreshold int) (ReindexResult, error) {
+	runs, unreadable, err := scanRuns(filepath.Join(outputRoot, "runs"))
+	if err != nil {
+		return ReindexResult{}, fmt.Errorf("failed to load runs: %w", err)
+	}
+	if _, err := writeSummary(outputRoot, runs, version, recentLimit, flakyThreshold); err != nil {
+		return ReindexResult{}, err
+	}
+	return ReindexResult{Runs: len(runs), Unreadable: unreadable}, nil
+}
+
+// writeSummary aggregates runs and writes them to outputRoot's summary.json
+func writeSummary(outputRoot string, runs []model.RunRecord, version string, recentLimit, flakyThreshold int) (Summary, error) {
+	summary := aggregateRuns(runs, version, recentLimit, flakyThreshold)
+	if err := writeSummaryJSON(filepath.Join(outputRoot, "summary.json"), summary); err != nil {
+		return Summary{}, fmt.Errorf("failed to write summary.json: %w", err)
+	}
+	return summary, nil
+}
+
 // loadAllRuns reads all run.json files from the runs directory
 func loadAllRuns(runsDir string) ([]model.RunRecord, error) {
+	runs, _, err := scanRuns(runsDir)
+	return runs, err
+}
+
+// scanRuns reads all run.json files from the runs directory, newest first. Run directories
+// without a run.json (e.g. a run still in progress) are left out; run.json files that cannot
+// be read or parsed are left out and returned as errors naming the file
+func scanRuns(runsDir string) ([]model.RunRecord, []error, error) {
 	entries, err := os.ReadDir(runsDir)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return []model.RunRecord{}, nil
+			return []model.RunRecord{}, nil, nil
 		}
-		return nil, err
+		return nil, nil, err
 	}
 
 	var runs []model.RunRecord
+	var unreadable []error
 	for _, entry := range entries {
 		if !entry.IsDir() {
 			continue
@@ -163,12 +222,16 @@ func loadAllRuns(runsDir string) ([]model.RunRecord, error) {
 		runJSONPath := filepath.Join(runsDir, entry.Name(), "run.json")
 		data, err := os.ReadFile(runJSONPath)
 		if err != nil {
-			continue // Skip if can't read
+			if !os.IsNotExist(err) {
+				unreadable = append(unreadable, err)
+			}
+			continue
 		}
 
 		var run model.RunRecord
 		if err := json.Unmarshal(data, &run); err != nil {
-			continue // Skip if can't parse
+			unreadable = append(unreadable, fmt.Errorf("%s: %w", runJSONPath, err))
+			continue
 		}
 
 		runs = append(runs, run)
@@ -179,11 +242,11 @@ func loadAllRuns(runsDir string) ([]model.RunRecord, error) {
 		return runs[i].Timestamp > runs[j].Timestamp
 	})
 
-	return runs, nil
+	return runs, unreadable, nil
 }
 
-// aggregateRuns creates a summary from all runs
-func aggregateRuns(runs []model.RunRecord, version string) Summary {
+// aggregateRuns creates a summary from all runs, listing the recentLimit most recent (0 = all)
+func aggregateRuns(runs []model.RunRecord, version string, recentLimit, flakyThreshold int) Summary {
 	// Get username
 	username := os.Getenv("USER")
 	if username == "" {
@@ -220,32 +283,39 @@ func aggregateRuns(runs []model.RunRecord, version string) Summary {
 		Version:         version,
 	}
 
-	// Add recent runs (limit to 100 for pagination)
+	// Add recent runs (the dashboard paginates them)
 	for i, run := range runs {
-		if i < 100 {
+		if recentLimit <= 0 || i < recentLimit {
 			runSummary := summarizeRun(run)
 			summary.RecentRuns = append(summary.RecentRuns, runSummary)
 		}
 	}
 
 	// Calculate task stats for different ranges
-	summary.TaskStats = calculateTaskStats(runs, len(runs))                   // All runs
-	summary.TaskStatsRecent = calculateTaskStats(runs, 1)                     // Most recent run
-	summary.TaskStatsLast25 = calculateTaskStats(runs, minInt(25, len(runs))) // Last 25 runs
+	summary.TaskStats = ComputeTaskStats(runs, len(runs))                   // All runs
+	summary.TaskStatsRecent = ComputeTaskStats(runs, 1)                     // Most recent run
+	summary.TaskStatsLast25 = ComputeTaskStats(runs, minInt(25, len(runs))) // Last 25 runs
+	summary.FlakyTests = ComputeFlakyTests(runs, 25, flakyThreshold)
 
 	return summary
 }
 
-// calculateTaskStats aggregates task statistics for a given number of recent runs
-func calculateTaskStats(runs []model.RunRecord, numRuns int) map[string]TaskStats {
+// ComputeTaskStats aggregates per-task statistics over the most recent window runs.
+// runs must be sorted newest first (as returned by LoadRuns).
+func ComputeTaskStats(runs []model.RunRecord, window int) map[string]TaskStats {
 	taskStats := make(map[string]TaskStats)
 	taskDurations := make(map[string][]int64)
 
 	// Process only the specified number of runs
-	for i := 0; i < numRuns && i < len(runs); i++ {
+	for i := 0; i < window && i < len(runs); i++ {
 		run := runs[i]
 
 		for _, task := range run.Tasks {
+			// Results reused by --resume were already counted in the run they came from
+			if task.CachedFrom != "" {
+				continue
+			}
+
 			stats, exists := taskStats[task.ID]
 			if !exists {
 				stats = TaskStats{
@@ -278,15 +348,20 @@ func calculateTaskStats(runs []model.RunRecord, numRuns int) map[string]TaskStat
 		}
 	}
 
-	// Calculate average durations
+	// Calculate average and percentile durations
 	for id, durations := range taskDurations {
 		if len(durations) > 0 {
 			var sum int64
 			for _, d := range durations {
 				sum += d
 			}
+			sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
 			stats := taskStats[id]
 			stats.AvgDuration = float64(sum) / float64(len(durations))
+			stats.P50Duration = percentile(durations, 50)
+			stats.P95Duration = percentile(durations, 95)
+			stats.MaxDuration = durations[len(durations)-1]
 			taskStats[id] = stats
 		}
 	}
@@ -294,6 +369,18 @@ func calculateTaskStats(runs []model.RunRecord, numRuns int) map[string]TaskStat
 	return taskStats
 }
 
+// percentile returns the nearest-rank percentile p (0-100) of sorted values
+func percentile(sorted []int64, p int) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
 // minInt returns the minimum of two integers
 func minInt(a, b int) int {
 	if a < b {
@@ -302,6 +389,20 @@ func minInt(a, b int) int {
 	return b
 }
 
+// runCommit returns the HEAD commit and branch recorded in the run's git info, or "" for runs
+// outside a repo or recorded before devpipe stored them
+func runCommit(run model.RunRecord) (commit, branch string) {
+	var info struct {
+		Commit string `json:"commit"`
+		Branch string `json:"branch"`
+	}
+	// Git is a git.GitInfo, or the map it was decoded to when read back from run.json
+	if data, err := json.Marshal(run.Git); err == nil {
+		_ = json.Unmarshal(data, &info)
+	}
+	return info.Commit, info.Branch
+}
+
 // summarizeRun creates a RunSummary from a RunRecord
 func summarizeRun(run model.RunRecord) RunSummary {
 	summary := RunSummary{
@@ -311,6 +412,7 @@ func summarizeRun(run model.RunRecord) RunSummary {
 		Command:         cleanCommand(run.Command),
 		PipelineVersion: run.PipelineVersion,
 	}
+	summary.Commit, summary.Branch = runCommit(run)
 
 	anyFailed := false
 	var totalDuration int64
@@ -342,13 +444,47 @@ func summarizeRun(run model.RunRecord) RunSummary {
 	return summary
 }
 
-// writeSummaryJSON writes the summary to a JSON file
+// writeSummaryJSON writes the summary to a JSON file. It writes a temporary file and renames
+// it over path, so a process killed mid-write leaves the previous summary rather than a
+// truncated one
 func writeSummaryJSON(path string, summary Summary) error {
 	data, err := json.MarshalIndent(summary, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0644)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// LoadSummary reads outputRoot's summary.json. A missing file returns an error matching
+// os.ErrNotExist. A file that cannot be parsed (e.g. truncated by an older devpipe killed while
+// writing it) is rebuilt from the run.json files and rewritten, and repaired is true
+func LoadSummary(outputRoot, version string) (summary Summary, repaired bool, err error) {
+	summaryPath := filepath.Join(outputRoot, "summary.json")
+	data, err := os.ReadFile(summaryPath)
+	if err != nil {
+		return Summary{}, false, err
+	}
+	if err := json.Unmarshal(data, &summary); err == nil {
+		return summary, false, nil
+	}
+
+	runs, err := loadAllRuns(filepath.Join(outputRoot, "runs"))
+	if err != nil {
+		return Summary{}, false, fmt.Errorf("summary.json is corrupt and the runs could not be read: %w", err)
+	}
+	summary = aggregateRuns(runs, version, DefaultRecentLimit, DefaultFlakyThreshold)
+	if err := writeSummaryJSON(summaryPath, summary); err != nil {
+		return Summary{}, false, fmt.Errorf("summary.json is corrupt and could not be rewritten: %w", err)
+	}
+	return summary, true, nil
 }
 
 // cleanCommand removes shell prompt cruft from old command strings
@@ -2,9 +2,11 @@ package dashboard
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -93,7 +95,7 @@ func TestGenerateDashboardWithOptions(t *testing.T) {
 		t.Fatalf("Failed to create runs dir: %v", err)
 	}
 
-	err := GenerateDashboardWithOptions(tmpDir, "1.0.0", false, "")
+	err := GenerateDashboardWithOptions(tmpDir, "1.0.0", false, DefaultRecentLimit, "", DefaultFlakyThreshold)
 	if err != nil {
 		t.Fatalf("GenerateDashboardWithOptions() error = %v", err)
 	}
@@ -177,7 +179,7 @@ func TestAggregateRuns(t *testing.T) {
 		},
 	}
 
-	summary := aggregateRuns(runs, "1.0.0")
+	summary := aggregateRuns(runs, "1.0.0", DefaultRecentLimit, DefaultFlakyThreshold)
 
 	if summary.TotalRuns != 2 {
 		t.Errorf("Expected 2 total runs, got %d", summary.TotalRuns)
@@ -224,7 +226,7 @@ func TestCalculateTaskStats(t *testing.T) {
 		},
 	}
 
-	stats := calculateTaskStats(runs, len(runs))
+	stats := ComputeTaskStats(runs, len(runs))
 
 	if len(stats) != 1 {
 		t.Fatalf("Expected 1 task in stats, got %d", len(stats))
@@ -393,6 +395,98 @@ func TestWriteSummaryJSON(t *testing.T) {
 	}
 }
 
+func TestLoadSummary(t *testing.T) {
+	outputRoot := t.TempDir()
+	summaryPath := filepath.Join(outputRoot, "summary.json")
+
+	if _, _, err := LoadSummary(outputRoot, "dev"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("LoadSummary() without summary.json error = %v, want os.ErrNotExist", err)
+	}
+
+	if err := writeSummaryJSON(summaryPath, Summary{TotalRuns: 3}); err != nil {
+		t.Fatalf("writeSummaryJSON() error = %v", err)
+	}
+	if _, err := os.Stat(summaryPath + ".tmp"); !os.IsNotExist(err) {
+		t.Error("writeSummaryJSON() left its temporary file behind")
+	}
+	summary, repaired, err := LoadSummary(outputRoot, "dev")
+	if err != nil || repaired || summary.TotalRuns != 3 {
+		t.Fatalf("LoadSummary() = %d runs, repaired %v, error %v; want 3 runs, not repaired", summary.TotalRuns, repaired, err)
+	}
+
+	// A truncated summary is rebuilt from the run.json files
+	run := model.RunRecord{
+		RunID:     "run-1",
+		Timestamp: time.Now().Format(time.RFC3339),
+		Tasks:     []model.TaskResult{{ID: "lint", Name: "Lint", Status: model.StatusPass, DurationMs: 4000}},
+	}
+	if err := os.MkdirAll(filepath.Join(outputRoot, "runs", run.RunID), 0755); err != nil {
+		t.Fatalf("Failed to create run dir: %v", err)
+	}
+	if err := writeRunJSON(filepath.Join(outputRoot, "runs", run.RunID, "run.json"), run); err != nil {
+		t.Fatalf("writeRunJSON() error = %v", err)
+	}
+	if err := os.WriteFile(summaryPath, []byte(`{"totalRuns": 3, "taskSt`), 0644); err != nil {
+		t.Fatalf("Failed to write summary.json: %v", err)
+	}
+	summary, repaired, err = LoadSummary(outputRoot, "dev")
+	if err != nil || !repaired {
+		t.Fatalf("LoadSummary() of a truncated file: repaired %v, error %v; want repaired", repaired, err)
+	}
+	if summary.TotalRuns != 1 || summary.TaskStats["lint"].AvgDuration != 4000 {
+		t.Errorf("rebuilt summary = %d runs, lint avg %v; want 1 run, lint avg 4000", summary.TotalRuns, summary.TaskStats["lint"].AvgDuration)
+	}
+	if _, repaired, err := LoadSummary(outputRoot, "dev"); err != nil || repaired {
+		t.Errorf("LoadSummary() after the rebuild: repaired %v, error %v; want the rewritten file to parse", repaired, err)
+	}
+}
+
+func TestReindex(t *testing.T) {
+	outputRoot := t.TempDir()
+	runsDir := filepath.Join(outputRoot, "runs")
+	for _, id := range []string{"run-1", "run-2", "broken", "in-progress"} {
+		if err := os.MkdirAll(filepath.Join(runsDir, id), 0755); err != nil {
+			t.Fatalf("Failed to create run dir: %v", err)
+		}
+	}
+	for _, id := range []string{"run-1", "run-2"} {
+		run := model.RunRecord{
+			RunID:     id,
+			Timestamp: time.Now().Format(time.RFC3339),
+			Tasks:     []model.TaskResult{{ID: "lint", Status: model.StatusPass, DurationMs: 1000}},
+		}
+		if err := writeRunJSON(filepath.Join(runsDir, id, "run.json"), run); err != nil {
+			t.Fatalf("writeRunJSON() error = %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(runsDir, "broken", "run.json"), []byte(`{"runId":`), 0644); err != nil {
+		t.Fatalf("Failed to write run.json: %v", err)
+	}
+
+	result, err := Reindex(outputRoot, "dev", DefaultRecentLimit, DefaultFlakyThreshold)
+	if err != nil {
+		t.Fatalf("Reindex() error = %v", err)
+	}
+	if result.Runs != 2 {
+		t.Errorf("Reindex() indexed %d runs, want 2", result.Runs)
+	}
+	// The run directory without a run.json is not reported, it may still be running
+	if len(result.Unreadable) != 1 || !strings.Contains(result.Unreadable[0].Error(), filepath.Join("broken", "run.json")) {
+		t.Errorf("Reindex() unreadable = %v, want only broken/run.json", result.Unreadable)
+	}
+
+	summary, repaired, err := LoadSummary(outputRoot, "dev")
+	if err != nil || repaired {
+		t.Fatalf("LoadSummary() after Reindex: repaired %v, error %v", repaired, err)
+	}
+	if summary.TotalRuns != 2 || summary.TaskStatsLast25["lint"].TotalRuns != 2 {
+		t.Errorf("summary = %d runs, lint last-25 %d runs; want 2 and 2", summary.TotalRuns, summary.TaskStatsLast25["lint"].TotalRuns)
+	}
+	if _, err := os.Stat(filepath.Join(outputRoot, "report.html")); !os.IsNotExist(err) {
+		t.Error("Reindex() should not write report.html")
+	}
+}
+
 func TestCopyMascotAssets(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -446,6 +540,23 @@ func TestFormatDuration(t *testing.T) {
 	}
 }
 
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{512, "512 B"},
+		{1536, "1.5 KiB"},
+		{250 * 1024 * 1024, "250.0 MiB"},
+		{3 << 30, "3.0 GiB"},
+	}
+	for _, tt := range tests {
+		if got := formatBytes(tt.n); got != tt.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
 func TestFormatTime(t *testing.T) {
 	testTime := time.Date(2024, 1, 15, 14, 30, 0, 0, time.UTC)
 
@@ -572,6 +683,27 @@ func TestSummarizeRunAllSkipped(t *testing.T) {
 	}
 }
 
+func TestSummarizeRunCommit(t *testing.T) {
+	// Git is a map when the run is read back from run.json
+	var run model.RunRecord
+	data := `{"runId":"test-123","git":{"mode":"staged","commit":"0123456789abcdef0123456789abcdef01234567","branch":"main"}}`
+	if err := json.Unmarshal([]byte(data), &run); err != nil {
+		t.Fatalf("failed to parse run: %v", err)
+	}
+	summary := summarizeRun(run)
+	if summary.Commit != "0123456789abcdef0123456789abcdef01234567" || summary.Branch != "main" {
+		t.Errorf("got commit=%q branch=%q, want the recorded commit on main", summary.Commit, summary.Branch)
+	}
+
+	// Runs outside a repo (or from older versions) have neither
+	if summary := summarizeRun(model.RunRecord{RunID: "test-456"}); summary.Commit != "" || summary.Branch != "" {
+		t.Errorf("got commit=%q branch=%q, want none", summary.Commit, summary.Branch)
+	}
+	if got := shortCommit("0123456789abcdef"); got != "0123456" {
+		t.Errorf("shortCommit() = %q, want 0123456", got)
+	}
+}
+
 func TestCalculateTaskStatsWithSkippedTasks(t *testing.T) {
 	runs := []model.RunRecord{
 		{
@@ -594,7 +726,7 @@ func TestCalculateTaskStatsWithSkippedTasks(t *testing.T) {
 		},
 	}
 
-	stats := calculateTaskStats(runs, len(runs))
+	stats := ComputeTaskStats(runs, len(runs))
 
 	// Skipped tasks should not be included in average duration
 	task1Stats := stats["task1"]
@@ -621,7 +753,7 @@ func TestCalculateTaskStatsLimitedRuns(t *testing.T) {
 	}
 
 	// Calculate stats for only the first 2 runs
-	stats := calculateTaskStats(runs, 2)
+	stats := ComputeTaskStats(runs, 2)
 
 	task1Stats := stats["task1"]
 	if task1Stats.TotalRuns != 2 {
@@ -634,6 +766,55 @@ func TestCalculateTaskStatsLimitedRuns(t *testing.T) {
 	}
 }
 
+func TestComputeTaskStatsPercentiles(t *testing.T) {
+	var runs []model.RunRecord
+	for i := 1; i <= 20; i++ {
+		runs = append(runs, model.RunRecord{Tasks: []model.TaskResult{
+			{ID: "test", Status: model.StatusPass, DurationMs: int64(i * 100)},
+		}})
+	}
+
+	stats := ComputeTaskStats(runs, len(runs))["test"]
+	if stats.P50Duration != 1000 {
+		t.Errorf("Expected p50 1000, got %d", stats.P50Duration)
+	}
+	if stats.P95Duration != 1900 {
+		t.Errorf("Expected p95 1900, got %d", stats.P95Duration)
+	}
+	if stats.MaxDuration != 2000 {
+		t.Errorf("Expected max 2000, got %d", stats.MaxDuration)
+	}
+
+	// A single run is its own p50 and p95
+	single := ComputeTaskStats(runs, 1)["test"]
+	if single.P50Duration != 100 || single.P95Duration != 100 {
+		t.Errorf("Expected p50/p95 of 100 for one run, got %d/%d", single.P50Duration, single.P95Duration)
+	}
+}
+
+func TestLoadRuns(t *testing.T) {
+	outputRoot := t.TempDir()
+	for _, run := range []model.RunRecord{
+		{RunID: "old", Timestamp: "2024-01-01T00:00:00Z"},
+		{RunID: "new", Timestamp: "2024-02-01T00:00:00Z"},
+	} {
+		if err := os.MkdirAll(filepath.Join(outputRoot, "runs", run.RunID), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeRunJSON(filepath.Join(outputRoot, "runs", run.RunID, "run.json"), run); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runs, err := LoadRuns(outputRoot)
+	if err != nil {
+		t.Fatalf("LoadRuns() error = %v", err)
+	}
+	if len(runs) != 2 || runs[0].RunID != "new" {
+		t.Errorf("Expected 2 runs newest first, got %+v", runs)
+	}
+}
+
 func TestWriteRunJSON(t *testing.T) {
 	tmpDir := t.TempDir()
 	runPath := filepath.Join(tmpDir, "run.json")
@@ -705,7 +886,7 @@ func TestGenerateDashboardWithOptionsRegenerateAll(t *testing.T) {
 	}
 
 	// Generate with regenerateAll=true
-	err := GenerateDashboardWithOptions(tmpDir, "new-version", true, "")
+	err := GenerateDashboardWithOptions(tmpDir, "new-version", true, DefaultRecentLimit, "", DefaultFlakyThreshold)
 	if err != nil {
 		t.Fatalf("GenerateDashboardWithOptions() error = %v", err)
 	}
@@ -727,6 +908,47 @@ func TestGenerateDashboardWithOptionsRegenerateAll(t *testing.T) {
 	}
 }
 
+func TestGenerateDashboardWithOptionsRecentLimit(t *testing.T) {
+	tmpDir := t.TempDir()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= 3; i++ {
+		run := model.RunRecord{RunID: fmt.Sprintf("run-%d", i), Timestamp: base.Add(time.Duration(i) * time.Minute).Format(time.RFC3339)}
+		runDir := filepath.Join(tmpDir, "runs", run.RunID)
+		if err := os.MkdirAll(runDir, 0755); err != nil {
+			t.Fatalf("Failed to create run dir: %v", err)
+		}
+		runData, _ := json.Marshal(run)
+		if err := os.WriteFile(filepath.Join(runDir, "run.json"), runData, 0644); err != nil {
+			t.Fatalf("Failed to write run.json: %v", err)
+		}
+	}
+
+	// run-1 is the oldest, beyond the limit of 2, unless it is the current run
+	if err := GenerateDashboardWithOptions(tmpDir, "1.0.0", true, 2, "", DefaultFlakyThreshold); err != nil {
+		t.Fatalf("GenerateDashboardWithOptions() error = %v", err)
+	}
+	for id, want := range map[string]bool{"run-3": true, "run-2": true, "run-1": false} {
+		_, err := os.Stat(filepath.Join(tmpDir, "runs", id, "report.html"))
+		if (err == nil) != want {
+			t.Errorf("%s has report.html = %v, want %v", id, err == nil, want)
+		}
+	}
+	summary, _, err := LoadSummary(tmpDir, "1.0.0")
+	if err != nil {
+		t.Fatalf("LoadSummary() error = %v", err)
+	}
+	if summary.TotalRuns != 3 || len(summary.RecentRuns) != 2 || summary.RecentRuns[1].RunID != "run-2" {
+		t.Errorf("got %d runs with %d listed, want 3 runs with the newest 2 listed", summary.TotalRuns, len(summary.RecentRuns))
+	}
+
+	if err := GenerateDashboardWithOptions(tmpDir, "1.0.0", false, 2, "run-1", DefaultFlakyThreshold); err != nil {
+		t.Fatalf("GenerateDashboardWithOptions() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "runs", "run-1", "report.html")); err != nil {
+		t.Errorf("Expected the current run's report to be generated beyond the limit: %v", err)
+	}
+}
+
 func TestAggregateRunsWithLargeNumberOfRuns(t *testing.T) {
 	// Create 150 runs to test pagination limit
 	runs := make([]model.RunRecord, 150)
@@ -740,7 +962,7 @@ func TestAggregateRunsWithLargeNumberOfRuns(t *testing.T) {
 		}
 	}
 
-	summary := aggregateRuns(runs, "1.0.0")
+	summary := aggregateRuns(runs, "1.0.0", DefaultRecentLimit, DefaultFlakyThreshold)
 
 	// Should have all 150 runs
 	if summary.TotalRuns != 150 {
@@ -817,7 +1039,7 @@ func TestGenerateDashboardIDEViewerEnabled(t *testing.T) {
 		t.Fatalf("Failed to write run.json: %v", err)
 	}
 
-	err := GenerateDashboardWithOptions(tmpDir, "1.0.0", true, "")
+	err := GenerateDashboardWithOptions(tmpDir, "1.0.0", true, DefaultRecentLimit, "", DefaultFlakyThreshold)
 	if err != nil {
 		t.Fatalf("GenerateDashboardWithOptions() error = %v", err)
 	}
@@ -0,0 +1,241 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/drew/devpipe/internal/model"
+)
+
+// Task change kinds reported by CompareRuns
+const (
+	ChangeAdded     = "added"     // Task only exists in run B
+	ChangeRemoved   = "removed"   // Task only exists in run A
+	ChangeRegressed = "regressed" // Task passed (or was skipped) in A and failed in B
+	ChangeFixed     = "fixed"     // Task failed in A and passed in B
+	ChangeStatus    = "status"    // Any other status change
+	ChangeNone      = "unchanged" // Same status in both runs
+)
+
+// TaskDiff describes how a single task changed between two runs
+type TaskDiff struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	StatusA   string `json:"statusA,omitempty"` // Empty if the task is not in run A
+	StatusB   string `json:"statusB,omitempty"` // Empty if the task is not in run B
+	DurationA int64  `json:"durationA"`
+	DurationB int64  `json:"durationB"`
+	DeltaMs   int64  `json:"deltaMs"` // DurationB - DurationA (positive = slower)
+	Change    string `json:"change"`
+}
+
+// RunDiff is a task-by-task comparison of two runs
+type RunDiff struct {
+	RunA  model.RunRecord
+	RunB  model.RunRecord
+	Tasks []TaskDiff // Sorted by biggest duration regression first
+}
+
+// LoadRun reads runs/<runID>/run.json from the output root
+func LoadRun(outputRoot, runID string) (model.RunRecord, error) {
+	var run model.RunRecord
+
+	path := filepath.Join(outputRoot, "runs", runID, "run.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return run, fmt.Errorf("failed to read run %s: %w", runID, err)
+	}
+	if err := json.Unmarshal(data, &run); err != nil {
+		return run, fmt.Errorf("failed to parse run %s: %w", runID, err)
+	}
+
+	return run, nil
+}
+
+// CompareRuns builds a per-task comparison of run A (baseline) against run B
+func CompareRuns(a, b model.RunRecord) RunDiff {
+	diff := RunDiff{RunA: a, RunB: b}
+
+	tasksA := make(map[string]model.TaskResult, len(a.Tasks))
+	for _, t := range a.Tasks {
+		tasksA[t.ID] = t
+	}
+	seen := make(map[string]bool, len(b.Tasks))
+
+	for _, tb := range b.Tasks {
+		seen[tb.ID] = true
+		td := TaskDiff{
+			ID:        tb.ID,
+			Name:      tb.Name,
+			StatusB:   string(tb.Status),
+			DurationB: tb.DurationMs,
+		}
+		if ta, ok := tasksA[tb.ID]; ok {
+			td.StatusA = string(ta.Status)
+			td.DurationA = ta.DurationMs
+			td.DeltaMs = tb.DurationMs - ta.DurationMs
+			td.Change = classifyChange(ta.Status, tb.Status)
+		} else {
+			td.Change = ChangeAdded
+		}
+		diff.Tasks = append(diff.Tasks, td)
+	}
+
+	for _, ta := range a.Tasks {
+		if seen[ta.ID] {
+			continue
+		}
+		diff.Tasks = append(diff.Tasks, TaskDiff{
+			ID:        ta.ID,
+			Name:      ta.Name,
+			StatusA:   string(ta.Status),
+			DurationA: ta.DurationMs,
+			Change:    ChangeRemoved,
+		})
+	}
+
+	// Biggest regression first; tasks only present in one run have no delta and sort last
+	sort.SliceStable(diff.Tasks, func(i, j int) bool {
+		iBoth := diff.Tasks[i].StatusA != "" && diff.Tasks[i].StatusB != ""
+		jBoth := diff.Tasks[j].StatusA != "" && diff.Tasks[j].StatusB != ""
+		if iBoth != jBoth {
+			return iBoth
+		}
+		return diff.Tasks[i].DeltaMs > diff.Tasks[j].DeltaMs
+	})
+
+	return diff
+}
+
+// classifyChange describes a status transition between two runs
+func classifyChange(a, b model.TaskStatus) string {
+	switch {
+	case a == b:
+		return ChangeNone
+	case b == model.StatusFail:
+		return ChangeRegressed
+	case a == model.StatusFail && b == model.StatusPass:
+		return ChangeFixed
+	default:
+		return ChangeStatus
+	}
+}
+
+// DiffReportPath returns where the HTML comparison for two runs is written
+func DiffReportPath(outputRoot, runA, runB string) string {
+	return filepath.Join(outputRoot, fmt.Sprintf("diff_%s_vs_%s.html", runA, runB))
+}
+
+// WriteDiffHTML generates an HTML comparison page for two runs
+func WriteDiffHTML(path string, diff RunDiff) error {
+	tmpl, err := template.New("diff").Funcs(template.FuncMap{
+		"formatDuration": formatDuration,
+		"formatDelta":    formatDelta,
+		"statusClass":    statusClass,
+		"statusSymbol":   statusSymbol,
+	}).Parse(diffTemplate)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("failed to close file: %w", cerr)
+		}
+	}()
+
+	return tmpl.Execute(f, diff)
+}
+
+// formatDelta formats a duration change with an explicit sign
+func formatDelta(ms int64) string {
+	if ms > 0 {
+		return "+" + formatDuration(ms)
+	}
+	if ms < 0 {
+		return "-" + formatDuration(-ms)
+	}
+	return "±0ms"
+}
+
+const diffTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Compare {{.RunA.RunID}} vs {{.RunB.RunID}} - devpipe</title>
+    <style>
+        body {
+            font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Oxygen, Ubuntu, Cantarell, sans-serif;
+            background: #f5f5f5;
+            color: #333;
+            line-height: 1.6;
+            margin: 0;
+        }
+        .container { max-width: 1100px; margin: 0 auto; padding: 20px; }
+        .breadcrumb { margin-bottom: 20px; color: #7f8c8d; }
+        .breadcrumb a { color: #3498db; text-decoration: none; }
+        .section {
+            background: white;
+            padding: 30px;
+            border-radius: 8px;
+            box-shadow: 0 2px 4px rgba(0,0,0,0.1);
+            margin-bottom: 30px;
+        }
+        h1 { font-size: 28px; color: #2c3e50; margin: 0 0 10px 0; }
+        table { width: 100%; border-collapse: collapse; }
+        th, td { text-align: left; padding: 8px 12px; border-bottom: 1px solid #ecf0f1; }
+        th { font-size: 12px; color: #7f8c8d; text-transform: uppercase; }
+        .pass { color: #27ae60; }
+        .fail { color: #e74c3c; }
+        .skip { color: #f39c12; }
+        .slower { color: #e74c3c; }
+        .faster { color: #27ae60; }
+        .change-regressed, .change-removed { background: #fdecea; }
+        .change-fixed, .change-added { background: #eafaf1; }
+        .muted { color: #95a5a6; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="breadcrumb"><a href="report.html">Dashboard</a> / Compare runs</div>
+        <div class="section">
+            <h1>Run comparison</h1>
+            <p>
+                <a href="runs/{{.RunA.RunID}}/report.html">{{.RunA.RunID}}</a>
+                &rarr;
+                <a href="runs/{{.RunB.RunID}}/report.html">{{.RunB.RunID}}</a>
+            </p>
+        </div>
+        <div class="section">
+            <table>
+                <thead>
+                    <tr><th>Task</th><th>Run A</th><th>Run B</th><th>Duration A</th><th>Duration B</th><th>Delta</th><th>Change</th></tr>
+                </thead>
+                <tbody>
+                {{range .Tasks}}
+                    <tr class="change-{{.Change}}">
+                        <td>{{.ID}}</td>
+                        <td>{{if .StatusA}}<span class="{{statusClass .StatusA}}">{{statusSymbol .StatusA}} {{.StatusA}}</span>{{else}}<span class="muted">—</span>{{end}}</td>
+                        <td>{{if .StatusB}}<span class="{{statusClass .StatusB}}">{{statusSymbol .StatusB}} {{.StatusB}}</span>{{else}}<span class="muted">—</span>{{end}}</td>
+                        <td>{{if .StatusA}}{{formatDuration .DurationA}}{{end}}</td>
+                        <td>{{if .StatusB}}{{formatDuration .DurationB}}{{end}}</td>
+                        <td class="{{if gt .DeltaMs 0}}slower{{else if lt .DeltaMs 0}}faster{{end}}">{{if and .StatusA .StatusB}}{{formatDelta .DeltaMs}}{{end}}</td>
+                        <td>{{.Change}}</td>
+                    </tr>
+                {{end}}
+                </tbody>
+            </table>
+        </div>
+    </div>
+</body>
+</html>
+`
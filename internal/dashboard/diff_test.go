@@ -0,0 +1,110 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/drew/devpipe/internal/model"
+)
+
+func TestCompareRuns(t *testing.T) {
+	runA := model.RunRecord{
+		RunID: "run-a",
+		Tasks: []model.TaskResult{
+			{ID: "lint", Status: model.StatusPass, DurationMs: 1000},
+			{ID: "test", Status: model.StatusPass, DurationMs: 2000},
+			{ID: "build", Status: model.StatusFail, DurationMs: 3000},
+			{ID: "old", Status: model.StatusPass, DurationMs: 500},
+		},
+	}
+	runB := model.RunRecord{
+		RunID: "run-b",
+		Tasks: []model.TaskResult{
+			{ID: "lint", Status: model.StatusPass, DurationMs: 900},
+			{ID: "test", Status: model.StatusFail, DurationMs: 6000},
+			{ID: "build", Status: model.StatusPass, DurationMs: 3500},
+			{ID: "new", Status: model.StatusPass, DurationMs: 100},
+		},
+	}
+
+	diff := CompareRuns(runA, runB)
+
+	var order []string
+	changes := make(map[string]TaskDiff)
+	for _, td := range diff.Tasks {
+		order = append(order, td.ID)
+		changes[td.ID] = td
+	}
+
+	// Sorted by biggest regression; single-run tasks last
+	want := []string{"test", "build", "lint", "new", "old"}
+	if strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+
+	if changes["test"].Change != ChangeRegressed || changes["test"].DeltaMs != 4000 {
+		t.Errorf("test diff = %+v", changes["test"])
+	}
+	if changes["build"].Change != ChangeFixed {
+		t.Errorf("build change = %q, want %q", changes["build"].Change, ChangeFixed)
+	}
+	if changes["lint"].Change != ChangeNone || changes["lint"].DeltaMs != -100 {
+		t.Errorf("lint diff = %+v", changes["lint"])
+	}
+	if changes["new"].Change != ChangeAdded || changes["new"].StatusA != "" {
+		t.Errorf("new diff = %+v", changes["new"])
+	}
+	if changes["old"].Change != ChangeRemoved || changes["old"].StatusB != "" {
+		t.Errorf("old diff = %+v", changes["old"])
+	}
+}
+
+func TestLoadRun(t *testing.T) {
+	outputRoot := t.TempDir()
+	runDir := filepath.Join(outputRoot, "runs", "run-1")
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(model.RunRecord{RunID: "run-1"})
+	if err := os.WriteFile(filepath.Join(runDir, "run.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run, err := LoadRun(outputRoot, "run-1")
+	if err != nil {
+		t.Fatalf("LoadRun() error = %v", err)
+	}
+	if run.RunID != "run-1" {
+		t.Errorf("RunID = %q, want run-1", run.RunID)
+	}
+
+	if _, err := LoadRun(outputRoot, "missing"); err == nil {
+		t.Error("expected error for missing run")
+	}
+}
+
+func TestWriteDiffHTML(t *testing.T) {
+	diff := CompareRuns(
+		model.RunRecord{RunID: "run-a", Tasks: []model.TaskResult{{ID: "lint", Status: model.StatusPass, DurationMs: 100}}},
+		model.RunRecord{RunID: "run-b", Tasks: []model.TaskResult{{ID: "lint", Status: model.StatusFail, DurationMs: 1500}}},
+	)
+
+	path := DiffReportPath(t.TempDir(), "run-a", "run-b")
+	if err := WriteDiffHTML(path, diff); err != nil {
+		t.Fatalf("WriteDiffHTML() error = %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(content)
+	for _, want := range []string{"run-a", "run-b", "change-regressed", "1.4s"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML missing %q", want)
+		}
+	}
+}
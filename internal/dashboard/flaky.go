@@ -0,0 +1,152 @@
+package dashboard
+
+import (
+	"sort"
+
+	"github.com/drew/devpipe/internal/model"
+)
+
+// DefaultFlakyThreshold is the minimum number of pass/fail flips for a test to be reported as flaky
+const DefaultFlakyThreshold = 2
+
+// maxFlakyTests caps how many flaky tests are listed in the summary
+const maxFlakyTests = 20
+
+// FlakyTest is a test case whose outcome alternated between pass and fail across recent runs
+type FlakyTest struct {
+	TaskID     string   `json:"taskId"`
+	Name       string   `json:"name"`       // classname.name, or just name when there is no classname
+	Runs       int      `json:"runs"`       // Runs where the test passed or failed (skips are ignored)
+	Failures   int      `json:"failures"`   // Runs where the test failed or errored
+	Flips      int      `json:"flips"`      // Number of pass<->fail transitions
+	FlipRate   float64  `json:"flipRate"`   // Flips / possible transitions (0-1)
+	FailedRuns []string `json:"failedRuns"` // Run IDs where the test failed, newest first
+}
+
+// testHistory tracks one test case's outcomes, oldest first
+type testHistory struct {
+	taskID     string
+	name       string
+	outcomes   []bool // true = passed
+	failedRuns []string
+}
+
+// ComputeFlakyTests finds test cases whose JUnit outcome flipped between pass and fail
+// at least threshold times over the most recent window runs. runs must be sorted newest
+// first (as returned by LoadRuns). Results are ordered by flips, worst first.
+func ComputeFlakyTests(runs []model.RunRecord, window, threshold int) []FlakyTest {
+	if threshold <= 0 {
+		threshold = DefaultFlakyThreshold
+	}
+	if window > len(runs) {
+		window = len(runs)
+	}
+
+	histories := make(map[string]*testHistory)
+	var order []string
+
+	// Walk oldest to newest so flips are counted in the order they happened
+	for i := window - 1; i >= 0; i-- {
+		run := runs[i]
+		for _, task := range run.Tasks {
+			for _, tc := range testCases(task) {
+				key := task.ID + "\x00" + tc.name
+				h, ok := histories[key]
+				if !ok {
+					h = &testHistory{taskID: task.ID, name: tc.name}
+					histories[key] = h
+					order = append(order, key)
+				}
+				h.outcomes = append(h.outcomes, tc.passed)
+				if !tc.passed {
+					h.failedRuns = append([]string{run.RunID}, h.failedRuns...)
+				}
+			}
+		}
+	}
+
+	flaky := []FlakyTest{}
+	for _, key := range order {
+		h := histories[key]
+		flips := 0
+		for i := 1; i < len(h.outcomes); i++ {
+			if h.outcomes[i] != h.outcomes[i-1] {
+				flips++
+			}
+		}
+		if flips < threshold {
+			continue
+		}
+		flaky = append(flaky, FlakyTest{
+			TaskID:     h.taskID,
+			Name:       h.name,
+			Runs:       len(h.outcomes),
+			Failures:   len(h.failedRuns),
+			Flips:      flips,
+			FlipRate:   float64(flips) / float64(len(h.outcomes)-1),
+			FailedRuns: h.failedRuns,
+		})
+	}
+
+	sort.SliceStable(flaky, func(i, j int) bool {
+		if flaky[i].Flips != flaky[j].Flips {
+			return flaky[i].Flips > flaky[j].Flips
+		}
+		return flaky[i].FlipRate > flaky[j].FlipRate
+	})
+	if len(flaky) > maxFlakyTests {
+		flaky = flaky[:maxFlakyTests]
+	}
+	return flaky
+}
+
+// testCaseOutcome is a single non-skipped test case result
+type testCaseOutcome struct {
+	name   string
+	passed bool
+}
+
+// rawTestCases returns the testcases of a task's JUnit metrics
+func rawTestCases(task model.TaskResult) []map[string]interface{} {
+	if task.Metrics == nil || task.Metrics.Data == nil {
+		return nil
+	}
+
+	// Freshly parsed metrics hold []map[string]interface{}; run.json round-trips to []interface{}
+	var raw []map[string]interface{}
+	switch v := task.Metrics.Data["testcases"].(type) {
+	case []map[string]interface{}:
+		raw = v
+	case []interface{}:
+		for _, item := range v {
+			if tc, ok := item.(map[string]interface{}); ok {
+				raw = append(raw, tc)
+			}
+		}
+	}
+	return raw
+}
+
+// testCases extracts pass/fail outcomes from a task's JUnit metrics. Skipped tests are omitted.
+func testCases(task model.TaskResult) []testCaseOutcome {
+	var outcomes []testCaseOutcome
+	for _, tc := range rawTestCases(task) {
+		name, _ := tc["name"].(string)
+		classname, _ := tc["classname"].(string)
+		status, _ := tc["status"].(string)
+		if name == "" {
+			continue
+		}
+		if classname != "" {
+			name = classname + "." + name
+		}
+
+		switch status {
+		case "passed":
+			outcomes = append(outcomes, testCaseOutcome{name: name, passed: true})
+		case "failed", "error":
+			outcomes = append(outcomes, testCaseOutcome{name: name, passed: false})
+		}
+	}
+	return outcomes
+}
@@ -0,0 +1,84 @@
+package dashboard
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/drew/devpipe/internal/model"
+)
+
+// junitRun builds a run whose "unit" task reports the given testcase statuses
+func junitRun(runID string, statuses map[string]string) model.RunRecord {
+	var testcases []interface{}
+	for name, status := range statuses {
+		testcases = append(testcases, map[string]interface{}{
+			"name":      name,
+			"classname": "pkg",
+			"status":    status,
+		})
+	}
+	return model.RunRecord{
+		RunID: runID,
+		Tasks: []model.TaskResult{{
+			ID:     "unit",
+			Status: model.StatusPass,
+			Metrics: &model.TaskMetrics{
+				Kind: "test",
+				Data: map[string]interface{}{"testcases": testcases},
+			},
+		}},
+	}
+}
+
+func TestComputeFlakyTests(t *testing.T) {
+	// Newest first, as returned by LoadRuns
+	runs := []model.RunRecord{
+		junitRun("run-5", map[string]string{"TestFlaky": "passed", "TestStable": "passed", "TestBroken": "failed", "TestOnce": "passed"}),
+		junitRun("run-4", map[string]string{"TestFlaky": "failed", "TestStable": "passed", "TestBroken": "failed", "TestOnce": "passed"}),
+		junitRun("run-3", map[string]string{"TestFlaky": "skipped", "TestStable": "passed", "TestBroken": "error", "TestOnce": "failed"}),
+		junitRun("run-2", map[string]string{"TestFlaky": "passed", "TestStable": "passed", "TestBroken": "failed", "TestOnce": "passed"}),
+		junitRun("run-1", map[string]string{"TestFlaky": "failed", "TestStable": "passed", "TestBroken": "failed", "TestOnce": "passed"}),
+	}
+
+	flaky := ComputeFlakyTests(runs, 25, 3)
+	if len(flaky) != 1 {
+		t.Fatalf("Expected 1 flaky test, got %d: %+v", len(flaky), flaky)
+	}
+
+	got := flaky[0]
+	if got.TaskID != "unit" || got.Name != "pkg.TestFlaky" {
+		t.Errorf("Unexpected flaky test %s/%s", got.TaskID, got.Name)
+	}
+	// fail, pass, (skip), fail, pass => 3 flips over 4 observed runs
+	if got.Flips != 3 || got.Runs != 4 || got.Failures != 2 {
+		t.Errorf("Expected 3 flips over 4 runs with 2 failures, got %d/%d/%d", got.Flips, got.Runs, got.Failures)
+	}
+	if got.FlipRate != 1.0 {
+		t.Errorf("Expected flip rate 1.0, got %f", got.FlipRate)
+	}
+	if want := []string{"run-4", "run-1"}; !reflect.DeepEqual(got.FailedRuns, want) {
+		t.Errorf("FailedRuns = %v, want %v", got.FailedRuns, want)
+	}
+
+	// The default threshold (2) also surfaces the test that failed once
+	flaky = ComputeFlakyTests(runs, 25, 0)
+	if len(flaky) != 2 || flaky[0].Name != "pkg.TestFlaky" || flaky[1].Name != "pkg.TestOnce" {
+		t.Errorf("Expected TestFlaky then TestOnce, got %+v", flaky)
+	}
+
+	// A window of the two newest runs only sees one flip
+	if flaky := ComputeFlakyTests(runs, 2, 1); len(flaky) != 1 || flaky[0].Flips != 1 {
+		t.Errorf("Expected 1 flaky test with 1 flip in a 2-run window, got %+v", flaky)
+	}
+}
+
+func TestComputeFlakyTestsNoMetrics(t *testing.T) {
+	runs := []model.RunRecord{
+		{RunID: "run-2", Tasks: []model.TaskResult{{ID: "lint", Status: model.StatusFail}}},
+		{RunID: "run-1", Tasks: []model.TaskResult{{ID: "lint", Status: model.StatusPass}}},
+	}
+
+	if flaky := ComputeFlakyTests(runs, 25, 1); len(flaky) != 0 {
+		t.Errorf("Expected no flaky tests without testcase metrics, got %+v", flaky)
+	}
+}
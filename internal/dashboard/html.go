@@ -8,9 +8,9 @@ import (
 	"strings"
 	"time"
 
-	"github.com/acarl005/stripansi"
-
 	"github.com/drew/devpipe/internal/model"
+	"github.com/drew/devpipe/internal/sanitize"
+	"github.com/drew/devpipe/internal/tasklog"
 )
 
 // writeHTMLDashboard generates the HTML report
@@ -31,12 +31,15 @@ func writeHTMLDashboard(path string, summary Summary) error {
 		"statusClass":    statusClass,
 		"statusSymbol":   statusSymbol,
 		"shortRunID":     shortRunID,
+		"shortCommit":    shortCommit,
+		"runSearchText":  runSearchText,
 		"truncate":       truncateString,
 		"phaseEmoji":     phaseEmoji,
 		"float64":        func(i int) float64 { return float64(i) },
 		"mul":            func(a, b float64) float64 { return a * b },
 		"div":            func(a, b float64) float64 { return a / b },
 		"int64":          func(f float64) int64 { return int64(f) },
+		"sub":            func(a, b int) int { return a - b },
 	}).Parse(dashboardTemplate)
 
 	if err != nil {
@@ -69,6 +72,20 @@ func formatDuration(ms int64) string {
 	return fmt.Sprintf("%dm %ds", minutes, secs)
 }
 
+// formatBytes formats a byte count with a binary unit, e.g. 512.0 KiB or 1.5 GiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, exp := float64(n)/unit, 0
+	for value >= unit && exp < 3 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGT"[exp])
+}
+
 func formatTime(timestamp string) string {
 	t, err := time.Parse(time.RFC3339, timestamp)
 	if err != nil {
@@ -91,6 +108,8 @@ func statusClass(status string) string {
 		return "pass"
 	case "FAIL":
 		return "fail"
+	case "WARN":
+		return "warn"
 	case "SKIPPED":
 		return "skip"
 	default:
@@ -104,6 +123,8 @@ func statusSymbol(status string) string {
 		return "✓"
 	case "FAIL":
 		return "✗"
+	case "WARN":
+		return "⚠"
 	case "SKIPPED":
 		return "⊘"
 	default:
@@ -124,6 +145,20 @@ func shortRunID(fullID string) string {
 	return fullID
 }
 
+// shortCommit abbreviates a commit hash to its first 7 characters, like git log --oneline
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// runSearchText is the lowercased text the Recent Runs search box matches a run against:
+// its command, run ID, branch and commit
+func runSearchText(run RunSummary) string {
+	return strings.ToLower(strings.Join([]string{run.Command, run.RunID, run.Branch, run.Commit}, " "))
+}
+
 // truncateString truncates a string to maxLen characters and adds "..." if truncated
 func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
@@ -179,9 +214,10 @@ func writeRunDetailHTML(path string, run model.RunRecord) error {
 	// Prepare data with log previews
 	type TaskWithLog struct {
 		model.TaskResult
-		LogPreview []string
-		OutputPath string
-		OutputSize int64
+		LogPreview    []tasklog.Record
+		OutputPath    string
+		OutputSize    int64
+		TestDurations *TestDurations
 	}
 
 	type DetailData struct {
@@ -214,21 +250,25 @@ func writeRunDetailHTML(path string, run model.RunRecord) error {
 	// Load log previews and artifact info for each task
 	for _, task := range run.Tasks {
 		taskWithLog := TaskWithLog{
-			TaskResult: task,
-			LogPreview: readLastLines(task.LogPath, 10),
+			TaskResult:    task,
+			LogPreview:    readLastLines(task.LogPath, task.LogFormat, logPreviewLines(task)),
+			TestDurations: summarizeTestDurations(task),
 		}
 
-		// Check for artifact file (stored in metrics for artifact format)
-		// TODO: The artifact path should be in the workdir + metrics path from task definition
-		// We need to reconstruct it from the run record
-		// For now, check if we can get it from the task's workdir
-		_ = task.Metrics // Placeholder for future artifact handling
+		// Link the copy of the output file stored in the run directory
+		if task.Artifact != "" {
+			if info, err := os.Stat(filepath.Join(filepath.Dir(path), filepath.FromSlash(task.Artifact))); err == nil {
+				taskWithLog.OutputPath = task.Artifact
+				taskWithLog.OutputSize = info.Size()
+			}
+		}
 
 		data.TasksWithLogs = append(data.TasksWithLogs, taskWithLog)
 	}
 
 	tmpl, err := template.New("rundetail").Funcs(template.FuncMap{
 		"formatDuration": formatDuration,
+		"formatBytes":    formatBytes,
 		"formatTime":     formatTime,
 		"statusClass":    statusClass,
 		"statusSymbol":   statusSymbol,
@@ -312,36 +352,34 @@ func toFloat64(v interface{}) float64 {
 	}
 }
 
-// readLastLines reads the last N lines from a file and strips ANSI codes
-func readLastLines(path string, n int) []string {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return []string{"Error reading log file"}
-	}
-
-	// Strip ANSI codes from entire content first
-	cleanData := stripansi.Strip(string(data))
+// defaultLogPreviewLines is how many log lines are previewed for runs recorded before the
+// count was configurable
+const defaultLogPreviewLines = 10
 
-	// Split by newlines
-	allLines := []string{}
-	currentLine := ""
-	for _, ch := range cleanData {
-		if ch == '\n' {
-			allLines = append(allLines, currentLine)
-			currentLine = ""
-		} else {
-			currentLine += string(ch)
-		}
+// logPreviewLines returns how many trailing log lines the run page previews for task
+func logPreviewLines(task model.TaskResult) int {
+	if task.LogPreviewLines > 0 {
+		return task.LogPreviewLines
 	}
-	if currentLine != "" {
-		allLines = append(allLines, currentLine)
+	return defaultLogPreviewLines
+}
+
+// readLastLines reads the last N lines of a task log, sanitized for HTML. Lines from
+// JSONL logs keep their stream so stderr can be shown differently.
+func readLastLines(path, format string, n int) []tasklog.Record {
+	records, err := tasklog.ReadRecords(path, format)
+	if err != nil {
+		return []tasklog.Record{{Line: "Error reading log file"}}
 	}
 
 	// Return last N lines
-	if len(allLines) <= n {
-		return allLines
+	if len(records) > n {
+		records = records[len(records)-n:]
+	}
+	for i := range records {
+		records[i].Line = sanitize.Plain(records[i].Line)
 	}
-	return allLines[len(allLines)-n:]
+	return records
 }
 
 const dashboardTemplate = `<!DOCTYPE html>
@@ -586,6 +624,11 @@ const dashboardTemplate = `<!DOCTYPE html>
             color: #856404;
         }
         
+        .badge-warn {
+            background: #ffe5cc;
+            color: #8a4b08;
+        }
+        
         .mono {
             font-family: 'Monaco', 'Menlo', 'Courier New', monospace;
             font-size: 13px;
@@ -719,7 +762,21 @@ const dashboardTemplate = `<!DOCTYPE html>
         </header>
         
         <div class="section">
-            <h2>Recent Runs</h2>
+            <div style="display: flex; justify-content: space-between; align-items: center; margin-bottom: 20px;">
+                <h2 style="margin: 0;">Recent Runs</h2>
+                {{if .RecentRuns}}
+                <div style="display: flex; align-items: center; gap: 10px;">
+                    <label for="runStatusFilter" style="font-size: 14px; color: #7f8c8d;">Status:</label>
+                    <select id="runStatusFilter" onchange="filterRuns()" style="padding: 8px 12px; border: 1px solid #dee2e6; border-radius: 4px; font-size: 14px; background: white; cursor: pointer;">
+                        <option value="all" selected>All</option>
+                        <option value="fail">Failed only</option>
+                        <option value="pass">Passed</option>
+                        <option value="skip">Skipped</option>
+                    </select>
+                    <input type="search" id="runSearch" oninput="filterRuns()" placeholder="Search commands..." style="padding: 8px 12px; border: 1px solid #dee2e6; border-radius: 4px; font-size: 14px; width: 220px;">
+                </div>
+                {{end}}
+            </div>
             {{if .RecentRuns}}
             <table id="runsTable">
                 <thead>
@@ -730,12 +787,13 @@ const dashboardTemplate = `<!DOCTYPE html>
                         <th>Duration</th>
                         <th>Tasks</th>
                         <th>Version</th>
+                        <th>Commit</th>
                         <th>Command</th>
                     </tr>
                 </thead>
                 <tbody id="runsTableBody">
                     {{range .RecentRuns}}
-                    <tr class="run-row" data-index="{{$.RecentRuns | len}}">
+                    <tr class="run-row" data-index="{{$.RecentRuns | len}}" data-status="{{.Status | statusClass}}" data-search="{{runSearchText .}}">
                         <td class="mono"><a href="runs/{{.RunID}}/report.html" title="{{.RunID}}">{{shortRunID .RunID}}</a></td>
                         <td>{{formatTime .Timestamp}}</td>
                         <td>
@@ -746,11 +804,15 @@ const dashboardTemplate = `<!DOCTYPE html>
                         <td>{{formatDuration .Duration}}</td>
                         <td>{{.TotalTasks}}</td>
                         <td class="mono" style="font-size: 11px;">{{.PipelineVersion}}</td>
+                        <td class="mono" style="font-size: 11px;" title="{{.Commit}}">{{if .Commit}}{{shortCommit .Commit}}{{if .Branch}} ({{.Branch}}){{end}}{{end}}</td>
                         <td class="mono" style="font-size: 11px; max-width: 400px; overflow: hidden; text-overflow: ellipsis; white-space: nowrap;" title="{{.Command}}">{{.Command}}</td>
                     </tr>
                     {{end}}
                 </tbody>
             </table>
+            <div id="noMatchingRuns" class="empty-state" style="display: none;">
+                <p>No runs match the current filter.</p>
+            </div>
             <div id="loadMoreContainer" style="text-align: center; margin-top: 20px;">
                 <button id="loadMoreBtn" class="load-more-btn" onclick="loadMoreRuns()" style="display: none;">
                     Load More (25)
@@ -872,6 +934,40 @@ const dashboardTemplate = `<!DOCTYPE html>
             </div>
             {{end}}
         </div>
+        
+        {{if .FlakyTests}}
+        <div class="section">
+            <h2>Flaky Tests</h2>
+            <p style="color: #7f8c8d; font-size: 14px; margin-bottom: 15px;">Tests that flipped between pass and fail in the last 25 runs</p>
+            <table>
+                <thead>
+                    <tr>
+                        <th>Test</th>
+                        <th>Task</th>
+                        <th>Flips</th>
+                        <th>Flip Rate</th>
+                        <th>Failures</th>
+                        <th>Failed In</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{range .FlakyTests}}
+                    <tr>
+                        <td class="mono" style="max-width: 400px; overflow: hidden; text-overflow: ellipsis; white-space: nowrap;" title="{{.Name}}">{{.Name}}</td>
+                        <td class="mono">{{.TaskID}}</td>
+                        <td>{{.Flips}}</td>
+                        <td>{{printf "%.0f%%" (mul .FlipRate 100.0)}}</td>
+                        <td>{{.Failures}}/{{.Runs}}</td>
+                        <td class="mono" style="font-size: 11px;">
+                            {{range $i, $runID := .FailedRuns}}{{if lt $i 5}}<a href="runs/{{$runID}}/report.html" title="{{$runID}}">{{shortRunID $runID}}</a> {{end}}{{end}}
+                            {{if gt (len .FailedRuns) 5}}<span style="color: #7f8c8d;">+{{sub (len .FailedRuns) 5}} more</span>{{end}}
+                        </td>
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+        </div>
+        {{end}}
     </div>
     
     <script>
@@ -880,44 +976,58 @@ const dashboardTemplate = `<!DOCTYPE html>
         const runsPerLoad = 25;
         const maxRuns = 100;
         
-        function initializePagination() {
-            const allRows = document.querySelectorAll('.run-row');
-            const totalRuns = allRows.length;
-            
-            // Hide rows beyond the initial count
-            allRows.forEach((row, index) => {
-                if (index >= visibleRunCount) {
-                    row.style.display = 'none';
-                }
+        // Rows matching the status filter and search box
+        function matchingRuns() {
+            const statusFilter = document.getElementById('runStatusFilter');
+            const search = document.getElementById('runSearch');
+            const status = statusFilter ? statusFilter.value : 'all';
+            const query = search ? search.value.trim().toLowerCase() : '';
+            return Array.from(document.querySelectorAll('.run-row')).filter(row =>
+                (status === 'all' || row.dataset.status === status) &&
+                (query === '' || row.dataset.search.includes(query)));
+        }
+        
+        // Show the first visibleRunCount matching rows and hide the rest
+        function renderRuns() {
+            const matching = matchingRuns();
+            document.querySelectorAll('.run-row').forEach(row => {
+                row.style.display = 'none';
+            });
+            matching.slice(0, visibleRunCount).forEach(row => {
+                row.style.display = '';
             });
             
-            // Show "Load More" button if there are more runs to display
+            const noMatches = document.getElementById('noMatchingRuns');
+            if (noMatches) {
+                noMatches.style.display = matching.length === 0 ? 'block' : 'none';
+            }
+            
+            // Show "Load More" button if there are more matching runs to display
             const loadMoreBtn = document.getElementById('loadMoreBtn');
-            if (totalRuns > visibleRunCount) {
+            if (!loadMoreBtn) {
+                return;
+            }
+            if (matching.length > visibleRunCount) {
                 loadMoreBtn.style.display = 'inline-block';
-                updateLoadMoreButton(totalRuns);
+                updateLoadMoreButton(matching.length);
+            } else {
+                loadMoreBtn.style.display = 'none';
             }
         }
         
+        function initializePagination() {
+            renderRuns();
+        }
+        
         function loadMoreRuns() {
-            const allRows = document.querySelectorAll('.run-row');
-            const totalRuns = allRows.length;
-            
-            // Show next batch of runs
-            const newVisibleCount = Math.min(visibleRunCount + runsPerLoad, totalRuns);
-            
-            for (let i = visibleRunCount; i < newVisibleCount; i++) {
-                allRows[i].style.display = '';
-            }
-            
-            visibleRunCount = newVisibleCount;
-            
-            // Update or hide the button
-            if (visibleRunCount >= totalRuns) {
-                document.getElementById('loadMoreBtn').style.display = 'none';
-            } else {
-                updateLoadMoreButton(totalRuns);
-            }
+            visibleRunCount += runsPerLoad;
+            renderRuns();
+        }
+        
+        // Changing the filter starts again from the first page of matches
+        function filterRuns() {
+            visibleRunCount = runsPerLoad;
+            renderRuns();
         }
         
         function updateLoadMoreButton(totalRuns) {
@@ -1088,6 +1198,17 @@ const runDetailTemplate = `<!DOCTYPE html>
             color: #2c3e50;
         }
         
+        .label-chip {
+            display: inline-block;
+            margin: 0 4px 4px 0;
+            padding: 2px 8px;
+            border-radius: 10px;
+            background: #e8f0fe;
+            color: #1a4f8b;
+            font-size: 12px;
+            font-family: 'Monaco', 'Courier New', monospace;
+        }
+        
         .section {
             background: white;
             padding: 30px;
@@ -1136,6 +1257,21 @@ const runDetailTemplate = `<!DOCTYPE html>
             margin-left: 10px;
         }
         
+        .task-desc {
+            font-size: 13px;
+            color: #7f8c8d;
+            margin-top: -8px;
+        }
+        
+        .task-desc-fail {
+            color: #721c24;
+            background: #f8d7da;
+            border-left: 4px solid #e74c3c;
+            border-radius: 4px;
+            padding: 10px 12px;
+            margin-top: 0;
+        }
+        
         .task-details {
             display: grid;
             grid-template-columns: repeat(auto-fit, minmax(150px, 1fr));
@@ -1208,6 +1344,11 @@ const runDetailTemplate = `<!DOCTYPE html>
             color: #856404;
         }
         
+        .badge-warn {
+            background: #ffe5cc;
+            color: #8a4b08;
+        }
+        
         .mono {
             font-family: 'Monaco', 'Menlo', 'Courier New', monospace;
             font-size: 13px;
@@ -1220,6 +1361,41 @@ const runDetailTemplate = `<!DOCTYPE html>
             margin-top: 15px;
             border-radius: 4px;
         }
+        .slowest-tests {
+            border-collapse: collapse;
+            font-size: 12px;
+        }
+        .slowest-tests td {
+            padding: 3px 12px 3px 0;
+            vertical-align: top;
+        }
+        .duration-bucket {
+            display: flex;
+            align-items: center;
+            gap: 10px;
+            font-size: 12px;
+            margin-bottom: 4px;
+        }
+        .duration-bucket-label {
+            width: 80px;
+            color: #7f8c8d;
+        }
+        .duration-bucket-bar {
+            flex: 1;
+            max-width: 300px;
+            height: 10px;
+            background: #ecf0f1;
+            border-radius: 3px;
+            overflow: hidden;
+        }
+        .duration-bucket-bar span {
+            display: block;
+            height: 100%;
+            background: #3498db;
+        }
+        .duration-bucket-count {
+            width: 50px;
+        }
         
         /* Phase Flow Styles */
         .phase-flow-container {
@@ -1435,6 +1611,7 @@ const runDetailTemplate = `<!DOCTYPE html>
         .phase-task-icon.success { color: #27ae60; }
         .phase-task-icon.fail { color: #e74c3c; }
         .phase-task-icon.skip { color: #f39c12; }
+        .phase-task-icon.warn { color: #e67e22; }
         
         .phase-task-name {
             font-weight: 600;
@@ -1629,6 +1806,12 @@ const runDetailTemplate = `<!DOCTYPE html>
                     <div class="meta-value mono">{{.ReportVersion}}</div>
                 </div>
                 {{end}}
+                {{if .Labels}}
+                <div class="meta-item">
+                    <div class="meta-label">Labels</div>
+                    <div class="meta-value">{{range $k, $v := .Labels}}<span class="label-chip">{{$k}}={{$v}}</span>{{end}}</div>
+                </div>
+                {{end}}
             </div>
         </header>
         
@@ -1695,6 +1878,16 @@ const runDetailTemplate = `<!DOCTYPE html>
                             <div class="detail-label">Reference</div>
                             <div class="detail-value mono">{{.Git.ref}}</div>
                         </div>
+                        {{if .Git.commit}}
+                        <div class="detail-item">
+                            <div class="detail-label">Commit</div>
+                            <div class="detail-value mono">{{.Git.commit}}</div>
+                        </div>
+                        <div class="detail-item">
+                            <div class="detail-label">Branch</div>
+                            <div class="detail-value mono">{{if .Git.branch}}{{.Git.branch}}{{else}}(detached HEAD){{end}}</div>
+                        </div>
+                        {{end}}
                         {{if .Git.changedFiles}}
                         <div class="detail-item">
                             <div class="detail-label">Changed Files ({{len .Git.changedFiles}})</div>
@@ -1730,6 +1923,11 @@ const runDetailTemplate = `<!DOCTYPE html>
                             <p style="color: #7f8c8d; margin: 15px 0; font-size: 13px;">
                                 Final configuration values used for this run, including their sources and overrides.
                             </p>
+                            {{with .EffectiveConfig}}{{if .ConfigSource}}
+                            <p class="mono" style="color: #495057; margin: 0 0 10px 0; font-size: 12px;">
+                                Config file: {{or .ConfigPath "none"}} ({{.ConfigSource}}){{if .Profile}} · Profile: {{.Profile}} ({{.ProfileSource}}){{end}}
+                            </p>
+                            {{end}}{{end}}
                         
                         {{$defaults := slice}}
                         {{$defaultsGit := slice}}
@@ -1760,6 +1958,8 @@ const runDetailTemplate = `<!DOCTYPE html>
                                         <span class="badge" style="background: #d4edda; color: #155724; font-size: 10px;">📄 Config</span>
                                         {{else if eq .Source "cli-flag"}}
                                         <span class="badge" style="background: #cce5ff; color: #004085; font-size: 10px;">🚩 CLI</span>
+                                        {{else if eq .Source "env"}}
+                                        <span class="badge" style="background: #cce5ff; color: #004085; font-size: 10px;">🌐 Env</span>
                                         {{else if eq .Source "default"}}
                                         <span class="badge" style="background: #e2e3e5; color: #383d41; font-size: 10px;">⚙️ Default</span>
                                         {{end}}
@@ -1964,10 +2164,12 @@ const runDetailTemplate = `<!DOCTYPE html>
                                     <span class="phase-task-icon success">✓</span>
                                     {{else if eq (string .Status) "FAIL"}}
                                     <span class="phase-task-icon fail">✗</span>
+                                    {{else if eq (string .Status) "WARN"}}
+                                    <span class="phase-task-icon warn">⚠</span>
                                     {{else}}
                                     <span class="phase-task-icon skip">⊘</span>
                                     {{end}}
-                                    <span class="phase-task-name" title="{{.Name}}{{if .Metrics}}{{if eq .Metrics.SummaryFormat "junit"}} 🧪{{else if eq .Metrics.SummaryFormat "sarif"}} 🔒{{else if eq .Metrics.SummaryFormat "artifact"}} 📦{{end}}{{end}}">{{truncate .Name 25}}{{if .Metrics}}{{if eq .Metrics.SummaryFormat "junit"}} 🧪{{else if eq .Metrics.SummaryFormat "sarif"}} 🔒{{else if eq .Metrics.SummaryFormat "artifact"}} 📦{{end}}{{end}}</span>
+                                    <span class="phase-task-name" title="{{.Name}}{{if .Metrics}}{{if or (eq .Metrics.SummaryFormat "junit") (eq .Metrics.SummaryFormat "tap")}} 🧪{{else if eq .Metrics.SummaryFormat "sarif"}} 🔒{{else if or (eq .Metrics.SummaryFormat "eslint") (eq .Metrics.SummaryFormat "checkstyle")}} 🔍{{else if eq .Metrics.SummaryFormat "artifact"}} 📦{{end}}{{end}}">{{truncate .Name 25}}{{if .Metrics}}{{if or (eq .Metrics.SummaryFormat "junit") (eq .Metrics.SummaryFormat "tap")}} 🧪{{else if eq .Metrics.SummaryFormat "sarif"}} 🔒{{else if or (eq .Metrics.SummaryFormat "eslint") (eq .Metrics.SummaryFormat "checkstyle")}} 🔍{{else if eq .Metrics.SummaryFormat "artifact"}} 📦{{end}}{{end}}</span>
                                     <span class="phase-task-duration">{{formatDuration .DurationMs}}</span>
                                 </div>
                                 {{if .Desc}}
@@ -1996,11 +2198,19 @@ const runDetailTemplate = `<!DOCTYPE html>
                     <div>
                         <span class="task-title">{{.Name}}</span>
                         <span class="task-id">({{.ID}})</span>
+                        {{range $k, $v := .Labels}}<span class="label-chip">{{$k}}={{$v}}</span>{{end}}
                     </div>
                     <span class="badge badge-{{.Status | string | statusClass}}">
                         {{.Status | string | statusSymbol}} {{.Status}}
                     </span>
                 </div>
+                {{if .Desc}}
+                {{if eq (string .Status) "FAIL"}}
+                <div class="task-desc task-desc-fail"><strong>What this checks:</strong> {{.Desc}}</div>
+                {{else}}
+                <div class="task-desc">{{.Desc}}</div>
+                {{end}}
+                {{end}}
                 
                 <div class="task-details">
                     <div class="detail-item">
@@ -2048,6 +2258,28 @@ const runDetailTemplate = `<!DOCTYPE html>
                         <div class="detail-value">{{formatDuration .RecheckDurationMs}}</div>
                     </div>
                     {{end}}
+                    {{if .FixedBefore}}
+                    <div class="detail-item">
+                        <div class="detail-label">Fixed Before Check</div>
+                        <div class="detail-value">
+                            <span class="badge" style="background: #d4edda; color: #155724;">🔧 {{.FixCommand}}</span>
+                        </div>
+                    </div>
+                    <div class="detail-item">
+                        <div class="detail-label">Fix Duration</div>
+                        <div class="detail-value">{{formatDuration .FixDurationMs}}</div>
+                    </div>
+                    {{end}}
+                    {{if .Usage}}
+                    <div class="detail-item">
+                        <div class="detail-label">Peak Memory</div>
+                        <div class="detail-value">{{formatBytes .Usage.MaxRSSBytes}}</div>
+                    </div>
+                    <div class="detail-item">
+                        <div class="detail-label">CPU Time</div>
+                        <div class="detail-value" title="user {{formatDuration .Usage.UserCPUMs}}, system {{formatDuration .Usage.SystemCPUMs}}">{{formatDuration .Usage.CPUMs}}</div>
+                    </div>
+                    {{end}}
                     <div class="detail-item">
                         <div class="detail-label">Start Time ({{$.Timezone}})</div>
                         <div class="detail-value">{{formatTime .StartTime}}</div>
@@ -2084,8 +2316,14 @@ const runDetailTemplate = `<!DOCTYPE html>
                             <div class="detail-value" style="font-weight: bold;">{{index .Metrics.Data "size"}} bytes</div>
                         </div>
                     </div>
-                    {{else if eq .Metrics.SummaryFormat "sarif"}}
+                    {{else if or (eq .Metrics.SummaryFormat "sarif") (eq .Metrics.SummaryFormat "eslint") (eq .Metrics.SummaryFormat "checkstyle")}}
+                    {{if eq .Metrics.SummaryFormat "eslint"}}
+                    <div class="metrics-title">🔍 Lint Results (ESLint)</div>
+                    {{else if eq .Metrics.SummaryFormat "checkstyle"}}
+                    <div class="metrics-title">🔍 Lint Results (checkstyle)</div>
+                    {{else}}
                     <div class="metrics-title">🔒 Security Scan Results (SARIF)</div>
+                    {{end}}
                     <div class="metrics-grid">
                         <div class="detail-item">
                             <div class="detail-label">Total Issues</div>
@@ -2181,8 +2419,10 @@ const runDetailTemplate = `<!DOCTYPE html>
                         </div>
                     </details>
                     {{end}}
-                    {{else if eq .Metrics.SummaryFormat "junit"}}
-                    <div class="metrics-title">🧪 Test Results (JUnit)</div>
+                    {{else if or (eq .Metrics.SummaryFormat "junit") (eq .Metrics.SummaryFormat "tap")}}
+                    <div class="metrics-title">🧪 Test Results ({{if eq .Metrics.SummaryFormat "tap"}}TAP{{else}}JUnit{{end}})</div>
+                    {{with index .Metrics.Data "planMismatch"}}<div style="color: #f39c12; font-size: 13px; margin-bottom: 10px;">⚠️ {{.}}</div>{{end}}
+                    {{with index .Metrics.Data "bailOut"}}<div style="color: #e74c3c; font-size: 13px; margin-bottom: 10px;">Bail out! {{.}}</div>{{end}}
                     <div class="metrics-grid">
                         <div class="detail-item">
                             <div class="detail-label">Total Tests</div>
@@ -2241,9 +2481,33 @@ const runDetailTemplate = `<!DOCTYPE html>
                                 {{printf "%.3f" $avgDuration}}s per test
                             </div>
                             {{$testcases := index .Metrics.Data "testcases"}}
+                            {{with .TestDurations}}
+                            <div style="border-top: 1px solid #dee2e6; padding-top: 15px; margin-bottom: 15px;">
+                                <strong style="display: block; margin-bottom: 10px;">⏱ Slowest Tests:</strong>
+                                <table class="slowest-tests">
+                                    {{range .Slowest}}
+                                    <tr>
+                                        <td class="mono">{{printf "%.3f" .Seconds}}s</td>
+                                        <td>{{.Name}}{{if .Classname}} <span style="color: #7f8c8d; font-size: 11px;">({{.Classname}})</span>{{end}}</td>
+                                        <td style="color: {{if eq .Status "passed"}}#27ae60{{else if eq .Status "skipped"}}#f39c12{{else}}#e74c3c{{end}};">{{.Status}}</td>
+                                    </tr>
+                                    {{end}}
+                                </table>
+                            </div>
+                            <div style="margin-bottom: 15px;">
+                                <strong style="display: block; margin-bottom: 10px;">Duration Distribution:</strong>
+                                {{range .Buckets}}
+                                <div class="duration-bucket">
+                                    <span class="duration-bucket-label">{{.Label}}</span>
+                                    <span class="duration-bucket-bar"><span style="width: {{printf "%.1f" .Percent}}%;"></span></span>
+                                    <span class="duration-bucket-count">{{.Count}}</span>
+                                </div>
+                                {{end}}
+                            </div>
+                            {{end}}
                             {{if $testcases}}
-                            <div style="border-top: 1px solid #dee2e6; padding-top: 15px;">
-                                <strong style="display: block; margin-bottom: 10px;">Individual Test Cases:</strong>
+                            <details style="border-top: 1px solid #dee2e6; padding-top: 15px;" {{if and .TestDurations .TestDurations.ShowCases}}open{{end}}>
+                                <summary style="cursor: pointer; font-weight: 600; margin-bottom: 10px;">Individual Test Cases{{with .TestDurations}} ({{.Total}}){{end}}</summary>
                                 <div style="max-height: 400px; overflow-y: auto;">
                                     {{range $testcases}}
                                     <div style="padding: 8px; margin-bottom: 6px; background: #f8f9fa; border-left: 3px solid {{if eq .status "passed"}}#27ae60{{else if eq .status "failed"}}#e74c3c{{else if eq .status "error"}}#e74c3c{{else}}#f39c12{{end}}; border-radius: 3px; font-size: 12px;">
@@ -2267,7 +2531,7 @@ const runDetailTemplate = `<!DOCTYPE html>
                                     </div>
                                     {{end}}
                                 </div>
-                            </div>
+                            </details>
                             {{end}}
                         </div>
                     </details>
@@ -2287,12 +2551,13 @@ const runDetailTemplate = `<!DOCTYPE html>
                 
                 {{if .LogPath}}
                 <div class="detail-item" style="margin-top: 15px;">
-                    <div class="detail-label">Output (last 10 lines)</div>
-                    <pre style="background: #2c3e50; color: #ecf0f1; padding: 15px; border-radius: 4px; overflow-x: auto; font-size: 12px; line-height: 1.5;">{{range .LogPreview}}{{.}}
+                    <div class="detail-label">Output (last {{len .LogPreview}} lines)</div>
+                    <pre style="background: #2c3e50; color: #ecf0f1; padding: 15px; border-radius: 4px; overflow-x: auto; font-size: 12px; line-height: 1.5;">{{range .LogPreview}}{{if eq .Stream "stderr"}}<span style="color: #f5b7b1;">{{.Line}}</span>{{else}}{{.Line}}{{end}}
 {{end}}</pre>
                     <div style="display: flex; gap: 15px; margin-top: 10px;">
                         <a href="logs/{{.ID}}.log" class="log-link">📄 View raw log</a>
                         <a href="ide.html?file=logs/{{.ID}}.log" class="log-link">🖥️ View in web IDE</a>
+                        {{if .OutputPath}}<a href="{{.OutputPath}}" class="log-link">📦 View output file ({{.OutputSize}} bytes)</a>{{else if .OutputFile}}<span class="log-link" title="copyArtifacts = false: the run directory has no copy of the output file">📦 Output file not preserved: <span class="mono">{{.OutputFile}}</span></span>{{end}}
                     </div>
                 </div>
                 {{end}}
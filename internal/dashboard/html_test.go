@@ -1,6 +1,7 @@
 package dashboard
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -214,7 +215,7 @@ line 10`
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			lines := readLastLines(tt.path, tt.maxLines)
+			lines := readLastLines(tt.path, "", tt.maxLines)
 			if len(lines) != tt.wantLen {
 				t.Errorf("readLastLines() returned %d lines, want %d", len(lines), tt.wantLen)
 			}
@@ -231,7 +232,7 @@ func TestReadLastLinesContent(t *testing.T) {
 		t.Fatalf("Failed to write test file: %v", err)
 	}
 
-	lines := readLastLines(logPath, 3)
+	lines := readLastLines(logPath, "", 3)
 
 	if len(lines) != 3 {
 		t.Fatalf("Expected 3 lines, got %d", len(lines))
@@ -240,8 +241,8 @@ func TestReadLastLinesContent(t *testing.T) {
 	// Should get the last 3 lines
 	expected := []string{"line 3", "line 4", "line 5"}
 	for i, line := range lines {
-		if !strings.Contains(line, expected[i]) {
-			t.Errorf("Line %d: expected to contain %q, got %q", i, expected[i], line)
+		if !strings.Contains(line.Line, expected[i]) {
+			t.Errorf("Line %d: expected to contain %q, got %q", i, expected[i], line.Line)
 		}
 	}
 }
@@ -302,6 +303,63 @@ func TestWriteHTMLDashboard(t *testing.T) {
 	}
 }
 
+func TestWriteHTMLDashboardRunFilters(t *testing.T) {
+	htmlPath := filepath.Join(t.TempDir(), "test.html")
+	summary := Summary{
+		RecentRuns: []RunSummary{
+			{RunID: "run-2", Status: "FAIL", Command: "devpipe --only Lint", Branch: "main"},
+			{RunID: "run-1", Status: "PASS", Command: "devpipe"},
+		},
+	}
+
+	if err := writeHTMLDashboard(htmlPath, summary); err != nil {
+		t.Fatalf("writeHTMLDashboard() error = %v", err)
+	}
+	content, err := os.ReadFile(htmlPath)
+	if err != nil {
+		t.Fatalf("Failed to read HTML file: %v", err)
+	}
+	contentStr := string(content)
+
+	for _, want := range []string{`id="runStatusFilter"`, `<option value="fail">Failed only</option>`, `id="runSearch"`,
+		`data-status="fail" data-search="devpipe --only lint run-2 main "`, `data-status="pass" data-search="devpipe run-1  "`} {
+		if !strings.Contains(contentStr, want) {
+			t.Errorf("Expected HTML to contain %q", want)
+		}
+	}
+}
+
+func TestWriteHTMLDashboardFlakyTests(t *testing.T) {
+	tmpDir := t.TempDir()
+	htmlPath := filepath.Join(tmpDir, "test.html")
+
+	failedRuns := []string{"run-7", "run-6", "run-5", "run-4", "run-3", "run-2", "run-1"}
+	summary := Summary{
+		FlakyTests: []FlakyTest{
+			{TaskID: "unit", Name: "pkg.TestRace", Runs: 10, Failures: 7, Flips: 6, FlipRate: 0.6667, FailedRuns: failedRuns},
+		},
+	}
+
+	if err := writeHTMLDashboard(htmlPath, summary); err != nil {
+		t.Fatalf("writeHTMLDashboard() error = %v", err)
+	}
+
+	content, err := os.ReadFile(htmlPath)
+	if err != nil {
+		t.Fatalf("Failed to read HTML file: %v", err)
+	}
+	contentStr := string(content)
+
+	for _, want := range []string{"Flaky Tests", "pkg.TestRace", "67%", `href="runs/run-7/report.html"`, "+2 more"} {
+		if !strings.Contains(contentStr, want) {
+			t.Errorf("Expected HTML to contain %q", want)
+		}
+	}
+	if strings.Contains(contentStr, `href="runs/run-2/report.html"`) {
+		t.Error("Expected only the 5 most recent failed runs to be linked")
+	}
+}
+
 func TestWriteRunDetailHTML(t *testing.T) {
 	tmpDir := t.TempDir()
 	htmlPath := filepath.Join(tmpDir, "detail.html")
@@ -363,6 +421,37 @@ func TestWriteRunDetailHTML(t *testing.T) {
 	}
 }
 
+func TestWriteRunDetailHTMLLabels(t *testing.T) {
+	htmlPath := filepath.Join(t.TempDir(), "detail.html")
+
+	run := model.RunRecord{
+		RunID:     "labelled-run",
+		Timestamp: "2024-01-15T10:00:00Z",
+		Labels:    model.Labels{"pr": "1234", "ci": "true"},
+		Tasks: []model.TaskResult{
+			{ID: "task1", Name: "Test Task", Status: model.StatusPass, Labels: model.Labels{"owner": "web"}},
+		},
+	}
+
+	if err := writeRunDetailHTML(htmlPath, run); err != nil {
+		t.Fatalf("writeRunDetailHTML() error = %v", err)
+	}
+	content, err := os.ReadFile(htmlPath)
+	if err != nil {
+		t.Fatalf("Failed to read detail HTML file: %v", err)
+	}
+
+	// Run labels are rendered in key order, task labels next to the task
+	for _, want := range []string{
+		`<span class="label-chip">ci=true</span><span class="label-chip">pr=1234</span>`,
+		`<span class="label-chip">owner=web</span>`,
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected HTML to contain %s", want)
+		}
+	}
+}
+
 func TestGetLocalTimezone(t *testing.T) {
 	tz := getLocalTimezone()
 
@@ -443,19 +532,19 @@ func TestReadLastLinesWithANSI(t *testing.T) {
 		t.Fatalf("Failed to write log file: %v", err)
 	}
 
-	lines := readLastLines(logPath, 10)
+	lines := readLastLines(logPath, "", 10)
 
 	// ANSI codes should be stripped
 	for _, line := range lines {
-		if strings.Contains(line, "\x1b") {
-			t.Errorf("Expected ANSI codes to be stripped, got line: %q", line)
+		if strings.Contains(line.Line, "\x1b") {
+			t.Errorf("Expected ANSI codes to be stripped, got line: %q", line.Line)
 		}
 	}
 
 	// Should contain the text content
 	found := false
 	for _, line := range lines {
-		if strings.Contains(line, "Red line") || strings.Contains(line, "Green line") {
+		if strings.Contains(line.Line, "Red line") || strings.Contains(line.Line, "Green line") {
 			found = true
 			break
 		}
@@ -465,15 +554,36 @@ func TestReadLastLinesWithANSI(t *testing.T) {
 	}
 }
 
+func TestReadLastLinesJSONL(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "jsonl.log")
+
+	content := `{"ts":"2025-01-01T00:00:00Z","task":"lint","stream":"stdout","line":"\u001b[32mok\u001b[0m"}
+{"ts":"2025-01-01T00:00:01Z","task":"lint","stream":"stderr","line":"warning: unused"}
+`
+	if err := os.WriteFile(logPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write log file: %v", err)
+	}
+
+	lines := readLastLines(logPath, "jsonl", 10)
+
+	if len(lines) != 2 || lines[0].Line != "ok" || lines[1].Line != "warning: unused" {
+		t.Fatalf("readLastLines() = %+v, want the output lines without JSON or ANSI codes", lines)
+	}
+	if lines[0].Stream != "stdout" || lines[1].Stream != "stderr" {
+		t.Errorf("readLastLines() streams = %q, %q; want stdout, stderr", lines[0].Stream, lines[1].Stream)
+	}
+}
+
 func TestReadLastLinesNonexistentFile(t *testing.T) {
-	lines := readLastLines("/nonexistent/file.log", 10)
+	lines := readLastLines("/nonexistent/file.log", "", 10)
 
 	if len(lines) != 1 {
 		t.Errorf("Expected 1 error line, got %d", len(lines))
 	}
 
-	if !strings.Contains(lines[0], "Error") {
-		t.Errorf("Expected error message, got: %q", lines[0])
+	if !strings.Contains(lines[0].Line, "Error") {
+		t.Errorf("Expected error message, got: %q", lines[0].Line)
 	}
 }
 
@@ -577,6 +687,78 @@ command = "echo test"
 	}
 }
 
+func TestWriteRunDetailHTMLLogPreviewLines(t *testing.T) {
+	runDir := t.TempDir()
+	logPath := filepath.Join(runDir, "task.log")
+	var log strings.Builder
+	for i := 1; i <= 40; i++ {
+		fmt.Fprintf(&log, "line %d\n", i)
+	}
+	if err := os.WriteFile(logPath, []byte(log.String()), 0644); err != nil {
+		t.Fatalf("Failed to write log file: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		preview   int
+		wantFirst string
+		wantCount string
+	}{
+		{"configured count", 25, "line 16\n", "Output (last 25 lines)"},
+		{"older runs default to 10", 0, "line 31\n", "Output (last 10 lines)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			htmlPath := filepath.Join(runDir, "report.html")
+			run := model.RunRecord{
+				RunID: "run-1",
+				Tasks: []model.TaskResult{{ID: "test", Status: model.StatusFail, LogPath: logPath, LogPreviewLines: tt.preview}},
+			}
+			if err := writeRunDetailHTML(htmlPath, run); err != nil {
+				t.Fatalf("writeRunDetailHTML() error = %v", err)
+			}
+			content, err := os.ReadFile(htmlPath)
+			if err != nil {
+				t.Fatalf("Failed to read HTML file: %v", err)
+			}
+			html := string(content)
+			if !strings.Contains(html, tt.wantCount) || !strings.Contains(html, ">"+tt.wantFirst) {
+				t.Errorf("expected %q starting at %q in the preview", tt.wantCount, tt.wantFirst)
+			}
+			if !strings.Contains(html, `href="logs/test.log"`) {
+				t.Error("expected the raw log link")
+			}
+		})
+	}
+}
+
+func TestWriteRunDetailHTMLUncopiedOutput(t *testing.T) {
+	runDir := t.TempDir()
+	logPath := filepath.Join(runDir, "task.log")
+	if err := os.WriteFile(logPath, []byte("done\n"), 0644); err != nil {
+		t.Fatalf("Failed to write log file: %v", err)
+	}
+	htmlPath := filepath.Join(runDir, "report.html")
+	run := model.RunRecord{
+		RunID: "run-1",
+		Tasks: []model.TaskResult{{ID: "coverage", Status: model.StatusPass, LogPath: logPath, OutputFile: "/src/coverage/lcov.info"}},
+	}
+	if err := writeRunDetailHTML(htmlPath, run); err != nil {
+		t.Fatalf("writeRunDetailHTML() error = %v", err)
+	}
+	content, err := os.ReadFile(htmlPath)
+	if err != nil {
+		t.Fatalf("Failed to read HTML file: %v", err)
+	}
+	html := string(content)
+	if !strings.Contains(html, "Output file not preserved") || !strings.Contains(html, "/src/coverage/lcov.info") {
+		t.Error("expected the original output path marked as not preserved")
+	}
+	if strings.Contains(html, "View output file") {
+		t.Error("did not expect a link to a copy of the output file")
+	}
+}
+
 func TestWriteRunDetailHTMLWithMetricsData(t *testing.T) {
 	tmpDir := t.TempDir()
 	htmlPath := filepath.Join(tmpDir, "detail.html")
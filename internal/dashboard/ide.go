@@ -6,22 +6,25 @@ import (
 	"html/template"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/acarl005/stripansi"
+	"github.com/drew/devpipe/internal/model"
 )
 
 // FileInfo represents a file in the IDE file tree
 type FileInfo struct {
-	Name    string `json:"name"`
-	Path    string `json:"path"`
-	Size    int64  `json:"size"`
-	Content string `json:"content"`
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	Content  string `json:"content"`
+	Artifact bool   `json:"artifact,omitempty"` // Output copy stored outside outputs/ by artifactDir
 }
 
 // writeIDEViewer generates the IDE viewer HTML page for a run
-func writeIDEViewer(path, runID, runDir string) error {
+func writeIDEViewer(path, runID, runDir string, tasks []model.TaskResult) error {
 	// Collect files list
-	files := collectFiles(runDir)
+	files := append(collectFiles(runDir), collectArtifacts(runDir, tasks)...)
 
 	// Convert to JSON string
 	filesJSON, err := json.Marshal(files)
@@ -136,6 +139,30 @@ func collectFiles(runDir string) []FileInfo {
 	return files
 }
 
+// collectArtifacts gathers output copies stored outside outputs/ by a task's artifactDir
+func collectArtifacts(runDir string, tasks []model.TaskResult) []FileInfo {
+	var files []FileInfo
+	for _, task := range tasks {
+		if task.Artifact == "" || strings.HasPrefix(task.Artifact, "outputs/") {
+			continue
+		}
+		path := filepath.Join(runDir, filepath.FromSlash(task.Artifact))
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		content, _ := os.ReadFile(path)
+		files = append(files, FileInfo{
+			Name:     task.Artifact,
+			Path:     task.Artifact,
+			Size:     info.Size(),
+			Content:  stripansi.Strip(string(content)),
+			Artifact: true,
+		})
+	}
+	return files
+}
+
 const ideTemplate = `<!DOCTYPE html>
 <html lang="en">
 <head>
@@ -399,7 +426,7 @@ const ideTemplate = `<!DOCTYPE html>
             files.forEach(file => {
                 if (file.path.startsWith('logs/')) {
                     structure.logs.push(file);
-                } else if (file.path.startsWith('outputs/')) {
+                } else if (file.path.startsWith('outputs/') || file.artifact) {
                     structure.outputs.push(file);
                 } else {
                     structure.root.push(file);
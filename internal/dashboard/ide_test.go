@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/drew/devpipe/internal/model"
 )
 
 func TestWriteIDEViewer(t *testing.T) {
@@ -21,7 +23,7 @@ func TestWriteIDEViewer(t *testing.T) {
 	}
 
 	idePath := filepath.Join(tmpDir, "ide.html")
-	err := writeIDEViewer(idePath, "run-123", runDir)
+	err := writeIDEViewer(idePath, "run-123", runDir, nil)
 	if err != nil {
 		t.Fatalf("writeIDEViewer() error = %v", err)
 	}
@@ -196,7 +198,7 @@ func TestWriteIDEViewerWithInvalidJSON(t *testing.T) {
 	idePath := filepath.Join(tmpDir, "ide.html")
 
 	// This should not fail even with no files
-	err := writeIDEViewer(idePath, "test-run", runDir)
+	err := writeIDEViewer(idePath, "test-run", runDir, nil)
 	if err != nil {
 		t.Fatalf("writeIDEViewer() should handle empty file list, error = %v", err)
 	}
@@ -273,7 +275,7 @@ func TestWriteIDEViewerError(t *testing.T) {
 	invalidPath := "/invalid/path/that/does/not/exist/ide.html"
 	runDir := "/some/run/dir"
 
-	err := writeIDEViewer(invalidPath, "test-run", runDir)
+	err := writeIDEViewer(invalidPath, "test-run", runDir, nil)
 	if err == nil {
 		t.Error("Expected error when writing to invalid path")
 	}
@@ -333,3 +335,28 @@ func TestCollectFilesAllFileTypes(t *testing.T) {
 		}
 	}
 }
+
+func TestCollectArtifacts(t *testing.T) {
+	runDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(runDir, "artifacts", "web"), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(runDir, "artifacts", "web", "junit.xml"), []byte("<testsuites/>"), 0644); err != nil {
+		t.Fatalf("Failed to write artifact: %v", err)
+	}
+
+	tasks := []model.TaskResult{
+		{ID: "web-test", Artifact: "artifacts/web/junit.xml"},
+		{ID: "go-test", Artifact: "outputs/junit.xml"}, // Already collected from outputs/
+		{ID: "gone", Artifact: "artifacts/missing.xml"},
+		{ID: "lint"},
+	}
+
+	files := collectArtifacts(runDir, tasks)
+	if len(files) != 1 {
+		t.Fatalf("collectArtifacts() returned %d files, want 1: %+v", len(files), files)
+	}
+	if files[0].Path != "artifacts/web/junit.xml" || !files[0].Artifact || files[0].Content != "<testsuites/>" {
+		t.Errorf("collectArtifacts() = %+v, want the artifactDir copy", files[0])
+	}
+}
@@ -0,0 +1,150 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ImportResult describes an ImportRuns
+type ImportResult struct {
+	Imported   []string // Run ids copied into the output root
+	Duplicates []string // Run ids the output root already had, left as they were
+	Incomplete []string // Run directories without a run.json (e.g. a run still in progress), not copied
+}
+
+// ImportRuns copies the run directories of the devpipe output root srcRoot into outputRoot,
+// skipping run ids outputRoot already has. Each run is copied to a temporary directory and
+// renamed into place, so an interrupted import leaves no partial runs behind. Reindex
+// afterwards to add the runs to summary.json
+func ImportRuns(outputRoot, srcRoot string) (ImportResult, error) {
+	var result ImportResult
+	srcRuns := filepath.Join(srcRoot, "runs")
+	if info, err := os.Stat(srcRuns); err != nil || !info.IsDir() {
+		return result, fmt.Errorf("%s does not look like a devpipe output root: it has no runs directory", srcRoot)
+	}
+	runsDir := filepath.Join(outputRoot, "runs")
+	if err := os.MkdirAll(runsDir, 0755); err != nil {
+		return result, err
+	}
+	if same, _ := sameDir(srcRuns, runsDir); same {
+		return result, fmt.Errorf("%s is the local output root", srcRoot)
+	}
+
+	entries, err := os.ReadDir(srcRuns)
+	if err != nil {
+		return result, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		id := entry.Name()
+		src := filepath.Join(srcRuns, id)
+		if _, err := os.Stat(filepath.Join(src, "run.json")); err != nil {
+			result.Incomplete = append(result.Incomplete, id)
+			continue
+		}
+		dst := filepath.Join(runsDir, id)
+		if _, err := os.Lstat(dst); err == nil {
+			result.Duplicates = append(result.Duplicates, id)
+			continue
+		}
+		if err := copyRun(outputRoot, src, dst); err != nil {
+			return result, fmt.Errorf("failed to import run %s: %w", id, err)
+		}
+		result.Imported = append(result.Imported, id)
+	}
+	return result, nil
+}
+
+// copyRun copies the run directory src to dst through a temporary directory in outputRoot
+func copyRun(outputRoot, src, dst string) error {
+	tmp, err := os.MkdirTemp(outputRoot, ".import-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	staged := filepath.Join(tmp, "run")
+	if err := os.CopyFS(staged, os.DirFS(src)); err != nil {
+		return err
+	}
+	if err := rebaseRunPaths(filepath.Join(staged, "run.json"), filepath.Base(src), dst); err != nil {
+		return err
+	}
+	return os.Rename(staged, dst)
+}
+
+// rebaseRunPaths points the absolute logPath and artifact of each task in the run.json at path,
+// recorded where run id was written (often another machine), into the run directory dst.
+// Other fields are kept as they were
+func rebaseRunPaths(path, id, dst string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // Keep numbers as written
+	var record map[string]any
+	if err := decoder.Decode(&record); err != nil {
+		return nil // Imported as it is, like any other run.json reindex cannot read
+	}
+
+	tasks, _ := record["tasks"].([]any)
+	changed := false
+	for _, t := range tasks {
+		task, ok := t.(map[string]any)
+		if !ok {
+			continue
+		}
+		for _, key := range []string{"logPath", "artifact"} {
+			old, _ := task[key].(string)
+			if rebased, ok := rebasePath(old, id, dst); ok {
+				task[key] = rebased
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	data, err = json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// rebasePath maps p, an absolute path inside a runs/<id> directory written on any OS, to the
+// same file under dst. Relative paths are already relative to the run directory and are kept
+func rebasePath(p, id, dst string) (string, bool) {
+	slashed := strings.ReplaceAll(p, `\`, "/")
+	driveAbs := len(slashed) > 2 && slashed[1] == ':' && slashed[2] == '/' // e.g. C:/runs
+	if !strings.HasPrefix(slashed, "/") && !driveAbs {
+		return "", false
+	}
+	marker := "/runs/" + id + "/"
+	i := strings.LastIndex(slashed, marker)
+	if i == -1 {
+		return "", false
+	}
+	return filepath.Join(dst, filepath.FromSlash(slashed[i+len(marker):])), true
+}
+
+// sameDir reports whether a and b are the same directory
+func sameDir(a, b string) (bool, error) {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(infoA, infoB), nil
+}
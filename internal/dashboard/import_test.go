@@ -0,0 +1,83 @@
+package dashboard
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/drew/devpipe/internal/model"
+)
+
+func TestImportRuns(t *testing.T) {
+	writeRun := func(root, id string) {
+		t.Helper()
+		dir := filepath.Join(root, "runs", id)
+		if err := os.MkdirAll(filepath.Join(dir, "logs"), 0755); err != nil {
+			t.Fatalf("Failed to create run dir: %v", err)
+		}
+		// Paths as recorded on the machine that ran it
+		run := model.RunRecord{RunID: id, Timestamp: time.Now().Format(time.RFC3339), Tasks: []model.TaskResult{
+			{ID: "lint", LogPath: "/home/ci/src/.devpipe/runs/" + id + "/logs/lint.log", Artifact: "outputs/lint.xml"},
+			{ID: "test", LogPath: `C:\ci\.devpipe\runs\` + id + `\logs\test.log`},
+		}}
+		if err := writeRunJSON(filepath.Join(dir, "run.json"), run); err != nil {
+			t.Fatalf("writeRunJSON() error = %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "logs", "lint.log"), []byte(root), 0644); err != nil {
+			t.Fatalf("Failed to write log: %v", err)
+		}
+	}
+
+	local := t.TempDir()
+	other := t.TempDir()
+	writeRun(local, "shared")
+	writeRun(other, "shared")
+	writeRun(other, "ci-1")
+	if err := os.MkdirAll(filepath.Join(other, "runs", "running"), 0755); err != nil {
+		t.Fatalf("Failed to create run dir: %v", err)
+	}
+
+	result, err := ImportRuns(local, other)
+	if err != nil {
+		t.Fatalf("ImportRuns() error = %v", err)
+	}
+	want := ImportResult{Imported: []string{"ci-1"}, Duplicates: []string{"shared"}, Incomplete: []string{"running"}}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("ImportRuns() = %+v, want %+v", result, want)
+	}
+
+	// The imported run is copied whole, the local copy of a duplicate is left alone
+	if data, err := os.ReadFile(filepath.Join(local, "runs", "ci-1", "logs", "lint.log")); err != nil || string(data) != other {
+		t.Errorf("imported log = %q, %v; want the source run's log", data, err)
+	}
+	// Its log paths point at the imported copy; relative paths stay relative
+	imported, err := LoadRun(local, "ci-1")
+	if err != nil {
+		t.Fatalf("imported run.json: %v", err)
+	}
+	logs := filepath.Join(local, "runs", "ci-1", "logs")
+	if got := imported.Tasks[0]; got.LogPath != filepath.Join(logs, "lint.log") || got.Artifact != "outputs/lint.xml" {
+		t.Errorf("lint = %q, %q; want the imported log and the artifact unchanged", got.LogPath, got.Artifact)
+	}
+	if got := imported.Tasks[1].LogPath; got != filepath.Join(logs, "test.log") {
+		t.Errorf("test log = %q, want the imported log", got)
+	}
+	if imported.RunID != "ci-1" || imported.Timestamp == "" {
+		t.Errorf("imported run = %+v, want its other fields kept", imported)
+	}
+	if data, _ := os.ReadFile(filepath.Join(local, "runs", "shared", "logs", "lint.log")); string(data) != local {
+		t.Errorf("duplicate run's log = %q, want the local one kept", data)
+	}
+	if entries, _ := filepath.Glob(filepath.Join(local, ".import-*")); len(entries) != 0 {
+		t.Errorf("ImportRuns() left temporary directories behind: %v", entries)
+	}
+
+	if _, err := ImportRuns(local, t.TempDir()); err == nil {
+		t.Error("ImportRuns() from a directory without runs/ should fail")
+	}
+	if _, err := ImportRuns(local, local); err == nil {
+		t.Error("ImportRuns() from the local output root should fail")
+	}
+}
@@ -0,0 +1,111 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/drew/devpipe/internal/model"
+)
+
+// Statuses a task has in the live state before it has a result
+const (
+	LivePending = "PENDING"
+	LiveRunning = "RUNNING"
+)
+
+// LiveState is the progress of the current run, written to <outputRoot>/live.json while it runs
+// so devpipe serve can push it to open dashboards
+type LiveState struct {
+	RunID     string     `json:"runId"`
+	StartTime string     `json:"startTime"`
+	UpdatedAt string     `json:"updatedAt"`
+	Done      bool       `json:"done"`
+	Tasks     []LiveTask `json:"tasks"`
+}
+
+// LiveTask is one task's status in the live state
+type LiveTask struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// LiveRun keeps a run's LiveState up to date on disk; it is safe for concurrent use
+type LiveRun struct {
+	mu    sync.Mutex
+	path  string
+	state LiveState
+}
+
+// NewLiveRun starts the live state of a run with every task pending
+func NewLiveRun(outputRoot, runID string, taskIDs []string) *LiveRun {
+	tasks := make([]LiveTask, len(taskIDs))
+	for i, id := range taskIDs {
+		tasks[i] = LiveTask{ID: id, Status: LivePending}
+	}
+	l := &LiveRun{
+		path: liveStatePath(outputRoot),
+		state: LiveState{
+			RunID:     runID,
+			StartTime: time.Now().UTC().Format(time.RFC3339),
+			Tasks:     tasks,
+		},
+	}
+	l.write()
+	return l
+}
+
+// Set records a task's status
+func (l *LiveRun) Set(taskID, status string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.state.Tasks {
+		if l.state.Tasks[i].ID == taskID {
+			l.state.Tasks[i].Status = status
+		}
+	}
+	l.write()
+}
+
+// Finish records the final results, which auto-fix may have changed, and marks the run done
+// once its reports have been generated
+func (l *LiveRun) Finish(results []model.TaskResult) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	status := make(map[string]string, len(results))
+	for _, res := range results {
+		status[res.ID] = string(res.Status)
+	}
+	for i := range l.state.Tasks {
+		if s, ok := status[l.state.Tasks[i].ID]; ok {
+			l.state.Tasks[i].Status = s
+		}
+	}
+	l.state.Done = true
+	l.write()
+}
+
+// write saves the state; the file is replaced atomically so readers never see a partial write.
+// Failures are ignored: the live state only feeds the served dashboard
+func (l *LiveRun) write() {
+	l.state.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	data, err := json.Marshal(l.state)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, l.path)
+}
+
+// liveStatePath is where the live state of the current run is kept
+func liveStatePath(outputRoot string) string {
+	return filepath.Join(outputRoot, "live.json")
+}
@@ -0,0 +1,215 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/drew/devpipe/internal/model"
+)
+
+// pollInterval is how often /events checks the live state for changes (a variable so tests can shorten it)
+var pollInterval = time.Second
+
+// NewServer serves the dashboards under outputRoot. HTML pages get a script that follows
+// /events, a server-sent event stream of the live state, to show the progress of a running
+// pipeline and reload when a run starts or finishes. A run's report page that was never
+// generated (the run is older than dashboardRecentLimit) is generated when it is first opened
+func NewServer(outputRoot string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		serveEvents(w, r, outputRoot)
+	})
+	files := http.FileServer(http.Dir(outputRoot))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		name := path.Clean("/" + r.URL.Path)
+		if name == "/" {
+			name = "/report.html"
+		}
+		if !strings.HasSuffix(name, ".html") {
+			files.ServeHTTP(w, r)
+			return
+		}
+		if id, ok := runReportID(name); ok {
+			ensureRunReport(outputRoot, id)
+		}
+		serveHTML(w, outputRoot, name)
+	})
+	return mux
+}
+
+// runReportID returns the run ID of a run's report page, /runs/<id>/report.html
+func runReportID(name string) (string, bool) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 4 || parts[1] != "runs" || parts[2] == "" || parts[3] != "report.html" {
+		return "", false
+	}
+	return parts[2], true
+}
+
+// ensureRunReport generates the report pages of run id when it has a run.json but no report.html
+func ensureRunReport(outputRoot, id string) {
+	runDir := filepath.Join(outputRoot, "runs", id)
+	if _, err := os.Stat(filepath.Join(runDir, "report.html")); err == nil {
+		return
+	}
+	data, err := os.ReadFile(filepath.Join(runDir, "run.json"))
+	if err != nil {
+		return
+	}
+	var run model.RunRecord
+	if err := json.Unmarshal(data, &run); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: cannot generate report for run %s: %v\n", id, err)
+		return
+	}
+	if err := writeRunPages(runDir, run); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: failed to generate report for run %s: %v\n", id, err)
+	}
+}
+
+// serveHTML serves a dashboard page with the live script added
+func serveHTML(w http.ResponseWriter, outputRoot, name string) {
+	page, err := readFile(http.Dir(outputRoot), name)
+	if err != nil {
+		if name != "/report.html" {
+			http.Error(w, "404 page not found", http.StatusNotFound)
+			return
+		}
+		// No run has finished yet; the live script reloads once one does
+		page = []byte("<!DOCTYPE html><html><head><meta charset=\"UTF-8\"><title>devpipe</title></head>" +
+			"<body style=\"font-family: sans-serif; padding: 40px;\"><h1>devpipe</h1><p>No runs yet. This page updates when a run finishes.</p></body></html>")
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if i := bytes.LastIndex(page, []byte("</body>")); i >= 0 {
+		_, _ = w.Write(page[:i])
+		_, _ = io.WriteString(w, liveScript)
+		_, _ = w.Write(page[i:])
+		return
+	}
+	_, _ = w.Write(page)
+	_, _ = io.WriteString(w, liveScript)
+}
+
+// readFile reads name from fsys, which rejects paths outside its root
+func readFile(fsys http.FileSystem, name string) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return io.ReadAll(f)
+}
+
+// serveEvents streams the live state whenever it changes until the client disconnects
+func serveEvents(w http.ResponseWriter, r *http.Request, outputRoot string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var last []byte
+	for {
+		if data, err := os.ReadFile(liveStatePath(outputRoot)); err == nil && !bytes.Equal(data, last) {
+			last = data
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// OpenBrowser opens target, a URL or file path, in the default browser. It is a no-op on
+// unsupported platforms and when the opener program is not installed
+func OpenBrowser(target string) error {
+	name, args := browserCommand(runtime.GOOS, target)
+	if name == "" {
+		return nil
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return nil
+	}
+	return exec.Command(name, args...).Start()
+}
+
+// Headless reports whether there is no one to show a browser to: in CI, over a non-terminal
+// stdout, or on Linux without an X11 or Wayland display
+func Headless(goos string, getenv func(string) string, tty bool) bool {
+	if getenv("CI") != "" || !tty {
+		return true
+	}
+	return goos == "linux" && getenv("DISPLAY") == "" && getenv("WAYLAND_DISPLAY") == ""
+}
+
+// browserCommand returns the command that opens url on the given OS
+func browserCommand(goos, url string) (string, []string) {
+	switch goos {
+	case "darwin":
+		return "open", []string{url}
+	case "linux":
+		return "xdg-open", []string{url}
+	case "windows":
+		return "rundll32", []string{"url.dll,FileProtocolHandler", url}
+	default:
+		return "", nil
+	}
+}
+
+// liveScript shows a progress banner while a run is active and reloads the page when a run
+// starts or finishes, so the report always shows the latest results
+const liveScript = `<script>
+(function () {
+    if (!window.EventSource) return;
+    var banner = document.createElement('div');
+    banner.style.cssText = 'position: fixed; bottom: 16px; right: 16px; z-index: 1000; display: none; padding: 10px 16px; border-radius: 6px; background: #2c3e50; color: #ecf0f1; font: 13px -apple-system, BlinkMacSystemFont, sans-serif; box-shadow: 0 2px 8px rgba(0,0,0,0.3);';
+    document.body.appendChild(banner);
+
+    var seen = null;
+    new EventSource('/events').onmessage = function (e) {
+        var state = JSON.parse(e.data);
+        if (seen && (seen.runId !== state.runId || seen.done !== state.done)) {
+            location.reload();
+            return;
+        }
+        seen = state;
+        if (state.done) {
+            banner.style.display = 'none';
+            return;
+        }
+        var finished = 0, running = [];
+        state.tasks.forEach(function (t) {
+            if (t.status === 'RUNNING') running.push(t.id);
+            else if (t.status !== 'PENDING') finished++;
+        });
+        banner.textContent = '● Live: ' + finished + '/' + state.tasks.length + ' tasks done' +
+            (running.length ? ', running ' + running.join(', ') : '');
+        banner.style.display = 'block';
+    };
+})();
+</script>
+`
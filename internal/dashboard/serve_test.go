@@ -0,0 +1,175 @@
+package dashboard
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drew/devpipe/internal/model"
+)
+
+func TestNewServer(t *testing.T) {
+	root := t.TempDir()
+	srv := httptest.NewServer(NewServer(root))
+	defer srv.Close()
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	// Before any run there is a placeholder that still follows the live state
+	if code, body := get("/"); code != http.StatusOK || !strings.Contains(body, "No runs yet") || !strings.Contains(body, "/events") {
+		t.Errorf("GET / without report = %d %q, want placeholder with live script", code, body)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "report.html"), []byte("<html><body><h1>Runs</h1></body></html>"), 0o644); err != nil {
+		t.Fatalf("failed to write report: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "summary.json"), []byte(`{"totalRuns":1}`), 0o644); err != nil {
+		t.Fatalf("failed to write summary: %v", err)
+	}
+
+	_, body := get("/")
+	if !strings.Contains(body, "<h1>Runs</h1>") || !strings.HasSuffix(body, "</script>\n</body></html>") {
+		t.Errorf("GET / = %q, want report with the live script before </body>", body)
+	}
+	if _, body := get("/summary.json"); body != `{"totalRuns":1}` {
+		t.Errorf("GET /summary.json = %q, want the file unchanged", body)
+	}
+	if code, _ := get("/runs/missing/report.html"); code != http.StatusNotFound {
+		t.Errorf("GET missing page = %d, want 404", code)
+	}
+}
+
+func TestNewServerGeneratesMissingRunReport(t *testing.T) {
+	root := t.TempDir()
+	runDir := filepath.Join(root, "runs", "old-run")
+	if err := os.MkdirAll(runDir, 0o755); err != nil {
+		t.Fatalf("failed to create run dir: %v", err)
+	}
+	run := model.RunRecord{RunID: "old-run", Timestamp: time.Now().Format(time.RFC3339)}
+	data, _ := json.Marshal(run)
+	if err := os.WriteFile(filepath.Join(runDir, "run.json"), data, 0o644); err != nil {
+		t.Fatalf("failed to write run.json: %v", err)
+	}
+	srv := httptest.NewServer(NewServer(root))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/runs/old-run/report.html")
+	if err != nil {
+		t.Fatalf("GET report: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "old-run") {
+		t.Errorf("GET report = %d, want the generated report for old-run", resp.StatusCode)
+	}
+	if _, err := os.Stat(filepath.Join(runDir, "report.html")); err != nil {
+		t.Errorf("Expected report.html to be written: %v", err)
+	}
+}
+
+func TestServeEvents(t *testing.T) {
+	oldInterval := pollInterval
+	pollInterval = 10 * time.Millisecond
+	defer func() { pollInterval = oldInterval }()
+
+	root := t.TempDir()
+	live := NewLiveRun(root, "run-1", []string{"lint", "test"})
+
+	srv := httptest.NewServer(NewServer(root))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatalf("GET /events: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	events := bufio.NewScanner(resp.Body)
+	next := func() LiveState {
+		t.Helper()
+		for events.Scan() {
+			if data, ok := strings.CutPrefix(events.Text(), "data: "); ok {
+				var state LiveState
+				if err := json.Unmarshal([]byte(data), &state); err != nil {
+					t.Fatalf("invalid event %q: %v", data, err)
+				}
+				return state
+			}
+		}
+		t.Fatalf("event stream ended: %v", events.Err())
+		return LiveState{}
+	}
+
+	if state := next(); state.RunID != "run-1" || state.Done || state.Tasks[0].Status != LivePending {
+		t.Errorf("first event = %+v, want run-1 with pending tasks", state)
+	}
+
+	live.Set("lint", LiveRunning)
+	if state := next(); state.Tasks[0].Status != LiveRunning {
+		t.Errorf("event after Set = %+v, want lint running", state)
+	}
+
+	live.Finish([]model.TaskResult{{ID: "lint", Status: model.StatusPass}, {ID: "test", Status: model.StatusFail}})
+	state := next()
+	if !state.Done || state.Tasks[0].Status != "PASS" || state.Tasks[1].Status != "FAIL" {
+		t.Errorf("event after Finish = %+v, want done with final statuses", state)
+	}
+}
+
+func TestHeadless(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+	tests := []struct {
+		name string
+		goos string
+		env  map[string]string
+		tty  bool
+		want bool
+	}{
+		{"macOS terminal", "darwin", nil, true, false},
+		{"CI", "darwin", map[string]string{"CI": "true"}, true, true},
+		{"piped output", "darwin", nil, false, true},
+		{"linux without display", "linux", nil, true, true},
+		{"linux with X11", "linux", map[string]string{"DISPLAY": ":0"}, true, false},
+		{"linux with Wayland", "linux", map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Headless(tt.goos, env(tt.env), tt.tty); got != tt.want {
+				t.Errorf("Headless() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBrowserCommand(t *testing.T) {
+	if name, args := browserCommand("darwin", "http://localhost:8080/"); name != "open" || args[0] != "http://localhost:8080/" {
+		t.Errorf("darwin = %s %v, want open <url>", name, args)
+	}
+	if name, _ := browserCommand("linux", "http://localhost:8080/"); name != "xdg-open" {
+		t.Errorf("linux = %s, want xdg-open", name)
+	}
+	if name, _ := browserCommand("plan9", "http://localhost:8080/"); name != "" {
+		t.Errorf("plan9 = %s, want unsupported", name)
+	}
+}
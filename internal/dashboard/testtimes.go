@@ -0,0 +1,85 @@
+package dashboard
+
+import (
+	"sort"
+
+	"github.com/drew/devpipe/internal/model"
+)
+
+// slowestTestCount is how many of the slowest tests a run's page lists per task
+const slowestTestCount = 10
+
+// testCaseListLimit is the largest suite whose individual test cases are expanded by default;
+// bigger ones start collapsed behind the slowest tests and the duration distribution
+const testCaseListLimit = 100
+
+// durationBuckets bound the test duration distribution, in seconds
+var durationBuckets = []struct {
+	label string
+	upTo  float64
+}{
+	{"< 10ms", 0.01},
+	{"10-100ms", 0.1},
+	{"100ms-1s", 1},
+	{"1-10s", 10},
+	{"≥ 10s", 0},
+}
+
+// SlowTest is one of a task's slowest test cases
+type SlowTest struct {
+	Name      string
+	Classname string
+	Status    string
+	Seconds   float64
+}
+
+// DurationBucket counts the test cases whose duration falls in one range
+type DurationBucket struct {
+	Label   string
+	Count   int
+	Percent float64 // Share of all test cases (0-100), for the bar width
+}
+
+// TestDurations summarizes how long a task's test cases took
+type TestDurations struct {
+	Total     int
+	Slowest   []SlowTest       // Longest first
+	Buckets   []DurationBucket // Shortest range first
+	ShowCases bool             // Small enough to expand every test case by default
+}
+
+// summarizeTestDurations finds the slowest test cases in a task's JUnit metrics and buckets
+// their durations, or returns nil when the task has no test cases
+func summarizeTestDurations(task model.TaskResult) *TestDurations {
+	raw := rawTestCases(task)
+	if len(raw) == 0 {
+		return nil
+	}
+
+	d := &TestDurations{Total: len(raw), ShowCases: len(raw) <= testCaseListLimit}
+	for _, b := range durationBuckets {
+		d.Buckets = append(d.Buckets, DurationBucket{Label: b.label})
+	}
+	tests := make([]SlowTest, 0, len(raw))
+	for _, tc := range raw {
+		t := SlowTest{Seconds: toFloat64(tc["time"])}
+		t.Name, _ = tc["name"].(string)
+		t.Classname, _ = tc["classname"].(string)
+		t.Status, _ = tc["status"].(string)
+		tests = append(tests, t)
+
+		i := 0
+		for i < len(durationBuckets)-1 && t.Seconds >= durationBuckets[i].upTo {
+			i++
+		}
+		d.Buckets[i].Count++
+	}
+	for i := range d.Buckets {
+		d.Buckets[i].Percent = float64(d.Buckets[i].Count) * 100 / float64(d.Total)
+	}
+
+	// Ties keep the suite's order, so the list does not shuffle between page builds
+	sort.SliceStable(tests, func(i, j int) bool { return tests[i].Seconds > tests[j].Seconds })
+	d.Slowest = tests[:min(slowestTestCount, len(tests))]
+	return d
+}
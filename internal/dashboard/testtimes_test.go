@@ -0,0 +1,45 @@
+package dashboard
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/drew/devpipe/internal/model"
+)
+
+func TestSummarizeTestDurations(t *testing.T) {
+	if d := summarizeTestDurations(model.TaskResult{ID: "lint"}); d != nil {
+		t.Errorf("summarizeTestDurations() without test cases = %+v, want nil", d)
+	}
+
+	// As read back from run.json
+	var testcases []interface{}
+	for i, seconds := range []float64{0.001, 0.002, 0.05, 0.5, 2, 12, 0.002, 0.5, 3, 0.05, 0.2, 0.004} {
+		testcases = append(testcases, map[string]interface{}{
+			"name": string(rune('a' + i)), "classname": "pkg", "status": "passed", "time": seconds,
+		})
+	}
+	task := model.TaskResult{ID: "test", Metrics: &model.TaskMetrics{Kind: "test", Data: map[string]interface{}{"testcases": testcases}}}
+
+	d := summarizeTestDurations(task)
+	if d == nil || d.Total != 12 || !d.ShowCases {
+		t.Fatalf("summarizeTestDurations() = %+v, want 12 test cases shown", d)
+	}
+	if len(d.Slowest) != slowestTestCount || d.Slowest[0].Name != "f" || d.Slowest[1].Name != "i" || d.Slowest[0].Classname != "pkg" {
+		t.Errorf("Slowest = %+v, want the 10 slowest, longest first", d.Slowest)
+	}
+	// Equal durations keep the suite's order
+	if d.Slowest[3].Name != "d" || d.Slowest[4].Name != "h" {
+		t.Errorf("Slowest = %+v, want ties in suite order", d.Slowest)
+	}
+	var counts []int
+	for _, b := range d.Buckets {
+		counts = append(counts, b.Count)
+	}
+	if want := []int{4, 2, 3, 2, 1}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("bucket counts = %v, want %v", counts, want)
+	}
+	if d.Buckets[0].Percent < 33.3 || d.Buckets[0].Percent > 33.4 {
+		t.Errorf("Percent = %v, want 4 of 12", d.Buckets[0].Percent)
+	}
+}
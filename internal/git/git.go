@@ -14,9 +14,13 @@ import (
 type GitInfo struct {
 	InGitRepo    bool     `json:"inGitRepo"`
 	RepoRoot     string   `json:"projectRoot"`
-	Mode         string   `json:"mode"` // "staged", "staged_unstaged", "ref"
-	Ref          string   `json:"ref"`  // reference used for comparison
+	Mode         string   `json:"mode"`                // "staged", "staged_unstaged", "ref", "branch"
+	Ref          string   `json:"ref"`                 // reference used for comparison
+	MergeBase    string   `json:"mergeBase,omitempty"` // commit diffed against in "branch" mode
+	Untracked    bool     `json:"untracked,omitempty"` // untracked files are included in ChangedFiles
 	ChangedFiles []string `json:"changedFiles"`
+	Commit       string   `json:"commit,omitempty"` // Full hash of HEAD ("" outside a repo or before the first commit)
+	Branch       string   `json:"branch,omitempty"` // Checked-out branch ("" on a detached HEAD)
 }
 
 // DetectProjectRoot detects the git repository root from current working directory
@@ -52,8 +56,9 @@ func DetectProjectRootFrom(dir string) (string, bool) {
 	return root, true
 }
 
-// DetectChangedFiles detects changed files based on the specified mode
-func DetectChangedFiles(projectRoot string, inGitRepo bool, mode string, ref string, verbose bool) GitInfo {
+// DetectChangedFiles detects changed files based on the specified mode. With includeUntracked,
+// files not yet known to git (excluding ignored ones) are added to the diff
+func DetectChangedFiles(projectRoot string, inGitRepo bool, mode string, ref string, includeUntracked bool, verbose bool) GitInfo {
 	info := GitInfo{
 		InGitRepo:    inGitRepo,
 		RepoRoot:     projectRoot,
@@ -65,6 +70,8 @@ func DetectChangedFiles(projectRoot string, inGitRepo bool, mode string, ref str
 	if !inGitRepo {
 		return info
 	}
+	info.Commit = runGit(projectRoot, "rev-parse", "--verify", "--quiet", "HEAD")
+	info.Branch = CurrentBranch(projectRoot)
 
 	var cmd *exec.Cmd
 
@@ -81,6 +88,25 @@ func DetectChangedFiles(projectRoot string, inGitRepo bool, mode string, ref str
 		// Compare against specific ref
 		cmd = exec.Command("git", "diff", "--name-only", ref)
 
+	case "branch":
+		// Compare against the merge-base with the default branch (files changed on this branch)
+		base := DefaultBranch(projectRoot)
+		mergeBase := ""
+		if base != "" {
+			mergeBase = runGit(projectRoot, "merge-base", "HEAD", base)
+		}
+		if mergeBase == "" {
+			if verbose {
+				fmt.Fprintf(os.Stderr, "WARNING: could not resolve a merge-base with the default branch, using staged_unstaged\n")
+			}
+			cmd = exec.Command("git", "diff", "--name-only", "HEAD")
+			info.Mode = "staged_unstaged"
+			break
+		}
+		cmd = exec.Command("git", "diff", "--name-only", mergeBase)
+		info.Ref = base
+		info.MergeBase = mergeBase
+
 	default:
 		// Default to staged_unstaged
 		cmd = exec.Command("git", "diff", "--name-only", "HEAD")
@@ -99,22 +125,137 @@ func DetectChangedFiles(projectRoot string, inGitRepo bool, mode string, ref str
 		return info
 	}
 
-	output := strings.TrimSpace(out.String())
-	if output == "" {
-		info.ChangedFiles = []string{}
-		return info
+	files := splitLines(out.String())
+
+	if includeUntracked {
+		info.Untracked = true
+		// --exclude-standard applies .gitignore, .git/info/exclude and the global excludes file
+		untracked := exec.Command("git", "ls-files", "--others", "--exclude-standard")
+		untracked.Dir = projectRoot
+		var untrackedOut bytes.Buffer
+		untracked.Stdout = &untrackedOut
+		untracked.Stderr = &bytes.Buffer{}
+		if err := untracked.Run(); err != nil {
+			if verbose {
+				fmt.Fprintf(os.Stderr, "WARNING: git ls-files failed: %v\n", err)
+			}
+		} else {
+			seen := make(map[string]bool, len(files))
+			for _, f := range files {
+				seen[f] = true
+			}
+			for _, f := range splitLines(untrackedOut.String()) {
+				if !seen[f] {
+					seen[f] = true
+					files = append(files, f)
+				}
+			}
+		}
 	}
 
-	lines := strings.Split(output, "\n")
-	var files []string
-	for _, l := range lines {
+	info.ChangedFiles = files
+	return info
+}
+
+// splitLines returns the non-empty lines of git output
+func splitLines(output string) []string {
+	files := []string{}
+	for _, l := range strings.Split(strings.TrimSpace(output), "\n") {
 		if strings.TrimSpace(l) != "" {
 			files = append(files, l)
 		}
 	}
+	return files
+}
 
-	info.ChangedFiles = files
-	return info
+// CurrentBranch returns the checked-out branch name, or "" if unknown (not a repo or detached HEAD)
+func CurrentBranch(dir string) string {
+	// symbolic-ref fails on a detached HEAD and works before the first commit
+	cmd := exec.Command("git", "symbolic-ref", "--short", "HEAD")
+	cmd.Dir = dir
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	cmd.Stderr = &bytes.Buffer{}
+
+	if err := cmd.Run(); err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(buf.String())
+}
+
+// HeadCommit returns the abbreviated hash of HEAD, or "" if unknown (not a repo or no commits yet)
+func HeadCommit(dir string) string {
+	return runGit(dir, "rev-parse", "--short", "HEAD")
+}
+
+// FilterIgnored splits files (relative to projectRoot) into those kept and those matched by
+// the repository's ignore rules (.gitignore, .git/info/exclude and the global excludes file).
+// Tracked files are checked too, so generated files committed by mistake are dropped.
+// If git cannot answer, every file is kept
+func FilterIgnored(projectRoot string, files []string) (kept []string, ignored []string) {
+	kept = []string{}
+	if len(files) == 0 {
+		return kept, nil
+	}
+
+	// --no-index applies the ignore rules even to files that are tracked; -z keeps odd names unquoted
+	cmd := exec.Command("git", "check-ignore", "--no-index", "--stdin", "-z")
+	cmd.Dir = projectRoot
+	cmd.Stdin = strings.NewReader(strings.Join(files, "\x00") + "\x00")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &bytes.Buffer{}
+
+	// Exit code 1 means no file is ignored
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+			return append(kept, files...), nil
+		}
+	}
+
+	matched := make(map[string]bool)
+	for _, f := range strings.Split(out.String(), "\x00") {
+		matched[f] = true
+	}
+	for _, f := range files {
+		if matched[f] {
+			ignored = append(ignored, f)
+		} else {
+			kept = append(kept, f)
+		}
+	}
+	return kept, ignored
+}
+
+// DefaultBranch returns the repository's default branch as a ref usable with git diff,
+// or "" if none is found. origin/HEAD is preferred; otherwise the first of main and master
+// that exists locally or on origin is used
+func DefaultBranch(dir string) string {
+	if ref := runGit(dir, "symbolic-ref", "--short", "refs/remotes/origin/HEAD"); ref != "" {
+		return ref
+	}
+	for _, name := range []string{"main", "master", "origin/main", "origin/master"} {
+		if runGit(dir, "rev-parse", "--verify", "--quiet", name+"^{commit}") != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// runGit runs a git command in dir and returns its trimmed stdout, or "" on failure
+func runGit(dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	cmd.Stderr = &bytes.Buffer{}
+
+	if err := cmd.Run(); err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(buf.String())
 }
 
 // IsSafeDirectory checks if a directory is safe to run devpipe in.
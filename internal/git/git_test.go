@@ -3,6 +3,9 @@ package git
 import (
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -69,7 +72,7 @@ func TestDetectChangedFiles(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			info := DetectChangedFiles(root, inGitRepo, tt.mode, tt.ref, false)
+			info := DetectChangedFiles(root, inGitRepo, tt.mode, tt.ref, false, false)
 
 			// Should return a GitInfo struct
 			if !info.InGitRepo {
@@ -108,7 +111,7 @@ func TestGitInfo(t *testing.T) {
 
 func TestDetectChangedFilesNotInRepo(t *testing.T) {
 	// Test when not in a git repo
-	info := DetectChangedFiles("/some/path", false, "staged", "", false)
+	info := DetectChangedFiles("/some/path", false, "staged", "", false, false)
 
 	if info.InGitRepo {
 		t.Error("Expected InGitRepo to be false")
@@ -135,7 +138,7 @@ func TestDetectChangedFilesRefMode(t *testing.T) {
 	}
 
 	// Test ref mode with HEAD~1 (if it exists)
-	info := DetectChangedFiles(root, inGitRepo, "ref", "HEAD~1", false)
+	info := DetectChangedFiles(root, inGitRepo, "ref", "HEAD~1", false, false)
 
 	if !info.InGitRepo {
 		t.Error("Expected InGitRepo to be true")
@@ -164,7 +167,7 @@ func TestDetectChangedFilesDefaultMode(t *testing.T) {
 	}
 
 	// Test with an unknown/invalid mode - should default to staged_unstaged
-	info := DetectChangedFiles(root, inGitRepo, "invalid_mode", "", false)
+	info := DetectChangedFiles(root, inGitRepo, "invalid_mode", "", false, false)
 
 	if !info.InGitRepo {
 		t.Error("Expected InGitRepo to be true")
@@ -189,7 +192,7 @@ func TestDetectChangedFilesVerbose(t *testing.T) {
 
 	// Test with verbose=true - this should not panic or error
 	// even if git commands fail
-	info := DetectChangedFiles(root, inGitRepo, "staged", "", true)
+	info := DetectChangedFiles(root, inGitRepo, "staged", "", false, true)
 
 	if !info.InGitRepo {
 		t.Error("Expected InGitRepo to be true")
@@ -209,7 +212,7 @@ func TestDetectChangedFilesInvalidRef(t *testing.T) {
 	}
 
 	// Test with an invalid ref - should handle gracefully
-	info := DetectChangedFiles(root, inGitRepo, "ref", "nonexistent_ref_12345", false)
+	info := DetectChangedFiles(root, inGitRepo, "ref", "nonexistent_ref_12345", false, false)
 
 	if !info.InGitRepo {
 		t.Error("Expected InGitRepo to be true")
@@ -229,7 +232,7 @@ func TestDetectChangedFilesEmptyMode(t *testing.T) {
 	}
 
 	// Test with empty mode - should default to staged_unstaged
-	info := DetectChangedFiles(root, inGitRepo, "", "", false)
+	info := DetectChangedFiles(root, inGitRepo, "", "", false, false)
 
 	if !info.InGitRepo {
 		t.Error("Expected InGitRepo to be true")
@@ -248,7 +251,7 @@ func TestDetectChangedFilesWithInvalidRepoRoot(t *testing.T) {
 	}
 
 	// Test with an invalid project root path - git command should fail
-	info := DetectChangedFiles("/nonexistent/path/to/repo", true, "staged", "", false)
+	info := DetectChangedFiles("/nonexistent/path/to/repo", true, "staged", "", false, false)
 
 	// Should handle gracefully and return empty changed files
 	if info.ChangedFiles == nil {
@@ -269,7 +272,7 @@ func TestDetectChangedFilesWithInvalidRepoRootVerbose(t *testing.T) {
 
 	// Test with an invalid project root path and verbose=true
 	// This should trigger the verbose error message path
-	info := DetectChangedFiles("/nonexistent/path/to/repo", true, "staged", "", true)
+	info := DetectChangedFiles("/nonexistent/path/to/repo", true, "staged", "", false, true)
 
 	// Should handle gracefully and return empty changed files
 	if info.ChangedFiles == nil {
@@ -334,7 +337,7 @@ func TestDetectChangedFilesAllModes(t *testing.T) {
 
 	for _, tc := range modes {
 		t.Run("mode_"+tc.mode, func(t *testing.T) {
-			info := DetectChangedFiles(root, inGitRepo, tc.mode, tc.ref, false)
+			info := DetectChangedFiles(root, inGitRepo, tc.mode, tc.ref, false, false)
 
 			if info.Mode != tc.expectedMode {
 				t.Errorf("Mode %s: expected mode '%s', got '%s'", tc.mode, tc.expectedMode, info.Mode)
@@ -363,7 +366,7 @@ func TestDetectChangedFilesRefWithEmptyRef(t *testing.T) {
 	}
 
 	// Test ref mode with empty ref string - should fail gracefully
-	info := DetectChangedFiles(root, inGitRepo, "ref", "", false)
+	info := DetectChangedFiles(root, inGitRepo, "ref", "", false, false)
 
 	if !info.InGitRepo {
 		t.Error("Expected InGitRepo to be true")
@@ -397,7 +400,7 @@ func TestDetectChangedFilesMultipleRefs(t *testing.T) {
 
 	for _, ref := range refs {
 		t.Run("ref_"+ref, func(t *testing.T) {
-			info := DetectChangedFiles(root, inGitRepo, "ref", ref, false)
+			info := DetectChangedFiles(root, inGitRepo, "ref", ref, false, false)
 
 			if info.Mode != "ref" {
 				t.Errorf("Ref %s: expected mode 'ref', got '%s'", ref, info.Mode)
@@ -536,7 +539,7 @@ func TestDetectChangedFilesNotInRepoWithDifferentModes(t *testing.T) {
 
 	for _, mode := range modes {
 		t.Run("notInRepo_"+mode, func(t *testing.T) {
-			info := DetectChangedFiles("/some/path", false, mode, "HEAD", false)
+			info := DetectChangedFiles("/some/path", false, mode, "HEAD", false, false)
 
 			if info.InGitRepo {
 				t.Error("Expected InGitRepo to be false")
@@ -631,3 +634,150 @@ func TestIsSafeDirectoryEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestCurrentBranch(t *testing.T) {
+	if branch := CurrentBranch(t.TempDir()); branch != "" {
+		t.Errorf("Expected empty branch outside a git repo, got %s", branch)
+	}
+
+	root, inGitRepo := DetectProjectRoot()
+	if !inGitRepo {
+		t.Skip("Skipping git test: not in a git repository")
+		return
+	}
+
+	// Branch may legitimately be empty on a detached HEAD (e.g. in CI)
+	if branch := CurrentBranch(root); strings.Contains(branch, "\n") {
+		t.Errorf("Expected a single branch name, got %q", branch)
+	}
+}
+
+func TestDetectChangedFilesBranchMode(t *testing.T) {
+	dir := t.TempDir()
+	runGitT := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	runGitT("init", "-q", "-b", "main")
+	write("base.txt")
+	runGitT("add", "-A")
+	runGitT("commit", "-qm", "base")
+	runGitT("checkout", "-qb", "feature")
+	write("feature.txt")
+	runGitT("add", "-A")
+	runGitT("commit", "-qm", "feature")
+	write("wip.txt")
+	runGitT("add", "wip.txt")
+
+	if base := DefaultBranch(dir); base != "main" {
+		t.Errorf("DefaultBranch() = %q, want main", base)
+	}
+
+	info := DetectChangedFiles(dir, true, "branch", "", false, false)
+	if info.Mode != "branch" || info.Ref != "main" || info.MergeBase == "" {
+		t.Errorf("got mode=%q ref=%q mergeBase=%q, want branch against main", info.Mode, info.Ref, info.MergeBase)
+	}
+	got := strings.Join(info.ChangedFiles, ",")
+	if got != "feature.txt,wip.txt" {
+		t.Errorf("ChangedFiles = %q, want feature.txt,wip.txt", got)
+	}
+	if info.Branch != "feature" || len(info.Commit) != 40 || !strings.HasPrefix(info.Commit, HeadCommit(dir)) {
+		t.Errorf("got branch=%q commit=%q, want feature at %s", info.Branch, info.Commit, HeadCommit(dir))
+	}
+
+	// A detached HEAD records the commit without a branch
+	runGitT("checkout", "-q", "--detach")
+	if detached := DetectChangedFiles(dir, true, "staged", "", false, false); detached.Branch != "" || detached.Commit != info.Commit {
+		t.Errorf("detached: got branch=%q commit=%q, want no branch at %s", detached.Branch, detached.Commit, info.Commit)
+	}
+	runGitT("checkout", "-q", "feature")
+
+	// Without a default branch it falls back to staged_unstaged
+	runGitT("branch", "-qm", "main", "trunk")
+	info = DetectChangedFiles(dir, true, "branch", "", false, false)
+	if info.Mode != "staged_unstaged" || info.MergeBase != "" {
+		t.Errorf("got mode=%q mergeBase=%q, want staged_unstaged fallback", info.Mode, info.MergeBase)
+	}
+}
+
+func TestDetectChangedFilesIncludeUntracked(t *testing.T) {
+	dir := t.TempDir()
+	runGitT := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	runGitT("init", "-q")
+	write(".gitignore", "ignored.txt\n")
+	write("tracked.txt", "v1")
+	runGitT("add", "-A")
+	runGitT("commit", "-qm", "base")
+	write("tracked.txt", "v2")
+	write("new.txt", "new")
+	write("ignored.txt", "ignored")
+
+	// Opt-in: the default stays a plain diff
+	info := DetectChangedFiles(dir, true, "staged_unstaged", "", false, false)
+	if got := strings.Join(info.ChangedFiles, ","); got != "tracked.txt" {
+		t.Errorf("without includeUntracked ChangedFiles = %q, want tracked.txt", got)
+	}
+
+	info = DetectChangedFiles(dir, true, "staged_unstaged", "", true, false)
+	if got := strings.Join(info.ChangedFiles, ","); got != "tracked.txt,new.txt" {
+		t.Errorf("with includeUntracked ChangedFiles = %q, want tracked.txt,new.txt", got)
+	}
+	if !info.Untracked {
+		t.Errorf("expected Untracked to be recorded in GitInfo")
+	}
+}
+
+func TestFilterIgnored(t *testing.T) {
+	dir := t.TempDir()
+	cmd := exec.Command("git", "init", "-q")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, out)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("dist/\nnode_modules/\n"), 0o644); err != nil {
+		t.Fatalf("failed to write .gitignore: %v", err)
+	}
+
+	kept, ignored := FilterIgnored(dir, []string{"src/app.js", "dist/app.js", "node_modules/x/index.js", "my file.js"})
+	if got := strings.Join(kept, ","); got != "src/app.js,my file.js" {
+		t.Errorf("kept = %q, want src/app.js,my file.js", got)
+	}
+	if got := strings.Join(ignored, ","); got != "dist/app.js,node_modules/x/index.js" {
+		t.Errorf("ignored = %q, want dist/app.js,node_modules/x/index.js", got)
+	}
+
+	// Nothing ignored (git exits 1) keeps everything
+	if kept, ignored := FilterIgnored(dir, []string{"src/app.js"}); len(kept) != 1 || len(ignored) != 0 {
+		t.Errorf("FilterIgnored() = %v, %v; want everything kept", kept, ignored)
+	}
+
+	// Outside a repo every file is kept
+	if kept, _ := FilterIgnored(t.TempDir(), []string{"dist/app.js"}); len(kept) != 1 {
+		t.Errorf("expected files to be kept outside a git repo, got %v", kept)
+	}
+}
@@ -0,0 +1,168 @@
+// Package logsink forwards task output lines to an external log pipeline (defaults.logSink).
+package logsink
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/drew/devpipe/internal/tasklog"
+)
+
+// writeTimeout bounds each network write, so an unreachable collector cannot stall a task
+const writeTimeout = 2 * time.Second
+
+// Sink receives each line of task output. Implementations are safe for concurrent use,
+// since tasks run in parallel
+type Sink interface {
+	WriteLine(ts time.Time, taskID, stream, line string) error
+	Close() error
+}
+
+// Parse checks a logSink URL without connecting to it. Supported URLs are
+//
+//	syslog://host:514       syslog over UDP
+//	syslog+tcp://host:514   syslog over TCP, one message per line
+//	file:///path/to/file    JSONL records appended to a file (or written to a fifo)
+func Parse(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "syslog", "syslog+tcp":
+		if u.Host == "" {
+			return nil, fmt.Errorf("%s needs a host, e.g. %s://localhost:514", rawURL, u.Scheme)
+		}
+		if u.Port() == "" {
+			u.Host = net.JoinHostPort(u.Hostname(), "514")
+		}
+	case "file":
+		if u.Path == "" {
+			return nil, fmt.Errorf("%s needs a path, e.g. file:///var/log/devpipe.jsonl", rawURL)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported log sink %q: use syslog://, syslog+tcp:// or file://", rawURL)
+	}
+	return u, nil
+}
+
+// Open connects to the sink at rawURL (see Parse)
+func Open(rawURL string) (Sink, error) {
+	u, err := Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "file":
+		f, err := os.OpenFile(u.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, err
+		}
+		return &fileSink{file: f}, nil
+	default:
+		network := "udp"
+		if u.Scheme == "syslog+tcp" {
+			network = "tcp"
+		}
+		conn, err := net.DialTimeout(network, u.Host, writeTimeout)
+		if err != nil {
+			return nil, err
+		}
+		hostname, _ := os.Hostname()
+		if hostname == "" {
+			hostname = "-"
+		}
+		return &syslogSink{conn: conn, hostname: hostname, pid: os.Getpid()}, nil
+	}
+}
+
+// syslogSink sends each line as an RFC 3164 message from the user facility
+type syslogSink struct {
+	mu       sync.Mutex
+	conn     net.Conn
+	hostname string
+	pid      int
+}
+
+// Syslog priorities: facility user (1) with severity info (6) for stdout, notice (5) for stderr
+const (
+	priorityStdout = 1*8 + 6
+	priorityStderr = 1*8 + 5
+)
+
+// WriteLine implements Sink
+func (s *syslogSink) WriteLine(ts time.Time, taskID, stream, line string) error {
+	priority := priorityStdout
+	if stream == "stderr" {
+		priority = priorityStderr
+	}
+	msg := fmt.Sprintf("<%d>%s %s devpipe[%d]: [%s] %s\n", priority, ts.Format(time.Stamp), s.hostname, s.pid, taskID, line)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, err := io.WriteString(s.conn, msg)
+	return err
+}
+
+// Close implements Sink
+func (s *syslogSink) Close() error {
+	return s.conn.Close()
+}
+
+// fileSink appends one tasklog JSONL record per line
+type fileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// WriteLine implements Sink
+func (s *fileSink) WriteLine(ts time.Time, taskID, stream, line string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return tasklog.WriteRecord(s.file, ts, taskID, stream, line)
+}
+
+// Close implements Sink
+func (s *fileSink) Close() error {
+	return s.file.Close()
+}
+
+// Forwarder writes lines to a Sink for the run. The first failed write prints a warning
+// and later failures are ignored, so a broken log pipeline never fails the run. A nil
+// *Forwarder forwards nothing
+type Forwarder struct {
+	sink Sink
+	warn io.Writer
+	once sync.Once
+}
+
+// NewForwarder returns a Forwarder writing to sink and warning on warn
+func NewForwarder(sink Sink, warn io.Writer) *Forwarder {
+	return &Forwarder{sink: sink, warn: warn}
+}
+
+// Forward sends one line of a task's output to the sink
+func (f *Forwarder) Forward(taskID, stream, line string) {
+	if f == nil {
+		return
+	}
+	if err := f.sink.WriteLine(time.Now(), taskID, stream, line); err != nil {
+		f.once.Do(func() {
+			_, _ = fmt.Fprintf(f.warn, "WARNING: failed to forward task output to the log sink: %v (further errors are not shown)\n", err)
+		})
+	}
+}
+
+// Close closes the sink
+func (f *Forwarder) Close() error {
+	if f == nil {
+		return nil
+	}
+	return f.sink.Close()
+}
@@ -0,0 +1,118 @@
+package logsink
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drew/devpipe/internal/tasklog"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		url      string
+		wantHost string
+		wantErr  bool
+	}{
+		{"syslog://logs.internal:1514", "logs.internal:1514", false},
+		{"syslog+tcp://logs.internal", "logs.internal:514", false},
+		{"file:///var/log/devpipe.jsonl", "", false},
+		{"syslog://", "", true},
+		{"file://", "", true},
+		{"http://logs.internal", "", true},
+	}
+	for _, tt := range tests {
+		u, err := Parse(tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			continue
+		}
+		if err == nil && u.Host != tt.wantHost {
+			t.Errorf("Parse(%q) host = %q, want %q", tt.url, u.Host, tt.wantHost)
+		}
+	}
+}
+
+func TestSyslogSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on UDP: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	sink, err := Open("syslog://" + conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() { _ = sink.Close() }()
+	if err := sink.WriteLine(time.Now(), "lint", "stderr", "2 problems"); err != nil {
+		t.Fatalf("WriteLine() error = %v", err)
+	}
+
+	buf := make([]byte, 1024)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("no syslog message received: %v", err)
+	}
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "<13>") || !strings.Contains(msg, " devpipe[") || !strings.HasSuffix(msg, ": [lint] 2 problems\n") {
+		t.Errorf("message = %q, want a user.notice message for the lint line", msg)
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devpipe.jsonl")
+	if err := os.WriteFile(path, []byte("earlier\n"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	sink, err := Open("file://" + filepath.ToSlash(path))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	_ = sink.WriteLine(time.Now(), "unit-tests", "stdout", "ok")
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	records, err := tasklog.ReadRecords(path, tasklog.FormatJSONL)
+	if err != nil {
+		t.Fatalf("ReadRecords() error = %v", err)
+	}
+	if len(records) != 2 || records[0].Line != "earlier" || records[1].Task != "unit-tests" || records[1].Line != "ok" {
+		t.Errorf("records = %+v, want the earlier line and the appended record", records)
+	}
+}
+
+// failingSink fails every write
+type failingSink struct{ writes int }
+
+func (s *failingSink) WriteLine(time.Time, string, string, string) error {
+	s.writes++
+	return errors.New("connection refused")
+}
+
+func (s *failingSink) Close() error { return nil }
+
+func TestForwarderWarnsOnce(t *testing.T) {
+	sink := &failingSink{}
+	var warn bytes.Buffer
+	f := NewForwarder(sink, &warn)
+	f.Forward("lint", "stdout", "one")
+	f.Forward("lint", "stdout", "two")
+
+	if sink.writes != 2 {
+		t.Errorf("writes = %d, want 2", sink.writes)
+	}
+	if strings.Count(warn.String(), "WARNING") != 1 || !strings.Contains(warn.String(), "connection refused") {
+		t.Errorf("warnings = %q, want a single warning with the error", warn.String())
+	}
+
+	var none *Forwarder
+	none.Forward("lint", "stdout", "ignored") // A nil Forwarder forwards nothing
+}
@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/drew/devpipe/internal/model"
+)
+
+// ParseCommandOutput reads what a task's metricsParser printed: a JSON object whose fields
+// become the metrics data, shown as-is on the dashboard
+func ParseCommandOutput(data []byte) (*model.TaskMetrics, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("parser output is not a JSON object: %w", err)
+	}
+	if fields == nil {
+		return nil, errors.New("parser output is not a JSON object: null")
+	}
+
+	return &model.TaskMetrics{
+		Kind:          "custom",
+		SummaryFormat: "command",
+		Data:          fields,
+	}, nil
+}
@@ -0,0 +1,22 @@
+package metrics
+
+import "testing"
+
+func TestParseCommandOutput(t *testing.T) {
+	m, err := ParseCommandOutput([]byte(`{"scenarios": 12, "failed": 1, "suite": "smoke"}`))
+	if err != nil {
+		t.Fatalf("ParseCommandOutput: %v", err)
+	}
+	if m.Kind != "custom" || m.SummaryFormat != "command" {
+		t.Errorf("Kind = %q, SummaryFormat = %q; want custom, command", m.Kind, m.SummaryFormat)
+	}
+	if m.Data["scenarios"] != float64(12) || m.Data["suite"] != "smoke" {
+		t.Errorf("Data = %v", m.Data)
+	}
+
+	for _, out := range []string{"", "not json", "[1, 2]", "null"} {
+		if _, err := ParseCommandOutput([]byte(out)); err == nil {
+			t.Errorf("ParseCommandOutput(%q) succeeded, want an error", out)
+		}
+	}
+}
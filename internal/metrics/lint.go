@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/drew/devpipe/internal/model"
+	"github.com/drew/devpipe/internal/sarif"
+)
+
+// eslintFile is one entry of ESLint's JSON formatter output (eslint -f json)
+type eslintFile struct {
+	FilePath     string `json:"filePath"`
+	ErrorCount   int    `json:"errorCount"`
+	WarningCount int    `json:"warningCount"`
+	Messages     []struct {
+		RuleID   string `json:"ruleId"`
+		Severity int    `json:"severity"` // 1 = warning, 2 = error
+		Message  string `json:"message"`
+		Line     int    `json:"line"`
+		Column   int    `json:"column"`
+		Fatal    bool   `json:"fatal"`
+	} `json:"messages"`
+}
+
+// ParseESLint parses ESLint JSON output and returns metrics in the same shape as ParseSARIF.
+// Error and warning totals are the sum of each file's errorCount and warningCount.
+func ParseESLint(path string) (*model.TaskMetrics, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []eslintFile
+	if err := json.Unmarshal(data, &files); err != nil {
+		return nil, fmt.Errorf("failed to parse ESLint JSON: %w", err)
+	}
+
+	var findings []sarif.Finding
+	var errors, warnings int
+	for _, file := range files {
+		errors += file.ErrorCount
+		warnings += file.WarningCount
+
+		for _, msg := range file.Messages {
+			level := "warning"
+			if msg.Severity >= 2 || msg.Fatal {
+				level = "error"
+			}
+			ruleID := msg.RuleID
+			if ruleID == "" {
+				ruleID = "eslint" // Parse errors have no rule
+			}
+			findings = append(findings, sarif.Finding{
+				RuleID:  ruleID,
+				File:    file.FilePath,
+				Line:    msg.Line,
+				Column:  msg.Column,
+				Message: msg.Message,
+				Level:   level,
+			})
+		}
+	}
+
+	return findingsMetrics("lint", "eslint", findings, errors, warnings, 0), nil
+}
+
+// checkstyleReport is the root of a checkstyle XML report
+type checkstyleReport struct {
+	XMLName xml.Name `xml:"checkstyle"`
+	Files   []struct {
+		Name   string `xml:"name,attr"`
+		Errors []struct {
+			Line     int    `xml:"line,attr"`
+			Column   int    `xml:"column,attr"`
+			Severity string `xml:"severity,attr"`
+			Message  string `xml:"message,attr"`
+			Source   string `xml:"source,attr"`
+		} `xml:"error"`
+	} `xml:"file"`
+}
+
+// ParseCheckstyle parses checkstyle XML and returns metrics in the same shape as ParseSARIF.
+// Severities map to SARIF levels: error -> error, warning -> warning, info/ignore -> note.
+func ParseCheckstyle(path string) (*model.TaskMetrics, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var report checkstyleReport
+	if err := xml.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse checkstyle XML: %w", err)
+	}
+
+	var findings []sarif.Finding
+	var errors, warnings, notes int
+	for _, file := range report.Files {
+		for _, e := range file.Errors {
+			level := "warning"
+			switch strings.ToLower(e.Severity) {
+			case "error":
+				level = "error"
+				errors++
+			case "info", "ignore":
+				level = "note"
+				notes++
+			default:
+				warnings++
+			}
+			ruleID := e.Source
+			if ruleID == "" {
+				ruleID = "checkstyle"
+			}
+			findings = append(findings, sarif.Finding{
+				RuleID:  ruleID,
+				File:    file.Name,
+				Line:    e.Line,
+				Column:  e.Column,
+				Message: e.Message,
+				Level:   level,
+			})
+		}
+	}
+
+	return findingsMetrics("lint", "checkstyle", findings, errors, warnings, notes), nil
+}
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseESLint(t *testing.T) {
+	content := `[
+  {
+    "filePath": "/repo/src/app.js",
+    "errorCount": 2,
+    "warningCount": 1,
+    "messages": [
+      {"ruleId": "no-unused-vars", "severity": 2, "message": "'x' is defined but never used.", "line": 3, "column": 7},
+      {"ruleId": "eqeqeq", "severity": 1, "message": "Expected '===' and instead saw '=='.", "line": 10, "column": 9},
+      {"ruleId": null, "severity": 2, "fatal": true, "message": "Parsing error: Unexpected token", "line": 20, "column": 1}
+    ]
+  },
+  {
+    "filePath": "/repo/src/clean.js",
+    "errorCount": 0,
+    "warningCount": 0,
+    "messages": []
+  }
+]`
+	path := filepath.Join(t.TempDir(), "eslint.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := ParseESLint(path)
+	if err != nil {
+		t.Fatalf("ParseESLint() error = %v", err)
+	}
+	if m.Kind != "lint" || m.SummaryFormat != "eslint" {
+		t.Errorf("Expected kind lint / format eslint, got %s / %s", m.Kind, m.SummaryFormat)
+	}
+	if m.Data["errors"] != 2 || m.Data["warnings"] != 1 || m.Data["total"] != 3 {
+		t.Errorf("Unexpected totals: errors=%v warnings=%v total=%v", m.Data["errors"], m.Data["warnings"], m.Data["total"])
+	}
+
+	findings := m.Data["findings"].([]map[string]interface{})
+	if len(findings) != 3 {
+		t.Fatalf("Expected 3 findings, got %d", len(findings))
+	}
+	first := findings[0]
+	if first["ruleId"] != "no-unused-vars" || first["file"] != "/repo/src/app.js" || first["line"] != 3 || first["level"] != "error" {
+		t.Errorf("Unexpected first finding: %v", first)
+	}
+	if findings[1]["level"] != "warning" {
+		t.Errorf("Expected severity 1 to map to warning, got %v", findings[1]["level"])
+	}
+	if findings[2]["ruleId"] != "eslint" {
+		t.Errorf("Expected parse errors to use the eslint rule ID, got %v", findings[2]["ruleId"])
+	}
+}
+
+func TestParseESLintInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "eslint.json")
+	if err := os.WriteFile(path, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseESLint(path); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
+
+func TestParseCheckstyle(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="4.3">
+  <file name="src/Main.java">
+    <error line="12" column="5" severity="error" message="Missing a Javadoc comment." source="com.puppycrawl.tools.checkstyle.checks.javadoc.MissingJavadocMethodCheck"/>
+    <error line="30" severity="warning" message="Line is longer than 100 characters." source="LineLength"/>
+  </file>
+  <file name="src/Util.java">
+    <error line="1" severity="info" message="File has no package comment."/>
+  </file>
+  <file name="src/Clean.java"/>
+</checkstyle>`
+	path := filepath.Join(t.TempDir(), "checkstyle.xml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := ParseCheckstyle(path)
+	if err != nil {
+		t.Fatalf("ParseCheckstyle() error = %v", err)
+	}
+	if m.Kind != "lint" || m.SummaryFormat != "checkstyle" {
+		t.Errorf("Expected kind lint / format checkstyle, got %s / %s", m.Kind, m.SummaryFormat)
+	}
+	if m.Data["errors"] != 1 || m.Data["warnings"] != 1 || m.Data["notes"] != 1 || m.Data["total"] != 3 {
+		t.Errorf("Unexpected totals: %v", m.Data)
+	}
+
+	findings := m.Data["findings"].([]map[string]interface{})
+	if len(findings) != 3 {
+		t.Fatalf("Expected 3 findings, got %d", len(findings))
+	}
+	if findings[0]["file"] != "src/Main.java" || findings[0]["line"] != 12 || findings[0]["column"] != 5 {
+		t.Errorf("Unexpected first finding: %v", findings[0])
+	}
+	if findings[2]["level"] != "note" || findings[2]["ruleId"] != "checkstyle" {
+		t.Errorf("Expected info without source to be a checkstyle note, got %v", findings[2])
+	}
+}
+
+func TestParseCheckstyleInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkstyle.xml")
+	if err := os.WriteFile(path, []byte(`<testsuite name="x"/>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseCheckstyle(path); err == nil {
+		t.Error("Expected error for XML without a checkstyle root")
+	}
+}
@@ -0,0 +1,86 @@
+package metrics
+
+import "github.com/drew/devpipe/internal/model"
+
+// Merge combines the metrics parsed from several report files of one format, such as the
+// JUnit files of a sharded test run: counts are summed and lists (testcases, findings) are
+// concatenated. Rules are combined by ID; any other value is taken from the first file
+func Merge(parts []*model.TaskMetrics) *model.TaskMetrics {
+	if len(parts) == 0 {
+		return nil
+	}
+
+	merged := &model.TaskMetrics{
+		Kind:          parts[0].Kind,
+		SummaryFormat: parts[0].SummaryFormat,
+		Data:          map[string]interface{}{},
+	}
+	for _, part := range parts {
+		for key, value := range part.Data {
+			current, ok := merged.Data[key]
+			if !ok {
+				merged.Data[key] = value
+				continue
+			}
+			if key == "rules" {
+				merged.Data[key] = mergeRules(current, value)
+				continue
+			}
+			merged.Data[key] = mergeValue(current, value)
+		}
+	}
+	return merged
+}
+
+// mergeValue sums numbers and concatenates lists of the same type, keeping current otherwise
+func mergeValue(current, value interface{}) interface{} {
+	switch c := current.(type) {
+	case int:
+		if v, ok := value.(int); ok {
+			return c + v
+		}
+	case int64:
+		if v, ok := value.(int64); ok {
+			return c + v
+		}
+	case float64:
+		if v, ok := value.(float64); ok {
+			return c + v
+		}
+	case []map[string]interface{}:
+		if v, ok := value.([]map[string]interface{}); ok {
+			return append(append([]map[string]interface{}{}, c...), v...)
+		}
+	case []interface{}:
+		if v, ok := value.([]interface{}); ok {
+			return append(append([]interface{}{}, c...), v...)
+		}
+	}
+	return current
+}
+
+// mergeRules adds up the per-rule counts of SARIF and lint findings
+func mergeRules(current, value interface{}) interface{} {
+	rules, ok := current.([]map[string]interface{})
+	more, ok2 := value.([]map[string]interface{})
+	if !ok || !ok2 {
+		return mergeValue(current, value)
+	}
+
+	merged := make([]map[string]interface{}, 0, len(rules)+len(more))
+	index := map[interface{}]int{}
+	for _, rule := range append(append([]map[string]interface{}{}, rules...), more...) {
+		if i, seen := index[rule["id"]]; seen {
+			combined := map[string]interface{}{}
+			for k, v := range merged[i] {
+				combined[k] = v
+			}
+			combined["count"] = mergeValue(merged[i]["count"], rule["count"])
+			merged[i] = combined
+			continue
+		}
+		index[rule["id"]] = len(merged)
+		merged = append(merged, rule)
+	}
+	return merged
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/drew/devpipe/internal/model"
+)
+
+func TestMerge(t *testing.T) {
+	shard1 := &model.TaskMetrics{Kind: "test", SummaryFormat: "junit", Data: map[string]interface{}{
+		"tests": 2, "failures": 1, "time": 1.5,
+		"testcases": []map[string]interface{}{{"name": "a"}, {"name": "b"}},
+	}}
+	shard2 := &model.TaskMetrics{Kind: "test", SummaryFormat: "junit", Data: map[string]interface{}{
+		"tests": 1, "failures": 0, "time": 0.5,
+		"testcases": []map[string]interface{}{{"name": "c"}},
+	}}
+
+	m := Merge([]*model.TaskMetrics{shard1, shard2})
+	if m.Kind != "test" || m.SummaryFormat != "junit" {
+		t.Errorf("Kind = %q, SummaryFormat = %q", m.Kind, m.SummaryFormat)
+	}
+	if m.Data["tests"] != 3 || m.Data["failures"] != 1 || m.Data["time"] != 2.0 {
+		t.Errorf("counts = %v", m.Data)
+	}
+	if cases := m.Data["testcases"].([]map[string]interface{}); len(cases) != 3 || cases[2]["name"] != "c" {
+		t.Errorf("testcases = %v", cases)
+	}
+	if len(shard1.Data["testcases"].([]map[string]interface{})) != 2 {
+		t.Error("Merge modified its input")
+	}
+}
+
+func TestMergeRules(t *testing.T) {
+	a := &model.TaskMetrics{Kind: "security", SummaryFormat: "sarif", Data: map[string]interface{}{
+		"rules": []map[string]interface{}{{"id": "G101", "count": 2}, {"id": "G104", "count": 1}},
+	}}
+	b := &model.TaskMetrics{Kind: "security", SummaryFormat: "sarif", Data: map[string]interface{}{
+		"rules": []map[string]interface{}{{"id": "G104", "count": 3}},
+	}}
+
+	rules := Merge([]*model.TaskMetrics{a, b}).Data["rules"].([]map[string]interface{})
+	if len(rules) != 2 || rules[1]["id"] != "G104" || rules[1]["count"] != 4 {
+		t.Errorf("rules = %v, want G104 counted once with 4", rules)
+	}
+	if a.Data["rules"].([]map[string]interface{})[1]["count"] != 1 {
+		t.Error("Merge modified its input")
+	}
+}
@@ -34,6 +34,12 @@ func ParseSARIF(path string) (*model.TaskMetrics, error) {
 		}
 	}
 
+	return findingsMetrics("security", "sarif", findings, errors, warnings, notes), nil
+}
+
+// findingsMetrics builds the findings-style metrics shared by SARIF and the lint formats.
+// errors, warnings and notes are the totals reported by the tool.
+func findingsMetrics(kind, format string, findings []sarif.Finding, errors, warnings, notes int) *model.TaskMetrics {
 	// Group findings by rule ID for summary
 	ruleCount := make(map[string]int)
 	ruleSeverity := make(map[string]string)
@@ -103,15 +109,63 @@ func ParseSARIF(path string) (*model.TaskMetrics, error) {
 	}
 
 	return &model.TaskMetrics{
-		Kind:          "security",
-		SummaryFormat: "sarif",
+		Kind:          kind,
+		SummaryFormat: format,
 		Data: map[string]interface{}{
-			"total":    len(findings),
+			"total":    errors + warnings + notes,
 			"errors":   errors,
 			"warnings": warnings,
 			"notes":    notes,
 			"findings": findingsData,
 			"rules":    rules,
 		},
-	}, nil
+	}
+}
+
+// findingsLabels names each findings format in threshold failure messages
+var findingsLabels = map[string]string{
+	"sarif":      "SARIF",
+	"eslint":     "ESLint",
+	"checkstyle": "checkstyle",
+}
+
+// SARIFThresholdFailure checks parsed SARIF, ESLint or checkstyle metrics against a
+// task's failure thresholds.
+// failOn is the lowest level that fails the task ("error", "warning" or "note");
+// maxIssues is the maximum number of findings allowed at any level.
+// Returns a description of the violation, or "" if the thresholds are met.
+func SARIFThresholdFailure(m *model.TaskMetrics, failOn string, maxIssues *int) string {
+	if m == nil {
+		return ""
+	}
+	label, ok := findingsLabels[m.SummaryFormat]
+	if !ok {
+		return ""
+	}
+
+	errors, _ := m.Data["errors"].(int)
+	warnings, _ := m.Data["warnings"].(int)
+	notes, _ := m.Data["notes"].(int)
+
+	var count int
+	switch failOn {
+	case "error":
+		count = errors
+	case "warning":
+		count = errors + warnings
+	case "note":
+		count = errors + warnings + notes
+	}
+	if count > 0 {
+		if failOn == "error" {
+			return fmt.Sprintf("%d %s findings at level error", count, label)
+		}
+		return fmt.Sprintf("%d %s findings at level %s or above", count, label, failOn)
+	}
+
+	if total := errors + warnings + notes; maxIssues != nil && total > *maxIssues {
+		return fmt.Sprintf("%d %s findings (max %d)", total, label, *maxIssues)
+	}
+
+	return ""
 }
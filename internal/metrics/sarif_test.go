@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/drew/devpipe/internal/model"
 )
 
 func TestParseSARIF(t *testing.T) {
@@ -848,3 +850,45 @@ func TestParseSARIF_EmptySeverity(t *testing.T) {
 		t.Error("Expected no severity field when security-severity is empty")
 	}
 }
+
+func TestSARIFThresholdFailure(t *testing.T) {
+	metrics := &model.TaskMetrics{
+		Kind:          "security",
+		SummaryFormat: "sarif",
+		Data:          map[string]interface{}{"errors": 3, "warnings": 2, "notes": 1},
+	}
+	clean := &model.TaskMetrics{
+		Kind:          "security",
+		SummaryFormat: "sarif",
+		Data:          map[string]interface{}{"errors": 0, "warnings": 2, "notes": 0},
+	}
+	zero, two, ten := 0, 2, 10
+
+	tests := []struct {
+		name      string
+		metrics   *model.TaskMetrics
+		failOn    string
+		maxIssues *int
+		want      string
+	}{
+		{"no thresholds", metrics, "", nil, ""},
+		{"fail on error", metrics, "error", nil, "3 SARIF findings at level error"},
+		{"fail on warning", metrics, "warning", nil, "5 SARIF findings at level warning or above"},
+		{"fail on note", metrics, "note", nil, "6 SARIF findings at level note or above"},
+		{"no errors", clean, "error", nil, ""},
+		{"max issues exceeded", metrics, "", &zero, "6 SARIF findings (max 0)"},
+		{"max issues met", clean, "", &two, ""},
+		{"max issues not exceeded", metrics, "", &ten, ""},
+		{"nil metrics", nil, "error", &zero, ""},
+		{"not sarif", &model.TaskMetrics{SummaryFormat: "junit", Data: map[string]interface{}{"errors": 3}}, "error", nil, ""},
+		{"eslint findings", &model.TaskMetrics{SummaryFormat: "eslint", Data: map[string]interface{}{"errors": 2, "warnings": 1, "notes": 0}}, "error", nil, "2 ESLint findings at level error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SARIFThresholdFailure(tt.metrics, tt.failOn, tt.maxIssues); got != tt.want {
+				t.Errorf("SARIFThresholdFailure() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
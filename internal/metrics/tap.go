@@ -0,0 +1,174 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/drew/devpipe/internal/model"
+)
+
+var (
+	tapPlanRe = regexp.MustCompile(`^1\.\.(\d+)`)
+	tapTestRe = regexp.MustCompile(`^(not ok|ok)\b\s*(\d+)?\s*(?:-\s*)?(.*)$`)
+)
+
+// ParseTAP parses Test Anything Protocol output and returns metrics in the same
+// shape as ParseJUnitXML. Tests marked "# SKIP" or "# TODO" count as skipped
+// (a failing TODO test is expected to fail). Indented subtest output is ignored.
+// When the plan line is missing or disagrees with the assertions seen, the test
+// count is taken from the assertions and the discrepancy is noted in "planMismatch".
+func ParseTAP(path string) (*model.TaskMetrics, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var totalTests, totalFailures, totalSkipped, totalTodo int
+	var testCases []map[string]interface{}
+	planned := -1
+	bailOut := ""
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		// Subtests and YAML diagnostics are indented; only top-level lines count
+		if line == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+
+		if strings.HasPrefix(line, "Bail out!") {
+			bailOut = strings.TrimSpace(strings.TrimPrefix(line, "Bail out!"))
+			if bailOut == "" {
+				bailOut = "(no reason given)"
+			}
+			break
+		}
+
+		if m := tapPlanRe.FindStringSubmatch(line); m != nil {
+			planned, _ = strconv.Atoi(m[1])
+			continue
+		}
+
+		m := tapTestRe.FindStringSubmatch(line)
+		if m == nil {
+			continue // "TAP version", "# comments" and other noise
+		}
+
+		totalTests++
+		passed := m[1] == "ok"
+		description, directive, reason := splitTAPDirective(m[3])
+		if description == "" {
+			number := m[2]
+			if number == "" {
+				number = strconv.Itoa(totalTests)
+			}
+			description = "test " + number
+		}
+
+		status := "passed"
+		switch {
+		case directive == "skip":
+			status = "skipped"
+			totalSkipped++
+		case directive == "todo":
+			status = "skipped"
+			totalSkipped++
+			totalTodo++
+		case !passed:
+			status = "failed"
+			totalFailures++
+		}
+
+		testCase := map[string]interface{}{
+			"name":      description,
+			"classname": "",
+			"time":      0.0,
+			"status":    status,
+		}
+		if reason != "" {
+			testCase["message"] = reason
+		}
+		testCases = append(testCases, testCase)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if totalTests == 0 && planned < 0 && bailOut == "" {
+		return nil, fmt.Errorf("no TAP plan or test lines found - file may not be valid TAP")
+	}
+
+	data := map[string]interface{}{
+		"tests":     totalTests,
+		"failures":  totalFailures,
+		"errors":    0,
+		"skipped":   totalSkipped,
+		"todo":      totalTodo,
+		"time":      0.0,
+		"testcases": testCases,
+	}
+
+	switch {
+	case planned < 0:
+		data["planMismatch"] = fmt.Sprintf("no plan line; counted %d test(s) from assertions", totalTests)
+	case planned != totalTests:
+		data["planned"] = planned
+		data["planMismatch"] = fmt.Sprintf("plan declared %d test(s) but %d ran", planned, totalTests)
+	default:
+		data["planned"] = planned
+	}
+
+	if bailOut != "" {
+		data["bailOut"] = bailOut
+	}
+
+	return &model.TaskMetrics{
+		Kind:          "test",
+		SummaryFormat: "tap",
+		Data:          data,
+	}, nil
+}
+
+// splitTAPDirective splits "desc # SKIP reason" into its description, directive
+// ("skip", "todo" or "") and the directive's reason
+func splitTAPDirective(s string) (description, directive, reason string) {
+	idx := strings.Index(s, "#")
+	for idx >= 0 {
+		// A "\#" is an escaped hash, not a directive
+		if idx == 0 || s[idx-1] != '\\' {
+			rest := strings.TrimSpace(s[idx+1:])
+			word := strings.ToLower(rest)
+			switch {
+			case strings.HasPrefix(word, "skip"):
+				directive = "skip"
+			case strings.HasPrefix(word, "todo"):
+				directive = "todo"
+			}
+			if directive != "" {
+				// Drop the directive keyword (SKIP, skipped, TODO...) to leave the reason
+				if sp := strings.IndexAny(rest, " \t"); sp >= 0 {
+					reason = strings.TrimSpace(rest[sp+1:])
+				}
+				return unescapeTAP(s[:idx]), directive, reason
+			}
+		}
+		next := strings.Index(s[idx+1:], "#")
+		if next < 0 {
+			break
+		}
+		idx += next + 1
+	}
+	return unescapeTAP(s), "", ""
+}
+
+// unescapeTAP trims a description and restores escaped hashes
+func unescapeTAP(s string) string {
+	return strings.TrimSpace(strings.ReplaceAll(s, `\#`, "#"))
+}
@@ -0,0 +1,180 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseTAP(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		wantErr      bool
+		wantTests    int
+		wantFailures int
+		wantSkipped  int
+		wantTodo     int
+		wantMismatch bool
+	}{
+		{
+			name: "all passing with plan",
+			content: `TAP version 13
+1..3
+ok 1 - first
+ok 2 - second
+ok 3 - third
+`,
+			wantTests: 3,
+		},
+		{
+			name: "failures and directives",
+			content: `1..4
+ok 1 - passes
+not ok 2 - fails
+ok 3 - skipped # SKIP no database
+not ok 4 - pending # TODO write the feature
+`,
+			wantTests:    4,
+			wantFailures: 1,
+			wantSkipped:  2,
+			wantTodo:     1,
+		},
+		{
+			name: "plan at end",
+			content: `ok 1 - a
+not ok 2 - b
+1..2
+`,
+			wantTests:    2,
+			wantFailures: 1,
+		},
+		{
+			name: "missing plan is inferred",
+			content: `ok 1 - a
+ok 2 - b
+`,
+			wantTests:    2,
+			wantMismatch: true,
+		},
+		{
+			name: "plan mismatch",
+			content: `1..5
+ok 1 - a
+ok 2 - b
+`,
+			wantTests:    2,
+			wantMismatch: true,
+		},
+		{
+			name: "indented subtests are ignored",
+			content: `1..1
+    ok 1 - inner a
+    not ok 2 - inner b # TODO flaky
+    1..2
+ok 1 - outer
+`,
+			wantTests: 1,
+		},
+		{
+			name:      "skip all plan",
+			content:   "1..0 # SKIP not on this platform\n",
+			wantTests: 0,
+		},
+		{
+			name:    "not tap",
+			content: "hello world\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "results.tap")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			m, err := ParseTAP(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTAP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if m.Kind != "test" || m.SummaryFormat != "tap" {
+				t.Errorf("Expected kind test / format tap, got %s / %s", m.Kind, m.SummaryFormat)
+			}
+			if got := m.Data["tests"].(int); got != tt.wantTests {
+				t.Errorf("tests = %d, want %d", got, tt.wantTests)
+			}
+			if got := m.Data["failures"].(int); got != tt.wantFailures {
+				t.Errorf("failures = %d, want %d", got, tt.wantFailures)
+			}
+			if got := m.Data["skipped"].(int); got != tt.wantSkipped {
+				t.Errorf("skipped = %d, want %d", got, tt.wantSkipped)
+			}
+			if got := m.Data["todo"].(int); got != tt.wantTodo {
+				t.Errorf("todo = %d, want %d", got, tt.wantTodo)
+			}
+			if _, ok := m.Data["planMismatch"]; ok != tt.wantMismatch {
+				t.Errorf("planMismatch present = %v, want %v (%v)", ok, tt.wantMismatch, m.Data["planMismatch"])
+			}
+		})
+	}
+}
+
+func TestParseTAPTestCases(t *testing.T) {
+	m, err := ParseTAP(filepath.Join("..", "..", "testdata", "tap-sample.tap"))
+	if err != nil {
+		t.Fatalf("ParseTAP() error = %v", err)
+	}
+
+	testCases := m.Data["testcases"].([]map[string]interface{})
+	want := []struct{ name, status, message string }{
+		{"parses config", "passed", ""},
+		{"handles empty input", "failed", ""},
+		{"network test", "skipped", "no network in CI"},
+		{"unicode support", "skipped", "not implemented yet"},
+		{"escapes # characters", "passed", ""},
+	}
+	if len(testCases) != len(want) {
+		t.Fatalf("Expected %d test cases, got %d", len(want), len(testCases))
+	}
+	for i, w := range want {
+		tc := testCases[i]
+		message, _ := tc["message"].(string)
+		if tc["name"] != w.name || tc["status"] != w.status || message != w.message {
+			t.Errorf("testcase %d = %v/%v/%q, want %s/%s/%q", i, tc["name"], tc["status"], message, w.name, w.status, w.message)
+		}
+	}
+	if m.Data["planned"] != 5 {
+		t.Errorf("planned = %v, want 5", m.Data["planned"])
+	}
+}
+
+func TestParseTAPBailOut(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.tap")
+	content := "1..3\nok 1 - a\nBail out! database unavailable\nok 2 - never counted\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := ParseTAP(path)
+	if err != nil {
+		t.Fatalf("ParseTAP() error = %v", err)
+	}
+	if m.Data["bailOut"] != "database unavailable" {
+		t.Errorf("bailOut = %v, want %q", m.Data["bailOut"], "database unavailable")
+	}
+	if m.Data["tests"] != 1 {
+		t.Errorf("tests = %v, want 1", m.Data["tests"])
+	}
+}
+
+func TestParseTAPFileNotFound(t *testing.T) {
+	if _, err := ParseTAP("does-not-exist.tap"); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
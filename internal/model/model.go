@@ -11,25 +11,67 @@ const (
 	StatusPass    TaskStatus = "PASS"
 	StatusFail    TaskStatus = "FAIL"
 	StatusSkipped TaskStatus = "SKIPPED"
+	StatusWarn    TaskStatus = "WARN" // Failed, but the task has continueOnError so the pipeline does not fail
 )
 
+// Labels are descriptive key/value metadata on a run or task; they never change behavior
+type Labels map[string]string
+
 // TaskDefinition is the resolved definition of a task ready to execute
 type TaskDefinition struct {
 	ID               string
 	Name             string
 	Desc             string
 	Phase            string
+	PhaseID          string // Phase header id, e.g. "phase-test" (empty if the config has no phases)
 	Type             string
-	Command          string
+	Command          string   // Shell command, or the display form of CommandArgs
+	CommandArgs      []string // Program and args run directly without a shell (empty = run Command via Shell)
 	Workdir          string
+	CreateWorkdir    bool // Create Workdir before running if it does not exist
 	EstimatedSeconds int
 	IsEstimateGuess  bool     // True if estimate is a default guess (show as "10s?")
 	Wait             bool     // If true, marks end of phase (wait for all previous tasks)
-	OutputType       string   // "junit", "sarif", "artifact"
+	Barrier          bool     // With Wait, the phase end is a wait marker that dependsOn cannot cross
+	DependsOn        []string // Tasks that must finish first; with parallel tasks it may start before its phase
+	OutputType       string   // "junit", "tap", "sarif", "eslint", "checkstyle", "artifact", "command"
 	OutputPath       string   // Path to output file
+	MetricsParser    string   // Command that parses the output file when OutputType is "command"
+	ArtifactDir      string   // Where the output copy is stored, relative to the run dir (empty = outputs/<outputPath>)
+	SkipArtifactCopy bool     // Record the output files' original path instead of copying them into the run dir
 	FixType          string   // "auto", "helper", "none", or ""
+	FixMode          string   // "before" runs an auto fix ahead of the check; "after" or "" re-checks after a failure
 	FixCommand       string   // Command to run to fix issues
 	WatchPaths       []string // Glob patterns to watch (relative to workdir)
+	Required         bool     // Always run, even when watchPaths filtering would skip the task
+	ContinueOnError  bool     // Advisory task: a failure is reported as WARN and does not fail the pipeline
+	WatchPathsMatch  bool     // Set by watchPaths filtering when changed files matched WatchPaths
+	CacheInputs      []string // Glob patterns hashed to skip the task when its inputs are unchanged
+	EmptyOutput      string   // "warn", "fail", or "ignore" when a task passes instantly with no output
+	Shell            []string // Shell program and args used to run commands (e.g. ["sh", "-c"])
+	Image            string   // Container image the command and fix command run in (empty = on the host)
+	ContainerRuntime string   // Container CLI used when Image is set, e.g. "docker"
+	When             string   // Condition that must be true for the task to run (empty = always)
+	OnlyIf           string   // Path or glob (relative to Workdir) that must exist for the task to run
+	SkipIf           string   // Path or glob (relative to Workdir) that skips the task when it exists
+	RequiredEnv      []string // Environment variables that must be set and non-empty for the task to run
+	Tags             []string // Tags used by --tag/--exclude-tag
+	Labels           Labels   // Descriptive labels copied to the task's result
+	Group            string   // Concurrency group; tasks sharing it in a phase run one at a time
+	Timestamps       string   // "clock" or "elapsed" to prefix streamed output lines ("off" or "" for none)
+	TimestampsInLogs bool     // Also write the timestamp prefix to the task log file
+	LogFormat        string   // "text" or "jsonl" for the task log file
+	MaxLogBytes      int64    // Output kept in the log and on the console before truncating (0 = unlimited)
+	LogPreviewLines  int      // Log lines previewed on the run report for a task that did not fail
+	LogPreviewFailed int      // Log lines previewed on the run report for a failed task
+	HeartbeatSeconds int      // Print a still-running line after this long without output (0 = never)
+	MaskPatterns     []string // Regexes masked with *** in the task's output before it is logged or shown
+	SanitizeOutput   bool     // Escape control characters and invalid UTF-8 in output shown on the console
+	AllowExitCodes   []int    // Non-zero exit codes that count as success
+	FailOnMatch      string   // Regex that fails the task when an output line matches (empty = none)
+	PassOnMatch      string   // Regex that passes the task when an output line matches (empty = none)
+	SarifFailOn      string   // Lowest SARIF level that fails the task ("error", "warning", "note")
+	SarifMaxIssues   *int     // Maximum SARIF findings allowed before the task fails
 }
 
 // TaskResult is the per-task record written into run.json
@@ -46,6 +88,7 @@ type TaskResult struct {
 	Command           string       `json:"command"`
 	Workdir           string       `json:"workdir"`
 	LogPath           string       `json:"logPath"`
+	LogFormat         string       `json:"logFormat,omitempty"` // "jsonl" for JSON lines logs, empty for raw text
 	StartTime         string       `json:"startTime,omitempty"`
 	EndTime           string       `json:"endTime,omitempty"`
 	DurationMs        int64        `json:"durationMs"`
@@ -55,13 +98,40 @@ type TaskResult struct {
 	InitialExitCode   *int         `json:"initialExitCode,omitempty"`
 	FixDurationMs     int64        `json:"fixDurationMs,omitempty"`
 	RecheckDurationMs int64        `json:"recheckDurationMs,omitempty"`
+	FixedBefore       bool         `json:"fixedBefore,omitempty"` // fixCommand ran before the check (fixMode = "before"), taking FixDurationMs of DurationMs
 	Metrics           *TaskMetrics `json:"metrics,omitempty"`
+	NoOutput          bool         `json:"noOutput,omitempty"`        // Passed almost instantly without output or metrics
+	AllowedExitCode   bool         `json:"allowedExitCode,omitempty"` // Passed with a non-zero exit code listed in allowExitCodes
+	FailedOnMatch     string       `json:"failedOnMatch,omitempty"`   // failOnMatch pattern that failed the task although it exited 0
+	PassedOnMatch     string       `json:"passedOnMatch,omitempty"`   // passOnMatch pattern that passed the task although it exited non-zero
+	MatchedLine       string       `json:"matchedLine,omitempty"`     // Output line that matched FailedOnMatch or PassedOnMatch
+	CachedFrom        string       `json:"cachedFrom,omitempty"`      // Run ID this passing result was reused from by --resume
+	Interrupted       bool         `json:"interrupted,omitempty"`     // Killed by Ctrl-C/SIGTERM while running
+	NotFound          string       `json:"notFound,omitempty"`        // Program of the task command that was not found
+	FixNotFound       string       `json:"fixNotFound,omitempty"`     // Program of the fix command that was not found
+	Artifact          string       `json:"artifact,omitempty"`        // Stored copy of the output file, relative to the run directory
+	OutputFile        string       `json:"outputFile,omitempty"`      // Original output path (or glob), when copyArtifacts = false left it uncopied
+	LogPreviewLines   int          `json:"logPreviewLines,omitempty"` // Log lines previewed on the run report (0 = the report's default)
+	Labels            Labels       `json:"labels,omitempty"`          // Descriptive labels from the task's config
+	Usage             *Usage       `json:"usage,omitempty"`           // Peak memory and CPU time of the command, where the platform reports them
+}
+
+// Usage is the resource usage of a task's command, including the processes it waited for
+type Usage struct {
+	MaxRSSBytes int64 `json:"maxRssBytes"` // Peak resident memory of the largest process
+	UserCPUMs   int64 `json:"userCpuMs"`
+	SystemCPUMs int64 `json:"systemCpuMs"`
+}
+
+// CPUMs returns the total user and system CPU time
+func (u Usage) CPUMs() int64 {
+	return u.UserCPUMs + u.SystemCPUMs
 }
 
 // TaskMetrics holds parsed metrics from task outputs
 type TaskMetrics struct {
 	Kind          string                 `json:"kind"`                    // "test", "lint", "coverage", "build"
-	SummaryFormat string                 `json:"summaryFormat,omitempty"` // "junit", "eslint", "sarif"
+	SummaryFormat string                 `json:"summaryFormat,omitempty"` // "junit", "tap", "sarif", "eslint", "checkstyle"
 	Data          map[string]interface{} `json:"data,omitempty"`
 }
 
@@ -76,27 +146,34 @@ type TestMetrics struct {
 
 // RunFlags captures CLI flags for run.json
 type RunFlags struct {
-	Fast     bool     `json:"fast"`
-	FailFast bool     `json:"failFast"`
-	DryRun   bool     `json:"dryRun"`
-	Verbose  bool     `json:"verbose"`
-	Only     string   `json:"only,omitempty"`
-	Skip     []string `json:"skip,omitempty"`
-	Config   string   `json:"config,omitempty"`
-	Since    string   `json:"since,omitempty"`
+	Fast        bool     `json:"fast"`
+	FailFast    bool     `json:"failFast"`
+	DryRun      bool     `json:"dryRun"`
+	Verbose     bool     `json:"verbose"`
+	Only        string   `json:"only,omitempty"`
+	Skip        []string `json:"skip,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	ExcludeTags []string `json:"excludeTags,omitempty"`
+	Config      string   `json:"config,omitempty"`
+	Since       string   `json:"since,omitempty"`
+	Resume      string   `json:"resume,omitempty"` // Run ID resumed with --resume
 }
 
 // ConfigValue represents a single configuration value with its source
 type ConfigValue struct {
 	Key      string `json:"key"`
 	Value    string `json:"value"`
-	Source   string `json:"source"`             // "config-file", "cli-flag", "default", "historical"
+	Source   string `json:"source"`             // "config-file", "profile", "cli-flag", "default", "historical"
 	Overrode string `json:"overrode,omitempty"` // What value it replaced, if any
 }
 
 // EffectiveConfig holds the resolved configuration with source tracking
 type EffectiveConfig struct {
-	Values []ConfigValue `json:"values"`
+	Profile       string        `json:"profile,omitempty"`       // Profile laid over the config file, if any
+	ProfileSource string        `json:"profileSource,omitempty"` // Where the profile was chosen: cli-flag or env
+	ConfigPath    string        `json:"configPath,omitempty"`    // Config file loaded
+	ConfigSource  string        `json:"configSource,omitempty"`  // Where the config file was chosen: cli-flag, env, discovered or default
+	Values        []ConfigValue `json:"values"`
 }
 
 // RunRecord is the top-level JSON written per run
@@ -113,5 +190,16 @@ type RunRecord struct {
 	Git             interface{}      `json:"git"`               // git.GitInfo
 	Flags           RunFlags         `json:"flags"`
 	Tasks           []TaskResult     `json:"tasks"`
+	Interrupted     bool             `json:"interrupted,omitempty"` // Run was stopped by Ctrl-C/SIGTERM; tasks not yet started are missing
+	Labels          Labels           `json:"labels,omitempty"`      // Descriptive labels from defaults.labels and --label
+	Hooks           []HookResult     `json:"hooks,omitempty"`       // Before and after hooks, in the order they ran
 	EffectiveConfig *EffectiveConfig `json:"effectiveConfig,omitempty"`
 }
+
+// HookResult is the outcome of one hooks.before or hooks.after command; its output is in pipeline.log
+type HookResult struct {
+	Stage      string `json:"stage"` // "before" or "after"
+	Command    string `json:"command"`
+	ExitCode   int    `json:"exitCode"` // -1 when the command could not be started or was killed
+	DurationMs int64  `json:"durationMs"`
+}
@@ -0,0 +1,59 @@
+// Package notify sends desktop notifications when a pipeline run finishes.
+package notify
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// lookPath and runCommand are variables so tests can stub them
+var (
+	lookPath   = exec.LookPath
+	runCommand = func(name string, args ...string) error {
+		return exec.Command(name, args...).Run()
+	}
+)
+
+// Send fires an OS notification. Failures use an error-style notification.
+// It is a no-op on unsupported platforms or when the notifier binary is missing.
+func Send(title, message string, failed bool) error {
+	name, args := buildCommand(runtime.GOOS, title, message, failed)
+	if name == "" {
+		return nil
+	}
+	if _, err := lookPath(name); err != nil {
+		return nil
+	}
+	return runCommand(name, args...)
+}
+
+// buildCommand returns the notifier binary and arguments for the given OS
+// An empty name means notifications are not supported on that OS
+func buildCommand(goos, title, message string, failed bool) (string, []string) {
+	switch goos {
+	case "darwin":
+		script := "display notification " + appleScriptString(message) + " with title " + appleScriptString(title)
+		if failed {
+			script += ` sound name "Basso"`
+		}
+		return "osascript", []string{"-e", script}
+	case "linux":
+		urgency := "normal"
+		icon := "dialog-information"
+		if failed {
+			urgency = "critical"
+			icon = "dialog-error"
+		}
+		return "notify-send", []string{"--urgency=" + urgency, "--icon=" + icon, title, message}
+	default:
+		return "", nil
+	}
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBuildCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		goos     string
+		failed   bool
+		wantName string
+		wantArg  string
+	}{
+		{"macOS success", "darwin", false, "osascript", `display notification "2 passed" with title "devpipe"`},
+		{"macOS failure plays error sound", "darwin", true, "osascript", `sound name "Basso"`},
+		{"linux success", "linux", false, "notify-send", "--urgency=normal"},
+		{"linux failure is critical", "linux", true, "notify-send", "--urgency=critical"},
+		{"unsupported platform", "windows", false, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, args := buildCommand(tt.goos, "devpipe", "2 passed", tt.failed)
+			if name != tt.wantName {
+				t.Errorf("name = %q, want %q", name, tt.wantName)
+			}
+			if tt.wantArg != "" && !strings.Contains(strings.Join(args, " "), tt.wantArg) {
+				t.Errorf("args %q missing %q", args, tt.wantArg)
+			}
+		})
+	}
+}
+
+func TestAppleScriptString(t *testing.T) {
+	got := appleScriptString(`say "hi" \ bye`)
+	want := `"say \"hi\" \\ bye"`
+	if got != want {
+		t.Errorf("appleScriptString() = %s, want %s", got, want)
+	}
+}
+
+func TestSend_MissingBinaryIsNoop(t *testing.T) {
+	origLookPath, origRun := lookPath, runCommand
+	defer func() { lookPath, runCommand = origLookPath, origRun }()
+
+	lookPath = func(string) (string, error) { return "", errors.New("not found") }
+	runCommand = func(string, ...string) error {
+		t.Fatal("notifier should not run when binary is missing")
+		return nil
+	}
+
+	if err := Send("devpipe", "done", false); err != nil {
+		t.Errorf("Send() error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Package preflight checks that the programs task commands run are installed before a run starts.
+package preflight
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Status is the outcome of looking up one program
+type Status string
+
+const (
+	StatusFound     Status = "found"
+	StatusMissing   Status = "missing"
+	StatusBuiltin   Status = "builtin"
+	StatusUnchecked Status = "unchecked" // Shell syntax that cannot be checked statically
+)
+
+// Tool is one program a command runs
+type Tool struct {
+	Name   string // Program as written in the command, e.g. "gofmt" or "./scripts/lint.sh"
+	Path   string // Resolved location when found
+	Status Status
+}
+
+// builtins are commands the shell runs itself, so they never need to be on PATH
+var builtins = map[string]bool{
+	".": true, ":": true, "[": true, "alias": true, "cd": true, "command": true, "echo": true,
+	"eval": true, "exec": true, "exit": true, "export": true, "false": true, "printf": true,
+	"read": true, "return": true, "set": true, "shift": true, "source": true, "test": true,
+	"trap": true, "true": true, "type": true, "ulimit": true, "umask": true, "unset": true, "wait": true,
+}
+
+// IsBuiltin reports whether name is a shell builtin
+func IsBuiltin(name string) bool {
+	return builtins[name]
+}
+
+// Lookup resolves a program the way the shell would from workdir: names containing a slash
+// are paths relative to workdir, anything else is searched on PATH
+func Lookup(name, workdir string) (string, bool) {
+	if strings.ContainsRune(name, '/') {
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workdir, path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return "", false
+		}
+		return path, true
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// Program returns the program a simple command runs: its first word after any leading
+// VAR=value assignments, or "" if there is none
+func Program(command string) string {
+	for _, field := range strings.Fields(command) {
+		if strings.Contains(field, "=") {
+			continue
+		}
+		return field
+	}
+	return ""
+}
+
+// CheckCommand looks up the program of each simple command in command, which may be joined
+// with |, &&, || or ;. Segments using subshells, command substitution or quoting are
+// reported as unchecked rather than guessed at
+func CheckCommand(command, workdir string) []Tool {
+	var tools []Tool
+	for _, segment := range splitCommand(command) {
+		if strings.ContainsAny(segment, "()`'\"{}") || strings.Contains(segment, "$(") {
+			tools = append(tools, Tool{Name: strings.TrimSpace(segment), Status: StatusUnchecked})
+			continue
+		}
+
+		name := Program(segment)
+		switch {
+		case name == "":
+			continue
+		case strings.HasPrefix(name, "$"):
+			// The program comes from a variable
+			tools = append(tools, Tool{Name: name, Status: StatusUnchecked})
+		case IsBuiltin(name):
+			tools = append(tools, Tool{Name: name, Status: StatusBuiltin})
+		default:
+			tools = append(tools, CheckProgram(name, workdir))
+		}
+	}
+	return tools
+}
+
+// CheckProgram looks up a single program, e.g. the first element of a task's commandArgs
+func CheckProgram(name, workdir string) Tool {
+	if path, ok := Lookup(name, workdir); ok {
+		return Tool{Name: name, Path: path, Status: StatusFound}
+	}
+	return Tool{Name: name, Status: StatusMissing}
+}
+
+// splitCommand splits a shell command on the operators that start a new simple command
+func splitCommand(command string) []string {
+	for _, op := range []string{"&&", "||", "|", ";", "\n"} {
+		command = strings.ReplaceAll(command, op, "\x00")
+	}
+	return strings.Split(command, "\x00")
+}
@@ -0,0 +1,55 @@
+package preflight
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProgram(t *testing.T) {
+	tests := map[string]string{
+		"gofmt -l .":                 "gofmt",
+		"GOFLAGS=-mod=mod go test":   "go",
+		"  ./scripts/lint.sh --fix ": "./scripts/lint.sh",
+		"":                           "",
+	}
+	for command, want := range tests {
+		if got := Program(command); got != want {
+			t.Errorf("Program(%q) = %q, want %q", command, got, want)
+		}
+	}
+}
+
+func TestCheckCommand(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "scripts"), 0o755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "scripts", "lint.sh"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+
+	tools := CheckCommand("cd web && devpipe-no-such-tool run | sh -c 'wc -l'; ./scripts/lint.sh; $LINTER .", dir)
+	want := []struct {
+		name   string
+		status Status
+	}{
+		{"cd", StatusBuiltin},
+		{"devpipe-no-such-tool", StatusMissing},
+		{"sh -c 'wc -l'", StatusUnchecked},
+		{"./scripts/lint.sh", StatusFound},
+		{"$LINTER", StatusUnchecked},
+	}
+
+	if len(tools) != len(want) {
+		t.Fatalf("CheckCommand() = %+v, want %d tools", tools, len(want))
+	}
+	for i, w := range want {
+		if tools[i].Name != w.name || tools[i].Status != w.status {
+			t.Errorf("tool %d = %s (%s), want %s (%s)", i, tools[i].Name, tools[i].Status, w.name, w.status)
+		}
+	}
+	if tools[3].Path != filepath.Join(dir, "scripts", "lint.sh") {
+		t.Errorf("script path = %q, want it resolved against the workdir", tools[3].Path)
+	}
+}
@@ -0,0 +1,96 @@
+// Package promexport writes run results in the Prometheus text exposition format
+// for the node_exporter textfile collector.
+package promexport
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/drew/devpipe/internal/model"
+)
+
+// statuses are the values of the status label on devpipe_task_status, one series each
+var statuses = []model.TaskStatus{model.StatusPass, model.StatusFail, model.StatusWarn, model.StatusSkipped}
+
+// Write renders the metrics for a finished run
+func Write(w io.Writer, results []model.TaskResult, totalMs int64) error {
+	var sb strings.Builder
+
+	sb.WriteString("# HELP devpipe_pipeline_duration_seconds Wall-clock duration of the last devpipe run.\n")
+	sb.WriteString("# TYPE devpipe_pipeline_duration_seconds gauge\n")
+	sb.WriteString(fmt.Sprintf("devpipe_pipeline_duration_seconds %s\n", seconds(totalMs)))
+
+	sb.WriteString("# HELP devpipe_task_duration_seconds Duration of each task in the last devpipe run.\n")
+	sb.WriteString("# TYPE devpipe_task_duration_seconds gauge\n")
+	for _, r := range results {
+		sb.WriteString(fmt.Sprintf("devpipe_task_duration_seconds{task=\"%s\",status=\"%s\"} %s\n",
+			escapeLabel(r.ID), statusLabel(r.Status), seconds(r.DurationMs)))
+	}
+
+	// One series per possible status so alerts can match status="fail" == 1
+	sb.WriteString("# HELP devpipe_task_status Task status in the last devpipe run (1 for the status the task ended in).\n")
+	sb.WriteString("# TYPE devpipe_task_status gauge\n")
+	for _, r := range results {
+		for _, status := range statuses {
+			value := 0
+			if r.Status == status {
+				value = 1
+			}
+			sb.WriteString(fmt.Sprintf("devpipe_task_status{task=\"%s\",status=\"%s\"} %d\n",
+				escapeLabel(r.ID), statusLabel(status), value))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteFile writes the metrics to path atomically: a temp file in the same directory
+// is renamed over path, so a collector never reads a partial file
+func WriteFile(path string, results []model.TaskResult, totalMs int64) (err error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp.Name()) // Best effort cleanup
+		}
+	}()
+
+	if err := Write(tmp, results, totalMs); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// CreateTemp uses 0600; textfile collectors often run as a different user
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// statusLabel lowercases a task status for use as a label value ("pass", "fail", "skipped")
+func statusLabel(status model.TaskStatus) string {
+	return strings.ToLower(string(status))
+}
+
+// escapeLabel escapes a label value per the exposition format
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// seconds formats milliseconds as a seconds sample value
+func seconds(ms int64) string {
+	return fmt.Sprintf("%.3f", float64(ms)/1000.0)
+}
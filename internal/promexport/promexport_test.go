@@ -0,0 +1,70 @@
+package promexport
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/drew/devpipe/internal/model"
+)
+
+func sampleResults() []model.TaskResult {
+	return []model.TaskResult{
+		{ID: "lint", Status: model.StatusPass, DurationMs: 1200},
+		{ID: "test", Status: model.StatusFail, DurationMs: 3400},
+		{ID: `odd"id`, Status: model.StatusSkipped},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, sampleResults(), 5000); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"# TYPE devpipe_pipeline_duration_seconds gauge\n",
+		"devpipe_pipeline_duration_seconds 5.000\n",
+		`devpipe_task_duration_seconds{task="lint",status="pass"} 1.200`,
+		`devpipe_task_duration_seconds{task="test",status="fail"} 3.400`,
+		`devpipe_task_status{task="test",status="fail"} 1`,
+		`devpipe_task_status{task="test",status="pass"} 0`,
+		`devpipe_task_status{task="odd\"id",status="skipped"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteFile_ReplacesAtomically(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "textfile", "devpipe.prom")
+
+	if err := WriteFile(path, sampleResults(), 5000); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := WriteFile(path, sampleResults()[:1], 1200); err != nil {
+		t.Fatalf("second WriteFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read metrics: %v", err)
+	}
+	if strings.Contains(string(data), `task="test"`) {
+		t.Errorf("second write should replace the file, got:\n%s", data)
+	}
+
+	// No temp files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only devpipe.prom in the directory, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,88 @@
+// Package redact masks secrets in task output before it is logged or shown.
+package redact
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Mask replaces every redacted secret
+const Mask = "***"
+
+// minEnvValueLen is the shortest environment value that is masked; shorter values such as
+// "1" or "on" would mask unrelated output
+const minEnvValueLen = 4
+
+// Builtin are the patterns applied unless defaults.maskBuiltins is false. Patterns with a
+// capture group only mask the group, so "Bearer" stays readable
+var Builtin = []string{
+	`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,                                     // AWS access key IDs
+	`(?i)aws_secret_access_key["']?\s*[=:]\s*["']?([A-Za-z0-9/+=]{40})`, // AWS secret keys
+	`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]+=*)`,                            // Bearer tokens
+	`\bgh[pousr]_[A-Za-z0-9]{36,}\b`,                                    // GitHub tokens
+	`(?i)\b[a-z][a-z0-9+.-]*://[^/\s:@]+:([^/\s@]+)@`,                   // Passwords in connection strings
+}
+
+// Masker replaces matches of its patterns with Mask. A nil Masker leaves text unchanged
+type Masker struct {
+	patterns []*regexp.Regexp
+}
+
+// New compiles patterns into a Masker, or returns nil when there are none
+func New(patterns []string) (*Masker, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	m := &Masker{}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		m.patterns = append(m.patterns, re)
+	}
+	return m, nil
+}
+
+// EnvPatterns returns patterns matching the values of the named environment variables.
+// Unset variables and values shorter than 4 characters are left out
+func EnvPatterns(names []string, getenv func(string) string) []string {
+	var patterns []string
+	for _, name := range names {
+		if value := getenv(name); len(value) >= minEnvValueLen {
+			patterns = append(patterns, regexp.QuoteMeta(value))
+		}
+	}
+	return patterns
+}
+
+// String masks every match in s. For a pattern with a capture group only the first group is masked
+func (m *Masker) String(s string) string {
+	if m == nil {
+		return s
+	}
+	for _, re := range m.patterns {
+		if re.NumSubexp() == 0 {
+			s = re.ReplaceAllLiteralString(s, Mask)
+			continue
+		}
+		matches := re.FindAllStringSubmatchIndex(s, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, match := range matches {
+			start, end := match[0], match[1]
+			if match[2] >= 0 {
+				start, end = match[2], match[3]
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(Mask)
+			last = end
+		}
+		b.WriteString(s[last:])
+		s = b.String()
+	}
+	return s
+}
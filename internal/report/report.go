@@ -0,0 +1,449 @@
+// Package report serializes pipeline run results into machine-readable summary formats.
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/drew/devpipe/internal/model"
+	"github.com/drew/devpipe/internal/sanitize"
+	"github.com/drew/devpipe/internal/tasklog"
+)
+
+// Summary is the format-independent view of a finished run
+// Every writer in this package renders from the same Summary so outputs never disagree
+type Summary struct {
+	RunID      string             `json:"runId"`
+	Branch     string             `json:"branch,omitempty"` // Checked-out branch, empty on a detached HEAD
+	Commit     string             `json:"commit,omitempty"` // Abbreviated HEAD commit
+	Status     string             `json:"status"`           // "PASS" or "FAIL"
+	TotalMs    int64              `json:"totalMs"`
+	PassCount  int                `json:"passCount"`
+	FailCount  int                `json:"failCount"`
+	SkipCount  int                `json:"skipCount"`
+	WarnCount  int                `json:"warnCount,omitempty"` // advisory failures (continueOnError)
+	TotalTasks int                `json:"totalTasks"`
+	Tasks      []model.TaskResult `json:"tasks"`
+}
+
+// Summarize builds a Summary from task results
+func Summarize(runID string, results []model.TaskResult, totalMs int64) Summary {
+	s := Summary{
+		RunID:      runID,
+		Status:     "PASS",
+		TotalMs:    totalMs,
+		TotalTasks: len(results),
+		Tasks:      results,
+	}
+
+	for _, r := range results {
+		switch r.Status {
+		case model.StatusPass:
+			s.PassCount++
+		case model.StatusFail:
+			s.FailCount++
+		case model.StatusSkipped:
+			s.SkipCount++
+		case model.StatusWarn:
+			s.WarnCount++
+		}
+	}
+
+	if s.FailCount > 0 {
+		s.Status = "FAIL"
+	}
+
+	return s
+}
+
+// WriteJSON writes the summary as indented JSON
+func WriteJSON(w io.Writer, s Summary) error {
+	out := jsonSummary{Summary: s, Tasks: make([]jsonTask, len(s.Tasks))}
+	for i, t := range s.Tasks {
+		out.Tasks[i].TaskResult = t
+		if t.Status == model.StatusFail {
+			out.Tasks[i].FailureTail = failureTail(t)
+		}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+// jsonSummary is the JSON summary, whose tasks carry the end of a failed task's log
+type jsonSummary struct {
+	Summary
+	Tasks []jsonTask `json:"tasks"`
+}
+
+type jsonTask struct {
+	model.TaskResult
+	FailureTail []string `json:"failureTail,omitempty"` // Last failureLogLines lines of a failed task's log
+}
+
+// failureTail returns the last failureLogLines lines of t's log, leaving out trailing blank lines
+func failureTail(t model.TaskResult) []string {
+	if t.LogPath == "" {
+		return nil
+	}
+	lines, err := tasklog.ReadLines(t.LogPath, t.LogFormat)
+	if err != nil {
+		return nil
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > failureLogLines {
+		lines = lines[len(lines)-failureLogLines:]
+	}
+	for i, line := range lines {
+		lines[i] = sanitize.Plain(line)
+	}
+	return lines
+}
+
+// junitTestSuites is the root element of the JUnit report
+type junitTestSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name       string           `xml:"name,attr"`
+	Classname  string           `xml:"classname,attr"`
+	Time       string           `xml:"time,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	Failure    *junitMessage    `xml:"failure,omitempty"`
+	Skipped    *junitMessage    `xml:"skipped,omitempty"`
+	SystemOut  string           `xml:"system-out,omitempty"`
+	SystemErr  string           `xml:"system-err,omitempty"`
+}
+
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+// junitProperty is a name/value pair on a testcase, e.g. the task's description
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the summary as JUnit XML, one testcase per task. A task's desc is
+// recorded as a "description" property and leads the failure text, so CI shows why it matters.
+// The command and the tail of the log are included as <system-out>, or <system-err> for a
+// failed task, whose <failure> message also quotes the line that most likely explains it
+func WriteJUnit(w io.Writer, s Summary) error {
+	suite := junitSuite{
+		Name:     "devpipe",
+		Tests:    s.TotalTasks,
+		Failures: s.FailCount,
+		Skipped:  s.SkipCount,
+		Time:     formatSeconds(s.TotalMs),
+	}
+
+	for _, t := range s.Tasks {
+		classname := "devpipe"
+		if t.Phase != "" {
+			classname = "devpipe." + t.Phase
+		}
+		tc := junitTestCase{
+			Name:      t.ID,
+			Classname: classname,
+			Time:      formatSeconds(t.DurationMs),
+		}
+		if t.Desc != "" {
+			tc.Properties = &junitProperties{Properties: []junitProperty{{Name: "description", Value: t.Desc}}}
+		}
+		switch t.Status {
+		case model.StatusFail:
+			msg := "task failed"
+			if t.ExitCode != nil {
+				msg = fmt.Sprintf("exit code %d", *t.ExitCode)
+			}
+			if line := errorLine(t); line != "" {
+				msg += ": " + line
+			}
+			body := t.Command
+			if t.Desc != "" {
+				body = t.Desc + "\n\n" + body
+			}
+			if tail := readLastLines(t.LogPath, t.LogFormat, failureLogLines); tail != "" {
+				body += "\n\n" + tail
+			}
+			tc.Failure = &junitMessage{Message: msg, Body: body}
+			tc.SystemErr = systemOutput(t)
+		case model.StatusSkipped:
+			tc.Skipped = &junitMessage{Message: t.SkipReason}
+		default:
+			tc.SystemOut = systemOutput(t)
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+
+	root := junitTestSuites{
+		Name:     "devpipe run " + s.RunID,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteMarkdown writes the summary as Markdown for a PR comment: the run id and git ref, a
+// results table, test and findings rollups from JUnit/TAP and SARIF-style metrics, and the
+// last log lines of each failed task. Everything is in task order, so re-running the same
+// pipeline only changes what actually changed
+func WriteMarkdown(w io.Writer, s Summary) error {
+	var sb strings.Builder
+
+	icon := "✅"
+	if s.Status == "FAIL" {
+		icon = "❌"
+	}
+	sb.WriteString(fmt.Sprintf("## %s devpipe run `%s`\n\n", icon, s.RunID))
+	if ref := gitRef(s.Branch, s.Commit); ref != "" {
+		sb.WriteString(fmt.Sprintf("On %s\n\n", ref))
+	}
+	warned := ""
+	if s.WarnCount > 0 {
+		warned = fmt.Sprintf(", **%d warned**", s.WarnCount)
+	}
+	sb.WriteString(fmt.Sprintf("**%d passed**, **%d failed**%s, **%d skipped** in %.2fs\n\n",
+		s.PassCount, s.FailCount, warned, s.SkipCount, float64(s.TotalMs)/1000.0))
+
+	sb.WriteString("| Task | Status | Duration |\n")
+	sb.WriteString("|------|--------|----------|\n")
+	for _, t := range s.Tasks {
+		status := string(t.Status)
+		if t.AutoFixed {
+			status += " (auto-fixed)"
+		}
+		sb.WriteString(fmt.Sprintf("| `%s` | %s | %.2fs |\n", t.ID, status, float64(t.DurationMs)/1000.0))
+	}
+
+	writeMarkdownRollups(&sb, s.Tasks)
+
+	// Failures, with the end of their logs
+	var failed []model.TaskResult
+	for _, t := range s.Tasks {
+		if t.Status == model.StatusFail || t.Status == model.StatusWarn {
+			failed = append(failed, t)
+		}
+	}
+	if len(failed) > 0 {
+		sb.WriteString("\n### Failures\n")
+		for _, t := range failed {
+			heading := fmt.Sprintf("`%s`", t.ID)
+			if t.ExitCode != nil {
+				heading += fmt.Sprintf(" (exit code %d)", *t.ExitCode)
+			}
+			if t.Status == model.StatusWarn {
+				heading += " (advisory)"
+			}
+			sb.WriteString(fmt.Sprintf("\n#### %s\n\n", heading))
+			if t.Desc != "" {
+				sb.WriteString(t.Desc + "\n\n")
+			}
+			if tail := readLastLines(t.LogPath, t.LogFormat, failureLogLines); tail != "" {
+				fence := codeFence(tail)
+				sb.WriteString(fmt.Sprintf("%stext\n%s\n%s\n", fence, tail, fence))
+			} else {
+				sb.WriteString("_No log output._\n")
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeMarkdownRollups adds tables totalling the test results (JUnit, TAP) and findings
+// (SARIF and other linters) that tasks parsed from their output, when any did
+func writeMarkdownRollups(sb *strings.Builder, tasks []model.TaskResult) {
+	var tests, findings []string
+	for _, t := range tasks {
+		if t.Metrics == nil {
+			continue
+		}
+		data := t.Metrics.Data
+		if t.Metrics.Kind == "test" {
+			tests = append(tests, fmt.Sprintf("| `%s` | %d | %d | %d | %d |", t.ID,
+				metricInt(data, "tests"), metricInt(data, "failures"), metricInt(data, "errors"), metricInt(data, "skipped")))
+		} else if _, ok := data["findings"]; ok {
+			findings = append(findings, fmt.Sprintf("| `%s` | %s | %d | %d | %d |", t.ID, t.Metrics.SummaryFormat,
+				metricInt(data, "errors"), metricInt(data, "warnings"), metricInt(data, "notes")))
+		}
+	}
+	if len(tests) > 0 {
+		sb.WriteString("\n### Tests\n\n| Task | Tests | Failures | Errors | Skipped |\n|------|-------|----------|--------|---------|\n")
+		sb.WriteString(strings.Join(tests, "\n") + "\n")
+	}
+	if len(findings) > 0 {
+		sb.WriteString("\n### Findings\n\n| Task | Format | Errors | Warnings | Notes |\n|------|--------|--------|----------|-------|\n")
+		sb.WriteString(strings.Join(findings, "\n") + "\n")
+	}
+}
+
+// metricInt reads a count from metrics data, which holds ints when fresh and float64s when
+// read back from run.json
+func metricInt(data map[string]interface{}, key string) int {
+	switch v := data[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// gitRef describes the commit a run was made on, e.g. "`main` @ `3f9a2c1`"
+func gitRef(branch, commit string) string {
+	var parts []string
+	if branch != "" {
+		parts = append(parts, "`"+branch+"`")
+	}
+	if commit != "" {
+		parts = append(parts, "`"+commit+"`")
+	}
+	return strings.Join(parts, " @ ")
+}
+
+// codeFence returns a backtick fence longer than any run of backticks in s, so log output
+// cannot close the code block early
+func codeFence(s string) string {
+	longest, run := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// WriteFile creates path (and its parent directories) and renders the summary into it
+func WriteFile(path string, s Summary, write func(io.Writer, Summary) error) (err error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", path, err)
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("failed to close %s: %w", path, cerr)
+		}
+	}()
+
+	return write(f, s)
+}
+
+// failureLogLines is how many trailing log lines are included in a JUnit <failure> or a
+// failed task's failureTail in the JSON summary
+const failureLogLines = 20
+
+// systemLogLines is how many trailing log lines are included in a JUnit <system-out> or <system-err>
+const systemLogLines = 50
+
+// maxMessageLen caps the log line quoted in a JUnit <failure> message, in runes
+const maxMessageLen = 200
+
+// systemOutput returns a task's command and the tail of its log for <system-out>/<system-err>
+func systemOutput(t model.TaskResult) string {
+	var parts []string
+	if t.Command != "" {
+		parts = append(parts, "$ "+t.Command)
+	}
+	if tail := readLastLines(t.LogPath, t.LogFormat, systemLogLines); tail != "" {
+		parts = append(parts, tail)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// errorLine returns the output line that best explains a failed task: the line its
+// failOnMatch matched, or else the last non-blank line of its log
+func errorLine(t model.TaskResult) string {
+	var line string
+	if t.FailedOnMatch != "" {
+		line = t.MatchedLine
+	} else {
+		lines := strings.Split(readLastLines(t.LogPath, t.LogFormat, failureLogLines), "\n")
+		for i := len(lines) - 1; i >= 0 && line == ""; i-- {
+			line = strings.TrimSpace(lines[i])
+		}
+	}
+	if r := []rune(line); len(r) > maxMessageLen {
+		line = string(r[:maxMessageLen]) + "…"
+	}
+	return line
+}
+
+// readLastLines returns the last n readable lines of a task log without ANSI sequences or
+// control characters, or "" if it can't be read
+func readLastLines(path, format string, n int) string {
+	if path == "" {
+		return ""
+	}
+	lines, err := tasklog.ReadLines(path, format)
+	if err != nil {
+		return ""
+	}
+
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	for i, line := range lines {
+		lines[i] = sanitize.Plain(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatSeconds converts milliseconds to a JUnit-style seconds string
+func formatSeconds(ms int64) string {
+	return fmt.Sprintf("%.3f", float64(ms)/1000.0)
+}
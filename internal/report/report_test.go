@@ -0,0 +1,303 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/drew/devpipe/internal/model"
+)
+
+func sampleResults() []model.TaskResult {
+	exitCode := 2
+	return []model.TaskResult{
+		{ID: "lint", Phase: "Quality", Status: model.StatusPass, DurationMs: 1200},
+		{ID: "test", Status: model.StatusFail, ExitCode: &exitCode, Command: "go test ./...", DurationMs: 3400},
+		{ID: "e2e", Status: model.StatusSkipped, Skipped: true, SkipReason: "skipped by --fast"},
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	s := Summarize("run-1", sampleResults(), 5000)
+
+	if s.Status != "FAIL" {
+		t.Errorf("Status = %q, want FAIL", s.Status)
+	}
+	if s.PassCount != 1 || s.FailCount != 1 || s.SkipCount != 1 {
+		t.Errorf("counts = %d/%d/%d, want 1/1/1", s.PassCount, s.FailCount, s.SkipCount)
+	}
+	if s.TotalTasks != 3 {
+		t.Errorf("TotalTasks = %d, want 3", s.TotalTasks)
+	}
+
+	if got := Summarize("run-2", nil, 0).Status; got != "PASS" {
+		t.Errorf("empty run Status = %q, want PASS", got)
+	}
+
+	// Advisory failures are counted but do not fail the run
+	advisory := Summarize("run-3", []model.TaskResult{{ID: "spellcheck", Status: model.StatusWarn}}, 100)
+	if advisory.Status != "PASS" || advisory.WarnCount != 1 {
+		t.Errorf("advisory run = %q with %d warned, want PASS with 1", advisory.Status, advisory.WarnCount)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, Summarize("run-1", sampleResults(), 5000)); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var decoded Summary
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if decoded.RunID != "run-1" || len(decoded.Tasks) != 3 {
+		t.Errorf("decoded = %+v", decoded)
+	}
+}
+
+func TestWriteJSONFailureTail(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "test.log")
+	var log strings.Builder
+	for i := 1; i <= 30; i++ {
+		log.WriteString("line " + strconv.Itoa(i) + "\n")
+	}
+	log.WriteString("\n\n")
+	if err := os.WriteFile(logPath, []byte(log.String()), 0o644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+
+	results := sampleResults()
+	results[0].LogPath = logPath
+	results[1].LogPath = logPath
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, Summarize("run-1", results, 5000)); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	var decoded struct {
+		Tasks []struct {
+			ID          string   `json:"id"`
+			Status      string   `json:"status"`
+			FailureTail []string `json:"failureTail"`
+		} `json:"tasks"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+
+	// Only the failed task has a tail, its last 20 non-blank lines
+	if tail := decoded.Tasks[0].FailureTail; tail != nil {
+		t.Errorf("passed task failureTail = %q, want none", tail)
+	}
+	tail := decoded.Tasks[1].FailureTail
+	if len(tail) != failureLogLines || tail[0] != "line 11" || tail[len(tail)-1] != "line 30" {
+		t.Errorf("failed task failureTail = %q, want lines 11 to 30", tail)
+	}
+	if decoded.Tasks[1].ID != "test" || decoded.Tasks[1].Status != "FAIL" {
+		t.Errorf("task fields lost: %+v", decoded.Tasks[1])
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, Summarize("run-1", sampleResults(), 5000)); err != nil {
+		t.Fatalf("WriteJUnit() error = %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		`<testsuites name="devpipe run run-1" tests="3" failures="1" skipped="1" time="5.000">`,
+		`<testcase name="lint" classname="devpipe.Quality" time="1.200"></testcase>`,
+		`<failure message="exit code 2">go test ./...</failure>`,
+		`<skipped message="skipped by --fast"></skipped>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("JUnit output missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteJUnit_FailureLogTail(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "test.log")
+	var log strings.Builder
+	for i := 1; i <= 30; i++ {
+		log.WriteString("line " + strconv.Itoa(i) + "\n")
+	}
+	if err := os.WriteFile(logPath, []byte(log.String()), 0o644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+
+	results := sampleResults()
+	results[1].LogPath = logPath
+
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, Summarize("run-1", results, 5000)); err != nil {
+		t.Fatalf("WriteJUnit() error = %v", err)
+	}
+
+	var decoded junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid XML: %v", err)
+	}
+	failure := decoded.Suites[0].TestCases[1].Failure
+	if failure == nil {
+		t.Fatalf("expected a failure for the failed task")
+	}
+	if !strings.HasPrefix(failure.Body, "go test ./...\n\nline 11\n") || !strings.HasSuffix(failure.Body, "line 30") {
+		t.Errorf("failure body should hold the command and the last 20 log lines, got %q", failure.Body)
+	}
+}
+
+func TestWriteJUnit_SystemOutput(t *testing.T) {
+	dir := t.TempDir()
+	var log strings.Builder
+	for i := 1; i <= 60; i++ {
+		log.WriteString("line " + strconv.Itoa(i) + "\n")
+	}
+	passLog := filepath.Join(dir, "lint.log")
+	if err := os.WriteFile(passLog, []byte(log.String()), 0o644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	failLog := filepath.Join(dir, "test.log")
+	if err := os.WriteFile(failLog, []byte("ok pkg/a\n\x1b[31m--- FAIL: TestParse <want> & \x07</got>\x1b[0m\n\n"), 0o644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+
+	results := sampleResults()
+	results[0].Command, results[0].LogPath = "golangci-lint run", passLog
+	results[1].LogPath = failLog
+
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, Summarize("run-1", results, 5000)); err != nil {
+		t.Fatalf("WriteJUnit() error = %v", err)
+	}
+	var decoded junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid XML: %v\n%s", err, buf.String())
+	}
+	cases := decoded.Suites[0].TestCases
+
+	if out := cases[0].SystemOut; !strings.HasPrefix(out, "$ golangci-lint run\n\nline 11\n") || !strings.HasSuffix(out, "line 60") || cases[0].SystemErr != "" {
+		t.Errorf("passing task system-out should hold the command and the last 50 log lines, got %q (system-err %q)", out, cases[0].SystemErr)
+	}
+
+	// The failure message quotes the last line of output, with colors and control characters removed
+	if want := `exit code 2: --- FAIL: TestParse <want> & \x07</got>`; cases[1].Failure.Message != want {
+		t.Errorf("failure message = %q, want %q", cases[1].Failure.Message, want)
+	}
+	if err := cases[1].SystemErr; !strings.HasPrefix(err, "$ go test ./...\n\nok pkg/a\n") || cases[1].SystemOut != "" {
+		t.Errorf("failed task system-err should hold the command and log, got %q (system-out %q)", err, cases[1].SystemOut)
+	}
+	if cases[2].SystemOut != "" || cases[2].SystemErr != "" {
+		t.Errorf("skipped task should have no system output, got %q %q", cases[2].SystemOut, cases[2].SystemErr)
+	}
+
+	// A failOnMatch failure quotes the matched line
+	results[1].FailedOnMatch, results[1].MatchedLine = "^ERROR:", "ERROR: config not found"
+	buf.Reset()
+	if err := WriteJUnit(&buf, Summarize("run-1", results, 5000)); err != nil {
+		t.Fatalf("WriteJUnit() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `<failure message="exit code 2: ERROR: config not found">`) {
+		t.Errorf("failure message should quote the failOnMatch line:\n%s", buf.String())
+	}
+}
+
+func TestWriteJUnit_Desc(t *testing.T) {
+	results := sampleResults()
+	results[1].Desc = "Unit tests must pass before merge"
+
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, Summarize("run-1", results, 5000)); err != nil {
+		t.Fatalf("WriteJUnit() error = %v", err)
+	}
+
+	var decoded junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid XML: %v", err)
+	}
+	cases := decoded.Suites[0].TestCases
+	if cases[0].Properties != nil {
+		t.Errorf("task without desc should have no properties, got %+v", cases[0].Properties)
+	}
+	if want := []junitProperty{{Name: "description", Value: "Unit tests must pass before merge"}}; cases[1].Properties == nil || !reflect.DeepEqual(cases[1].Properties.Properties, want) {
+		t.Errorf("properties = %+v, want %+v", cases[1].Properties, want)
+	}
+	if !strings.HasPrefix(cases[1].Failure.Body, "Unit tests must pass before merge\n\ngo test ./...") {
+		t.Errorf("failure body should start with the desc, got %q", cases[1].Failure.Body)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, Summarize("run-1", sampleResults(), 5000)); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"## ❌ devpipe run `run-1`",
+		"**1 passed**, **1 failed**, **1 skipped** in 5.00s",
+		"| `test` | FAIL | 3.40s |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Markdown output missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteMarkdown_PRComment(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "test.log")
+	if err := os.WriteFile(logPath, []byte("=== RUN TestParse\n```\n--- FAIL: TestParse\n"), 0o644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	results := sampleResults()
+	results[1].LogPath = logPath
+	results[1].Metrics = &model.TaskMetrics{Kind: "test", SummaryFormat: "junit", Data: map[string]interface{}{"tests": 12, "failures": 1, "errors": 0, "skipped": 2}}
+	results[0].Metrics = &model.TaskMetrics{Kind: "lint", SummaryFormat: "sarif", Data: map[string]interface{}{"errors": 0.0, "warnings": 3.0, "notes": 1.0, "findings": []interface{}{}}}
+
+	s := Summarize("run-1", results, 5000)
+	s.Branch, s.Commit = "feature/login", "3f9a2c1"
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, s); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"## ❌ devpipe run `run-1`\n\nOn `feature/login` @ `3f9a2c1`\n",
+		"### Tests\n\n| Task | Tests | Failures | Errors | Skipped |\n|------|-------|----------|--------|---------|\n| `test` | 12 | 1 | 0 | 2 |\n",
+		"### Findings\n\n| Task | Format | Errors | Warnings | Notes |\n|------|--------|--------|----------|-------|\n| `lint` | sarif | 0 | 3 | 1 |\n",
+		"### Failures\n\n#### `test` (exit code 2)\n\n````text\n=== RUN TestParse\n```\n--- FAIL: TestParse\n````\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Markdown output missing %q\n%s", want, out)
+		}
+	}
+
+	// The same summary always renders the same comment
+	var again bytes.Buffer
+	_ = WriteMarkdown(&again, s)
+	if again.String() != out {
+		t.Error("WriteMarkdown() is not deterministic")
+	}
+}
+
+func TestWriteFile_CreatesParentDirs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "dir", "summary.json")
+
+	if err := WriteFile(path, Summarize("run-1", sampleResults(), 5000), WriteJSON); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected file to exist: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+// Package sanitize makes task output safe to show on a terminal or in a report.
+package sanitize
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/acarl005/stripansi"
+)
+
+// Line returns a line of task output that can't corrupt a terminal: invalid UTF-8 becomes
+// U+FFFD and control characters are hex-escaped (ESC as \x1b), so binary output can't move the
+// cursor, clear the screen or retitle the terminal. Tabs and SGR color sequences are kept, and a
+// carriage return keeps only the text after it, as a terminal would show the line
+func Line(s string) string {
+	return escape(lastSegment(s), true)
+}
+
+// Plain is Line without any ANSI sequences, for logs shown in HTML, Markdown or XML reports
+func Plain(s string) string {
+	return escape(lastSegment(stripansi.Strip(s)), false)
+}
+
+// lastSegment drops a CRLF's carriage return and anything a bare one would have overwritten
+func lastSegment(s string) string {
+	s = strings.TrimSuffix(s, "\r")
+	if i := strings.LastIndexByte(s, '\r'); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
+
+// escape rewrites invalid UTF-8 and control characters, keeping SGR sequences when keepSGR is set
+func escape(s string, keepSGR bool) string {
+	if isClean(s) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if keepSGR && s[i] == 0x1b {
+			if n := sgrLen(s[i:]); n > 0 {
+				b.WriteString(s[i : i+n])
+				i += n
+				continue
+			}
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			b.WriteRune(utf8.RuneError)
+		case r == '\t':
+			b.WriteRune(r)
+		case r < 0x20 || (r >= 0x7f && r < 0xa0):
+			fmt.Fprintf(&b, `\x%02x`, r)
+		default:
+			b.WriteRune(r)
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// isClean reports whether s is printable ASCII (plus tabs), the common case that needs no rewriting
+func isClean(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; (c < 0x20 && c != '\t') || c >= 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// sgrLen returns the length of the SGR (color) sequence ESC [ params m at the start of s, or 0
+func sgrLen(s string) int {
+	if len(s) < 3 || s[1] != '[' {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == 'm':
+			return i + 1
+		case (c < '0' || c > '9') && c != ';':
+			return 0
+		}
+	}
+	return 0
+}
@@ -0,0 +1,35 @@
+package sanitize
+
+import "testing"
+
+func TestLine(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain text", "ok  \tgithub.com/x 0.1s", "ok  \tgithub.com/x 0.1s"},
+		{"unicode", "✓ passed – ünïcode", "✓ passed – ünïcode"},
+		{"colors kept", "\x1b[1;32mPASS\x1b[0m", "\x1b[1;32mPASS\x1b[0m"},
+		{"cursor movement escaped", "\x1b[2Jcleared\x1b[H", `\x1b[2Jcleared\x1b[H`},
+		{"title change escaped", "\x1b]0;pwned\x07", `\x1b]0;pwned\x07`},
+		{"invalid utf-8", "bin\xff\xfeary", "bin��ary"},
+		{"nul and bell", "a\x00b\x07", `a\x00b\x07`},
+		{"c1 control", "a\u009bb", `a\x9bb`},
+		{"crlf", "windows line\r", "windows line"},
+		{"progress overwritten", "10%\r50%\r100%", "100%"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Line(tt.in); got != tt.want {
+				t.Errorf("Line(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlain(t *testing.T) {
+	if got, want := Plain("\x1b[31mFAIL\x1b[0m \x00\xff"), `FAIL \x00`+"�"; got != want {
+		t.Errorf("Plain() = %q, want %q", got, want)
+	}
+}
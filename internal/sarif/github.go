@@ -0,0 +1,81 @@
+package sarif
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GitHubAnnotation is a GitHub Actions workflow command that shows up inline on PRs
+type GitHubAnnotation struct {
+	Level   string // "error", "warning" or "notice"
+	File    string // Optional file path
+	Line    int    // Optional line (requires File)
+	Column  int    // Optional column (requires Line)
+	Title   string // Optional title
+	Message string
+}
+
+// String formats the annotation as a workflow command, e.g.
+// ::error file=main.go,line=10,title=G101::Hardcoded credentials
+func (a GitHubAnnotation) String() string {
+	var props []string
+	if a.File != "" {
+		props = append(props, "file="+escapeGitHubProperty(a.File))
+		if a.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", a.Line))
+			if a.Column > 0 {
+				props = append(props, fmt.Sprintf("col=%d", a.Column))
+			}
+		}
+	}
+	if a.Title != "" {
+		props = append(props, "title="+escapeGitHubProperty(a.Title))
+	}
+
+	cmd := "::" + a.Level
+	if len(props) > 0 {
+		cmd += " " + strings.Join(props, ",")
+	}
+	return cmd + "::" + escapeGitHubData(a.Message)
+}
+
+// GitHubLevel maps a SARIF level to a GitHub annotation level
+func GitHubLevel(level string) string {
+	switch strings.ToLower(level) {
+	case "error":
+		return "error"
+	case "note", "none":
+		return "notice"
+	default:
+		return "warning"
+	}
+}
+
+// PrintGitHubAnnotations writes one GitHub Actions annotation per finding
+func PrintGitHubAnnotations(w io.Writer, findings []Finding) {
+	for _, f := range findings {
+		_, _ = fmt.Fprintln(w, GitHubAnnotation{
+			Level:   GitHubLevel(f.Level),
+			File:    f.File,
+			Line:    f.Line,
+			Column:  f.Column,
+			Title:   f.RuleID,
+			Message: f.Message,
+		})
+	}
+}
+
+// escapeGitHubData escapes an annotation message
+func escapeGitHubData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// escapeGitHubProperty escapes an annotation property value
+func escapeGitHubProperty(s string) string {
+	s = escapeGitHubData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
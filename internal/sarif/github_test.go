@@ -0,0 +1,65 @@
+package sarif
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGitHubAnnotationString(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation GitHubAnnotation
+		want       string
+	}{
+		{
+			name:       "message only",
+			annotation: GitHubAnnotation{Level: "error", Message: "Task lint failed"},
+			want:       "::error::Task lint failed",
+		},
+		{
+			name:       "file line and column",
+			annotation: GitHubAnnotation{Level: "warning", File: "cmd/main.go", Line: 10, Column: 4, Title: "G104", Message: "Errors unhandled"},
+			want:       "::warning file=cmd/main.go,line=10,col=4,title=G104::Errors unhandled",
+		},
+		{
+			name:       "escaping",
+			annotation: GitHubAnnotation{Level: "notice", Title: "a,b:c", Message: "100% done\nnext line"},
+			want:       "::notice title=a%2Cb%3Ac::100%25 done%0Anext line",
+		},
+		{
+			name:       "line without file is dropped",
+			annotation: GitHubAnnotation{Level: "error", Line: 5, Message: "x"},
+			want:       "::error::x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.annotation.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintGitHubAnnotations(t *testing.T) {
+	findings := []Finding{
+		{RuleID: "G101", File: "main.go", Line: 10, Message: "Hardcoded credentials", Level: "error"},
+		{RuleID: "G104", File: "util.go", Line: 3, Column: 2, Message: "Errors unhandled", Level: "warning"},
+		{RuleID: "style", File: "doc.go", Line: 1, Message: "Consider a doc comment", Level: "note"},
+	}
+
+	var buf bytes.Buffer
+	PrintGitHubAnnotations(&buf, findings)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		"::error file=main.go,line=10,title=G101::Hardcoded credentials",
+		"::warning file=util.go,line=3,col=2,title=G104::Errors unhandled",
+		"::notice file=doc.go,line=1,title=style::Consider a doc comment",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("PrintGitHubAnnotations() =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -411,6 +411,68 @@ func PrintSummary(findings []Finding) {
 	}
 }
 
+// levelRank orders SARIF levels for --min-level filtering (unknown levels count as warning)
+func levelRank(level string) int {
+	switch strings.ToLower(level) {
+	case "note", "none":
+		return 0
+	case "error":
+		return 2
+	default:
+		return 1
+	}
+}
+
+// IsValidLevel reports whether level can be used as a minimum level filter
+func IsValidLevel(level string) bool {
+	switch level {
+	case "note", "warning", "error":
+		return true
+	default:
+		return false
+	}
+}
+
+// FilterByLevel returns the findings at or above minLevel ("note", "warning" or "error")
+func FilterByLevel(findings []Finding, minLevel string) []Finding {
+	if minLevel == "" {
+		return findings
+	}
+
+	minRank := levelRank(minLevel)
+	var filtered []Finding
+	for _, f := range findings {
+		if levelRank(f.Level) >= minRank {
+			filtered = append(filtered, f)
+		}
+	}
+	return filtered
+}
+
+// Deduplicate collapses findings with the same rule, file, line and message
+// (e.g. reported by overlapping scanners), keeping the first occurrence.
+// Returns the unique findings and how many duplicates were removed.
+func Deduplicate(findings []Finding) ([]Finding, int) {
+	type findingKey struct {
+		ruleID  string
+		file    string
+		line    int
+		message string
+	}
+
+	seen := make(map[findingKey]bool, len(findings))
+	var unique []Finding
+	for _, f := range findings {
+		key := findingKey{f.RuleID, f.File, f.Line, f.Message}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, f)
+	}
+	return unique, len(findings) - len(unique)
+}
+
 // FindSARIFFiles finds all SARIF files in a directory
 func FindSARIFFiles(dir string) ([]string, error) {
 	var files []string
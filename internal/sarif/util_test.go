@@ -179,3 +179,56 @@ func TestFindSARIFFiles_NonexistentDir(t *testing.T) {
 		t.Error("Expected error for nonexistent directory")
 	}
 }
+
+func TestDeduplicate(t *testing.T) {
+	findings := []Finding{
+		{RuleID: "G101", File: "main.go", Line: 10, Message: "Hardcoded credentials", Level: "error"},
+		{RuleID: "G101", File: "main.go", Line: 10, Message: "Hardcoded credentials", Level: "warning"},
+		{RuleID: "G101", File: "main.go", Line: 11, Message: "Hardcoded credentials"},
+		{RuleID: "G104", File: "main.go", Line: 10, Message: "Errors unhandled"},
+		{RuleID: "G104", File: "main.go", Line: 10, Message: "Errors unhandled"},
+	}
+
+	unique, removed := Deduplicate(findings)
+	if removed != 2 {
+		t.Errorf("expected 2 duplicates removed, got %d", removed)
+	}
+	if len(unique) != 3 {
+		t.Fatalf("expected 3 unique findings, got %d", len(unique))
+	}
+	// First occurrence wins
+	if unique[0].Level != "error" {
+		t.Errorf("expected first occurrence to be kept, got level %q", unique[0].Level)
+	}
+}
+
+func TestFilterByLevel(t *testing.T) {
+	findings := []Finding{
+		{RuleID: "a", Level: "error"},
+		{RuleID: "b", Level: "warning"},
+		{RuleID: "c", Level: "note"},
+		{RuleID: "d", Level: ""}, // Unknown level counts as warning
+	}
+
+	tests := []struct {
+		minLevel string
+		want     int
+	}{
+		{"", 4},
+		{"note", 4},
+		{"warning", 3},
+		{"error", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.minLevel, func(t *testing.T) {
+			if got := FilterByLevel(findings, tt.minLevel); len(got) != tt.want {
+				t.Errorf("FilterByLevel(%q) returned %d findings, want %d", tt.minLevel, len(got), tt.want)
+			}
+		})
+	}
+
+	if IsValidLevel("critical") || !IsValidLevel("warning") {
+		t.Error("IsValidLevel() returned unexpected result")
+	}
+}
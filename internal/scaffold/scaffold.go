@@ -0,0 +1,243 @@
+// Package scaffold generates a starter config.toml from the project's detected ecosystems.
+package scaffold
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Phases in the order they appear in generated configs
+var phaseOrder = []string{"checks", "build", "test"}
+
+var phaseNames = map[string]string{
+	"checks": "Checks",
+	"build":  "Build",
+	"test":   "Test",
+}
+
+// Task is a detected task to include in the generated config
+type Task struct {
+	ID      string
+	Name    string
+	Type    string
+	Command string
+	Phase   string // "checks", "build" or "test"
+	Source  string // Ecosystem that produced the task (e.g. "go", "npm")
+}
+
+// detector inspects a project root and returns the tasks it recognises
+type detector struct {
+	name   string
+	marker string // File that identifies the ecosystem
+	tasks  func(root string) []Task
+}
+
+var detectors = []detector{
+	{name: "go", marker: "go.mod", tasks: goTasks},
+	{name: "npm", marker: "package.json", tasks: npmTasks},
+	{name: "cargo", marker: "Cargo.toml", tasks: cargoTasks},
+}
+
+// Detect returns tasks for every ecosystem found in root, in phase order.
+// Task IDs are prefixed with the ecosystem name when more than one is detected.
+func Detect(root string) []Task {
+	var found [][]Task
+	for _, d := range detectors {
+		if _, err := os.Stat(filepath.Join(root, d.marker)); err != nil {
+			continue
+		}
+		tasks := d.tasks(root)
+		for i := range tasks {
+			tasks[i].Source = d.name
+		}
+		if len(tasks) > 0 {
+			found = append(found, tasks)
+		}
+	}
+
+	var all []Task
+	for _, tasks := range found {
+		for _, t := range tasks {
+			if len(found) > 1 {
+				t.ID = t.Source + "-" + t.ID
+			}
+			all = append(all, t)
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		return phaseIndex(all[i].Phase) < phaseIndex(all[j].Phase)
+	})
+	return all
+}
+
+// FallbackTasks are placeholder tasks used when no ecosystem is detected
+func FallbackTasks() []Task {
+	return []Task{
+		{ID: "lint", Name: "Lint", Type: "check", Command: "echo 'Running linter...'", Phase: "checks"},
+		{ID: "format", Name: "Format Check", Type: "check", Command: "echo 'Checking code formatting...'", Phase: "checks"},
+		{ID: "build", Name: "Build", Type: "build", Command: "echo 'Building application...'", Phase: "build"},
+	}
+}
+
+func goTasks(_ string) []Task {
+	return []Task{
+		{ID: "fmt", Name: "Go Format Check", Type: "check", Command: `out=$(gofmt -l .); echo "${out:-gofmt: all files formatted}"; test -z "$out"`, Phase: "checks"},
+		{ID: "vet", Name: "Go Vet", Type: "check", Command: "go vet ./...", Phase: "checks"},
+		{ID: "build", Name: "Go Build", Type: "build", Command: "go build ./...", Phase: "build"},
+		{ID: "test", Name: "Go Test", Type: "test", Command: "go test ./...", Phase: "test"},
+	}
+}
+
+// npmScripts maps well-known package.json scripts to their phase and type
+var npmScripts = []struct {
+	script, phase, typ string
+}{
+	{"lint", "checks", "check"},
+	{"format:check", "checks", "check"},
+	{"typecheck", "checks", "check"},
+	{"build", "build", "build"},
+	{"test", "test", "test"},
+	{"test:e2e", "test", "test"},
+}
+
+func npmTasks(root string) []Task {
+	data, err := os.ReadFile(filepath.Join(root, "package.json"))
+	if err != nil {
+		return nil
+	}
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil
+	}
+
+	var tasks []Task
+	for _, s := range npmScripts {
+		if _, ok := pkg.Scripts[s.script]; !ok {
+			continue
+		}
+		tasks = append(tasks, Task{
+			ID:      strings.ReplaceAll(s.script, ":", "-"),
+			Name:    "npm " + s.script,
+			Type:    s.typ,
+			Command: "npm run " + s.script,
+			Phase:   s.phase,
+		})
+	}
+	return tasks
+}
+
+func cargoTasks(_ string) []Task {
+	return []Task{
+		{ID: "fmt", Name: "Cargo Format Check", Type: "check", Command: "cargo fmt --check", Phase: "checks"},
+		{ID: "clippy", Name: "Cargo Clippy", Type: "check", Command: "cargo clippy -- -D warnings", Phase: "checks"},
+		{ID: "build", Name: "Cargo Build", Type: "build", Command: "cargo build", Phase: "build"},
+		{ID: "test", Name: "Cargo Test", Type: "test", Command: "cargo test", Phase: "test"},
+	}
+}
+
+func phaseIndex(phase string) int {
+	for i, p := range phaseOrder {
+		if p == phase {
+			return i
+		}
+	}
+	return len(phaseOrder)
+}
+
+// Render produces config.toml content for the tasks, grouped under phase headers
+func Render(tasks []Task) string {
+	var sb strings.Builder
+	sb.WriteString("# devpipe configuration file\n")
+	sb.WriteString("# Full reference: https://github.com/drewkhoury/devpipe/blob/main/config.example.toml\n")
+
+	currentPhase := ""
+	for _, t := range tasks {
+		if t.Phase != currentPhase && t.Phase != "" {
+			currentPhase = t.Phase
+			name := phaseNames[t.Phase]
+			if name == "" {
+				name = t.Phase
+			}
+			fmt.Fprintf(&sb, "\n[tasks.phase-%s]\nname = %s\n", t.Phase, tomlString(name))
+		}
+		fmt.Fprintf(&sb, "\n[tasks.%s]\n", t.ID)
+		fmt.Fprintf(&sb, "name = %s\n", tomlString(t.Name))
+		fmt.Fprintf(&sb, "command = %s\n", tomlString(t.Command))
+		fmt.Fprintf(&sb, "type = %s\n", tomlString(t.Type))
+	}
+	return sb.String()
+}
+
+// Write renders the tasks to path, replacing any existing file
+func Write(path string, tasks []Task) error {
+	if err := os.WriteFile(path, []byte(Render(tasks)), 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// ParseSelection parses a task selection like "1,3-4" into zero-based indexes.
+// An empty input or "all" selects all n tasks.
+func ParseSelection(input string, n int) ([]int, error) {
+	input = strings.TrimSpace(strings.ToLower(input))
+	if input == "" || input == "all" {
+		all := make([]int, n)
+		for i := range all {
+			all[i] = i
+		}
+		return all, nil
+	}
+
+	seen := make(map[int]bool)
+	var selected []int
+	for _, part := range strings.Split(input, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		lo, hi := part, part
+		if before, after, ok := strings.Cut(part, "-"); ok {
+			lo, hi = before, after
+		}
+		start, err := strconv.Atoi(strings.TrimSpace(lo))
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection %q", part)
+		}
+		end, err := strconv.Atoi(strings.TrimSpace(hi))
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection %q", part)
+		}
+		if start < 1 || end > n || start > end {
+			return nil, fmt.Errorf("selection %q out of range 1-%d", part, n)
+		}
+
+		for i := start - 1; i < end; i++ {
+			if !seen[i] {
+				seen[i] = true
+				selected = append(selected, i)
+			}
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no tasks selected")
+	}
+	sort.Ints(selected)
+	return selected, nil
+}
+
+// tomlString quotes s as a TOML basic string
+func tomlString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,164 @@
+package scaffold_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/drew/devpipe/internal/config"
+	"github.com/drew/devpipe/internal/scaffold"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func taskIDs(tasks []scaffold.Task) string {
+	var ids []string
+	for _, t := range tasks {
+		ids = append(ids, t.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "nothing detected",
+			files: map[string]string{"README.md": "# hi"},
+			want:  "",
+		},
+		{
+			name:  "go module",
+			files: map[string]string{"go.mod": "module example.com/app\n"},
+			want:  "fmt,vet,build,test",
+		},
+		{
+			name:  "npm scripts",
+			files: map[string]string{"package.json": `{"scripts": {"test": "jest", "lint": "eslint .", "start": "node ."}}`},
+			want:  "lint,test",
+		},
+		{
+			name:  "cargo",
+			files: map[string]string{"Cargo.toml": "[package]\nname = \"app\"\n"},
+			want:  "fmt,clippy,build,test",
+		},
+		{
+			name: "go and npm are prefixed and ordered by phase",
+			files: map[string]string{
+				"go.mod":       "module example.com/app\n",
+				"package.json": `{"scripts": {"build": "vite build", "format:check": "prettier -c ."}}`,
+			},
+			want: "go-fmt,go-vet,npm-format-check,go-build,npm-build,go-test",
+		},
+		{
+			name:  "invalid package.json",
+			files: map[string]string{"package.json": "{not json"},
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				writeFile(t, dir, name, content)
+			}
+
+			if got := taskIDs(scaffold.Detect(dir)); got != tt.want {
+				t.Errorf("scaffold.Detect() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWritePassesValidation(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "go.mod", "module example.com/app\n")
+	writeFile(t, dir, "package.json", `{"scripts": {"lint": "eslint .", "test": "jest"}}`)
+
+	for name, tasks := range map[string][]scaffold.Task{"detected": scaffold.Detect(dir), "fallback": scaffold.FallbackTasks()} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := scaffold.Write(path, tasks); err != nil {
+				t.Fatalf("scaffold.Write() error = %v", err)
+			}
+
+			result, err := config.ValidateConfigFile(path)
+			if err != nil {
+				t.Fatalf("ValidateConfigFile() error = %v", err)
+			}
+			if !result.Valid || len(result.Warnings) > 0 {
+				t.Errorf("generated config is not clean: errors %v, warnings %v", result.Errors, result.Warnings)
+			}
+
+			cfg, order, phases, _, err := config.LoadConfig(path)
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			if len(cfg.Tasks) != len(tasks)+countPhases(tasks) || len(order) == 0 || len(phases) == 0 {
+				t.Errorf("unexpected config: %d tasks, order %v, phases %v", len(cfg.Tasks), order, phases)
+			}
+		})
+	}
+}
+
+func countPhases(tasks []scaffold.Task) int {
+	seen := make(map[string]bool)
+	for _, t := range tasks {
+		seen[t.Phase] = true
+	}
+	return len(seen)
+}
+
+func TestRenderEscapesStrings(t *testing.T) {
+	out := scaffold.Render([]scaffold.Task{{ID: "x", Name: `say "hi"`, Type: "check", Command: `echo C:\tmp`, Phase: "checks"}})
+	for _, want := range []string{`name = "say \"hi\""`, `command = "echo C:\\tmp"`, "[tasks.phase-checks]"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("scaffold.Render() missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestParseSelection(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"", "0,1,2,3", false},
+		{"all", "0,1,2,3", false},
+		{"1,3", "0,2", false},
+		{"2-4", "1,2,3", false},
+		{"4, 1-2, 2", "0,1,3", false},
+		{"5", "", true},
+		{"0", "", true},
+		{"3-1", "", true},
+		{"abc", "", true},
+		{",", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := scaffold.ParseSelection(tt.input, 4)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("scaffold.ParseSelection(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			var parts []string
+			for _, i := range got {
+				parts = append(parts, string(rune('0'+i)))
+			}
+			if strings.Join(parts, ",") != tt.want {
+				t.Errorf("scaffold.ParseSelection(%q) = %v, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// Package tasklog reads and writes per-task log files in devpipe's log formats.
+package tasklog
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Log formats for defaults.logFormat
+const (
+	FormatText  = "text"  // Raw command output
+	FormatJSONL = "jsonl" // One Record per output line
+)
+
+// Record is one line of task output in a JSONL log
+type Record struct {
+	TS     string `json:"ts"`
+	Task   string `json:"task"`
+	Stream string `json:"stream"` // "stdout" or "stderr"
+	Line   string `json:"line"`
+}
+
+// WriteRecord writes line as a single JSONL record
+func WriteRecord(w io.Writer, ts time.Time, task, stream, line string) error {
+	data, err := json.Marshal(Record{
+		TS:     ts.UTC().Format(time.RFC3339Nano),
+		Task:   task,
+		Stream: stream,
+		Line:   line,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// ReadRecords returns the lines of a task log as records. Text logs have no stream
+// or timestamp, so only Line is set; in JSONL logs, lines that are not records are
+// kept as plain lines.
+func ReadRecords(path, format string) ([]Record, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	content := strings.TrimRight(string(data), "\n")
+	if content == "" {
+		return []Record{}, nil
+	}
+
+	lines := strings.Split(content, "\n")
+	records := make([]Record, 0, len(lines))
+	for _, line := range lines {
+		var rec Record
+		if format != FormatJSONL || json.Unmarshal([]byte(line), &rec) != nil {
+			rec = Record{Line: line}
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// ReadLines returns the human-readable lines of a task log (the output line of
+// each record for JSONL logs)
+func ReadLines(path, format string) ([]string, error) {
+	records, err := ReadRecords(path, format)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]string, len(records))
+	for i, rec := range records {
+		lines[i] = rec.Line
+	}
+	return lines, nil
+}
@@ -0,0 +1,64 @@
+package tasklog
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWriteRecord(t *testing.T) {
+	var buf bytes.Buffer
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := WriteRecord(&buf, ts, "lint", "stderr", `say "hi"`); err != nil {
+		t.Fatalf("WriteRecord() error = %v", err)
+	}
+
+	var rec Record
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("record is not valid JSON: %v\n%s", err, buf.String())
+	}
+	want := Record{TS: "2025-01-02T03:04:05Z", Task: "lint", Stream: "stderr", Line: `say "hi"`}
+	if rec != want {
+		t.Errorf("record = %+v, want %+v", rec, want)
+	}
+}
+
+func TestReadLines(t *testing.T) {
+	dir := t.TempDir()
+	jsonlPath := filepath.Join(dir, "jsonl.log")
+
+	var buf bytes.Buffer
+	now := time.Now()
+	_ = WriteRecord(&buf, now, "test", "stdout", "ok  pkg/a")
+	_ = WriteRecord(&buf, now, "test", "stderr", "FAIL pkg/b")
+	buf.WriteString("not a record\n")
+	if err := os.WriteFile(jsonlPath, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+
+	got, err := ReadLines(jsonlPath, FormatJSONL)
+	if err != nil {
+		t.Fatalf("ReadLines() error = %v", err)
+	}
+	want := []string{"ok  pkg/a", "FAIL pkg/b", "not a record"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadLines(jsonl) = %q, want %q", got, want)
+	}
+
+	// Text logs are returned line by line, unchanged
+	textPath := filepath.Join(dir, "text.log")
+	if err := os.WriteFile(textPath, []byte("one\ntwo\n"), 0o644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	got, err = ReadLines(textPath, FormatText)
+	if err != nil {
+		t.Fatalf("ReadLines() error = %v", err)
+	}
+	if want := []string{"one", "two"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadLines(text) = %q, want %q", got, want)
+	}
+}
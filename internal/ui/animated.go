@@ -3,11 +3,25 @@ package ui
 
 import (
 	"fmt"
+	"os"
 	"strings"
 	"sync"
 	"time"
 )
 
+// spinnerFrames are the frames drawn next to running tasks for each defaults.animationStyle.
+// plain is a single ASCII frame for terminals with poor glyph support
+var spinnerFrames = map[string][]string{
+	"dots":  {"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"},
+	"line":  {"-", "\\", "|", "/"},
+	"bar":   {"▁", "▃", "▄", "▅", "▆", "▇", "█", "▇", "▆", "▅", "▄", "▃"},
+	"plain": {"*"},
+}
+
+// minRemoteRefreshMs is the fastest refresh used over SSH, where redrawing the whole view
+// more often than this flickers
+const minRemoteRefreshMs = 200
+
 // AnimatedTaskTracker tracks live progress of tasks
 type AnimatedTaskTracker struct {
 	tasks        []TaskProgress
@@ -23,8 +37,14 @@ type AnimatedTaskTracker struct {
 	termHeight   int
 	animLines    int
 	refreshMs    int
-	groupBy      string // "type" or "phase"
-	maxIDWidth   int    // Calculated once at init for consistent alignment
+	groupBy      string   // "type" or "phase"
+	maxIDWidth   int      // Calculated once at init for consistent alignment
+	collapse     bool     // Show passed, skipped and pending tasks as counts (defaults.collapseCompleted)
+	frames       []string // Spinner frames for running tasks (defaults.animationStyle)
+	frame        int      // Renders so far, to pick the spinner frame
+	final        bool     // Last render: always shows every task
+	drawnLines   int      // Lines drawn by the previous render, to move back over
+	loopDone     chan struct{}
 }
 
 // NewAnimatedTaskTracker creates a new animated task tracker
@@ -63,6 +83,9 @@ func NewAnimatedTaskTracker(renderer *Renderer, tasks []TaskProgress, headerLine
 	if refreshMs < 20 || refreshMs > 2000 {
 		refreshMs = 500 // Default to 500ms if invalid
 	}
+	if isRemoteSession() && refreshMs < minRemoteRefreshMs {
+		refreshMs = minRemoteRefreshMs
+	}
 
 	// Validate groupBy
 	if groupBy != "type" && groupBy != "phase" {
@@ -97,9 +120,40 @@ func NewAnimatedTaskTracker(renderer *Renderer, tasks []TaskProgress, headerLine
 		refreshMs:    refreshMs,
 		groupBy:      groupBy,
 		maxIDWidth:   maxIDWidth,
+		frames:       spinnerFrames["dots"],
+		loopDone:     make(chan struct{}),
 	}
 }
 
+// SetCollapseCompleted makes the live view show passed, skipped and pending tasks as count
+// lines, so running and failed tasks stay visible in pipelines taller than the terminal.
+// The final render still lists every task
+func (a *AnimatedTaskTracker) SetCollapseCompleted(collapse bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.collapse = collapse
+}
+
+// SetStyle picks the spinner drawn next to running tasks: dots, line, bar or plain.
+// Unknown styles keep the default, dots
+func (a *AnimatedTaskTracker) SetStyle(style string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if frames, ok := spinnerFrames[style]; ok {
+		a.frames = frames
+	}
+}
+
+// isRemoteSession reports whether devpipe runs in an SSH session
+func isRemoteSession() bool {
+	return os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != ""
+}
+
+// runningSymbol returns the current spinner frame
+func (a *AnimatedTaskTracker) runningSymbol() string {
+	return a.renderer.colors.Blue(a.frames[a.frame%len(a.frames)])
+}
+
 // Start begins the animation loop
 func (a *AnimatedTaskTracker) Start() error {
 	// Test if terminal supports animation
@@ -122,6 +176,7 @@ func (a *AnimatedTaskTracker) Stop() {
 	defer fmt.Print("\033[?25h") // Show cursor again
 
 	close(a.done)
+	<-a.loopDone // Wait for the final render
 
 	fmt.Println() // Add newline after animation
 }
@@ -181,6 +236,8 @@ func (a *AnimatedTaskTracker) AddVerboseLine(line string) {
 
 // animationLoop continuously updates the display
 func (a *AnimatedTaskTracker) animationLoop() {
+	defer close(a.loopDone)
+
 	// Do initial render immediately
 	a.render()
 
@@ -195,6 +252,13 @@ func (a *AnimatedTaskTracker) animationLoop() {
 	for {
 		select {
 		case <-a.done:
+			// Expand collapsed tasks so the end state is complete
+			if a.collapse {
+				a.mu.Lock()
+				a.final = true
+				a.mu.Unlock()
+				a.render()
+			}
 			return
 		case <-ticker.C:
 			a.render()
@@ -204,8 +268,8 @@ func (a *AnimatedTaskTracker) animationLoop() {
 
 // render draws the current state
 func (a *AnimatedTaskTracker) render() {
-	a.mu.RLock()
-	defer a.mu.RUnlock()
+	a.mu.Lock()
+	defer a.mu.Unlock()
 
 	if a.firstRender {
 		// First render: hide cursor and print normally
@@ -220,7 +284,7 @@ func (a *AnimatedTaskTracker) render() {
 		// Subsequent renders: move to top, clear animation area, redraw
 
 		// Move cursor to start of animation area and clear all lines
-		linesToMove := a.calculateLines()
+		linesToMove := a.drawnLines
 
 		// Move cursor up to start of animation area
 		if linesToMove > 0 {
@@ -238,6 +302,8 @@ func (a *AnimatedTaskTracker) render() {
 			a.renderBasicMode()
 		}
 	}
+	a.drawnLines = a.calculateLines()
+	a.frame++
 }
 
 // calculateLines calculates how many lines we need to clear
@@ -265,7 +331,11 @@ func (a *AnimatedTaskTracker) calculateLines() int {
 		}
 
 		for _, taskList := range groups {
-			lines += 2 + len(taskList) + 1 // Header + tasks + footer + blank
+			rows, collapsed := a.visibleTasks(taskList)
+			lines += 2 + len(rows) + 1 // Header + tasks + footer + blank
+			if collapsed != "" {
+				lines++
+			}
 		}
 
 		// Add FIXED log box lines (always reserve maxLogLines)
@@ -274,7 +344,59 @@ func (a *AnimatedTaskTracker) calculateLines() int {
 		return lines
 	}
 	// Basic mode: progress bar + blank + tasks + blank + log header + FIXED log lines
-	return 2 + len(a.tasks) + 1 + 1 + a.maxLogLines
+	rows, collapsed := a.visibleTasks(a.tasks)
+	lines := 2 + len(rows) + 1 + 1 + a.maxLogLines
+	if collapsed != "" {
+		lines++
+	}
+	return lines
+}
+
+// visibleTasks returns the tasks to draw as rows and, when collapsing, a count line for the rest
+func (a *AnimatedTaskTracker) visibleTasks(tasks []TaskProgress) ([]TaskProgress, string) {
+	if !a.collapse || a.final {
+		return tasks, ""
+	}
+	return collapseTasks(tasks)
+}
+
+// collapseTasks keeps failed tasks, pinned first, and running ones as rows, and summarizes
+// passed, skipped and pending tasks as counts (e.g. "12 passed, 3 pending")
+func collapseTasks(tasks []TaskProgress) ([]TaskProgress, string) {
+	var failed, active []TaskProgress
+	counts := make(map[string]int)
+	for _, task := range tasks {
+		switch task.Status {
+		case "PASS", "SKIPPED", "PENDING":
+			counts[task.Status]++
+		case "FAIL", "WARN", "FIX FAILED", "STILL FAILING":
+			failed = append(failed, task)
+		default:
+			active = append(active, task)
+		}
+	}
+
+	var parts []string
+	for _, c := range []struct{ status, label string }{{"PASS", "passed"}, {"SKIPPED", "skipped"}, {"PENDING", "pending"}} {
+		if counts[c.status] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[c.status], c.label))
+		}
+	}
+	return append(failed, active...), strings.Join(parts, ", ")
+}
+
+// timeLeft describes the pipeline's estimated remaining time for the progress line, e.g.
+// "  ~1m 20s left" ("?" marks default guesses), or "" when there is nothing left to estimate
+func (a *AnimatedTaskTracker) timeLeft() string {
+	seconds, guess := EstimateRemainingSeconds(a.tasks)
+	if seconds < 1 {
+		return ""
+	}
+	left := "~" + FormatDuration(int64(seconds*1000))
+	if guess {
+		left += "?"
+	}
+	return "  " + a.renderer.colors.Gray(left+" left")
 }
 
 // renderBasicMode renders the basic animated mode
@@ -290,17 +412,24 @@ func (a *AnimatedTaskTracker) renderBasicMode() {
 
 	completed := 0
 	for _, task := range a.tasks {
-		if task.Status == "PASS" || task.Status == "FAIL" || task.Status == "SKIPPED" {
+		if task.Status == "PASS" || task.Status == "FAIL" || task.Status == "WARN" || task.Status == "SKIPPED" {
 			completed++
 		}
 	}
 
 	bar := a.renderer.colors.ProgressBar(int(overallProgress), 100, barWidth)
-	fmt.Printf("%s (%d/%d tasks)\n\n", bar, completed, len(a.tasks))
+	fmt.Printf("%s (%d/%d tasks)%s\n\n", bar, completed, len(a.tasks), a.timeLeft())
 
 	// Render task list
-	for _, task := range a.tasks {
+	rows, collapsed := a.visibleTasks(a.tasks)
+	if collapsed != "" {
+		fmt.Printf("%s %s\n", a.renderer.colors.Gray("…"), a.renderer.colors.Gray(collapsed))
+	}
+	for _, task := range rows {
 		symbol := a.renderer.colors.StatusSymbol(task.Status)
+		if task.Status == "RUNNING" {
+			symbol = a.runningSymbol()
+		}
 
 		// Truncate task ID if needed (max 45 chars)
 		taskID := task.ID
@@ -309,7 +438,7 @@ func (a *AnimatedTaskTracker) renderBasicMode() {
 		}
 
 		switch task.Status {
-		case "PASS", "FAIL", "SKIPPED":
+		case "PASS", "FAIL", "WARN", "SKIPPED":
 			statusText := a.renderer.colors.StatusColor(task.Status, task.Status)
 			fmt.Printf("%s %-*s %s\n", symbol, a.maxIDWidth, taskID, statusText)
 		case "RUNNING":
@@ -349,7 +478,7 @@ func (a *AnimatedTaskTracker) renderFullMode() {
 	}
 
 	bar := a.renderer.colors.ProgressBar(int(overallProgress), 100, barWidth)
-	fmt.Printf("Overall: %s\n\n", bar)
+	fmt.Printf("Overall: %s%s\n\n", bar, a.timeLeft())
 
 	// Group tasks by type or phase
 	groups := make(map[string][]TaskProgress)
@@ -388,8 +517,15 @@ func (a *AnimatedTaskTracker) renderFullMode() {
 		fmt.Printf("┌%s%s┐\n", headerText, padding)
 
 		// Tasks in group
-		for _, task := range taskList {
+		rows, collapsed := a.visibleTasks(taskList)
+		if collapsed != "" {
+			fmt.Printf("│ %s %s\n", a.renderer.colors.Gray("…"), a.renderer.colors.Gray(collapsed))
+		}
+		for _, task := range rows {
 			symbol := a.renderer.colors.StatusSymbol(task.Status)
+			if task.Status == "RUNNING" {
+				symbol = a.runningSymbol()
+			}
 
 			// Truncate task ID if needed (max 45 chars)
 			taskID := task.ID
@@ -408,6 +544,10 @@ func (a *AnimatedTaskTracker) renderFullMode() {
 				duration := FormatDuration(int64(task.ElapsedSeconds * 1000))
 				content = fmt.Sprintf("%s %-*s %s", symbol, a.maxIDWidth, taskID,
 					a.renderer.colors.Red(duration))
+			case "WARN":
+				duration := FormatDuration(int64(task.ElapsedSeconds * 1000))
+				content = fmt.Sprintf("%s %-*s %s", symbol, a.maxIDWidth, taskID,
+					a.renderer.colors.Yellow(duration))
 			case "SKIPPED":
 				content = fmt.Sprintf("%s %-*s %s", symbol, a.maxIDWidth, taskID,
 					a.renderer.colors.Yellow("skipped"))
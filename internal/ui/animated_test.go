@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -513,3 +514,92 @@ func TestAnimatedTrackerStartError(t *testing.T) {
 	// Stop the tracker
 	tracker.Stop()
 }
+
+func TestCollapseTasks(t *testing.T) {
+	tasks := []TaskProgress{
+		{ID: "lint", Status: "PASS"},
+		{ID: "build", Status: "RUNNING"},
+		{ID: "vet", Status: "SKIPPED"},
+		{ID: "unit", Status: "FAIL"},
+		{ID: "e2e", Status: "PENDING"},
+		{ID: "fmt", Status: "PASS"},
+	}
+
+	rows, collapsed := collapseTasks(tasks)
+	var ids []string
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+	}
+	if got := strings.Join(ids, ","); got != "unit,build" {
+		t.Errorf("rows = %s, want failed tasks pinned before running ones", got)
+	}
+	if collapsed != "2 passed, 1 skipped, 1 pending" {
+		t.Errorf("collapsed = %q", collapsed)
+	}
+}
+
+func TestCollapseCompletedLines(t *testing.T) {
+	renderer := NewRenderer(UIModeBasic, false, true)
+	tasks := []TaskProgress{
+		{ID: "lint", Status: "PASS"},
+		{ID: "vet", Status: "PASS"},
+		{ID: "unit", Status: "RUNNING"},
+	}
+	tracker := NewAnimatedTaskTracker(renderer, tasks, 4, 500, "phase")
+	expanded := tracker.calculateLines()
+
+	tracker.SetCollapseCompleted(true)
+	if got := tracker.calculateLines(); got != expanded-1 {
+		t.Errorf("collapsed lines = %d, want %d (two passed rows replaced by one count line)", got, expanded-1)
+	}
+
+	// The final render lists every task again
+	tracker.final = true
+	if got := tracker.calculateLines(); got != expanded {
+		t.Errorf("final lines = %d, want %d", got, expanded)
+	}
+}
+
+func TestAnimatedTrackerStyle(t *testing.T) {
+	renderer := NewRenderer(UIModeBasic, false, false)
+	tracker := NewAnimatedTaskTracker(renderer, []TaskProgress{{ID: "task1", Status: "RUNNING"}}, 3, 100, "type")
+
+	tracker.SetStyle("line")
+	var got []string
+	for i := 0; i < 5; i++ {
+		got = append(got, tracker.runningSymbol())
+		tracker.frame++
+	}
+	if want := []string{"-", "\\", "|", "/", "-"}; strings.Join(got, "") != strings.Join(want, "") {
+		t.Errorf("line frames = %q, want %q", got, want)
+	}
+
+	tracker.SetStyle("plain")
+	if got := tracker.runningSymbol(); got != "*" {
+		t.Errorf("plain frame = %q, want *", got)
+	}
+
+	tracker.SetStyle("unknown")
+	if got := tracker.runningSymbol(); got != "*" {
+		t.Errorf("unknown style changed the frame to %q", got)
+	}
+}
+
+func TestAnimatedTrackerRemoteRefresh(t *testing.T) {
+	renderer := NewRenderer(UIModeBasic, false, false)
+	tasks := []TaskProgress{{ID: "task1", Status: "PENDING"}}
+
+	t.Setenv("SSH_CONNECTION", "")
+	t.Setenv("SSH_TTY", "")
+	if tracker := NewAnimatedTaskTracker(renderer, tasks, 3, 50, "type"); tracker.refreshMs != 50 {
+		t.Errorf("local refreshMs = %d, want 50", tracker.refreshMs)
+	}
+
+	t.Setenv("SSH_CONNECTION", "10.0.0.1 52000 10.0.0.2 22")
+	if tracker := NewAnimatedTaskTracker(renderer, tasks, 3, 50, "type"); tracker.refreshMs != minRemoteRefreshMs {
+		t.Errorf("SSH refreshMs = %d, want %d", tracker.refreshMs, minRemoteRefreshMs)
+	}
+	if tracker := NewAnimatedTaskTracker(renderer, tasks, 3, 1000, "type"); tracker.refreshMs != 1000 {
+		t.Errorf("SSH refreshMs = %d, want 1000 (slower rates are kept)", tracker.refreshMs)
+	}
+}
@@ -20,11 +20,12 @@ type ColorFunc func(string) string
 // Colors holds all color functions
 type Colors struct {
 	enabled bool
+	symbols Symbols // Status markers used by StatusSymbol
 }
 
 // NewColors creates a new Colors instance
 func NewColors(enabled bool) *Colors {
-	return &Colors{enabled: enabled}
+	return &Colors{enabled: enabled, symbols: EmojiSymbols}
 }
 
 // Red returns red colored text
@@ -90,6 +91,8 @@ func (c *Colors) StatusColor(status string, text string) string {
 		return c.Green(text)
 	case "FAIL":
 		return c.Red(text)
+	case "WARN":
+		return c.Yellow(text)
 	case "SKIPPED":
 		return c.Yellow(text)
 	case "RUNNING":
@@ -103,17 +106,18 @@ func (c *Colors) StatusColor(status string, text string) string {
 
 // StatusSymbol returns a colored symbol for the status
 func (c *Colors) StatusSymbol(status string) string {
+	symbol := c.symbols.For(status)
 	switch status {
 	case "PASS":
-		return c.Green("✓")
+		return c.Green(symbol)
 	case "FAIL":
-		return c.Red("✗")
-	case "SKIPPED":
-		return c.Yellow("⊘")
+		return c.Red(symbol)
+	case "WARN", "SKIPPED":
+		return c.Yellow(symbol)
 	case "RUNNING":
-		return c.Blue("⚙")
+		return c.Blue(symbol)
 	case "PENDING":
-		return c.Gray("⋯")
+		return c.Gray(symbol)
 	default:
 		return " "
 	}
@@ -11,6 +11,7 @@ type TaskProgress struct {
 	Type             string // Type of task (quality, correctness, release)
 	Phase            int    // Phase number (1, 2, 3, etc.)
 	PhaseName        string // Display name for the phase
+	PhaseMaxParallel int    // Tasks of the phase that run at once (0 = all of them)
 	Status           string
 	EstimatedSeconds int
 	IsEstimateGuess  bool // True if estimate is a default guess
@@ -43,7 +44,7 @@ func CalculateOverallProgress(tasks []TaskProgress) float64 {
 
 	for _, task := range tasks {
 		switch task.Status {
-		case "PASS", "FAIL", "SKIPPED":
+		case "PASS", "FAIL", "WARN", "SKIPPED":
 			// Task complete - counts as 1.0
 			completed++
 		case "RUNNING":
@@ -65,6 +66,54 @@ func CalculateOverallProgress(tasks []TaskProgress) float64 {
 	return totalProgress
 }
 
+// EstimateRemainingSeconds estimates how long the rest of the pipeline takes. Phases run one
+// after another; within a phase, tasks take PhaseMaxParallel slots in order, so the phase
+// lasts as long as its busiest slot. Running tasks count for what is left of their estimate,
+// finished ones for nothing. guess reports whether a task still to finish only has a default
+// estimate
+func EstimateRemainingSeconds(tasks []TaskProgress) (seconds float64, guess bool) {
+	for start := 0; start < len(tasks); {
+		end := start + 1
+		for end < len(tasks) && tasks[end].Phase == tasks[start].Phase {
+			end++
+		}
+		phase := tasks[start:end]
+		start = end
+
+		slots := len(phase)
+		if limit := phase[0].PhaseMaxParallel; limit > 0 && limit < slots {
+			slots = limit
+		}
+		busy := make([]float64, slots)
+		// Running tasks hold their slots; pending ones queue for the first free slot
+		for _, status := range []string{"RUNNING", "PENDING"} {
+			for _, task := range phase {
+				if task.Status != status {
+					continue
+				}
+				remaining := float64(task.EstimatedSeconds)
+				if status == "RUNNING" {
+					remaining = max(remaining-task.ElapsedSeconds, 0)
+				}
+				guess = guess || task.IsEstimateGuess
+				free := 0
+				for i := range busy {
+					if busy[i] < busy[free] {
+						free = i
+					}
+				}
+				busy[free] += remaining
+			}
+		}
+		longest := 0.0
+		for _, b := range busy {
+			longest = max(longest, b)
+		}
+		seconds += longest
+	}
+	return seconds, guess
+}
+
 // FormatDuration formats a duration in milliseconds to a human-readable string
 func FormatDuration(ms int64) string {
 	if ms < 1000 {
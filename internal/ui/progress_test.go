@@ -311,3 +311,59 @@ func TestFormatDurationEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestEstimateRemainingSeconds(t *testing.T) {
+	tests := []struct {
+		name      string
+		tasks     []TaskProgress
+		want      float64
+		wantGuess bool
+	}{
+		{
+			name: "phases add up, tasks in a phase overlap",
+			tasks: []TaskProgress{
+				{Phase: 1, Status: "PENDING", EstimatedSeconds: 10},
+				{Phase: 1, Status: "PENDING", EstimatedSeconds: 30},
+				{Phase: 2, Status: "PENDING", EstimatedSeconds: 20},
+			},
+			want: 50,
+		},
+		{
+			name: "parallel limit queues tasks",
+			tasks: []TaskProgress{
+				{Phase: 1, PhaseMaxParallel: 2, Status: "PENDING", EstimatedSeconds: 30},
+				{Phase: 1, PhaseMaxParallel: 2, Status: "PENDING", EstimatedSeconds: 10},
+				{Phase: 1, PhaseMaxParallel: 2, Status: "PENDING", EstimatedSeconds: 10},
+				{Phase: 1, PhaseMaxParallel: 2, Status: "PENDING", EstimatedSeconds: 15},
+			},
+			want: 35, // 30 | 10+10+15
+		},
+		{
+			name: "running and finished tasks",
+			tasks: []TaskProgress{
+				{Phase: 1, Status: "PASS", EstimatedSeconds: 60},
+				{Phase: 1, Status: "RUNNING", EstimatedSeconds: 40, ElapsedSeconds: 25},
+				{Phase: 1, Status: "RUNNING", EstimatedSeconds: 5, ElapsedSeconds: 9},
+				{Phase: 2, Status: "PENDING", EstimatedSeconds: 8, IsEstimateGuess: true},
+			},
+			want:      23,
+			wantGuess: true,
+		},
+		{
+			name: "all done",
+			tasks: []TaskProgress{
+				{Phase: 1, Status: "PASS", EstimatedSeconds: 10, IsEstimateGuess: true},
+				{Phase: 2, Status: "SKIPPED", EstimatedSeconds: 10},
+			},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, guess := EstimateRemainingSeconds(tt.tasks)
+			if got != tt.want || guess != tt.wantGuess {
+				t.Errorf("EstimateRemainingSeconds() = %v, %v, want %v, %v", got, guess, tt.want, tt.wantGuess)
+			}
+		})
+	}
+}
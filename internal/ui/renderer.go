@@ -15,13 +15,33 @@ const (
 	UIModeFull  UIMode = "full"
 )
 
+// Verbosity controls how much per-task output the renderer prints
+type Verbosity int
+
+// Verbosity levels
+const (
+	VerbosityNormal Verbosity = iota // Header, RUN/PASS/SKIP lines and task output
+	VerbosityQuiet                   // Only failing tasks and the final summary
+)
+
+// DefaultLinePrefix is the line prefix template used when defaults.logPrefix is not set
+const DefaultLinePrefix = "[{id}]"
+
+// defaultPrefixWidth is the id column width before SetLinePrefix is called
+const defaultPrefixWidth = 15
+
 // Renderer handles UI rendering
 type Renderer struct {
 	mode        UIMode
+	verbosity   Verbosity
+	linePrefix  string // Template for per-line task prefixes; "{id}" is replaced by the task id
+	prefixWidth int    // Width the id is padded to so prefixes line up
 	colors      *Colors
 	width       int
 	isTTY       bool
 	animated    bool
+	noEmoji     bool                 // ASCII status markers and no decorative emoji (--no-emoji)
+	phases      []SummaryPhase       // Phases the summary is grouped by (flat with fewer than two)
 	tracker     *AnimatedTaskTracker // Reference to tracker for verbose output
 	pipelineLog *os.File             // Log file for verbose output
 }
@@ -36,8 +56,8 @@ func NewRenderer(mode UIMode, enableColors bool, animated bool) *Renderer {
 		mode = UIModeBasic
 	}
 
-	// Disable colors if not a TTY or explicitly disabled
-	if !isTTY {
+	// Disable colors if not a TTY, unless CLICOLOR_FORCE asks for them
+	if !isTTY && !IsColorEnabled() {
 		enableColors = false
 	}
 
@@ -47,14 +67,53 @@ func NewRenderer(mode UIMode, enableColors bool, animated bool) *Renderer {
 	}
 
 	return &Renderer{
-		mode:     mode,
-		colors:   NewColors(enableColors),
-		width:    width,
-		isTTY:    isTTY,
-		animated: animated,
+		mode:        mode,
+		linePrefix:  DefaultLinePrefix,
+		prefixWidth: defaultPrefixWidth,
+		colors:      NewColors(enableColors),
+		width:       width,
+		isTTY:       isTTY,
+		animated:    animated,
+	}
+}
+
+// SetLinePrefix sets the template used to prefix task output and status lines.
+// "{id}" is replaced by the task id padded to the longest of ids, so columns stay
+// aligned for the whole run. An empty template disables prefixes.
+func (r *Renderer) SetLinePrefix(template string, ids []string) {
+	r.linePrefix = template
+	r.prefixWidth = 0
+	for _, id := range ids {
+		if len(id) > r.prefixWidth {
+			r.prefixWidth = len(id)
+		}
 	}
 }
 
+// Prefix returns the line prefix for a task id, including the trailing space ("" when disabled)
+func (r *Renderer) Prefix(id string) string {
+	return r.styledPrefix(id, func(s string) string { return s })
+}
+
+// styledPrefix formats the prefix, applying style to the padded id so colors don't break alignment
+func (r *Renderer) styledPrefix(id string, style func(string) string) string {
+	if r.linePrefix == "" {
+		return ""
+	}
+	padded := style(fmt.Sprintf("%-*s", r.prefixWidth, id))
+	return strings.ReplaceAll(r.linePrefix, "{id}", padded) + " "
+}
+
+// SetVerbosity sets the renderer verbosity level
+func (r *Renderer) SetVerbosity(v Verbosity) {
+	r.verbosity = v
+}
+
+// IsQuiet returns true if only failures and the summary should be printed
+func (r *Renderer) IsQuiet() bool {
+	return r.verbosity == VerbosityQuiet
+}
+
 // IsAnimated returns true if animated mode is enabled
 func (r *Renderer) IsAnimated() bool {
 	return r.animated
@@ -62,6 +121,10 @@ func (r *Renderer) IsAnimated() bool {
 
 // RenderHeader renders the pipeline header
 func (r *Renderer) RenderHeader(runID, projectRoot string, gitMode string, changedFiles int) {
+	if r.IsQuiet() {
+		return
+	}
+
 	switch r.mode {
 	case UIModeFull:
 		r.renderFullHeader(runID, projectRoot, gitMode, changedFiles)
@@ -108,15 +171,14 @@ func truncateTaskID(id string, maxLen int) string {
 // RenderTaskStart renders when a task starts
 func (r *Renderer) RenderTaskStart(id, command string, verbose bool) {
 	// In animated mode, don't print anything yet
-	if r.animated {
+	if r.animated || r.IsQuiet() {
 		return
 	}
 
-	taskID := truncateTaskID(id, 15)
 	if verbose {
-		fmt.Printf("[%-15s] %s    %s\n", taskID, r.colors.Blue("RUN"), command)
+		fmt.Printf("%s%s    %s\n", r.Prefix(id), r.colors.Blue("RUN"), command)
 	} else {
-		fmt.Printf("[%-15s] %s\n", taskID, r.colors.Blue("RUN"))
+		fmt.Printf("%s%s\n", r.Prefix(id), r.colors.Blue("RUN"))
 	}
 }
 
@@ -126,15 +188,18 @@ func (r *Renderer) RenderTaskComplete(id, status string, exitCode *int, duration
 	if r.animated {
 		return
 	}
+	// Quiet mode only reports failures (advisory ones included)
+	if r.IsQuiet() && status != "FAIL" && status != "WARN" {
+		return
+	}
 
-	taskID := truncateTaskID(id, 15)
 	symbol := r.colors.StatusSymbol(status)
 	statusText := r.colors.StatusColor(status, status)
 
 	if verbose && exitCode != nil {
-		fmt.Printf("[%-15s] %s %s (exit %d, %dms)\n", taskID, symbol, statusText, *exitCode, durationMs)
+		fmt.Printf("%s%s %s (exit %d, %dms)\n", r.Prefix(id), symbol, statusText, *exitCode, durationMs)
 	} else {
-		fmt.Printf("[%-15s] %s %s (%dms)\n", taskID, symbol, statusText, durationMs)
+		fmt.Printf("%s%s %s (%dms)\n", r.Prefix(id), symbol, statusText, durationMs)
 	}
 	fmt.Println() // Blank line after task
 }
@@ -142,20 +207,35 @@ func (r *Renderer) RenderTaskComplete(id, status string, exitCode *int, duration
 // RenderTaskSkipped renders when a task is skipped
 func (r *Renderer) RenderTaskSkipped(id, reason string, verbose bool) {
 	// In animated mode, don't print anything (animation handles it)
-	if r.animated {
+	if r.animated || r.IsQuiet() {
 		return
 	}
 
-	taskID := truncateTaskID(id, 15)
 	symbol := r.colors.StatusSymbol("SKIPPED")
 	if verbose {
-		fmt.Printf("[%-15s] %s %s (%s)\n", taskID, symbol, r.colors.Yellow("SKIPPED"), reason)
+		fmt.Printf("%s%s %s (%s)\n", r.Prefix(id), symbol, r.colors.Yellow("SKIPPED"), reason)
 	} else {
-		fmt.Printf("[%-15s] %s %s\n", taskID, symbol, r.colors.Yellow("SKIPPED"))
+		fmt.Printf("%s%s %s\n", r.Prefix(id), symbol, r.colors.Yellow("SKIPPED"))
 	}
 	fmt.Println() // Blank line after skipped task
 }
 
+// RenderTaskCached renders a task whose result was reused because its inputs are unchanged
+func (r *Renderer) RenderTaskCached(id, runID string, verbose bool) {
+	// In animated mode, don't print anything (animation handles it)
+	if r.animated || r.IsQuiet() {
+		return
+	}
+
+	symbol := r.colors.StatusSymbol("PASS")
+	if verbose {
+		fmt.Printf("%s%s %s (inputs unchanged since %s)\n", r.Prefix(id), symbol, r.colors.Green("CACHED"), runID)
+	} else {
+		fmt.Printf("%s%s %s\n", r.Prefix(id), symbol, r.colors.Green("CACHED"))
+	}
+	fmt.Println() // Blank line after cached task
+}
+
 // RenderSummary renders the final summary
 func (r *Renderer) RenderSummary(results []TaskSummary, anyFailed bool, totalMs int64) {
 	// Add blank line before summary if animated (animation already on screen)
@@ -177,41 +257,183 @@ func (r *Renderer) RenderSummary(results []TaskSummary, anyFailed bool, totalMs
 
 	fmt.Println(r.colors.Bold("Summary:"))
 
+	warned := 0
+	skipped, savedSeconds := 0, 0
 	for _, result := range results {
-		symbol := r.colors.StatusSymbol(result.Status)
-		statusText := r.colors.StatusColor(result.Status, fmt.Sprintf("%-10s", result.Status))
-		seconds := float64(result.DurationMs) / 1000.0
-		durationText := fmt.Sprintf("%.2fs (%dms)", seconds, result.DurationMs)
-
-		annotation := ""
-		if result.AutoFixed {
-			annotation = " " + r.colors.Gray("[auto-fixed]")
+		switch result.Status {
+		case "WARN":
+			warned++
+		case "SKIPPED":
+			skipped++
+			savedSeconds += result.EstimatedSeconds
 		}
+	}
 
-		taskID := truncateTaskID(result.ID, 45)
-		fmt.Printf("  %s %-*s %s %s%s\n", symbol, maxIDWidth, taskID, statusText, durationText, annotation)
+	if groups := r.groupByPhase(results); groups != nil {
+		for _, group := range groups {
+			fmt.Println()
+			fmt.Println(r.phaseHeader(group))
+			for _, result := range group.results {
+				r.renderSummaryRow(result, maxIDWidth)
+			}
+		}
+	} else {
+		for _, result := range results {
+			r.renderSummaryRow(result, maxIDWidth)
+		}
 	}
 
 	// Show total pipeline duration
 	fmt.Println()
 	totalSeconds := float64(totalMs) / 1000.0
 	fmt.Printf("Total: %.2fs (%dms)\n", totalSeconds, totalMs)
+	if skipped > 0 {
+		fmt.Println(r.colors.Gray(fmt.Sprintf("Skipped %d task(s) (~%ds estimated time saved)", skipped, savedSeconds)))
+	}
 
 	fmt.Println()
 	if anyFailed {
 		fmt.Println(r.colors.Red("devpipe: one or more tasks failed"))
+	} else if warned > 0 {
+		fmt.Println(r.colors.Yellow(fmt.Sprintf("devpipe: all required tasks passed (%d advisory task(s) failed, see WARN)", warned)))
 	} else {
 		fmt.Println(r.colors.Green("devpipe: all tasks passed or were skipped"))
 	}
 	fmt.Println() // Blank line at very end
 }
 
+// FailureTail is the end of a failed task's log
+type FailureTail struct {
+	ID      string
+	LogPath string
+	Lines   []string
+}
+
+// RenderFailureTails prints the end of each failed task's log after the summary, grouped by
+// task, so the error is at the bottom of the output rather than scrolled away
+func (r *Renderer) RenderFailureTails(tails []FailureTail) {
+	for _, tail := range tails {
+		header := fmt.Sprintf("%s %s: last %d line(s)", r.Symbol("FAIL"), tail.ID, len(tail.Lines))
+		fmt.Println(r.colors.Red(r.colors.Bold(header)))
+		for _, line := range tail.Lines {
+			fmt.Printf("  %s\n", line)
+		}
+		fmt.Println(r.colors.Gray("  Full log: " + tail.LogPath))
+		fmt.Println()
+	}
+}
+
+// SummaryPhase is a phase of the pipeline and its task ids, in order
+type SummaryPhase struct {
+	Name    string
+	TaskIDs []string
+}
+
+// SetSummaryPhases groups the final summary by phase, each under a header with its task count
+// and time. With fewer than two phases the summary stays a flat list
+func (r *Renderer) SetSummaryPhases(phases []SummaryPhase) {
+	r.phases = phases
+}
+
+// phaseResults is a phase's results in the summary
+type phaseResults struct {
+	name    string
+	results []TaskSummary
+}
+
+// groupByPhase splits results by the summary phases, keeping their order and dropping phases
+// without results. Results in no phase (e.g. from tasks since removed) come last, without a
+// name. It returns nil when the summary is not grouped
+func (r *Renderer) groupByPhase(results []TaskSummary) []phaseResults {
+	if len(r.phases) < 2 {
+		return nil
+	}
+	phaseOf := make(map[string]int)
+	for i, phase := range r.phases {
+		for _, id := range phase.TaskIDs {
+			phaseOf[id] = i
+		}
+	}
+	groups := make([]phaseResults, len(r.phases)+1)
+	for i, phase := range r.phases {
+		groups[i].name = phase.Name
+	}
+	for _, result := range results {
+		i, ok := phaseOf[result.ID]
+		if !ok {
+			i = len(r.phases)
+		}
+		groups[i].results = append(groups[i].results, result)
+	}
+	var kept []phaseResults
+	for _, group := range groups {
+		if len(group.results) > 0 {
+			kept = append(kept, group)
+		}
+	}
+	return kept
+}
+
+// phaseHeader describes a phase in the summary, e.g. "Build (2 tasks, 1 failed, 4.20s)". The
+// time is the sum of its tasks' durations
+func (r *Renderer) phaseHeader(group phaseResults) string {
+	var totalMs int64
+	failed := 0
+	for _, result := range group.results {
+		totalMs += result.DurationMs
+		if result.Status == "FAIL" {
+			failed++
+		}
+	}
+	counts := fmt.Sprintf("%d tasks", len(group.results))
+	if len(group.results) == 1 {
+		counts = "1 task"
+	}
+	if failed > 0 {
+		counts += fmt.Sprintf(", %d failed", failed)
+	}
+	name := group.name
+	if name == "" {
+		name = "Other"
+	}
+	return r.colors.Bold(name) + " " + r.colors.Gray(fmt.Sprintf("(%s, %.2fs)", counts, float64(totalMs)/1000.0))
+}
+
+// renderSummaryRow prints one task's line of the summary
+func (r *Renderer) renderSummaryRow(result TaskSummary, maxIDWidth int) {
+	symbol := r.colors.StatusSymbol(result.Status)
+	statusText := r.colors.StatusColor(result.Status, fmt.Sprintf("%-10s", result.Status))
+	seconds := float64(result.DurationMs) / 1000.0
+	durationText := fmt.Sprintf("%.2fs (%dms)", seconds, result.DurationMs)
+
+	annotation := ""
+	if result.AutoFixed {
+		annotation = " " + r.colors.Gray("[auto-fixed]")
+	}
+	if result.NoOutput {
+		annotation += " " + r.colors.Yellow("[no output]")
+	}
+	if result.CachedFrom != "" {
+		annotation += " " + r.colors.Gray("[cached from "+result.CachedFrom+"]")
+	}
+	if result.Interrupted {
+		annotation += " " + r.colors.Yellow("[interrupted]")
+	}
+
+	taskID := truncateTaskID(result.ID, 45)
+	fmt.Printf("  %s %-*s %s %s%s\n", symbol, maxIDWidth, taskID, statusText, durationText, annotation)
+}
+
 // TaskSummary represents a task result for the summary
 type TaskSummary struct {
-	ID         string
-	Status     string
-	DurationMs int64
-	AutoFixed  bool
+	ID               string
+	Status           string
+	DurationMs       int64
+	AutoFixed        bool
+	NoOutput         bool
+	CachedFrom       string // Run ID the result was reused from by --resume
+	Interrupted      bool   // Killed by Ctrl-C/SIGTERM while running
+	EstimatedSeconds int    // Historical duration estimate, summed for skipped tasks as time saved
 }
 
 // RenderProgress renders a progress bar (for full mode)
@@ -272,6 +494,12 @@ func (r *Renderer) StatusColor(status string) string {
 	return r.colors.StatusColor(status, status)
 }
 
+// DisableAnimation switches to plain sequential output, e.g. when the animated tracker fails to start
+func (r *Renderer) DisableAnimation() {
+	r.animated = false
+	r.tracker = nil
+}
+
 // SetTracker sets the animated tracker reference
 func (r *Renderer) SetTracker(tracker *AnimatedTaskTracker) {
 	r.tracker = tracker
@@ -290,15 +518,14 @@ func (r *Renderer) Verbose(verbose bool, format string, args ...interface{}) {
 
 	// Always write to pipeline.log (without color codes)
 	if r.pipelineLog != nil {
-		plainLine := fmt.Sprintf("[%-15s] %s\n", "verbose", msg)
+		plainLine := r.Prefix("verbose") + msg + "\n"
 		_, _ = r.pipelineLog.WriteString(plainLine) // Best effort logging
 	}
 
 	// Only output to console/tracker if verbose flag is enabled
 	if verbose {
-		// Pad "verbose" to 15 chars BEFORE applying color, so alignment works
-		paddedVerbose := fmt.Sprintf("%-15s", "verbose")
-		line := fmt.Sprintf("[%s] %s", r.colors.Gray(paddedVerbose), msg)
+		// Pad "verbose" BEFORE applying color, so alignment works
+		line := r.styledPrefix("verbose", r.colors.Gray) + msg
 
 		if r.tracker != nil {
 			r.tracker.AddLogLine(line)
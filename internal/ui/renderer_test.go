@@ -335,6 +335,50 @@ func TestRenderSummary(t *testing.T) {
 	}
 }
 
+func TestRenderSummaryGroupsByPhase(t *testing.T) {
+	render := func(phases []SummaryPhase) string {
+		old := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		renderer := NewRenderer(UIModeBasic, false, false)
+		renderer.SetSummaryPhases(phases)
+		renderer.RenderSummary([]TaskSummary{
+			{ID: "test", Status: "FAIL", DurationMs: 2500},
+			{ID: "lint", Status: "PASS", DurationMs: 1000},
+			{ID: "build", Status: "PASS", DurationMs: 1200},
+			{ID: "extra", Status: "SKIPPED"},
+		}, true, 4700)
+
+		_ = w.Close() // Test cleanup
+		os.Stdout = old
+		var buf bytes.Buffer
+		_, _ = io.Copy(&buf, r) // Test output capture
+		return buf.String()
+	}
+
+	output := render([]SummaryPhase{
+		{Name: "Checks", TaskIDs: []string{"lint", "build"}},
+		{Name: "Tests", TaskIDs: []string{"test"}},
+	})
+	checks := strings.Index(output, "Checks (2 tasks, 2.20s)")
+	tests := strings.Index(output, "Tests (1 task, 1 failed, 2.50s)")
+	other := strings.Index(output, "Other (1 task, 0.00s)")
+	if checks < 0 || tests < 0 || other < 0 {
+		t.Fatalf("missing phase headers:\n%s", output)
+	}
+	if !(checks < strings.Index(output, "lint") && strings.Index(output, "lint") < tests &&
+		tests < strings.Index(output, "test ") && strings.Index(output, "test ") < other) {
+		t.Errorf("tasks not listed under their phases:\n%s", output)
+	}
+
+	// A single phase has nothing to group
+	output = render([]SummaryPhase{{Name: "Checks", TaskIDs: []string{"lint", "build", "test", "extra"}}})
+	if strings.Contains(output, "Checks (") {
+		t.Errorf("single phase should not print a header:\n%s", output)
+	}
+}
+
 func TestRenderProgress(t *testing.T) {
 	// Capture stdout
 	old := os.Stdout
@@ -525,6 +569,32 @@ func TestRenderSummaryWithAutoFixed(t *testing.T) {
 	}
 }
 
+func TestRenderSummaryTimeSaved(t *testing.T) {
+	// Capture stdout
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	renderer := NewRenderer(UIModeBasic, false, false)
+	summaries := []TaskSummary{
+		{ID: "lint", Status: "PASS", DurationMs: 1000, EstimatedSeconds: 1},
+		{ID: "e2e", Status: "SKIPPED", EstimatedSeconds: 90},
+		{ID: "security", Status: "SKIPPED", EstimatedSeconds: 30},
+	}
+	renderer.RenderSummary(summaries, false, 1000)
+
+	_ = w.Close() // Test cleanup
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r) // Test output capture
+	output := buf.String()
+
+	if !strings.Contains(output, "Skipped 2 task(s) (~120s estimated time saved)") {
+		t.Errorf("Expected skipped tasks' estimates to be summed, got:\n%s", output)
+	}
+}
+
 func TestRenderSummaryWithFailures(t *testing.T) {
 	// Capture stdout
 	old := os.Stdout
@@ -551,6 +621,30 @@ func TestRenderSummaryWithFailures(t *testing.T) {
 	}
 }
 
+func TestRenderFailureTails(t *testing.T) {
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	renderer := NewRenderer(UIModeBasic, false, false)
+	renderer.RenderFailureTails([]FailureTail{
+		{ID: "lint", LogPath: "runs/r1/logs/lint.log", Lines: []string{"src/app.ts:3 error", "1 problem"}},
+		{ID: "test", LogPath: "runs/r1/logs/test.log", Lines: []string{"FAIL TestLogin"}},
+	})
+
+	_ = w.Close() // Test cleanup
+	os.Stdout = old
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r) // Test output capture
+	output := buf.String()
+
+	lint := strings.Index(output, "lint: last 2 line(s)")
+	test := strings.Index(output, "test: last 1 line(s)")
+	if lint < 0 || test < lint || !strings.Contains(output, "  1 problem\n  Full log: runs/r1/logs/lint.log") {
+		t.Errorf("failure tails not grouped by task:\n%s", output)
+	}
+}
+
 func TestVerboseWithTracker(t *testing.T) {
 	renderer := NewRenderer(UIModeBasic, false, false)
 
@@ -887,3 +981,70 @@ func TestRenderSummarySkippedStatus(t *testing.T) {
 		t.Error("Expected output to contain SKIPPED status")
 	}
 }
+
+func TestRendererQuietVerbosity(t *testing.T) {
+	// Capture stdout
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	renderer := NewRenderer(UIModeBasic, false, false)
+	renderer.SetVerbosity(VerbosityQuiet)
+	if !renderer.IsQuiet() {
+		t.Fatal("Expected IsQuiet() to be true after SetVerbosity(VerbosityQuiet)")
+	}
+
+	exitCode := 0
+	failCode := 1
+	renderer.RenderTaskStart("started-task", "echo hi", false)
+	renderer.RenderTaskSkipped("skipped-task", "disabled", false)
+	renderer.RenderTaskComplete("passed-task", "PASS", &exitCode, 1500, false)
+	renderer.RenderTaskComplete("failed-task", "FAIL", &failCode, 1500, false)
+
+	_ = w.Close() // Test cleanup
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r) // Test output capture
+	output := buf.String()
+
+	for _, hidden := range []string{"started-task", "skipped-task", "passed-task"} {
+		if strings.Contains(output, hidden) {
+			t.Errorf("Expected quiet output to omit %q, got: %s", hidden, output)
+		}
+	}
+	if !strings.Contains(output, "failed-task") {
+		t.Errorf("Expected quiet output to contain 'failed-task', got: %s", output)
+	}
+}
+
+func TestRendererPrefix(t *testing.T) {
+	ids := []string{"lint", "a-much-longer-task-id"}
+	tests := []struct {
+		name     string
+		template string
+		id       string
+		want     string
+	}{
+		{"default pads to longest id", DefaultLinePrefix, "lint", "[lint                 ] "},
+		{"longest id is not truncated", DefaultLinePrefix, "a-much-longer-task-id", "[a-much-longer-task-id] "},
+		{"custom template", "{id} |", "lint", "lint                  | "},
+		{"disabled", "", "lint", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRenderer(UIModeBasic, false, false)
+			r.SetLinePrefix(tt.template, ids)
+			if got := r.Prefix(tt.id); got != tt.want {
+				t.Errorf("Prefix(%q) = %q, want %q", tt.id, got, tt.want)
+			}
+		})
+	}
+
+	// Before SetLinePrefix the historical 15-character column is used
+	r := NewRenderer(UIModeBasic, false, false)
+	if got := r.Prefix("build"); got != "[build          ] " {
+		t.Errorf("default Prefix() = %q, want %q", got, "[build          ] ")
+	}
+}
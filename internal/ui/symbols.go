@@ -0,0 +1,97 @@
+package ui
+
+// Symbols are the markers printed before task statuses
+type Symbols struct {
+	Pass    string
+	Fail    string
+	Warn    string
+	Skip    string
+	Running string
+	Pending string
+}
+
+// EmojiSymbols are the default markers
+var EmojiSymbols = Symbols{Pass: "✓", Fail: "✗", Warn: "⚠", Skip: "⊘", Running: "⚙", Pending: "⋯"}
+
+// ASCIISymbols replace them with --no-emoji (or defaults.emoji = false)
+var ASCIISymbols = Symbols{Pass: "+", Fail: "x", Warn: "!", Skip: "-", Running: "*", Pending: "."}
+
+// SymbolNames are the keys of defaults.symbols
+var SymbolNames = []string{"pass", "fail", "warn", "skip", "running", "pending"}
+
+// WithOverrides returns s with the markers named in overrides (keys from SymbolNames) replaced
+func (s Symbols) WithOverrides(overrides map[string]string) Symbols {
+	for name, symbol := range overrides {
+		switch name {
+		case "pass":
+			s.Pass = symbol
+		case "fail":
+			s.Fail = symbol
+		case "warn":
+			s.Warn = symbol
+		case "skip":
+			s.Skip = symbol
+		case "running":
+			s.Running = symbol
+		case "pending":
+			s.Pending = symbol
+		}
+	}
+	return s
+}
+
+// For returns the marker for a task status, or "" for an unknown one
+func (s Symbols) For(status string) string {
+	switch status {
+	case "PASS":
+		return s.Pass
+	case "FAIL":
+		return s.Fail
+	case "WARN":
+		return s.Warn
+	case "SKIPPED":
+		return s.Skip
+	case "RUNNING":
+		return s.Running
+	case "PENDING":
+		return s.Pending
+	default:
+		return ""
+	}
+}
+
+// SetSymbols picks the status markers: the emoji set or, when emoji is false, the ASCII one,
+// with overrides (defaults.symbols) applied on top. Without emoji, Icon drops decorative
+// emoji such as 📊 and the phase markers become ASCII too
+func (r *Renderer) SetSymbols(emoji bool, overrides map[string]string) {
+	symbols := EmojiSymbols
+	if !emoji {
+		symbols = ASCIISymbols
+	}
+	r.noEmoji = !emoji
+	r.colors.symbols = symbols.WithOverrides(overrides)
+}
+
+// Symbol returns the uncolored marker for a task status, "•" for an unknown one
+func (r *Renderer) Symbol(status string) string {
+	if symbol := r.colors.symbols.For(status); symbol != "" {
+		return symbol
+	}
+	return "•"
+}
+
+// Icon returns a decorative emoji followed by a space, or "" when emoji are off
+func (r *Renderer) Icon(emoji string) string {
+	if r.noEmoji {
+		return ""
+	}
+	return emoji + " "
+}
+
+// PhaseMarkers returns the markers printed before a phase starts and after it ends
+func (r *Renderer) PhaseMarkers() (start, end string) {
+	if r.noEmoji {
+		return ">", "<"
+	}
+	return "▶", "◀"
+}
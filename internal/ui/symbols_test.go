@@ -0,0 +1,38 @@
+package ui
+
+import "testing"
+
+func TestSetSymbols(t *testing.T) {
+	r := NewRenderer(UIModeBasic, false, false)
+	if got := r.Symbol("PASS"); got != "✓" {
+		t.Errorf("default Symbol(PASS) = %q, want ✓", got)
+	}
+	if got := r.Icon("📊"); got != "📊 " {
+		t.Errorf("default Icon() = %q, want the emoji and a space", got)
+	}
+
+	r.SetSymbols(false, map[string]string{"fail": "FAILED"})
+	tests := map[string]string{"PASS": "+", "FAIL": "FAILED", "WARN": "!", "SKIPPED": "-", "RUNNING": "*", "PENDING": ".", "UNKNOWN": "•"}
+	for status, want := range tests {
+		if got := r.Symbol(status); got != want {
+			t.Errorf("Symbol(%s) = %q, want %q", status, got, want)
+		}
+	}
+	if got := r.colors.StatusSymbol("SKIPPED"); got != "-" {
+		t.Errorf("StatusSymbol(SKIPPED) = %q, want -", got)
+	}
+	if got := r.Icon("📊"); got != "" {
+		t.Errorf("Icon() without emoji = %q, want empty", got)
+	}
+	if start, end := r.PhaseMarkers(); start != ">" || end != "<" {
+		t.Errorf("PhaseMarkers() = %q, %q, want ASCII markers", start, end)
+	}
+
+	r.SetSymbols(true, map[string]string{"pass": "OK"})
+	if got := r.Symbol("PASS"); got != "OK" {
+		t.Errorf("overridden Symbol(PASS) = %q, want OK", got)
+	}
+	if got := r.Symbol("FAIL"); got != "✗" {
+		t.Errorf("Symbol(FAIL) = %q, want ✗", got)
+	}
+}
@@ -28,13 +28,32 @@ func GetTerminalHeight() int {
 	return height
 }
 
-// IsColorEnabled returns true if color output should be enabled
+// IsColorEnabled returns true if color output should be enabled, following the NO_COLOR and
+// CLICOLOR conventions (see colorEnabled)
 func IsColorEnabled() bool {
-	// Check NO_COLOR environment variable
-	if os.Getenv("NO_COLOR") != "" {
+	return colorEnabled(os.Getenv, IsTTY(os.Stdout.Fd()))
+}
+
+// ColorEnabled is IsColorEnabled for a command with a --no-color flag, which overrides the
+// environment
+func ColorEnabled(noColorFlag bool) bool {
+	return !noColorFlag && IsColorEnabled()
+}
+
+// colorEnabled decides whether to color output, in order of precedence:
+//   - NO_COLOR set to any non-empty value disables color
+//   - CLICOLOR_FORCE set to anything but 0 enables color, even when stdout is not a terminal
+//   - CLICOLOR=0 disables color
+//   - otherwise output is colored when stdout is a terminal
+func colorEnabled(getenv func(string) string, tty bool) bool {
+	if getenv("NO_COLOR") != "" {
 		return false
 	}
-
-	// Check if stdout is a TTY
-	return IsTTY(os.Stdout.Fd())
+	if force := getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true
+	}
+	if getenv("CLICOLOR") == "0" {
+		return false
+	}
+	return tty
 }
@@ -139,3 +139,42 @@ func TestIsTTYWithStdin(t *testing.T) {
 	// Result depends on environment, just verify it doesn't panic
 	t.Logf("IsTTY(stdin) = %v", result)
 }
+
+func TestColorEnabledPrecedence(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		tty  bool
+		want bool
+	}{
+		{"terminal", nil, true, true},
+		{"not a terminal", nil, false, false},
+		{"NO_COLOR", map[string]string{"NO_COLOR": "1"}, true, false},
+		{"empty NO_COLOR is ignored", map[string]string{"NO_COLOR": ""}, true, true},
+		{"CLICOLOR_FORCE without a terminal", map[string]string{"CLICOLOR_FORCE": "1"}, false, true},
+		{"CLICOLOR_FORCE=0 is ignored", map[string]string{"CLICOLOR_FORCE": "0"}, false, false},
+		{"NO_COLOR beats CLICOLOR_FORCE", map[string]string{"NO_COLOR": "1", "CLICOLOR_FORCE": "1"}, true, false},
+		{"CLICOLOR=0", map[string]string{"CLICOLOR": "0"}, true, false},
+		{"CLICOLOR_FORCE beats CLICOLOR=0", map[string]string{"CLICOLOR": "0", "CLICOLOR_FORCE": "1"}, false, true},
+		{"CLICOLOR=1 needs a terminal", map[string]string{"CLICOLOR": "1"}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			if got := colorEnabled(getenv, tt.tty); got != tt.want {
+				t.Errorf("colorEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColorEnabledFlagOverridesEnvironment(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("CLICOLOR_FORCE", "1")
+	if !ColorEnabled(false) {
+		t.Error("Expected CLICOLOR_FORCE to enable color without --no-color")
+	}
+	if ColorEnabled(true) {
+		t.Error("Expected --no-color to disable color even with CLICOLOR_FORCE")
+	}
+}
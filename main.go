@@ -18,30 +18,65 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/bmatcuk/doublestar/v4"
+	"github.com/drew/devpipe/internal/cache"
+	"github.com/drew/devpipe/internal/completion"
+	"github.com/drew/devpipe/internal/condition"
 	"github.com/drew/devpipe/internal/config"
+	"github.com/drew/devpipe/internal/container"
 	"github.com/drew/devpipe/internal/dashboard"
 	"github.com/drew/devpipe/internal/git"
+	"github.com/drew/devpipe/internal/logsink"
 	"github.com/drew/devpipe/internal/metrics"
 	"github.com/drew/devpipe/internal/model"
+	"github.com/drew/devpipe/internal/notify"
+	"github.com/drew/devpipe/internal/preflight"
+	"github.com/drew/devpipe/internal/promexport"
+	"github.com/drew/devpipe/internal/redact"
+	"github.com/drew/devpipe/internal/report"
+	"github.com/drew/devpipe/internal/sanitize"
 	"github.com/drew/devpipe/internal/sarif"
+	"github.com/drew/devpipe/internal/scaffold"
+	"github.com/drew/devpipe/internal/tasklog"
 	"github.com/drew/devpipe/internal/ui"
 	"golang.org/x/sync/errgroup"
 )
 
+// Exit codes of a run, so wrapper scripts can tell a failing task from a broken setup
+const (
+	exitOK          = 0 // Every task passed (or only advisory tasks failed, see defaults.advisoryExitCode)
+	exitTaskFailed  = 1 // At least one task failed
+	exitConfigError = 2 // The config, flags or environment kept the run from starting
+	exitInterrupted = 3 // The run was stopped by Ctrl-C or SIGTERM
+)
+
 // Version information (set via ldflags during build)
 var (
 	version   = "dev"
@@ -49,7 +84,7 @@ var (
 	buildDate = "unknown"
 )
 
-// sliceFlag allows repeating --skip
+// sliceFlag allows repeating --skip, --tag and --exclude-tag
 type sliceFlag []string
 
 func (s *sliceFlag) String() string {
@@ -61,6 +96,57 @@ func (s *sliceFlag) Set(val string) error {
 	return nil
 }
 
+// timestampFlag is --timestamps; a bare --timestamps means "clock"
+type timestampFlag string
+
+func (t *timestampFlag) String() string {
+	return string(*t)
+}
+
+func (t *timestampFlag) Set(val string) error {
+	switch val {
+	case "true":
+		*t = "clock"
+	case "false":
+		*t = "off"
+	case "off", "clock", "elapsed":
+		*t = timestampFlag(val)
+	default:
+		return fmt.Errorf("must be clock, elapsed or off")
+	}
+	return nil
+}
+
+func (t *timestampFlag) IsBoolFlag() bool {
+	return true
+}
+
+// resumeFlag is --resume; a bare --resume resumes the latest run
+type resumeFlag string
+
+func (r *resumeFlag) String() string {
+	return string(*r)
+}
+
+func (r *resumeFlag) Set(val string) error {
+	switch val {
+	case "true":
+		*r = "latest"
+	case "false":
+		*r = ""
+	default:
+		*r = resumeFlag(val)
+	}
+	return nil
+}
+
+func (r *resumeFlag) IsBoolFlag() bool {
+	return true
+}
+
+// subcommands are the commands devpipe accepts before any run flags
+var subcommands = []string{"run", "init", "list", "validate", "generate-reports", "reindex", "import", "sarif", "diff", "history", "config", "doctor", "serve", "completion", "version", "help"}
+
 func main() {
 	// Check for subcommands first
 	if len(os.Args) > 1 {
@@ -75,9 +161,35 @@ func main() {
 		case "generate-reports":
 			generateReportsCmd()
 			return
+		case "reindex":
+			reindexCmd()
+			return
+		case "import":
+			importCmd()
+			return
 		case "sarif":
 			sarifCmd()
 			return
+		case "diff":
+			diffCmd()
+			return
+		case "history":
+			historyCmd()
+			return
+		case "config":
+			configCmd()
+			return
+		case "doctor":
+			doctorCmd()
+			return
+		case "serve":
+			serveCmd()
+			return
+		case "init":
+			initCmd()
+			return
+		case "run", "completion":
+			// Handled once the run flags are registered: run takes them too, and completion completes them
 		case "version", "--version", "-v":
 			fmt.Printf("devpipe version %s\n", version)
 			return
@@ -90,13 +202,12 @@ func main() {
 				fmt.Fprintf(os.Stderr, "Unknown command: %s\n", arg)
 
 				// Suggest similar commands
-				commands := []string{"list", "validate", "generate-reports", "sarif", "version", "help"}
-				if suggestion := findSimilarCommand(arg, commands); suggestion != "" {
+				if suggestion := findSimilarCommand(arg, subcommands); suggestion != "" {
 					fmt.Fprintf(os.Stderr, "Did you mean '%s'?\n", suggestion)
 				}
 				fmt.Fprintln(os.Stderr)
 				printHelp()
-				os.Exit(1)
+				os.Exit(exitConfigError)
 			}
 		}
 	}
@@ -108,60 +219,164 @@ func main() {
 		flagOnly             string
 		flagUI               string
 		flagFixType          string
+		flagJUnitOut         string
+		flagMarkdownOut      string
+		flagJSONOut          string
+		flagMetricsOut       string
 		flagNoColor          bool
+		flagNoEmoji          bool
+		flagFlatSummary      bool
+		flagProfile          string
 		flagDashboard        bool
+		flagNotify           bool
+		flagStrictEnv        bool
+		flagGitHub           bool
+		flagGitHubOnly       bool
 		flagFailFast         bool
 		flagDryRun           bool
 		flagVerbose          bool
+		flagQuiet            bool
 		flagFast             bool
 		flagIgnoreWatchPaths bool
+		flagChangedOnly      bool
+		flagNoCache          bool
+		flagNoShowFailures   bool
+		flagNoDiscovery      bool
+		flagServe            bool
+		flagOpen             bool
+		flagExitZero         bool
+		flagParallel         bool
+		flagJobs             int
 		flagSkipVals         sliceFlag
+		flagTasks            sliceFlag
+		flagLabels           sliceFlag
+		flagTags             sliceFlag
+		flagExcludeTags      sliceFlag
+		flagTimestamps       timestampFlag
+		flagResume           resumeFlag
 	)
 
-	flag.StringVar(&flagConfig, "config", "", "Path to config file (default: config.toml)")
+	flag.StringVar(&flagConfig, "config", "", "Path to config file, or - to read it from stdin (default: $"+config.ConfigEnvVar+", else config.toml or .devpipe.toml in this or a parent directory)")
+	flag.StringVar(&flagProfile, "profile", "", "Config profile to lay over the config, e.g. ci (default: $"+config.ProfileEnvVar+")")
+	flag.BoolVar(&flagNoDiscovery, "no-discovery", false, "Only look for config.toml in the current directory, not in parent directories")
 	flag.StringVar(&flagSince, "since", "", "Git ref to compare against (overrides config)")
-	flag.StringVar(&flagOnly, "only", "", "Run only specific task(s) by id (comma-separated)")
+	flag.StringVar(&flagOnly, "only", "", "Run only specific tasks by id, phase or glob (comma-separated)")
 	flag.StringVar(&flagUI, "ui", "basic", "UI mode: basic, full")
 	flag.StringVar(&flagFixType, "fix-type", "", "Fix type: auto, helper, none (overrides config)")
+	flag.StringVar(&flagJUnitOut, "junit-out", "", "Write a JUnit XML summary of the run to this path")
+	flag.StringVar(&flagMarkdownOut, "markdown-out", "", "Write a Markdown summary of the run to this path")
+	flag.StringVar(&flagJSONOut, "json-out", "", "Write a JSON summary of the run to this path")
+	flag.StringVar(&flagMetricsOut, "metrics-out", "", "Write Prometheus textfile metrics for the run to this path")
 	flag.BoolVar(&flagDashboard, "dashboard", false, "Show dashboard with live progress")
+	flag.BoolVar(&flagServe, "serve", false, fmt.Sprintf("Serve the HTML dashboard with live progress on port %d and open it in the browser", defaultServePort))
+	flag.BoolVar(&flagNotify, "notify", false, "Send a desktop notification when the pipeline finishes")
+	flag.BoolVar(&flagOpen, "open", false, "Open the run's HTML report in the browser when the pipeline finishes")
+	flag.BoolVar(&flagStrictEnv, "strict-env", false, "Fail if a ${VAR} in the config is not defined")
+	flag.BoolVar(&flagGitHub, "github", false, "Emit GitHub Actions annotations for failed tasks (auto-enabled when GITHUB_ACTIONS=true)")
+	flag.BoolVar(&flagGitHubOnly, "github-only", false, "Emit GitHub Actions annotations instead of the terminal summary")
 	flag.BoolVar(&flagNoColor, "no-color", false, "Disable colored output")
-	flag.Var(&flagSkipVals, "skip", "Skip a task by id (can be specified multiple times)")
+	flag.BoolVar(&flagNoEmoji, "no-emoji", false, "Use ASCII status markers instead of emoji and symbols")
+	flag.BoolVar(&flagFlatSummary, "flat-summary", false, "List tasks in the summary without phase headers")
+	flag.BoolVar(&flagNoShowFailures, "no-show-failures", false, "Don't repeat the end of each failed task's log after the summary")
+	flag.Var(&flagSkipVals, "skip", "Skip tasks by id, phase or glob (can be specified multiple times)")
+	flag.Var(&flagTags, "tag", "Run only tasks with this tag (repeatable or comma-separated)")
+	flag.Var(&flagExcludeTags, "exclude-tag", "Skip tasks with this tag (repeatable or comma-separated)")
+	flag.Var(&flagLabels, "label", "Attach a key=value label to the run record and report (repeatable)")
+	flag.IntVar(&flagJobs, "jobs", -1, "Max tasks to run in parallel per phase (overrides config; 0 or 1 = sequential)")
 	flag.BoolVar(&flagFailFast, "fail-fast", false, "Stop on first task failure")
-	flag.BoolVar(&flagDryRun, "dry-run", false, "Do not execute commands, simulate only")
+	flag.BoolVar(&flagExitZero, "exit-zero", false, "Exit 0 even when tasks fail (config errors and interrupts keep their exit codes)")
+	flag.BoolVar(&flagDryRun, "dry-run", false, "Do not execute commands; show what each task would run")
 	flag.BoolVar(&flagVerbose, "verbose", false, "Verbose logging")
+	flag.BoolVar(&flagQuiet, "quiet", false, "Only print failing tasks and the final summary")
+	flag.Var(&flagTimestamps, "timestamps", "Prefix task output lines with a timestamp: clock (default) or elapsed, e.g. --timestamps=elapsed")
 	flag.BoolVar(&flagFast, "fast", false, "Skip long running tasks")
 	flag.BoolVar(&flagIgnoreWatchPaths, "ignore-watch-paths", false, "Ignore watchPaths and run all tasks")
-	flag.Parse()
+	flag.BoolVar(&flagChangedOnly, "changed-only", false, "Run only tasks whose type [fileTypeMap] selects for the changed files")
+	flag.BoolVar(&flagNoCache, "no-cache", false, "Run tasks even when their cacheInputs are unchanged")
+	flag.Var(&flagResume, "resume", "Re-run only the failed and skipped tasks of the latest run (or --resume <runID>)")
+	flag.Var(&flagTasks, "task", "With devpipe run: a command to run as an ad-hoc task (repeatable)")
+	flag.BoolVar(&flagParallel, "parallel", false, "With devpipe run: run the --task commands in parallel instead of one after another")
+
+	if len(os.Args) > 1 && os.Args[1] == "completion" {
+		completionCmd(flag.CommandLine)
+		return
+	}
+
+	// devpipe run takes the same flags as a bare devpipe
+	adHoc := len(os.Args) > 1 && os.Args[1] == "run"
+	args := os.Args[1:]
+	if adHoc {
+		args = args[1:]
+	}
+	_ = flag.CommandLine.Parse(args) // Exits on error
+	if adHoc && len(flagTasks) == 0 {
+		fmt.Fprintf(os.Stderr, "ERROR: devpipe run needs at least one --task <command>\n")
+		os.Exit(exitConfigError)
+	}
+	if !adHoc && (len(flagTasks) > 0 || flagParallel) {
+		fmt.Fprintf(os.Stderr, "ERROR: --task and --parallel are only used with devpipe run\n")
+		os.Exit(exitConfigError)
+	}
+
+	// A bare --resume leaves "--resume <runID>" as a positional argument
+	if flagResume == "latest" && flag.NArg() == 1 {
+		flagResume = resumeFlag(flag.Arg(0))
+	}
 
 	// Load configuration first to get UI mode
-	cfg, configTaskOrder, phaseNames, taskToPhase, err := config.LoadConfig(flagConfig)
+	configPath := findConfig(flagConfig, !flagNoDiscovery)
+	cfg, configTaskOrder, phaseNames, taskToPhase, err := config.LoadConfig(configPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitConfigError)
+	}
+	if err := applyProfile(cfg, flagProfile); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+
+	// devpipe run replaces the config's tasks with its --task commands and keeps the rest
+	if adHoc {
+		if cfg == nil {
+			cfg = &config.Config{}
+		}
+		cfg.Tasks, configTaskOrder = adHocTasks(flagTasks, flagParallel)
+		phaseNames, taskToPhase = nil, nil
 	}
 
 	// Merge with defaults
 	mergedCfg := config.MergeWithDefaults(cfg)
+	if flagStrictEnv {
+		mergedCfg.Defaults.StrictEnv = true
+	}
 
 	// Validate configuration before running
 	result, err := config.ValidateConfig(&mergedCfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "ERROR: Failed to validate config: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitConfigError)
 	}
 	if !result.Valid {
 		fmt.Fprintf(os.Stderr, "ERROR: Configuration validation failed:\n")
 		for _, e := range result.Errors {
 			fmt.Fprintf(os.Stderr, "  - %s: %s\n", e.Field, e.Message)
 		}
-		os.Exit(1)
+		os.Exit(exitConfigError)
 	}
-	if len(result.Warnings) > 0 && flagVerbose {
-		for _, w := range result.Warnings {
+	// Undefined variables silently expand to empty, so those warnings always show
+	for _, w := range result.Warnings {
+		if flagVerbose || w.IsUndefinedEnv() {
 			fmt.Fprintf(os.Stderr, "WARNING: %s: %s\n", w.Field, w.Message)
 		}
 	}
 
+	// Run labels: defaults.labels, added to or overridden by --label
+	runLabels, err := mergeLabels(mergedCfg.Defaults.Labels, flagLabels)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+
 	// Parse UI mode (CLI flag overrides config)
 	uiModeStr := flagUI
 	if flagUI == "basic" && cfg != nil && mergedCfg.Defaults.UIMode != "" {
@@ -180,16 +395,26 @@ func main() {
 	}
 
 	// Create renderer
-	enableColors := !flagNoColor && ui.IsColorEnabled()
-	// Determine if we should use dashboard (animated tracker)
-	useAnimated := flagDashboard && ui.IsTTY(uintptr(1))
+	enableColors := ui.ColorEnabled(flagNoColor)
+	// Determine if we should use dashboard (animated tracker); --quiet disables it
+	useAnimated, notice := dashboardMode(flagDashboard, flagQuiet, ui.IsTTY(uintptr(1)))
+	if notice != "" {
+		fmt.Fprintln(os.Stderr, notice)
+	}
 	renderer := ui.NewRenderer(uiMode, enableColors, useAnimated)
+	if flagQuiet {
+		renderer.SetVerbosity(ui.VerbosityQuiet)
+	}
+	renderer.SetSymbols(emojiEnabled(flagNoEmoji, mergedCfg.Defaults), mergedCfg.Defaults.Symbols)
+
+	// Expand ${VAR} references in projectRoot and outputRoot
+	mergedCfg.ExpandDefaults()
 
 	// Determine project root first (for all path resolution)
 	// This can be overridden in config, or auto-detected from git/config location
 	// We need to do this before git detection to know where to look for git
 	cwdGitRoot, cwdInGitRepo := git.DetectProjectRoot()
-	projectRoot := determineProjectRoot(flagConfig, mergedCfg, cwdGitRoot, cwdInGitRepo)
+	projectRoot := determineProjectRoot(configPath, mergedCfg, cwdGitRoot, cwdInGitRepo)
 
 	// Now detect git root from the project root location (for git operations)
 	gitRoot, inGitRepo := git.DetectProjectRootFrom(projectRoot)
@@ -199,7 +424,7 @@ func main() {
 		fmt.Fprintf(os.Stderr, "ERROR: Refusing to run devpipe in system directory: %s\n", projectRoot)
 		fmt.Fprintf(os.Stderr, "This safety check prevents accidental execution in critical system paths.\n")
 		fmt.Fprintf(os.Stderr, "Please run devpipe from your project directory, or set projectRoot in your config.\n")
-		os.Exit(1)
+		os.Exit(exitConfigError)
 	}
 
 	// Auto-generate config.toml if it doesn't exist and no custom config specified
@@ -207,14 +432,14 @@ func main() {
 		defaultConfigPath := "config.toml"
 
 		// Prompt user to create config
-		fmt.Printf("No config.toml found. Create one with example tasks? (y/n): ")
+		fmt.Printf("No config.toml found. Create one with tasks for this project? (y/n): ")
 		var response string
 		_, _ = fmt.Scanln(&response) // Best effort user input
 
 		if response == "y" || response == "Y" || response == "yes" || response == "Yes" {
 			if err := config.GenerateDefaultConfig(defaultConfigPath, projectRoot); err != nil {
 				fmt.Fprintf(os.Stderr, "ERROR: Could not generate config.toml: %v\n", err)
-				os.Exit(1)
+				os.Exit(exitConfigError)
 			}
 			fmt.Printf("✓ Created config.toml - edit it to customize your tasks\n")
 			fmt.Printf("  Full reference: https://github.com/drewkhoury/devpipe/blob/main/config.example.toml\n\n")
@@ -224,13 +449,14 @@ func main() {
 			mergedCfg = config.MergeWithDefaults(cfg)
 		} else {
 			fmt.Fprintf(os.Stderr, "ERROR: No config.toml found. Create one or specify with --config flag\n")
-			os.Exit(1)
+			os.Exit(exitConfigError)
 		}
 	}
 
 	// Determine which tasks to use
 	var tasks map[string]config.TaskConfig
 	var taskOrder []string
+	var writtenWaits map[string]bool // Wait markers written in the config, which are barriers
 
 	if cfg == nil || len(cfg.Tasks) == 0 {
 		// No config file or no tasks defined, use built-in
@@ -242,8 +468,16 @@ func main() {
 	} else {
 		// Use tasks from config
 		tasks = mergedCfg.Tasks
+
 		// Use the order extracted from the config file
 		if len(configTaskOrder) > 0 {
+			if result := config.ValidateTaskOrder(&mergedCfg, configTaskOrder); !result.Valid {
+				fmt.Fprintf(os.Stderr, "ERROR: Configuration validation failed:\n")
+				for _, e := range result.Errors {
+					fmt.Fprintf(os.Stderr, "  - %s: %s\n", e.Field, e.Message)
+				}
+				os.Exit(exitConfigError)
+			}
 			taskOrder = configTaskOrder
 		} else {
 			// Fallback: use built-in order if tasks match, otherwise alphabetical
@@ -270,11 +504,7 @@ func main() {
 
 		// Filter out "wait*" and "phase-*" pseudo-tasks (they're just phase markers)
 		// Keep them in taskOrder for phase detection, but remove from tasks map
-		for id := range tasks {
-			if id == "wait" || strings.HasPrefix(id, "wait-") || strings.HasPrefix(id, "phase-") {
-				delete(tasks, id)
-			}
-		}
+		writtenWaits = removeMarkers(tasks)
 	}
 
 	// Determine git mode and ref
@@ -288,7 +518,27 @@ func main() {
 	}
 
 	// Get changed files (uses git root)
-	gitInfo := git.DetectChangedFiles(gitRoot, inGitRepo, gitMode, gitRef, flagVerbose)
+	gitInfo := git.DetectChangedFiles(gitRoot, inGitRepo, gitMode, gitRef, mergedCfg.Defaults.Git.IncludeUntracked, flagVerbose)
+
+	// Drop ignored files (e.g. build output) so they never trigger watchPaths
+	var ignoredFiles []string
+	if gitInfo.InGitRepo && mergedCfg.Defaults.Git.RespectGitignore {
+		gitInfo.ChangedFiles, ignoredFiles = git.FilterIgnored(gitRoot, gitInfo.ChangedFiles)
+	}
+
+	// Set git-related environment variables for all tasks (and for ${VAR} expansion in task config)
+	if gitInfo.InGitRepo {
+		_ = os.Setenv("DEVPIPE_GIT_MODE", gitInfo.Mode)
+		_ = os.Setenv("DEVPIPE_GIT_REF", gitInfo.Ref)
+		_ = os.Setenv("DEVPIPE_CHANGED_FILES_COUNT", fmt.Sprintf("%d", len(gitInfo.ChangedFiles)))
+
+		// Newline-separated list (handles spaces in filenames)
+		_ = os.Setenv("DEVPIPE_CHANGED_FILES", strings.Join(gitInfo.ChangedFiles, "\n"))
+
+		// JSON array (language-agnostic)
+		changedFilesJSON, _ := json.Marshal(gitInfo.ChangedFiles)
+		_ = os.Setenv("DEVPIPE_CHANGED_FILES_JSON", string(changedFilesJSON))
+	}
 
 	// Prepare output dir (uses project root for relative paths, respects absolute paths)
 	var outputRoot string
@@ -330,20 +580,21 @@ func main() {
 		fmt.Fprintf(os.Stderr, "ERROR: Output directory resolves to dangerous location: %s\n", outputRoot)
 		fmt.Fprintf(os.Stderr, "This safety check prevents accidental execution in critical system paths.\n")
 		fmt.Fprintf(os.Stderr, "Use a safe location like /tmp/devpipe or a relative path within your project.\n")
-		os.Exit(1)
+		os.Exit(exitConfigError)
 	}
 
 	// Verbose logging (after all paths are determined)
 	if flagVerbose {
 		renderer.Verbose(flagVerbose, "devpipe version: %s (commit: %s, built: %s)", version, commit, buildDate)
-		configPath := flagConfig
-		if configPath == "" {
-			configPath = "config.toml"
-		}
-		if flagConfig != "" {
+		switch {
+		case flagConfig != "":
 			renderer.Verbose(flagVerbose, "Config: %s (from --config)", configPath)
-		} else {
-			renderer.Verbose(flagVerbose, "Config: %s", configPath)
+		case os.Getenv(config.ConfigEnvVar) != "":
+			renderer.Verbose(flagVerbose, "Config: %s (from $%s)", configPath, config.ConfigEnvVar)
+		case configPath != "":
+			renderer.Verbose(flagVerbose, "Config: %s (discovered)", configPath)
+		default:
+			renderer.Verbose(flagVerbose, "Config: config.toml")
 		}
 		if mergedCfg.Defaults.ProjectRoot != "" {
 			renderer.Verbose(flagVerbose, "Project root: %s (from config)", projectRoot)
@@ -359,30 +610,44 @@ func main() {
 		} else {
 			renderer.Verbose(flagVerbose, "Git root: %s (no git repo found at project root)", gitRoot)
 		}
+		if gitInfo.MergeBase != "" {
+			renderer.Verbose(flagVerbose, "Git base: %s (merge-base %.7s)", gitInfo.Ref, gitInfo.MergeBase)
+		}
+		if len(ignoredFiles) > 0 {
+			renderer.Verbose(flagVerbose, "Ignored changed files: %s (matched by .gitignore)", strings.Join(ignoredFiles, ", "))
+		}
 		renderer.Verbose(flagVerbose, "Output directory: %s", outputRoot)
 		fmt.Println() // Blank line before run output
 	}
-	runID := makeRunID()
+	runID, err := createRunDir(outputRoot, mergedCfg.Defaults.RunIDFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: failed to create run directory: %v\n", err)
+		os.Exit(exitConfigError)
+	}
 	runDir := filepath.Join(outputRoot, "runs", runID)
 	logDir := filepath.Join(runDir, "logs")
 
 	if err := os.MkdirAll(logDir, 0o755); err != nil {
 		fmt.Fprintf(os.Stderr, "ERROR: failed to create run directories: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitConfigError)
 	}
 
-	// Load historical averages
-	historicalAvg := loadHistoricalAverages(outputRoot)
+	// Load historical duration estimates
+	var summaryWarn io.Writer
+	if flagVerbose {
+		summaryWarn = os.Stderr
+	}
+	historicalAvg := loadHistoricalAverages(outputRoot, mergedCfg.Defaults.EstimateStat, summaryWarn)
+
+	// Secrets masked in task output (the patterns were checked when the config was validated)
+	masks := maskPatterns(mergedCfg.Defaults, os.Getenv)
 
 	// Build task list
 	var taskDefs []model.TaskDefinition
 	for _, id := range taskOrder {
 		// Check if this is a "wait" or "wait-*" marker
 		if id == "wait" || strings.HasPrefix(id, "wait-") {
-			// Mark the previous task as a wait point (end of phase)
-			if len(taskDefs) > 0 {
-				taskDefs[len(taskDefs)-1].Wait = true
-			}
+			markWait(taskDefs, id, writtenWaits)
 			continue
 		}
 
@@ -422,26 +687,36 @@ func main() {
 			}
 		}
 
+		// Tasks using commandArgs show (and hash) the joined form
+		if len(resolved.CommandArgs) > 0 {
+			resolved.Command = displayArgs(resolved.CommandArgs)
+		}
+
 		taskDef := model.TaskDefinition{
 			ID:               id,
 			Name:             resolved.Name,
 			Desc:             resolved.Desc,
 			Phase:            phaseName,
+			PhaseID:          taskToPhase[id],
 			Type:             resolved.Type,
 			Command:          resolved.Command,
+			CommandArgs:      resolved.CommandArgs,
 			Workdir:          resolved.Workdir,
+			CreateWorkdir:    resolved.CreateWorkdir,
 			EstimatedSeconds: estimatedSeconds,
 			IsEstimateGuess:  isGuess,
 			Wait:             resolved.Wait,
+			DependsOn:        resolved.DependsOn,
 		}
 
 		// Add output config if present
 		if resolved.OutputType != "" {
 			taskDef.OutputType = resolved.OutputType
 			taskDef.OutputPath = resolved.OutputPath
+			taskDef.MetricsParser = resolved.MetricsParser
 			if flagVerbose && !useAnimated {
 				// Only print before dashboard starts; during dashboard it goes to output
-				fmt.Printf("[%-15s] %s Output configured: type=%s, path=%s\n", renderer.Gray("verbose"), id, resolved.OutputType, resolved.OutputPath)
+				renderer.Verbose(true, "%s Output configured: type=%s, path=%s", id, resolved.OutputType, resolved.OutputPath)
 			}
 		}
 
@@ -453,26 +728,141 @@ func main() {
 		}
 		taskDef.FixType = fixType
 		taskDef.FixCommand = resolved.FixCommand
+		taskDef.FixMode = resolved.FixMode
 
 		// Add watchPaths if present
 		taskDef.WatchPaths = resolved.WatchPaths
+		taskDef.Required = resolved.Required
+		taskDef.ContinueOnError = resolved.ContinueOnError
+		taskDef.CacheInputs = resolved.CacheInputs
+		taskDef.ArtifactDir = resolved.ArtifactDir
+		taskDef.SkipArtifactCopy = mergedCfg.Defaults.CopyArtifacts != nil && !*mergedCfg.Defaults.CopyArtifacts
+		if resolved.CopyArtifacts != nil {
+			taskDef.SkipArtifactCopy = !*resolved.CopyArtifacts
+		}
+
+		taskDef.EmptyOutput = mergedCfg.Defaults.EmptyOutput
+		taskDef.Shell = mergedCfg.Defaults.Shell
+		taskDef.Image = resolved.Image
+		taskDef.ContainerRuntime = mergedCfg.Defaults.ContainerRuntime
+		taskDef.When = resolved.When
+		taskDef.OnlyIf = resolved.OnlyIf
+		taskDef.SkipIf = resolved.SkipIf
+		taskDef.RequiredEnv = resolved.RequiredEnv
+		taskDef.Tags = resolved.Tags
+		taskDef.Labels = resolved.Labels
+		taskDef.Group = resolved.Group
+		taskDef.Timestamps = mergedCfg.Defaults.Timestamps
+		if flagTimestamps != "" {
+			taskDef.Timestamps = string(flagTimestamps)
+		}
+		taskDef.TimestampsInLogs = mergedCfg.Defaults.TimestampsInLogs
+		taskDef.LogFormat = mergedCfg.Defaults.LogFormat
+		taskDef.MaxLogBytes = mergedCfg.Defaults.MaxLogBytes
+		if mergedCfg.Defaults.HeartbeatSeconds != nil {
+			taskDef.HeartbeatSeconds = *mergedCfg.Defaults.HeartbeatSeconds
+		}
+		if resolved.MaxLogBytes != nil {
+			taskDef.MaxLogBytes = *resolved.MaxLogBytes
+		}
+		taskDef.LogPreviewLines = mergedCfg.Defaults.LogPreviewLines
+		taskDef.LogPreviewFailed = mergedCfg.Defaults.LogPreviewLinesFailed
+		if resolved.LogPreviewLines != nil {
+			taskDef.LogPreviewLines = *resolved.LogPreviewLines
+			taskDef.LogPreviewFailed = *resolved.LogPreviewLines
+		}
+		taskDef.MaskPatterns = masks
+		taskDef.SanitizeOutput = mergedCfg.Defaults.SanitizeOutput == nil || *mergedCfg.Defaults.SanitizeOutput
+		taskDef.AllowExitCodes = resolved.AllowExitCodes
+		taskDef.FailOnMatch = resolved.FailOnMatch
+		taskDef.PassOnMatch = resolved.PassOnMatch
+		taskDef.SarifFailOn = resolved.SarifFailOn
+		taskDef.SarifMaxIssues = resolved.SarifMaxIssues
 
 		taskDefs = append(taskDefs, taskDef)
 	}
 
+	// Size the line prefix to the task ids (and the verbose label) so columns line up
+	prefixIDs := make([]string, 0, len(taskDefs)+1)
+	for _, t := range taskDefs {
+		prefixIDs = append(prefixIDs, t.ID)
+	}
+	if flagVerbose {
+		prefixIDs = append(prefixIDs, "verbose")
+	}
+	renderer.SetLinePrefix(*mergedCfg.Defaults.LogPrefix, prefixIDs)
+
+	// --resume narrows the run to what failed (or never ran) last time
+	candidateTasks := taskDefs
+	var cachedResults []model.TaskResult
+	if flagResume != "" {
+		prev, err := loadResumeRun(outputRoot, string(flagResume), runID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+		candidateTasks, cachedResults = selectResumeTasks(taskDefs, prev)
+		fmt.Printf("Resuming run %s: %d task(s) to re-run, %d cached\n", prev.RunID, len(candidateTasks), len(cachedResults))
+	}
+
 	// Apply CLI filters
-	filteredTasks := filterTasks(taskDefs, flagOnly, flagSkipVals, flagFast, mergedCfg.Defaults.FastThreshold, flagVerbose)
+	filteredTasks := filterTasks(candidateTasks, flagOnly, flagSkipVals, flagFast, mergedCfg.Defaults.FastThreshold, renderer, flagVerbose)
+	filteredTasks = filterTasksByTags(filteredTasks, taskDefs, splitFlagValues(flagTags), splitFlagValues(flagExcludeTags), renderer, flagVerbose)
+
+	// Select tasks by the types of the changed files
+	if flagChangedOnly {
+		switch {
+		case len(cfg.FileTypeMap) == 0:
+			fmt.Fprintf(os.Stderr, "ERROR: --changed-only needs a [fileTypeMap] section mapping file types to task types\n")
+			os.Exit(exitConfigError)
+		case !gitInfo.InGitRepo:
+			fmt.Fprintf(os.Stderr, "WARNING: --changed-only needs a git repository to find changed files; running all tasks\n")
+		default:
+			filteredTasks = filterTasksByFileTypes(filteredTasks, gitInfo.ChangedFiles, cfg.FileTypeMap, renderer, flagVerbose || flagDryRun)
+		}
+	}
 
 	// Apply watchPaths filtering based on git changes (unless --ignore-watch-paths is set)
 	if !flagIgnoreWatchPaths && gitInfo.InGitRepo && len(gitInfo.ChangedFiles) >= 0 {
-		filteredTasks = filterTasksByWatchPaths(filteredTasks, gitInfo.ChangedFiles, projectRoot, flagVerbose)
+		// --dry-run always explains which tasks watchPaths left out
+		filteredTasks = filterTasksByWatchPaths(filteredTasks, gitInfo.ChangedFiles, projectRoot, renderer, flagVerbose || flagDryRun)
+	}
+
+	// Workdirs of the tasks that will run; onlyIf or skipIf already skips a task without one
+	runIDs := make([]string, 0, len(filteredTasks))
+	for _, st := range filteredTasks {
+		if fileConditionSkip(st) == "" {
+			runIDs = append(runIDs, st.ID)
+		}
+	}
+	if result := config.ValidateWorkdirs(&mergedCfg, projectRoot, runIDs); !result.Valid {
+		fmt.Fprintf(os.Stderr, "ERROR: Configuration validation failed:\n")
+		for _, e := range result.Errors {
+			fmt.Fprintf(os.Stderr, "  - %s: %s\n", e.Field, e.Message)
+		}
+		os.Exit(exitConfigError)
+	}
+
+	// Report every missing tool up front instead of failing task by task
+	if mergedCfg.Defaults.Preflight && !flagDryRun {
+		var checks []taskPreflight
+		for _, task := range filteredTasks {
+			fixCommand := ""
+			if task.FixType == "auto" {
+				fixCommand = task.FixCommand
+			}
+			checks = append(checks, checkTaskTools(task.ID, task.Command, task.CommandArgs, fixCommand, task.Workdir, containerRuntime(task.Image, task.ContainerRuntime))...)
+		}
+		if missing := printPreflight(checks, ui.NewColors(enableColors), true); missing > 0 {
+			fmt.Fprintf(os.Stderr, "ERROR: preflight failed, not running any tasks (see 'devpipe doctor')\n")
+			os.Exit(exitConfigError)
+		}
 	}
 
 	// Run tasks
 	var (
-		results         []model.TaskResult
-		overallExitCode int
-		anyFailed       bool
+		results   []model.TaskResult
+		anyFailed bool
 	)
 
 	// Create pipeline.log for verbose output
@@ -492,9 +882,58 @@ func main() {
 		renderer.SetPipelineLog(pipelineLog)
 	}
 
+	// Forward task output to defaults.logSink; the run goes ahead without it if it can't be reached
+	if sinkURL := mergedCfg.Defaults.LogSink; sinkURL != "" && !flagDryRun {
+		if sink, err := logsink.Open(sinkURL); err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: cannot open log sink %s: %v\n", sinkURL, err)
+		} else {
+			outputSink = logsink.NewForwarder(sink, os.Stderr)
+			defer func() { _ = outputSink.Close() }()
+		}
+	}
+
 	// Render header
 	renderer.RenderHeader(runID, projectRoot, gitMode, len(gitInfo.ChangedFiles))
 
+	// Ctrl-C or SIGTERM cancels ctx, which kills running commands and stops launching new ones
+	ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	go func() {
+		<-ctx.Done()
+		stopSignals() // A second signal terminates immediately
+	}()
+
+	// hooks.after run once the tasks finish, however the run ends; the defer covers a panic
+	var hookLog io.Writer = io.Discard
+	if pipelineLog != nil {
+		hookLog = pipelineLog
+	}
+	var hookResults []model.HookResult
+	runAfterHooks := sync.OnceFunc(func() {
+		if flagDryRun {
+			return
+		}
+		// A fresh context, so teardown still runs after Ctrl-C
+		after, _ := runHooks(context.Background(), "after", mergedCfg.Hooks.After, mergedCfg.Defaults.Shell, projectRoot, hookLog, renderer)
+		hookResults = append(hookResults, after...)
+	})
+	defer runAfterHooks()
+
+	// hooks.before set up for every task, so a failing one stops the run
+	if !flagDryRun {
+		before, err := runHooks(ctx, "before", mergedCfg.Hooks.Before, mergedCfg.Defaults.Shell, projectRoot, hookLog, renderer)
+		hookResults = before
+		if err != nil {
+			code := exitConfigError
+			if ctx.Err() != nil {
+				code = exitInterrupted
+			}
+			fmt.Fprintf(os.Stderr, "ERROR: %v, not running any tasks\n\n", err)
+			runAfterHooks()
+			os.Exit(code)
+		}
+	}
+
 	// Setup animation if enabled
 	var tracker *ui.AnimatedTaskTracker
 
@@ -509,6 +948,32 @@ func main() {
 	// Group tasks into phases based on wait markers
 	phases := groupTasksIntoPhases(filteredTasks, phaseNames)
 
+	// Progress of the run for dashboards served by devpipe serve or --serve
+	var liveTaskIDs []string
+	for _, phase := range phases {
+		for _, st := range phase.Tasks {
+			liveTaskIDs = append(liveTaskIDs, st.ID)
+		}
+	}
+	live := dashboard.NewLiveRun(outputRoot, runID, liveTaskIDs)
+
+	if flagServe {
+		// Fall back to any free port when the default one is taken
+		url, err := startDashboardServer(outputRoot, defaultServePort)
+		if err != nil {
+			url, err = startDashboardServer(outputRoot, 0)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: failed to serve dashboard: %v\n", err)
+			flagServe = false
+		} else {
+			fmt.Printf("%sLive dashboard: %s\n", renderer.Icon("🌐"), url)
+			if err := dashboard.OpenBrowser(url); err != nil {
+				renderer.Verbose(flagVerbose, "Failed to open browser: %v", err)
+			}
+		}
+	}
+
 	if renderer.IsAnimated() {
 		// Build task progress list with phase information
 		var taskProgress []ui.TaskProgress
@@ -520,6 +985,7 @@ func main() {
 					Type:             st.Type,
 					Phase:            phaseIdx + 1, // 1-indexed phases
 					PhaseName:        phase.Name,
+					PhaseMaxParallel: phaseParallelLimit(phase, flagJobs, mergedCfg.Defaults.MaxParallel),
 					Status:           "PENDING",
 					EstimatedSeconds: st.EstimatedSeconds,
 					IsEstimateGuess:  st.IsEstimateGuess,
@@ -537,11 +1003,12 @@ func main() {
 
 		tracker = renderer.CreateAnimatedTracker(taskProgress, headerLines, mergedCfg.Defaults.AnimationRefreshMs, mergedCfg.Defaults.AnimatedGroupBy)
 		if tracker != nil {
+			tracker.SetCollapseCompleted(mergedCfg.Defaults.CollapseCompleted)
+			tracker.SetStyle(mergedCfg.Defaults.AnimationStyle)
 			if err := tracker.Start(); err != nil {
-				// Animation failed, fall back to non-animated
-				if flagVerbose {
-					fmt.Fprintf(os.Stderr, "WARNING: Animation not supported, using basic mode\n")
-				}
+				// Animation failed: every task streams through the plain sequential path instead
+				fmt.Fprintf(os.Stderr, "devpipe: dashboard unavailable (%v), showing plain output\n", err)
+				renderer.DisableAnimation()
 				tracker = nil
 			} else {
 				// Set tracker on renderer so verbose output can be routed
@@ -557,88 +1024,193 @@ func main() {
 	// Track total pipeline duration
 	pipelineStart := time.Now()
 
-	// Set git-related environment variables for all tasks
-	if gitInfo.InGitRepo {
-		_ = os.Setenv("DEVPIPE_GIT_MODE", gitInfo.Mode)
-		_ = os.Setenv("DEVPIPE_GIT_REF", gitInfo.Ref)
-		_ = os.Setenv("DEVPIPE_CHANGED_FILES_COUNT", fmt.Sprintf("%d", len(gitInfo.ChangedFiles)))
-
-		// Newline-separated list (handles spaces in filenames)
-		_ = os.Setenv("DEVPIPE_CHANGED_FILES", strings.Join(gitInfo.ChangedFiles, "\n"))
-
-		// JSON array (language-agnostic)
-		changedFilesJSON, _ := json.Marshal(gitInfo.ChangedFiles)
-		_ = os.Setenv("DEVPIPE_CHANGED_FILES_JSON", string(changedFilesJSON))
+	// Values available to task when conditions
+	condCtx := condition.Context{
+		Branch:       git.CurrentBranch(gitRoot),
+		ChangedFiles: len(gitInfo.ChangedFiles),
+		Env:          os.LookupEnv,
 	}
 
+	// Input hashes of tasks with cacheInputs
+	taskCache := cache.NewStore(outputRoot)
+
 	// Execute phases sequentially, tasks within each phase in parallel
 	var resultsMu sync.Mutex
 	var outputMu sync.Mutex // For sequential output display
 
-	for phaseIdx, phase := range phases {
-		// Log phase start
-		if len(phases) > 1 {
-			phaseName := phase.Name
-			if phaseName == "" {
-				phaseName = fmt.Sprintf("Phase %d", phaseIdx+1)
-			}
-			if tracker == nil {
-				fmt.Printf("\n▶ Starting %s (%d tasks)\n", phaseName, len(phase.Tasks))
-			}
-			renderer.Verbose(flagVerbose, "Phase %d/%d (%d tasks)", phaseIdx+1, len(phases), len(phase.Tasks))
-		}
+	// --fail-fast is the "task" mode of defaults.failFast
+	failFastMode := mergedCfg.Defaults.FailFast
+	if flagFailFast {
+		failFastMode = "task"
+	}
 
-		// Use errgroup for parallel execution within phase
-		g := new(errgroup.Group)
-		g.SetLimit(10) // Max 10 concurrent tasks
+	// Every task is launched up front and waits for its turn in the schedule: the start of its
+	// phase or, for a task with dependsOn while tasks run in parallel, its dependencies
+	sched := newSchedule(phases, tracker != nil)
+	phaseFailed := make([]bool, len(phases))
+	var phaseFailMu sync.Mutex
+	var tasksWG sync.WaitGroup
 
-		var phaseFailed bool
-		var phaseFailMu sync.Mutex
+	for phaseIdx, phase := range phases {
+		// Parallel tasks take a slot of their phase's limit while they run
+		slots := make(chan struct{}, phaseParallelLimit(phase, flagJobs, mergedCfg.Defaults.MaxParallel))
 
 		// For sequential output: each task gets a completion channel from the previous task
 		var prevTaskDone chan struct{}
 
+		// Tasks sharing a concurrency group take turns
+		turns := groupTurns{}
+
 		for _, st := range phase.Tasks {
-			// Check if should skip due to --fast
-			longRunning := st.EstimatedSeconds >= mergedCfg.Defaults.FastThreshold
-			if flagFast && longRunning && flagOnly == "" {
-				reason := fmt.Sprintf("skipped by --fast (est %ds)", st.EstimatedSeconds)
+			// Capture task for goroutine
+			task := st
+			phaseIdx := phaseIdx
 
-				// Update tracker if animated
-				if tracker != nil {
-					tracker.UpdateTask(st.ID, "SKIPPED", 0)
+			// Create a done channel for this task
+			taskDone := make(chan struct{})
+			waitForPrev := prevTaskDone
+			prevTaskDone = taskDone // Next task will wait for this one
+			waitForGroup, groupDone := turns.take(task.Group)
+
+			// passTurn lets the next task display its output when this one does not run
+			passTurn := func() {
+				if tracker == nil {
+					if waitForPrev != nil {
+						<-waitForPrev
+					}
+					close(taskDone)
 				}
+			}
 
-				renderer.RenderTaskSkipped(st.ID, reason, flagVerbose)
+			// addResult records a task's result for the run and for the tasks depending on it
+			addResult := func(res model.TaskResult) {
 				resultsMu.Lock()
-				results = append(results, model.TaskResult{
-					ID:               st.ID,
-					Name:             st.Name,
-					Desc:             st.Desc,
-					Phase:            st.Phase,
-					Type:             st.Type,
-					Status:           model.StatusSkipped,
-					Skipped:          true,
-					SkipReason:       "skipped by --fast",
-					Command:          st.Command,
-					Workdir:          st.Workdir,
-					LogPath:          "",
-					EstimatedSeconds: st.EstimatedSeconds,
-				})
+				results = append(results, res)
 				resultsMu.Unlock()
-				continue
+				sched.record(res.ID, res.Status)
 			}
 
-			// Capture task for goroutine
-			task := st
+			tasksWG.Add(1)
+			go func() {
+				defer tasksWG.Done()
+				final := true
+				defer func() { sched.ran(task.ID, final) }()
 
-			// Create a done channel for this task
-			taskDone := make(chan struct{})
-			waitForPrev := prevTaskDone
-			prevTaskDone = taskDone // Next task will wait for this one
+				sched.wait(task)
+				if waitForGroup != nil {
+					renderer.Verbose(flagVerbose, "%s Waiting for the previous task in group %q", task.ID, task.Group)
+					<-waitForGroup
+				}
+				if groupDone != nil {
+					defer close(groupDone)
+				}
+
+				// Stop launching tasks once interrupted or stopped by fail-fast
+				if ctx.Err() != nil || sched.stopped(phaseIdx) {
+					passTurn()
+					return
+				}
+
+				// Skip the task when a task it depends on failed
+				if dep, failed := sched.blockedBy(task); dep != "" {
+					reason := fmt.Sprintf("dependency %s was skipped", dep)
+					if failed {
+						reason = fmt.Sprintf("dependency %s failed", dep)
+					}
+					passTurn()
+					if tracker != nil {
+						tracker.UpdateTask(task.ID, "SKIPPED", 0)
+					}
+
+					renderer.RenderTaskSkipped(task.ID, reason, flagVerbose)
+					live.Set(task.ID, string(model.StatusSkipped))
+					sched.block(task.ID)
+					addResult(skippedResult(task, reason))
+					return
+				}
+
+				// Check if should skip due to --fast
+				longRunning := task.EstimatedSeconds >= mergedCfg.Defaults.FastThreshold
+				if flagFast && longRunning && flagOnly == "" {
+					reason := fmt.Sprintf("skipped by --fast (est %ds)", task.EstimatedSeconds)
+					passTurn()
+
+					// Update tracker if animated
+					if tracker != nil {
+						tracker.UpdateTask(task.ID, "SKIPPED", 0)
+					}
+
+					renderer.RenderTaskSkipped(task.ID, reason, flagVerbose)
+					live.Set(task.ID, string(model.StatusSkipped))
+					addResult(skippedResult(task, "skipped by --fast"))
+					return
+				}
+
+				// Check the task's when condition (syntax was checked during validation)
+				if task.When != "" {
+					if met, err := condition.Evaluate(task.When, condCtx); err != nil || !met {
+						passTurn()
+						if tracker != nil {
+							tracker.UpdateTask(task.ID, "SKIPPED", 0)
+						}
+
+						renderer.RenderTaskSkipped(task.ID, fmt.Sprintf("condition not met: %s", task.When), flagVerbose)
+						live.Set(task.ID, string(model.StatusSkipped))
+						addResult(skippedResult(task, "condition not met"))
+						return
+					}
+				}
+
+				// Check the task's onlyIf/skipIf files, which an earlier phase may have written
+				if reason := fileConditionSkip(task); reason != "" {
+					passTurn()
+					if tracker != nil {
+						tracker.UpdateTask(task.ID, "SKIPPED", 0)
+					}
+
+					renderer.RenderTaskSkipped(task.ID, reason, flagVerbose)
+					live.Set(task.ID, string(model.StatusSkipped))
+					addResult(skippedResult(task, reason))
+					return
+				}
+
+				// Reuse the last passing result when the task's cacheInputs are unchanged
+				var taskCacheHash string
+				if len(task.CacheInputs) > 0 {
+					hash, err := cache.HashInputs(task.Workdir, cacheCommand(task), task.CacheInputs)
+					if err != nil {
+						renderer.Verbose(flagVerbose, "%s Not using cache: %v", task.ID, err)
+					} else if entry, hit := taskCache.Lookup(task.ID, hash); hit && !flagNoCache {
+						passTurn()
+						if tracker != nil {
+							tracker.UpdateTask(task.ID, "PASS", 0)
+						}
+
+						renderer.RenderTaskCached(task.ID, entry.RunID, flagVerbose)
+						live.Set(task.ID, string(model.StatusPass))
+						addResult(cachedResult(task, entry.RunID))
+						return
+					} else {
+						taskCacheHash = hash
+					}
+				}
+
+				// Streamed output already runs one task at a time; parallel tasks share the phase's limit
+				if tracker != nil {
+					slots <- struct{}{}
+					defer func() { <-slots }()
+				}
+
+				live.Set(task.ID, dashboard.LiveRunning)
+				res, taskBuffer, _ := runTask(ctx, task, runDir, logDir, flagDryRun, flagVerbose, renderer, tracker, &outputMu, waitForPrev, taskDone)
+				live.Set(task.ID, string(res.Status))
 
-			g.Go(func() error {
-				res, taskBuffer, _ := runTask(task, runDir, logDir, flagDryRun, flagVerbose, renderer, tracker, &outputMu, waitForPrev, taskDone)
+				// Remember the inputs of a passing run so an unchanged task can be skipped next time
+				if taskCacheHash != "" && res.Status == model.StatusPass {
+					if err := taskCache.Save(task.ID, taskCacheHash, runID); err != nil {
+						renderer.Verbose(flagVerbose, "%s Failed to save cache entry: %v", task.ID, err)
+					}
+				}
 
 				// Display buffered output sequentially (always, even in animated mode)
 				if taskBuffer != nil && taskBuffer.Len() > 0 {
@@ -656,37 +1228,69 @@ func main() {
 					outputMu.Unlock()
 				}
 
-				resultsMu.Lock()
-				results = append(results, res)
-				resultsMu.Unlock()
+				addResult(res)
 
 				if res.Status == model.StatusFail {
 					phaseFailMu.Lock()
-					phaseFailed = true
+					phaseFailed[phaseIdx] = true
 					anyFailed = true
-					overallExitCode = 1
 					phaseFailMu.Unlock()
 
-					if flagFailFast {
+					if failFastMode == "task" {
 						if flagVerbose {
-							fmt.Printf("[%-15s] FAIL, stopping due to --fail-fast\n", task.ID)
+							fmt.Printf("%sFAIL, stopping due to fail-fast\n", renderer.Prefix(task.ID))
 						}
-						return fmt.Errorf("task %s failed", task.ID)
+						sched.stop()
+					}
+
+					// Dependents wait for the phase's auto-fix to settle the result
+					if fixesAfter(task) && !flagDryRun {
+						final = false
 					}
 				}
-				return nil
-			})
+			}()
 		}
+	}
 
-		// Wait for all tasks in this phase to complete
-		if err := g.Wait(); err != nil && flagFailFast {
-			// Fail-fast triggered, stop all phases
+	// Open the phases in order, running each phase's auto-fixes once its tasks have finished
+	for phaseIdx, phase := range phases {
+		// Stop once interrupted, or when fail-fast stopped the pipeline
+		if ctx.Err() != nil || sched.stopped(phaseIdx) {
 			break
 		}
 
-		// Auto-fix logic: check for failed tasks that have fixType="auto"
-		if !flagDryRun {
-			resultsMu.Lock()
+		// Log phase start
+		if len(phases) > 1 {
+			phaseName := phase.Name
+			if phaseName == "" {
+				phaseName = fmt.Sprintf("Phase %d", phaseIdx+1)
+			}
+			if tracker == nil {
+				start, _ := renderer.PhaseMarkers()
+				fmt.Printf("\n%s Starting %s (%d tasks)\n", start, phaseName, len(phase.Tasks))
+			}
+			renderer.Verbose(flagVerbose, "Phase %d/%d (%d tasks, max %d parallel)", phaseIdx+1, len(phases), len(phase.Tasks), phaseParallelLimit(phase, flagJobs, mergedCfg.Defaults.MaxParallel))
+		}
+
+		// Wait for all tasks in this phase to complete
+		parallelLimit := phaseParallelLimit(phase, flagJobs, mergedCfg.Defaults.MaxParallel)
+		sched.open(phaseIdx)
+		sched.waitPhase(phaseIdx)
+
+		phaseFailMu.Lock()
+		failed := phaseFailed[phaseIdx]
+		phaseFailMu.Unlock()
+		if failed && failFastMode == "task" {
+			// Fail-fast triggered, stop all phases
+			break
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		// Auto-fix logic: check for failed tasks that have fixType="auto"
+		if !flagDryRun {
+			resultsMu.Lock()
 			var tasksToFix []struct {
 				task   model.TaskDefinition
 				result model.TaskResult
@@ -694,12 +1298,12 @@ func main() {
 			}
 
 			// Find failed tasks in this phase that need fixing
-			for i := len(results) - len(phase.Tasks); i < len(results); i++ {
+			for _, i := range phaseResults(results, phase) {
 				res := results[i]
 				if res.Status == model.StatusFail {
 					// Find the corresponding task definition
 					for _, task := range phase.Tasks {
-						if task.ID == res.ID && task.FixType == "auto" && task.FixCommand != "" {
+						if task.ID == res.ID && fixesAfter(task) {
 							tasksToFix = append(tasksToFix, struct {
 								task   model.TaskDefinition
 								result model.TaskResult
@@ -715,7 +1319,7 @@ func main() {
 			// Run fixes in parallel (same as original tasks)
 			if len(tasksToFix) > 0 {
 				fixGroup := new(errgroup.Group)
-				fixGroup.SetLimit(10)
+				fixGroup.SetLimit(parallelLimit)
 
 				for _, item := range tasksToFix {
 					task := item.task
@@ -736,33 +1340,46 @@ func main() {
 						}()
 
 						// Run fix command and time it
-						fixCmd := exec.Command("sh", "-c", task.FixCommand)
-						fixCmd.Dir = task.Workdir
 						fixStart := time.Now()
 
-						// Capture output and write to log
-						fixCmd.Stdout = logFile
-						fixCmd.Stderr = logFile
+						// Capture output and write to log (in the task's log format)
+						var fixMu sync.Mutex
+						fixMasker, _ := redact.New(task.MaskPatterns) // Validated with the config
+						fixStdout := &lineWriter{taskID: task.ID, stream: "stdout", file: logFile, mu: &fixMu, renderer: renderer, logFormat: task.LogFormat, masker: fixMasker, sink: outputSink}
+						fixStderr := &lineWriter{taskID: task.ID, stream: "stderr", file: logFile, mu: &fixMu, renderer: renderer, logFormat: task.LogFormat, masker: fixMasker, sink: outputSink}
 
 						// Write separator to log
-						_, _ = fmt.Fprintf(logFile, "\n--- Auto-fix: %s ---\n", task.FixCommand) // Log write
+						_, _ = fmt.Fprintf(fixStdout, "\n--- Auto-fix: %s ---\n", task.FixCommand) // Log write
 
-						fixErr := fixCmd.Run()
+						_, _, fixErr := newExecutor(task).Run(ctx, fixTask(task), fixStdout, fixStderr)
+						fixStdout.flushLog()
+						fixStderr.flushLog()
 						fixDuration := time.Since(fixStart)
 
 						// Show fix message with timing
 						if tracker != nil {
 							tracker.UpdateTask(task.ID, "FIXING", 0)
 						} else {
-							fmt.Printf("[%-15s] 🔧 %s (%dms)\n", task.ID, renderer.Blue("Auto-fixing: "+task.FixCommand), fixDuration.Milliseconds())
+							fmt.Printf("%s%s%s (%dms)\n", renderer.Prefix(task.ID), renderer.Icon("🔧"), renderer.Blue("Auto-fixing: "+task.FixCommand), fixDuration.Milliseconds())
 						}
 
 						if fixErr != nil {
-							// Fix failed
+							// Fix failed; a missing tool gets its own message instead of a bare failure
+							message := "Failed to fix"
+							if missing := missingCommand(fixErr, hostCommand(task, task.FixCommand), task.Workdir); missing != "" {
+								message = "Failed to fix: fix command not found: " + missing
+								resultsMu.Lock()
+								results[resultIndex].FixCommand = task.FixCommand
+								results[resultIndex].FixNotFound = missing
+								resultsMu.Unlock()
+								_, _ = fmt.Fprintf(fixStderr, "devpipe: fix command not found: %s\n", missing) // Log write
+								fixStderr.flushLog()
+							}
 							if tracker != nil {
 								tracker.UpdateTask(task.ID, "FIX FAILED", 0)
+								tracker.AddLogLine(renderer.Prefix(task.ID) + message)
 							} else {
-								fmt.Printf("[%-15s] ❌ %s\n", task.ID, renderer.Red("Failed to fix"))
+								fmt.Printf("%s%s%s\n", renderer.Prefix(task.ID), renderer.Icon("❌"), renderer.Red(message))
 							}
 							return nil // Don't stop other fixes
 						}
@@ -771,20 +1388,25 @@ func main() {
 						if tracker != nil {
 							tracker.UpdateTask(task.ID, "RE-CHECKING", 0)
 						} else {
-							fmt.Printf("[%-15s] ✅ %s\n", task.ID, renderer.Green("Fix succeeded, re-checking..."))
+							fmt.Printf("%s%s%s\n", renderer.Prefix(task.ID), renderer.Icon("✅"), renderer.Green("Fix succeeded, re-checking..."))
 						}
 
 						// Write separator to log
-						_, _ = fmt.Fprintf(logFile, "\n--- Re-check: %s ---\n", task.Command) // Log write
+						_, _ = fmt.Fprintf(fixStdout, "\n--- Re-check: %s ---\n", task.Command) // Log write
 
 						// Re-run original command
-						recheckCmd := exec.Command("sh", "-c", task.Command)
-						recheckCmd.Dir = task.Workdir
-						recheckCmd.Stdout = logFile
-						recheckCmd.Stderr = logFile
 						recheckStart := time.Now()
-						recheckErr := recheckCmd.Run()
+						recheckMatch := newOutputMatch(task)
+						fixStdout.match, fixStderr.match = recheckMatch, recheckMatch
+						recheckCode, _, recheckErr := newExecutor(task).Run(ctx, task, fixStdout, fixStderr)
+						fixStdout.flushLog()
+						fixStderr.flushLog()
 						recheckDuration := time.Since(recheckStart)
+						if recheckErr != nil && recheckCode >= 0 && exitCodeAllowed(recheckCode, task.AllowExitCodes) {
+							recheckErr = nil
+						}
+						var recheckResult model.TaskResult
+						recheckErr = recheckMatch.apply(recheckCode, recheckErr, &recheckResult)
 
 						// Calculate total time: original check + fix + recheck
 						totalDuration := time.Duration(originalResult.DurationMs)*time.Millisecond + fixDuration + recheckDuration
@@ -802,28 +1424,30 @@ func main() {
 							results[resultIndex].InitialExitCode = originalResult.ExitCode
 							results[resultIndex].FixDurationMs = fixDuration.Milliseconds()
 							results[resultIndex].RecheckDurationMs = recheckDuration.Milliseconds()
+							results[resultIndex].FailedOnMatch = ""
+							results[resultIndex].PassedOnMatch = recheckResult.PassedOnMatch
+							results[resultIndex].MatchedLine = recheckResult.MatchedLine
 
 							// Update phase failure status
 							phaseFailMu.Lock()
 							// Recount failures in this phase
 							phaseStillFailed := false
-							for i := len(results) - len(phase.Tasks); i < len(results); i++ {
+							for _, i := range phaseResults(results, phase) {
 								if results[i].Status == model.StatusFail {
 									phaseStillFailed = true
 									break
 								}
 							}
-							phaseFailed = phaseStillFailed
+							phaseFailed[phaseIdx] = phaseStillFailed
 							if !phaseStillFailed {
 								anyFailed = false
-								overallExitCode = 0
 							}
 							phaseFailMu.Unlock()
 
 							if tracker != nil {
 								tracker.UpdateTask(task.ID, "PASS", recheckDuration.Seconds())
 							} else {
-								fmt.Printf("[%-15s] ✅ %s (%dms)\n", task.ID, renderer.Green("PASS"), recheckDuration.Milliseconds())
+								fmt.Printf("%s%s%s (%dms)\n", renderer.Prefix(task.ID), renderer.Icon("✅"), renderer.Green("PASS"), recheckDuration.Milliseconds())
 							}
 						} else {
 							// Still failing after fix
@@ -835,7 +1459,7 @@ func main() {
 							if tracker != nil {
 								tracker.UpdateTask(task.ID, "STILL FAILING", recheckDuration.Seconds())
 							} else {
-								fmt.Printf("[%-15s] ❌ %s\n", task.ID, renderer.Red("Still failing after fix"))
+								fmt.Printf("%s%s%s\n", renderer.Prefix(task.ID), renderer.Icon("❌"), renderer.Red("Still failing after fix"))
 							}
 						}
 						resultsMu.Unlock()
@@ -849,13 +1473,13 @@ func main() {
 
 			// Show helper messages for failed tasks with fixType="helper"
 			resultsMu.Lock()
-			for i := len(results) - len(phase.Tasks); i < len(results); i++ {
+			for _, i := range phaseResults(results, phase) {
 				res := results[i]
-				if res.Status == model.StatusFail {
+				if res.Status == model.StatusFail || res.Status == model.StatusWarn {
 					for _, task := range phase.Tasks {
 						if task.ID == res.ID && task.FixType == "helper" && task.FixCommand != "" {
 							if tracker == nil {
-								fmt.Printf("[%-15s] 💡 %s\n", task.ID, renderer.Yellow("To fix run: "+task.FixCommand))
+								fmt.Printf("%s%s%s\n", renderer.Prefix(task.ID), renderer.Icon("💡"), renderer.Yellow("To fix run: "+task.FixCommand))
 							}
 							break
 						}
@@ -865,6 +1489,14 @@ func main() {
 			resultsMu.Unlock()
 		}
 
+		// Tasks depending on this phase's tasks can now see their final results
+		resultsMu.Lock()
+		for _, i := range phaseResults(results, phase) {
+			sched.record(results[i].ID, results[i].Status)
+		}
+		resultsMu.Unlock()
+		sched.settle(phaseIdx)
+
 		// Log phase completion
 		if len(phases) > 1 {
 			phaseName := phase.Name
@@ -872,69 +1504,138 @@ func main() {
 				phaseName = fmt.Sprintf("Phase %d", phaseIdx+1)
 			}
 			phaseFailMu.Lock()
-			status := "✓ Complete"
-			if phaseFailed {
-				status = "✗ Failed"
+			status := renderer.Symbol("PASS") + " Complete"
+			if phaseFailed[phaseIdx] {
+				status = renderer.Symbol("FAIL") + " Failed"
 			}
 			phaseFailMu.Unlock()
 			if tracker == nil {
-				fmt.Printf("◀ %s %s\n", phaseName, status)
+				_, end := renderer.PhaseMarkers()
+				fmt.Printf("%s %s %s\n", end, phaseName, status)
 			}
 		}
 
-		// If phase failed and fail-fast is enabled, stop
+		// If phase failed and fail-fast applies to it, skip the remaining phases
 		phaseFailMu.Lock()
-		shouldStop := phaseFailed && flagFailFast
+		shouldStop := phaseFailed[phaseIdx] && phaseFailFast(phase, failFastMode)
 		phaseFailMu.Unlock()
 
 		if shouldStop {
 			if tracker == nil && len(phases) > 1 {
-				fmt.Printf("\n⚠ Stopping execution due to phase failure (fail-fast enabled)\n")
+				fmt.Printf("\n%s Stopping execution due to phase failure (fail-fast enabled)\n", renderer.Symbol("WARN"))
 			}
+			sched.stop()
 			break
 		}
 	}
 
+	// Let the tasks still waiting for their turn see the pipeline has stopped, and wait for
+	// any that were already running
+	sched.release()
+	tasksWG.Wait()
+
 	// Calculate total pipeline duration BEFORE the pause
 	pipelineDuration := time.Since(pipelineStart)
 	totalMs := pipelineDuration.Milliseconds()
 
+	// Reports are still written after an interrupt; a further signal now exits immediately
+	interrupted := ctx.Err() != nil
+	stopSignals()
+
 	// Stop animation if it was running
 	if tracker != nil {
 		// Do a final render to show completed state
 		time.Sleep(100 * time.Millisecond)
 
 		tracker.Stop()
-
+	}
+	if interrupted {
+		fmt.Println()
+		fmt.Println(renderer.Yellow(renderer.Symbol("WARN") + " Interrupted: running tasks were stopped and the remaining tasks were not run"))
+	}
+	runAfterHooks()
+	if !interrupted && tracker != nil {
 		// Show completion message and wait for user input
-		fmt.Print(renderer.Green("✓ Done") + " - Press Enter to continue...")
+		fmt.Print(renderer.Green(renderer.Symbol("PASS")+" Done") + " - Press Enter to continue...")
 
 		// Wait for Enter key
 		_, _ = fmt.Scanln() // Best effort wait for user
 	}
 
+	// Results arrive in completion order; record and report them in config order (phases, then
+	// tasks within a phase) so run.json and the summary are the same from run to run. Cached
+	// results from --resume are reported alongside the tasks that ran
+	results = orderResultsByTasks(append(results, cachedResults...), taskDefs)
+	setLogPreviewLines(results, taskDefs)
+
 	// Render summary
 	var summaries []ui.TaskSummary
 	for _, r := range results {
 		summaries = append(summaries, ui.TaskSummary{
-			ID:         r.ID,
-			Status:     string(r.Status),
-			DurationMs: r.DurationMs,
-			AutoFixed:  r.AutoFixed,
+			ID:               r.ID,
+			Status:           string(r.Status),
+			DurationMs:       r.DurationMs,
+			AutoFixed:        r.AutoFixed,
+			NoOutput:         r.NoOutput,
+			CachedFrom:       r.CachedFrom,
+			Interrupted:      r.Interrupted,
+			EstimatedSeconds: r.EstimatedSeconds,
 		})
 	}
-	renderer.RenderSummary(summaries, anyFailed, totalMs)
+	if !flagFlatSummary {
+		var summaryPhases []ui.SummaryPhase
+		for _, phase := range phases {
+			var ids []string
+			for _, t := range phase.Tasks {
+				ids = append(ids, t.ID)
+			}
+			summaryPhases = append(summaryPhases, ui.SummaryPhase{Name: phase.Name, TaskIDs: ids})
+		}
+		renderer.SetSummaryPhases(summaryPhases)
+	}
+	if !flagGitHubOnly {
+		renderer.RenderSummary(summaries, anyFailed, totalMs)
+
+		// Repeat the end of each failed task's log, unless --quiet already showed its output
+		if n := *mergedCfg.Defaults.FailureTailLines; n > 0 && !flagNoShowFailures && !renderer.IsQuiet() {
+			renderer.RenderFailureTails(failureTails(results, n))
+		}
+	}
+
+	// GitHub Actions annotations for failed tasks
+	if githubAnnotationsEnabled(flagGitHub || flagGitHubOnly) {
+		printTaskAnnotations(results)
+	}
+
+	summary := report.Summarize(runID, results, totalMs)
+	summary.Branch, summary.Commit = condCtx.Branch, git.HeadCommit(gitRoot)
+
+	// Desktop notification (best effort, never affects the exit code)
+	if flagNotify || mergedCfg.Defaults.Notify {
+		sendCompletionNotification(summary)
+	}
 
 	// Show where to find logs and reports
 	fmt.Println()
-	fmt.Printf("📁 Run logs:  %s\n", filepath.Join(outputRoot, "runs", runID, "logs"))
-	fmt.Printf("📊 Dashboard: %s\n", filepath.Join(outputRoot, "report.html"))
+	fmt.Printf("%sRun logs:  %s\n", renderer.Icon("📁"), filepath.Join(outputRoot, "runs", runID, "logs"))
+	fmt.Printf("%sDashboard: %s\n", renderer.Icon("📊"), filepath.Join(outputRoot, "report.html"))
+
+	// Write any requested summary files (all formats derive from the same results)
+	writeSummaryReports(renderer, summary, flagJUnitOut, flagMarkdownOut, flagJSONOut)
+	if flagMetricsOut != "" {
+		if err := promexport.WriteFile(flagMetricsOut, results, totalMs); err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: failed to write Prometheus metrics: %v\n", err)
+		} else {
+			fmt.Printf("%sPrometheus metrics: %s\n", renderer.Icon("📄"), flagMetricsOut)
+		}
+	}
 
 	// Build effective config tracking
-	effectiveConfig := buildEffectiveConfig(cfg, &mergedCfg, flagSince, flagUI, uiModeStr, gitMode, gitRef, historicalAvg)
+	effectiveConfig := config.BuildEffectiveConfig(cfg, &mergedCfg, flagSince, flagUI, uiModeStr, gitMode, gitRef)
+	setConfigSources(effectiveConfig, flagConfig, configPath, flagProfile)
 
 	// Determine the actual config path used
-	actualConfigPath := flagConfig
+	actualConfigPath := configPath
 	if actualConfigPath == "" {
 		// Check if default config.toml exists
 		if _, err := os.Stat("config.toml"); err == nil {
@@ -953,64 +1654,242 @@ func main() {
 		PipelineVersion: version, // Version used to run the pipeline
 		Git:             gitInfo,
 		Flags: model.RunFlags{
-			Fast:     flagFast,
-			FailFast: flagFailFast,
-			DryRun:   flagDryRun,
-			Verbose:  flagVerbose,
-			Only:     flagOnly,
-			Skip:     flagSkipVals,
-			Config:   flagConfig,
-			Since:    flagSince,
+			Fast:        flagFast,
+			FailFast:    flagFailFast,
+			DryRun:      flagDryRun,
+			Verbose:     flagVerbose,
+			Only:        flagOnly,
+			Skip:        flagSkipVals,
+			Tags:        splitFlagValues(flagTags),
+			ExcludeTags: splitFlagValues(flagExcludeTags),
+			Config:      flagConfig,
+			Since:       flagSince,
+			Resume:      string(flagResume),
 		},
 		Tasks:           results,
+		Interrupted:     interrupted,
+		Labels:          runLabels,
+		Hooks:           hookResults,
 		EffectiveConfig: effectiveConfig,
 	}
 	if err := writeRunJSON(runDir, runRecord); err != nil {
 		fmt.Fprintf(os.Stderr, "WARNING: failed to write run record: %v\n", err)
 	}
 
-	// Copy config file to run directory
-	if err := copyConfigToRun(runDir, flagConfig, &mergedCfg); err != nil {
+	// Copy config file to run directory (devpipe run records the config with its tasks instead)
+	copyConfig := func() error { return copyConfigToRun(runDir, configPath, &mergedCfg) }
+	if adHoc {
+		copyConfig = func() error { return writeMergedConfig(runDir, &mergedCfg) }
+	}
+	if err := copyConfig(); err != nil {
 		if flagVerbose {
 			fmt.Fprintf(os.Stderr, "WARNING: failed to copy config: %v\n", err)
 		}
 	}
 
 	// Generate dashboard (only generate report for current run)
-	if err := dashboard.GenerateDashboardWithOptions(outputRoot, version, false, runID); err != nil {
+	if err := dashboard.GenerateDashboardWithOptions(outputRoot, version, false, mergedCfg.Defaults.DashboardRecentLimit, runID, mergedCfg.Defaults.FlakyThreshold); err != nil {
 		fmt.Fprintf(os.Stderr, "WARNING: failed to generate dashboard: %v\n", err)
 	}
+	live.Finish(results)
+
+	// Show the report just generated, unless nobody is there to see it
+	if (flagOpen || mergedCfg.Defaults.OpenReport) && !flagServe {
+		runReport := filepath.Join(outputRoot, "runs", runID, "report.html")
+		if dashboard.Headless(runtime.GOOS, os.Getenv, ui.IsTTY(uintptr(1))) {
+			renderer.Verbose(flagVerbose, "Not opening the report: no browser available (CI, no display, or not a terminal)")
+		} else if err := dashboard.OpenBrowser(runReport); err != nil {
+			renderer.Verbose(flagVerbose, "Failed to open report: %v", err)
+		}
+	}
 
 	// Final cursor restoration (belt and suspenders)
 	fmt.Print("\033[?25h")
 
-	os.Exit(overallExitCode)
+	// Keep serving the finished report until interrupted; ctx is already cancelled by now, so
+	// wait on a fresh signal handler. An interrupted run exits straight away
+	if flagServe && !interrupted {
+		fmt.Printf("%sStill serving the dashboard, press Ctrl-C to stop\n", renderer.Icon("🌐"))
+		waitForInterrupt()
+	}
+
+	os.Exit(runExitCode(results, interrupted, mergedCfg.Defaults.AdvisoryExitCode, flagExitZero))
 }
 
-// loadHistoricalAverages loads task averages from the dashboard summary
-func loadHistoricalAverages(outputRoot string) map[string]int {
-	averages := make(map[string]int)
+// runExitCode decides how a finished run exits: interrupted, then failed tasks, then failed
+// advisory (continueOnError) tasks, which exit with advisoryCode. --exit-zero reports task
+// failures without failing the run
+func runExitCode(results []model.TaskResult, interrupted bool, advisoryCode int, exitZero bool) int {
+	if interrupted {
+		return exitInterrupted
+	}
+	if exitZero {
+		return exitOK
+	}
+	code := exitOK
+	for _, res := range results {
+		switch res.Status {
+		case model.StatusFail:
+			return exitTaskFailed
+		case model.StatusWarn:
+			code = advisoryCode
+		}
+	}
+	return code
+}
 
-	summaryPath := filepath.Join(outputRoot, "summary.json")
-	data, err := os.ReadFile(summaryPath)
-	if err != nil {
-		return averages // No history yet
+// runHooks runs the hooks.before or hooks.after commands of stage in order through shell from
+// dir, appending their output to log. A failing before hook stops the rest and is returned;
+// after hooks are teardown, so each one runs and failures are only reported
+func runHooks(ctx context.Context, stage string, commands, shell []string, dir string, log io.Writer, renderer *ui.Renderer) ([]model.HookResult, error) {
+	var results []model.HookResult
+	if len(commands) > 0 {
+		defer fmt.Println()
 	}
+	for _, command := range commands {
+		fmt.Printf("%s%s %s\n", renderer.Icon("🪝"), renderer.Blue(stage+" hook:"), command)
+		_, _ = fmt.Fprintf(log, "\n--- %s hook: %s ---\n", stage, command) // Log write
+
+		var output bytes.Buffer
+		w := io.MultiWriter(log, &output)
+		start := time.Now()
+		hook := model.TaskDefinition{ID: stage + "-hook", Command: command, Shell: shell, Workdir: dir}
+		exitCode, _, err := ShellExecutor{}.Run(ctx, hook, w, w)
+		results = append(results, model.HookResult{Stage: stage, Command: command, ExitCode: exitCode, DurationMs: time.Since(start).Milliseconds()})
+		if err == nil {
+			continue
+		}
+
+		// The output is only in pipeline.log otherwise, so show why it failed
+		_, _ = os.Stderr.Write(output.Bytes())
+		err = fmt.Errorf("%s hook failed: %s: %w", stage, command, err)
+		if stage == "before" {
+			return results, err
+		}
+		fmt.Fprintf(os.Stderr, "WARNING: %v\n", err)
+	}
+	return results, nil
+}
+
+// writeSummaryReports writes the run summary to each requested output path
+// Failures are reported as warnings so a bad path never changes the run's exit code
+func writeSummaryReports(renderer *ui.Renderer, summary report.Summary, junitOut, markdownOut, jsonOut string) {
+	outputs := []struct {
+		label string
+		path  string
+		write func(io.Writer, report.Summary) error
+	}{
+		{"JUnit", junitOut, report.WriteJUnit},
+		{"Markdown", markdownOut, report.WriteMarkdown},
+		{"JSON", jsonOut, report.WriteJSON},
+	}
+
+	for _, out := range outputs {
+		if out.path == "" {
+			continue
+		}
+		if err := report.WriteFile(out.path, summary, out.write); err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: failed to write %s summary: %v\n", out.label, err)
+			continue
+		}
+		fmt.Printf("%s%s summary: %s\n", renderer.Icon("📄"), out.label, out.path)
+	}
+}
+
+// failureTails returns the last n lines of each failed task's log, in result order, leaving out
+// trailing blank lines and tasks without a readable log
+func failureTails(results []model.TaskResult, n int) []ui.FailureTail {
+	var tails []ui.FailureTail
+	for _, r := range results {
+		if r.Status != model.StatusFail || r.LogPath == "" {
+			continue
+		}
+		lines, err := tasklog.ReadLines(r.LogPath, r.LogFormat)
+		if err != nil {
+			continue
+		}
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		if len(lines) > n {
+			lines = lines[len(lines)-n:]
+		}
+		for i, line := range lines {
+			lines[i] = sanitize.Plain(line)
+		}
+		tails = append(tails, ui.FailureTail{ID: r.ID, LogPath: r.LogPath, Lines: lines})
+	}
+	return tails
+}
+
+// sendCompletionNotification fires a desktop notification summarizing the run
+func sendCompletionNotification(summary report.Summary) {
+	title := "devpipe: all tasks passed"
+	if summary.FailCount > 0 {
+		title = "devpipe: one or more tasks failed"
+	}
+	message := fmt.Sprintf("%d passed, %d failed, %d skipped in %.1fs",
+		summary.PassCount, summary.FailCount, summary.SkipCount, float64(summary.TotalMs)/1000.0)
 
-	var summary struct {
-		TaskStats map[string]struct {
-			AvgDuration float64 `json:"avgDuration"`
-		} `json:"taskStats"`
+	if err := notify.Send(title, message, summary.FailCount > 0); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: failed to send notification: %v\n", err)
 	}
+}
+
+// dashboardMode decides whether --dashboard animates. The dashboard needs a terminal on stdout,
+// so elsewhere (CI logs, pipes) it degrades to plain sequential output with a one-line notice
+func dashboardMode(requested, quiet, tty bool) (bool, string) {
+	switch {
+	case !requested || quiet:
+		return false, ""
+	case !tty:
+		return false, "devpipe: --dashboard needs a terminal, showing plain output"
+	default:
+		return true, ""
+	}
+}
 
-	if err := json.Unmarshal(data, &summary); err != nil {
-		return averages
+// readSummary reads the dashboard summary, rebuilding it from the run history when it is
+// corrupt. Problems other than there being no history yet are reported to warn, when not nil
+func readSummary(outputRoot string, warn io.Writer) dashboard.Summary {
+	summary, repaired, err := dashboard.LoadSummary(outputRoot, version)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// No history yet
+	case err != nil:
+		if warn != nil {
+			fmt.Fprintf(warn, "WARNING: cannot read summary.json, duration estimates are unavailable: %v\n", err)
+		}
+	case repaired && warn != nil:
+		fmt.Fprintf(warn, "WARNING: %s was corrupt, rebuilt it from the run history\n", filepath.Join(outputRoot, "summary.json"))
 	}
+	return summary
+}
+
+// loadHistoricalAverages loads task duration estimates (in seconds) from the dashboard
+// summary. stat selects the statistic: "mean" (default), "p95" or "max". Problems reading
+// the summary are reported to warn, when not nil
+func loadHistoricalAverages(outputRoot, stat string, warn io.Writer) map[string]int {
+	averages := make(map[string]int)
 
 	// Convert milliseconds to seconds
-	for taskID, stats := range summary.TaskStats {
-		if stats.AvgDuration > 0 {
-			avgSeconds := int(stats.AvgDuration / 1000)
+	for taskID, stats := range readSummary(outputRoot, warn).TaskStats {
+		durationMs := stats.AvgDuration
+		switch stat {
+		case "p95":
+			durationMs = float64(stats.P95Duration)
+		case "max":
+			durationMs = float64(stats.MaxDuration)
+		}
+		// Summaries written before p95/max were recorded fall back to the mean
+		if durationMs <= 0 {
+			durationMs = stats.AvgDuration
+		}
+		if durationMs > 0 {
+			avgSeconds := int(durationMs / 1000)
 			if avgSeconds < 1 {
 				avgSeconds = 1
 			}
@@ -1048,114 +1927,43 @@ func containsSpace(s string) bool {
 	return false
 }
 
-// buildEffectiveConfig creates a detailed breakdown of configuration values and their sources
-func buildEffectiveConfig(cfg *config.Config, mergedCfg *config.Config, flagSince, flagUI, uiModeStr, gitMode, gitRef string, _ map[string]int) *model.EffectiveConfig {
-	defaults := config.GetDefaults()
-	var values []model.ConfigValue
-
-	// Helper to add a config value
-	addValue := func(key, value, source, overrode string) {
-		values = append(values, model.ConfigValue{
-			Key:      key,
-			Value:    value,
-			Source:   source,
-			Overrode: overrode,
-		})
-	}
-
-	// Output Root
-	if cfg != nil && cfg.Defaults.OutputRoot != "" {
-		addValue("defaults.outputRoot", mergedCfg.Defaults.OutputRoot, "config-file", "")
-	} else {
-		addValue("defaults.outputRoot", mergedCfg.Defaults.OutputRoot, "default", "")
-	}
-
-	// Fast Threshold
-	if cfg != nil && cfg.Defaults.FastThreshold != 0 {
-		addValue("defaults.fastThreshold", fmt.Sprintf("%d", mergedCfg.Defaults.FastThreshold), "config-file", "")
-	} else {
-		addValue("defaults.fastThreshold", fmt.Sprintf("%d", mergedCfg.Defaults.FastThreshold), "default", "")
-	}
-
-	// UI Mode
-	var uiSource, uiOverrode string
-	if flagUI != "basic" {
-		uiSource = "cli-flag"
-		if cfg != nil && cfg.Defaults.UIMode != "" {
-			uiOverrode = cfg.Defaults.UIMode
-		} else {
-			uiOverrode = defaults.Defaults.UIMode
-		}
-	} else if cfg != nil && cfg.Defaults.UIMode != "" {
-		uiSource = "config-file"
-	} else {
-		uiSource = "default"
-	}
-	addValue("defaults.uiMode", uiModeStr, uiSource, uiOverrode)
-
-	// Animation Refresh
-	if cfg != nil && cfg.Defaults.AnimationRefreshMs != 0 {
-		addValue("defaults.animationRefreshMs", fmt.Sprintf("%d", mergedCfg.Defaults.AnimationRefreshMs), "config-file", "")
-	} else {
-		addValue("defaults.animationRefreshMs", fmt.Sprintf("%d", mergedCfg.Defaults.AnimationRefreshMs), "default", "")
-	}
+// Phase represents a group of tasks that can run in parallel
+type Phase struct {
+	Tasks       []model.TaskDefinition
+	Name        string // Display name for the phase
+	MaxParallel *int   // Per-phase parallelism limit from the phase header, if set
+	FailFast    *bool  // Per-phase fail-fast marker from the phase header, if set
+	Barrier     bool   // No task of this or a later phase starts before the earlier phases finish
+}
 
-	// Git Mode
-	var gitModeSource, gitModeOverrode string
-	if flagSince != "" {
-		gitModeSource = "cli-flag"
-		if cfg != nil && cfg.Defaults.Git.Mode != "" {
-			gitModeOverrode = cfg.Defaults.Git.Mode
-		} else {
-			gitModeOverrode = defaults.Defaults.Git.Mode
+// removeMarkers deletes the phase header and wait marker pseudo-tasks from tasks, returning the
+// ids of the wait markers among them (the task order still has every marker)
+func removeMarkers(tasks map[string]config.TaskConfig) map[string]bool {
+	written := make(map[string]bool)
+	for id := range tasks {
+		if id == "wait" || strings.HasPrefix(id, "wait-") {
+			written[id] = true
 		}
-	} else if cfg != nil && cfg.Defaults.Git.Mode != "" {
-		gitModeSource = "config-file"
-	} else {
-		gitModeSource = "default"
-	}
-	addValue("defaults.git.mode", gitMode, gitModeSource, gitModeOverrode)
-
-	// Git Ref
-	var gitRefSource, gitRefOverrode string
-	if flagSince != "" {
-		gitRefSource = "cli-flag"
-		if cfg != nil && cfg.Defaults.Git.Ref != "" {
-			gitRefOverrode = cfg.Defaults.Git.Ref
-		} else {
-			gitRefOverrode = defaults.Defaults.Git.Ref
+		if written[id] || strings.HasPrefix(id, "phase-") {
+			delete(tasks, id)
 		}
-	} else if cfg != nil && cfg.Defaults.Git.Ref != "" {
-		gitRefSource = "config-file"
-	} else {
-		gitRefSource = "default"
-	}
-	addValue("defaults.git.ref", gitRef, gitRefSource, gitRefOverrode)
-
-	// Task Defaults
-	if cfg != nil && cfg.TaskDefaults.Enabled != nil {
-		addValue("task_defaults.enabled", fmt.Sprintf("%t", *mergedCfg.TaskDefaults.Enabled), "config-file", "")
-	} else {
-		addValue("task_defaults.enabled", fmt.Sprintf("%t", *mergedCfg.TaskDefaults.Enabled), "default", "")
 	}
+	return written
+}
 
-	if cfg != nil && cfg.TaskDefaults.Workdir != "" {
-		addValue("task_defaults.workdir", mergedCfg.TaskDefaults.Workdir, "config-file", "")
-	} else {
-		addValue("task_defaults.workdir", mergedCfg.TaskDefaults.Workdir, "default", "")
+// markWait marks the last task before the wait marker id as a wait point (end of phase). Wait
+// markers written in the config, unlike the ones phase headers add, are barriers dependsOn
+// cannot cross
+func markWait(taskDefs []model.TaskDefinition, id string, written map[string]bool) {
+	if len(taskDefs) == 0 {
+		return
 	}
-
-	return &model.EffectiveConfig{
-		Values: values,
+	taskDefs[len(taskDefs)-1].Wait = true
+	if written[id] {
+		taskDefs[len(taskDefs)-1].Barrier = true
 	}
 }
 
-// Phase represents a group of tasks that can run in parallel
-type Phase struct {
-	Tasks []model.TaskDefinition
-	Name  string // Display name for the phase
-}
-
 // groupTasksIntoPhases splits tasks into phases based on wait markers
 func groupTasksIntoPhases(tasks []model.TaskDefinition, phaseNames map[string]config.PhaseInfo) []Phase {
 	if len(tasks) == 0 {
@@ -1165,6 +1973,7 @@ func groupTasksIntoPhases(tasks []model.TaskDefinition, phaseNames map[string]co
 	var phases []Phase
 	currentPhase := Phase{Tasks: []model.TaskDefinition{}}
 	phaseNum := 1
+	barrier := false // The previous phase ended with a wait marker
 
 	for _, task := range tasks {
 		currentPhase.Tasks = append(currentPhase.Tasks, task)
@@ -1178,10 +1987,14 @@ func groupTasksIntoPhases(tasks []model.TaskDefinition, phaseNames map[string]co
 			} else {
 				currentPhase.Name = fmt.Sprintf("Phase %d", phaseNum)
 			}
+			currentPhase.MaxParallel = phaseNames[phaseKey].MaxParallel
+			currentPhase.FailFast = phaseNames[phaseKey].FailFast
+			currentPhase.Barrier = barrier || phaseNames[phaseKey].Barrier
 
 			phases = append(phases, currentPhase)
 			currentPhase = Phase{Tasks: []model.TaskDefinition{}}
 			phaseNum++
+			barrier = task.Barrier
 		}
 	}
 
@@ -1194,1386 +2007,4053 @@ func groupTasksIntoPhases(tasks []model.TaskDefinition, phaseNames map[string]co
 		} else {
 			currentPhase.Name = fmt.Sprintf("Phase %d", phaseNum)
 		}
+		currentPhase.MaxParallel = phaseNames[phaseKey].MaxParallel
+		currentPhase.FailFast = phaseNames[phaseKey].FailFast
+		currentPhase.Barrier = barrier || phaseNames[phaseKey].Barrier
 		phases = append(phases, currentPhase)
 	}
 
 	return phases
 }
 
-func filterTasks(tasks []model.TaskDefinition, only string, skip sliceFlag, _ bool, _ int, verbose bool) []model.TaskDefinition {
-	skipSet := map[string]struct{}{}
-	for _, id := range skip {
-		skipSet[id] = struct{}{}
+// phaseFailFast reports whether a failure in phase stops the remaining phases
+// "task" mode (--fail-fast) always stops; otherwise the phase header's failFast wins
+// over defaults.failFast = "phase"
+func phaseFailFast(phase Phase, mode string) bool {
+	if mode == "task" {
+		return true
 	}
+	if phase.FailFast != nil {
+		return *phase.FailFast
+	}
+	return mode == "phase"
+}
 
-	var out []model.TaskDefinition
-	if only != "" {
-		// Parse comma-separated list from --only
-		rawIDs := strings.Split(only, ",")
-		var requested []string
-		seen := make(map[string]struct{})
-		for _, raw := range rawIDs {
-			id := strings.TrimSpace(raw)
-			if id == "" {
-				continue
-			}
-			if _, dup := seen[id]; dup {
-				continue
-			}
-			seen[id] = struct{}{}
-			requested = append(requested, id)
-		}
-
-		// Index tasks by ID for validation
-		taskIndex := make(map[string]model.TaskDefinition, len(tasks))
-		for _, s := range tasks {
-			taskIndex[s.ID] = s
-		}
+// fixesBefore reports whether st runs its auto fix ahead of the check (fixMode = "before")
+func fixesBefore(st model.TaskDefinition) bool {
+	return st.FixType == "auto" && st.FixCommand != "" && st.FixMode == "before"
+}
 
-		// Validate all requested IDs exist
-		for _, id := range requested {
-			if _, ok := taskIndex[id]; !ok {
-				fmt.Fprintf(os.Stderr, "ERROR: --only task id %q not found\n", id)
-				os.Exit(1)
-			}
-		}
+// fixesAfter reports whether a failure of st is auto-fixed and re-checked after its phase
+func fixesAfter(st model.TaskDefinition) bool {
+	return st.FixType == "auto" && st.FixCommand != "" && st.FixMode != "before"
+}
 
-		// Build a set of requested IDs to filter by, then
-		// walk the full task list in pipeline order and
-		// keep only requested tasks that are not skipped.
-		requestedSet := make(map[string]struct{}, len(requested))
-		for _, id := range requested {
-			requestedSet[id] = struct{}{}
-		}
+// phaseParallelLimit resolves how many tasks of a phase may run at once
+// Priority: --jobs flag (when >= 0), phase header maxParallel, defaults.maxParallel
+// Values below 1 run the phase strictly sequentially
+func phaseParallelLimit(phase Phase, jobs int, defaultMax *int) int {
+	limit := 10
+	switch {
+	case jobs >= 0:
+		limit = jobs
+	case phase.MaxParallel != nil:
+		limit = *phase.MaxParallel
+	case defaultMax != nil:
+		limit = *defaultMax
+	}
+	if limit < 1 {
+		limit = 1
+	}
+	return limit
+}
 
-		for _, s := range tasks {
-			if _, want := requestedSet[s.ID]; !want {
-				continue
-			}
-			if _, skip := skipSet[s.ID]; skip {
-				if verbose {
-					fmt.Printf("[%-15s] SKIP requested by --skip\n", s.ID)
-				}
-				continue
-			}
-			out = append(out, s)
+// exitCodeAllowed reports whether a task exit code counts as success
+func exitCodeAllowed(code int, allowed []int) bool {
+	if code == 0 {
+		return true
+	}
+	for _, c := range allowed {
+		if c == code {
+			return true
 		}
+	}
+	return false
+}
 
-		return out
+// missingCommand returns the program that could not be found when err comes from running
+// command through a shell in workdir, or "" when the command ran and simply failed
+func missingCommand(err error, command, workdir string) string {
+	// The shell program itself is not installed
+	var execErr *exec.Error
+	if errors.As(err, &execErr) && errors.Is(execErr.Err, exec.ErrNotFound) {
+		return execErr.Name
 	}
 
-	for _, s := range tasks {
-		if _, ok := skipSet[s.ID]; ok {
-			if verbose {
-				fmt.Printf("[%-15s] SKIP requested by --skip\n", s.ID)
-			}
-			continue
-		}
-		out = append(out, s)
+	// POSIX shells exit 127 (cmd.exe 9009) when they cannot find a program, but a tool may
+	// also exit 127 itself, so only blame the command's program when it really is missing
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || (exitErr.ExitCode() != 127 && exitErr.ExitCode() != 9009) {
+		return ""
 	}
-	return out
+	program := preflight.Program(command)
+	if program == "" || preflight.IsBuiltin(program) {
+		return ""
+	}
+	if _, ok := preflight.Lookup(program, workdir); ok {
+		return ""
+	}
+	return program
 }
 
-func filterTasksByWatchPaths(tasks []model.TaskDefinition, changedFiles []string, projectRoot string, verbose bool) []model.TaskDefinition {
-	var out []model.TaskDefinition
-	for _, task := range tasks {
-		// If task has no watchPaths, always include it
-		if len(task.WatchPaths) == 0 {
-			out = append(out, task)
-			continue
+// adHocTasks builds the tasks of devpipe run from its --task commands, with ids task-1, task-2, ...
+// in the order given. Unless parallel, a wait marker after each task puts it in a phase of its own,
+// so the commands run one after another
+func adHocTasks(commands []string, parallel bool) (map[string]config.TaskConfig, []string) {
+	tasks := make(map[string]config.TaskConfig, len(commands))
+	var order []string
+	for i, command := range commands {
+		id := fmt.Sprintf("task-%d", i+1)
+		tasks[id] = config.TaskConfig{Command: command}
+		if i > 0 && !parallel {
+			order = append(order, fmt.Sprintf("wait-%d", i))
 		}
+		order = append(order, id)
+	}
+	return tasks, order
+}
 
-		// If task has watchPaths but no changed files, skip it
-		if len(changedFiles) == 0 {
-			if verbose {
-				fmt.Printf("[%-15s] SKIP (no changed files, has watchPaths)\n", task.ID)
-			}
-			continue
+// mergeLabels combines config labels with key=value --label flags, which win on conflicts
+func mergeLabels(base map[string]string, flags []string) (model.Labels, error) {
+	if len(base) == 0 && len(flags) == 0 {
+		return nil, nil
+	}
+	labels := make(model.Labels, len(base)+len(flags))
+	for k, v := range base {
+		labels[k] = v
+	}
+	for _, f := range flags {
+		key, value, ok := strings.Cut(f, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --label %q: expected key=value", f)
 		}
+		labels[key] = value
+	}
+	return labels, nil
+}
 
-		// Check if any changed file matches any watchPath pattern
-		matched := false
-		for _, changedFile := range changedFiles {
-			// Make changed file path absolute
-			absChangedFile := changedFile
-			if !filepath.IsAbs(changedFile) {
-				absChangedFile = filepath.Join(projectRoot, changedFile)
-			}
-
-			// Check against each watchPath pattern
-			for _, pattern := range task.WatchPaths {
-				// Make pattern absolute relative to task workdir
-				absPattern := pattern
-				if !filepath.IsAbs(pattern) {
-					absPattern = filepath.Join(task.Workdir, pattern)
-				}
+// groupTurns serializes the tasks of a phase that share a concurrency group: each task waits
+// for the previous task of its group, in config order, to finish. Taking turns in a fixed order
+// rather than racing for a mutex means a task never holds its group while waiting on an earlier
+// task, which could deadlock with sequential output, where every task waits for the one before it
+type groupTurns map[string]chan struct{}
+
+// take returns the channel to wait on before the task runs (nil when it has no group or is the
+// first of its group) and the channel to close when it finishes (nil when it has no group)
+func (t groupTurns) take(group string) (wait, done chan struct{}) {
+	if group == "" {
+		return nil, nil
+	}
+	wait = t[group]
+	done = make(chan struct{})
+	t[group] = done
+	return wait, done
+}
 
-				// Use doublestar for glob matching (supports **)
-				match, err := doublestar.Match(absPattern, absChangedFile)
-				if err != nil {
-					// Invalid pattern, log and skip
-					if verbose {
-						fmt.Printf("[%-15s] WARNING: invalid watchPath pattern %q: %v\n", task.ID, pattern, err)
-					}
-					continue
-				}
+// schedule decides when each task of a run may start. Phases open one after another as the
+// previous one finishes. When tasks run in parallel (overlap), a task with dependsOn instead
+// starts as soon as its dependencies finish and the most recent barrier phase has opened, so
+// it can run alongside earlier phases
+type schedule struct {
+	overlap   bool
+	phaseOpen []chan struct{} // Closed when a phase opens
+	barrier   []int           // The phase each phase's dependsOn tasks wait to open
+	tasks     map[string]*scheduledTask
+	phases    [][]*scheduledTask
+
+	mu        sync.Mutex
+	opened    int  // Number of phases opened so far
+	stopAfter int  // Phases after this one do not start (valid when isStopped)
+	isStopped bool // Set by stop
+}
 
-				if match {
-					matched = true
-					break
-				}
-			}
+// scheduledTask is a task's place in the schedule
+type scheduledTask struct {
+	def     model.TaskDefinition
+	phase   int
+	ran     chan struct{} // Closed when the task has a result (or will not run)
+	settled chan struct{} // Closed when the result is final, after its phase's auto-fix
+	status  model.TaskStatus
+	blocked bool // Skipped because a dependency failed
+}
 
-			if matched {
-				break
-			}
+// newSchedule builds the schedule for phases; overlap lets dependsOn tasks cross phase boundaries
+func newSchedule(phases []Phase, overlap bool) *schedule {
+	s := &schedule{
+		overlap:   overlap,
+		phaseOpen: make([]chan struct{}, len(phases)),
+		barrier:   make([]int, len(phases)),
+		tasks:     make(map[string]*scheduledTask),
+		phases:    make([][]*scheduledTask, len(phases)),
+	}
+	for i, phase := range phases {
+		s.phaseOpen[i] = make(chan struct{})
+		if i > 0 && !phase.Barrier {
+			s.barrier[i] = s.barrier[i-1]
+		} else {
+			s.barrier[i] = i
 		}
-
-		if matched {
-			out = append(out, task)
-		} else if verbose {
-			fmt.Printf("[%-15s] SKIP (no matching changes for watchPaths)\n", task.ID)
+		for _, task := range phase.Tasks {
+			t := &scheduledTask{def: task, phase: i, ran: make(chan struct{}), settled: make(chan struct{})}
+			s.tasks[task.ID] = t
+			s.phases[i] = append(s.phases[i], t)
 		}
 	}
+	return s
+}
 
-	return out
+// wait blocks until task may start: its phase has opened (with overlap and dependsOn, its
+// barrier phase) and its dependencies have finished. A dependency in an earlier phase must
+// have its final result; one in the same phase only gets auto-fixed after the phase
+func (s *schedule) wait(task model.TaskDefinition) {
+	t := s.tasks[task.ID]
+	if s.overlap && len(task.DependsOn) > 0 {
+		<-s.phaseOpen[s.barrier[t.phase]]
+	} else {
+		<-s.phaseOpen[t.phase]
+	}
+	for _, id := range task.DependsOn {
+		dep, ok := s.tasks[id]
+		if !ok {
+			continue // Not part of this run
+		}
+		if dep.phase == t.phase {
+			<-dep.ran
+		} else {
+			<-dep.settled
+		}
+	}
 }
 
-// determineProjectRoot resolves the project root directory
-// Priority: 1) config.projectRoot override, 2) git root from config location, 3) config directory
-func determineProjectRoot(configPath string, cfg config.Config, gitRoot string, inGitRepo bool) string {
-	// If projectRoot is explicitly set in config, use it
-	if cfg.Defaults.ProjectRoot != "" {
-		projectRoot := cfg.Defaults.ProjectRoot
-		// If relative, resolve from config file location
-		if !filepath.IsAbs(projectRoot) {
-			if configPath != "" {
-				absConfigPath, err := filepath.Abs(configPath)
-				if err == nil {
-					configDir := filepath.Dir(absConfigPath)
-					projectRoot = filepath.Join(configDir, projectRoot)
-				}
-			}
+// blockedBy returns the first dependency of task that failed (failed = true) or was itself
+// skipped for a failed dependency, or "" when the task can run
+func (s *schedule) blockedBy(task model.TaskDefinition) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range task.DependsOn {
+		dep, ok := s.tasks[id]
+		if !ok {
+			continue
+		}
+		switch {
+		case dep.status == model.StatusFail:
+			return id, true
+		case dep.blocked:
+			return id, false
 		}
-		return filepath.Clean(projectRoot)
 	}
+	return "", false
+}
 
-	// Auto-detect: if config path is provided, detect from config location
-	if configPath != "" {
-		absConfigPath, err := filepath.Abs(configPath)
-		if err == nil {
-			configDir := filepath.Dir(absConfigPath)
-			// Try to find git root from config directory
-			detectedRoot, detectedInRepo := git.DetectProjectRootFrom(configDir)
-			if detectedInRepo {
-				return detectedRoot
-			}
-			// No git repo, use config directory
-			return configDir
-		}
+// record stores a task's latest status for its dependents
+func (s *schedule) record(id string, status model.TaskStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.tasks[id]; ok {
+		t.status = status
 	}
+}
 
-	// Fallback: use git root if in repo, otherwise current directory
-	if inGitRepo {
-		return gitRoot
+// block marks a task as skipped because of a failed dependency
+func (s *schedule) block(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.tasks[id]; ok {
+		t.blocked = true
 	}
-	return gitRoot // gitRoot is already CWD if not in repo
 }
 
-func makeRunID() string {
-	now := time.Now().UTC()
-	ts := now.Format("2006-01-02T15-04-05Z")
-	// Use PID for uniqueness instead of deprecated rand.Seed
-	suffix := os.Getpid() % 1_000_000
-	return fmt.Sprintf("%s_%06d", ts, suffix)
+// ran marks a task as finished; settled is false when its result waits for the phase's auto-fix
+func (s *schedule) ran(id string, settled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.tasks[id]
+	closeOnce(t.ran)
+	if settled {
+		closeOnce(t.settled)
+	}
 }
 
-func runTask(st model.TaskDefinition, runDir, logDir string, dryRun bool, verbose bool, renderer *ui.Renderer, tracker *ui.AnimatedTaskTracker, outputMu *sync.Mutex, waitForPrev chan struct{}, taskDone chan struct{}) (model.TaskResult, *bytes.Buffer, error) {
-	res := model.TaskResult{
-		ID:               st.ID,
-		Name:             st.Name,
-		Desc:             st.Desc,
-		Phase:            st.Phase,
-		Type:             st.Type,
-		Status:           model.StatusPending,
-		Command:          st.Command,
-		Workdir:          st.Workdir,
-		LogPath:          "",
-		EstimatedSeconds: st.EstimatedSeconds,
+// open lets the tasks of a phase start
+func (s *schedule) open(phase int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	closeOnce(s.phaseOpen[phase])
+	if phase+1 > s.opened {
+		s.opened = phase + 1
 	}
+}
 
-	// Create a buffer to capture all output for this task
-	var taskOutputBuffer bytes.Buffer
+// waitPhase blocks until every task of a phase has finished
+func (s *schedule) waitPhase(phase int) {
+	for _, t := range s.phases[phase] {
+		<-t.ran
+	}
+}
 
-	logPath := filepath.Join(logDir, fmt.Sprintf("%s.log", st.ID))
-	res.LogPath = logPath
+// settle marks the results of a phase as final once its auto-fixes are done
+func (s *schedule) settle(phase int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.phases[phase] {
+		closeOnce(t.settled)
+	}
+}
 
-	if dryRun {
-		res.Status = model.StatusSkipped
-		res.Skipped = true
-		res.SkipReason = "dry-run"
-		// Don't render anything yet - will be shown when displayed
-		return res, &taskOutputBuffer, nil
+// stop keeps tasks of phases that have not opened yet from starting, including dependsOn
+// tasks that would have started early. Tasks of open phases still run, as before
+func (s *schedule) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isStopped {
+		s.isStopped = true
+		s.stopAfter = s.opened - 1
 	}
+}
 
-	// Wait for our turn to display output (non-animated mode only)
-	if tracker == nil {
-		// Wait for previous task to finish (if there is one)
-		if waitForPrev != nil {
-			<-waitForPrev
-		}
+// stopped reports whether tasks of phase must not start
+func (s *schedule) stopped(phase int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isStopped && phase > s.stopAfter
+}
 
-		// Now we can stream output
-		if verbose {
-			fmt.Printf("[%-15s] %s    %s\n", st.ID, renderer.Blue("RUN"), st.Command)
-		} else {
-			fmt.Printf("[%-15s] %s\n", st.ID, renderer.Blue("RUN"))
-		}
-	} else {
-		// Animated mode: buffer the RUN message with a blank line before it
-		renderer.RenderTaskStart(st.ID, st.Command, verbose)
-		taskOutputBuffer.WriteString("\n") // Blank line before task
-		if verbose {
-			taskOutputBuffer.WriteString(fmt.Sprintf("[%-15s] %s    %s\n", st.ID, renderer.Blue("RUN"), st.Command))
-		} else {
-			taskOutputBuffer.WriteString(fmt.Sprintf("[%-15s] %s\n", st.ID, renderer.Blue("RUN")))
-		}
+// release opens every phase and settles every task so no task is left waiting once the
+// pipeline has stopped; the tasks that had not started see stopped (or the cancelled context)
+func (s *schedule) release() {
+	s.mu.Lock()
+	if !s.isStopped {
+		s.isStopped = true
+		s.stopAfter = s.opened - 1
 	}
+	for _, ch := range s.phaseOpen {
+		closeOnce(ch)
+	}
+	for _, t := range s.tasks {
+		closeOnce(t.settled)
+	}
+	s.mu.Unlock()
+}
 
-	start := time.Now().UTC()
-	res.StartTime = start.Format(time.RFC3339)
-	res.Status = model.StatusRunning
-
-	// Update tracker if animated
-	if tracker != nil {
-		tracker.UpdateTask(st.ID, "RUNNING", 0)
+// closeOnce closes ch unless it is already closed; callers serialize calls with a lock
+func closeOnce(ch chan struct{}) {
+	select {
+	case <-ch:
+	default:
+		close(ch)
 	}
+}
 
-	logFile, err := os.Create(logPath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "ERROR: cannot create log file %s: %v\n", logPath, err)
-		res.Status = model.StatusFail
-		return res, &taskOutputBuffer, err
+// phaseResults returns the indexes in results of the results for phase's tasks
+func phaseResults(results []model.TaskResult, phase Phase) []int {
+	ids := make(map[string]bool, len(phase.Tasks))
+	for _, t := range phase.Tasks {
+		ids[t.ID] = true
 	}
-	defer func() {
-		if err := logFile.Close(); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to close log file: %v\n", err)
+	var indexes []int
+	for i, res := range results {
+		if ids[res.ID] {
+			indexes = append(indexes, i)
 		}
-	}()
+	}
+	return indexes
+}
 
-	cmd := exec.Command("sh", "-c", st.Command)
-	cmd.Dir = st.Workdir
-	cmd.Env = append(os.Environ(), "FORCE_COLOR=1")
+// skippedResult builds the run record for a task that was skipped before it started
+func skippedResult(task model.TaskDefinition, reason string) model.TaskResult {
+	return model.TaskResult{
+		ID:               task.ID,
+		Name:             task.Name,
+		Desc:             task.Desc,
+		Phase:            task.Phase,
+		Type:             task.Type,
+		Status:           model.StatusSkipped,
+		Skipped:          true,
+		SkipReason:       reason,
+		Command:          task.Command,
+		Workdir:          task.Workdir,
+		LogPath:          "",
+		EstimatedSeconds: task.EstimatedSeconds,
+		Labels:           task.Labels,
+	}
+}
 
-	// Setup output handling
-	var bufferMu sync.Mutex
+// cachedResult is the result of a task skipped because its cacheInputs are unchanged since runID
+func cachedResult(task model.TaskDefinition, runID string) model.TaskResult {
+	return model.TaskResult{
+		ID:               task.ID,
+		Name:             task.Name,
+		Desc:             task.Desc,
+		Phase:            task.Phase,
+		Type:             task.Type,
+		Status:           model.StatusPass,
+		CachedFrom:       runID,
+		Command:          task.Command,
+		Workdir:          task.Workdir,
+		EstimatedSeconds: task.EstimatedSeconds,
+		Labels:           task.Labels,
+	}
+}
 
-	if tracker != nil {
-		// Animated mode: buffer output for sequential display
-		stdoutWriter := &lineWriter{taskID: st.ID, file: logFile, outputBuffer: &taskOutputBuffer, mu: &bufferMu, renderer: renderer}
-		stderrWriter := &lineWriter{taskID: st.ID, file: logFile, outputBuffer: &taskOutputBuffer, mu: &bufferMu, renderer: renderer}
-		cmd.Stdout = stdoutWriter
-		cmd.Stderr = stderrWriter
-	} else {
-		// Non-animated mode: stream output directly (we already have the turn)
-		stdoutWriter := &lineWriter{taskID: st.ID, file: logFile, console: os.Stdout, renderer: renderer}
-		stderrWriter := &lineWriter{taskID: st.ID, file: logFile, console: os.Stderr, renderer: renderer}
-		cmd.Stdout = stdoutWriter
-		cmd.Stderr = stderrWriter
+// filterTasks applies --only and --skip. Each value selects tasks by, in order of
+// precedence: exact task id, phase (id like "phase-test", "test", or its name), or
+// a glob pattern matched against task ids (e.g. "test-*").
+func filterTasks(tasks []model.TaskDefinition, only string, skip sliceFlag, _ bool, _ int, renderer *ui.Renderer, verbose bool) []model.TaskDefinition {
+	skipSet := map[string]struct{}{}
+	for _, value := range skip {
+		ids := selectTasks(tasks, value)
+		if len(ids) == 0 {
+			fmt.Fprintf(os.Stderr, "WARNING: --skip %q matches no task id, phase or pattern\n", value)
+		}
+		for _, id := range ids {
+			skipSet[id] = struct{}{}
+		}
 	}
 
-	// Start ticker to update progress during execution
-	var tickerDone chan struct{}
-	if tracker != nil {
-		tickerDone = make(chan struct{})
-		go func() {
-			ticker := time.NewTicker(100 * time.Millisecond)
-			defer ticker.Stop()
-			startTime := time.Now()
+	var out []model.TaskDefinition
+	if only != "" {
+		// Build a set of requested IDs from the comma-separated --only values, then
+		// walk the full task list in pipeline order and keep only requested tasks
+		// that are not skipped.
+		requestedSet := make(map[string]struct{})
+		for _, raw := range strings.Split(only, ",") {
+			value := strings.TrimSpace(raw)
+			if value == "" {
+				continue
+			}
+			ids := selectTasks(tasks, value)
+			if len(ids) == 0 {
+				fmt.Fprintf(os.Stderr, "ERROR: --only %q matches no task id, phase or pattern\n", value)
+				os.Exit(exitConfigError)
+			}
+			for _, id := range ids {
+				requestedSet[id] = struct{}{}
+			}
+		}
 
-			for {
-				select {
-				case <-tickerDone:
-					return
-				case <-ticker.C:
-					elapsed := time.Since(startTime).Seconds()
-					tracker.UpdateTask(st.ID, "RUNNING", elapsed)
+		for _, s := range tasks {
+			if _, want := requestedSet[s.ID]; !want {
+				continue
+			}
+			if _, skip := skipSet[s.ID]; skip {
+				if verbose {
+					fmt.Printf("%sSKIP requested by --skip\n", renderer.Prefix(s.ID))
 				}
+				continue
 			}
-		}()
-	}
+			out = append(out, s)
+		}
 
-	err = cmd.Run()
+		return out
+	}
 
-	// Stop ticker
-	if tickerDone != nil {
-		close(tickerDone)
+	for _, s := range tasks {
+		if _, ok := skipSet[s.ID]; ok {
+			if verbose {
+				fmt.Printf("%sSKIP requested by --skip\n", renderer.Prefix(s.ID))
+			}
+			continue
+		}
+		out = append(out, s)
 	}
+	return out
+}
 
-	end := time.Now().UTC()
-	res.EndTime = end.Format(time.RFC3339)
-	res.DurationMs = end.Sub(start).Milliseconds()
-	elapsed := end.Sub(start).Seconds()
+// loadResumeRun loads the run to resume: runID, or the newest run other than the current one for "latest"
+func loadResumeRun(outputRoot, runID, currentRunID string) (model.RunRecord, error) {
+	if runID != "latest" {
+		return dashboard.LoadRun(outputRoot, runID)
+	}
 
-	exitCode := 0
+	runs, err := dashboard.LoadRuns(outputRoot)
 	if err != nil {
-		var ee *exec.ExitError
-		if errors.As(err, &ee) {
-			exitCode = ee.ExitCode()
-		} else {
-			exitCode = 1
+		return model.RunRecord{}, fmt.Errorf("failed to load previous runs: %w", err)
+	}
+	for _, run := range runs {
+		if run.RunID != currentRunID {
+			return run, nil
 		}
-		res.Status = model.StatusFail
-		res.ExitCode = &exitCode
+	}
+	return model.RunRecord{}, fmt.Errorf("no previous run to resume in %s", filepath.Join(outputRoot, "runs"))
+}
 
-		// Parse output even on failure (especially useful for SARIF/JUnit)
-		// This allows us to show what failed in the dashboard
-		if st.OutputType != "" && st.OutputPath != "" {
-			renderer.Verbose(verbose, "%s Task failed, but attempting to parse output: type=%s, path=%s", st.ID, st.OutputType, st.OutputPath)
-			res.Metrics = parseTaskMetrics(st, verbose)
-			if res.Metrics != nil {
-				renderer.Verbose(verbose, "%s Output parsed successfully despite failure: %+v", st.ID, res.Metrics.Data)
-			}
-		}
+// selectResumeTasks splits tasks into those to re-run and passing results to reuse from prev.
+// A task re-runs if it did not pass in prev (failed, skipped or not run), or if it is in a
+// later phase than such a task, since phases depend on the ones before them.
+func selectResumeTasks(tasks []model.TaskDefinition, prev model.RunRecord) ([]model.TaskDefinition, []model.TaskResult) {
+	prevResults := make(map[string]model.TaskResult)
+	for _, r := range prev.Tasks {
+		prevResults[r.ID] = r
+	}
 
-		// Update tracker with final status
-		if tracker != nil {
-			tracker.UpdateTask(st.ID, "FAIL", elapsed)
-			renderer.RenderTaskComplete(st.ID, string(res.Status), &exitCode, res.DurationMs, verbose)
+	current := make(map[string]bool)
+	phaseIndex := make(map[string]int)
+	for _, t := range tasks {
+		current[t.ID] = true
+		if _, ok := phaseIndex[t.PhaseID]; !ok {
+			phaseIndex[t.PhaseID] = len(phaseIndex)
+		}
+	}
+	for _, r := range prev.Tasks {
+		if !current[r.ID] {
+			fmt.Fprintf(os.Stderr, "WARNING: task %q from run %s is no longer in the config, skipping it\n", r.ID, prev.RunID)
+		}
+	}
 
-			// Also buffer the failure message for the output section
-			taskOutputBuffer.WriteString(fmt.Sprintf("[%-15s] ✗ %s (%dms)\n", st.ID, renderer.Red("FAIL"), res.DurationMs))
-		} else {
-			// Stream the failure message with color
-			fmt.Printf("[%-15s] ✗ %s (%dms)\n\n", st.ID, renderer.Red("FAIL"), res.DurationMs)
+	needsRun := func(t model.TaskDefinition) bool {
+		r, ok := prevResults[t.ID]
+		return !ok || r.Status != model.StatusPass
+	}
 
-			// Signal that this task is done streaming
-			close(taskDone)
+	firstPhase := -1
+	for _, t := range tasks {
+		if needsRun(t) && (firstPhase < 0 || phaseIndex[t.PhaseID] < firstPhase) {
+			firstPhase = phaseIndex[t.PhaseID]
 		}
-		return res, &taskOutputBuffer, err
 	}
 
-	res.Status = model.StatusPass
-	res.ExitCode = &exitCode
-
-	// Parse output if configured
-	if st.OutputType != "" && st.OutputPath != "" {
-		renderer.Verbose(verbose, "%s Parsing output: type=%s, path=%s", st.ID, st.OutputType, st.OutputPath)
-		res.Metrics = parseTaskMetrics(st, verbose)
-		if res.Metrics != nil {
-			renderer.Verbose(verbose, "%s Output parsed successfully: %+v", st.ID, res.Metrics.Data)
+	var rerun []model.TaskDefinition
+	var cached []model.TaskResult
+	for _, t := range tasks {
+		downstream := firstPhase >= 0 && phaseIndex[t.PhaseID] > firstPhase
+		if needsRun(t) || downstream {
+			rerun = append(rerun, t)
+			continue
 		}
-		// Output parsing failed - this means either:
-		// 1. File doesn't exist (will be caught below)
-		// 2. Invalid format (error already printed)
-		// 3. Parse error (error already printed)
-		// We'll fail the task below if file is missing/empty, or here if it's a parse/format error
+		r := prevResults[t.ID]
+		r.CachedFrom = prev.RunID
+		cached = append(cached, r)
+	}
+	return rerun, cached
+}
 
-		// Validate artifact if output path specified
-		// Handle both absolute and relative paths
-		var artifactPath string
-		if filepath.IsAbs(st.OutputPath) {
-			artifactPath = st.OutputPath
-		} else {
-			artifactPath = filepath.Join(st.Workdir, st.OutputPath)
+// orderResultsByTasks sorts results into the order of tasks (results for unknown ids go last)
+func orderResultsByTasks(results []model.TaskResult, tasks []model.TaskDefinition) []model.TaskResult {
+	index := make(map[string]int, len(tasks))
+	for i, t := range tasks {
+		index[t.ID] = i
+	}
+	position := func(id string) int {
+		if i, ok := index[id]; ok {
+			return i
 		}
-		if info, err := os.Stat(artifactPath); err != nil || info.Size() == 0 {
-			// Artifact missing or empty - fail the task
-			res.Status = model.StatusFail
-			if err != nil {
-				// Always show this error (not just in verbose)
-				fmt.Fprintf(os.Stderr, "[%-15s] ❌ ERROR: Output file not found: %s\n", st.ID, st.OutputPath)
-				renderer.Verbose(verbose, "%s Full path: %s", st.ID, artifactPath)
-			} else {
-				// Always show this error (not just in verbose)
-				fmt.Fprintf(os.Stderr, "[%-15s] ❌ ERROR: Output file is empty: %s\n", st.ID, st.OutputPath)
-				renderer.Verbose(verbose, "%s Full path: %s", st.ID, artifactPath)
-			}
-		} else if res.Metrics == nil {
-			// File exists but output parsing failed (invalid format or parse error)
-			res.Status = model.StatusFail
-			// Error already printed by parseTaskMetrics
-			renderer.Verbose(verbose, "%s Output validation FAILED: file exists but parsing failed", st.ID)
-		} else {
-			// Artifact exists, has size, and metrics parsed successfully
-			res.Metrics.Data["path"] = artifactPath
-			res.Metrics.Data["size"] = info.Size()
+		return len(tasks)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return position(results[i].ID) < position(results[j].ID)
+	})
+	return results
+}
 
-			renderer.Verbose(verbose, "%s Artifact validation PASSED: %s (%d bytes)", st.ID, artifactPath, info.Size())
+// filterTasksByTags keeps tasks that have any of the include tags (when given) and
+// none of the exclude tags. allTasks is used to reject tags no task declares.
+func filterTasksByTags(tasks, allTasks []model.TaskDefinition, include, exclude []string, renderer *ui.Renderer, verbose bool) []model.TaskDefinition {
+	if len(include) == 0 && len(exclude) == 0 {
+		return tasks
+	}
 
-			// Copy output to run directory for historical preservation
-			outputsDir := filepath.Join(runDir, "outputs")
-			if err := os.MkdirAll(outputsDir, 0755); err != nil {
-				renderer.Verbose(verbose, "%s Failed to create outputs directory: %v", st.ID, err)
-			} else {
-				// Determine destination path based on whether source is absolute or relative
-				var destPath string
-				if filepath.IsAbs(st.OutputPath) {
-					// For absolute paths, store under task ID with full path to avoid conflicts
-					// e.g., /foo/bar/file.xml -> outputs/<task-id>/foo/bar/file.xml
-					destPath = filepath.Join(outputsDir, st.ID, st.OutputPath)
-				} else {
-					// For relative paths, preserve directory structure
-					destPath = filepath.Join(outputsDir, st.OutputPath)
-				}
-				destDir := filepath.Dir(destPath)
-				if err := os.MkdirAll(destDir, 0755); err != nil {
-					renderer.Verbose(verbose, "%s Failed to create output subdirectory: %v", st.ID, err)
-				} else {
-					// Copy the file
-					if content, err := os.ReadFile(artifactPath); err != nil {
-						renderer.Verbose(verbose, "%s Failed to read output for copying: %v", st.ID, err)
-					} else if err := os.WriteFile(destPath, content, 0644); err != nil {
-						renderer.Verbose(verbose, "%s Failed to copy output: %v", st.ID, err)
-					} else {
-						renderer.Verbose(verbose, "%s Output copied to: %s", st.ID, destPath)
-					}
-				}
-			}
+	known := make(map[string]struct{})
+	for _, t := range allTasks {
+		for _, tag := range t.Tags {
+			known[tag] = struct{}{}
 		}
-	} else {
-		renderer.Verbose(verbose, "%s No output configured (type=%s, path=%s)", st.ID, st.OutputType, st.OutputPath)
 	}
-
-	// Update tracker with final status
-	if tracker != nil {
-		tracker.UpdateTask(st.ID, string(res.Status), elapsed)
-		renderer.RenderTaskComplete(st.ID, string(res.Status), &exitCode, res.DurationMs, verbose)
-
-		// Also buffer the completion message for the output section
-		symbol := "•"
-		var statusText string
-
-		switch res.Status {
-		case model.StatusPass:
-			symbol = "✓"
-			statusText = renderer.Green(string(res.Status))
-		case model.StatusFail:
-			symbol = "✗"
-			statusText = renderer.Red(string(res.Status))
-		case model.StatusSkipped:
-			symbol = "⊘"
-			statusText = renderer.Yellow(string(res.Status))
-		default:
-			statusText = string(res.Status)
+	for _, tag := range append(append([]string{}, include...), exclude...) {
+		if _, ok := known[tag]; !ok {
+			fmt.Fprintf(os.Stderr, "ERROR: no task has tag %q\n", tag)
+			os.Exit(exitConfigError)
 		}
+	}
 
-		if verbose && exitCode != 0 {
-			taskOutputBuffer.WriteString(fmt.Sprintf("[%-15s] %s %s (exit %d, %dms)\n", st.ID, symbol, statusText, exitCode, res.DurationMs))
-		} else {
-			taskOutputBuffer.WriteString(fmt.Sprintf("[%-15s] %s %s (%dms)\n", st.ID, symbol, statusText, res.DurationMs))
+	var out []model.TaskDefinition
+	for _, t := range tasks {
+		if len(include) > 0 && !hasAnyTag(t.Tags, include) {
+			if verbose {
+				fmt.Printf("%sSKIP (no matching --tag)\n", renderer.Prefix(t.ID))
+			}
+			continue
 		}
-	} else {
-		// Stream the completion message for non-animated mode with colors
-		symbol := "•"
-		var statusText string
-
-		switch res.Status {
-		case model.StatusPass:
-			symbol = "✓"
-			statusText = renderer.Green(string(res.Status))
-		case model.StatusFail:
-			symbol = "✗"
-			statusText = renderer.Red(string(res.Status))
-		case model.StatusSkipped:
-			symbol = "⊘"
-			statusText = renderer.Yellow(string(res.Status))
-		default:
-			statusText = string(res.Status)
+		if hasAnyTag(t.Tags, exclude) {
+			if verbose {
+				fmt.Printf("%sSKIP requested by --exclude-tag\n", renderer.Prefix(t.ID))
+			}
+			continue
 		}
+		out = append(out, t)
+	}
+	return out
+}
 
-		if verbose && exitCode != 0 {
-			fmt.Printf("[%-15s] %s %s (exit %d, %dms)\n", st.ID, symbol, statusText, exitCode, res.DurationMs)
-		} else {
-			fmt.Printf("[%-15s] %s %s (%dms)\n", st.ID, symbol, statusText, res.DurationMs)
+// hasAnyTag reports whether tags contains any of want
+func hasAnyTag(tags, want []string) bool {
+	for _, tag := range tags {
+		for _, w := range want {
+			if tag == w {
+				return true
+			}
 		}
-		fmt.Println() // Blank line after task
-
-		// Signal that this task is done streaming
-		close(taskDone)
 	}
-
-	return res, &taskOutputBuffer, nil
+	return false
 }
 
-func writeRunJSON(runDir string, record model.RunRecord) error {
-	path := filepath.Join(runDir, "run.json")
-	data, err := json.MarshalIndent(record, "", "  ")
-	if err != nil {
-		return err
+// splitFlagValues flattens repeated, comma-separated flag values
+func splitFlagValues(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
 	}
-	return os.WriteFile(path, data, 0o644)
+	return out
 }
 
-// parseTaskMetrics parses output for a completed task
-func parseTaskMetrics(st model.TaskDefinition, verbose bool) *model.TaskMetrics {
-	// Build full path to output file (handle both absolute and relative paths)
-	var outputPath string
-	if filepath.IsAbs(st.OutputPath) {
-		outputPath = st.OutputPath
-	} else {
-		outputPath = filepath.Join(st.Workdir, st.OutputPath)
+// selectTasks returns the ids of the tasks an --only/--skip value refers to.
+// An exact task id wins; otherwise all tasks in a matching phase; otherwise, if the
+// value contains glob characters, all tasks whose id matches it.
+func selectTasks(tasks []model.TaskDefinition, value string) []string {
+	for _, t := range tasks {
+		if t.ID == value {
+			return []string{value}
+		}
 	}
 
-	// Check if file exists
-	if _, err := os.Stat(outputPath); os.IsNotExist(err) {
-		if verbose {
-			fmt.Fprintf(os.Stderr, "[%-15s] Output file not found: %s\n", st.ID, outputPath)
+	var ids []string
+	for _, t := range tasks {
+		if t.PhaseID != "" && (t.PhaseID == value || t.PhaseID == "phase-"+value || strings.EqualFold(t.Phase, value)) {
+			ids = append(ids, t.ID)
 		}
-		return nil
+	}
+	if len(ids) > 0 {
+		return ids
 	}
 
-	// Parse based on type
-	switch st.OutputType {
-	case "junit":
-		m, err := metrics.ParseJUnitXML(outputPath)
-		if err != nil {
-			// Always show parse failures (not just in verbose mode)
-			fmt.Fprintf(os.Stderr, "[%-15s] ❌ ERROR: Failed to parse JUnit XML: %v\n", st.ID, err)
-			fmt.Fprintf(os.Stderr, "[%-15s]          File: %s\n", st.ID, st.OutputPath)
-			return nil
-		}
-		return m
-	case "sarif":
-		m, err := metrics.ParseSARIF(outputPath)
-		if err != nil {
-			// Always show parse failures (not just in verbose mode)
-			fmt.Fprintf(os.Stderr, "[%-15s] ❌ ERROR: Failed to parse SARIF: %v\n", st.ID, err)
-			fmt.Fprintf(os.Stderr, "[%-15s]          File: %s\n", st.ID, st.OutputPath)
-			return nil
-		}
-		return m
-	case "artifact":
-		// For artifact format, just verify file exists and has content (already done above)
-		return &model.TaskMetrics{
-			Kind:          "artifact",
-			SummaryFormat: "artifact",
-			Data: map[string]interface{}{
-				"path": outputPath,
-			},
-		}
-	default:
-		// Unknown type - this is an error
-		fmt.Fprintf(os.Stderr, "[%-15s] ❌ ERROR: Unknown output type: %s\n", st.ID, st.OutputType)
-		fmt.Fprintf(os.Stderr, "[%-15s]          Supported types: junit, sarif, artifact\n", st.ID)
+	if !strings.ContainsAny(value, "*?[{") {
 		return nil
 	}
-}
-
-// copyConfigToRun copies the config file to the run directory
-func copyConfigToRun(runDir, configPath string, mergedCfg *config.Config) error {
-	destPath := filepath.Join(runDir, "config.toml")
-
-	// If no config path specified, try default location
-	if configPath == "" {
-		configPath = "config.toml"
+	for _, t := range tasks {
+		if match, err := doublestar.Match(value, t.ID); err == nil && match {
+			ids = append(ids, t.ID)
+		}
 	}
+	return ids
+}
 
-	// If config file exists, copy it
-	if _, err := os.Stat(configPath); err == nil {
-		data, err := os.ReadFile(configPath)
-		if err != nil {
-			return err
+func filterTasksByWatchPaths(tasks []model.TaskDefinition, changedFiles []string, projectRoot string, renderer *ui.Renderer, verbose bool) []model.TaskDefinition {
+	var out []model.TaskDefinition
+	for _, task := range tasks {
+		// If task has no watchPaths, always include it
+		if len(task.WatchPaths) == 0 {
+			out = append(out, task)
+			continue
 		}
-		return os.WriteFile(destPath, data, 0644)
-	}
 
-	// Otherwise, write the merged config as JSON (built-in + defaults)
-	data, err := json.MarshalIndent(mergedCfg, "", "  ")
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(filepath.Join(runDir, "config.json"), data, 0644)
-}
+		// Required tasks run regardless of which files changed
+		if task.Required {
+			if verbose {
+				fmt.Printf("%sRUN (required, watchPaths ignored)\n", renderer.Prefix(task.ID))
+			}
+			out = append(out, task)
+			continue
+		}
 
-// lineWriter captures output line by line and sends to tracker
-type lineWriter struct {
-	tracker      *ui.AnimatedTaskTracker
-	taskID       string
-	file         *os.File
-	buffer       []byte
-	outputBuffer *bytes.Buffer // Buffer all output until task completes
-	mu           *sync.Mutex   // Protect outputBuffer
-	console      *os.File      // For streaming output directly
-	renderer     *ui.Renderer  // For colorizing output
-}
+		// If task has watchPaths but no changed files, skip it
+		if len(changedFiles) == 0 {
+			if verbose {
+				fmt.Printf("%sSKIP (no changed files, has watchPaths)\n", renderer.Prefix(task.ID))
+			}
+			continue
+		}
 
-func (w *lineWriter) Write(p []byte) (n int, err error) {
-	// Write to log file (unprefixed)
-	_, _ = w.file.Write(p) // Best effort log write
+		// Check if any changed file is selected by the watchPath patterns
+		matched := false
+		for _, changedFile := range changedFiles {
+			// Make changed file path absolute
+			absChangedFile := changedFile
+			if !filepath.IsAbs(changedFile) {
+				absChangedFile = filepath.Join(projectRoot, changedFile)
+			}
 
-	// Add to buffer and extract complete lines
-	w.buffer = append(w.buffer, p...)
+			// Patterns apply in order like .gitignore: the last one matching the file decides,
+			// so "!pattern" excludes files selected by an earlier inclusion
+			selected := false
+			for _, pattern := range task.WatchPaths {
+				negate := strings.HasPrefix(pattern, "!")
+				glob := strings.TrimPrefix(pattern, "!")
+				if glob == "" || selected != negate {
+					// Nothing to include or exclude for this file
+					continue
+				}
 
-	// Process complete lines
-	for {
-		idx := bytes.IndexByte(w.buffer, '\n')
-		if idx == -1 {
-			break
-		}
+				// Make pattern absolute relative to task workdir
+				absPattern := glob
+				if !filepath.IsAbs(glob) {
+					absPattern = filepath.Join(task.Workdir, glob)
+				}
 
-		line := string(w.buffer[:idx])
+				// Use doublestar for glob matching (supports **)
+				match, err := doublestar.Match(absPattern, absChangedFile)
+				if err != nil {
+					// Invalid pattern, log and skip
+					if verbose {
+						fmt.Printf("%sWARNING: invalid watchPath pattern %q: %v\n", renderer.Prefix(task.ID), pattern, err)
+					}
+					continue
+				}
 
-		// Prefix line with task ID
-		prefixedLine := fmt.Sprintf("[%-15s] %s", w.taskID, line)
+				if match {
+					selected = !negate
+				}
+			}
 
-		if w.tracker != nil {
-			w.tracker.AddLogLine(prefixedLine)
-		} else if w.outputBuffer != nil {
-			// Buffer output for sequential display
-			w.mu.Lock()
-			w.outputBuffer.WriteString(prefixedLine)
-			w.outputBuffer.WriteString("\n")
-			w.mu.Unlock()
-		} else if w.console != nil {
-			// Stream output directly
-			_, _ = fmt.Fprintln(w.console, prefixedLine) // Best effort console write
+			if selected {
+				matched = true
+				break
+			}
 		}
 
-		w.buffer = w.buffer[idx+1:]
+		if matched {
+			task.WatchPathsMatch = true
+			out = append(out, task)
+		} else if verbose {
+			fmt.Printf("%sSKIP (no matching changes for watchPaths)\n", renderer.Prefix(task.ID))
+		}
 	}
 
-	return len(p), nil
+	return out
 }
 
-// printVersion prints version information
-func printVersion() {
-	fmt.Printf("devpipe version %s\n", version)
-	fmt.Printf("  commit: %s\n", commit)
-	fmt.Printf("  built: %s\n", buildDate)
-	fmt.Printf("  go: %s\n", runtime.Version())
-	fmt.Printf("  platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+// changedTaskTypes returns the task types fileTypeMap selects for the changed files, lowercased.
+// A key matches a file by extension (".go" or "go") or by its whole name ("Dockerfile")
+func changedTaskTypes(changedFiles []string, fileTypeMap map[string][]string) map[string]bool {
+	types := map[string]bool{}
+	for _, file := range changedFiles {
+		base := filepath.Base(file)
+		ext := strings.ToLower(filepath.Ext(base))
+		for key, taskTypes := range fileTypeMap {
+			if key != base && (ext == "" || "."+strings.ToLower(strings.TrimPrefix(key, ".")) != ext) {
+				continue
+			}
+			for _, taskType := range taskTypes {
+				types[strings.ToLower(taskType)] = true
+			}
+		}
+	}
+	return types
 }
 
-// findSimilarCommand finds the most similar command using simple string matching
-func findSimilarCommand(input string, commands []string) string {
-	minDist := len(input)
-	var bestMatch string
-
-	for _, cmd := range commands {
-		dist := levenshteinDistance(input, cmd)
-		// Only suggest if distance is small (typo, not completely different)
-		if dist < minDist && dist <= 2 {
-			minDist = dist
-			bestMatch = cmd
+// filterTasksByFileTypes keeps the tasks whose type fileTypeMap selects for the changed files
+// (--changed-only). Required tasks always run
+func filterTasksByFileTypes(tasks []model.TaskDefinition, changedFiles []string, fileTypeMap map[string][]string, renderer *ui.Renderer, verbose bool) []model.TaskDefinition {
+	types := changedTaskTypes(changedFiles, fileTypeMap)
+	var out []model.TaskDefinition
+	for _, task := range tasks {
+		switch {
+		case types[strings.ToLower(task.Type)]:
+			out = append(out, task)
+		case task.Required:
+			if verbose {
+				fmt.Printf("%sRUN (required, --changed-only ignored)\n", renderer.Prefix(task.ID))
+			}
+			out = append(out, task)
+		case verbose:
+			if task.Type == "" {
+				fmt.Printf("%sSKIP (--changed-only, task has no type)\n", renderer.Prefix(task.ID))
+			} else {
+				fmt.Printf("%sSKIP (--changed-only, no changed files of type %s)\n", renderer.Prefix(task.ID), task.Type)
+			}
 		}
 	}
-
-	return bestMatch
+	return out
 }
 
-// levenshteinDistance calculates the edit distance between two strings
-func levenshteinDistance(s1, s2 string) int {
-	if len(s1) == 0 {
-		return len(s2)
+// findConfig returns the config to load: path when one was given, otherwise $DEVPIPE_CONFIG, or
+// else the config.toml or .devpipe.toml found in the working directory or its parents up to the git root. It is ""
+// when there is none, or when discover is off, so LoadConfig falls back to ./config.toml
+func findConfig(path string, discover bool) string {
+	if path == "" {
+		path = os.Getenv(config.ConfigEnvVar)
 	}
-	if len(s2) == 0 {
-		return len(s1)
+	if path != "" || !discover {
+		return path
 	}
-
-	// Create matrix
-	matrix := make([][]int, len(s1)+1)
-	for i := range matrix {
-		matrix[i] = make([]int, len(s2)+1)
-		matrix[i][0] = i
+	found := config.Discover(".")
+	if found == "" {
+		return ""
 	}
-	for j := range matrix[0] {
-		matrix[0][j] = j
+	// A config in the working directory keeps its short name in messages and run records
+	if cwd, err := os.Getwd(); err == nil && filepath.Dir(found) == cwd {
+		return filepath.Base(found)
 	}
+	return found
+}
 
-	// Fill matrix
-	for i := 1; i <= len(s1); i++ {
-		for j := 1; j <= len(s2); j++ {
-			cost := 0
-			if s1[i-1] != s2[j-1] {
-				cost = 1
-			}
-			matrix[i][j] = min(
-				matrix[i-1][j]+1,      // deletion
-				matrix[i][j-1]+1,      // insertion
-				matrix[i-1][j-1]+cost, // substitution
-			)
-		}
+// determineProjectRoot resolves the project root directory
+// Priority: 1) config.projectRoot override, 2) git root from config location, 3) config directory
+// A config read from stdin has no location, so it resolves like no --config (cwd/git root)
+func determineProjectRoot(configPath string, cfg config.Config, gitRoot string, inGitRepo bool) string {
+	if configPath == config.StdinPath {
+		configPath = ""
 	}
 
-	return matrix[len(s1)][len(s2)]
-}
-
-// min returns the minimum of three integers
-func min(a, b, c int) int {
-	if a < b {
-		if a < c {
-			return a
+	// If projectRoot is explicitly set in config, use it
+	if cfg.Defaults.ProjectRoot != "" {
+		projectRoot := cfg.Defaults.ProjectRoot
+		// If relative, resolve from config file location
+		if !filepath.IsAbs(projectRoot) {
+			if configPath != "" {
+				absConfigPath, err := filepath.Abs(configPath)
+				if err == nil {
+					configDir := filepath.Dir(absConfigPath)
+					projectRoot = filepath.Join(configDir, projectRoot)
+				}
+			}
 		}
-		return c
-	}
-	if b < c {
-		return b
-	}
-	return c
-}
-
-// printHelp prints usage information
-func printHelp() {
-	fmt.Println("devpipe - Fast, local pipeline runner")
-	fmt.Println()
-	fmt.Println("USAGE:")
-	fmt.Println("  devpipe [flags]              Run the pipeline")
-	fmt.Println("  devpipe list [--verbose]     List all tasks")
-	fmt.Println("  devpipe validate [files...]  Validate config file(s)")
-	fmt.Println("  devpipe generate-reports     Regenerate all reports with latest template")
-	fmt.Println("  devpipe sarif [options] ...  View SARIF security scan results")
-	fmt.Println("  devpipe version              Show version information")
-	fmt.Println("  devpipe help                 Show this help")
-	fmt.Println()
-	fmt.Println("RUN FLAGS:")
-	fmt.Println("  --config <path>       Path to config file (default: config.toml)")
-	fmt.Println("  --since <ref>         Git ref to compare against (overrides config)")
-	fmt.Println("  --only <task-ids>     Run only specific task(s) by id (comma-separated)")
-	fmt.Println("  --skip <task-id>      Skip a task by id (can be specified multiple times)")
-	fmt.Println("  --ui <mode>           UI mode: basic, full (default: basic)")
-	fmt.Println("  --dashboard           Show dashboard with live progress")
-	fmt.Println("  --fail-fast           Stop on first task failure")
-	fmt.Println("  --fast                Skip long running tasks")
-	fmt.Println("  --ignore-watch-paths  Ignore watchPaths and run all tasks")
-	fmt.Println("  --dry-run             Do not execute commands, simulate only")
-	fmt.Println("  --verbose             Verbose logging")
-	fmt.Println("  --no-color            Disable colored output")
-	fmt.Println()
-	fmt.Println("VALIDATE FLAGS:")
-	fmt.Println("  --config <path>       Path to config file to validate (default: config.toml)")
-	fmt.Println()
-	fmt.Println("EXAMPLES:")
-	fmt.Println("  devpipe                                    # Run pipeline with default config")
-	fmt.Println("  devpipe --config config/custom.toml        # Run with custom config")
-	fmt.Println("  devpipe --fast --fail-fast                 # Skip slow tasks, stop on failure")
-	fmt.Println("  devpipe list                               # List all task IDs")
-	fmt.Println("  devpipe list --verbose                     # List tasks in table format with details")
-	fmt.Println("  devpipe validate                           # Validate default config.toml")
-	fmt.Println("  devpipe validate config/*.toml             # Validate all configs in folder")
-	fmt.Println("  devpipe generate-reports                   # Regenerate all reports with latest template")
-	fmt.Println("  devpipe sarif tmp/codeql/results.sarif     # View CodeQL security scan results")
-	fmt.Println("  devpipe sarif -s tmp/codeql/results.sarif  # Show summary of security issues")
-	fmt.Println()
-}
-
-// validateCmd handles the validate subcommand
-func validateCmd() {
-	// Parse remaining args
-	files := os.Args[2:]
-	if len(files) == 0 {
-		files = []string{"config.toml"}
+		return filepath.Clean(projectRoot)
 	}
 
-	hasErrors := false
-	for _, file := range files {
-		result, err := config.ValidateConfigFile(file)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "❌ ERROR: %v\n", err)
-			hasErrors = true
-			continue
-		}
-
-		config.PrintValidationResult(file, result)
-		if !result.Valid {
-			hasErrors = true
+	// Auto-detect: if config path is provided, detect from config location
+	if configPath != "" {
+		absConfigPath, err := filepath.Abs(configPath)
+		if err == nil {
+			configDir := filepath.Dir(absConfigPath)
+			// Try to find git root from config directory
+			detectedRoot, detectedInRepo := git.DetectProjectRootFrom(configDir)
+			if detectedInRepo {
+				return detectedRoot
+			}
+			// No git repo, use config directory
+			return configDir
 		}
 	}
 
-	if hasErrors {
-		os.Exit(1)
+	// Fallback: use git root if in repo, otherwise current directory
+	if inGitRepo {
+		return gitRoot
 	}
+	return gitRoot // gitRoot is already CWD if not in repo
 }
 
-// generateReportsCmd handles the generate-reports subcommand
-func generateReportsCmd() {
-	startTime := time.Now()
-	fmt.Println("Regenerating all reports with latest template...")
-
-	// Determine project root
-	projectRoot, _ := git.DetectProjectRoot()
-
-	// Get output root from default config
-	cfg, _, _, _, err := config.LoadConfig("")
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "ERROR: failed to load config: %v\n", err)
-		os.Exit(1)
-	}
+// runCounterFile, in the output root, holds the last run number handed out with runIdFormat = "counter"
+const runCounterFile = "run-counter"
 
-	mergedCfg := config.MergeWithDefaults(cfg)
-	outputRoot := filepath.Join(projectRoot, mergedCfg.Defaults.OutputRoot)
+// maxRunIDAttempts bounds how many run numbers createRunDir tries when other runs claim them first
+const maxRunIDAttempts = 100
 
-	// Count runs before regenerating
+// createRunDir picks a run ID in the given runIdFormat and creates its directory under
+// outputRoot/runs. The directory is created exclusively, so runs sharing an output root (e.g.
+// a network-mounted .devpipe) fail loudly instead of writing into each other's directory
+func createRunDir(outputRoot, format string) (string, error) {
 	runsDir := filepath.Join(outputRoot, "runs")
-	entries, err := os.ReadDir(runsDir)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "ERROR: failed to read runs directory: %v\n", err)
-		os.Exit(1)
+	if err := os.MkdirAll(runsDir, 0o755); err != nil {
+		return "", err
+	}
+	if format == "counter" {
+		return claimRunNumber(outputRoot, runsDir)
 	}
-	numRuns := len(entries)
 
-	// Regenerate all reports
-	if err := dashboard.GenerateDashboardWithOptions(outputRoot, version, true, ""); err != nil {
-		fmt.Fprintf(os.Stderr, "ERROR: failed to regenerate reports: %v\n", err)
-		os.Exit(1)
+	hostname := ""
+	if format == "hostname" {
+		hostname, _ = os.Hostname()
+	}
+	runID := makeRunID(time.Now(), hostname)
+	if err := os.Mkdir(filepath.Join(runsDir, runID), 0o755); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return "", fmt.Errorf("run directory %s already exists", filepath.Join(runsDir, runID))
+		}
+		return "", err
 	}
+	return runID, nil
+}
 
-	duration := time.Since(startTime)
-	fmt.Printf("✓ Regenerated %d reports in %s\n", numRuns, duration.Round(time.Millisecond))
-	fmt.Printf("📊 Dashboard: %s\n", filepath.Join(outputRoot, "report.html"))
+// makeRunID returns the UTC timestamp, the short host name when given, and a random suffix,
+// e.g. 2024-05-01T10-00-00Z_3f9a2c. IDs sort by start time
+func makeRunID(now time.Time, hostname string) string {
+	id := now.UTC().Format("2006-01-02T15-04-05Z")
+	if host := sanitizeHostname(hostname); host != "" {
+		id += "_" + host
+	}
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	return id + "_" + hex.EncodeToString(suffix)
 }
 
-// getTerminalWidth returns the current terminal width, defaulting to 160 if unable to detect
-func getTerminalWidth() int {
-	// Try to get terminal width using stty
-	cmd := exec.Command("stty", "size")
-	cmd.Stdin = os.Stdin
-	out, err := cmd.Output()
-	if err == nil {
-		var rows, cols int
-		if _, err := fmt.Sscanf(string(out), "%d %d", &rows, &cols); err == nil && cols > 0 {
-			return cols
+// sanitizeHostname keeps the first label of a host name, lowercased and limited to
+// characters that are safe in a directory name
+func sanitizeHostname(hostname string) string {
+	label, _, _ := strings.Cut(strings.ToLower(hostname), ".")
+	var b strings.Builder
+	for _, r := range label {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
+			b.WriteRune(r)
 		}
 	}
-
-	// Default to 160 columns if we can't detect
-	return 160
+	return b.String()
 }
 
-// wrapText wraps text to specified width, breaking on word boundaries
-func wrapText(text string, width int) []string {
-	if text == "" {
-		return []string{}
+// claimRunNumber hands out the next run number, zero-padded to six digits. It starts after the
+// higher of the persisted counter and the highest numbered run directory, and moves on to the
+// next number when another run has already created that directory
+func claimRunNumber(outputRoot, runsDir string) (string, error) {
+	counterPath := filepath.Join(outputRoot, runCounterFile)
+	last := 0
+	if data, err := os.ReadFile(counterPath); err == nil {
+		last, _ = strconv.Atoi(strings.TrimSpace(string(data)))
 	}
-
-	var lines []string
-	words := strings.Fields(text)
-	if len(words) == 0 {
-		return []string{}
+	if entries, err := os.ReadDir(runsDir); err == nil {
+		for _, entry := range entries {
+			if n, err := strconv.Atoi(entry.Name()); err == nil && entry.IsDir() && n > last {
+				last = n
+			}
+		}
 	}
 
-	currentLine := words[0]
-	for _, word := range words[1:] {
-		if len(currentLine)+1+len(word) <= width {
-			currentLine += " " + word
-		} else {
-			lines = append(lines, currentLine)
-			currentLine = word
+	for n := last + 1; n <= last+maxRunIDAttempts; n++ {
+		runID := fmt.Sprintf("%06d", n)
+		err := os.Mkdir(filepath.Join(runsDir, runID), 0o755)
+		if errors.Is(err, fs.ErrExist) {
+			continue // Claimed by another run
 		}
+		if err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(counterPath, []byte(strconv.Itoa(n)+"\n"), 0o644); err != nil {
+			return "", fmt.Errorf("failed to update %s: %w", counterPath, err)
+		}
+		return runID, nil
 	}
-	if currentLine != "" {
-		lines = append(lines, currentLine)
-	}
-
-	return lines
+	return "", fmt.Errorf("no free run number after %06d in %s", last+maxRunIDAttempts, runsDir)
 }
 
-// truncate truncates a string to the specified length with "..." if needed
-func truncate(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
-	}
-	if maxLen <= 3 {
-		return s[:maxLen]
+func runTask(ctx context.Context, st model.TaskDefinition, runDir, logDir string, dryRun bool, verbose bool, renderer *ui.Renderer, tracker *ui.AnimatedTaskTracker, outputMu *sync.Mutex, waitForPrev chan struct{}, taskDone chan struct{}) (model.TaskResult, *bytes.Buffer, error) {
+	res := model.TaskResult{
+		ID:               st.ID,
+		Name:             st.Name,
+		Desc:             st.Desc,
+		Phase:            st.Phase,
+		Type:             st.Type,
+		Status:           model.StatusPending,
+		Command:          st.Command,
+		Workdir:          st.Workdir,
+		LogPath:          "",
+		EstimatedSeconds: st.EstimatedSeconds,
+		Labels:           st.Labels,
 	}
-	return s[:maxLen-3] + "..."
-}
 
-// phaseEmoji returns an emoji for a phase based on its name
-func phaseEmoji(phaseName string) string {
-	// Normalize to lowercase for matching
-	name := strings.ToLower(phaseName)
+	// Create a buffer to capture all output for this task
+	var taskOutputBuffer bytes.Buffer
 
-	// Map phase names/keywords to emojis
-	emojiMap := map[string]string{
-		"validation":  "🧪",  // Test tube for validation/testing
-		"test":        "🧪",  // Test tube
-		"testing":     "🧪",  // Test tube
-		"build":       "📦",  // Package for build
-		"package":     "📦",  // Package
-		"compile":     "🔨",  // Hammer for compilation
-		"deploy":      "🚀",  // Rocket for deployment
-		"release":     "🚀",  // Rocket for release
-		"lint":        "🔍",  // Magnifying glass for linting
-		"security":    "🔒",  // Lock for security
-		"e2e":         "🎯",  // Target for end-to-end tests
-		"end-to-end":  "🎯",  // Target
-		"integration": "🔗",  // Link for integration
-		"setup":       "⚙️", // Gear for setup
-		"cleanup":     "🧹",  // Broom for cleanup
-		"docs":        "📚",  // Books for documentation
-		"publish":     "📤",  // Outbox for publishing
+	logPath := filepath.Join(logDir, fmt.Sprintf("%s.log", st.ID))
+	res.LogPath = logPath
+	if st.LogFormat == tasklog.FormatJSONL {
+		res.LogFormat = st.LogFormat
 	}
 
-	// Check for exact match first
-	if emoji, ok := emojiMap[name]; ok {
-		return emoji
-	}
+	if dryRun {
+		res.Status = model.StatusSkipped
+		res.Skipped = true
+		res.SkipReason = "dry-run"
 
-	// Check if any keyword is contained in the phase name
-	for keyword, emoji := range emojiMap {
-		if strings.Contains(name, keyword) {
-			return emoji
+		// Explain what would run, in task order like real output
+		plan := dryRunPlan(st, renderer, verbose)
+
+		// requiredEnv is checked even though nothing runs, so a dry run shows the environment is ready
+		if envErr := requiredEnvError(st); envErr != nil {
+			res.Status = model.StatusFail
+			res.Skipped = false
+			res.SkipReason = ""
+			plan = strings.TrimSuffix(plan, "\n") + fmt.Sprintf("%s%s %s %v\n\n", renderer.Prefix(st.ID), renderer.Symbol("FAIL"), renderer.Red("FAIL"), envErr)
+		}
+		if tracker != nil {
+			taskOutputBuffer.WriteString(plan)
+		} else {
+			if waitForPrev != nil {
+				<-waitForPrev
+			}
+			if !renderer.IsQuiet() {
+				fmt.Print(plan)
+			}
+			close(taskDone)
 		}
+		return res, &taskOutputBuffer, nil
 	}
 
-	// Default emoji
-	return "📋" // Clipboard as default
-}
+	// Quiet mode (non-animated) buffers output and only shows it if the task fails
+	quiet := tracker == nil && renderer.IsQuiet()
 
-// loadTaskAveragesLast25 loads task average durations from last 25 runs
-func loadTaskAveragesLast25(outputRoot string) map[string]float64 {
-	averages := make(map[string]float64)
+	// Wait for our turn to display output (non-animated mode only)
+	if tracker == nil {
+		// Wait for previous task to finish (if there is one)
+		if waitForPrev != nil {
+			<-waitForPrev
+		}
 
-	summaryPath := filepath.Join(outputRoot, "summary.json")
-	data, err := os.ReadFile(summaryPath)
-	if err != nil {
-		return averages // No history yet
-	}
+		// Interrupted while waiting: never start the command
+		if ctx.Err() != nil {
+			res.Status = model.StatusSkipped
+			res.Skipped = true
+			res.SkipReason = "interrupted"
+			close(taskDone)
+			return res, &taskOutputBuffer, ctx.Err()
+		}
 
-	var summary struct {
-		TaskStatsLast25 map[string]struct {
-			AvgDuration float64 `json:"avgDuration"`
-		} `json:"taskStatsLast25"`
+		// Now we can stream output (quiet mode shows nothing unless the task fails)
+		if !quiet {
+			if verbose {
+				fmt.Printf("%s%s    %s\n", renderer.Prefix(st.ID), renderer.Blue("RUN"), st.Command)
+			} else {
+				fmt.Printf("%s%s\n", renderer.Prefix(st.ID), renderer.Blue("RUN"))
+			}
+		}
+	} else {
+		// Animated mode: buffer the RUN message with a blank line before it
+		renderer.RenderTaskStart(st.ID, st.Command, verbose)
+		taskOutputBuffer.WriteString("\n") // Blank line before task
+		if verbose {
+			taskOutputBuffer.WriteString(fmt.Sprintf("%s%s    %s\n", renderer.Prefix(st.ID), renderer.Blue("RUN"), st.Command))
+		} else {
+			taskOutputBuffer.WriteString(fmt.Sprintf("%s%s\n", renderer.Prefix(st.ID), renderer.Blue("RUN")))
+		}
+	}
+
+	start := time.Now().UTC()
+	res.StartTime = start.Format(time.RFC3339)
+	res.Status = model.StatusRunning
+
+	// Update tracker if animated
+	if tracker != nil {
+		tracker.UpdateTask(st.ID, "RUNNING", 0)
+	}
+
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: cannot create log file %s: %v\n", logPath, err)
+		res.Status = model.StatusFail
+		return res, &taskOutputBuffer, err
+	}
+	defer func() {
+		if err := logFile.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to close log file: %v\n", err)
+		}
+	}()
+
+	// Setup output handling
+	var bufferMu sync.Mutex
+	var stdoutWriter, stderrWriter *lineWriter
+
+	if tracker != nil || quiet {
+		// Animated or quiet mode: buffer output for sequential display
+		stdoutWriter = &lineWriter{taskID: st.ID, stream: "stdout", file: logFile, outputBuffer: &taskOutputBuffer, mu: &bufferMu, renderer: renderer}
+		stderrWriter = &lineWriter{taskID: st.ID, stream: "stderr", file: logFile, outputBuffer: &taskOutputBuffer, mu: &bufferMu, renderer: renderer}
+	} else {
+		// Non-animated mode: stream output directly (we already have the turn)
+		stdoutWriter = &lineWriter{taskID: st.ID, stream: "stdout", file: logFile, console: os.Stdout, mu: &bufferMu, renderer: renderer}
+		stderrWriter = &lineWriter{taskID: st.ID, stream: "stderr", file: logFile, console: os.Stderr, mu: &bufferMu, renderer: renderer}
+	}
+	limit := &outputLimit{max: st.MaxLogBytes}
+	masker, _ := redact.New(st.MaskPatterns) // Validated with the config
+	lastOutput := start
+	match := newOutputMatch(st)
+	for _, w := range []*lineWriter{stdoutWriter, stderrWriter} {
+		w.limit = limit
+		w.masker = masker
+		w.match = match
+		w.sink = outputSink
+		w.sanitize = st.SanitizeOutput
+		w.lastOutput = &lastOutput
+		w.timestamps = st.Timestamps
+		w.timestampLogs = st.TimestampsInLogs
+		w.logFormat = st.LogFormat
+		w.showStream = verbose
+		w.start = start
+	}
+	// Start ticker to update progress during execution
+	var tickerDone chan struct{}
+	if tracker != nil {
+		tickerDone = make(chan struct{})
+		go func() {
+			ticker := time.NewTicker(100 * time.Millisecond)
+			defer ticker.Stop()
+			startTime := time.Now()
+
+			for {
+				select {
+				case <-tickerDone:
+					return
+				case <-ticker.C:
+					elapsed := time.Since(startTime).Seconds()
+					tracker.UpdateTask(st.ID, "RUNNING", elapsed)
+				}
+			}
+		}()
+	}
+
+	// Streamed output: reassure that a silent task is still running
+	var heartbeatDone, heartbeatStopped chan struct{}
+	if tracker == nil && !quiet && st.HeartbeatSeconds > 0 {
+		heartbeatDone, heartbeatStopped = make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(heartbeatStopped)
+			heartbeat(os.Stdout, renderer, st.ID, time.Duration(st.HeartbeatSeconds)*time.Second, start, &lastOutput, &bufferMu, heartbeatDone)
+		}()
+	}
+
+	// requiredEnv: fail with the missing names instead of a cryptic error from the command
+	if envErr := requiredEnvError(st); envErr != nil {
+		_, _ = fmt.Fprintf(stderrWriter, "ERROR: %v\n", envErr)
+		err = envErr
+	}
+
+	// createWorkdir: make the workdir first; otherwise a missing one fails like a missing command
+	if err == nil && st.CreateWorkdir {
+		if mkErr := os.MkdirAll(st.Workdir, 0o755); mkErr != nil {
+			_, _ = fmt.Fprintf(stderrWriter, "ERROR: cannot create workdir %s: %v\n", st.Workdir, mkErr)
+			err = mkErr
+		}
+	}
+	// fixMode = "before": fix first, so an always-fixable task checks the fixed files instead
+	// of failing once and being fixed after its phase. The check decides the status either way
+	if err == nil && fixesBefore(st) {
+		stdoutWriter.match, stderrWriter.match = nil, nil // failOnMatch and passOnMatch look at the check
+		_, _ = fmt.Fprintf(stdoutWriter, "--- Auto-fix: %s ---\n", st.FixCommand)
+		fixStart := time.Now()
+		_, _, fixErr := newExecutor(st).Run(ctx, fixTask(st), stdoutWriter, stderrWriter)
+		res.FixDurationMs = time.Since(fixStart).Milliseconds()
+		res.FixCommand = st.FixCommand
+		res.FixedBefore = true
+		if fixErr != nil && ctx.Err() == nil {
+			if missing := missingCommand(fixErr, hostCommand(st, st.FixCommand), st.Workdir); missing != "" {
+				res.FixNotFound = missing
+				fixErr = fmt.Errorf("fix command not found: %s", missing)
+			}
+			stderrWriter.endLine()
+			_, _ = fmt.Fprintf(stderrWriter, "devpipe: fix failed (%v), checking anyway\n", fixErr)
+		}
+		stdoutWriter.endLine()
+		stderrWriter.endLine()
+		_, _ = fmt.Fprintf(stdoutWriter, "--- Check: %s ---\n", st.Command)
+		stdoutWriter.match, stderrWriter.match = match, match
+	}
+
+	exitCode := -1 // Stays -1 when the checks above fail
+	if err == nil && ctx.Err() == nil {
+		exitCode, res.Usage, err = newExecutor(st).Run(ctx, st, stdoutWriter, stderrWriter)
+	}
+	stdoutWriter.flushLog()
+	stderrWriter.flushLog()
+
+	// Stop ticker
+	if tickerDone != nil {
+		close(tickerDone)
+	}
+	if heartbeatDone != nil {
+		close(heartbeatDone)
+		<-heartbeatStopped
+	}
+
+	end := time.Now().UTC()
+	res.EndTime = end.Format(time.RFC3339)
+	res.DurationMs = end.Sub(start).Milliseconds()
+	elapsed := end.Sub(start).Seconds()
+
+	// Cancelled by Ctrl-C/SIGTERM: the process group was killed, so the result is meaningless
+	if ctx.Err() != nil {
+		exitCode = 130
+		res.Status = model.StatusFail
+		res.ExitCode = &exitCode
+		res.Interrupted = true
+
+		line := fmt.Sprintf("%s%s %s (%dms)\n", renderer.Prefix(st.ID), renderer.Symbol("FAIL"), renderer.Red("INTERRUPTED"), res.DurationMs)
+		if tracker != nil {
+			tracker.UpdateTask(st.ID, "FAIL", elapsed)
+			taskOutputBuffer.WriteString(line)
+		} else {
+			if quiet {
+				taskOutputBuffer.WriteString(line)
+			} else {
+				fmt.Print(line)
+			}
+			close(taskDone)
+		}
+		return res, &taskOutputBuffer, ctx.Err()
+	}
+
+	// Exit codes listed in allowExitCodes count as success (metrics are still parsed below)
+	if err != nil && exitCode >= 0 && exitCodeAllowed(exitCode, st.AllowExitCodes) {
+		res.AllowedExitCode = true
+		renderer.Verbose(verbose, "%s Exit code %d allowed by allowExitCodes", st.ID, exitCode)
+		err = nil
+	}
+
+	// passOnMatch and failOnMatch correct tools whose exit code does not tell whether they failed
+	err = match.apply(exitCode, err, &res)
+	if res.PassedOnMatch != "" {
+		renderer.Verbose(verbose, "%s Exit code %d overridden by passOnMatch: %s", st.ID, exitCode, res.MatchedLine)
+	}
+	if res.FailedOnMatch != "" {
+		// Always show this error (not just in verbose)
+		fmt.Fprintf(os.Stderr, "%s%sERROR: output matched failOnMatch %q: %s\n", renderer.Prefix(st.ID), renderer.Icon("❌"), res.FailedOnMatch, res.MatchedLine)
+	}
+
+	if err != nil {
+		if exitCode < 0 {
+			exitCode = 1
+		}
+		res.Status = model.StatusFail
+		if st.ContinueOnError {
+			res.Status = model.StatusWarn
+		}
+		res.ExitCode = &exitCode
+
+		// A tool that is not installed looks like any other failure, so name it
+		if missing := missingCommand(err, hostCommand(st, st.Command), st.Workdir); missing != "" {
+			res.NotFound = missing
+			fmt.Fprintf(os.Stderr, "%s%sERROR: command not found: %s (is it installed and on PATH?)\n", renderer.Prefix(st.ID), renderer.Icon("❌"), missing)
+		}
+
+		// Parse output even on failure (especially useful for SARIF/JUnit)
+		// This allows us to show what failed in the dashboard
+		if st.OutputType != "" && st.OutputPath != "" {
+			renderer.Verbose(verbose, "%s Task failed, but attempting to parse output: type=%s, path=%s", st.ID, st.OutputType, st.OutputPath)
+			res.Metrics = parseTaskMetrics(st, renderer, verbose)
+			if res.Metrics != nil {
+				renderer.Verbose(verbose, "%s Output parsed successfully despite failure: %+v", st.ID, res.Metrics.Data)
+			}
+		}
+
+		symbol, statusText := renderer.Symbol("FAIL"), renderer.Red("FAIL")
+		if res.Status == model.StatusWarn {
+			symbol, statusText = renderer.Symbol("WARN"), renderer.Yellow("WARN")+" (continueOnError)"
+		}
+
+		// Update tracker with final status
+		if tracker != nil {
+			tracker.UpdateTask(st.ID, string(res.Status), elapsed)
+			renderer.RenderTaskComplete(st.ID, string(res.Status), &exitCode, res.DurationMs, verbose)
+
+			// Also buffer the failure message for the output section
+			taskOutputBuffer.WriteString(fmt.Sprintf("%s%s %s (%dms)\n", renderer.Prefix(st.ID), symbol, statusText, res.DurationMs))
+		} else if quiet {
+			// Quiet mode: the buffered output is printed with the failure
+			taskOutputBuffer.WriteString(fmt.Sprintf("%s%s %s (%dms)\n\n", renderer.Prefix(st.ID), symbol, statusText, res.DurationMs))
+			close(taskDone)
+		} else {
+			// Stream the failure message with color
+			fmt.Printf("%s%s %s (%dms)\n\n", renderer.Prefix(st.ID), symbol, statusText, res.DurationMs)
+
+			// Signal that this task is done streaming
+			close(taskDone)
+		}
+		return res, &taskOutputBuffer, err
+	}
+
+	res.Status = model.StatusPass
+	res.ExitCode = &exitCode
+
+	// Parse output if configured
+	if st.OutputType != "" && st.OutputPath != "" {
+		renderer.Verbose(verbose, "%s Parsing output: type=%s, path=%s", st.ID, st.OutputType, st.OutputPath)
+		res.Metrics = parseTaskMetrics(st, renderer, verbose)
+		if res.Metrics != nil {
+			renderer.Verbose(verbose, "%s Output parsed successfully: %+v", st.ID, res.Metrics.Data)
+		}
+		// Output parsing failed - this means either:
+		// 1. File doesn't exist (will be caught below)
+		// 2. Invalid format (error already printed)
+		// 3. Parse error (error already printed)
+		// We'll fail the task below if file is missing/empty, or here if it's a parse/format error
+
+		// Validate artifacts if output path specified (every file a glob matches)
+		artifactPath := resolveOutputPath(st, st.OutputPath)
+		var artifactSize int64
+		files, globErr := outputFiles(st)
+		artifactOK := globErr == nil
+		if globErr != nil {
+			// Always show this error (not just in verbose)
+			fmt.Fprintf(os.Stderr, "%s%sERROR: %v\n", renderer.Prefix(st.ID), renderer.Icon("❌"), globErr)
+		}
+		for _, file := range files {
+			path := resolveOutputPath(st, file)
+			info, err := os.Stat(path)
+			if err == nil && info.Size() > 0 {
+				artifactSize += info.Size()
+				continue
+			}
+			// Artifact missing or empty - fail the task
+			artifactOK = false
+			if err != nil {
+				// Always show this error (not just in verbose)
+				fmt.Fprintf(os.Stderr, "%s%sERROR: Output file not found: %s\n", renderer.Prefix(st.ID), renderer.Icon("❌"), file)
+			} else {
+				// Always show this error (not just in verbose)
+				fmt.Fprintf(os.Stderr, "%s%sERROR: Output file is empty: %s\n", renderer.Prefix(st.ID), renderer.Icon("❌"), file)
+			}
+			renderer.Verbose(verbose, "%s Full path: %s", st.ID, path)
+			break
+		}
+		if !artifactOK {
+			res.Status = model.StatusFail
+		} else if res.Metrics == nil {
+			// File exists but output parsing failed (invalid format or parse error)
+			res.Status = model.StatusFail
+			// Error already printed by parseTaskMetrics
+			renderer.Verbose(verbose, "%s Output validation FAILED: file exists but parsing failed", st.ID)
+		} else {
+			// Artifact exists, has size, and metrics parsed successfully
+			res.Metrics.Data["path"] = artifactPath
+			res.Metrics.Data["size"] = artifactSize
+			if len(files) > 1 {
+				res.Metrics.Data["files"] = len(files)
+			}
+
+			renderer.Verbose(verbose, "%s Artifact validation PASSED: %s (%d file(s), %d bytes)", st.ID, artifactPath, len(files), artifactSize)
+
+			// Fail on SARIF/lint findings above the configured thresholds
+			if msg := metrics.SARIFThresholdFailure(res.Metrics, st.SarifFailOn, st.SarifMaxIssues); msg != "" {
+				res.Status = model.StatusFail
+				// Always show this error (not just in verbose)
+				fmt.Fprintf(os.Stderr, "%s%sERROR: %s\n", renderer.Prefix(st.ID), renderer.Icon("❌"), msg)
+			}
+
+			// Copy output to run directory for historical preservation, or just record where it is
+			if st.SkipArtifactCopy {
+				res.OutputFile = artifactPath
+				renderer.Verbose(verbose, "%s Output not copied (copyArtifacts = false): %s", st.ID, artifactPath)
+			} else {
+				for _, file := range files {
+					destPath := artifactDest(runDir, st, file)
+					if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+						renderer.Verbose(verbose, "%s Failed to create output directory: %v", st.ID, err)
+					} else if content, err := os.ReadFile(resolveOutputPath(st, file)); err != nil {
+						renderer.Verbose(verbose, "%s Failed to read output for copying: %v", st.ID, err)
+					} else if err := os.WriteFile(destPath, content, 0644); err != nil {
+						renderer.Verbose(verbose, "%s Failed to copy output: %v", st.ID, err)
+					} else {
+						renderer.Verbose(verbose, "%s Output copied to: %s", st.ID, destPath)
+						// The dashboard links a single output file
+						if rel, err := filepath.Rel(runDir, destPath); err == nil && len(files) == 1 {
+							res.Artifact = filepath.ToSlash(rel)
+						}
+					}
+				}
+			}
+		}
+	} else {
+		renderer.Verbose(verbose, "%s No output configured (type=%s, path=%s)", st.ID, st.OutputType, st.OutputPath)
+	}
+
+	// Flag tasks that pass vacuously: no output, no metrics, and almost no runtime
+	// (usually a typo'd command that still exits 0)
+	producedOutput := stdoutWriter.hasOutput() || stderrWriter.hasOutput()
+	if res.Status == model.StatusPass && isSilentTask(producedOutput, res.Metrics, res.DurationMs) && st.EmptyOutput != "ignore" {
+		res.NoOutput = true
+		warning := fmt.Sprintf("%s%s%s\n", renderer.Prefix(st.ID), renderer.Icon("⚠️ "), renderer.Yellow("WARNING: task produced no output — verify the command"))
+		if tracker != nil || quiet {
+			taskOutputBuffer.WriteString(warning)
+		} else {
+			fmt.Print(warning)
+		}
+		if st.EmptyOutput == "fail" {
+			res.Status = model.StatusFail
+		}
+	}
+
+	// Advisory tasks report output and threshold failures without failing the pipeline
+	if res.Status == model.StatusFail && st.ContinueOnError {
+		res.Status = model.StatusWarn
+	}
+
+	// Update tracker with final status
+	if tracker != nil {
+		tracker.UpdateTask(st.ID, string(res.Status), elapsed)
+		renderer.RenderTaskComplete(st.ID, string(res.Status), &exitCode, res.DurationMs, verbose)
+
+		// Also buffer the completion message for the output section
+		symbol := renderer.Symbol(string(res.Status))
+		var statusText string
+
+		switch res.Status {
+		case model.StatusPass:
+			statusText = renderer.Green(string(res.Status))
+		case model.StatusFail:
+			statusText = renderer.Red(string(res.Status))
+		case model.StatusWarn, model.StatusSkipped:
+			statusText = renderer.Yellow(string(res.Status))
+		default:
+			statusText = string(res.Status)
+		}
+
+		if verbose && exitCode != 0 {
+			taskOutputBuffer.WriteString(fmt.Sprintf("%s%s %s (exit %d, %dms)\n", renderer.Prefix(st.ID), symbol, statusText, exitCode, res.DurationMs))
+		} else {
+			taskOutputBuffer.WriteString(fmt.Sprintf("%s%s %s (%dms)\n", renderer.Prefix(st.ID), symbol, statusText, res.DurationMs))
+		}
+	} else {
+		// Stream the completion message for non-animated mode with colors
+		symbol := renderer.Symbol(string(res.Status))
+		var statusText string
+
+		switch res.Status {
+		case model.StatusPass:
+			statusText = renderer.Green(string(res.Status))
+		case model.StatusFail:
+			statusText = renderer.Red(string(res.Status))
+		case model.StatusWarn, model.StatusSkipped:
+			statusText = renderer.Yellow(string(res.Status))
+		default:
+			statusText = string(res.Status)
+		}
+
+		var line string
+		if verbose && exitCode != 0 {
+			line = fmt.Sprintf("%s%s %s (exit %d, %dms)\n", renderer.Prefix(st.ID), symbol, statusText, exitCode, res.DurationMs)
+		} else {
+			line = fmt.Sprintf("%s%s %s (%dms)\n", renderer.Prefix(st.ID), symbol, statusText, res.DurationMs)
+		}
+
+		if quiet {
+			// Quiet mode: keep the buffered output only for failures (advisory ones included)
+			if res.Status == model.StatusFail || res.Status == model.StatusWarn {
+				taskOutputBuffer.WriteString(line + "\n")
+			} else {
+				taskOutputBuffer.Reset()
+			}
+		} else {
+			fmt.Print(line)
+			fmt.Println() // Blank line after task
+		}
+
+		// Signal that this task is done streaming
+		close(taskDone)
+	}
+
+	return res, &taskOutputBuffer, nil
+}
+
+// artifactDest is where one of a task's output files (see outputFiles) is copied in the run
+// directory: under artifactDir when the task sets one, keeping the layout below the glob's base
+// directory, otherwise under outputs/ keeping the outputPath layout
+func artifactDest(runDir string, st model.TaskDefinition, file string) string {
+	if st.ArtifactDir != "" {
+		name := filepath.Base(file)
+		if isOutputGlob(st.OutputPath) {
+			base, _ := doublestar.SplitPattern(filepath.ToSlash(st.OutputPath))
+			if rel, err := filepath.Rel(filepath.FromSlash(base), file); err == nil {
+				name = rel
+			}
+		}
+		return filepath.Join(runDir, filepath.FromSlash(st.ArtifactDir), name)
+	}
+	outputsDir := filepath.Join(runDir, "outputs")
+	if filepath.IsAbs(file) {
+		// For absolute paths, store under task ID with full path to avoid conflicts
+		// e.g., /foo/bar/file.xml -> outputs/<task-id>/foo/bar/file.xml
+		return filepath.Join(outputsDir, st.ID, file)
+	}
+	// For relative paths, preserve directory structure
+	return filepath.Join(outputsDir, file)
+}
+
+// setLogPreviewLines records how many log lines the run report previews for each result,
+// now that auto-fix has settled whether it failed
+func setLogPreviewLines(results []model.TaskResult, tasks []model.TaskDefinition) {
+	byID := make(map[string]model.TaskDefinition, len(tasks))
+	for _, t := range tasks {
+		byID[t.ID] = t
+	}
+	for i, r := range results {
+		t, ok := byID[r.ID]
+		if !ok {
+			continue
+		}
+		results[i].LogPreviewLines = t.LogPreviewLines
+		if r.Status == model.StatusFail {
+			results[i].LogPreviewLines = t.LogPreviewFailed
+		}
+	}
+}
+
+// hostCommand returns command when it runs on the host, or "" for a task in a container,
+// whose programs cannot be looked up on the host
+func hostCommand(st model.TaskDefinition, command string) string {
+	if st.Image != "" {
+		return ""
+	}
+	return command
+}
+
+// cacheCommand is the command hashed with a task's cacheInputs: its commandArgs when set,
+// since the executor runs those instead of command, or else its command. A task with an image
+// adds the image and container runtime, so the result of one image is not reused for another
+func cacheCommand(st model.TaskDefinition) string {
+	command := st.Command
+	if len(st.CommandArgs) > 0 {
+		command = displayArgs(st.CommandArgs)
+	}
+	if st.Image != "" {
+		command = fmt.Sprintf("%s\x00image\x00%s\x00%s", command, st.ContainerRuntime, st.Image)
+	}
+	return command
+}
+
+// displayArgs joins commandArgs for display, quoting arguments the shell would split or expand
+func displayArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$`|&;<>()*?[]{}~#!") {
+			arg = shellQuote(arg)
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// maskPatterns returns the patterns masked in task output: the built-in secret patterns (unless
+// maskBuiltins is false), maskPatterns, and the values of the maskEnv variables
+func maskPatterns(defaults config.DefaultsConfig, getenv func(string) string) []string {
+	var patterns []string
+	if defaults.MaskBuiltins == nil || *defaults.MaskBuiltins {
+		patterns = append(patterns, redact.Builtin...)
+	}
+	patterns = append(patterns, defaults.MaskPatterns...)
+	return append(patterns, redact.EnvPatterns(defaults.MaskEnv, getenv)...)
+}
+
+// requiredEnvError reports the task's requiredEnv variables that are unset or empty
+func requiredEnvError(st model.TaskDefinition) error {
+	if missing := config.MissingEnv(st.RequiredEnv, os.LookupEnv); len(missing) > 0 {
+		return fmt.Errorf("task %s requires env %s", st.ID, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// dryRunPlan describes what runTask would do for a task under --dry-run: the resolved
+// shell command, workdir, environment, phase and watchPaths outcome (and the estimate with --verbose)
+func dryRunPlan(st model.TaskDefinition, renderer *ui.Renderer, verbose bool) string {
+	var sb strings.Builder
+	prefix := renderer.Prefix(st.ID)
+	line := func(label, value string) {
+		sb.WriteString(fmt.Sprintf("%s  %s %s\n", prefix, renderer.Gray(fmt.Sprintf("%-11s", label+":")), value))
+	}
+
+	sb.WriteString(fmt.Sprintf("%s%s\n", prefix, renderer.Cyan("DRY-RUN")))
+
+	phase := st.Phase
+	if phase == "" {
+		phase = st.PhaseID
+	} else if st.PhaseID != "" {
+		phase = fmt.Sprintf("%s (%s)", st.Phase, st.PhaseID)
+	}
+	if phase != "" {
+		line("phase", phase)
+	}
+	if st.Group != "" {
+		line("group", st.Group+" (runs one at a time with the phase's other tasks in this group)")
+	}
+
+	shell := st.Shell
+	if len(shell) == 0 {
+		shell = config.DefaultShell()
+	}
+	switch {
+	case st.Image != "":
+		spec := containerSpec(st)
+		line("image", st.Image+" (workdir mounted at "+container.WorkDir+")")
+		line("command", displayArgs(append([]string{spec.Runtime}, container.RunArgs(spec)...)))
+	case len(st.CommandArgs) > 0:
+		line("command", st.Command+" (no shell)")
+	default:
+		line("command", strings.Join(shell, " ")+" "+shellQuote(st.Command))
+	}
+	line("workdir", st.Workdir)
+	line("env", "FORCE_COLOR=1 (plus the inherited environment)")
+	if len(st.RequiredEnv) > 0 {
+		missing := config.MissingEnv(st.RequiredEnv, os.LookupEnv)
+		var names []string
+		for _, name := range st.RequiredEnv {
+			if slices.Contains(missing, name) {
+				name += " (missing)"
+			}
+			names = append(names, name)
+		}
+		line("needs env", strings.Join(names, ", "))
+	}
+
+	if len(st.WatchPaths) > 0 {
+		watch := strings.Join(st.WatchPaths, ", ")
+		switch {
+		case st.WatchPathsMatch:
+			watch += " (matched changed files)"
+		case st.Required:
+			watch += " (required, runs regardless of changes)"
+		default:
+			watch += " (not applied)"
+		}
+		line("watchPaths", watch)
+	}
+
+	if verbose {
+		estimate := fmt.Sprintf("%ds (from run history)", st.EstimatedSeconds)
+		if st.IsEstimateGuess {
+			estimate = fmt.Sprintf("%ds? (default guess, no run history)", st.EstimatedSeconds)
+		}
+		line("estimate", estimate)
+	}
+
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// metricsParserTimeout bounds a metricsParser run (a variable so tests can shorten it)
+var metricsParserTimeout = 30 * time.Second
+
+// runMetricsParser runs a task's metricsParser from its workdir with the output file path as
+// the last argument, and reads the JSON object it prints
+func runMetricsParser(st model.TaskDefinition, outputPath string) (*model.TaskMetrics, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), metricsParserTimeout)
+	defer cancel()
+
+	cmd := shellCommand(ctx, st.Shell, st.MetricsParser+" "+shellQuote(outputPath))
+	cmd.Dir = st.Workdir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("timed out after %s", metricsParserTimeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return metrics.ParseCommandOutput(out)
+}
+
+// shellQuote single-quotes s for display as a POSIX shell argument
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// silentTaskThresholdMs is how quickly a task must finish to be considered vacuous when it produced no output
+const silentTaskThresholdMs = 500
+
+// isSilentTask reports whether a task finished almost instantly without producing output or metrics
+func isSilentTask(producedOutput bool, metrics *model.TaskMetrics, durationMs int64) bool {
+	return !producedOutput && metrics == nil && durationMs < silentTaskThresholdMs
+}
+
+func writeRunJSON(runDir string, record model.RunRecord) error {
+	path := filepath.Join(runDir, "run.json")
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// parseTaskMetrics parses output for a completed task. When outputPath is a glob, every
+// matching file is parsed and their metrics are merged
+func parseTaskMetrics(st model.TaskDefinition, renderer *ui.Renderer, verbose bool) *model.TaskMetrics {
+	files, err := outputFiles(st)
+	if err != nil {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "%s%v\n", renderer.Prefix(st.ID), err)
+		}
+		return nil
+	}
+
+	parts := make([]*model.TaskMetrics, 0, len(files))
+	for _, file := range files {
+		m := parseMetricsFile(st, file, renderer, verbose)
+		if m == nil {
+			return nil
+		}
+		parts = append(parts, m)
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return metrics.Merge(parts)
+}
+
+// parseMetricsFile parses one output file, as written in outputPath or matched by its glob
+func parseMetricsFile(st model.TaskDefinition, file string, renderer *ui.Renderer, verbose bool) *model.TaskMetrics {
+	// Build full path to output file (handle both absolute and relative paths)
+	outputPath := resolveOutputPath(st, file)
+
+	// Check if file exists
+	if _, err := os.Stat(outputPath); os.IsNotExist(err) {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "%sOutput file not found: %s\n", renderer.Prefix(st.ID), outputPath)
+		}
+		return nil
+	}
+
+	// Parse based on type
+	switch st.OutputType {
+	case "junit":
+		m, err := metrics.ParseJUnitXML(outputPath)
+		if err != nil {
+			// Always show parse failures (not just in verbose mode)
+			fmt.Fprintf(os.Stderr, "%s%sERROR: Failed to parse JUnit XML: %v\n", renderer.Prefix(st.ID), renderer.Icon("❌"), err)
+			fmt.Fprintf(os.Stderr, "%s         File: %s\n", renderer.Prefix(st.ID), file)
+			return nil
+		}
+		return m
+	case "tap":
+		m, err := metrics.ParseTAP(outputPath)
+		if err != nil {
+			// Always show parse failures (not just in verbose mode)
+			fmt.Fprintf(os.Stderr, "%s%sERROR: Failed to parse TAP: %v\n", renderer.Prefix(st.ID), renderer.Icon("❌"), err)
+			fmt.Fprintf(os.Stderr, "%s         File: %s\n", renderer.Prefix(st.ID), file)
+			return nil
+		}
+		if note, ok := m.Data["planMismatch"].(string); ok && verbose {
+			fmt.Fprintf(os.Stderr, "%s%sWARNING: TAP %s\n", renderer.Prefix(st.ID), renderer.Icon("⚠️ "), note)
+		}
+		return m
+	case "sarif":
+		m, err := metrics.ParseSARIF(outputPath)
+		if err != nil {
+			// Always show parse failures (not just in verbose mode)
+			fmt.Fprintf(os.Stderr, "%s%sERROR: Failed to parse SARIF: %v\n", renderer.Prefix(st.ID), renderer.Icon("❌"), err)
+			fmt.Fprintf(os.Stderr, "%s         File: %s\n", renderer.Prefix(st.ID), file)
+			return nil
+		}
+		return m
+	case "eslint":
+		m, err := metrics.ParseESLint(outputPath)
+		if err != nil {
+			// Always show parse failures (not just in verbose mode)
+			fmt.Fprintf(os.Stderr, "%s%sERROR: Failed to parse ESLint JSON: %v\n", renderer.Prefix(st.ID), renderer.Icon("❌"), err)
+			fmt.Fprintf(os.Stderr, "%s         File: %s\n", renderer.Prefix(st.ID), file)
+			return nil
+		}
+		return m
+	case "checkstyle":
+		m, err := metrics.ParseCheckstyle(outputPath)
+		if err != nil {
+			// Always show parse failures (not just in verbose mode)
+			fmt.Fprintf(os.Stderr, "%s%sERROR: Failed to parse checkstyle XML: %v\n", renderer.Prefix(st.ID), renderer.Icon("❌"), err)
+			fmt.Fprintf(os.Stderr, "%s         File: %s\n", renderer.Prefix(st.ID), file)
+			return nil
+		}
+		return m
+	case "command":
+		m, err := runMetricsParser(st, outputPath)
+		if err != nil {
+			// Always show parse failures (not just in verbose mode)
+			fmt.Fprintf(os.Stderr, "%s%sERROR: metricsParser failed: %v\n", renderer.Prefix(st.ID), renderer.Icon("❌"), err)
+			fmt.Fprintf(os.Stderr, "%s         File: %s\n", renderer.Prefix(st.ID), file)
+			return nil
+		}
+		return m
+	case "artifact":
+		// For artifact format, just verify file exists and has content (already done above)
+		return &model.TaskMetrics{
+			Kind:          "artifact",
+			SummaryFormat: "artifact",
+			Data: map[string]interface{}{
+				"path": outputPath,
+			},
+		}
+	default:
+		// Unknown type - this is an error
+		fmt.Fprintf(os.Stderr, "%s%sERROR: Unknown output type: %s\n", renderer.Prefix(st.ID), renderer.Icon("❌"), st.OutputType)
+		fmt.Fprintf(os.Stderr, "%s         Supported types: junit, tap, sarif, eslint, checkstyle, artifact, command\n", renderer.Prefix(st.ID))
+		return nil
+	}
+}
+
+// resolveOutputPath is the full path of an output file, which is relative to the workdir unless absolute
+func resolveOutputPath(st model.TaskDefinition, file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(st.Workdir, file)
+}
+
+// fileConditionSkip returns why the task's onlyIf or skipIf skips it, or "" when it should run
+func fileConditionSkip(st model.TaskDefinition) string {
+	if st.OnlyIf != "" && !pathMatches(st.Workdir, st.OnlyIf) {
+		return fmt.Sprintf("onlyIf: %s not found", st.OnlyIf)
+	}
+	if st.SkipIf != "" && pathMatches(st.Workdir, st.SkipIf) {
+		return fmt.Sprintf("skipIf: %s exists", st.SkipIf)
+	}
+	return ""
+}
+
+// pathMatches reports whether pattern, a path or glob relative to workdir, matches anything
+func pathMatches(workdir, pattern string) bool {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(workdir, pattern)
+	}
+	matches, err := doublestar.FilepathGlob(pattern)
+	return err == nil && len(matches) > 0
+}
+
+// isOutputGlob reports whether an outputPath is a glob pattern rather than a single file
+func isOutputGlob(outputPath string) bool {
+	return strings.ContainsAny(outputPath, "*?[{")
+}
+
+// outputFiles lists a task's output files as written in outputPath, or the files its glob
+// matches (e.g. "results/junit-*.xml" for a sharded test run) in sorted order
+func outputFiles(st model.TaskDefinition) ([]string, error) {
+	if !isOutputGlob(st.OutputPath) {
+		return []string{st.OutputPath}, nil
+	}
+
+	var matches []string
+	var err error
+	if filepath.IsAbs(st.OutputPath) {
+		matches, err = doublestar.FilepathGlob(st.OutputPath, doublestar.WithFilesOnly())
+	} else {
+		matches, err = doublestar.Glob(os.DirFS(st.Workdir), filepath.ToSlash(st.OutputPath), doublestar.WithFilesOnly())
+		for i := range matches {
+			matches[i] = filepath.FromSlash(matches[i])
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid outputPath pattern %s: %w", st.OutputPath, err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no metrics files matched pattern %s", st.OutputPath)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// copyConfigToRun copies the config file to the run directory
+func copyConfigToRun(runDir, configPath string, mergedCfg *config.Config) error {
+	destPath := filepath.Join(runDir, "config.toml")
+
+	// If no config path specified, try default location
+	if configPath == "" {
+		configPath = "config.toml"
+	}
+
+	// A config piped on stdin is kept so the run can be reproduced
+	if configPath == config.StdinPath {
+		data, err := config.ReadConfigFile(configPath)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(destPath, data, 0644)
+	}
+
+	// If config file exists, copy it
+	if _, err := os.Stat(configPath); err == nil {
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(destPath, data, 0644)
+	}
+
+	// Otherwise, write the merged config as JSON (built-in + defaults)
+	return writeMergedConfig(runDir, mergedCfg)
+}
+
+// writeMergedConfig writes the merged config to the run directory as config.json
+func writeMergedConfig(runDir string, mergedCfg *config.Config) error {
+	data, err := json.MarshalIndent(mergedCfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(runDir, "config.json"), data, 0644)
+}
+
+// lineWriter captures output line by line and sends to tracker
+type lineWriter struct {
+	tracker      *ui.AnimatedTaskTracker
+	taskID       string
+	stream       string // "stdout" or "stderr", recorded in JSONL logs and shown with --verbose
+	file         *os.File
+	buffer       []byte
+	outputBuffer *bytes.Buffer // Buffer all output until task completes
+	mu           *sync.Mutex   // Shared by a task's stdout and stderr writers so lines keep their order
+	console      *os.File      // For streaming output directly
+	renderer     *ui.Renderer  // For colorizing output
+	lines        int           // Number of complete lines written
+
+	timestamps    string    // "clock" or "elapsed" to prefix each line with a timestamp
+	timestampLogs bool      // Also write the timestamp prefix to the log file
+	start         time.Time // Task start, for elapsed timestamps
+	logFormat     string    // "jsonl" writes one record per line to the log file instead of raw output
+	showStream    bool      // Tag console lines with the stream they came from (--verbose)
+	limit         *outputLimit
+	lastOutput    *time.Time         // When the task last wrote anything, shared by its writers (guarded by mu)
+	masker        *redact.Masker     // Masks secrets in each line before it is logged or shown (nil = none)
+	sanitize      bool               // Escape control characters and invalid UTF-8 in shown lines (the log keeps raw bytes)
+	match         *outputMatch       // Watches lines for failOnMatch and passOnMatch (nil = neither set)
+	sink          *logsink.Forwarder // Forwards each masked line to defaults.logSink (nil = none)
+}
+
+// outputSink forwards task output to defaults.logSink for the run (nil = not configured)
+var outputSink *logsink.Forwarder
+
+// outputMatch watches a task's output lines for its failOnMatch and passOnMatch patterns. It
+// is shared by the task's stdout and stderr writers and guarded by their shared mutex
+type outputMatch struct {
+	fail, pass         *regexp.Regexp // nil = not set
+	failLine, passLine string         // First line each pattern matched
+	failed, passed     bool
+}
+
+// newOutputMatch returns the matcher for st's failOnMatch and passOnMatch, or nil when neither is set
+func newOutputMatch(st model.TaskDefinition) *outputMatch {
+	if st.FailOnMatch == "" && st.PassOnMatch == "" {
+		return nil
+	}
+	m := &outputMatch{}
+	// Validated with the config
+	if st.FailOnMatch != "" {
+		m.fail, _ = regexp.Compile(st.FailOnMatch)
+	}
+	if st.PassOnMatch != "" {
+		m.pass, _ = regexp.Compile(st.PassOnMatch)
+	}
+	return m
+}
+
+// check records whether a (masked) output line matches the patterns, ignoring its colors
+func (m *outputMatch) check(line string) {
+	if m == nil {
+		return
+	}
+	line = sanitize.Plain(line)
+	if !m.failed && m.fail != nil && m.fail.MatchString(line) {
+		m.failed, m.failLine = true, line
+	}
+	if !m.passed && m.pass != nil && m.pass.MatchString(line) {
+		m.passed, m.passLine = true, line
+	}
+}
+
+// apply overrides err, the result of a command that exited with exitCode, by the output
+// patterns: a passOnMatch match passes a command that exited non-zero, and a failOnMatch match
+// fails one that succeeded (failOnMatch wins when both match). The pattern and line that
+// decided it are recorded in res
+func (m *outputMatch) apply(exitCode int, err error, res *model.TaskResult) error {
+	switch {
+	case m == nil:
+	case m.failed && err == nil:
+		res.FailedOnMatch, res.MatchedLine = m.fail.String(), m.failLine
+		return fmt.Errorf("output matched failOnMatch %q", m.fail.String())
+	case m.passed && !m.failed && err != nil && exitCode >= 0:
+		res.PassedOnMatch, res.MatchedLine = m.pass.String(), m.passLine
+		return nil
+	}
+	return err
+}
+
+// outputLimit caps the output kept for a task (maxLogBytes). It is shared by the task's stdout
+// and stderr writers and guarded by their shared mutex
+type outputLimit struct {
+	max       int64 // 0 = unlimited
+	written   int64
+	truncated bool
+}
+
+// timestamp returns the prefix for a line emitted now, or "" when timestamps are off
+func (w *lineWriter) timestamp() string {
+	switch w.timestamps {
+	case "clock":
+		return time.Now().Format("15:04:05.000") + " "
+	case "elapsed":
+		return fmt.Sprintf("+%.3fs ", time.Since(w.start).Seconds())
+	}
+	return ""
+}
+
+// logsTimestamps reports whether log file lines get the timestamp prefix too
+func (w *lineWriter) logsTimestamps() bool {
+	return w.timestampLogs && (w.timestamps == "clock" || w.timestamps == "elapsed")
+}
+
+// logsJSONL reports whether the log file gets JSONL records instead of raw output
+func (w *lineWriter) logsJSONL() bool {
+	return w.logFormat == tasklog.FormatJSONL
+}
+
+// logsLines reports whether the log file is written line by line (timestamps, JSONL or
+// masking) rather than passing the raw output through
+func (w *lineWriter) logsLines() bool {
+	return w.logsJSONL() || w.logsTimestamps() || w.masker != nil
+}
+
+// flushLog writes a trailing partial line to the log file. Only needed when
+// the log is written line by line, since it is otherwise unbuffered.
+func (w *lineWriter) flushLog() {
+	if len(w.buffer) == 0 {
+		return
+	}
+	line := w.masker.String(string(w.buffer))
+	w.match.check(line)
+	w.sink.Forward(w.taskID, w.stream, line)
+	if w.logsJSONL() {
+		_ = tasklog.WriteRecord(w.file, time.Now(), w.taskID, w.stream, line) // Best effort log write
+	} else if w.logsTimestamps() {
+		_, _ = w.file.WriteString(w.timestamp() + line) // Best effort log write
+	} else if w.logsLines() {
+		_, _ = w.file.WriteString(line) // Best effort log write
+	}
+}
+
+// endLine ends a trailing partial line, so the next write starts a line of its own
+func (w *lineWriter) endLine() {
+	if w.mu != nil {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+	}
+	if len(w.buffer) > 0 {
+		w.write([]byte("\n"))
+	}
+}
+
+// hasOutput reports whether anything was written, including a trailing partial line
+func (w *lineWriter) hasOutput() bool {
+	return w.lines > 0 || len(w.buffer) > 0
+}
+
+// streamTag labels a console line with its stream when --verbose is set
+func (w *lineWriter) streamTag() string {
+	switch {
+	case !w.showStream || w.stream == "":
+		return ""
+	case w.stream == "stderr":
+		return w.renderer.Yellow("stderr|") + " "
+	default:
+		return w.renderer.Gray("stdout|") + " "
+	}
+}
+
+func (w *lineWriter) Write(p []byte) (n int, err error) {
+	// stdout and stderr are copied by separate goroutines; holding the shared lock
+	// for the whole write keeps their lines whole and in order in the log and console
+	if w.mu != nil {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+	}
+	if w.lastOutput != nil {
+		*w.lastOutput = time.Now()
+	}
+
+	// Past maxLogBytes the output is dropped, but the command keeps running: report the
+	// whole write as consumed so it never blocks on a full pipe
+	if l := w.limit; l != nil && l.max > 0 {
+		if l.truncated {
+			return len(p), nil
+		}
+		if remaining := l.max - l.written; int64(len(p)) > remaining {
+			w.write(p[:remaining])
+			l.written = l.max
+			l.truncated = true
+			if len(w.buffer) > 0 {
+				w.write([]byte("\n")) // End the cut-off line so the marker stands on its own
+			}
+			w.write([]byte(fmt.Sprintf("[output truncated after %d bytes]\n", l.max)))
+			return len(p), nil
+		}
+		l.written += int64(len(p))
+	}
+
+	w.write(p)
+	return len(p), nil
+}
+
+// write sends output to the log file and the console, tracker or buffer; the caller holds the lock
+func (w *lineWriter) write(p []byte) {
+	// Write to log file (unprefixed unless timestamps are also logged, the log is JSONL or
+	// secrets are masked, which all need whole lines)
+	logJSONL := w.logsJSONL()
+	logTimestamps := !logJSONL && w.logsTimestamps()
+	logLines := w.logsLines()
+	if !logLines {
+		_, _ = w.file.Write(p) // Best effort log write
+	}
+
+	// Add to buffer and extract complete lines
+	w.buffer = append(w.buffer, p...)
+
+	// Process complete lines
+	for {
+		idx := bytes.IndexByte(w.buffer, '\n')
+		if idx == -1 {
+			break
+		}
+
+		line := w.masker.String(string(w.buffer[:idx]))
+		w.lines++
+		w.match.check(line)
+		w.sink.Forward(w.taskID, w.stream, line)
+
+		ts := w.timestamp()
+		if logJSONL {
+			_ = tasklog.WriteRecord(w.file, time.Now(), w.taskID, w.stream, line) // Best effort log write
+		} else if logTimestamps {
+			_, _ = w.file.WriteString(ts + line + "\n") // Best effort log write
+		} else if logLines {
+			_, _ = w.file.WriteString(line + "\n") // Best effort log write
+		}
+
+		if w.sanitize {
+			line = sanitize.Line(line)
+		}
+
+		// Prefix line with task ID
+		prefixedLine := w.renderer.Prefix(w.taskID) + w.streamTag() + ts + line
+
+		if w.tracker != nil {
+			w.tracker.AddLogLine(prefixedLine)
+		} else if w.outputBuffer != nil {
+			// Buffer output for sequential display
+			w.outputBuffer.WriteString(prefixedLine)
+			w.outputBuffer.WriteString("\n")
+		} else if w.console != nil {
+			// Stream output directly
+			_, _ = fmt.Fprintln(w.console, prefixedLine) // Best effort console write
+		}
+
+		w.buffer = w.buffer[idx+1:]
+	}
+}
+
+// heartbeat prints "still running (90s)..." for a task each time it has been silent for interval,
+// until done is closed. lastOutput is updated by the task's writers under mu, which the line is
+// also printed under so it never lands in the middle of the task's output
+func heartbeat(out io.Writer, renderer *ui.Renderer, taskID string, interval time.Duration, start time.Time, lastOutput *time.Time, mu *sync.Mutex, done <-chan struct{}) {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-timer.C:
+		}
+
+		mu.Lock()
+		wait := interval - time.Since(*lastOutput)
+		if wait <= 0 {
+			elapsed := int(time.Since(start).Seconds())
+			_, _ = fmt.Fprintf(out, "%s%s\n", renderer.Prefix(taskID), renderer.Gray(fmt.Sprintf("still running (%ds)...", elapsed))) // Best effort console write
+			*lastOutput = time.Now()
+			wait = interval
+		}
+		mu.Unlock()
+		timer.Reset(wait)
+	}
+}
+
+// printVersion prints version information
+func printVersion() {
+	fmt.Printf("devpipe version %s\n", version)
+	fmt.Printf("  commit: %s\n", commit)
+	fmt.Printf("  built: %s\n", buildDate)
+	fmt.Printf("  go: %s\n", runtime.Version())
+	fmt.Printf("  platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+}
+
+// findSimilarCommand finds the most similar command using simple string matching
+func findSimilarCommand(input string, commands []string) string {
+	minDist := len(input)
+	var bestMatch string
+
+	for _, cmd := range commands {
+		dist := levenshteinDistance(input, cmd)
+		// Only suggest if distance is small (typo, not completely different)
+		if dist < minDist && dist <= 2 {
+			minDist = dist
+			bestMatch = cmd
+		}
+	}
+
+	return bestMatch
+}
+
+// levenshteinDistance calculates the edit distance between two strings
+func levenshteinDistance(s1, s2 string) int {
+	if len(s1) == 0 {
+		return len(s2)
+	}
+	if len(s2) == 0 {
+		return len(s1)
+	}
+
+	// Create matrix
+	matrix := make([][]int, len(s1)+1)
+	for i := range matrix {
+		matrix[i] = make([]int, len(s2)+1)
+		matrix[i][0] = i
+	}
+	for j := range matrix[0] {
+		matrix[0][j] = j
+	}
+
+	// Fill matrix
+	for i := 1; i <= len(s1); i++ {
+		for j := 1; j <= len(s2); j++ {
+			cost := 0
+			if s1[i-1] != s2[j-1] {
+				cost = 1
+			}
+			matrix[i][j] = min(
+				matrix[i-1][j]+1,      // deletion
+				matrix[i][j-1]+1,      // insertion
+				matrix[i-1][j-1]+cost, // substitution
+			)
+		}
+	}
+
+	return matrix[len(s1)][len(s2)]
+}
+
+// min returns the minimum of three integers
+func min(a, b, c int) int {
+	if a < b {
+		if a < c {
+			return a
+		}
+		return c
+	}
+	if b < c {
+		return b
+	}
+	return c
+}
+
+// printHelp prints usage information
+func printHelp() {
+	fmt.Println("devpipe - Fast, local pipeline runner")
+	fmt.Println()
+	fmt.Println("USAGE:")
+	fmt.Println("  devpipe [flags]              Run the pipeline")
+	fmt.Println("  devpipe run --task <cmd>...  Run ad-hoc commands as tasks, without editing the config")
+	fmt.Println("  devpipe init [--yes]         Generate config.toml from detected project tasks")
+	fmt.Println("  devpipe list [--json]        List all tasks (--verbose for a table)")
+	fmt.Println("  devpipe validate [files...]  Validate config file(s)")
+	fmt.Println("  devpipe generate-reports     Regenerate all reports with latest template")
+	fmt.Println("  devpipe reindex              Rebuild summary.json from the run history, without the HTML")
+	fmt.Println("  devpipe import <dir>         Copy the runs of another output root into this one and reindex")
+	fmt.Println("  devpipe sarif [options] ...  View SARIF security scan results")
+	fmt.Println("  devpipe diff <runA> <runB>   Compare two runs task by task")
+	fmt.Println("  devpipe history [taskID]     Show per-task pass/fail and duration stats")
+	fmt.Println("  devpipe config [--json]      Show the effective config and where each value came from")
+	fmt.Println("  devpipe doctor               Check that the programs task commands run are installed")
+	fmt.Println("  devpipe serve [--port N]     Serve the HTML dashboard with live run progress")
+	fmt.Println("  devpipe completion <shell>   Print a bash, zsh or fish completion script")
+	fmt.Println("  devpipe version              Show version information")
+	fmt.Println("  devpipe help                 Show this help")
+	fmt.Println()
+	fmt.Println("RUN FLAGS:")
+	fmt.Println("  --config <path>       Path to config file, or - to read it from stdin (default: $DEVPIPE_CONFIG, else discovered)")
+	fmt.Println("  --profile <name>      Lay the config's [profiles.<name>] section over it (default: $DEVPIPE_PROFILE)")
+	fmt.Println("  --no-discovery        Only look for config.toml in the current directory")
+	fmt.Println("  --since <ref>         Git ref to compare against (overrides config)")
+	fmt.Println("  --only <tasks>        Run only specific tasks by id, phase or glob (comma-separated)")
+	fmt.Println("  --skip <task>         Skip tasks by id, phase or glob (can be specified multiple times)")
+	fmt.Println("  --tag <tag>           Run only tasks with this tag (repeatable or comma-separated)")
+	fmt.Println("  --exclude-tag <tag>   Skip tasks with this tag (repeatable or comma-separated)")
+	fmt.Println("  --label <key=value>   Attach a label to the run record and report (repeatable)")
+	fmt.Println("  --ui <mode>           UI mode: basic, full (default: basic)")
+	fmt.Println("  --dashboard           Show dashboard with live progress")
+	fmt.Println("  --serve               Serve the HTML dashboard live on port 8080 and open the browser")
+	fmt.Println("  --jobs <n>            Max tasks to run in parallel per phase (0 or 1 = sequential)")
+	fmt.Println("  --fail-fast           Stop on first task failure")
+	fmt.Println("  --exit-zero           Exit 0 even when tasks fail, to report without blocking")
+	fmt.Println("  --fast                Skip long running tasks")
+	fmt.Println("  --ignore-watch-paths  Ignore watchPaths and run all tasks")
+	fmt.Println("  --changed-only        Run only tasks whose type [fileTypeMap] selects for the changed files")
+	fmt.Println("  --no-cache            Run tasks even when their cacheInputs are unchanged")
+	fmt.Println("  --resume [runID]      Re-run failed/skipped tasks (and later phases) of the latest or given run")
+	fmt.Println("  --dry-run             Do not execute commands; show what each task would run")
+	fmt.Println("  --verbose             Verbose logging")
+	fmt.Println("  --quiet               Only print failing tasks and the final summary")
+	fmt.Println("  --timestamps[=MODE]   Prefix task output lines with a timestamp: clock (default) or elapsed")
+	fmt.Println("  --notify              Send a desktop notification when the pipeline finishes")
+	fmt.Println("  --open                Open the run's HTML report in the browser when the pipeline finishes")
+	fmt.Println("  --strict-env          Fail if a ${VAR} in the config is not defined")
+	fmt.Println("  --github              Emit GitHub Actions annotations for failed tasks (auto in Actions)")
+	fmt.Println("  --github-only         Emit annotations instead of the terminal summary")
+	fmt.Println("  --no-color            Disable colored output")
+	fmt.Println("  --no-emoji            Use ASCII status markers instead of emoji and symbols")
+	fmt.Println("  --flat-summary        List tasks in the summary without phase headers")
+	fmt.Println("  --no-show-failures    Don't repeat the end of each failed task's log after the summary")
+	fmt.Println("  --junit-out <path>    Write a JUnit XML summary of the run")
+	fmt.Println("  --markdown-out <path> Write a Markdown summary of the run")
+	fmt.Println("  --json-out <path>     Write a JSON summary of the run")
+	fmt.Println("  --metrics-out <path>  Write Prometheus textfile metrics for the run")
+	fmt.Println()
+	fmt.Println("RUN SUBCOMMAND FLAGS (plus the run flags above):")
+	fmt.Println("  --task <command>      A command to run as task-1, task-2, ... (repeatable)")
+	fmt.Println("  --parallel            Run the --task commands in parallel instead of one after another")
+	fmt.Println()
+	fmt.Println("VALIDATE FLAGS:")
+	fmt.Println("  --config <path>       Path to config file to validate, or - for stdin (default: config.toml)")
+	fmt.Println("  --strict              Treat warnings as errors (exit 1)")
+	fmt.Println("  --quiet               Only print configs that fail validation")
+	fmt.Println("  --merge-check         Show which file each task comes from when files are merged")
+	fmt.Println("  --json                Print the results as JSON for editors and linters")
+	fmt.Println()
+	fmt.Println("EXAMPLES:")
+	fmt.Println("  devpipe                                    # Run pipeline with default config")
+	fmt.Println("  devpipe --config config/custom.toml        # Run with custom config")
+	fmt.Println("  ./gen-config.sh | devpipe --config -       # Run with a config piped on stdin")
+	fmt.Println("  devpipe --fast --fail-fast                 # Skip slow tasks, stop on failure")
+	fmt.Println("  devpipe run --task 'go test ./...'         # Run a one-off command as a task")
+	fmt.Println("  devpipe list                               # List all task IDs")
+	fmt.Println("  devpipe list --verbose                     # List tasks in table format with details")
+	fmt.Println("  devpipe list --json                        # List tasks as JSON for editors and scripts")
+	fmt.Println("  devpipe validate                           # Validate default config.toml")
+	fmt.Println("  devpipe validate config/*.toml             # Validate all configs in folder")
+	fmt.Println("  devpipe validate --strict --quiet          # Fail CI on warnings, print only problems")
+	fmt.Println("  devpipe generate-reports                   # Regenerate all reports with latest template")
+	fmt.Println("  devpipe reindex                            # Refresh duration stats after deleting or importing runs")
+	fmt.Println("  devpipe import /mnt/ci-artifacts/.devpipe  # Merge CI history into the local dashboard")
+	fmt.Println("  devpipe sarif tmp/codeql/results.sarif     # View CodeQL security scan results")
+	fmt.Println("  devpipe sarif -s tmp/codeql/results.sarif  # Show summary of security issues")
+	fmt.Println("  devpipe diff <runA> <runB>                 # Show which tasks got slower or newly failed")
+	fmt.Println("  devpipe history test --last 50             # How often did 'test' fail recently?")
+	fmt.Println("  devpipe config --since main                # Which settings does my config (or a flag) change?")
+	fmt.Println("  devpipe doctor                             # Are all the tools my tasks need on PATH?")
+	fmt.Println("  devpipe --dashboard --serve                # Watch the run in the terminal and the browser")
+	fmt.Println()
+}
+
+// validateCmd handles the validate subcommand
+func validateCmd() {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	configPath := fs.String("config", "", "Path to config file to validate, or - for stdin (default: config.toml)")
+	strict := fs.Bool("strict", false, "Treat warnings as errors")
+	quiet := fs.Bool("quiet", false, "Only print configs that fail validation")
+	mergeCheck := fs.Bool("merge-check", false, "With several files, show which file each task would come from if they were merged")
+	jsonOut := fs.Bool("json", false, "Print the results as a JSON array, one {file, valid, errors, warnings} entry per file")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s validate [--strict] [--quiet] [--json] [--merge-check] [--config <path>] [files...]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Validate config files (default: config.toml).\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(os.Args[2:]) // Flag parsing
+
+	files := fs.Args()
+	if *configPath != "" {
+		files = append([]string{*configPath}, files...)
+	}
+	if len(files) == 0 {
+		files = []string{"config.toml"}
+		if found := findConfig("", true); found != "" {
+			files = []string{found}
+		}
+	}
+
+	hasErrors := false
+	taskIDs := make(map[string][]string)
+	var entries []config.FileValidationResult
+	for _, file := range files {
+		result, err := config.ValidateConfigFile(file)
+		if err != nil {
+			hasErrors = true
+			if *jsonOut {
+				entries = append(entries, config.FileValidationResult{
+					File:     config.DisplayPath(file),
+					Errors:   []config.ValidationError{{Message: err.Error()}},
+					Warnings: []config.ValidationError{},
+				})
+			} else {
+				fmt.Fprintf(os.Stderr, "❌ ERROR: %v\n", err)
+			}
+			continue
+		}
+		taskIDs[file] = result.TaskIDs
+		if result.Valid {
+			validateWorkdirs(file, result)
+		}
+
+		if *strict {
+			result.PromoteWarnings()
+		}
+		if !result.Valid {
+			hasErrors = true
+		}
+		if *jsonOut {
+			entries = append(entries, config.NewFileValidationResult(file, result))
+			continue
+		}
+		if *quiet && result.Valid {
+			continue
+		}
+		config.PrintValidationResult(file, result)
+	}
+
+	// The same task id in several files is ambiguous once the files are combined
+	var collisions []config.TaskCollision
+	if len(files) > 1 {
+		collisions = config.FindTaskCollisions(files, taskIDs)
+		if len(collisions) > 0 && *strict {
+			hasErrors = true
+		}
+	}
+
+	if *jsonOut {
+		addTaskCollisions(entries, collisions, *strict)
+		if *quiet {
+			entries = slices.DeleteFunc(entries, func(e config.FileValidationResult) bool { return e.Valid })
+		}
+		if err := config.WriteValidationJSON(os.Stdout, entries); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+	} else if len(files) > 1 {
+		if len(collisions) > 0 && (!*quiet || *strict) {
+			printTaskCollisions(collisions, *strict)
+		}
+		if *mergeCheck {
+			printMergeCheck(files, taskIDs)
+		}
+	}
+
+	if hasErrors {
+		os.Exit(1)
+	}
+}
+
+// validateWorkdirs adds an error to result for each task in the config at path whose workdir
+// is missing under the project root a run of that config would use
+func validateWorkdirs(path string, result *config.ValidationResult) {
+	cfg, _, _, _, err := config.LoadConfig(path)
+	if err != nil || cfg == nil {
+		return
+	}
+	mergedCfg := config.MergeWithDefaults(cfg)
+	mergedCfg.ExpandDefaults()
+	cwdGitRoot, cwdInGitRepo := git.DetectProjectRoot()
+	projectRoot := determineProjectRoot(path, mergedCfg, cwdGitRoot, cwdInGitRepo)
+
+	workdirs := config.ValidateWorkdirs(&mergedCfg, projectRoot, nil)
+	if !workdirs.Valid {
+		result.Valid = false
+		result.Errors = append(result.Errors, workdirs.Errors...)
+	}
+}
+
+// addTaskCollisions reports each task id defined by more than one file on the entries of those
+// files, as a warning (an error under --strict) naming the other files
+func addTaskCollisions(entries []config.FileValidationResult, collisions []config.TaskCollision, strict bool) {
+	for _, c := range collisions {
+		files := make([]string, len(c.Files))
+		for i, f := range c.Files {
+			files[i] = config.DisplayPath(f)
+		}
+		for i := range entries {
+			if !slices.Contains(files, entries[i].File) {
+				continue
+			}
+			others := slices.DeleteFunc(slices.Clone(files), func(f string) bool { return f == entries[i].File })
+			problem := config.ValidationError{
+				Field:   "tasks." + c.TaskID,
+				Message: "Task is also defined in " + strings.Join(others, ", "),
+			}
+			if strict {
+				problem.Message += " (warning treated as error by --strict)"
+				entries[i].Errors = append(entries[i].Errors, problem)
+				entries[i].Valid = false
+			} else {
+				entries[i].Warnings = append(entries[i].Warnings, problem)
+			}
+		}
+	}
+}
+
+// printTaskCollisions reports task ids defined by more than one validated file
+func printTaskCollisions(collisions []config.TaskCollision, strict bool) {
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	if strict {
+		fmt.Printf("❌ Found %d task id(s) defined in more than one file (warning treated as error by --strict):\n", len(collisions))
+	} else {
+		fmt.Printf("⚠️  Found %d task id(s) defined in more than one file:\n", len(collisions))
+	}
+	for _, c := range collisions {
+		fmt.Printf("  • [tasks.%s] %s\n", c.TaskID, strings.Join(c.Files, ", "))
+	}
+	fmt.Println()
+}
+
+// printMergeCheck shows, for every task id, which file would define it if the files
+// were merged in the order given (later files override earlier ones)
+func printMergeCheck(files []string, taskIDs map[string][]string) {
+	winner := make(map[string]string)
+	overridden := make(map[string][]string)
+	for _, file := range files {
+		for _, id := range taskIDs[file] {
+			if prev, ok := winner[id]; ok {
+				overridden[id] = append(overridden[id], prev)
+			}
+			winner[id] = file
+		}
+	}
+
+	ids := make([]string, 0, len(winner))
+	maxLen := 0
+	for id := range winner {
+		ids = append(ids, id)
+		if len(id) > maxLen {
+			maxLen = len(id)
+		}
+	}
+	sort.Strings(ids)
+
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Printf("🔀 Merged tasks (%d files, later files override earlier ones):\n", len(files))
+	for _, id := range ids {
+		line := fmt.Sprintf("  %-*s  %s", maxLen, id, winner[id])
+		if prev := overridden[id]; len(prev) > 0 {
+			line += fmt.Sprintf(" (overrides %s)", strings.Join(prev, ", "))
+		}
+		fmt.Println(line)
+	}
+	fmt.Println()
+}
+
+// generateReportsCmd handles the generate-reports subcommand
+func generateReportsCmd() {
+	startTime := time.Now()
+	fmt.Println("Regenerating all reports with latest template...")
+
+	// Determine project root
+	projectRoot, _ := git.DetectProjectRoot()
+
+	// Get output root from default config
+	cfg, _, _, _, err := config.LoadConfig(findConfig("", true))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	mergedCfg := config.MergeWithDefaults(cfg)
+	outputRoot := filepath.Join(projectRoot, mergedCfg.Defaults.OutputRoot)
+
+	// Count runs before regenerating
+	runsDir := filepath.Join(outputRoot, "runs")
+	entries, err := os.ReadDir(runsDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: failed to read runs directory: %v\n", err)
+		os.Exit(1)
+	}
+	numRuns := len(entries)
+	limit := mergedCfg.Defaults.DashboardRecentLimit
+	regenerated := numRuns
+	if regenerated > limit {
+		regenerated = limit
+	}
+
+	// Regenerate the reports of the most recent runs
+	if err := dashboard.GenerateDashboardWithOptions(outputRoot, version, true, limit, "", mergedCfg.Defaults.FlakyThreshold); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: failed to regenerate reports: %v\n", err)
+		os.Exit(1)
+	}
+
+	duration := time.Since(startTime)
+	fmt.Printf("✓ Regenerated %d reports in %s\n", regenerated, duration.Round(time.Millisecond))
+	if numRuns > regenerated {
+		fmt.Printf("  %d older runs kept their existing reports (dashboardRecentLimit = %d)\n", numRuns-regenerated, limit)
+	}
+	fmt.Printf("📊 Dashboard: %s\n", filepath.Join(outputRoot, "report.html"))
+}
+
+// reindexCmd handles the reindex subcommand
+func reindexCmd() {
+	startTime := time.Now()
+
+	// Determine project root
+	projectRoot, _ := git.DetectProjectRoot()
+
+	// Get output root from default config
+	cfg, _, _, _, err := config.LoadConfig(findConfig("", true))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	mergedCfg := config.MergeWithDefaults(cfg)
+	outputRoot := filepath.Join(projectRoot, mergedCfg.Defaults.OutputRoot)
+
+	if _, err := os.Stat(filepath.Join(outputRoot, "runs")); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: failed to read runs directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	result, err := dashboard.Reindex(outputRoot, version, mergedCfg.Defaults.DashboardRecentLimit, mergedCfg.Defaults.FlakyThreshold)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: failed to reindex runs: %v\n", err)
+		os.Exit(1)
+	}
+	for _, err := range result.Unreadable {
+		fmt.Fprintf(os.Stderr, "WARNING: skipped unreadable run: %v\n", err)
+	}
+
+	duration := time.Since(startTime)
+	fmt.Printf("✓ Indexed %d runs in %s", result.Runs, duration.Round(time.Millisecond))
+	if len(result.Unreadable) > 0 {
+		fmt.Printf(" (%d unreadable)", len(result.Unreadable))
+	}
+	fmt.Println()
+	fmt.Printf("📊 Summary: %s\n", filepath.Join(outputRoot, "summary.json"))
+}
+
+// importCmd handles the import subcommand
+func importCmd() {
+	if len(os.Args) != 3 || strings.HasPrefix(os.Args[2], "-") {
+		fmt.Fprintln(os.Stderr, "Usage: devpipe import <output-root>")
+		os.Exit(1)
+	}
+	srcRoot := os.Args[2]
+
+	// Determine project root
+	projectRoot, _ := git.DetectProjectRoot()
+
+	// Get output root from default config
+	cfg, _, _, _, err := config.LoadConfig(findConfig("", true))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	mergedCfg := config.MergeWithDefaults(cfg)
+	outputRoot := filepath.Join(projectRoot, mergedCfg.Defaults.OutputRoot)
+
+	imported, err := dashboard.ImportRuns(outputRoot, srcRoot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		if len(imported.Imported) == 0 {
+			os.Exit(1)
+		}
+	}
+	for _, id := range imported.Incomplete {
+		fmt.Fprintf(os.Stderr, "WARNING: skipped run %s: it has no run.json\n", id)
+	}
+
+	result, reindexErr := dashboard.Reindex(outputRoot, version, mergedCfg.Defaults.DashboardRecentLimit, mergedCfg.Defaults.FlakyThreshold)
+	if reindexErr != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: failed to reindex runs: %v\n", reindexErr)
+		os.Exit(1)
+	}
+	for _, err := range result.Unreadable {
+		fmt.Fprintf(os.Stderr, "WARNING: skipped unreadable run: %v\n", err)
+	}
+
+	fmt.Printf("✓ Imported %d runs from %s", len(imported.Imported), srcRoot)
+	if len(imported.Duplicates) > 0 {
+		fmt.Printf(" (%d already present)", len(imported.Duplicates))
+	}
+	fmt.Println()
+	fmt.Printf("✓ Indexed %d runs\n", result.Runs)
+	if len(imported.Imported) > 0 {
+		fmt.Println("Run 'devpipe generate-reports' to refresh the HTML dashboard")
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// getTerminalWidth returns the current terminal width, defaulting to 160 if unable to detect
+func getTerminalWidth() int {
+	// Try to get terminal width using stty (not available on Windows)
+	if runtime.GOOS != "windows" {
+		cmd := exec.Command("stty", "size")
+		cmd.Stdin = os.Stdin
+		out, err := cmd.Output()
+		if err == nil {
+			var rows, cols int
+			if _, err := fmt.Sscanf(string(out), "%d %d", &rows, &cols); err == nil && cols > 0 {
+				return cols
+			}
+		}
+	}
+
+	// Default to 160 columns if we can't detect
+	return 160
+}
+
+// wrapText wraps text to specified width, breaking on word boundaries
+func wrapText(text string, width int) []string {
+	if text == "" {
+		return []string{}
+	}
+
+	var lines []string
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{}
+	}
+
+	currentLine := words[0]
+	for _, word := range words[1:] {
+		if len(currentLine)+1+len(word) <= width {
+			currentLine += " " + word
+		} else {
+			lines = append(lines, currentLine)
+			currentLine = word
+		}
+	}
+	if currentLine != "" {
+		lines = append(lines, currentLine)
+	}
+
+	return lines
+}
+
+// truncate truncates a string to the specified length with "..." if needed
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	if maxLen <= 3 {
+		return s[:maxLen]
+	}
+	return s[:maxLen-3] + "..."
+}
+
+// applyProfile lays the profile named by --profile, or else DEVPIPE_PROFILE, over cfg
+func applyProfile(cfg *config.Config, flagProfile string) error {
+	name := flagProfile
+	if name == "" {
+		name = os.Getenv(config.ProfileEnvVar)
+	}
+	if name == "" {
+		return nil
+	}
+	if cfg == nil {
+		return fmt.Errorf("profile %q not found: there is no config file", name)
+	}
+	return cfg.ApplyProfile(name)
+}
+
+// emojiEnabled reports whether output uses emoji: not with --no-emoji or defaults.emoji = false
+func emojiEnabled(noEmojiFlag bool, defaults config.DefaultsConfig) bool {
+	return !noEmojiFlag && (defaults.Emoji == nil || *defaults.Emoji)
+}
+
+// phaseEmoji returns an emoji for a phase based on its name
+func phaseEmoji(phaseName string) string {
+	// Normalize to lowercase for matching
+	name := strings.ToLower(phaseName)
+
+	// Map phase names/keywords to emojis
+	emojiMap := map[string]string{
+		"validation":  "🧪",  // Test tube for validation/testing
+		"test":        "🧪",  // Test tube
+		"testing":     "🧪",  // Test tube
+		"build":       "📦",  // Package for build
+		"package":     "📦",  // Package
+		"compile":     "🔨",  // Hammer for compilation
+		"deploy":      "🚀",  // Rocket for deployment
+		"release":     "🚀",  // Rocket for release
+		"lint":        "🔍",  // Magnifying glass for linting
+		"security":    "🔒",  // Lock for security
+		"e2e":         "🎯",  // Target for end-to-end tests
+		"end-to-end":  "🎯",  // Target
+		"integration": "🔗",  // Link for integration
+		"setup":       "⚙️", // Gear for setup
+		"cleanup":     "🧹",  // Broom for cleanup
+		"docs":        "📚",  // Books for documentation
+		"publish":     "📤",  // Outbox for publishing
+	}
+
+	// Check for exact match first
+	if emoji, ok := emojiMap[name]; ok {
+		return emoji
+	}
+
+	// Check if any keyword is contained in the phase name
+	for keyword, emoji := range emojiMap {
+		if strings.Contains(name, keyword) {
+			return emoji
+		}
+	}
+
+	// Default emoji
+	return "📋" // Clipboard as default
+}
+
+// loadTaskAveragesLast25 loads task average durations from last 25 runs. Problems reading
+// the summary are reported to warn, when not nil
+func loadTaskAveragesLast25(outputRoot string, warn io.Writer) map[string]float64 {
+	averages := make(map[string]float64)
+
+	for taskID, stats := range readSummary(outputRoot, warn).TaskStatsLast25 {
+		if stats.AvgDuration > 0 {
+			averages[taskID] = stats.AvgDuration
+		}
+	}
+
+	return averages
+}
+
+// listedTask is one task in the output of devpipe list --json
+type listedTask struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Desc          string   `json:"desc"`
+	Type          string   `json:"type"`
+	Phase         string   `json:"phase"`   // Phase name, empty for tasks before the first phase
+	PhaseID       string   `json:"phaseId"` // Phase header id, e.g. phase-test
+	Command       string   `json:"command"`
+	AvgDurationMs *int64   `json:"avgDurationMs"` // Average over the last 25 runs, null without history
+	WatchPaths    []string `json:"watchPaths"`
+	Tags          []string `json:"tags"`
+}
+
+// newListedTask builds the JSON entry for a resolved task. Lists are never null, so tooling
+// can iterate them without checks
+func newListedTask(id, phase, phaseID string, resolved config.TaskConfig, avgMs float64, hasAvg bool) listedTask {
+	command := resolved.Command
+	if len(resolved.CommandArgs) > 0 {
+		command = displayArgs(resolved.CommandArgs)
+	}
+	listed := listedTask{
+		ID:         id,
+		Name:       resolved.Name,
+		Desc:       resolved.Desc,
+		Type:       resolved.Type,
+		Phase:      phase,
+		PhaseID:    phaseID,
+		Command:    command,
+		WatchPaths: append([]string{}, resolved.WatchPaths...),
+		Tags:       append([]string{}, resolved.Tags...),
+	}
+	if hasAvg {
+		ms := int64(avgMs + 0.5)
+		listed.AvgDurationMs = &ms
+	}
+	return listed
+}
+
+// listCmd handles the list subcommand
+func listCmd() {
+	// Parse flags
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	verbose := fs.Bool("verbose", false, "Show detailed table view with phases")
+	jsonOut := fs.Bool("json", false, "Output tasks as a JSON array")
+	configPath := fs.String("config", "", "Path to config file (default: config.toml)")
+	noEmoji := fs.Bool("no-emoji", false, "Leave emoji out of the --verbose table")
+	_ = fs.Parse(os.Args[2:]) // Flag parsing
+
+	// Load configuration
+	cfg, configTaskOrder, phaseNames, taskToPhase, err := config.LoadConfig(findConfig(*configPath, true))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	if cfg == nil {
+		fmt.Fprintf(os.Stderr, "ERROR: No config.toml found. Create one or specify with --config flag\n")
+		os.Exit(1)
+	}
+
+	// Merge with defaults
+	mergedCfg := config.MergeWithDefaults(cfg)
+
+	// Determine project root
+	projectRoot, _ := git.DetectProjectRoot()
+
+	// Load historical averages
+	outputRoot := filepath.Join(projectRoot, mergedCfg.Defaults.OutputRoot)
+	var summaryWarn io.Writer
+	if *verbose {
+		summaryWarn = os.Stderr
+	}
+	taskAverages := loadTaskAveragesLast25(outputRoot, summaryWarn)
+
+	// Build task list (filter out phase markers)
+	var tasks []struct {
+		id    string
+		task  config.TaskConfig
+		phase string
+	}
+
+	for _, id := range configTaskOrder {
+		// Skip wait markers and phase headers
+		if id == "wait" || strings.HasPrefix(id, "wait-") || strings.HasPrefix(id, "phase-") {
+			continue
+		}
+
+		taskCfg, ok := mergedCfg.Tasks[id]
+		if !ok {
+			continue
+		}
+
+		// Get phase name
+		phaseName := ""
+		if phaseID, ok := taskToPhase[id]; ok {
+			for _, phaseInfo := range phaseNames {
+				if phaseInfo.ID == phaseID {
+					phaseName = phaseInfo.Name
+					break
+				}
+			}
+		}
+
+		tasks = append(tasks, struct {
+			id    string
+			task  config.TaskConfig
+			phase string
+		}{id, taskCfg, phaseName})
+	}
+
+	// JSON mode: one object per task, in config order
+	if *jsonOut {
+		listed := make([]listedTask, 0, len(tasks))
+		for _, t := range tasks {
+			resolved := mergedCfg.ResolveTaskConfig(t.id, t.task, projectRoot)
+			avg, hasAvg := taskAverages[t.id]
+			listed = append(listed, newListedTask(t.id, t.phase, taskToPhase[t.id], resolved, avg, hasAvg))
+		}
+		data, err := json.MarshalIndent(listed, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: failed to encode tasks: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if len(tasks) == 0 {
+		fmt.Fprintln(os.Stderr, "No tasks found in config")
+		return
+	}
+
+	// Simple mode: just list task IDs
+	if !*verbose {
+		for _, t := range tasks {
+			fmt.Println(t.id)
+		}
+		return
+	}
+
+	// Verbose mode: table view grouped by phase
+	showEmoji := emojiEnabled(*noEmoji, mergedCfg.Defaults)
+	fmt.Println("Tasks:")
+	fmt.Println()
+
+	// Group tasks by phase
+	type phaseGroup struct {
+		name  string
+		tasks []struct {
+			id   string
+			task config.TaskConfig
+		}
+	}
+
+	var phases []phaseGroup
+	currentPhase := phaseGroup{name: "(no phase)"}
+
+	for _, t := range tasks {
+		if t.phase != "" && (len(currentPhase.tasks) == 0 || currentPhase.name != t.phase) {
+			// Start new phase
+			if len(currentPhase.tasks) > 0 {
+				phases = append(phases, currentPhase)
+			}
+			currentPhase = phaseGroup{name: t.phase}
+		}
+		currentPhase.tasks = append(currentPhase.tasks, struct {
+			id   string
+			task config.TaskConfig
+		}{t.id, t.task})
+	}
+	if len(currentPhase.tasks) > 0 {
+		phases = append(phases, currentPhase)
+	}
+
+	// Get terminal width
+	termWidth := getTerminalWidth()
+
+	// Column widths (fixed for name, desc, type, duration, command)
+	nameWidth := 40
+	durationWidth := 13 // "⚠️  ~2ms" format (emoji takes more space)
+	typeWidth := 18
+	cmdWidth := 45
+	spacing := 10 // 2 spaces between each column (5 gaps)
+	descWidth := termWidth - nameWidth - durationWidth - typeWidth - cmdWidth - spacing
+	if descWidth < 20 {
+		descWidth = 20 // Minimum description width
+	}
+
+	// Print each phase
+	for _, phase := range phases {
+		// Calculate phase average duration
+		var phaseAvgMs float64
+		var phaseTaskCount int
+		for _, t := range phase.tasks {
+			if avg, ok := taskAverages[t.id]; ok {
+				phaseAvgMs += avg
+				phaseTaskCount++
+			}
+		}
+
+		// Phase header with emoji and duration in a box
+		icon, iconWidth := phaseEmoji(phase.name)+" ", 3 // emoji (2) + space (1)
+		if !showEmoji {
+			icon, iconWidth = "", 0
+		}
+		var phaseText string
+		var durationTextPlain string
+		if phaseTaskCount > 0 {
+			phaseAvgSec := phaseAvgMs / 1000
+			durationTextPlain = fmt.Sprintf(" (~%.1fs)", phaseAvgSec)
+
+			// Gray color for phase duration (grouping, not individual timing)
+			grayDuration := fmt.Sprintf("\033[90m(~%.1fs)\033[0m", phaseAvgSec)
+			phaseText = fmt.Sprintf("%s%s %s", icon, phase.name, grayDuration)
+		} else {
+			phaseText = icon + phase.name
+		}
+		// Calculate visual width: icon + name + duration text + padding (2)
+		visualWidth := iconWidth + len(phase.name) + len(durationTextPlain) + 2
+
+		// Top border
+		fmt.Println("┌" + strings.Repeat("─", visualWidth) + "┐")
+		// Header with bold
+		fmt.Printf("│\033[1m %s \033[0m│\n", phaseText)
+		// Bottom border
+		fmt.Println("└" + strings.Repeat("─", visualWidth) + "┘")
+		fmt.Println()
+
+		// Table header (AVG is right-aligned)
+		fmt.Printf("%-*s  %-*s  %-*s  %-*s  %*s\n", nameWidth, "NAME", descWidth, "DESCRIPTION", typeWidth, "TYPE", cmdWidth, "COMMAND", durationWidth, "AVG")
+		fmt.Printf("%s  %s  %s  %s  %s\n", strings.Repeat("─", nameWidth), strings.Repeat("─", descWidth), strings.Repeat("─", typeWidth), strings.Repeat("─", cmdWidth), strings.Repeat("─", durationWidth))
+
+		// Tasks
+		for _, t := range phase.tasks {
+			resolvedTask := mergedCfg.ResolveTaskConfig(t.id, t.task, projectRoot)
+			if len(resolvedTask.CommandArgs) > 0 {
+				resolvedTask.Command = displayArgs(resolvedTask.CommandArgs)
+			}
+
+			// Add output emoji if present
+			metricsEmoji := ""
+			emojiDisplayWidth := 0
+			if showEmoji && resolvedTask.OutputType != "" {
+				switch resolvedTask.OutputType {
+				case "junit", "tap":
+					metricsEmoji = " 🧪"
+					emojiDisplayWidth = 3 // space + emoji (2 display chars)
+				case "sarif":
+					metricsEmoji = " 🔒"
+					emojiDisplayWidth = 3
+				case "eslint", "checkstyle":
+					metricsEmoji = " 🔍"
+					emojiDisplayWidth = 3
+				case "artifact":
+					metricsEmoji = " 📦"
+					emojiDisplayWidth = 3
+				}
+			}
+
+			// Calculate display widths
+			baseName := resolvedTask.Name
+			idText := t.id
+			// Total display width: name + emoji(if any) + space + id
+			totalDisplayWidth := len(baseName) + emojiDisplayWidth + 1 + len(idText)
+
+			// Truncate if needed
+			if totalDisplayWidth > nameWidth {
+				// Truncate the base name to fit
+				availableForName := nameWidth - emojiDisplayWidth - 1 - len(idText) - 3 // -3 for "..."
+				if availableForName > 0 {
+					baseName = baseName[:availableForName] + "..."
+					totalDisplayWidth = nameWidth
+				} else {
+					// Even the ID is too long, truncate everything
+					baseName = truncate(baseName+metricsEmoji+" "+idText, nameWidth)
+					metricsEmoji = ""
+					idText = ""
+					totalDisplayWidth = nameWidth
+				}
+			}
+
+			// Format with colors: name + emoji, then gray ID
+			var nameFormatted string
+			if idText != "" {
+				nameFormatted = fmt.Sprintf("%s%s \033[90m%s\033[0m", baseName, metricsEmoji, idText)
+			} else {
+				nameFormatted = baseName
+			}
+
+			// Calculate padding needed
+			padding := nameWidth - totalDisplayWidth
+			if padding < 0 {
+				padding = 0
+			}
+
+			// Format type
+			taskType := resolvedTask.Type
+			if taskType == "" {
+				taskType = "-"
+			}
+
+			// Truncate command if too long
+			cmd := resolvedTask.Command
+			if len(cmd) > cmdWidth {
+				cmd = cmd[:cmdWidth-3] + "..."
+			}
+
+			// Truncate description to fit in one line (tags first so they survive truncation)
+			desc := resolvedTask.Desc
+			if resolvedTask.Required {
+				desc = strings.TrimSpace("(required) " + desc)
+			}
+			if len(resolvedTask.Tags) > 0 {
+				desc = strings.TrimSpace("[" + strings.Join(resolvedTask.Tags, ", ") + "] " + desc)
+			}
+			if len(desc) > descWidth {
+				desc = desc[:descWidth-3] + "..."
+			}
+
+			// Format duration with color coding
+			var durationStr string
+			var visualLen int
+			if avgMs, ok := taskAverages[t.id]; ok && avgMs > 0 {
+				avgSec := avgMs / 1000
+				var timeStr string
+				if avgSec < 1 {
+					// Force 1 decimal place for milliseconds too
+					timeStr = fmt.Sprintf("~%.1fms", avgMs)
+				} else if avgSec < 60 {
+					timeStr = fmt.Sprintf("~%.1fs", avgSec)
+				} else {
+					minutes := avgSec / 60
+					timeStr = fmt.Sprintf("~%.1fm", minutes)
+				}
+
+				// Special case: ≤3ms likely means echo/mock, not real timing
+				if avgMs <= 3 {
+					durationStr = fmt.Sprintf("\033[31m[!] %s\033[0m", timeStr) // Red with warning (using [!] instead of emoji)
+					visualLen = 4 + len(timeStr)                                // [!] (3) + space (1) + time
+					// Green shades (fast - instant feedback)
+				} else if avgMs < 100 {
+					durationStr = fmt.Sprintf("\033[38;5;46m%s\033[0m", timeStr) // Bright green
+					visualLen = len(timeStr)
+				} else if avgMs < 500 {
+					durationStr = fmt.Sprintf("\033[38;5;40m%s\033[0m", timeStr) // Medium green
+					visualLen = len(timeStr)
+				} else if avgSec < 1 {
+					durationStr = fmt.Sprintf("\033[38;5;34m%s\033[0m", timeStr) // Darker green
+					visualLen = len(timeStr)
+					// Yellow shades (moderate - acceptable)
+				} else if avgSec < 5 {
+					durationStr = fmt.Sprintf("\033[38;5;226m%s\033[0m", timeStr) // Bright yellow
+					visualLen = len(timeStr)
+				} else if avgSec < 10 {
+					durationStr = fmt.Sprintf("\033[38;5;220m%s\033[0m", timeStr) // Medium yellow
+					visualLen = len(timeStr)
+				} else if avgSec < 20 {
+					durationStr = fmt.Sprintf("\033[38;5;214m%s\033[0m", timeStr) // Darker yellow/amber
+					visualLen = len(timeStr)
+					// Orange shades (slow - noticeable)
+				} else if avgSec < 30 {
+					durationStr = fmt.Sprintf("\033[38;5;208m%s\033[0m", timeStr) // Light orange
+					visualLen = len(timeStr)
+				} else if avgSec < 45 {
+					durationStr = fmt.Sprintf("\033[38;5;202m%s\033[0m", timeStr) // Medium orange
+					visualLen = len(timeStr)
+				} else if avgSec < 60 {
+					durationStr = fmt.Sprintf("\033[38;5;196m%s\033[0m", timeStr) // Dark orange
+					visualLen = len(timeStr)
+					// Red shades (very slow - needs attention)
+				} else if avgSec < 180 { // < 3 min
+					durationStr = fmt.Sprintf("\033[38;5;160m%s\033[0m", timeStr) // Light red
+					visualLen = len(timeStr)
+				} else if avgSec < 600 { // < 10 min
+					durationStr = fmt.Sprintf("\033[38;5;124m%s\033[0m", timeStr) // Medium red
+					visualLen = len(timeStr)
+				} else {
+					durationStr = fmt.Sprintf("\033[38;5;88m%s\033[0m", timeStr) // Dark red
+					visualLen = len(timeStr)
+				}
+			} else {
+				durationStr = "-"
+				visualLen = 1
+			}
+
+			// Right-align the duration by padding on the left
+			leftPadding := durationWidth - visualLen
+			if leftPadding < 0 {
+				leftPadding = 0
+			}
+
+			// Print row with proper padding (name, desc, type, command, right-aligned avg)
+			fmt.Printf("%s%s  %-*s  %-*s  %-*s  %s%s\n", nameFormatted, strings.Repeat(" ", padding), descWidth, desc, typeWidth, taskType, cmdWidth, cmd, strings.Repeat(" ", leftPadding), durationStr)
+		}
+		fmt.Println()
+	}
+
+	// Calculate total average
+	var totalAvgMs float64
+	var totalTasksWithAvg int
+	for _, t := range tasks {
+		if avg, ok := taskAverages[t.id]; ok {
+			totalAvgMs += avg
+			totalTasksWithAvg++
+		}
+	}
+
+	if totalTasksWithAvg > 0 {
+		totalAvgSec := totalAvgMs / 1000
+		// Gray color for total (summary, not individual timing)
+		fmt.Printf("Total: %d tasks \033[90m(~%.1fs avg)\033[0m\n", len(tasks), totalAvgSec)
+	} else {
+		fmt.Printf("Total: %d tasks\n", len(tasks))
+	}
+}
+
+// githubAnnotationsEnabled reports whether GitHub Actions annotations should be emitted:
+// when requested explicitly, or automatically when running inside GitHub Actions
+func githubAnnotationsEnabled(requested bool) bool {
+	return requested || os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// printTaskAnnotations emits a GitHub Actions error annotation for each failed task
+// (a warning annotation for advisory tasks with continueOnError)
+func printTaskAnnotations(results []model.TaskResult) {
+	for _, r := range results {
+		level := "error"
+		switch r.Status {
+		case model.StatusFail:
+		case model.StatusWarn:
+			level = "warning"
+		default:
+			continue
+		}
+		message := fmt.Sprintf("Task %s failed", r.ID)
+		if r.ExitCode != nil && *r.ExitCode != 0 {
+			message = fmt.Sprintf("Task %s failed (exit %d)", r.ID, *r.ExitCode)
+		}
+		if r.LogPath != "" {
+			message += ", see " + r.LogPath
+		}
+		fmt.Println(sarif.GitHubAnnotation{
+			Level:   level,
+			Title:   "devpipe: " + r.ID,
+			Message: message,
+		})
+	}
+}
+
+// sarifCmd handles the sarif subcommand
+func sarifCmd() {
+	// Define flags for sarif subcommand
+	fs := flag.NewFlagSet("sarif", flag.ExitOnError)
+	verbose := fs.Bool("v", false, "Verbose output (show severity, tags, precision, descriptions)")
+	summary := fs.Bool("s", false, "Show summary grouped by rule")
+	dir := fs.String("d", "", "Directory to search for SARIF files")
+	dedup := fs.Bool("dedup", true, "Collapse duplicate findings (same rule, file, line and message)")
+	minLevel := fs.String("min-level", "", "Only show findings at or above this level: note, warning, error")
+	github := fs.Bool("github", false, "Emit GitHub Actions annotations for each finding (auto-enabled when GITHUB_ACTIONS=true)")
+	githubOnly := fs.Bool("github-only", false, "Emit only GitHub Actions annotations, without the human-readable output")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s sarif [options] <sarif-file> [<sarif-file>...]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "   or: %s sarif -d <directory>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "View SARIF (Static Analysis Results Interchange Format) files in human-readable format.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nIf no file is specified, looks for tmp/codeql/results.sarif\n")
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  %s sarif tmp/codeql/results.sarif           # Default format\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s sarif -v tmp/codeql/results.sarif        # Verbose with metadata\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s sarif -s tmp/codeql/results.sarif        # Summary by rule\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s sarif -d tmp/                            # Scan directory\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s sarif -d tmp/ --min-level error          # Errors only, duplicates collapsed\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s sarif --github-only results.sarif        # GitHub Actions annotations only\n", os.Args[0])
+	}
+
+	// Parse flags (skip "sarif" subcommand)
+	_ = fs.Parse(os.Args[2:]) // Flag parsing
+
+	if *minLevel != "" && !sarif.IsValidLevel(*minLevel) {
+		fmt.Fprintf(os.Stderr, "Invalid --min-level %q. Valid options: note, warning, error\n", *minLevel)
+		os.Exit(1)
+	}
+
+	// Get SARIF file(s)
+	var files []string
+	if *dir != "" {
+		// Search directory for SARIF files
+		found, err := sarif.FindSARIFFiles(*dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error searching directory: %v\n", err)
+			os.Exit(1)
+		}
+		files = found
+	} else if fs.NArg() > 0 {
+		// Use files from arguments
+		files = fs.Args()
+	} else {
+		// Default: look for SARIF files in tmp/codeql
+		defaultPath := "tmp/codeql/results.sarif"
+		if _, err := os.Stat(defaultPath); err == nil {
+			files = []string{defaultPath}
+		} else {
+			fs.Usage()
+			os.Exit(1)
+		}
+	}
+
+	if len(files) == 0 {
+		fmt.Fprintf(os.Stderr, "No SARIF files found\n")
+		os.Exit(1)
+	}
+
+	// Process all files
+	var allFindings []sarif.Finding
+	var parseErrors bool
+	for _, file := range files {
+		// Parse SARIF file
+		doc, err := sarif.Parse(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", file, err)
+			parseErrors = true
+			continue
+		}
+
+		findings := doc.GetFindings()
+
+		// If multiple files, show which file we're processing
+		if len(files) > 1 && len(findings) > 0 && !*githubOnly {
+			fmt.Printf("\n📄 %s:\n", filepath.Base(file))
+		}
+
+		allFindings = append(allFindings, findings...)
+	}
+
+	// Filter and de-duplicate before printing so the summary reflects the same set
+	if *minLevel != "" {
+		before := len(allFindings)
+		allFindings = sarif.FilterByLevel(allFindings, *minLevel)
+		if hidden := before - len(allFindings); hidden > 0 && !*githubOnly {
+			fmt.Printf("🔽 Hid %d finding(s) below level %s\n", hidden, *minLevel)
+		}
+	}
+	if *dedup {
+		var duplicates int
+		allFindings, duplicates = sarif.Deduplicate(allFindings)
+		if duplicates > 0 && !*githubOnly {
+			fmt.Printf("🔁 Collapsed %d duplicate finding(s)\n", duplicates)
+		}
+	}
+
+	// Display results
+	switch {
+	case *githubOnly:
+		// Annotations only (printed below)
+	case *summary:
+		sarif.PrintSummary(allFindings)
+	default:
+		sarif.PrintFindings(allFindings, *verbose)
+	}
+	if githubAnnotationsEnabled(*github || *githubOnly) {
+		sarif.PrintGitHubAnnotations(os.Stdout, allFindings)
+	}
+
+	// Exit with error code only if parse errors occurred
+	if parseErrors {
+		os.Exit(1)
+	}
+}
+
+// initCmd handles the init subcommand
+func initCmd() {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	configPath := fs.String("config", "config.toml", "Path of the config file to create")
+	yes := fs.Bool("yes", false, "Include all detected tasks without prompting")
+	force := fs.Bool("force", false, "Overwrite an existing config without prompting")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s init [--config <path>] [--yes] [--force]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Generate a config file with tasks detected from go.mod, package.json or Cargo.toml.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(os.Args[2:]) // Flag parsing
+
+	reader := bufio.NewReader(os.Stdin)
+	prompt := func(question string) string {
+		fmt.Print(question)
+		line, _ := reader.ReadString('\n') // Best effort user input
+		return strings.TrimSpace(line)
+	}
+
+	// Confirm before replacing an existing config
+	if _, err := os.Stat(*configPath); err == nil && !*force {
+		if *yes {
+			fmt.Fprintf(os.Stderr, "ERROR: %s already exists (use --force to overwrite)\n", *configPath)
+			os.Exit(1)
+		}
+		answer := strings.ToLower(prompt(fmt.Sprintf("%s already exists. Overwrite? (y/n): ", *configPath)))
+		if answer != "y" && answer != "yes" {
+			fmt.Println("Aborted, config left unchanged")
+			return
+		}
+	}
+
+	// Detect tasks relative to where the config will live
+	absPath, err := filepath.Abs(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	tasks := scaffold.Detect(filepath.Dir(absPath))
+	if len(tasks) == 0 {
+		fmt.Println("No go.mod, package.json or Cargo.toml found, using placeholder tasks")
+		tasks = scaffold.FallbackTasks()
 	}
 
-	if err := json.Unmarshal(data, &summary); err != nil {
-		return averages
+	fmt.Println("Detected tasks:")
+	for i, t := range tasks {
+		fmt.Printf("  %2d. %-20s %-8s %s\n", i+1, t.ID, t.Phase, t.Command)
 	}
+	fmt.Println()
 
-	for taskID, stats := range summary.TaskStatsLast25 {
-		if stats.AvgDuration > 0 {
-			averages[taskID] = stats.AvgDuration
+	// Let the user pick which tasks to keep
+	if !*yes {
+		for {
+			selected, err := scaffold.ParseSelection(prompt("Include which tasks? (e.g. 1,3-4) [all]: "), len(tasks))
+			if err != nil {
+				fmt.Printf("  %v\n", err)
+				continue
+			}
+			var picked []scaffold.Task
+			for _, i := range selected {
+				picked = append(picked, tasks[i])
+			}
+			tasks = picked
+			break
 		}
 	}
 
-	return averages
+	if err := scaffold.Write(*configPath, tasks); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Created %s with %d task(s) - run 'devpipe validate --config %s' or just 'devpipe'\n", *configPath, len(tasks), *configPath)
 }
 
-// listCmd handles the list subcommand
-func listCmd() {
-	// Parse flags
-	fs := flag.NewFlagSet("list", flag.ExitOnError)
-	verbose := fs.Bool("verbose", false, "Show detailed table view with phases")
+// diffCmd handles the diff subcommand
+func diffCmd() {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
 	configPath := fs.String("config", "", "Path to config file (default: config.toml)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s diff [--config <path>] <runID-A> <runID-B>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Compare two runs task by task (A is the baseline).\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
 	_ = fs.Parse(os.Args[2:]) // Flag parsing
 
-	// Load configuration
-	cfg, configTaskOrder, phaseNames, taskToPhase, err := config.LoadConfig(*configPath)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	runIDA, runIDB := fs.Arg(0), fs.Arg(1)
+
+	outputRoot := resolveOutputRoot(*configPath)
+
+	runA, err := dashboard.LoadRun(outputRoot, runIDA)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
 		os.Exit(1)
 	}
-
-	if cfg == nil {
-		fmt.Fprintf(os.Stderr, "ERROR: No config.toml found. Create one or specify with --config flag\n")
+	runB, err := dashboard.LoadRun(outputRoot, runIDB)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Merge with defaults
-	mergedCfg := config.MergeWithDefaults(cfg)
+	diff := dashboard.CompareRuns(runA, runB)
+	printRunDiff(diff, ui.NewColors(ui.IsColorEnabled()))
 
-	// Determine project root
+	htmlPath := dashboard.DiffReportPath(outputRoot, runIDA, runIDB)
+	if err := dashboard.WriteDiffHTML(htmlPath, diff); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: failed to write comparison report: %v\n", err)
+		return
+	}
+	fmt.Println()
+	fmt.Printf("📊 Comparison: %s\n", htmlPath)
+}
+
+// resolveOutputRoot loads the config and returns its absolute output root,
+// exiting if the config cannot be loaded
+func resolveOutputRoot(configPath string) string {
+	// Determine output root the same way generate-reports does
 	projectRoot, _ := git.DetectProjectRoot()
+	cfg, _, _, _, err := config.LoadConfig(findConfig(configPath, true))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	mergedCfg := config.MergeWithDefaults(cfg)
+	mergedCfg.ExpandDefaults()
+	outputRoot := mergedCfg.Defaults.OutputRoot
+	if !filepath.IsAbs(outputRoot) {
+		outputRoot = filepath.Join(projectRoot, outputRoot)
+	}
+	return outputRoot
+}
 
-	// Load historical averages
-	outputRoot := filepath.Join(projectRoot, mergedCfg.Defaults.OutputRoot)
-	taskAverages := loadTaskAveragesLast25(outputRoot)
+// defaultServePort is the port devpipe serve and --serve listen on
+const defaultServePort = 8080
 
-	// Build task list (filter out phase markers)
-	var tasks []struct {
-		id    string
-		task  config.TaskConfig
-		phase string
+// serveCmd handles the serve subcommand: the HTML dashboard over HTTP, updated live during runs
+func serveCmd() {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	configPath := fs.String("config", "", "Path to config file (default: config.toml)")
+	port := fs.Int("port", defaultServePort, "Port to listen on (0 = any free port)")
+	open := fs.Bool("open", false, "Open the dashboard in the browser")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s serve [--config <path>] [--port N] [--open]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Serve the HTML dashboard on localhost. Open pages show the progress of a\n")
+		fmt.Fprintf(os.Stderr, "running pipeline and reload when a run finishes.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
 	}
+	_ = fs.Parse(os.Args[2:]) // Flag parsing
 
-	for _, id := range configTaskOrder {
-		// Skip wait markers and phase headers
-		if id == "wait" || strings.HasPrefix(id, "wait-") || strings.HasPrefix(id, "phase-") {
-			continue
-		}
+	if fs.NArg() > 0 || *port < 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
 
-		taskCfg, ok := mergedCfg.Tasks[id]
-		if !ok {
-			continue
+	outputRoot := resolveOutputRoot(*configPath)
+	url, err := startDashboardServer(outputRoot, *port)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: failed to serve dashboard: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("🌐 Serving %s at %s (Ctrl-C to stop)\n", outputRoot, url)
+	if *open {
+		if err := dashboard.OpenBrowser(url); err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: failed to open browser: %v\n", err)
 		}
+	}
 
-		// Get phase name
-		phaseName := ""
-		if phaseID, ok := taskToPhase[id]; ok {
-			for _, phaseInfo := range phaseNames {
-				if phaseInfo.ID == phaseID {
-					phaseName = phaseInfo.Name
-					break
-				}
-			}
-		}
+	waitForInterrupt()
+}
 
-		tasks = append(tasks, struct {
-			id    string
-			task  config.TaskConfig
-			phase string
-		}{id, taskCfg, phaseName})
-	}
+// waitForInterrupt blocks until Ctrl-C or SIGTERM
+func waitForInterrupt() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+}
 
-	if len(tasks) == 0 {
-		fmt.Println("No tasks found in config")
-		return
+// startDashboardServer serves the dashboards under outputRoot on localhost in the background
+// and returns their URL
+func startDashboardServer(outputRoot string, port int) (string, error) {
+	ln, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+	if err != nil {
+		return "", err
 	}
+	go func() {
+		server := &http.Server{Handler: dashboard.NewServer(outputRoot), ReadHeaderTimeout: 10 * time.Second}
+		_ = server.Serve(ln)
+	}()
+	return fmt.Sprintf("http://localhost:%d/", ln.Addr().(*net.TCPAddr).Port), nil
+}
 
-	// Simple mode: just list task IDs
-	if !*verbose {
-		for _, t := range tasks {
-			fmt.Println(t.id)
-		}
-		return
+// configCmd handles the config subcommand: the effective config and where each value came from
+func configCmd() {
+	fs := flag.NewFlagSet("config", flag.ExitOnError)
+	configPath := fs.String("config", "", "Path to config file, or - to read it from stdin (default: config.toml)")
+	jsonOut := fs.Bool("json", false, "Output the effective config as JSON")
+	flagUI := fs.String("ui", "basic", "UI mode to resolve as if passed to a run: basic, full")
+	flagSince := fs.String("since", "", "Git ref to resolve as if passed to a run")
+	flagProfile := fs.String("profile", "", "Config profile to apply, as if passed to a run (default: $"+config.ProfileEnvVar+")")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s config [--config <path>] [--json] [--ui <mode>] [--since <ref>] [--profile <name>]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Show the effective config: each value, where it came from, and what it overrode.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
 	}
+	_ = fs.Parse(os.Args[2:]) // Flag parsing
 
-	// Verbose mode: table view grouped by phase
-	fmt.Println("Tasks:")
-	fmt.Println()
-
-	// Group tasks by phase
-	type phaseGroup struct {
-		name  string
-		tasks []struct {
-			id   string
-			task config.TaskConfig
-		}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		os.Exit(1)
 	}
 
-	var phases []phaseGroup
-	currentPhase := phaseGroup{name: "(no phase)"}
-
-	for _, t := range tasks {
-		if t.phase != "" && (len(currentPhase.tasks) == 0 || currentPhase.name != t.phase) {
-			// Start new phase
-			if len(currentPhase.tasks) > 0 {
-				phases = append(phases, currentPhase)
-			}
-			currentPhase = phaseGroup{name: t.phase}
-		}
-		currentPhase.tasks = append(currentPhase.tasks, struct {
-			id   string
-			task config.TaskConfig
-		}{t.id, t.task})
+	cfg, _, _, _, err := config.LoadConfig(findConfig(*configPath, true))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
 	}
-	if len(currentPhase.tasks) > 0 {
-		phases = append(phases, currentPhase)
+	if err := applyProfile(cfg, *flagProfile); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
 	}
+	mergedCfg := config.MergeWithDefaults(cfg)
 
-	// Get terminal width
-	termWidth := getTerminalWidth()
-
-	// Column widths (fixed for name, desc, type, duration, command)
-	nameWidth := 40
-	durationWidth := 13 // "⚠️  ~2ms" format (emoji takes more space)
-	typeWidth := 18
-	cmdWidth := 45
-	spacing := 10 // 2 spaces between each column (5 gaps)
-	descWidth := termWidth - nameWidth - durationWidth - typeWidth - cmdWidth - spacing
-	if descWidth < 20 {
-		descWidth = 20 // Minimum description width
+	// Resolve the CLI-overridable values the same way a run does
+	uiModeStr := *flagUI
+	if *flagUI == "basic" && cfg != nil && mergedCfg.Defaults.UIMode != "" {
+		uiModeStr = mergedCfg.Defaults.UIMode
+	}
+	gitMode, gitRef := mergedCfg.Defaults.Git.Mode, mergedCfg.Defaults.Git.Ref
+	if *flagSince != "" {
+		gitMode, gitRef = "ref", *flagSince
 	}
 
-	// Print each phase
-	for _, phase := range phases {
-		// Calculate phase average duration
-		var phaseAvgMs float64
-		var phaseTaskCount int
-		for _, t := range phase.tasks {
-			if avg, ok := taskAverages[t.id]; ok {
-				phaseAvgMs += avg
-				phaseTaskCount++
-			}
+	effective := config.BuildEffectiveConfig(cfg, &mergedCfg, *flagSince, *flagUI, uiModeStr, gitMode, gitRef)
+	setConfigSources(effective, *configPath, findConfig(*configPath, true), *flagProfile)
+
+	if *jsonOut {
+		data, err := json.MarshalIndent(effective, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
 		}
+		fmt.Println(string(data))
+		return
+	}
 
-		// Phase header with emoji and duration in a box
-		emoji := phaseEmoji(phase.name)
-		var phaseText string
-		var durationTextPlain string
-		if phaseTaskCount > 0 {
-			phaseAvgSec := phaseAvgMs / 1000
-			durationTextPlain = fmt.Sprintf(" (~%.1fs)", phaseAvgSec)
+	printEffectiveConfig(effective, ui.NewColors(ui.IsColorEnabled()))
+}
 
-			// Gray color for phase duration (grouping, not individual timing)
-			grayDuration := fmt.Sprintf("\033[90m(~%.1fs)\033[0m", phaseAvgSec)
-			phaseText = fmt.Sprintf("%s %s %s", emoji, phase.name, grayDuration)
-		} else {
-			phaseText = fmt.Sprintf("%s %s", emoji, phase.name)
+// setConfigSources records in effective the config file and profile that were used, and where
+// each was chosen: the flag, its environment variable, discovery or the default
+func setConfigSources(effective *model.EffectiveConfig, flagConfig, configPath, flagProfile string) {
+	effective.ConfigPath = configPath
+	switch {
+	case flagConfig != "":
+		effective.ConfigSource = "cli-flag"
+	case os.Getenv(config.ConfigEnvVar) != "":
+		effective.ConfigSource = "env"
+	case configPath != "":
+		effective.ConfigSource = "discovered"
+	default:
+		// LoadConfig falls back to ./config.toml
+		if _, err := os.Stat("config.toml"); err == nil {
+			effective.ConfigPath = "config.toml"
+		}
+		effective.ConfigSource = "default"
+	}
+	if effective.Profile != "" {
+		effective.ProfileSource = "env"
+		if flagProfile != "" {
+			effective.ProfileSource = "cli-flag"
 		}
-		// Calculate visual width: emoji (2) + space (1) + name + duration text + padding (2)
-		visualWidth := 2 + 1 + len(phase.name) + len(durationTextPlain) + 2
+	}
+}
 
-		// Top border
-		fmt.Println("┌" + strings.Repeat("─", visualWidth) + "┐")
-		// Header with bold
-		fmt.Printf("│\033[1m %s \033[0m│\n", phaseText)
-		// Bottom border
-		fmt.Println("└" + strings.Repeat("─", visualWidth) + "┘")
+// printEffectiveConfig prints the effective config as an aligned table, highlighting non-default values
+func printEffectiveConfig(effective *model.EffectiveConfig, colors *ui.Colors) {
+	if effective.ConfigSource != "" {
+		fmt.Printf("Config:  %s %s\n", orDash(effective.ConfigPath), colors.Gray("("+effective.ConfigSource+")"))
+	}
+	if effective.Profile != "" {
+		fmt.Printf("Profile: %s %s\n", effective.Profile, colors.Gray("("+effective.ProfileSource+")"))
+	}
+	if effective.ConfigSource != "" || effective.Profile != "" {
 		fmt.Println()
+	}
+	keyWidth, valueWidth, sourceWidth := len("KEY"), len("VALUE"), len("SOURCE")
+	for _, v := range effective.Values {
+		keyWidth = max(keyWidth, len(v.Key))
+		valueWidth = max(valueWidth, len(v.Value))
+		sourceWidth = max(sourceWidth, len(v.Source))
+	}
 
-		// Table header (AVG is right-aligned)
-		fmt.Printf("%-*s  %-*s  %-*s  %-*s  %*s\n", nameWidth, "NAME", descWidth, "DESCRIPTION", typeWidth, "TYPE", cmdWidth, "COMMAND", durationWidth, "AVG")
-		fmt.Printf("%s  %s  %s  %s  %s\n", strings.Repeat("─", nameWidth), strings.Repeat("─", descWidth), strings.Repeat("─", typeWidth), strings.Repeat("─", cmdWidth), strings.Repeat("─", durationWidth))
+	fmt.Printf("%-*s  %-*s  %-*s  %s\n", keyWidth, "KEY", valueWidth, "VALUE", sourceWidth, "SOURCE", "OVERRODE")
+	fmt.Printf("%s  %s  %s  %s\n", strings.Repeat("─", keyWidth), strings.Repeat("─", valueWidth), strings.Repeat("─", sourceWidth), strings.Repeat("─", 8))
+
+	for _, v := range effective.Values {
+		// Pad before colorizing so ANSI codes don't break alignment
+		source := fmt.Sprintf("%-*s", sourceWidth, v.Source)
+		switch v.Source {
+		case "cli-flag", "env":
+			source = colors.Cyan(source)
+		case "config-file", "profile":
+			source = colors.Green(source)
+		default:
+			source = colors.Gray(source)
+		}
+		fmt.Printf("%-*s  %-*s  %s  %s\n", keyWidth, v.Key, valueWidth, v.Value, source, colors.Gray(orDash(v.Overrode)))
+	}
+}
 
-		// Tasks
-		for _, t := range phase.tasks {
-			resolvedTask := mergedCfg.ResolveTaskConfig(t.id, t.task, projectRoot)
+// doctorCmd handles the doctor subcommand
+func doctorCmd() {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	configPath := fs.String("config", "", "Path to config file, or - to read it from stdin (default: config.toml)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s doctor [--config <path>]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Check that the program each enabled task (and fix) command runs is installed.\n")
+		fmt.Fprintf(os.Stderr, "Exits 1 if any program is missing.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(os.Args[2:]) // Flag parsing
 
-			// Add output emoji if present
-			metricsEmoji := ""
-			emojiDisplayWidth := 0
-			if resolvedTask.OutputType != "" {
-				switch resolvedTask.OutputType {
-				case "junit":
-					metricsEmoji = " 🧪"
-					emojiDisplayWidth = 3 // space + emoji (2 display chars)
-				case "sarif":
-					metricsEmoji = " 🔒"
-					emojiDisplayWidth = 3
-				case "artifact":
-					metricsEmoji = " 📦"
-					emojiDisplayWidth = 3
-				}
-			}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
 
-			// Calculate display widths
-			baseName := resolvedTask.Name
-			idText := t.id
-			// Total display width: name + emoji(if any) + space + id
-			totalDisplayWidth := len(baseName) + emojiDisplayWidth + 1 + len(idText)
+	*configPath = findConfig(*configPath, true)
+	cfg, taskOrder, _, _, err := config.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	if cfg == nil {
+		fmt.Fprintf(os.Stderr, "ERROR: no config.toml found (run 'devpipe init' to create one)\n")
+		os.Exit(1)
+	}
+	mergedCfg := config.MergeWithDefaults(cfg)
+	mergedCfg.ExpandDefaults()
 
-			// Truncate if needed
-			if totalDisplayWidth > nameWidth {
-				// Truncate the base name to fit
-				availableForName := nameWidth - emojiDisplayWidth - 1 - len(idText) - 3 // -3 for "..."
-				if availableForName > 0 {
-					baseName = baseName[:availableForName] + "..."
-					totalDisplayWidth = nameWidth
-				} else {
-					// Even the ID is too long, truncate everything
-					baseName = truncate(baseName+metricsEmoji+" "+idText, nameWidth)
-					metricsEmoji = ""
-					idText = ""
-					totalDisplayWidth = nameWidth
-				}
-			}
+	cwdGitRoot, cwdInGitRepo := git.DetectProjectRoot()
+	projectRoot := determineProjectRoot(*configPath, mergedCfg, cwdGitRoot, cwdInGitRepo)
 
-			// Format with colors: name + emoji, then gray ID
-			var nameFormatted string
-			if idText != "" {
-				nameFormatted = fmt.Sprintf("%s%s \033[90m%s\033[0m", baseName, metricsEmoji, idText)
-			} else {
-				nameFormatted = baseName
-			}
+	var checks []taskPreflight
+	for _, id := range taskOrder {
+		taskCfg, ok := mergedCfg.Tasks[id]
+		if !ok || strings.HasPrefix(id, "phase-") {
+			continue
+		}
+		resolved := mergedCfg.ResolveTaskConfig(id, taskCfg, projectRoot)
+		if resolved.Enabled != nil && !*resolved.Enabled {
+			continue
+		}
+		checks = append(checks, checkTaskTools(id, resolved.Command, resolved.CommandArgs, resolved.FixCommand, resolved.Workdir, containerRuntime(resolved.Image, mergedCfg.Defaults.ContainerRuntime))...)
+	}
 
-			// Calculate padding needed
-			padding := nameWidth - totalDisplayWidth
-			if padding < 0 {
-				padding = 0
-			}
+	if missing := printPreflight(checks, ui.NewColors(ui.IsColorEnabled()), false); missing > 0 {
+		os.Exit(1)
+	}
+}
 
-			// Format type
-			taskType := resolvedTask.Type
-			if taskType == "" {
-				taskType = "-"
-			}
+// taskPreflight is the preflight result for one task's command or fix command
+type taskPreflight struct {
+	TaskID string
+	Fix    bool
+	Tools  []preflight.Tool
+}
 
-			// Truncate command if too long
-			cmd := resolvedTask.Command
-			if len(cmd) > cmdWidth {
-				cmd = cmd[:cmdWidth-3] + "..."
-			}
+// checkTaskTools looks up the programs of a task's command (or commandArgs) and, if set, its fix
+// command. For a task that runs in a container only the container runtime is looked up, since
+// the programs come from the image
+func checkTaskTools(id, command string, commandArgs []string, fixCommand, workdir, runtime string) []taskPreflight {
+	if runtime != "" {
+		return []taskPreflight{{TaskID: id, Tools: []preflight.Tool{preflight.CheckProgram(runtime, workdir)}}}
+	}
+	tools := preflight.CheckCommand(command, workdir)
+	if len(commandArgs) > 0 {
+		tools = []preflight.Tool{preflight.CheckProgram(config.ExpandArgs(commandArgs[:1])[0], workdir)}
+	}
+	checks := []taskPreflight{{TaskID: id, Tools: tools}}
+	if fixCommand != "" {
+		checks = append(checks, taskPreflight{TaskID: id, Fix: true, Tools: preflight.CheckCommand(fixCommand, workdir)})
+	}
+	return checks
+}
 
-			// Truncate description to fit in one line
-			desc := resolvedTask.Desc
-			if len(desc) > descWidth {
-				desc = desc[:descWidth-3] + "..."
-			}
+// containerRuntime returns the runtime a task with image runs in, or "" for a task on the host
+func containerRuntime(image, runtime string) string {
+	if image == "" {
+		return ""
+	}
+	if runtime == "" {
+		return container.DefaultRuntime
+	}
+	return runtime
+}
 
-			// Format duration with color coding
-			var durationStr string
-			var visualLen int
-			if avgMs, ok := taskAverages[t.id]; ok && avgMs > 0 {
-				avgSec := avgMs / 1000
-				var timeStr string
-				if avgSec < 1 {
-					// Force 1 decimal place for milliseconds too
-					timeStr = fmt.Sprintf("~%.1fms", avgMs)
-				} else if avgSec < 60 {
-					timeStr = fmt.Sprintf("~%.1fs", avgSec)
-				} else {
-					minutes := avgSec / 60
-					timeStr = fmt.Sprintf("~%.1fm", minutes)
-				}
+// printPreflight prints a found/missing checklist per task and returns how many programs are
+// missing. With onlyMissing, tasks whose programs were all found are left out
+func printPreflight(checks []taskPreflight, colors *ui.Colors, onlyMissing bool) int {
+	nameWidth := 0
+	for _, c := range checks {
+		for _, tool := range c.Tools {
+			nameWidth = max(nameWidth, len(tool.Name))
+		}
+	}
 
-				// Special case: ≤3ms likely means echo/mock, not real timing
-				if avgMs <= 3 {
-					durationStr = fmt.Sprintf("\033[31m[!] %s\033[0m", timeStr) // Red with warning (using [!] instead of emoji)
-					visualLen = 4 + len(timeStr)                                // [!] (3) + space (1) + time
-					// Green shades (fast - instant feedback)
-				} else if avgMs < 100 {
-					durationStr = fmt.Sprintf("\033[38;5;46m%s\033[0m", timeStr) // Bright green
-					visualLen = len(timeStr)
-				} else if avgMs < 500 {
-					durationStr = fmt.Sprintf("\033[38;5;40m%s\033[0m", timeStr) // Medium green
-					visualLen = len(timeStr)
-				} else if avgSec < 1 {
-					durationStr = fmt.Sprintf("\033[38;5;34m%s\033[0m", timeStr) // Darker green
-					visualLen = len(timeStr)
-					// Yellow shades (moderate - acceptable)
-				} else if avgSec < 5 {
-					durationStr = fmt.Sprintf("\033[38;5;226m%s\033[0m", timeStr) // Bright yellow
-					visualLen = len(timeStr)
-				} else if avgSec < 10 {
-					durationStr = fmt.Sprintf("\033[38;5;220m%s\033[0m", timeStr) // Medium yellow
-					visualLen = len(timeStr)
-				} else if avgSec < 20 {
-					durationStr = fmt.Sprintf("\033[38;5;214m%s\033[0m", timeStr) // Darker yellow/amber
-					visualLen = len(timeStr)
-					// Orange shades (slow - noticeable)
-				} else if avgSec < 30 {
-					durationStr = fmt.Sprintf("\033[38;5;208m%s\033[0m", timeStr) // Light orange
-					visualLen = len(timeStr)
-				} else if avgSec < 45 {
-					durationStr = fmt.Sprintf("\033[38;5;202m%s\033[0m", timeStr) // Medium orange
-					visualLen = len(timeStr)
-				} else if avgSec < 60 {
-					durationStr = fmt.Sprintf("\033[38;5;196m%s\033[0m", timeStr) // Dark orange
-					visualLen = len(timeStr)
-					// Red shades (very slow - needs attention)
-				} else if avgSec < 180 { // < 3 min
-					durationStr = fmt.Sprintf("\033[38;5;160m%s\033[0m", timeStr) // Light red
-					visualLen = len(timeStr)
-				} else if avgSec < 600 { // < 10 min
-					durationStr = fmt.Sprintf("\033[38;5;124m%s\033[0m", timeStr) // Medium red
-					visualLen = len(timeStr)
-				} else {
-					durationStr = fmt.Sprintf("\033[38;5;88m%s\033[0m", timeStr) // Dark red
-					visualLen = len(timeStr)
-				}
-			} else {
-				durationStr = "-"
-				visualLen = 1
+	missing := 0
+	lastTask := ""
+	for _, c := range checks {
+		for _, tool := range c.Tools {
+			if onlyMissing && tool.Status != preflight.StatusMissing {
+				continue
 			}
-
-			// Right-align the duration by padding on the left
-			leftPadding := durationWidth - visualLen
-			if leftPadding < 0 {
-				leftPadding = 0
+			if c.TaskID != lastTask {
+				fmt.Println(colors.Bold(c.TaskID))
+				lastTask = c.TaskID
 			}
 
-			// Print row with proper padding (name, desc, type, command, right-aligned avg)
-			fmt.Printf("%s%s  %-*s  %-*s  %-*s  %s%s\n", nameFormatted, strings.Repeat(" ", padding), descWidth, desc, typeWidth, taskType, cmdWidth, cmd, strings.Repeat(" ", leftPadding), durationStr)
+			detail := ""
+			switch tool.Status {
+			case preflight.StatusFound:
+				detail = colors.Green("✓") + " " + fmt.Sprintf("%-*s", nameWidth, tool.Name) + "  " + colors.Gray(tool.Path)
+			case preflight.StatusBuiltin:
+				detail = colors.Green("✓") + " " + fmt.Sprintf("%-*s", nameWidth, tool.Name) + "  " + colors.Gray("shell builtin")
+			case preflight.StatusMissing:
+				missing++
+				detail = colors.Red("✗") + " " + fmt.Sprintf("%-*s", nameWidth, tool.Name) + "  " + colors.Red("not found")
+			case preflight.StatusUnchecked:
+				detail = colors.Yellow("?") + " " + fmt.Sprintf("%-*s", nameWidth, tool.Name) + "  " + colors.Yellow("cannot be checked statically")
+			}
+			if c.Fix {
+				detail += colors.Gray(" (fix command)")
+			}
+			fmt.Println("  " + detail)
 		}
-		fmt.Println()
 	}
 
-	// Calculate total average
-	var totalAvgMs float64
-	var totalTasksWithAvg int
-	for _, t := range tasks {
-		if avg, ok := taskAverages[t.id]; ok {
-			totalAvgMs += avg
-			totalTasksWithAvg++
-		}
+	if missing > 0 {
+		fmt.Println()
+		fmt.Println(colors.Red(fmt.Sprintf("%d program(s) not found; install them or add them to PATH", missing)))
+	} else if !onlyMissing {
+		fmt.Println()
+		fmt.Println(colors.Green("All task programs were found"))
 	}
+	return missing
+}
 
-	if totalTasksWithAvg > 0 {
-		totalAvgSec := totalAvgMs / 1000
-		// Gray color for total (summary, not individual timing)
-		fmt.Printf("Total: %d tasks \033[90m(~%.1fs avg)\033[0m\n", len(tasks), totalAvgSec)
-	} else {
-		fmt.Printf("Total: %d tasks\n", len(tasks))
+// completionCmd prints the completion script for the shell named after "completion"
+func completionCmd(runFlags *flag.FlagSet) {
+	if len(os.Args) != 3 {
+		fmt.Fprintf(os.Stderr, "Usage: %s completion <%s>\n\n", os.Args[0], strings.Join(completion.Shells, "|"))
+		fmt.Fprintf(os.Stderr, "Print a shell completion script, e.g. source <(devpipe completion bash)\n")
+		os.Exit(1)
+	}
+	script, err := completion.Script(os.Args[2], subcommands, completionFlags(runFlags))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
 	}
+	fmt.Print(script)
 }
 
-// sarifCmd handles the sarif subcommand
-func sarifCmd() {
-	// Define flags for sarif subcommand
-	fs := flag.NewFlagSet("sarif", flag.ExitOnError)
-	verbose := fs.Bool("v", false, "Verbose output (show severity, tags, precision, descriptions)")
-	summary := fs.Bool("s", false, "Show summary grouped by rule")
-	dir := fs.String("d", "", "Directory to search for SARIF files")
+// completionFlags describes the run flags for completion scripts. Flags like --resume that
+// may be given without a value are completed as booleans
+func completionFlags(fs *flag.FlagSet) []completion.Flag {
+	var flags []completion.Flag
+	fs.VisitAll(func(f *flag.Flag) {
+		arg := completion.ArgValue
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			arg = completion.ArgNone
+		} else if f.Name == "only" || f.Name == "skip" {
+			arg = completion.ArgTask
+		} else if f.Name == "config" || strings.HasSuffix(f.Name, "-out") {
+			arg = completion.ArgFile
+		}
+		flags = append(flags, completion.Flag{Name: f.Name, Usage: f.Usage, Arg: arg})
+	})
+	return flags
+}
 
+// historyCmd handles the history subcommand
+func historyCmd() {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	configPath := fs.String("config", "", "Path to config file (default: config.toml)")
+	last := fs.Int("last", 50, "Number of most recent runs to include (0 = all)")
+	jsonOut := fs.Bool("json", false, "Output stats as JSON")
 	fs.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s sarif [options] <sarif-file> [<sarif-file>...]\n", os.Args[0])
-		fmt.Fprintf(os.Stderr, "   or: %s sarif -d <directory>\n\n", os.Args[0])
-		fmt.Fprintf(os.Stderr, "View SARIF (Static Analysis Results Interchange Format) files in human-readable format.\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: %s history [--config <path>] [--last N] [--json] [taskID]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Show per-task pass/fail counts and durations over recent runs.\n\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		fs.PrintDefaults()
-		fmt.Fprintf(os.Stderr, "\nIf no file is specified, looks for tmp/codeql/results.sarif\n")
-		fmt.Fprintf(os.Stderr, "\nExamples:\n")
-		fmt.Fprintf(os.Stderr, "  %s sarif tmp/codeql/results.sarif           # Default format\n", os.Args[0])
-		fmt.Fprintf(os.Stderr, "  %s sarif -v tmp/codeql/results.sarif        # Verbose with metadata\n", os.Args[0])
-		fmt.Fprintf(os.Stderr, "  %s sarif -s tmp/codeql/results.sarif        # Summary by rule\n", os.Args[0])
-		fmt.Fprintf(os.Stderr, "  %s sarif -d tmp/                            # Scan directory\n", os.Args[0])
 	}
-
-	// Parse flags (skip "sarif" subcommand)
 	_ = fs.Parse(os.Args[2:]) // Flag parsing
 
-	// Get SARIF file(s)
-	var files []string
-	if *dir != "" {
-		// Search directory for SARIF files
-		found, err := sarif.FindSARIFFiles(*dir)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error searching directory: %v\n", err)
+	// Allow flags after the task ID (devpipe history test --last 50)
+	taskID := ""
+	if fs.NArg() > 0 {
+		taskID = fs.Arg(0)
+		_ = fs.Parse(fs.Args()[1:])
+	}
+	if fs.NArg() > 0 || *last < 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	outputRoot := resolveOutputRoot(*configPath)
+	runs, err := dashboard.LoadRuns(outputRoot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: failed to load runs: %v\n", err)
+		os.Exit(1)
+	}
+
+	window := *last
+	if window == 0 || window > len(runs) {
+		window = len(runs)
+	}
+	stats := sortTaskStats(dashboard.ComputeTaskStats(runs, window))
+
+	if taskID != "" {
+		var filtered []dashboard.TaskStats
+		for _, s := range stats {
+			if s.ID == taskID {
+				filtered = append(filtered, s)
+			}
+		}
+		if len(filtered) == 0 {
+			fmt.Fprintf(os.Stderr, "ERROR: task %q not found in the last %d run(s)\n", taskID, window)
 			os.Exit(1)
 		}
-		files = found
-	} else if fs.NArg() > 0 {
-		// Use files from arguments
-		files = fs.Args()
-	} else {
-		// Default: look for SARIF files in tmp/codeql
-		defaultPath := "tmp/codeql/results.sarif"
-		if _, err := os.Stat(defaultPath); err == nil {
-			files = []string{defaultPath}
-		} else {
-			fs.Usage()
+		stats = filtered
+	}
+
+	if *jsonOut {
+		if stats == nil {
+			stats = []dashboard.TaskStats{}
+		}
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: failed to encode stats: %v\n", err)
 			os.Exit(1)
 		}
+		fmt.Println(string(data))
+		return
 	}
 
-	if len(files) == 0 {
-		fmt.Fprintf(os.Stderr, "No SARIF files found\n")
-		os.Exit(1)
+	printTaskHistory(stats, window, ui.NewColors(ui.IsColorEnabled()))
+}
+
+// sortTaskStats orders stats by failure count (most first), then by task ID
+func sortTaskStats(statsMap map[string]dashboard.TaskStats) []dashboard.TaskStats {
+	stats := make([]dashboard.TaskStats, 0, len(statsMap))
+	for _, s := range statsMap {
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].FailCount != stats[j].FailCount {
+			return stats[i].FailCount > stats[j].FailCount
+		}
+		return stats[i].ID < stats[j].ID
+	})
+	return stats
+}
+
+// printTaskHistory prints a per-task history table
+func printTaskHistory(stats []dashboard.TaskStats, window int, colors *ui.Colors) {
+	if len(stats) == 0 {
+		fmt.Println("No runs found")
+		return
 	}
+	fmt.Printf("Task history over the last %d run(s)\n\n", window)
 
-	// Process all files
-	var allFindings []sarif.Finding
-	var parseErrors bool
-	for _, file := range files {
-		// Parse SARIF file
-		doc, err := sarif.Parse(file)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", file, err)
-			parseErrors = true
-			continue
+	idWidth := 12
+	for _, s := range stats {
+		taskLen := len(s.ID)
+		if taskLen > 45 {
+			taskLen = 45
+		}
+		if taskLen > idWidth {
+			idWidth = taskLen
 		}
+	}
 
-		findings := doc.GetFindings()
+	fmt.Printf("%-*s  %5s  %5s  %5s  %5s  %6s  %9s  %9s  %9s  %s\n", idWidth, "TASK", "RUNS", "PASS", "FAIL", "SKIP", "FAIL%", "AVG", "P50", "P95", "LAST")
+	fmt.Printf("%s  %s  %s  %s  %s  %s  %s  %s  %s  %s\n", strings.Repeat("─", idWidth), strings.Repeat("─", 5), strings.Repeat("─", 5), strings.Repeat("─", 5), strings.Repeat("─", 5), strings.Repeat("─", 6), strings.Repeat("─", 9), strings.Repeat("─", 9), strings.Repeat("─", 9), strings.Repeat("─", 6))
 
-		// If multiple files, show which file we're processing
-		if len(files) > 1 && len(findings) > 0 {
-			fmt.Printf("\n📄 %s:\n", filepath.Base(file))
+	for _, s := range stats {
+		failRate := 0.0
+		if s.TotalRuns > 0 {
+			failRate = float64(s.FailCount) / float64(s.TotalRuns) * 100
 		}
 
-		allFindings = append(allFindings, findings...)
+		// Pad before colorizing so ANSI codes don't break alignment
+		failText := fmt.Sprintf("%5d", s.FailCount)
+		if s.FailCount > 0 {
+			failText = colors.Red(failText)
+		}
+		lastStatus := s.LastStatus
+		switch lastStatus {
+		case "PASS":
+			lastStatus = colors.Green(lastStatus)
+		case "FAIL":
+			lastStatus = colors.Red(lastStatus)
+		case "WARN":
+			lastStatus = colors.Yellow(lastStatus)
+		case "SKIPPED":
+			lastStatus = colors.Gray(lastStatus)
+		}
+
+		fmt.Printf("%-*s  %5d  %5d  %s  %5d  %5.1f%%  %7.0fms  %7dms  %7dms  %s\n",
+			idWidth, truncate(s.ID, idWidth), s.TotalRuns, s.PassCount, failText, s.SkipCount,
+			failRate, s.AvgDuration, s.P50Duration, s.P95Duration, lastStatus)
 	}
+}
 
-	// Display results
-	if *summary {
-		sarif.PrintSummary(allFindings)
-	} else {
-		sarif.PrintFindings(allFindings, *verbose)
+// printRunDiff prints a per-task comparison table
+func printRunDiff(diff dashboard.RunDiff, colors *ui.Colors) {
+	fmt.Printf("Comparing %s (A) → %s (B)\n\n", diff.RunA.RunID, diff.RunB.RunID)
+
+	if len(diff.Tasks) == 0 {
+		fmt.Println("No tasks in either run")
+		return
 	}
 
-	// Exit with error code only if parse errors occurred
-	if parseErrors {
-		os.Exit(1)
+	idWidth := 12
+	for _, t := range diff.Tasks {
+		taskLen := len(t.ID)
+		if taskLen > 45 {
+			taskLen = 45
+		}
+		if taskLen > idWidth {
+			idWidth = taskLen
+		}
+	}
+
+	fmt.Printf("%-*s  %-8s  %-8s  %10s  %10s  %10s  %s\n", idWidth, "TASK", "A", "B", "DURATION A", "DURATION B", "DELTA", "CHANGE")
+	fmt.Printf("%s  %s  %s  %s  %s  %s  %s\n", strings.Repeat("─", idWidth), strings.Repeat("─", 8), strings.Repeat("─", 8), strings.Repeat("─", 10), strings.Repeat("─", 10), strings.Repeat("─", 10), strings.Repeat("─", 9))
+
+	for _, t := range diff.Tasks {
+		statusA, statusB := orDash(t.StatusA), orDash(t.StatusB)
+		durationA, durationB, delta := "-", "-", "-"
+		if t.StatusA != "" {
+			durationA = fmt.Sprintf("%dms", t.DurationA)
+		}
+		if t.StatusB != "" {
+			durationB = fmt.Sprintf("%dms", t.DurationB)
+		}
+		if t.StatusA != "" && t.StatusB != "" {
+			delta = fmt.Sprintf("%+dms", t.DeltaMs)
+		}
+
+		// Pad before colorizing so ANSI codes don't break alignment
+		deltaText := fmt.Sprintf("%10s", delta)
+		switch {
+		case t.DeltaMs > 0:
+			deltaText = colors.Red(deltaText)
+		case t.DeltaMs < 0:
+			deltaText = colors.Green(deltaText)
+		}
+
+		change := t.Change
+		switch t.Change {
+		case dashboard.ChangeRegressed, dashboard.ChangeRemoved:
+			change = colors.Red(change)
+		case dashboard.ChangeFixed, dashboard.ChangeAdded:
+			change = colors.Green(change)
+		case dashboard.ChangeNone:
+			change = colors.Gray(change)
+		}
+
+		fmt.Printf("%-*s  %-8s  %-8s  %10s  %10s  %s  %s\n", idWidth, truncate(t.ID, idWidth), statusA, statusB, durationA, durationB, deltaText, change)
+	}
+}
+
+// orDash returns s, or "-" when s is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
 	}
+	return s
 }
@@ -1,13 +1,26 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/drew/devpipe/internal/config"
+	"github.com/drew/devpipe/internal/container"
+	"github.com/drew/devpipe/internal/logsink"
 	"github.com/drew/devpipe/internal/model"
+	"github.com/drew/devpipe/internal/redact"
+	"github.com/drew/devpipe/internal/tasklog"
 	"github.com/drew/devpipe/internal/ui"
 )
 
@@ -25,7 +38,7 @@ func TestRunTask_DryRun(t *testing.T) {
 		Name: "Dry Run Task",
 	}
 
-	res, buf, err := runTask(task, runDir, logDir, true, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
+	res, buf, err := runTask(context.Background(), task, runDir, logDir, true, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
 	if err != nil {
 		t.Fatalf("runTask returned error in dry-run: %v", err)
 	}
@@ -44,6 +57,42 @@ func TestRunTask_DryRun(t *testing.T) {
 	}
 }
 
+func TestDryRunPlan(t *testing.T) {
+	renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+	task := model.TaskDefinition{
+		ID:               "lint",
+		Phase:            "Checks",
+		PhaseID:          "phase-checks",
+		Command:          "echo it's fine",
+		Workdir:          "/repo/web",
+		Shell:            []string{"bash", "-c"},
+		WatchPaths:       []string{"src/**"},
+		WatchPathsMatch:  true,
+		EstimatedSeconds: 12,
+	}
+
+	plan := dryRunPlan(task, renderer, false)
+	for _, want := range []string{
+		"DRY-RUN",
+		"Checks (phase-checks)",
+		`bash -c 'echo it'\''s fine'`,
+		"/repo/web",
+		"FORCE_COLOR=1",
+		"src/** (matched changed files)",
+	} {
+		if !strings.Contains(plan, want) {
+			t.Errorf("plan missing %q:\n%s", want, plan)
+		}
+	}
+	if strings.Contains(plan, "estimate") {
+		t.Errorf("estimate should only be shown with --verbose:\n%s", plan)
+	}
+
+	if plan := dryRunPlan(task, renderer, true); !strings.Contains(plan, "12s (from run history)") {
+		t.Errorf("verbose plan missing estimate:\n%s", plan)
+	}
+}
+
 func TestRunTask_Success(t *testing.T) {
 	runDir := t.TempDir()
 	logDir := filepath.Join(runDir, "logs")
@@ -61,7 +110,7 @@ func TestRunTask_Success(t *testing.T) {
 	}
 
 	taskDone := make(chan struct{})
-	res, buf, err := runTask(task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, taskDone)
+	res, buf, err := runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, taskDone)
 	if err != nil {
 		t.Fatalf("runTask returned error: %v", err)
 	}
@@ -86,6 +135,46 @@ func TestRunTask_Success(t *testing.T) {
 	}
 }
 
+func TestRunTask_Interrupted(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh and sleep")
+	}
+
+	runDir := t.TempDir()
+	logDir := filepath.Join(runDir, "logs")
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		t.Fatalf("failed to create log dir: %v", err)
+	}
+
+	renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+
+	// The background sleep checks that the whole process group is killed, not just the shell
+	task := model.TaskDefinition{
+		ID:      "slow-task",
+		Name:    "Slow Task",
+		Command: "sleep 30 & sleep 31",
+		Workdir: runDir,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	start := time.Now()
+	res, _, err := runTask(ctx, task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
+	if err == nil {
+		t.Fatalf("expected an error for an interrupted task")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("runTask took %v after cancellation, expected the command to be killed", elapsed)
+	}
+	if res.Status != model.StatusFail || !res.Interrupted {
+		t.Fatalf("expected an interrupted FAIL, got status=%s interrupted=%v", res.Status, res.Interrupted)
+	}
+	if res.ExitCode == nil || *res.ExitCode != 130 {
+		t.Fatalf("expected exit code 130, got %v", res.ExitCode)
+	}
+}
+
 func TestRunTask_Failure(t *testing.T) {
 	runDir := t.TempDir()
 	logDir := filepath.Join(runDir, "logs")
@@ -103,7 +192,7 @@ func TestRunTask_Failure(t *testing.T) {
 	}
 
 	taskDone := make(chan struct{})
-	res, buf, err := runTask(task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, taskDone)
+	res, buf, err := runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, taskDone)
 
 	// runTask returns error when command fails
 	if err == nil {
@@ -121,6 +210,273 @@ func TestRunTask_Failure(t *testing.T) {
 	}
 }
 
+func TestRunTask_FixBefore(t *testing.T) {
+	runDir := t.TempDir()
+	logDir := filepath.Join(runDir, "logs")
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		t.Fatalf("failed to create log dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(runDir, "main.go"), []byte("messy"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+	task := model.TaskDefinition{
+		ID:          "fmt",
+		Command:     "grep -q formatted main.go",
+		Workdir:     runDir,
+		FixType:     "auto",
+		FixMode:     "before",
+		FixCommand:  "echo formatted > main.go",
+		FailOnMatch: "formatted", // Only the check's output counts, and it prints nothing
+	}
+
+	// The fix runs first, so the check passes on its first run
+	res, _, err := runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
+	if err != nil || res.Status != model.StatusPass {
+		t.Fatalf("runTask() = %s, %v, want PASS", res.Status, err)
+	}
+	if !res.FixedBefore || res.FixCommand != task.FixCommand || res.AutoFixed {
+		t.Errorf("result = %+v, want the fix recorded as run before the check", res)
+	}
+	log, _ := os.ReadFile(res.LogPath)
+	if fix, check := strings.Index(string(log), "--- Auto-fix: "), strings.Index(string(log), "--- Check: "); fix < 0 || check < fix {
+		t.Errorf("log = %q, want the fix and then the check", log)
+	}
+
+	// --fix-type none turns the fix off
+	if err := os.WriteFile(filepath.Join(runDir, "main.go"), []byte("messy"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	task.FixType = "none"
+	res, _, _ = runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
+	if res.Status != model.StatusFail || res.FixedBefore {
+		t.Errorf("with fixType none: status %s, fixedBefore %v, want FAIL without a fix", res.Status, res.FixedBefore)
+	}
+}
+
+func TestRunTask_RequiredEnv(t *testing.T) {
+	runDir := t.TempDir()
+	logDir := filepath.Join(runDir, "logs")
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		t.Fatalf("failed to create log dir: %v", err)
+	}
+	t.Setenv("DEVPIPE_TEST_REGION", "us-east-1")
+
+	renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+	marker := filepath.Join(runDir, "ran")
+	task := model.TaskDefinition{
+		ID:          "deploy",
+		Command:     "touch " + marker,
+		Workdir:     runDir,
+		RequiredEnv: []string{"DEVPIPE_TEST_REGION", "DEVPIPE_TEST_TOKEN"},
+	}
+
+	// A missing variable fails the task before the command runs
+	res, _, err := runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
+	if err == nil || err.Error() != "task deploy requires env DEVPIPE_TEST_TOKEN" {
+		t.Errorf("runTask() error = %v, want the missing variable", err)
+	}
+	if res.Status != model.StatusFail {
+		t.Errorf("expected status FAIL, got %s", res.Status)
+	}
+	if _, statErr := os.Stat(marker); statErr == nil {
+		t.Error("command ran although a required variable is missing")
+	}
+	if log, _ := os.ReadFile(res.LogPath); !strings.Contains(string(log), "requires env DEVPIPE_TEST_TOKEN") {
+		t.Errorf("log = %q, want the missing variable", log)
+	}
+
+	// The check also runs with --dry-run
+	res, _, _ = runTask(context.Background(), task, runDir, logDir, true, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
+	if res.Status != model.StatusFail || res.Skipped {
+		t.Errorf("dry-run status = %s (skipped %v), want FAIL", res.Status, res.Skipped)
+	}
+
+	t.Setenv("DEVPIPE_TEST_TOKEN", "secret")
+	res, _, err = runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
+	if err != nil || res.Status != model.StatusPass {
+		t.Errorf("runTask() with the variables set = %s, %v, want PASS", res.Status, err)
+	}
+}
+
+func TestRunTask_Usage(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("resource usage is not reported on Windows")
+	}
+	runDir := t.TempDir()
+	logDir := filepath.Join(runDir, "logs")
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		t.Fatalf("failed to create log dir: %v", err)
+	}
+
+	renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+	task := model.TaskDefinition{ID: "usage", Command: "echo hi", Workdir: runDir}
+	res, _, err := runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
+	if err != nil {
+		t.Fatalf("runTask returned error: %v", err)
+	}
+	if res.Usage == nil || res.Usage.MaxRSSBytes < 1024*1024 {
+		t.Errorf("Usage = %+v, want the peak memory of the shell in bytes", res.Usage)
+	}
+
+	// Not measured for a container, whose processes are not children of devpipe
+	if _, usage, _ := (ContainerExecutor{}).Run(context.Background(), model.TaskDefinition{ID: "c", Image: "alpine", ContainerRuntime: "true", Workdir: runDir}, io.Discard, io.Discard); usage != nil {
+		t.Errorf("container Usage = %+v, want nil", usage)
+	}
+}
+
+// fakeExecutor records the tasks it runs and writes output instead of running them
+type fakeExecutor struct {
+	ran      []string
+	output   string
+	exitCode int
+}
+
+func (f *fakeExecutor) Run(_ context.Context, st model.TaskDefinition, stdout, _ io.Writer) (int, *model.Usage, error) {
+	f.ran = append(f.ran, st.Command)
+	_, _ = io.WriteString(stdout, f.output)
+	if f.exitCode != 0 {
+		return f.exitCode, nil, fmt.Errorf("exit status %d", f.exitCode)
+	}
+	return 0, nil, nil
+}
+
+func TestRunTask_Executor(t *testing.T) {
+	runDir := t.TempDir()
+	logDir := filepath.Join(runDir, "logs")
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		t.Fatalf("failed to create log dir: %v", err)
+	}
+	fake := &fakeExecutor{output: "3 tests failed\n", exitCode: 3}
+	defer func(orig func(model.TaskDefinition) Executor) { newExecutor = orig }(newExecutor)
+	newExecutor = func(model.TaskDefinition) Executor { return fake }
+
+	renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+	task := model.TaskDefinition{ID: "test", Command: "make test", Workdir: runDir}
+	res, _, err := runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
+	if err == nil || res.Status != model.StatusFail || res.ExitCode == nil || *res.ExitCode != 3 {
+		t.Errorf("runTask() = %s, exit %v, %v, want FAIL with exit code 3", res.Status, res.ExitCode, err)
+	}
+	if len(fake.ran) != 1 || fake.ran[0] != "make test" {
+		t.Errorf("executor ran %q, want the task's command once", fake.ran)
+	}
+	if log, _ := os.ReadFile(res.LogPath); !strings.Contains(string(log), "3 tests failed") {
+		t.Errorf("log = %q, want the executor's output", log)
+	}
+
+	// The exit code reported by the executor is checked against allowExitCodes
+	task.AllowExitCodes = []int{3}
+	res, _, err = runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
+	if err != nil || res.Status != model.StatusPass || !res.AllowedExitCode {
+		t.Errorf("runTask() with allowExitCodes = %s, %v, want PASS", res.Status, err)
+	}
+
+	// Checks that fail before running never reach the executor
+	fake.ran = nil
+	task.RequiredEnv = []string{"DEVPIPE_TEST_UNSET"}
+	res, _, _ = runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
+	if len(fake.ran) != 0 || res.Status != model.StatusFail || *res.ExitCode != 1 {
+		t.Errorf("runTask() with a missing variable ran %q, status %s, want FAIL with exit code 1 and no run", fake.ran, res.Status)
+	}
+}
+
+func TestRunTask_ContinueOnError(t *testing.T) {
+	runDir := t.TempDir()
+	logDir := filepath.Join(runDir, "logs")
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		t.Fatalf("failed to create log dir: %v", err)
+	}
+
+	renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+
+	tests := []struct {
+		name        string
+		command     string
+		emptyOutput string
+	}{
+		{"non-zero exit", "echo 'typo: teh'; exit 1", ""},
+		{"empty output policy", "true", "fail"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := model.TaskDefinition{
+				ID:              "spellcheck",
+				Command:         tt.command,
+				Workdir:         runDir,
+				EmptyOutput:     tt.emptyOutput,
+				ContinueOnError: true,
+			}
+
+			res, _, _ := runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
+			if res.Status != model.StatusWarn {
+				t.Errorf("expected status WARN for an advisory failure, got %s", res.Status)
+			}
+		})
+	}
+}
+
+func TestRunTask_NoOutputWarning(t *testing.T) {
+	tests := []struct {
+		name         string
+		command      string
+		policy       string
+		wantStatus   model.TaskStatus
+		wantNoOutput bool
+	}{
+		{"silent task warns", "true", "warn", model.StatusPass, true},
+		{"silent task fails when configured", "true", "fail", model.StatusFail, true},
+		{"silent task ignored", "true", "ignore", model.StatusPass, false},
+		{"task with output is not flagged", "echo hello", "fail", model.StatusPass, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runDir := t.TempDir()
+			logDir := filepath.Join(runDir, "logs")
+			if err := os.MkdirAll(logDir, 0o755); err != nil {
+				t.Fatalf("failed to create log dir: %v", err)
+			}
+
+			renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+
+			task := model.TaskDefinition{
+				ID:          "silent-task",
+				Command:     tt.command,
+				Workdir:     runDir,
+				EmptyOutput: tt.policy,
+			}
+
+			res, _, _ := runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("expected status %s, got %s", tt.wantStatus, res.Status)
+			}
+			if res.NoOutput != tt.wantNoOutput {
+				t.Errorf("expected NoOutput=%v, got %v", tt.wantNoOutput, res.NoOutput)
+			}
+		})
+	}
+}
+
+func TestIsSilentTask(t *testing.T) {
+	metrics := &model.TaskMetrics{Kind: "artifact"}
+
+	if !isSilentTask(false, nil, 5) {
+		t.Error("expected instant task without output to be silent")
+	}
+	if isSilentTask(true, nil, 5) {
+		t.Error("task with output should not be silent")
+	}
+	if isSilentTask(false, metrics, 5) {
+		t.Error("task with metrics should not be silent")
+	}
+	if isSilentTask(false, nil, silentTaskThresholdMs) {
+		t.Error("slow task should not be silent")
+	}
+}
+
 func TestRunTask_CustomWorkdir(t *testing.T) {
 	runDir := t.TempDir()
 	logDir := filepath.Join(runDir, "logs")
@@ -148,7 +504,7 @@ func TestRunTask_CustomWorkdir(t *testing.T) {
 	}
 
 	taskDone := make(chan struct{})
-	res, _, err := runTask(task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, taskDone)
+	res, _, err := runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, taskDone)
 	if err != nil {
 		t.Fatalf("runTask returned error: %v", err)
 	}
@@ -175,7 +531,7 @@ func TestRunTask_OutputBuffering_NonAnimated(t *testing.T) {
 	}
 
 	taskDone := make(chan struct{})
-	res, buf, err := runTask(task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, taskDone)
+	res, buf, err := runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, taskDone)
 	if err != nil {
 		t.Fatalf("runTask returned error: %v", err)
 	}
@@ -190,6 +546,76 @@ func TestRunTask_OutputBuffering_NonAnimated(t *testing.T) {
 	}
 }
 
+func TestDashboardMode(t *testing.T) {
+	if animated, notice := dashboardMode(true, false, true); !animated || notice != "" {
+		t.Errorf("terminal = %v %q, want animated without notice", animated, notice)
+	}
+	if animated, notice := dashboardMode(true, true, true); animated || notice != "" {
+		t.Errorf("quiet = %v %q, want plain output without notice", animated, notice)
+	}
+	if animated, notice := dashboardMode(false, false, false); animated || notice != "" {
+		t.Errorf("not requested = %v %q, want plain output without notice", animated, notice)
+	}
+}
+
+func TestRunTask_DashboardWithoutTTY(t *testing.T) {
+	runDir := t.TempDir()
+	logDir := filepath.Join(runDir, "logs")
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		t.Fatalf("failed to create log dir: %v", err)
+	}
+
+	// --dashboard without a terminal degrades to plain output
+	animated, notice := dashboardMode(true, false, false)
+	if animated || !strings.Contains(notice, "--dashboard needs a terminal") {
+		t.Fatalf("dashboardMode = %v %q, want plain output with a notice", animated, notice)
+	}
+	renderer := ui.NewRenderer(ui.UIModeFull, true, animated)
+	if renderer.IsAnimated() {
+		t.Fatal("renderer is animated without a terminal")
+	}
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	// Tasks started together still print one after another, in order
+	var wg sync.WaitGroup
+	var prevDone chan struct{}
+	for _, id := range []string{"first", "second", "third"} {
+		task := model.TaskDefinition{ID: id, Name: id, Command: "echo " + id + "-1 && sleep 0.05 && echo " + id + "-2", Workdir: runDir}
+		waitForPrev, taskDone := prevDone, make(chan struct{})
+		prevDone = taskDone
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, _, err := runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, waitForPrev, taskDone)
+			if err != nil || res.Status != model.StatusPass {
+				t.Errorf("task %s = %s, %v", task.ID, res.Status, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	_ = w.Close() // Test cleanup
+	os.Stdout = old
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r) // Test output capture
+	output := buf.String()
+
+	if strings.Contains(output, "\033[") {
+		t.Errorf("output contains escape sequences: %q", output)
+	}
+	last := -1
+	for _, want := range []string{"[first", "first-1", "first-2", "[second", "second-1", "second-2", "[third", "third-1", "third-2"} {
+		i := strings.Index(output, want)
+		if i <= last {
+			t.Fatalf("%q missing or out of order in output:\n%s", want, output)
+		}
+		last = i
+	}
+}
+
 func TestRunTask_LogFileCreation(t *testing.T) {
 	runDir := t.TempDir()
 	logDir := filepath.Join(runDir, "logs")
@@ -207,7 +633,7 @@ func TestRunTask_LogFileCreation(t *testing.T) {
 	}
 
 	taskDone := make(chan struct{})
-	res, _, err := runTask(task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, taskDone)
+	res, _, err := runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, taskDone)
 	if err != nil {
 		t.Fatalf("runTask returned error: %v", err)
 	}
@@ -245,7 +671,7 @@ func TestRunTask_VerboseMode(t *testing.T) {
 	}
 
 	taskDone := make(chan struct{})
-	res, _, err := runTask(task, runDir, logDir, false, true, renderer, nil, &sync.Mutex{}, nil, taskDone)
+	res, _, err := runTask(context.Background(), task, runDir, logDir, false, true, renderer, nil, &sync.Mutex{}, nil, taskDone)
 	if err != nil {
 		t.Fatalf("runTask returned error: %v", err)
 	}
@@ -255,6 +681,160 @@ func TestRunTask_VerboseMode(t *testing.T) {
 	}
 }
 
+func TestRunTask_AllowExitCodes(t *testing.T) {
+	projectRoot, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd failed: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		command     string
+		allowed     []int
+		wantStatus  model.TaskStatus
+		wantExit    int
+		wantAllowed bool
+	}{
+		{"listed code passes", "echo differences; exit 1", []int{0, 1}, model.StatusPass, 1, true},
+		{"unlisted code fails", "echo broken; exit 2", []int{0, 1}, model.StatusFail, 2, false},
+		{"default only allows zero", "echo differences; exit 1", nil, model.StatusFail, 1, false},
+		{"zero always passes", "echo ok", []int{1}, model.StatusPass, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runDir := t.TempDir()
+			logDir := filepath.Join(runDir, "logs")
+			if err := os.MkdirAll(logDir, 0o755); err != nil {
+				t.Fatalf("failed to create log dir: %v", err)
+			}
+
+			renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+
+			// Metrics must still be parsed when a non-zero code is tolerated
+			task := model.TaskDefinition{
+				ID:             "exit-code-task",
+				Command:        tt.command,
+				Workdir:        projectRoot,
+				OutputType:     "junit",
+				OutputPath:     "testdata/junit-single-suite.xml",
+				AllowExitCodes: tt.allowed,
+			}
+
+			res, _, _ := runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("expected status %s, got %s", tt.wantStatus, res.Status)
+			}
+			if res.ExitCode == nil || *res.ExitCode != tt.wantExit {
+				t.Errorf("expected exit code %d, got %v", tt.wantExit, res.ExitCode)
+			}
+			if res.AllowedExitCode != tt.wantAllowed {
+				t.Errorf("expected AllowedExitCode=%v, got %v", tt.wantAllowed, res.AllowedExitCode)
+			}
+			if res.Metrics == nil {
+				t.Error("expected metrics to be parsed")
+			}
+		})
+	}
+}
+
+func TestRunTask_OutputMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		command    string
+		failOn     string
+		passOn     string
+		wantStatus model.TaskStatus
+		wantFailed string
+		wantPassed string
+		wantLine   string
+	}{
+		{"failOnMatch fails exit 0", "echo building; echo 'ERROR: bad input' >&2", "^ERROR:", "", model.StatusFail, "^ERROR:", "", "ERROR: bad input"},
+		{"failOnMatch without a match", "echo all good", "^ERROR:", "", model.StatusPass, "", "", ""},
+		{"colors are ignored", `printf '\033[31mERROR:\033[0m bad\n'`, "^ERROR:", "", model.StatusFail, "^ERROR:", "", "ERROR: bad"},
+		{"passOnMatch passes non-zero exit", "echo 'found 0 vulnerabilities'; exit 3", "", "0 vulnerabilities", model.StatusPass, "", "0 vulnerabilities", "found 0 vulnerabilities"},
+		{"passOnMatch without a match", "echo 'found 2 vulnerabilities'; exit 3", "", "0 vulnerabilities", model.StatusFail, "", "", ""},
+		{"unterminated last line", "printf 'ERROR: no newline'", "^ERROR:", "", model.StatusFail, "^ERROR:", "", "ERROR: no newline"},
+		{"failOnMatch wins", "echo '0 vulnerabilities'; echo ERROR; exit 1", "ERROR", "0 vulnerabilities", model.StatusFail, "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runDir := t.TempDir()
+			logDir := filepath.Join(runDir, "logs")
+			if err := os.MkdirAll(logDir, 0o755); err != nil {
+				t.Fatalf("failed to create log dir: %v", err)
+			}
+
+			renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+			task := model.TaskDefinition{
+				ID:          "match-task",
+				Command:     tt.command,
+				Workdir:     runDir,
+				FailOnMatch: tt.failOn,
+				PassOnMatch: tt.passOn,
+				EmptyOutput: "ignore",
+			}
+
+			res, _, _ := runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
+
+			if res.Status != tt.wantStatus {
+				t.Errorf("expected status %s, got %s", tt.wantStatus, res.Status)
+			}
+			if res.FailedOnMatch != tt.wantFailed || res.PassedOnMatch != tt.wantPassed || res.MatchedLine != tt.wantLine {
+				t.Errorf("expected failedOnMatch=%q passedOnMatch=%q matchedLine=%q, got %q %q %q",
+					tt.wantFailed, tt.wantPassed, tt.wantLine, res.FailedOnMatch, res.PassedOnMatch, res.MatchedLine)
+			}
+		})
+	}
+}
+
+func TestRunTask_CommandNotFound(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on the POSIX shell exit code 127")
+	}
+
+	tests := []struct {
+		name         string
+		command      string
+		wantNotFound string
+	}{
+		{"missing program", "devpipe-no-such-tool --check .", "devpipe-no-such-tool"},
+		{"missing after assignment", "GOFLAGS=-mod=mod devpipe-no-such-tool", "devpipe-no-such-tool"},
+		{"missing script", "./scripts/lint.sh", "./scripts/lint.sh"},
+		{"tool exiting 127 itself", "exit 127", ""},
+		{"ordinary failure", "false", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runDir := t.TempDir()
+			logDir := filepath.Join(runDir, "logs")
+			if err := os.MkdirAll(logDir, 0o755); err != nil {
+				t.Fatalf("failed to create log dir: %v", err)
+			}
+
+			renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+			task := model.TaskDefinition{ID: "lint", Command: tt.command, Workdir: runDir}
+
+			res, _, _ := runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
+			if res.Status != model.StatusFail {
+				t.Errorf("expected status FAIL, got %s", res.Status)
+			}
+			if res.NotFound != tt.wantNotFound {
+				t.Errorf("NotFound = %q, want %q", res.NotFound, tt.wantNotFound)
+			}
+		})
+	}
+
+	// A missing shell is reported by exec itself
+	err := shellCommand(context.Background(), []string{"devpipe-no-such-shell", "-c"}, "true").Run()
+	if got := missingCommand(err, "true", "."); got != "devpipe-no-such-shell" {
+		t.Errorf("missingCommand() = %q, want devpipe-no-such-shell", got)
+	}
+}
+
 func TestParseTaskMetrics_JUnit(t *testing.T) {
 	projectRoot, err := os.Getwd()
 	if err != nil {
@@ -268,7 +848,7 @@ func TestParseTaskMetrics_JUnit(t *testing.T) {
 		OutputPath: "testdata/junit-single-suite.xml",
 	}
 
-	m := parseTaskMetrics(task, false)
+	m := parseTaskMetrics(task, ui.NewRenderer(ui.UIModeBasic, false, false), false)
 	if m == nil {
 		t.Fatalf("expected non-nil metrics for valid junit file")
 	}
@@ -280,6 +860,154 @@ func TestParseTaskMetrics_JUnit(t *testing.T) {
 	}
 }
 
+func TestParseTaskMetrics_TAP(t *testing.T) {
+	projectRoot, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd failed: %v", err)
+	}
+
+	task := model.TaskDefinition{
+		ID:         "tap-task",
+		Workdir:    projectRoot,
+		OutputType: "tap",
+		OutputPath: "testdata/tap-sample.tap",
+	}
+
+	m := parseTaskMetrics(task, ui.NewRenderer(ui.UIModeBasic, false, false), false)
+	if m == nil {
+		t.Fatalf("expected non-nil metrics for valid TAP file")
+	}
+	if m.Kind != "test" {
+		t.Fatalf("expected metrics kind 'test', got %q", m.Kind)
+	}
+	if m.SummaryFormat != "tap" {
+		t.Fatalf("expected summary format 'tap', got %q", m.SummaryFormat)
+	}
+}
+
+func TestParseTaskMetrics_Glob(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "junit-single-suite.xml"))
+	if err != nil {
+		t.Fatalf("failed to read sample: %v", err)
+	}
+	workdir := t.TempDir()
+	for _, name := range []string{"junit-1.xml", "junit-2.xml", "other.xml"} {
+		if err := os.MkdirAll(filepath.Join(workdir, "results"), 0o755); err != nil {
+			t.Fatalf("failed to create results dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(workdir, "results", name), sample, 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+
+	single := parseTaskMetrics(model.TaskDefinition{ID: "shard", Workdir: workdir, OutputType: "junit", OutputPath: "results/junit-1.xml"}, renderer, false)
+	task := model.TaskDefinition{ID: "shards", Workdir: workdir, OutputType: "junit", OutputPath: "results/junit-*.xml"}
+	m := parseTaskMetrics(task, renderer, false)
+	if single == nil || m == nil {
+		t.Fatalf("expected metrics, got %+v and %+v", single, m)
+	}
+	if m.Data["tests"] != 2*single.Data["tests"].(int) {
+		t.Errorf("tests = %v, want the sum over both shards (2 x %v)", m.Data["tests"], single.Data["tests"])
+	}
+
+	task.OutputPath = "results/missing-*.xml"
+	if m := parseTaskMetrics(task, renderer, false); m != nil {
+		t.Errorf("expected nil metrics when nothing matches, got %+v", m)
+	}
+	if _, err := outputFiles(task); err == nil || !strings.Contains(err.Error(), "no metrics files matched pattern") {
+		t.Errorf("outputFiles error = %v, want no match", err)
+	}
+}
+
+func TestRunTask_OutputGlob(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	runDir := t.TempDir()
+	logDir := filepath.Join(runDir, "logs")
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		t.Fatalf("failed to create log dir: %v", err)
+	}
+	workdir := t.TempDir()
+	sample, err := filepath.Abs(filepath.Join("testdata", "junit-single-suite.xml"))
+	if err != nil {
+		t.Fatalf("Abs failed: %v", err)
+	}
+	renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+
+	task := model.TaskDefinition{
+		ID:         "shards",
+		Command:    "mkdir -p results/a results/b && cp " + sample + " results/a/junit-1.xml && cp " + sample + " results/b/junit-2.xml",
+		Workdir:    workdir,
+		OutputType: "junit",
+		OutputPath: "results/**/junit-*.xml",
+	}
+	res, _, _ := runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
+	if res.Status != model.StatusPass || res.Metrics == nil || res.Metrics.Data["files"] != 2 {
+		t.Fatalf("result = %s with metrics %+v, want PASS merged from 2 files", res.Status, res.Metrics)
+	}
+	for _, copied := range []string{"outputs/results/a/junit-1.xml", "outputs/results/b/junit-2.xml"} {
+		if _, err := os.Stat(filepath.Join(runDir, filepath.FromSlash(copied))); err != nil {
+			t.Errorf("output not copied to %s: %v", copied, err)
+		}
+	}
+
+	// Nothing matching the pattern fails the task
+	task.ID = "no-shards"
+	task.OutputPath = "results/**/missing-*.xml"
+	res, _, _ = runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
+	if res.Status != model.StatusFail {
+		t.Errorf("status = %s, want FAIL when no files match", res.Status)
+	}
+}
+
+func TestParseTaskMetrics_Command(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	workdir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workdir, "report.txt"), []byte("12 scenarios\n"), 0o644); err != nil {
+		t.Fatalf("failed to write report: %v", err)
+	}
+	parser := "#!/bin/sh\nprintf '{\"scenarios\": %s, \"file\": \"%s\"}' \"$(cut -d' ' -f1 \"$1\")\" \"$1\"\n"
+	if err := os.WriteFile(filepath.Join(workdir, "parse-report.sh"), []byte(parser), 0o755); err != nil {
+		t.Fatalf("failed to write parser: %v", err)
+	}
+
+	task := model.TaskDefinition{
+		ID:            "custom-task",
+		Workdir:       workdir,
+		OutputType:    "command",
+		OutputPath:    "report.txt",
+		MetricsParser: "./parse-report.sh",
+	}
+	renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+
+	m := parseTaskMetrics(task, renderer, false)
+	if m == nil {
+		t.Fatalf("expected metrics from the parser")
+	}
+	if m.Kind != "custom" || m.Data["scenarios"] != float64(12) || m.Data["file"] != filepath.Join(workdir, "report.txt") {
+		t.Errorf("metrics = %+v", m)
+	}
+
+	task.MetricsParser = "echo not-json"
+	if m := parseTaskMetrics(task, renderer, false); m != nil {
+		t.Errorf("expected nil metrics for invalid parser output, got %+v", m)
+	}
+
+	oldTimeout := metricsParserTimeout
+	metricsParserTimeout = 100 * time.Millisecond
+	defer func() { metricsParserTimeout = oldTimeout }()
+	task.MetricsParser = "sleep 5; echo '{}'"
+	if _, err := runMetricsParser(task, filepath.Join(workdir, "report.txt")); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("runMetricsParser error = %v, want a timeout", err)
+	}
+}
+
 func TestParseTaskMetrics_FileNotFound(t *testing.T) {
 	task := model.TaskDefinition{
 		ID:         "missing-metrics",
@@ -288,7 +1016,7 @@ func TestParseTaskMetrics_FileNotFound(t *testing.T) {
 		OutputPath: "does-not-exist.xml",
 	}
 
-	m := parseTaskMetrics(task, true)
+	m := parseTaskMetrics(task, ui.NewRenderer(ui.UIModeBasic, false, false), true)
 	if m != nil {
 		t.Fatalf("expected nil metrics when file is missing, got %#v", m)
 	}
@@ -307,7 +1035,7 @@ func TestParseTaskMetrics_SARIF(t *testing.T) {
 		OutputPath: "testdata/sarif-sample.json",
 	}
 
-	m := parseTaskMetrics(task, false)
+	m := parseTaskMetrics(task, ui.NewRenderer(ui.UIModeBasic, false, false), false)
 	if m == nil {
 		t.Fatalf("expected non-nil metrics for valid SARIF file")
 	}
@@ -335,7 +1063,7 @@ func TestParseTaskMetrics_Artifact(t *testing.T) {
 		OutputPath: "artifact.txt",
 	}
 
-	m := parseTaskMetrics(task, false)
+	m := parseTaskMetrics(task, ui.NewRenderer(ui.UIModeBasic, false, false), false)
 	if m == nil {
 		t.Fatalf("expected non-nil metrics for artifact format")
 	}
@@ -372,7 +1100,7 @@ func TestParseTaskMetrics_UnknownFormat(t *testing.T) {
 		OutputPath: "test.txt",
 	}
 
-	m := parseTaskMetrics(task, false)
+	m := parseTaskMetrics(task, ui.NewRenderer(ui.UIModeBasic, false, false), false)
 	if m != nil {
 		t.Fatalf("expected nil metrics for unknown format, got %#v", m)
 	}
@@ -395,7 +1123,7 @@ func TestParseTaskMetrics_MalformedJUnit(t *testing.T) {
 		OutputPath: "malformed.xml",
 	}
 
-	m := parseTaskMetrics(task, false)
+	m := parseTaskMetrics(task, ui.NewRenderer(ui.UIModeBasic, false, false), false)
 	if m != nil {
 		t.Fatalf("expected nil metrics for malformed JUnit XML, got %#v", m)
 	}
@@ -418,7 +1146,7 @@ func TestParseTaskMetrics_MalformedSARIF(t *testing.T) {
 		OutputPath: "malformed.sarif",
 	}
 
-	m := parseTaskMetrics(task, false)
+	m := parseTaskMetrics(task, ui.NewRenderer(ui.UIModeBasic, false, false), false)
 	if m != nil {
 		t.Fatalf("expected nil metrics for malformed SARIF, got %#v", m)
 	}
@@ -440,7 +1168,7 @@ func TestParseTaskMetrics_AbsolutePath(t *testing.T) {
 		OutputPath: artifactPath, // Absolute path
 	}
 
-	m := parseTaskMetrics(task, false)
+	m := parseTaskMetrics(task, ui.NewRenderer(ui.UIModeBasic, false, false), false)
 	if m == nil {
 		t.Fatalf("expected non-nil metrics for absolute path")
 	}
@@ -471,7 +1199,7 @@ func TestParseTaskMetrics_RelativePath(t *testing.T) {
 		OutputPath: "relative-artifact.txt", // Relative path
 	}
 
-	m := parseTaskMetrics(task, false)
+	m := parseTaskMetrics(task, ui.NewRenderer(ui.UIModeBasic, false, false), false)
 	if m == nil {
 		t.Fatalf("expected non-nil metrics for relative path")
 	}
@@ -526,3 +1254,458 @@ func TestCopyConfigToRun_NoFile_WritesJSON(t *testing.T) {
 		t.Fatalf("expected config.json to be written, got error: %v", err)
 	}
 }
+
+func TestShellCommand(t *testing.T) {
+	cmd := shellCommand(context.Background(), []string{"bash", "-e", "-c"}, "echo hi")
+	want := []string{"bash", "-e", "-c", "echo hi"}
+	if strings.Join(cmd.Args, "|") != strings.Join(want, "|") {
+		t.Errorf("Args = %q, want %q", cmd.Args, want)
+	}
+
+	// Empty shell falls back to the platform default
+	cmd = shellCommand(context.Background(), nil, "echo hi")
+	if cmd.Args[len(cmd.Args)-1] != "echo hi" || cmd.Args[0] != config.DefaultShell()[0] {
+		t.Errorf("Args = %q, want default shell", cmd.Args)
+	}
+}
+
+func TestRunTask_EnvRefsStayData(t *testing.T) {
+	runDir := t.TempDir()
+	logDir := filepath.Join(runDir, "logs")
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		t.Fatalf("failed to create log dir: %v", err)
+	}
+	pwned := filepath.Join(runDir, "pwned")
+	t.Setenv("DEVPIPE_TEST_FILES", "a.go\nb.go; touch "+pwned+"\n$(touch "+pwned+")")
+	t.Setenv("DEVPIPE_TEST_SECRET", "hunter2")
+
+	cfg := &config.Config{}
+	resolved := cfg.ResolveTaskConfig("lint", config.TaskConfig{
+		Command: `printf '%s\n' ${DEVPIPE_TEST_FILES} | wc -l; echo "${DEVPIPE_TEST_SECRET}"`,
+	}, runDir)
+	task := model.TaskDefinition{ID: "lint", Command: resolved.Command, Workdir: runDir}
+
+	renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+	res, _, err := runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
+	if err != nil || res.Status != model.StatusPass {
+		t.Fatalf("runTask() = %s, %v", res.Status, err)
+	}
+	if _, err := os.Stat(pwned); err == nil {
+		t.Error("a variable's value ran as shell code")
+	}
+	if strings.Contains(res.Command, "hunter2") || !strings.Contains(res.Command, "${DEVPIPE_TEST_SECRET}") {
+		t.Errorf("Command = %q, want the unexpanded reference", res.Command)
+	}
+	if log, _ := os.ReadFile(res.LogPath); !strings.Contains(string(log), "hunter2") {
+		t.Errorf("log = %q, want the shell to expand the secret", log)
+	}
+}
+
+func TestContainerCommand(t *testing.T) {
+	task := model.TaskDefinition{
+		ID:               "lint",
+		Command:          "npm run lint",
+		FixCommand:       "npm run lint -- --fix",
+		Workdir:          "/src/web",
+		Image:            "node:20",
+		ContainerRuntime: "podman",
+		RequiredEnv:      []string{"NPM_TOKEN"},
+	}
+
+	args := containerCommand(context.Background(), containerSpec(task)).Args
+	if args[0] != "podman" || args[1] != "run" || strings.Join(args[len(args)-4:], "|") != "node:20|sh|-c|npm run lint" {
+		t.Errorf("Args = %q, want podman run ... node:20 sh -c <command>", args)
+	}
+	joined := strings.Join(args, " ")
+	for _, want := range []string{"-v /src/web:/work", "-e FORCE_COLOR", "-e DEVPIPE_GIT_REF", "-e NPM_TOKEN"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("Args = %q, missing %q", args, want)
+		}
+	}
+
+	if args := container.RunArgs(containerSpec(fixTask(task))); args[len(args)-1] != "npm run lint -- --fix" {
+		t.Errorf("fix Args = %q, want the fix command in the container", args)
+	}
+
+	// Variables the command references reach the container's shell
+	task.Command = "npm publish --tag ${NPM_TAG} --otp ${NPM_TOKEN}"
+	if joined := strings.Join(container.RunArgs(containerSpec(task)), " "); !strings.Contains(joined, "-e NPM_TAG") || strings.Count(joined, "-e NPM_TOKEN") != 1 {
+		t.Errorf("Args = %q, want NPM_TAG and NPM_TOKEN passed through once", joined)
+	}
+
+	// Only the runtime is checked before running, the programs come from the image
+	checks := checkTaskTools("lint", task.Command, nil, task.FixCommand, task.Workdir, containerRuntime(task.Image, ""))
+	if len(checks) != 1 || len(checks[0].Tools) != 1 || checks[0].Tools[0].Name != "docker" {
+		t.Errorf("checkTaskTools() = %+v, want only the default runtime", checks)
+	}
+	if hostCommand(task, task.Command) != "" {
+		t.Error("hostCommand() should not look up programs of a container task on the host")
+	}
+}
+
+func TestRunTask_CustomShell(t *testing.T) {
+	runDir := t.TempDir()
+	logDir := filepath.Join(runDir, "logs")
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		t.Fatalf("failed to create log dir: %v", err)
+	}
+
+	renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+
+	// "sh -e -c" exits on the first failing command, plain "sh -c" would not
+	task := model.TaskDefinition{
+		ID:      "shell-task",
+		Command: "false; echo after",
+		Workdir: runDir,
+		Shell:   []string{"sh", "-e", "-c"},
+	}
+
+	res, _, _ := runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
+	if res.Status != model.StatusFail {
+		t.Errorf("expected FAIL with sh -e, got %s", res.Status)
+	}
+}
+
+func TestRunTask_CommandArgs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses printf from PATH")
+	}
+
+	runDir := t.TempDir()
+	logDir := filepath.Join(runDir, "logs")
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		t.Fatalf("failed to create log dir: %v", err)
+	}
+
+	renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+
+	// Arguments reach the program verbatim: no word splitting, globbing or expansion
+	args := []string{"printf", "%s|", "it's", "$HOME", "*.go"}
+	task := model.TaskDefinition{
+		ID:          "args-task",
+		Command:     displayArgs(args),
+		CommandArgs: args,
+		Workdir:     runDir,
+	}
+
+	res, _, _ := runTask(context.Background(), task, runDir, logDir, false, false, renderer, nil, &sync.Mutex{}, nil, make(chan struct{}))
+	if res.Status != model.StatusPass {
+		t.Fatalf("expected PASS, got %s", res.Status)
+	}
+	data, err := os.ReadFile(filepath.Join(logDir, "args-task.log"))
+	if err != nil {
+		t.Fatalf("failed to read log: %v", err)
+	}
+	if got := string(data); got != "it's|$HOME|*.go|" {
+		t.Errorf("log = %q, want the arguments unexpanded", got)
+	}
+	if res.Command != `printf '%s|' 'it'\''s' '$HOME' '*.go'` {
+		t.Errorf("Command = %q, want the quoted display form", res.Command)
+	}
+}
+
+func TestLineWriter_JSONL(t *testing.T) {
+	logFile, err := os.Create(filepath.Join(t.TempDir(), "task.log"))
+	if err != nil {
+		t.Fatalf("failed to create log file: %v", err)
+	}
+	defer func() { _ = logFile.Close() }()
+
+	var out bytes.Buffer
+	renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+	mu := &sync.Mutex{}
+	stdout := &lineWriter{taskID: "task", stream: "stdout", file: logFile, outputBuffer: &out, mu: mu, renderer: renderer, logFormat: tasklog.FormatJSONL}
+	stderr := &lineWriter{taskID: "task", stream: "stderr", file: logFile, outputBuffer: &out, mu: mu, renderer: renderer, logFormat: tasklog.FormatJSONL}
+
+	_, _ = stdout.Write([]byte("hello\n"))
+	_, _ = stderr.Write([]byte("oops\npartial"))
+	stdout.flushLog()
+	stderr.flushLog()
+
+	// Console output stays human-readable
+	if !strings.Contains(out.String(), "] hello\n") || strings.Contains(out.String(), "{") {
+		t.Errorf("console output = %q, want plain prefixed lines", out.String())
+	}
+
+	logData, err := os.ReadFile(logFile.Name())
+	if err != nil {
+		t.Fatalf("failed to read log: %v", err)
+	}
+	var got []tasklog.Record
+	for _, line := range strings.Split(strings.TrimSpace(string(logData)), "\n") {
+		var rec tasklog.Record
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("log line is not a JSON record: %q", line)
+		}
+		if rec.Task != "task" || rec.TS == "" {
+			t.Errorf("record missing task or ts: %+v", rec)
+		}
+		got = append(got, rec)
+	}
+	if len(got) != 3 || got[0].Stream != "stdout" || got[0].Line != "hello" ||
+		got[1].Stream != "stderr" || got[1].Line != "oops" || got[2].Line != "partial" {
+		t.Errorf("records = %+v, want hello (stdout), oops and partial (stderr)", got)
+	}
+}
+
+// recordingSink records the lines forwarded to it
+type recordingSink struct{ lines []string }
+
+func (s *recordingSink) WriteLine(_ time.Time, taskID, stream, line string) error {
+	s.lines = append(s.lines, taskID+" "+stream+" "+line)
+	return nil
+}
+
+func (s *recordingSink) Close() error { return nil }
+
+func TestLineWriter_Sink(t *testing.T) {
+	logFile, err := os.Create(filepath.Join(t.TempDir(), "task.log"))
+	if err != nil {
+		t.Fatalf("failed to create log file: %v", err)
+	}
+	defer func() { _ = logFile.Close() }()
+
+	var out bytes.Buffer
+	sink := &recordingSink{}
+	forwarder := logsink.NewForwarder(sink, io.Discard)
+	masker, _ := redact.New([]string{`token=\S+`})
+	renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+	mu := &sync.Mutex{}
+	stdout := &lineWriter{taskID: "task", stream: "stdout", file: logFile, outputBuffer: &out, mu: mu, renderer: renderer, masker: masker, sink: forwarder}
+	stderr := &lineWriter{taskID: "task", stream: "stderr", file: logFile, outputBuffer: &out, mu: mu, renderer: renderer, masker: masker, sink: forwarder}
+
+	_, _ = stdout.Write([]byte("login token=abc123\n"))
+	_, _ = stderr.Write([]byte("partial"))
+	stdout.flushLog()
+	stderr.flushLog()
+
+	want := []string{"task stdout login ***", "task stderr partial"}
+	if !reflect.DeepEqual(sink.lines, want) {
+		t.Errorf("forwarded lines = %q, want %q", sink.lines, want)
+	}
+}
+
+func TestLineWriter_StreamTag(t *testing.T) {
+	logFile, err := os.Create(filepath.Join(t.TempDir(), "task.log"))
+	if err != nil {
+		t.Fatalf("failed to create log file: %v", err)
+	}
+	defer func() { _ = logFile.Close() }()
+
+	var out bytes.Buffer
+	renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+	mu := &sync.Mutex{}
+	stdout := &lineWriter{taskID: "task", stream: "stdout", file: logFile, outputBuffer: &out, mu: mu, renderer: renderer, showStream: true}
+	stderr := &lineWriter{taskID: "task", stream: "stderr", file: logFile, outputBuffer: &out, mu: mu, renderer: renderer, showStream: true}
+
+	_, _ = stdout.Write([]byte("result\n"))
+	_, _ = stderr.Write([]byte("progress\n"))
+
+	want := "[task           ] stdout| result\n[task           ] stderr| progress\n"
+	if out.String() != want {
+		t.Errorf("console output = %q, want %q", out.String(), want)
+	}
+
+	// The raw log is not tagged
+	logData, err := os.ReadFile(logFile.Name())
+	if err != nil {
+		t.Fatalf("failed to read log: %v", err)
+	}
+	if string(logData) != "result\nprogress\n" {
+		t.Errorf("log = %q, want untagged output", logData)
+	}
+}
+
+func TestLineWriter_MaxLogBytes(t *testing.T) {
+	logFile, err := os.Create(filepath.Join(t.TempDir(), "task.log"))
+	if err != nil {
+		t.Fatalf("failed to create log file: %v", err)
+	}
+	defer func() { _ = logFile.Close() }()
+
+	var out bytes.Buffer
+	renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+	renderer.SetLinePrefix("", nil)
+	mu := &sync.Mutex{}
+	limit := &outputLimit{max: 10}
+	stdout := &lineWriter{taskID: "task", stream: "stdout", file: logFile, outputBuffer: &out, mu: mu, renderer: renderer, limit: limit}
+	stderr := &lineWriter{taskID: "task", stream: "stderr", file: logFile, outputBuffer: &out, mu: mu, renderer: renderer, limit: limit}
+
+	// The cap is shared by both streams; writes past it are still accepted in full
+	for _, write := range []struct {
+		w    *lineWriter
+		data string
+	}{{stdout, "one\n"}, {stderr, "two\n"}, {stdout, "three\n"}, {stderr, "four\n"}} {
+		if n, err := write.w.Write([]byte(write.data)); n != len(write.data) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", write.data, n, err)
+		}
+	}
+
+	want := "one\ntwo\nth\n[output truncated after 10 bytes]\n"
+	if out.String() != want {
+		t.Errorf("console output = %q, want %q", out.String(), want)
+	}
+	logData, err := os.ReadFile(logFile.Name())
+	if err != nil {
+		t.Fatalf("failed to read log: %v", err)
+	}
+	if string(logData) != want {
+		t.Errorf("log = %q, want %q", logData, want)
+	}
+}
+
+func TestLineWriter_Masking(t *testing.T) {
+	logFile, err := os.Create(filepath.Join(t.TempDir(), "task.log"))
+	if err != nil {
+		t.Fatalf("failed to create log file: %v", err)
+	}
+	defer func() { _ = logFile.Close() }()
+
+	masker, err := redact.New(maskPatterns(config.DefaultsConfig{MaskEnv: []string{"DEPLOY_TOKEN"}}, func(string) string { return "s3cr3t-value" }))
+	if err != nil {
+		t.Fatalf("redact.New() error = %v", err)
+	}
+	var out bytes.Buffer
+	renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+	renderer.SetLinePrefix("", nil)
+	w := &lineWriter{taskID: "task", stream: "stdout", file: logFile, outputBuffer: &out, mu: &sync.Mutex{}, renderer: renderer, masker: masker}
+
+	// A secret split across writes is still masked, and so is a trailing partial line
+	for _, data := range []string{"using s3cr3t", "-value\nkey AKIAIOSF", "ODNN7EXAMPLE\n", "Bearer abc.def"} {
+		_, _ = w.Write([]byte(data))
+	}
+	w.flushLog()
+
+	if want := "using ***\nkey ***\n"; out.String() != want {
+		t.Errorf("console output = %q, want %q", out.String(), want)
+	}
+	logData, err := os.ReadFile(logFile.Name())
+	if err != nil {
+		t.Fatalf("failed to read log: %v", err)
+	}
+	if want := "using ***\nkey ***\nBearer ***"; string(logData) != want {
+		t.Errorf("log = %q, want %q", logData, want)
+	}
+}
+
+func TestLineWriter_Sanitize(t *testing.T) {
+	logFile, err := os.Create(filepath.Join(t.TempDir(), "task.log"))
+	if err != nil {
+		t.Fatalf("failed to create log file: %v", err)
+	}
+	defer func() { _ = logFile.Close() }()
+
+	var out bytes.Buffer
+	renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+	renderer.SetLinePrefix("", nil)
+	w := &lineWriter{taskID: "task", stream: "stdout", file: logFile, outputBuffer: &out, mu: &sync.Mutex{}, renderer: renderer, sanitize: true}
+
+	raw := "\x1b[32mok\x1b[0m\n\x1b[2J\x00\xff\x89PNG\n"
+	_, _ = w.Write([]byte(raw))
+
+	if want := "\x1b[32mok\x1b[0m\n" + `\x1b[2J\x00` + "\ufffd\ufffdPNG\n"; out.String() != want {
+		t.Errorf("console output = %q, want %q", out.String(), want)
+	}
+	logData, err := os.ReadFile(logFile.Name())
+	if err != nil {
+		t.Fatalf("failed to read log: %v", err)
+	}
+	if string(logData) != raw {
+		t.Errorf("log = %q, want the raw bytes %q", logData, raw)
+	}
+}
+
+func TestMaskPatterns(t *testing.T) {
+	off := false
+	getenv := func(string) string { return "" }
+	if got := maskPatterns(config.DefaultsConfig{}, getenv); len(got) != len(redact.Builtin) {
+		t.Errorf("maskPatterns() = %v, want the built-in patterns", got)
+	}
+	got := maskPatterns(config.DefaultsConfig{MaskBuiltins: &off, MaskPatterns: []string{`pw=(\S+)`}, MaskEnv: []string{"UNSET"}}, getenv)
+	if len(got) != 1 || got[0] != `pw=(\S+)` {
+		t.Errorf("maskPatterns() without builtins = %v, want only the configured pattern", got)
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+	run := func(chatty bool) string {
+		var out bytes.Buffer
+		var mu sync.Mutex
+		start := time.Now()
+		lastOutput := start
+		done, stopped := make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(stopped)
+			heartbeat(&out, renderer, "slow", 50*time.Millisecond, start, &lastOutput, &mu, done)
+		}()
+		for i := 0; i < 25; i++ {
+			time.Sleep(5 * time.Millisecond)
+			if chatty {
+				mu.Lock()
+				lastOutput = time.Now()
+				mu.Unlock()
+			}
+		}
+		close(done)
+		<-stopped
+		return out.String()
+	}
+
+	if out := run(false); !strings.HasPrefix(out, "[slow           ] still running (0s)...\n") {
+		t.Errorf("silent task output = %q, want still running lines", out)
+	}
+	if out := run(true); out != "" {
+		t.Errorf("task with output printed %q, want no heartbeat", out)
+	}
+}
+
+func TestLineWriter_Timestamps(t *testing.T) {
+	tests := []struct {
+		name          string
+		timestamps    string
+		timestampLogs bool
+		wantConsole   string
+		wantLog       string
+	}{
+		{"off", "off", true, "[task           ] hello\n", "hello\npartial"},
+		{"elapsed console only", "elapsed", false, "[task           ] +0.", "hello\npartial"},
+		{"elapsed in logs", "elapsed", true, "[task           ] +0.", "+0."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logFile, err := os.Create(filepath.Join(t.TempDir(), "task.log"))
+			if err != nil {
+				t.Fatalf("failed to create log file: %v", err)
+			}
+			defer func() { _ = logFile.Close() }()
+
+			var out bytes.Buffer
+			w := &lineWriter{
+				taskID:        "task",
+				file:          logFile,
+				outputBuffer:  &out,
+				mu:            &sync.Mutex{},
+				renderer:      ui.NewRenderer(ui.UIModeBasic, false, false),
+				timestamps:    tt.timestamps,
+				timestampLogs: tt.timestampLogs,
+				start:         time.Now(),
+			}
+			_, _ = w.Write([]byte("hello\npartial"))
+			w.flushLog()
+
+			if !strings.HasPrefix(out.String(), tt.wantConsole) || !strings.HasSuffix(out.String(), "hello\n") {
+				t.Errorf("console output = %q, want prefix %q", out.String(), tt.wantConsole)
+			}
+
+			logData, err := os.ReadFile(logFile.Name())
+			if err != nil {
+				t.Fatalf("failed to read log: %v", err)
+			}
+			if !strings.HasPrefix(string(logData), tt.wantLog) || !strings.HasSuffix(string(logData), "partial") {
+				t.Errorf("log = %q, want prefix %q", logData, tt.wantLog)
+			}
+		})
+	}
+}
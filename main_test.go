@@ -1,10 +1,18 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
+	"io"
+	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/drew/devpipe/internal/config"
 	"github.com/drew/devpipe/internal/model"
@@ -103,21 +111,56 @@ func TestTruncate(t *testing.T) {
 }
 
 func TestMakeRunID(t *testing.T) {
-	// Test that makeRunID generates valid IDs
-	id := makeRunID()
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
 
-	if id == "" {
-		t.Error("Expected non-empty run ID")
+	id := makeRunID(now, "")
+	if !regexp.MustCompile(`^2024-05-01T10-00-00Z_[0-9a-f]{6}$`).MatchString(id) {
+		t.Errorf("makeRunID() = %q, want timestamp and random suffix", id)
 	}
+	if other := makeRunID(now, ""); other == id {
+		t.Errorf("makeRunID() returned %q twice for the same second", id)
+	}
+
+	id = makeRunID(now, "Build-Agent_7.corp.example.com")
+	if !regexp.MustCompile(`^2024-05-01T10-00-00Z_build-agent7_[0-9a-f]{6}$`).MatchString(id) {
+		t.Errorf("makeRunID() with hostname = %q, want sanitized short host name", id)
+	}
+}
+
+func TestCreateRunDir(t *testing.T) {
+	outputRoot := t.TempDir()
 
-	// Should contain timestamp format (YYYY-MM-DDTHH-MM-SSZ_NNNNNN)
-	if len(id) < 20 {
-		t.Errorf("Expected run ID length >= 20, got %d", len(id))
+	id, err := createRunDir(outputRoot, "timestamp")
+	if err != nil {
+		t.Fatalf("createRunDir() error = %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(outputRoot, "runs", id)); err != nil || !info.IsDir() {
+		t.Errorf("run directory %s was not created", id)
 	}
 
-	// Should contain underscore separator
-	// Valid format check
-	_ = !containsSpace(id) && len(id) > 0
+	// Counter IDs continue from the persisted counter and skip directories other runs claimed
+	if err := os.WriteFile(filepath.Join(outputRoot, runCounterFile), []byte("6\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(outputRoot, "runs", "000007"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"000008", "000009"} {
+		if id, err := createRunDir(outputRoot, "counter"); err != nil || id != want {
+			t.Errorf("createRunDir(counter) = %q, %v, want %s", id, err, want)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(outputRoot, runCounterFile)); string(data) != "9\n" {
+		t.Errorf("run counter = %q, want 9", data)
+	}
+
+	// Without the counter file, numbering continues after the highest run directory
+	if err := os.Remove(filepath.Join(outputRoot, runCounterFile)); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := createRunDir(outputRoot, "counter"); err != nil || id != "000010" {
+		t.Errorf("createRunDir(counter) without counter file = %q, %v, want 000010", id, err)
+	}
 }
 
 func TestContainsSpace(t *testing.T) {
@@ -199,6 +242,7 @@ func TestLoadHistoricalAverages(t *testing.T) {
 	tests := []struct {
 		name          string
 		summaryJSON   string
+		stat          string
 		wantAverages  map[string]int
 		createSummary bool
 	}{
@@ -242,6 +286,34 @@ func TestLoadHistoricalAverages(t *testing.T) {
 				"task2": 2,
 			},
 		},
+		{
+			name:          "p95 stat",
+			createSummary: true,
+			stat:          "p95",
+			summaryJSON: `{
+				"taskStats": {
+					"task1": {"avgDuration": 5000, "p95Duration": 42000, "maxDuration": 60000},
+					"task2": {"avgDuration": 3000}
+				}
+			}`,
+			wantAverages: map[string]int{
+				"task1": 42,
+				"task2": 3, // Older summaries without p95 fall back to the mean
+			},
+		},
+		{
+			name:          "max stat",
+			createSummary: true,
+			stat:          "max",
+			summaryJSON: `{
+				"taskStats": {
+					"task1": {"avgDuration": 5000, "p95Duration": 42000, "maxDuration": 60000}
+				}
+			}`,
+			wantAverages: map[string]int{
+				"task1": 60,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -254,7 +326,7 @@ func TestLoadHistoricalAverages(t *testing.T) {
 				_ = os.WriteFile(summaryPath, []byte(tt.summaryJSON), 0644)
 			}
 
-			got := loadHistoricalAverages(outputRoot)
+			got := loadHistoricalAverages(outputRoot, tt.stat, nil)
 
 			if len(got) != len(tt.wantAverages) {
 				t.Errorf("loadHistoricalAverages() returned %d items, want %d", len(got), len(tt.wantAverages))
@@ -271,6 +343,37 @@ func TestLoadHistoricalAverages(t *testing.T) {
 	}
 }
 
+func TestLoadHistoricalAveragesCorruptSummary(t *testing.T) {
+	outputRoot := t.TempDir()
+	runDir := filepath.Join(outputRoot, "runs", "run-1")
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	run := `{"runId": "run-1", "timestamp": "2026-01-02T03:04:05Z", "tasks": [{"id": "lint", "status": "PASS", "durationMs": 7000}]}`
+	if err := os.WriteFile(filepath.Join(runDir, "run.json"), []byte(run), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Truncated, as if the writer was killed part way through
+	if err := os.WriteFile(filepath.Join(outputRoot, "summary.json"), []byte(`{"taskStats": {"lint": {"avgDu`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var warn bytes.Buffer
+	got := loadHistoricalAverages(outputRoot, "mean", &warn)
+	if got["lint"] != 7 {
+		t.Errorf("loadHistoricalAverages() = %v, want lint rebuilt from the run history as 7", got)
+	}
+	if !strings.Contains(warn.String(), "summary.json was corrupt") {
+		t.Errorf("warning = %q, want it to mention the corrupt summary", warn.String())
+	}
+
+	warn.Reset()
+	_ = loadHistoricalAverages(outputRoot, "mean", &warn)
+	if warn.Len() != 0 {
+		t.Errorf("warning after the rebuild = %q, want none", warn.String())
+	}
+}
+
 func TestLoadTaskAveragesLast25(t *testing.T) {
 	// Create temp directory for test
 	tmpDir := t.TempDir()
@@ -325,7 +428,7 @@ func TestLoadTaskAveragesLast25(t *testing.T) {
 				_ = os.WriteFile(summaryPath, []byte(tt.summaryJSON), 0644)
 			}
 
-			got := loadTaskAveragesLast25(outputRoot)
+			got := loadTaskAveragesLast25(outputRoot, nil)
 
 			if len(got) != len(tt.wantAverages) {
 				t.Errorf("loadTaskAveragesLast25() returned %d items, want %d", len(got), len(tt.wantAverages))
@@ -388,95 +491,115 @@ func TestWriteRunJSON(t *testing.T) {
 	}
 }
 
-func TestBuildEffectiveConfig(t *testing.T) {
-	// Create a basic config
-	cfg := &config.Config{
-		Defaults: config.DefaultsConfig{
-			OutputRoot:    "custom-output",
-			FastThreshold: 30,
-			UIMode:        "full",
-		},
+func TestNewListedTask(t *testing.T) {
+	resolved := config.TaskConfig{
+		Name:        "Unit tests",
+		Type:        "test",
+		CommandArgs: []string{"go", "test", "./..."},
+		Tags:        []string{"fast"},
 	}
-
-	mergedCfg := config.MergeWithDefaults(cfg)
-
-	effective := buildEffectiveConfig(cfg, &mergedCfg, "", "basic", "basic", "staged", "HEAD", map[string]int{})
-
-	if effective == nil {
-		t.Fatal("buildEffectiveConfig() returned nil")
+	listed := newListedTask("unit", "Test", "phase-test", resolved, 1234.6, true)
+	if listed.Command != "go test ./..." || listed.Phase != "Test" || listed.PhaseID != "phase-test" {
+		t.Errorf("newListedTask() = %+v", listed)
 	}
-
-	if len(effective.Values) == 0 {
-		t.Error("buildEffectiveConfig() returned no values")
+	if listed.AvgDurationMs == nil || *listed.AvgDurationMs != 1235 {
+		t.Errorf("AvgDurationMs = %v, want 1235", listed.AvgDurationMs)
 	}
 
-	// Check that we have expected config values
-	foundOutputRoot := false
-	foundUIMode := false
-	for _, val := range effective.Values {
-		if val.Key == "defaults.outputRoot" {
-			foundOutputRoot = true
-			if val.Value != "custom-output" {
-				t.Errorf("outputRoot value = %q, want %q", val.Value, "custom-output")
-			}
-			if val.Source != "config-file" {
-				t.Errorf("outputRoot source = %q, want %q", val.Source, "config-file")
-			}
-		}
-		if val.Key == "defaults.uiMode" {
-			foundUIMode = true
-			if val.Value != "basic" {
-				t.Errorf("uiMode value = %q, want %q", val.Value, "basic")
-			}
+	data, err := json.Marshal(newListedTask("lint", "", "", config.TaskConfig{Command: "make lint"}, 0, false))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	for _, want := range []string{`"avgDurationMs":null`, `"watchPaths":[]`, `"tags":[]`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("JSON %s is missing %s", data, want)
 		}
 	}
+}
 
-	if !foundOutputRoot {
-		t.Error("buildEffectiveConfig() missing defaults.outputRoot")
+func TestSetLogPreviewLines(t *testing.T) {
+	tasks := []model.TaskDefinition{
+		{ID: "lint", LogPreviewLines: 10, LogPreviewFailed: 50},
+		{ID: "test", LogPreviewLines: 10, LogPreviewFailed: 50},
 	}
-	if !foundUIMode {
-		t.Error("buildEffectiveConfig() missing defaults.uiMode")
+	results := []model.TaskResult{
+		{ID: "lint", Status: model.StatusPass},
+		{ID: "test", Status: model.StatusFail},
+		{ID: "removed", Status: model.StatusFail},
+	}
+	setLogPreviewLines(results, tasks)
+	if results[0].LogPreviewLines != 10 || results[1].LogPreviewLines != 50 || results[2].LogPreviewLines != 0 {
+		t.Errorf("LogPreviewLines = %d, %d, %d; want 10, 50 and 0 for a task without a definition",
+			results[0].LogPreviewLines, results[1].LogPreviewLines, results[2].LogPreviewLines)
 	}
 }
 
-func TestBuildEffectiveConfigWithCLIOverrides(t *testing.T) {
-	// Test that CLI flags override config values
-	cfg := &config.Config{
-		Defaults: config.DefaultsConfig{
-			UIMode: "basic",
-			Git: config.GitConfig{
-				Mode: "staged",
-				Ref:  "main",
-			},
-		},
+func TestServeOutlivesRun(t *testing.T) {
+	if dir := os.Getenv("DEVPIPE_TEST_SERVE_DIR"); dir != "" {
+		if err := os.Chdir(dir); err != nil {
+			t.Fatal(err)
+		}
+		os.Args = []string{"devpipe", "--serve", "--config", "config.toml"}
+		main()
+		return
 	}
 
-	mergedCfg := config.MergeWithDefaults(cfg)
-
-	// Simulate CLI flags overriding config
-	flagSince := "HEAD~1"
-	flagUI := "full"
-
-	effective := buildEffectiveConfig(cfg, &mergedCfg, flagSince, flagUI, "full", "ref", "HEAD~1", map[string]int{})
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "-C", dir, "init", "-q").CombinedOutput(); err != nil {
+		t.Skipf("git init: %v: %s", err, out)
+	}
+	cfg := "[tasks.hello]\ncommand = \"true\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
 
-	if effective == nil {
-		t.Fatal("buildEffectiveConfig() returned nil")
+	cmd := exec.Command(os.Args[0], "-test.run=TestServeOutlivesRun")
+	cmd.Env = append(os.Environ(), "DEVPIPE_TEST_SERVE_DIR="+dir, "CI=1")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
 	}
+	defer func() { _ = cmd.Process.Kill() }()
 
-	// Check that CLI overrides are recorded
-	for _, val := range effective.Values {
-		if val.Key == "defaults.uiMode" {
-			if val.Source != "cli-flag" {
-				t.Errorf("uiMode source = %q, want %q", val.Source, "cli-flag")
-			}
-			if val.Overrode != "basic" {
-				t.Errorf("uiMode overrode = %q, want %q", val.Overrode, "basic")
-			}
+	// Read up to the line printed once the run has finished
+	url := ""
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := regexp.MustCompile(`Live dashboard: (\S+)`).FindStringSubmatch(line); m != nil {
+			url = m[1]
 		}
-		if val.Key == "defaults.git.mode" {
-			if val.Source != "cli-flag" {
-				t.Errorf("git.mode source = %q, want %q", val.Source, "cli-flag")
-			}
+		if strings.Contains(line, "Still serving the dashboard") {
+			break
 		}
 	}
+	if url == "" {
+		t.Fatal("no live dashboard URL printed before the run finished")
+	}
+	go func() { _, _ = io.Copy(io.Discard, stdout) }()
+
+	// The server must still answer once the run is over
+	time.Sleep(200 * time.Millisecond)
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("dashboard not served after the run finished: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET %s = %d, want 200", url, resp.StatusCode)
+	}
+
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("devpipe --serve did not stop on Ctrl-C")
+	}
 }
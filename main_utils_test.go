@@ -2,14 +2,22 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/drew/devpipe/internal/cache"
 	"github.com/drew/devpipe/internal/config"
+	"github.com/drew/devpipe/internal/dashboard"
 	"github.com/drew/devpipe/internal/model"
+	"github.com/drew/devpipe/internal/ui"
 )
 
 func TestWrapText(t *testing.T) {
@@ -109,11 +117,340 @@ func TestPrintHelp(t *testing.T) {
 	}
 }
 
+func TestGroupTurns(t *testing.T) {
+	turns := groupTurns{}
+
+	if wait, done := turns.take(""); wait != nil || done != nil {
+		t.Fatalf("take(\"\") = %v, %v; want no turn-taking without a group", wait, done)
+	}
+
+	wait1, done1 := turns.take("db")
+	if wait1 != nil || done1 == nil {
+		t.Fatalf("first task of a group should not wait")
+	}
+	waitOther, _ := turns.take("port")
+	if waitOther != nil {
+		t.Errorf("first task of another group should not wait")
+	}
+	wait2, done2 := turns.take("db")
+	if wait2 != done1 {
+		t.Errorf("second task of a group should wait for the first to finish")
+	}
+	if wait3, _ := turns.take("db"); wait3 != done2 {
+		t.Errorf("third task of a group should wait for the second to finish")
+	}
+}
+
+func TestMergeLabels(t *testing.T) {
+	labels, err := mergeLabels(map[string]string{"team": "web", "ci": "false"}, sliceFlag{"ci=true", "pr=1234", "note=a=b"})
+	if err != nil {
+		t.Fatalf("mergeLabels() error = %v", err)
+	}
+	want := model.Labels{"team": "web", "ci": "true", "pr": "1234", "note": "a=b"}
+	if len(labels) != len(want) {
+		t.Fatalf("mergeLabels() = %v, want %v", labels, want)
+	}
+	for k, v := range want {
+		if labels[k] != v {
+			t.Errorf("labels[%q] = %q, want %q", k, labels[k], v)
+		}
+	}
+
+	if labels, _ := mergeLabels(nil, nil); labels != nil {
+		t.Errorf("mergeLabels(nil, nil) = %v, want nil so run.json omits labels", labels)
+	}
+	for _, bad := range []string{"ci", "=true"} {
+		if _, err := mergeLabels(nil, sliceFlag{bad}); err == nil {
+			t.Errorf("mergeLabels(%q) expected an error", bad)
+		}
+	}
+}
+
+func TestFailureTails(t *testing.T) {
+	dir := t.TempDir()
+	failLog := filepath.Join(dir, "test.log")
+	if err := os.WriteFile(failLog, []byte("ok 1\nok 2\n\x1b[31mFAIL TestLogin\x1b[0m\n\n"), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	results := []model.TaskResult{
+		{ID: "lint", Status: model.StatusPass, LogPath: failLog},
+		{ID: "test", Status: model.StatusFail, LogPath: failLog},
+		{ID: "build", Status: model.StatusFail, LogPath: filepath.Join(dir, "missing.log")},
+	}
+
+	tails := failureTails(results, 2)
+	if len(tails) != 1 || tails[0].ID != "test" || tails[0].LogPath != failLog {
+		t.Fatalf("tails = %+v, want only the failed task with a log", tails)
+	}
+	if want := []string{"ok 2", "FAIL TestLogin"}; !reflect.DeepEqual(tails[0].Lines, want) {
+		t.Errorf("lines = %q, want %q without trailing blanks or color codes", tails[0].Lines, want)
+	}
+}
+
+func TestCacheCommand(t *testing.T) {
+	dir := t.TempDir()
+	hash := func(st model.TaskDefinition) string {
+		h, err := cache.HashInputs(dir, cacheCommand(st), []string{"**/*.go"})
+		if err != nil {
+			t.Fatalf("HashInputs() error = %v", err)
+		}
+		return h
+	}
+
+	// A commandArgs task has no command of its own, so its arguments must key the cache
+	base := hash(model.TaskDefinition{CommandArgs: []string{"go", "test", "./..."}})
+	if hash(model.TaskDefinition{CommandArgs: []string{"go", "test", "-race", "./..."}}) == base {
+		t.Error("changing commandArgs kept the cached hash")
+	}
+	if hash(model.TaskDefinition{Command: "go test ./..."}) == hash(model.TaskDefinition{Command: "go test -race ./..."}) {
+		t.Error("changing command kept the cached hash")
+	}
+
+	// So must the image and container runtime it runs in
+	node20 := hash(model.TaskDefinition{Command: "npm test", Image: "node:20"})
+	if hash(model.TaskDefinition{Command: "npm test"}) == node20 {
+		t.Error("adding an image kept the cached hash")
+	}
+	if hash(model.TaskDefinition{Command: "npm test", Image: "node:22"}) == node20 {
+		t.Error("changing the image kept the cached hash")
+	}
+	if hash(model.TaskDefinition{Command: "npm test", Image: "node:20", ContainerRuntime: "podman"}) == node20 {
+		t.Error("changing the container runtime kept the cached hash")
+	}
+}
+
+func TestAdHocTasks(t *testing.T) {
+	tasks, order := adHocTasks([]string{"go build ./...", "go test ./..."}, false)
+	if tasks["task-1"].Command != "go build ./..." || tasks["task-2"].Command != "go test ./..." {
+		t.Errorf("tasks = %+v, want task-1 and task-2 in the order given", tasks)
+	}
+	if want := []string{"task-1", "wait-1", "task-2"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("sequential order = %v, want %v", order, want)
+	}
+
+	_, order = adHocTasks([]string{"go vet ./...", "go test ./..."}, true)
+	if want := []string{"task-1", "task-2"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("parallel order = %v, want %v", order, want)
+	}
+}
+
+func TestArtifactDest(t *testing.T) {
+	runDir := filepath.Join("runs", "r1")
+	tests := []struct {
+		name string
+		task model.TaskDefinition
+		file string // Matched output file, when outputPath is a glob
+		want string
+	}{
+		{"relative path keeps layout", model.TaskDefinition{ID: "test", OutputPath: "results/junit.xml"}, "", filepath.Join(runDir, "outputs", "results", "junit.xml")},
+		{"absolute path under task id", model.TaskDefinition{ID: "test", OutputPath: "/tmp/junit.xml"}, "", filepath.Join(runDir, "outputs", "test", "tmp", "junit.xml")},
+		{"artifactDir override", model.TaskDefinition{ID: "test", OutputPath: "web/results/junit.xml", ArtifactDir: "artifacts/web"}, "", filepath.Join(runDir, "artifacts", "web", "junit.xml")},
+		{"glob match keeps layout", model.TaskDefinition{ID: "test", OutputPath: "results/**/junit-*.xml"}, filepath.Join("results", "shard1", "junit-1.xml"), filepath.Join(runDir, "outputs", "results", "shard1", "junit-1.xml")},
+		{"glob match under artifactDir", model.TaskDefinition{ID: "test", OutputPath: "web/results/**/junit-*.xml", ArtifactDir: "artifacts/web"}, filepath.Join("web", "results", "shard1", "junit-1.xml"), filepath.Join(runDir, "artifacts", "web", "shard1", "junit-1.xml")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := tt.file
+			if file == "" {
+				file = tt.task.OutputPath
+			}
+			if got := artifactDest(runDir, tt.task, file); got != tt.want {
+				t.Errorf("artifactDest() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterTasks_PhasesAndGlobs(t *testing.T) {
+	tasks := []model.TaskDefinition{
+		{ID: "lint", Phase: "Quality", PhaseID: "phase-quality"},
+		{ID: "lint-fix", Phase: "Quality", PhaseID: "phase-quality"},
+		{ID: "test-unit", Phase: "Test", PhaseID: "phase-test"},
+		{ID: "test-e2e", Phase: "Test", PhaseID: "phase-test"},
+		{ID: "build", Phase: "Build", PhaseID: "phase-lint"},
+	}
+
+	tests := []struct {
+		name string
+		only string
+		skip sliceFlag
+		want string
+	}{
+		{"exact id wins over phase", "lint", nil, "lint"},
+		{"phase short id", "quality", nil, "lint,lint-fix"},
+		{"phase full id", "phase-test", nil, "test-unit,test-e2e"},
+		{"phase name is case-insensitive", "test", nil, "test-unit,test-e2e"},
+		{"glob", "test-*", nil, "test-unit,test-e2e"},
+		{"mixed values keep pipeline order", "test-e2e,quality", nil, "lint,lint-fix,test-e2e"},
+		{"skip phase", "", sliceFlag{"test"}, "lint,lint-fix,build"},
+		{"skip glob", "", sliceFlag{"lint*"}, "test-unit,test-e2e,build"},
+		{"only phase skip glob", "test", sliceFlag{"*-e2e"}, "test-unit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []string
+			for _, task := range filterTasks(tasks, tt.only, tt.skip, false, 0, ui.NewRenderer(ui.UIModeBasic, false, false), false) {
+				ids = append(ids, task.ID)
+			}
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Errorf("filterTasks(only=%q, skip=%v) = %s, want %s", tt.only, tt.skip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterTasksByTags(t *testing.T) {
+	tasks := []model.TaskDefinition{
+		{ID: "eslint", Tags: []string{"fast", "frontend"}},
+		{ID: "jest", Tags: []string{"frontend"}},
+		{ID: "go-test", Tags: []string{"backend"}},
+		{ID: "e2e", Tags: []string{"frontend", "slow"}},
+		{ID: "untagged"},
+	}
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    string
+	}{
+		{"no tag filters", nil, nil, "eslint,jest,go-test,e2e,untagged"},
+		{"include one tag", []string{"frontend"}, nil, "eslint,jest,e2e"},
+		{"include any of several", []string{"fast", "backend"}, nil, "eslint,go-test"},
+		{"exclude only", nil, []string{"slow"}, "eslint,jest,go-test,untagged"},
+		{"include and exclude", []string{"frontend"}, []string{"slow"}, "eslint,jest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []string
+			for _, task := range filterTasksByTags(tasks, tasks, tt.include, tt.exclude, ui.NewRenderer(ui.UIModeBasic, false, false), false) {
+				ids = append(ids, task.ID)
+			}
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Errorf("filterTasksByTags() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// Tag filters intersect with --only
+	only := filterTasks(tasks, "jest,go-test", nil, false, 0, ui.NewRenderer(ui.UIModeBasic, false, false), false)
+	if got := filterTasksByTags(only, tasks, []string{"frontend"}, nil, ui.NewRenderer(ui.UIModeBasic, false, false), false); len(got) != 1 || got[0].ID != "jest" {
+		t.Errorf("Expected --only and --tag to intersect to [jest], got %v", got)
+	}
+}
+
+func TestFilterTasksByWatchPaths_Required(t *testing.T) {
+	root := t.TempDir()
+	tasks := []model.TaskDefinition{
+		{ID: "build"},
+		{ID: "frontend", Workdir: root, WatchPaths: []string{"web/**"}},
+		{ID: "security", Workdir: root, WatchPaths: []string{"go.sum"}, Required: true},
+	}
+
+	for _, changed := range [][]string{nil, {"README.md"}} {
+		var got []string
+		for _, task := range filterTasksByWatchPaths(tasks, changed, root, ui.NewRenderer(ui.UIModeBasic, false, false), false) {
+			got = append(got, task.ID)
+		}
+		if strings.Join(got, ",") != "build,security" {
+			t.Errorf("changed=%v: filterTasksByWatchPaths() = %v, want [build security]", changed, got)
+		}
+	}
+}
+
+func TestFilterTasksByWatchPaths_Negation(t *testing.T) {
+	root := t.TempDir()
+	tasks := []model.TaskDefinition{
+		{ID: "build", Workdir: root, WatchPaths: []string{"src/**", "!src/**/*.test.js"}},
+		{ID: "fixtures", Workdir: root, WatchPaths: []string{"src/**", "!src/**/*.test.js", "src/fixtures/**"}},
+	}
+
+	tests := []struct {
+		changed []string
+		want    string
+	}{
+		{[]string{"src/app.js"}, "build,fixtures"},
+		{[]string{"src/app.test.js"}, ""},
+		{[]string{"src/app.test.js", "src/app.js"}, "build,fixtures"},
+		// A later inclusion re-selects a file an earlier negation excluded
+		{[]string{"src/fixtures/data.test.js"}, "fixtures"},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, task := range filterTasksByWatchPaths(tasks, tt.changed, root, ui.NewRenderer(ui.UIModeBasic, false, false), false) {
+			got = append(got, task.ID)
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("changed=%v: filterTasksByWatchPaths() = %v, want %q", tt.changed, got, tt.want)
+		}
+	}
+}
+
+func TestFilterTasksByFileTypes(t *testing.T) {
+	fileTypeMap := map[string][]string{
+		".go":        {"test", "build"},
+		"md":         {"docs"},
+		"Dockerfile": {"image"},
+	}
+	tasks := []model.TaskDefinition{
+		{ID: "go-test", Type: "test"},
+		{ID: "go-build", Type: "Build"},
+		{ID: "markdownlint", Type: "docs"},
+		{ID: "docker", Type: "image"},
+		{ID: "gitleaks", Type: "security", Required: true},
+		{ID: "untyped"},
+	}
+
+	tests := []struct {
+		changed []string
+		want    string
+	}{
+		{[]string{"cmd/main.go"}, "go-test,go-build,gitleaks"},
+		{[]string{"README.MD"}, "markdownlint,gitleaks"},
+		{[]string{"deploy/Dockerfile", "docs/guide.md"}, "markdownlint,docker,gitleaks"},
+		{[]string{"go.mod"}, "gitleaks"},
+		{nil, "gitleaks"},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, task := range filterTasksByFileTypes(tasks, tt.changed, fileTypeMap, ui.NewRenderer(ui.UIModeBasic, false, false), false) {
+			got = append(got, task.ID)
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("changed=%v: filterTasksByFileTypes() = %v, want %q", tt.changed, got, tt.want)
+		}
+	}
+}
+
+func TestFilterTasksByTags_UnknownTagExits(t *testing.T) {
+	if os.Getenv("DEVPIPE_TEST_UNKNOWN_TAG") == "1" {
+		tasks := []model.TaskDefinition{{ID: "task1", Tags: []string{"fast"}}}
+		_ = filterTasksByTags(tasks, tasks, []string{"fsat"}, nil, ui.NewRenderer(ui.UIModeBasic, false, false), false)
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestFilterTasksByTags_UnknownTagExits")
+	cmd.Env = append(os.Environ(), "DEVPIPE_TEST_UNKNOWN_TAG=1")
+	var exitErr *exec.ExitError
+	if err := cmd.Run(); !errors.As(err, &exitErr) || exitErr.ExitCode() != exitConfigError {
+		t.Fatalf("expected exit code %d for an unknown tag, got %v", exitConfigError, err)
+	}
+}
+
+func TestSplitFlagValues(t *testing.T) {
+	got := splitFlagValues([]string{"fast, frontend", "slow", ""})
+	if strings.Join(got, "|") != "fast|frontend|slow" {
+		t.Errorf("splitFlagValues() = %v", got)
+	}
+}
+
 func TestFilterTasks_InvalidOnlyExits(t *testing.T) {
 	if os.Getenv("DEVPIPE_TEST_INVALID_ONLY") == "1" {
 		tasks := []model.TaskDefinition{{ID: "task1"}}
-		// This should call os.Exit(1) inside filterTasks
-		_ = filterTasks(tasks, "does-not-exist", sliceFlag{}, false, 0, false)
+		// This should call os.Exit(exitConfigError) inside filterTasks
+		_ = filterTasks(tasks, "does-not-exist", sliceFlag{}, false, 0, ui.NewRenderer(ui.UIModeBasic, false, false), false)
 		return
 	}
 
@@ -124,8 +461,9 @@ func TestFilterTasks_InvalidOnlyExits(t *testing.T) {
 		t.Fatalf("expected non-zero exit code, got nil error")
 	}
 	if exitErr, ok := err.(*exec.ExitError); ok {
-		if exitErr.ExitCode() == 0 {
-			t.Fatalf("expected non-zero exit code, got 0")
+		// A mistyped flag is a usage error, not a task failure
+		if exitErr.ExitCode() != exitConfigError {
+			t.Fatalf("expected exit code %d, got %d", exitConfigError, exitErr.ExitCode())
 		}
 	} else {
 		t.Fatalf("expected *exec.ExitError, got %T: %v", err, err)
@@ -275,7 +613,7 @@ func TestFilterTasks(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			filtered := filterTasks(tasks, tt.only, tt.skip, tt.fast, tt.fastThreshold, false)
+			filtered := filterTasks(tasks, tt.only, tt.skip, tt.fast, tt.fastThreshold, ui.NewRenderer(ui.UIModeBasic, false, false), false)
 
 			if len(filtered) != tt.wantCount {
 				t.Errorf("filterTasks() returned %d tasks, want %d", len(filtered), tt.wantCount)
@@ -402,3 +740,500 @@ func TestGroupTasksIntoPhasesWithWait(t *testing.T) {
 		t.Errorf("Second phase should have 2 tasks, got %d", len(phases[1].Tasks))
 	}
 }
+
+func TestGroupTasksIntoPhasesCarriesMaxParallel(t *testing.T) {
+	two := 2
+	tasks := []model.TaskDefinition{
+		{ID: "compile", Wait: true},
+		{ID: "lint"},
+	}
+	phaseNames := map[string]config.PhaseInfo{
+		"wait-1": {ID: "phase-build", Name: "Build", MaxParallel: &two},
+	}
+
+	phases := groupTasksIntoPhases(tasks, phaseNames)
+
+	if phases[0].MaxParallel == nil || *phases[0].MaxParallel != 2 {
+		t.Errorf("expected first phase MaxParallel=2, got %v", phases[0].MaxParallel)
+	}
+	if phases[1].MaxParallel != nil {
+		t.Errorf("expected second phase MaxParallel=nil, got %v", *phases[1].MaxParallel)
+	}
+}
+
+func TestGroupTasksIntoPhasesBarrier(t *testing.T) {
+	tasks := []model.TaskDefinition{
+		{ID: "compile", Wait: true},
+		{ID: "lint", Wait: true, Barrier: true}, // Followed by a wait marker in the config
+		{ID: "unit", Wait: true},
+		{ID: "e2e"},
+	}
+	phaseNames := map[string]config.PhaseInfo{
+		"wait-4": {ID: "phase-deploy", Name: "Deploy", Barrier: true},
+	}
+
+	var got []bool
+	for _, phase := range groupTasksIntoPhases(tasks, phaseNames) {
+		got = append(got, phase.Barrier)
+	}
+	if want := []bool{false, false, true, true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("phase barriers = %v, want %v", got, want)
+	}
+}
+
+func TestWrittenWaitMarkerIsBarrier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	content := `[tasks.phase-checks]
+name = "Checks"
+
+[tasks.build]
+command = "make build"
+
+[tasks.phase-tests]
+name = "Tests"
+
+[tasks.unit]
+command = "make test"
+
+[tasks.wait]
+
+[tasks.deploy]
+command = "make deploy"
+dependsOn = ["build"]
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, order, _, _, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	tasks := config.MergeWithDefaults(cfg).Tasks
+	written := removeMarkers(tasks)
+	var taskDefs []model.TaskDefinition
+	for _, id := range order {
+		if id == "wait" || strings.HasPrefix(id, "wait-") {
+			markWait(taskDefs, id, written)
+			continue
+		}
+		if _, ok := tasks[id]; ok {
+			taskDefs = append(taskDefs, model.TaskDefinition{ID: id})
+		}
+	}
+
+	var barriers []bool
+	for _, phase := range groupTasksIntoPhases(taskDefs, nil) {
+		barriers = append(barriers, phase.Barrier)
+	}
+	// The phase header's marker only ends a phase; the written [tasks.wait] is a barrier
+	if want := []bool{false, false, true}; !reflect.DeepEqual(barriers, want) {
+		t.Errorf("phase barriers = %v, want %v (tasks %+v)", barriers, want, taskDefs)
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	phases := []Phase{
+		{Tasks: []model.TaskDefinition{{ID: "slow"}, {ID: "build"}}},
+		{Tasks: []model.TaskDefinition{{ID: "unit", DependsOn: []string{"build"}}, {ID: "other"}}},
+		{Tasks: []model.TaskDefinition{{ID: "deploy", DependsOn: []string{"build"}}}, Barrier: true},
+	}
+	started := func(s *schedule, id string) <-chan struct{} {
+		ch := make(chan struct{})
+		go func() {
+			s.wait(s.tasks[id].def)
+			close(ch)
+		}()
+		return ch
+	}
+	isStarted := func(ch <-chan struct{}) bool {
+		select {
+		case <-ch:
+			return true
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}
+
+	// With overlap, unit starts once build has settled, while slow is still running
+	s := newSchedule(phases, true)
+	unit, other, deploy := started(s, "unit"), started(s, "other"), started(s, "deploy")
+	s.open(0)
+	if isStarted(unit) {
+		t.Fatal("unit started before its dependency finished")
+	}
+	s.record("build", model.StatusPass)
+	s.ran("build", true)
+	if !isStarted(unit) {
+		t.Error("unit did not start once build finished")
+	}
+	if isStarted(other) || isStarted(deploy) {
+		t.Error("tasks without dependsOn, or behind a barrier, started before their phase")
+	}
+	if dep, _ := s.blockedBy(s.tasks["unit"].def); dep != "" {
+		t.Errorf("blockedBy() = %q for a passing dependency", dep)
+	}
+
+	// A failed dependency blocks its dependents, directly and through skipped tasks
+	s.record("build", model.StatusFail)
+	if dep, failed := s.blockedBy(s.tasks["unit"].def); dep != "build" || !failed {
+		t.Errorf("blockedBy() = %q, %v, want build failed", dep, failed)
+	}
+	s.block("build")
+	s.record("build", model.StatusSkipped)
+	if dep, failed := s.blockedBy(s.tasks["deploy"].def); dep != "build" || failed {
+		t.Errorf("blockedBy() = %q, %v, want build skipped", dep, failed)
+	}
+
+	// Without overlap every task waits for its own phase
+	s = newSchedule(phases, false)
+	unit = started(s, "unit")
+	s.open(0)
+	s.ran("build", true)
+	if isStarted(unit) {
+		t.Error("unit started before its phase without overlap")
+	}
+	s.open(1)
+	if !isStarted(unit) {
+		t.Error("unit did not start once its phase opened")
+	}
+
+	// Stopping keeps unopened phases from starting; release lets waiting tasks see that
+	s.stop()
+	if s.stopped(1) || !s.stopped(2) {
+		t.Errorf("stopped() = %v, %v, want only the unopened phase stopped", s.stopped(1), s.stopped(2))
+	}
+	deploy = started(s, "deploy")
+	s.release()
+	if !isStarted(deploy) {
+		t.Error("release did not let a waiting task go")
+	}
+}
+
+func TestPhaseParallelLimit(t *testing.T) {
+	zero, two, thirtyTwo := 0, 2, 32
+
+	tests := []struct {
+		name       string
+		phaseMax   *int
+		jobs       int
+		defaultMax *int
+		want       int
+	}{
+		{"built-in default", nil, -1, nil, 10},
+		{"config default", nil, -1, &thirtyTwo, 32},
+		{"phase overrides config", &two, -1, &thirtyTwo, 2},
+		{"jobs flag overrides phase", &two, 4, &thirtyTwo, 4},
+		{"jobs 0 is sequential", nil, 0, &thirtyTwo, 1},
+		{"phase 0 is sequential", &zero, -1, nil, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := phaseParallelLimit(Phase{MaxParallel: tt.phaseMax}, tt.jobs, tt.defaultMax)
+			if got != tt.want {
+				t.Errorf("phaseParallelLimit() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPhaseFailFast(t *testing.T) {
+	on, off := true, false
+
+	tests := []struct {
+		name      string
+		phaseFlag *bool
+		mode      string
+		want      bool
+	}{
+		{"off by default", nil, "off", false},
+		{"phase mode", nil, "phase", true},
+		{"task mode", nil, "task", true},
+		{"phase header opts in", &on, "off", true},
+		{"phase header opts out", &off, "phase", false},
+		{"task mode ignores opt out", &off, "task", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := phaseFailFast(Phase{FailFast: tt.phaseFlag}, tt.mode); got != tt.want {
+				t.Errorf("phaseFailFast() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunExitCode(t *testing.T) {
+	pass := model.TaskResult{Status: model.StatusPass}
+	fail := model.TaskResult{Status: model.StatusFail}
+	warn := model.TaskResult{Status: model.StatusWarn}
+
+	tests := []struct {
+		name        string
+		results     []model.TaskResult
+		interrupted bool
+		advisory    int
+		exitZero    bool
+		want        int
+	}{
+		{"all passed", []model.TaskResult{pass, pass}, false, 0, false, exitOK},
+		{"task failed", []model.TaskResult{pass, fail}, false, 0, false, exitTaskFailed},
+		{"advisory failure", []model.TaskResult{warn, pass}, false, 0, false, exitOK},
+		{"advisory exit code", []model.TaskResult{warn, pass}, false, 4, false, 4},
+		{"failure beats advisory", []model.TaskResult{warn, fail}, false, 4, false, exitTaskFailed},
+		{"interrupted", []model.TaskResult{pass, fail}, true, 0, false, exitInterrupted},
+		{"exit zero", []model.TaskResult{warn, fail}, false, 4, true, exitOK},
+		{"exit zero still reports interrupts", []model.TaskResult{fail}, true, 0, true, exitInterrupted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runExitCode(tt.results, tt.interrupted, tt.advisory, tt.exitZero); got != tt.want {
+				t.Errorf("runExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunHooks(t *testing.T) {
+	renderer := ui.NewRenderer(ui.UIModeBasic, false, false)
+	dir := t.TempDir()
+	var log bytes.Buffer
+
+	// A failing before hook stops the ones after it
+	results, err := runHooks(context.Background(), "before", []string{"echo starting db", "exit 3", "echo never"}, nil, dir, &log, renderer)
+	if err == nil || !strings.Contains(err.Error(), "before hook failed: exit 3") {
+		t.Errorf("runHooks() error = %v, want the failed hook", err)
+	}
+	if len(results) != 2 || results[0].ExitCode != 0 || results[1].ExitCode != 3 || results[1].Stage != "before" {
+		t.Errorf("results = %+v, want the first two hooks", results)
+	}
+	if !strings.Contains(log.String(), "--- before hook: echo starting db ---") || !strings.Contains(log.String(), "starting db") || strings.Contains(log.String(), "never") {
+		t.Errorf("log = %q, want the output of the hooks that ran", log.String())
+	}
+
+	// Every after hook runs, and failures are not returned
+	results, err = runHooks(context.Background(), "after", []string{"exit 1", "echo stopped"}, nil, dir, &log, renderer)
+	if err != nil || len(results) != 2 || results[0].ExitCode != 1 || results[1].ExitCode != 0 {
+		t.Errorf("runHooks() = %+v, %v, want both after hooks", results, err)
+	}
+}
+
+func TestFileConditionSkip(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "coverage.out"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "reports", "web"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		onlyIf, skipIf string
+		want           string
+	}{
+		{"", "", ""},
+		{"coverage.out", "", ""},
+		{"coverage.xml", "", "onlyIf: coverage.xml not found"},
+		{"reports/**/web", "", ""},
+		{"*.out", "", ""},
+		{"", "*.out", "skipIf: *.out exists"},
+		{"", filepath.Join(dir, "reports"), "skipIf: " + filepath.Join(dir, "reports") + " exists"},
+		{"", "dist/.skip", ""},
+	}
+	for _, tt := range tests {
+		st := model.TaskDefinition{ID: "upload", Workdir: dir, OnlyIf: tt.onlyIf, SkipIf: tt.skipIf}
+		if got := fileConditionSkip(st); got != tt.want {
+			t.Errorf("fileConditionSkip(onlyIf %q, skipIf %q) = %q, want %q", tt.onlyIf, tt.skipIf, got, tt.want)
+		}
+	}
+}
+
+func TestSortTaskStats(t *testing.T) {
+	stats := sortTaskStats(map[string]dashboard.TaskStats{
+		"lint":  {ID: "lint", FailCount: 0},
+		"test":  {ID: "test", FailCount: 3},
+		"build": {ID: "build", FailCount: 1},
+		"e2e":   {ID: "e2e", FailCount: 3},
+	})
+
+	var ids []string
+	for _, s := range stats {
+		ids = append(ids, s.ID)
+	}
+	if got, want := strings.Join(ids, ","), "e2e,test,build,lint"; got != want {
+		t.Errorf("sortTaskStats() order = %s, want %s", got, want)
+	}
+}
+
+func TestSelectResumeTasks(t *testing.T) {
+	tasks := []model.TaskDefinition{
+		{ID: "lint", PhaseID: "phase-check"},
+		{ID: "fmt", PhaseID: "phase-check"},
+		{ID: "build", PhaseID: "phase-build"},
+		{ID: "unit", PhaseID: "phase-test"},
+	}
+
+	tests := []struct {
+		name       string
+		prev       []model.TaskResult
+		wantRerun  string
+		wantCached string
+	}{
+		{
+			name: "failure in the last phase",
+			prev: []model.TaskResult{
+				{ID: "lint", Status: model.StatusPass},
+				{ID: "fmt", Status: model.StatusPass},
+				{ID: "build", Status: model.StatusPass},
+				{ID: "unit", Status: model.StatusFail},
+			},
+			wantRerun:  "unit",
+			wantCached: "lint,fmt,build",
+		},
+		{
+			name: "failure reruns later phases",
+			prev: []model.TaskResult{
+				{ID: "lint", Status: model.StatusPass},
+				{ID: "fmt", Status: model.StatusFail},
+				{ID: "build", Status: model.StatusPass},
+				{ID: "unit", Status: model.StatusPass},
+			},
+			wantRerun:  "fmt,build,unit",
+			wantCached: "lint",
+		},
+		{
+			name: "skipped and new tasks run, removed tasks are ignored",
+			prev: []model.TaskResult{
+				{ID: "lint", Status: model.StatusPass},
+				{ID: "fmt", Status: model.StatusPass},
+				{ID: "unit", Status: model.StatusSkipped},
+				{ID: "removed", Status: model.StatusFail},
+			},
+			wantRerun:  "build,unit",
+			wantCached: "lint,fmt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rerun, cached := selectResumeTasks(tasks, model.RunRecord{RunID: "run-1", Tasks: tt.prev})
+
+			var rerunIDs, cachedIDs []string
+			for _, task := range rerun {
+				rerunIDs = append(rerunIDs, task.ID)
+			}
+			for _, r := range cached {
+				cachedIDs = append(cachedIDs, r.ID)
+				if r.CachedFrom != "run-1" {
+					t.Errorf("cached %s CachedFrom = %q, want run-1", r.ID, r.CachedFrom)
+				}
+			}
+			if got := strings.Join(rerunIDs, ","); got != tt.wantRerun {
+				t.Errorf("rerun = %s, want %s", got, tt.wantRerun)
+			}
+			if got := strings.Join(cachedIDs, ","); got != tt.wantCached {
+				t.Errorf("cached = %s, want %s", got, tt.wantCached)
+			}
+		})
+	}
+}
+
+func TestOrderResultsByTasks(t *testing.T) {
+	tasks := []model.TaskDefinition{{ID: "lint"}, {ID: "build"}, {ID: "unit"}}
+	results := []model.TaskResult{{ID: "unit"}, {ID: "extra"}, {ID: "lint"}, {ID: "build"}}
+
+	var got []string
+	for _, r := range orderResultsByTasks(results, tasks) {
+		got = append(got, r.ID)
+	}
+	if strings.Join(got, ",") != "lint,build,unit,extra" {
+		t.Errorf("orderResultsByTasks() = %v, want [lint build unit extra]", got)
+	}
+}
+
+func TestFindConfig(t *testing.T) {
+	if got := findConfig("custom.toml", true); got != "custom.toml" {
+		t.Errorf("findConfig(custom.toml) = %q, want the given path", got)
+	}
+	if got := findConfig("", false); got != "" {
+		t.Errorf("findConfig without discovery = %q, want empty", got)
+	}
+
+	// The repo's own config.toml is in the working directory, so it keeps its short name
+	if got := findConfig("", true); got != "config.toml" {
+		t.Errorf("findConfig in the repo root = %q, want config.toml", got)
+	}
+
+	// DEVPIPE_CONFIG is used when --config is not given, even without discovery
+	t.Setenv(config.ConfigEnvVar, "/etc/devpipe/ci.toml")
+	if got := findConfig("", false); got != "/etc/devpipe/ci.toml" {
+		t.Errorf("findConfig with %s = %q, want /etc/devpipe/ci.toml", config.ConfigEnvVar, got)
+	}
+	if got := findConfig("custom.toml", true); got != "custom.toml" {
+		t.Errorf("findConfig(custom.toml) with %s = %q, want the flag to win", config.ConfigEnvVar, got)
+	}
+}
+
+func TestAddTaskCollisions(t *testing.T) {
+	entries := []config.FileValidationResult{
+		{File: "backend.toml", Valid: true},
+		{File: "frontend.toml", Valid: true},
+		{File: "docs.toml", Valid: true},
+	}
+	collisions := []config.TaskCollision{{TaskID: "test", Files: []string{"backend.toml", "frontend.toml"}}}
+
+	addTaskCollisions(entries, collisions, false)
+	if len(entries[0].Warnings) != 1 || entries[0].Warnings[0].Message != "Task is also defined in frontend.toml" || !entries[0].Valid {
+		t.Errorf("backend.toml = %+v, want a warning naming frontend.toml", entries[0])
+	}
+	if len(entries[2].Warnings) != 0 {
+		t.Errorf("docs.toml = %+v, want no warnings", entries[2])
+	}
+
+	addTaskCollisions(entries, collisions, true)
+	if len(entries[1].Errors) != 1 || entries[1].Errors[0].Field != "tasks.test" || entries[1].Valid {
+		t.Errorf("frontend.toml under --strict = %+v, want an error for tasks.test", entries[1])
+	}
+}
+
+func TestValidateWorkdirsOfFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "web"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.toml")
+	cfg := "[defaults]\nprojectRoot = \".\"\n\n[tasks.web]\ncommand = \"npm test\"\nworkdir = \"web\"\n\n[tasks.api]\ncommand = \"go test\"\nworkdir = \"api\"\n"
+	if err := os.WriteFile(path, []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result := &config.ValidationResult{Valid: true}
+	validateWorkdirs(path, result)
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Field != "tasks.api.workdir" {
+		t.Errorf("validateWorkdirs() = %v, want one error for tasks.api.workdir", result.Errors)
+	}
+}
+
+func TestSetConfigSources(t *testing.T) {
+	tests := []struct {
+		name, flagConfig, env, configPath, flagProfile string
+		wantSource, wantProfileSource                  string
+	}{
+		{"flag", "ci.toml", "env.toml", "ci.toml", "ci", "cli-flag", "cli-flag"},
+		{"env", "", "env.toml", "env.toml", "", "env", "env"},
+		{"discovered", "", "", "/src/config.toml", "", "discovered", "env"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(config.ConfigEnvVar, tt.env)
+			effective := &model.EffectiveConfig{Profile: "ci"}
+			setConfigSources(effective, tt.flagConfig, tt.configPath, tt.flagProfile)
+			if effective.ConfigPath != tt.configPath || effective.ConfigSource != tt.wantSource || effective.ProfileSource != tt.wantProfileSource {
+				t.Errorf("got config %q from %q, profile from %q; want %q from %q, profile from %q",
+					effective.ConfigPath, effective.ConfigSource, effective.ProfileSource, tt.configPath, tt.wantSource, tt.wantProfileSource)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package main
+
+import "os/exec"
+
+// setProcessGroup is a no-op on Windows; cancelling the context kills the shell process
+func setProcessGroup(_ *exec.Cmd) {}
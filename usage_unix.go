@@ -0,0 +1,32 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+
+	"github.com/drew/devpipe/internal/model"
+)
+
+// processUsage reads the peak memory and CPU time of an exited process from its rusage, or
+// returns nil when there is none
+func processUsage(state *os.ProcessState) *model.Usage {
+	if state == nil {
+		return nil
+	}
+	ru, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok || ru == nil {
+		return nil
+	}
+	maxRSS := int64(ru.Maxrss)
+	if runtime.GOOS != "darwin" && runtime.GOOS != "ios" {
+		maxRSS *= 1024 // Linux and the BSDs report kilobytes, macOS bytes
+	}
+	return &model.Usage{
+		MaxRSSBytes: maxRSS,
+		UserCPUMs:   state.UserTime().Milliseconds(),
+		SystemCPUMs: state.SystemTime().Milliseconds(),
+	}
+}
@@ -0,0 +1,14 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+
+	"github.com/drew/devpipe/internal/model"
+)
+
+// processUsage returns nil: Windows does not report the peak memory of an exited process
+func processUsage(*os.ProcessState) *model.Usage {
+	return nil
+}